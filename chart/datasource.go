@@ -0,0 +1,54 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package chart
+
+import (
+	"fmt"
+
+	"github.com/unidoc/unioffice/v2"
+	crt "github.com/unidoc/unioffice/v2/schema/soo/dml/chart"
+)
+
+// X returns the inner wrapped XML type.
+func (c CategoryAxisDataSource) X() *crt.CT_AxDataSource { return c._ge }
+
+// SetLabelReferenceWithValues sets the category labels to a string reference
+// and caches the referenced values so that the chart can be displayed without
+// the underlying workbook.
+func (c CategoryAxisDataSource) SetLabelReferenceWithValues(ref string, v []string) {
+	c.SetLabelReference(ref)
+	cache := crt.NewCT_StrData()
+	cache.PtCount = crt.NewCT_UnsignedInt()
+	cache.PtCount.ValAttr = uint32(len(v))
+	for i, s := range v {
+		cache.Pt = append(cache.Pt, &crt.CT_StrVal{IdxAttr: uint32(i), V: s})
+	}
+	c._ge.AxDataSourceChoice.StrRef.StrCache = cache
+}
+
+// X returns the inner wrapped XML type.
+func (n NumberDataSource) X() *crt.CT_NumDataSource { return n._eeca }
+
+// SetReferenceWithValues sets the data source to a number reference and
+// caches the referenced values so that the chart can be displayed without the
+// underlying workbook.
+func (n NumberDataSource) SetReferenceWithValues(ref string, v []float64) {
+	n.ensureChoice()
+	n._eeca.NumDataSourceChoice.NumLit = nil
+	n.SetReference(ref)
+	cache := crt.NewCT_NumData()
+	cache.FormatCode = unioffice.String("General")
+	cache.PtCount = crt.NewCT_UnsignedInt()
+	cache.PtCount.ValAttr = uint32(len(v))
+	for i, f := range v {
+		cache.Pt = append(cache.Pt, &crt.CT_NumVal{IdxAttr: uint32(i), V: fmt.Sprintf("%g", f)})
+	}
+	n._eeca.NumDataSourceChoice.NumRef.NumCache = cache
+}
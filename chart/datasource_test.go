@@ -0,0 +1,70 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package chart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	crt "github.com/unidoc/unioffice/v2/schema/soo/dml/chart"
+)
+
+func TestReferenceWithValues(t *testing.T) {
+	c := MakeChart(crt.NewChartSpace())
+	s := c.AddBarChart().AddSeries()
+	s.CategoryAxis().SetLabelReferenceWithValues("Sheet1!$A$2:$A$3", []string{"a", "b"})
+	// a reference replaces literal values
+	s.Values().SetValues([]float64{9})
+	s.Values().SetReferenceWithValues("Sheet1!$B$2:$B$3", []float64{1, 2.5})
+
+	check := func(cat *crt.CT_AxDataSource, val *crt.CT_NumDataSource) {
+		t.Helper()
+		sr := cat.AxDataSourceChoice.StrRef
+		if sr == nil || sr.F != "Sheet1!$A$2:$A$3" || sr.StrCache == nil || sr.StrCache.PtCount.ValAttr != 2 {
+			t.Fatalf("category reference = %+v", sr)
+		}
+		labels := []string{}
+		for _, pt := range sr.StrCache.Pt {
+			labels = append(labels, pt.V)
+		}
+		if !reflect.DeepEqual(labels, []string{"a", "b"}) {
+			t.Errorf("cached labels = %q", labels)
+		}
+		if val.NumDataSourceChoice.NumLit != nil {
+			t.Error("values kept their literal")
+		}
+		nr := val.NumDataSourceChoice.NumRef
+		if nr == nil || nr.F != "Sheet1!$B$2:$B$3" || nr.NumCache == nil || nr.NumCache.PtCount.ValAttr != 2 {
+			t.Fatalf("value reference = %+v", nr)
+		}
+		values := []string{}
+		for _, pt := range nr.NumCache.Pt {
+			values = append(values, pt.V)
+		}
+		if !reflect.DeepEqual(values, []string{"1", "2.5"}) || *nr.NumCache.FormatCode != "General" {
+			t.Errorf("cached values = %q", values)
+		}
+	}
+	check(s.CategoryAxis().X(), s.Values().X())
+
+	// the caches are written and read with the chart
+	buf := bytes.Buffer{}
+	if err := xml.NewEncoder(&buf).Encode(c.X()); err != nil {
+		t.Fatal(err)
+	}
+	read := crt.NewChartSpace()
+	if err := xml.Unmarshal(buf.Bytes(), read); err != nil {
+		t.Fatal(err)
+	}
+	ser := read.Chart.PlotArea.PlotAreaChoice[0].BarChart.Ser[0]
+	check(ser.Cat, ser.Val)
+}
@@ -0,0 +1,399 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package common
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/internal/colorutils"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+)
+
+// Theme font scripts passed to Theme.MajorFont and Theme.MinorFont. Other
+// scripts are selected by their ISO 15924 code, e.g. "Jpan" or "Arab".
+const (
+	ThemeFontLatin         = ""
+	ThemeFontEastAsian     = "ea"
+	ThemeFontComplexScript = "cs"
+)
+
+// MakeTheme wraps a theme, such as one returned by the Themes method of a
+// document, workbook or presentation.
+func MakeTheme(x *dml.Theme) Theme { return Theme{x} }
+
+func (t Theme) elements() *dml.CT_BaseStyles {
+	if t.X().ThemeElements == nil {
+		t.X().ThemeElements = dml.NewCT_BaseStyles()
+	}
+	return t.X().ThemeElements
+}
+
+// Name returns the name of the theme.
+func (t Theme) Name() string {
+	if t.X().NameAttr != nil {
+		return *t.X().NameAttr
+	}
+	return ""
+}
+
+// SetName sets the name of the theme.
+func (t Theme) SetName(name string) { t.X().NameAttr = &name }
+
+func (t Theme) colorScheme() *dml.CT_ColorScheme {
+	te := t.elements()
+	if te.ClrScheme == nil {
+		te.ClrScheme = dml.NewCT_ColorScheme()
+	}
+	return te.ClrScheme
+}
+
+// ColorSchemeName returns the name of the color scheme.
+func (t Theme) ColorSchemeName() string { return t.colorScheme().NameAttr }
+
+// SetColorSchemeName sets the name of the color scheme.
+func (t Theme) SetColorSchemeName(name string) { t.colorScheme().NameAttr = name }
+
+// schemeSlot returns the color scheme entry of a scheme color, mapping the
+// text and background colors to the dark and light colors as the default
+// color mapping does.
+func (t Theme) schemeSlot(v dml.ST_SchemeColorVal) **dml.CT_Color {
+	cs := t.colorScheme()
+	switch v {
+	case dml.ST_SchemeColorValDk1, dml.ST_SchemeColorValTx1:
+		return &cs.Dk1
+	case dml.ST_SchemeColorValLt1, dml.ST_SchemeColorValBg1:
+		return &cs.Lt1
+	case dml.ST_SchemeColorValDk2, dml.ST_SchemeColorValTx2:
+		return &cs.Dk2
+	case dml.ST_SchemeColorValLt2, dml.ST_SchemeColorValBg2:
+		return &cs.Lt2
+	case dml.ST_SchemeColorValAccent1:
+		return &cs.Accent1
+	case dml.ST_SchemeColorValAccent2:
+		return &cs.Accent2
+	case dml.ST_SchemeColorValAccent3:
+		return &cs.Accent3
+	case dml.ST_SchemeColorValAccent4:
+		return &cs.Accent4
+	case dml.ST_SchemeColorValAccent5:
+		return &cs.Accent5
+	case dml.ST_SchemeColorValAccent6:
+		return &cs.Accent6
+	case dml.ST_SchemeColorValHlink:
+		return &cs.Hlink
+	case dml.ST_SchemeColorValFolHlink:
+		return &cs.FolHlink
+	}
+	return nil
+}
+
+// Color returns the RRGGBB value of a color of the color scheme, e.g.
+// dml.ST_SchemeColorValAccent1. The text and background colors tx1, bg1, tx2
+// and bg2 are the dk1, lt1, dk2 and lt2 colors. System colors resolve to
+// their last computed value.
+func (t Theme) Color(v dml.ST_SchemeColorVal) string {
+	slot := t.schemeSlot(v)
+	if slot == nil || *slot == nil {
+		return ""
+	}
+	c := *slot
+	switch {
+	case c.SrgbClr != nil:
+		return strings.ToUpper(c.SrgbClr.ValAttr)
+	case c.SysClr != nil && c.SysClr.LastClrAttr != nil:
+		return strings.ToUpper(*c.SysClr.LastClrAttr)
+	}
+	return ""
+}
+
+// SetColor sets a color of the color scheme to an RRGGBB value.
+func (t Theme) SetColor(v dml.ST_SchemeColorVal, rgb string) {
+	slot := t.schemeSlot(v)
+	if slot == nil {
+		return
+	}
+	c := dml.NewCT_Color()
+	c.SrgbClr = dml.NewCT_SRgbColor()
+	c.SrgbClr.ValAttr = strings.ToUpper(strings.TrimPrefix(rgb, "#"))
+	*slot = c
+}
+
+// schemeColorNames maps the scheme color names of DrawingML and the theme
+// color names of WordprocessingML to scheme colors.
+var schemeColorNames = map[string]dml.ST_SchemeColorVal{
+	"dk1": dml.ST_SchemeColorValDk1, "dark1": dml.ST_SchemeColorValDk1,
+	"lt1": dml.ST_SchemeColorValLt1, "light1": dml.ST_SchemeColorValLt1,
+	"dk2": dml.ST_SchemeColorValDk2, "dark2": dml.ST_SchemeColorValDk2,
+	"lt2": dml.ST_SchemeColorValLt2, "light2": dml.ST_SchemeColorValLt2,
+	"tx1": dml.ST_SchemeColorValTx1, "text1": dml.ST_SchemeColorValTx1,
+	"bg1": dml.ST_SchemeColorValBg1, "background1": dml.ST_SchemeColorValBg1,
+	"tx2": dml.ST_SchemeColorValTx2, "text2": dml.ST_SchemeColorValTx2,
+	"bg2": dml.ST_SchemeColorValBg2, "background2": dml.ST_SchemeColorValBg2,
+	"accent1": dml.ST_SchemeColorValAccent1, "accent2": dml.ST_SchemeColorValAccent2,
+	"accent3": dml.ST_SchemeColorValAccent3, "accent4": dml.ST_SchemeColorValAccent4,
+	"accent5": dml.ST_SchemeColorValAccent5, "accent6": dml.ST_SchemeColorValAccent6,
+	"hlink": dml.ST_SchemeColorValHlink, "hyperlink": dml.ST_SchemeColorValHlink,
+	"folhlink": dml.ST_SchemeColorValFolHlink, "followedhyperlink": dml.ST_SchemeColorValFolHlink,
+}
+
+// ColorByName returns the RRGGBB value of a color of the color scheme by its
+// DrawingML name, e.g. "accent1" or "tx1", or its WordprocessingML theme color
+// name, e.g. "dark1" or "hyperlink".
+func (t Theme) ColorByName(name string) string {
+	v, ok := schemeColorNames[strings.ToLower(name)]
+	if !ok {
+		return ""
+	}
+	return t.Color(v)
+}
+
+// ResolveColor returns the RRGGBB value of a DrawingML color with its tint,
+// shade and luminance transforms applied, resolving scheme colors against the
+// theme. It returns an empty string for colors it can't resolve.
+func (t Theme) ResolveColor(c *dml.CT_Color) string {
+	if c == nil {
+		return ""
+	}
+	switch {
+	case c.SrgbClr != nil:
+		return strings.ToUpper(colorutils.AdjustColor(c.SrgbClr.ValAttr, c.SrgbClr.EG_ColorTransform))
+	case c.SysClr != nil && c.SysClr.LastClrAttr != nil:
+		return strings.ToUpper(colorutils.AdjustColor(*c.SysClr.LastClrAttr, c.SysClr.EG_ColorTransform))
+	case c.SchemeClr != nil:
+		return t.ResolveSchemeColor(c.SchemeClr)
+	}
+	return ""
+}
+
+// ResolveSchemeColor returns the RRGGBB value of a scheme color with its tint,
+// shade and luminance transforms applied.
+func (t Theme) ResolveSchemeColor(c *dml.CT_SchemeColor) string {
+	if c == nil {
+		return ""
+	}
+	rgb := t.Color(c.ValAttr)
+	if rgb == "" {
+		return ""
+	}
+	return strings.ToUpper(colorutils.AdjustColor(rgb, c.EG_ColorTransform))
+}
+
+// spreadsheetThemeColors lists the scheme colors in the order of the theme
+// color indexes of SpreadsheetML, where the light colors come first.
+var spreadsheetThemeColors = []dml.ST_SchemeColorVal{
+	dml.ST_SchemeColorValLt1, dml.ST_SchemeColorValDk1,
+	dml.ST_SchemeColorValLt2, dml.ST_SchemeColorValDk2,
+	dml.ST_SchemeColorValAccent1, dml.ST_SchemeColorValAccent2,
+	dml.ST_SchemeColorValAccent3, dml.ST_SchemeColorValAccent4,
+	dml.ST_SchemeColorValAccent5, dml.ST_SchemeColorValAccent6,
+	dml.ST_SchemeColorValHlink, dml.ST_SchemeColorValFolHlink,
+}
+
+// ResolveIndex returns the RRGGBB value of a SpreadsheetML theme color, given
+// by its theme index and tint from -1 (darkest) to 1 (lightest).
+func (t Theme) ResolveIndex(idx int, tint float64) string {
+	if idx < 0 || idx >= len(spreadsheetThemeColors) {
+		return ""
+	}
+	rgb := t.Color(spreadsheetThemeColors[idx])
+	if rgb == "" || tint == 0 {
+		return rgb
+	}
+	return strings.ToUpper(colorutils.AdjustColorByTint(rgb, tint))
+}
+
+// ResolveWordColor returns the RRGGBB value of a WordprocessingML theme color,
+// given by the themeColor, themeTint and themeShade attributes. The tint and
+// shade are hex bytes as stored in the attributes, an empty string leaving the
+// color unchanged.
+func (t Theme) ResolveWordColor(themeColor, themeTint, themeShade string) string {
+	rgb := t.ColorByName(themeColor)
+	if rgb == "" {
+		return ""
+	}
+	if v, err := strconv.ParseUint(themeTint, 16, 8); err == nil {
+		rgb = colorutils.AdjustColorByTint(rgb, 1-float64(v)/255)
+	}
+	if v, err := strconv.ParseUint(themeShade, 16, 8); err == nil {
+		rgb = colorutils.AdjustColorByShade(rgb, float64(v)/255)
+	}
+	return strings.ToUpper(rgb)
+}
+
+func (t Theme) fontScheme() *dml.CT_FontScheme {
+	te := t.elements()
+	if te.FontScheme == nil {
+		te.FontScheme = dml.NewCT_FontScheme()
+	}
+	return te.FontScheme
+}
+
+// FontSchemeName returns the name of the font scheme.
+func (t Theme) FontSchemeName() string { return t.fontScheme().NameAttr }
+
+// SetFontSchemeName sets the name of the font scheme.
+func (t Theme) SetFontSchemeName(name string) { t.fontScheme().NameAttr = name }
+
+// MajorFont returns the heading font of a script, see ThemeFontLatin.
+func (t Theme) MajorFont(script string) string {
+	return collectionFont(t.fontScheme().MajorFont, script)
+}
+
+// SetMajorFont sets the heading font of a script, see ThemeFontLatin.
+func (t Theme) SetMajorFont(script, typeface string) {
+	fs := t.fontScheme()
+	if fs.MajorFont == nil {
+		fs.MajorFont = dml.NewCT_FontCollection()
+	}
+	setCollectionFont(fs.MajorFont, script, typeface)
+}
+
+// MinorFont returns the body font of a script, see ThemeFontLatin.
+func (t Theme) MinorFont(script string) string {
+	return collectionFont(t.fontScheme().MinorFont, script)
+}
+
+// SetMinorFont sets the body font of a script, see ThemeFontLatin.
+func (t Theme) SetMinorFont(script, typeface string) {
+	fs := t.fontScheme()
+	if fs.MinorFont == nil {
+		fs.MinorFont = dml.NewCT_FontCollection()
+	}
+	setCollectionFont(fs.MinorFont, script, typeface)
+}
+
+func collectionFont(fc *dml.CT_FontCollection, script string) string {
+	if fc == nil {
+		return ""
+	}
+	var tf *dml.CT_TextFont
+	switch script {
+	case ThemeFontLatin, "latin":
+		tf = fc.Latin
+	case ThemeFontEastAsian:
+		tf = fc.Ea
+	case ThemeFontComplexScript:
+		tf = fc.Cs
+	default:
+		for _, f := range fc.Font {
+			if f.ScriptAttr == script {
+				return f.TypefaceAttr
+			}
+		}
+	}
+	if tf == nil {
+		return ""
+	}
+	return tf.TypefaceAttr
+}
+
+func setCollectionFont(fc *dml.CT_FontCollection, script, typeface string) {
+	textFont := func(tf **dml.CT_TextFont) {
+		if *tf == nil {
+			*tf = dml.NewCT_TextFont()
+		}
+		(*tf).TypefaceAttr = typeface
+	}
+	switch script {
+	case ThemeFontLatin, "latin":
+		textFont(&fc.Latin)
+	case ThemeFontEastAsian:
+		textFont(&fc.Ea)
+	case ThemeFontComplexScript:
+		textFont(&fc.Cs)
+	default:
+		for i, f := range fc.Font {
+			if f.ScriptAttr == script {
+				if typeface == "" {
+					fc.Font = append(fc.Font[:i], fc.Font[i+1:]...)
+				} else {
+					f.TypefaceAttr = typeface
+				}
+				return
+			}
+		}
+		if typeface != "" {
+			fc.Font = append(fc.Font, &dml.CT_SupplementalFont{ScriptAttr: script, TypefaceAttr: typeface})
+		}
+	}
+}
+
+// FormatScheme returns the format scheme of the theme, holding the fill,
+// line, effect and background styles.
+func (t Theme) FormatScheme() *dml.CT_StyleMatrix {
+	te := t.elements()
+	if te.FmtScheme == nil {
+		te.FmtScheme = dml.NewCT_StyleMatrix()
+	}
+	return te.FmtScheme
+}
+
+// FormatSchemeName returns the name of the format scheme.
+func (t Theme) FormatSchemeName() string {
+	if n := t.FormatScheme().NameAttr; n != nil {
+		return *n
+	}
+	return ""
+}
+
+// SetFormatSchemeName sets the name of the format scheme.
+func (t Theme) SetFormatSchemeName(name string) { t.FormatScheme().NameAttr = &name }
+
+// Apply replaces the theme with a copy of another theme, e.g. to rebrand a
+// document with the theme of a template.
+func (t Theme) Apply(src Theme) error {
+	cp, err := copyTheme(src.X())
+	if err != nil {
+		return err
+	}
+	*t.X() = *cp
+	return nil
+}
+
+// ApplyColorScheme replaces the color scheme with a copy of the color scheme
+// of another theme.
+func (t Theme) ApplyColorScheme(src Theme) error {
+	cp, err := copyTheme(src.X())
+	if err != nil {
+		return err
+	}
+	t.elements().ClrScheme = cp.ThemeElements.ClrScheme
+	return nil
+}
+
+// ApplyFontScheme replaces the font scheme with a copy of the font scheme of
+// another theme.
+func (t Theme) ApplyFontScheme(src Theme) error {
+	cp, err := copyTheme(src.X())
+	if err != nil {
+		return err
+	}
+	t.elements().FontScheme = cp.ThemeElements.FontScheme
+	return nil
+}
+
+// copyTheme returns a deep copy of a theme.
+func copyTheme(th *dml.Theme) (*dml.Theme, error) {
+	buf := bytes.Buffer{}
+	if err := xml.NewEncoder(&buf).Encode(th); err != nil {
+		return nil, err
+	}
+	cp := dml.NewTheme()
+	if err := xml.NewDecoder(&buf).Decode(cp); err != nil {
+		return nil, err
+	}
+	if cp.ThemeElements == nil {
+		cp.ThemeElements = dml.NewCT_BaseStyles()
+	}
+	return cp, nil
+}
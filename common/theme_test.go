@@ -0,0 +1,284 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package common
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+)
+
+// newTheme returns a theme with the colors and fonts of the Office theme.
+func newTheme() Theme {
+	t := MakeTheme(dml.NewTheme())
+	t.SetName("Office Theme")
+	t.SetColorSchemeName("Office")
+	colors := map[dml.ST_SchemeColorVal]string{
+		dml.ST_SchemeColorValDk1: "000000", dml.ST_SchemeColorValLt1: "FFFFFF",
+		dml.ST_SchemeColorValDk2: "44546A", dml.ST_SchemeColorValLt2: "E7E6E6",
+		dml.ST_SchemeColorValAccent1: "4472C4", dml.ST_SchemeColorValAccent2: "ED7D31",
+		dml.ST_SchemeColorValAccent3: "A5A5A5", dml.ST_SchemeColorValAccent4: "FFC000",
+		dml.ST_SchemeColorValAccent5: "5B9BD5", dml.ST_SchemeColorValAccent6: "70AD47",
+		dml.ST_SchemeColorValHlink: "0563C1", dml.ST_SchemeColorValFolHlink: "954F72",
+	}
+	for v, rgb := range colors {
+		t.SetColor(v, rgb)
+	}
+	t.SetFontSchemeName("Office")
+	t.SetMajorFont(ThemeFontLatin, "Calibri Light")
+	t.SetMinorFont(ThemeFontLatin, "Calibri")
+	t.SetMinorFont("Jpan", "游明朝")
+	t.SetFormatSchemeName("Office")
+	return t
+}
+
+// roundTripTheme encodes a theme and decodes it again.
+func roundTripTheme(t *testing.T, th Theme) Theme {
+	t.Helper()
+	buf := bytes.Buffer{}
+	if err := xml.NewEncoder(&buf).Encode(th.X()); err != nil {
+		t.Fatalf("encoding: %s", err)
+	}
+	read := dml.NewTheme()
+	if err := xml.NewDecoder(&buf).Decode(read); err != nil {
+		t.Fatalf("decoding: %s", err)
+	}
+	return MakeTheme(read)
+}
+
+func TestThemeColors(t *testing.T) {
+	th := newTheme()
+	th.SetColor(dml.ST_SchemeColorValAccent2, "#c00000")
+	for _, th := range []Theme{th, roundTripTheme(t, th)} {
+		if th.Name() != "Office Theme" || th.ColorSchemeName() != "Office" {
+			t.Errorf("names = %q %q", th.Name(), th.ColorSchemeName())
+		}
+		tests := []struct {
+			v    dml.ST_SchemeColorVal
+			want string
+		}{
+			{dml.ST_SchemeColorValDk1, "000000"},
+			{dml.ST_SchemeColorValTx1, "000000"},
+			{dml.ST_SchemeColorValBg1, "FFFFFF"},
+			{dml.ST_SchemeColorValTx2, "44546A"},
+			{dml.ST_SchemeColorValBg2, "E7E6E6"},
+			{dml.ST_SchemeColorValAccent2, "C00000"},
+			{dml.ST_SchemeColorValFolHlink, "954F72"},
+			{dml.ST_SchemeColorValPhClr, ""},
+		}
+		for _, tc := range tests {
+			if got := th.Color(tc.v); got != tc.want {
+				t.Errorf("color %s = %q, want %q", tc.v, got, tc.want)
+			}
+		}
+		names := map[string]string{"accent1": "4472C4", "Text1": "000000", "background2": "E7E6E6", "hyperlink": "0563C1", "FollowedHyperlink": "954F72", "accent7": ""}
+		for name, want := range names {
+			if got := th.ColorByName(name); got != want {
+				t.Errorf("color %s = %q, want %q", name, got, want)
+			}
+		}
+	}
+
+	// system colors resolve to their last computed value
+	sys := dml.NewCT_Color()
+	sys.SysClr = dml.NewCT_SystemColor()
+	sys.SysClr.ValAttr = dml.ST_SystemColorValWindowText
+	last := "1f1f1f"
+	sys.SysClr.LastClrAttr = &last
+	th.X().ThemeElements.ClrScheme.Dk1 = sys
+	if got := th.Color(dml.ST_SchemeColorValTx1); got != "1F1F1F" {
+		t.Errorf("system color = %q, want 1F1F1F", got)
+	}
+	if got := MakeTheme(dml.NewTheme()).Color(dml.ST_SchemeColorValAccent1); got != "" {
+		t.Errorf("color of empty theme = %q", got)
+	}
+}
+
+func TestThemeResolveColor(t *testing.T) {
+	th := newTheme()
+	lumMod := func(v int32) []*dml.EG_ColorTransform {
+		ct := dml.NewEG_ColorTransform()
+		ct.ColorTransformChoice.LumMod = &dml.CT_Percentage{ValAttr: dml.ST_Percentage{ST_PercentageDecimal: &v}}
+		return []*dml.EG_ColorTransform{ct}
+	}
+
+	scheme := dml.NewCT_Color()
+	scheme.SchemeClr = dml.NewCT_SchemeColor()
+	scheme.SchemeClr.ValAttr = dml.ST_SchemeColorValAccent1
+	scheme.SchemeClr.EG_ColorTransform = lumMod(75000)
+	rgb := dml.NewCT_Color()
+	rgb.SrgbClr = dml.NewCT_SRgbColor()
+	rgb.SrgbClr.ValAttr = "4472c4"
+	rgb.SrgbClr.EG_ColorTransform = lumMod(75000)
+	unknown := dml.NewCT_Color()
+	unknown.SchemeClr = dml.NewCT_SchemeColor()
+	unknown.SchemeClr.ValAttr = dml.ST_SchemeColorValPhClr
+
+	tests := []struct {
+		c    *dml.CT_Color
+		want string
+	}{
+		{scheme, "2F5496"},
+		{rgb, "2F5496"},
+		{unknown, ""},
+		{dml.NewCT_Color(), ""},
+		{nil, ""},
+	}
+	for i, tc := range tests {
+		if got := th.ResolveColor(tc.c); got != tc.want {
+			t.Errorf("color %d = %q, want %q", i, got, tc.want)
+		}
+	}
+	if got := th.ResolveSchemeColor(scheme.SchemeClr); got != "2F5496" {
+		t.Errorf("scheme color = %q, want 2F5496", got)
+	}
+}
+
+func TestThemeResolveIndex(t *testing.T) {
+	th := newTheme()
+	tests := []struct {
+		idx  int
+		tint float64
+		want string
+	}{
+		{0, 0, "FFFFFF"},
+		{1, 0, "000000"},
+		{3, 0, "44546A"},
+		{4, 0, "4472C4"},
+		{1, 0.5, "7F7F7F"},
+		{0, -0.5, "7F7F7F"},
+		{4, -0.25, "335593"},
+		{11, 0, "954F72"},
+		{12, 0, ""},
+		{-1, 0, ""},
+	}
+	for _, tc := range tests {
+		if got := th.ResolveIndex(tc.idx, tc.tint); got != tc.want {
+			t.Errorf("theme color %d with tint %g = %q, want %q", tc.idx, tc.tint, got, tc.want)
+		}
+	}
+}
+
+func TestThemeResolveWordColor(t *testing.T) {
+	th := newTheme()
+	tests := []struct {
+		color, tint, shade string
+		want               string
+	}{
+		{"accent1", "", "", "4472C4"},
+		{"text1", "", "", "000000"},
+		{"background1", "", "BF", "BFBFBF"},
+		{"text1", "80", "", "7F7F7F"},
+		{"accent1", "", "80", "223962"},
+		{"accent1", "zz", "", "4472C4"},
+		{"none", "", "", ""},
+	}
+	for _, tc := range tests {
+		if got := th.ResolveWordColor(tc.color, tc.tint, tc.shade); got != tc.want {
+			t.Errorf("%s tint %q shade %q = %q, want %q", tc.color, tc.tint, tc.shade, got, tc.want)
+		}
+	}
+}
+
+func TestThemeFonts(t *testing.T) {
+	th := newTheme()
+	th.SetMajorFont(ThemeFontEastAsian, "MS Gothic")
+	th.SetMajorFont(ThemeFontComplexScript, "Times New Roman")
+	th.SetMinorFont("Arab", "Arial")
+	th.SetMinorFont("Jpan", "Yu Mincho")
+	for _, th := range []Theme{th, roundTripTheme(t, th)} {
+		if th.FontSchemeName() != "Office" || th.FormatSchemeName() != "Office" {
+			t.Errorf("scheme names = %q %q", th.FontSchemeName(), th.FormatSchemeName())
+		}
+		tests := []struct {
+			major        bool
+			script, want string
+		}{
+			{true, ThemeFontLatin, "Calibri Light"},
+			{true, "latin", "Calibri Light"},
+			{true, ThemeFontEastAsian, "MS Gothic"},
+			{true, ThemeFontComplexScript, "Times New Roman"},
+			{true, "Jpan", ""},
+			{false, ThemeFontLatin, "Calibri"},
+			{false, ThemeFontEastAsian, ""},
+			{false, "Jpan", "Yu Mincho"},
+			{false, "Arab", "Arial"},
+		}
+		for _, tc := range tests {
+			got := th.MinorFont(tc.script)
+			if tc.major {
+				got = th.MajorFont(tc.script)
+			}
+			if got != tc.want {
+				t.Errorf("font of %q (major %v) = %q, want %q", tc.script, tc.major, got, tc.want)
+			}
+		}
+	}
+
+	// an empty typeface removes a script font
+	th.SetMinorFont("Jpan", "")
+	if n := len(th.X().ThemeElements.FontScheme.MinorFont.Font); th.MinorFont("Jpan") != "" || n != 1 {
+		t.Errorf("removed font = %q, %d fonts left", th.MinorFont("Jpan"), n)
+	}
+	if got := MakeTheme(dml.NewTheme()).MajorFont(ThemeFontLatin); got != "" {
+		t.Errorf("font of empty theme = %q", got)
+	}
+}
+
+func TestThemeApply(t *testing.T) {
+	brand := newTheme()
+	brand.SetName("Brand")
+	brand.SetColorSchemeName("Brand colors")
+	brand.SetColor(dml.ST_SchemeColorValAccent1, "C00000")
+	brand.SetFontSchemeName("Brand fonts")
+	brand.SetMajorFont(ThemeFontLatin, "Georgia")
+
+	th := newTheme()
+	if err := th.ApplyColorScheme(brand); err != nil {
+		t.Fatal(err)
+	}
+	if th.Name() != "Office Theme" || th.ColorSchemeName() != "Brand colors" || th.Color(dml.ST_SchemeColorValAccent1) != "C00000" {
+		t.Errorf("applied color scheme = %q %q %q", th.Name(), th.ColorSchemeName(), th.Color(dml.ST_SchemeColorValAccent1))
+	}
+	if th.MajorFont(ThemeFontLatin) != "Calibri Light" {
+		t.Errorf("applying the color scheme changed the fonts to %q", th.MajorFont(ThemeFontLatin))
+	}
+	if err := th.ApplyFontScheme(brand); err != nil {
+		t.Fatal(err)
+	}
+	if th.FontSchemeName() != "Brand fonts" || th.MajorFont(ThemeFontLatin) != "Georgia" || th.MinorFont("Jpan") != "游明朝" {
+		t.Errorf("applied font scheme = %q %q", th.FontSchemeName(), th.MajorFont(ThemeFontLatin))
+	}
+
+	th = newTheme()
+	if err := th.Apply(brand); err != nil {
+		t.Fatal(err)
+	}
+	if th.Name() != "Brand" || th.Color(dml.ST_SchemeColorValAccent1) != "C00000" || th.MajorFont(ThemeFontLatin) != "Georgia" {
+		t.Errorf("applied theme = %q %q %q", th.Name(), th.Color(dml.ST_SchemeColorValAccent1), th.MajorFont(ThemeFontLatin))
+	}
+	// the applied theme is a copy
+	brand.SetColor(dml.ST_SchemeColorValAccent1, "00B050")
+	brand.SetMajorFont(ThemeFontLatin, "Verdana")
+	if th.Color(dml.ST_SchemeColorValAccent1) != "C00000" || th.MajorFont(ThemeFontLatin) != "Georgia" {
+		t.Error("changing the source theme changed the applied theme")
+	}
+
+	// a theme without elements applies as an empty theme
+	th = newTheme()
+	if err := th.Apply(MakeTheme(dml.NewTheme())); err != nil {
+		t.Fatal(err)
+	}
+	if th.Color(dml.ST_SchemeColorValAccent1) != "" || th.Name() != "" {
+		t.Errorf("applied empty theme = %q %q", th.Name(), th.Color(dml.ST_SchemeColorValAccent1))
+	}
+}
@@ -0,0 +1,579 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+/*
+Package diagram provides reading, editing and drawing regeneration of SmartArt
+diagrams shared by Word documents and PowerPoint presentations.
+
+A SmartArt diagram is stored as a data model holding a tree of text nodes, a
+layout definition and a drawing caching the shapes produced by laying out the
+nodes. The data model can be edited with this package, and the cached drawing
+regenerated for basic list, process, hierarchy and cycle layouts.
+
+Example:
+
+	for _, dg := range doc.Diagrams() {
+		for _, n := range dg.Roots() {
+			n.SetText(strings.ToUpper(n.Text()))
+		}
+		dg.Roots()[0].AddChild("New item")
+		dg.Regenerate()
+	}
+*/
+package diagram
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+	dgm "github.com/unidoc/unioffice/v2/schema/soo/dml/diagram"
+	"github.com/unidoc/unioffice/v2/zippkg"
+)
+
+// Relationship and content types of the diagram parts.
+const (
+	DataType           = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/diagramData"
+	DrawingType        = "http://schemas.microsoft.com/office/2007/relationships/diagramDrawing"
+	DataContentType    = "application/vnd.openxmlformats-officedocument.drawingml.diagramData+xml"
+	DrawingContentType = "application/vnd.ms-office.drawingml.diagramDrawing+xml"
+)
+
+const (
+	dspNS           = "http://schemas.microsoft.com/office/drawing/2008/diagram"
+	dataModelExtURI = dspNS
+	dgmNS           = "http://schemas.openxmlformats.org/drawingml/2006/diagram"
+)
+
+// Layout is the kind of layout used to arrange the nodes of a diagram.
+type Layout byte
+
+// Layout constants.
+const (
+	LayoutList Layout = iota
+	LayoutProcess
+	LayoutHierarchy
+	LayoutCycle
+)
+
+func (l Layout) String() string {
+	switch l {
+	case LayoutProcess:
+		return "process"
+	case LayoutHierarchy:
+		return "hierarchy"
+	case LayoutCycle:
+		return "cycle"
+	}
+	return "list"
+}
+
+// Diagram is a SmartArt diagram, made up of a data model holding the nodes
+// and their text and an optional drawing caching the laid out shapes.
+type Diagram struct {
+	x       *dgm.DataModel
+	drawing *Drawing
+	w, h    measurement.Distance
+}
+
+// New constructs a new diagram with an empty data model.
+func New() *Diagram {
+	dm := dgm.NewDataModel()
+	dm.PtLst = dgm.NewCT_PtList()
+	doc := dgm.NewCT_Pt()
+	doc.ModelIdAttr = newModelID()
+	doc.TypeAttr = dgm.ST_PtTypeDoc
+	doc.PrSet = dgm.NewCT_ElemPropSet()
+	doc.SpPr = dml.NewCT_ShapeProperties()
+	doc.T = newTextBody("")
+	dm.PtLst.Pt = append(dm.PtLst.Pt, doc)
+	dm.CxnLst = dgm.NewCT_CxnList()
+	return &Diagram{x: dm}
+}
+
+// Read reads a diagram data model part.
+func Read(r io.Reader) (*Diagram, error) {
+	dm := dgm.NewDataModel()
+	if err := xml.NewDecoder(r).Decode(dm); err != nil {
+		return nil, fmt.Errorf("error reading diagram data: %w", err)
+	}
+	if dm.PtLst == nil {
+		dm.PtLst = dgm.NewCT_PtList()
+	}
+	return &Diagram{x: dm}, nil
+}
+
+// X returns the inner wrapped XML type.
+func (d *Diagram) X() *dgm.DataModel { return d.x }
+
+// Write writes the diagram data model part.
+func (d *Diagram) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, zippkg.XMLHeader); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(d.x)
+}
+
+// Drawing returns the cached drawing of the diagram, or nil if the diagram
+// has no drawing.
+func (d *Diagram) Drawing() *Drawing { return d.drawing }
+
+// SetDrawing sets the cached drawing of the diagram.
+func (d *Diagram) SetDrawing(dr *Drawing) { d.drawing = dr }
+
+// Size returns the size of the frame holding the diagram.
+func (d *Diagram) Size() (w, h measurement.Distance) { return d.w, d.h }
+
+// SetSize sets the size of the frame holding the diagram, used when
+// regenerating the drawing.
+func (d *Diagram) SetSize(w, h measurement.Distance) { d.w, d.h = w, h }
+
+// DrawingRelID returns the relationship ID of the drawing part, relative to
+// the part containing the diagram, or an empty string if there is none.
+func (d *Diagram) DrawingRelID() string {
+	if a := d.dataModelExt(); a != nil {
+		for _, attr := range a.Attrs {
+			if attr.Name.Local == "relId" {
+				return attr.Value
+			}
+		}
+	}
+	return ""
+}
+
+// SetDrawingRelID sets the relationship ID of the drawing part.
+func (d *Diagram) SetDrawingRelID(id string) {
+	a := d.dataModelExt()
+	if a == nil {
+		if d.x.ExtLst == nil {
+			d.x.ExtLst = dml.NewCT_OfficeArtExtensionList()
+		}
+		ext := dml.NewCT_OfficeArtExtension()
+		ext.UriAttr = dataModelExtURI
+		a = &unioffice.XSDAny{XMLName: xml.Name{Space: dspNS, Local: "dataModelExt"}}
+		a.Attrs = append(a.Attrs, xml.Attr{Name: xml.Name{Local: "minVer"}, Value: dgmNS})
+		ext.Any = append(ext.Any, a)
+		d.x.ExtLst.Ext = append(d.x.ExtLst.Ext, ext)
+	}
+	for i, attr := range a.Attrs {
+		if attr.Name.Local == "relId" {
+			a.Attrs[i].Value = id
+			return
+		}
+	}
+	a.Attrs = append([]xml.Attr{{Name: xml.Name{Local: "relId"}, Value: id}}, a.Attrs...)
+}
+
+func (d *Diagram) dataModelExt() *unioffice.XSDAny {
+	if d.x.ExtLst == nil {
+		return nil
+	}
+	for _, ext := range d.x.ExtLst.Ext {
+		for _, a := range ext.Any {
+			if xa, ok := a.(*unioffice.XSDAny); ok && xa.XMLName.Local == "dataModelExt" {
+				return xa
+			}
+		}
+	}
+	return nil
+}
+
+// Layout returns the kind of layout of the diagram, determined from the
+// layout definition identifier recorded in the data model. Layouts that are
+// not recognized are reported as LayoutList.
+func (d *Diagram) Layout() Layout {
+	doc := d.docPoint()
+	if doc == nil || doc.PrSet == nil || doc.PrSet.LoTypeIdAttr == nil {
+		return LayoutList
+	}
+	id := strings.ToLower(*doc.PrSet.LoTypeIdAttr)
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		id = id[i+1:]
+	}
+	switch {
+	case strings.Contains(id, "hierarchy"), strings.Contains(id, "orgchart"):
+		return LayoutHierarchy
+	case strings.Contains(id, "cycle"), strings.Contains(id, "radial"):
+		return LayoutCycle
+	case strings.Contains(id, "process"), strings.Contains(id, "chevron"), strings.Contains(id, "arrow"):
+		return LayoutProcess
+	}
+	return LayoutList
+}
+
+// Nodes returns all of the content nodes of the diagram, in hierarchy order.
+func (d *Diagram) Nodes() []Node {
+	ret := []Node{}
+	var walk func(ns []Node)
+	walk = func(ns []Node) {
+		for _, n := range ns {
+			ret = append(ret, n)
+			walk(n.Children())
+		}
+	}
+	walk(d.Roots())
+	return ret
+}
+
+// Roots returns the top level nodes of the diagram in order.
+func (d *Diagram) Roots() []Node {
+	if doc := d.docPoint(); doc != nil {
+		return d.children(doc.ModelIdAttr.String())
+	}
+	ret := []Node{}
+	for _, pt := range d.x.PtLst.Pt {
+		if isContent(pt) && d.parentCxn(pt.ModelIdAttr.String()) == nil {
+			ret = append(ret, Node{d, pt})
+		}
+	}
+	return ret
+}
+
+// Node returns the content node with the given model ID.
+func (d *Diagram) Node(id string) (Node, bool) {
+	if pt := d.point(id); pt != nil && isContent(pt) {
+		return Node{d, pt}, true
+	}
+	return Node{}, false
+}
+
+// AddNode adds a new top level node with the given text after the existing
+// top level nodes.
+func (d *Diagram) AddNode(text string) Node {
+	doc := d.docPoint()
+	if doc == nil {
+		doc = New().x.PtLst.Pt[0]
+		d.x.PtLst.Pt = append([]*dgm.CT_Pt{doc}, d.x.PtLst.Pt...)
+	}
+	return d.addChild(doc.ModelIdAttr.String(), text)
+}
+
+func (d *Diagram) docPoint() *dgm.CT_Pt {
+	for _, pt := range d.x.PtLst.Pt {
+		if pt.TypeAttr == dgm.ST_PtTypeDoc {
+			return pt
+		}
+	}
+	return nil
+}
+
+func (d *Diagram) point(id string) *dgm.CT_Pt {
+	for _, pt := range d.x.PtLst.Pt {
+		if pt.ModelIdAttr.String() == id {
+			return pt
+		}
+	}
+	return nil
+}
+
+func (d *Diagram) cxns() []*dgm.CT_Cxn {
+	if d.x.CxnLst == nil {
+		return nil
+	}
+	return d.x.CxnLst.Cxn
+}
+
+func isParOf(c *dgm.CT_Cxn) bool {
+	return c.TypeAttr == dgm.ST_CxnTypeUnset || c.TypeAttr == dgm.ST_CxnTypeParOf
+}
+
+func isContent(pt *dgm.CT_Pt) bool {
+	switch pt.TypeAttr {
+	case dgm.ST_PtTypeUnset, dgm.ST_PtTypeNode, dgm.ST_PtTypeAsst:
+		return true
+	}
+	return false
+}
+
+// parentCxn returns the parent connection of the point with the given ID.
+func (d *Diagram) parentCxn(id string) *dgm.CT_Cxn {
+	for _, c := range d.cxns() {
+		if isParOf(c) && c.DestIdAttr.String() == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// childCxns returns the connections to the children of the point with the
+// given ID, ordered by source order.
+func (d *Diagram) childCxns(id string) []*dgm.CT_Cxn {
+	ret := []*dgm.CT_Cxn{}
+	for _, c := range d.cxns() {
+		if isParOf(c) && c.SrcIdAttr.String() == id {
+			ret = append(ret, c)
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].SrcOrdAttr < ret[j].SrcOrdAttr })
+	return ret
+}
+
+func (d *Diagram) children(id string) []Node {
+	ret := []Node{}
+	for _, c := range d.childCxns(id) {
+		if pt := d.point(c.DestIdAttr.String()); pt != nil && isContent(pt) {
+			ret = append(ret, Node{d, pt})
+		}
+	}
+	return ret
+}
+
+func (d *Diagram) addChild(parentID, text string) Node {
+	pt := dgm.NewCT_Pt()
+	pt.ModelIdAttr = newModelID()
+	pt.PrSet = dgm.NewCT_ElemPropSet()
+	pt.PrSet.PhldrTAttr = unioffice.String("[Text]")
+	pt.SpPr = dml.NewCT_ShapeProperties()
+	pt.T = newTextBody(text)
+
+	cxn := dgm.NewCT_Cxn()
+	cxn.ModelIdAttr = newModelID()
+	cxn.SrcIdAttr = modelID(parentID)
+	cxn.DestIdAttr = pt.ModelIdAttr
+	cxn.SrcOrdAttr = uint32(len(d.childCxns(parentID)))
+	parTrans := newTransPoint(dgm.ST_PtTypeParTrans, cxn.ModelIdAttr)
+	sibTrans := newTransPoint(dgm.ST_PtTypeSibTrans, cxn.ModelIdAttr)
+	cxn.ParTransIdAttr = &parTrans.ModelIdAttr
+	cxn.SibTransIdAttr = &sibTrans.ModelIdAttr
+
+	d.x.PtLst.Pt = append(d.x.PtLst.Pt, pt, parTrans, sibTrans)
+	if d.x.CxnLst == nil {
+		d.x.CxnLst = dgm.NewCT_CxnList()
+	}
+	d.x.CxnLst.Cxn = append(d.x.CxnLst.Cxn, cxn)
+	return Node{d, pt}
+}
+
+func newTransPoint(typ dgm.ST_PtType, cxnID dgm.ST_ModelId) *dgm.CT_Pt {
+	pt := dgm.NewCT_Pt()
+	pt.ModelIdAttr = newModelID()
+	pt.TypeAttr = typ
+	pt.CxnIdAttr = &cxnID
+	pt.PrSet = dgm.NewCT_ElemPropSet()
+	pt.SpPr = dml.NewCT_ShapeProperties()
+	pt.T = newTextBody("")
+	return pt
+}
+
+// presPoints returns the IDs of the presentation points associated with the
+// point with the given ID.
+func (d *Diagram) presPoints(id string) []string {
+	ret := []string{}
+	for _, pt := range d.x.PtLst.Pt {
+		if pt.TypeAttr == dgm.ST_PtTypePres && pt.PrSet != nil && pt.PrSet.PresAssocIDAttr != nil &&
+			pt.PrSet.PresAssocIDAttr.String() == id {
+			ret = append(ret, pt.ModelIdAttr.String())
+		}
+	}
+	return ret
+}
+
+// textPresPoint returns the ID of the presentation point displaying the text
+// of the point with the given ID, or an empty string if there is none.
+func (d *Diagram) textPresPoint(id string) string {
+	for _, pid := range d.presPoints(id) {
+		if pt := d.point(pid); pt.PrSet.PresStyleLblAttr != nil {
+			return pid
+		}
+	}
+	return ""
+}
+
+// Node is a content node of a diagram.
+type Node struct {
+	d *Diagram
+	x *dgm.CT_Pt
+}
+
+// X returns the inner wrapped XML type.
+func (n Node) X() *dgm.CT_Pt { return n.x }
+
+// ID returns the model ID of the node.
+func (n Node) ID() string { return n.x.ModelIdAttr.String() }
+
+// Text returns the text of the node, with paragraphs separated by newlines.
+func (n Node) Text() string { return textBodyText(n.x.T) }
+
+// SetText sets the text of the node. Newlines in the text start new
+// paragraphs. The text of the node is also updated in the cached drawing.
+func (n Node) SetText(s string) {
+	n.x.T = replaceText(n.x.T, s)
+	if n.d.drawing == nil {
+		return
+	}
+	ids := append(n.d.presPoints(n.ID()), n.ID())
+	for _, sp := range n.d.drawing.Shapes {
+		for _, id := range ids {
+			if sp.ModelID == id && sp.TxBody != nil {
+				sp.TxBody = replaceText(sp.TxBody, s)
+			}
+		}
+	}
+}
+
+// Children returns the child nodes of the node in order.
+func (n Node) Children() []Node { return n.d.children(n.ID()) }
+
+// Parent returns the parent node of the node. It returns false for top level
+// nodes.
+func (n Node) Parent() (Node, bool) {
+	if c := n.d.parentCxn(n.ID()); c != nil {
+		return n.d.Node(c.SrcIdAttr.String())
+	}
+	return Node{}, false
+}
+
+// AddChild adds a child node with the given text after the existing children
+// of the node.
+func (n Node) AddChild(text string) Node { return n.d.addChild(n.ID(), text) }
+
+// Remove removes the node and all of its descendants from the diagram, along
+// with their shapes in the cached drawing.
+func (n Node) Remove() {
+	d := n.d
+	removed := map[string]bool{}
+	var collect func(id string)
+	collect = func(id string) {
+		removed[id] = true
+		for _, pid := range d.presPoints(id) {
+			removed[pid] = true
+		}
+		for _, c := range d.childCxns(id) {
+			collect(c.DestIdAttr.String())
+		}
+	}
+	collect(n.ID())
+
+	parent := ""
+	cxns := []*dgm.CT_Cxn{}
+	for _, c := range d.cxns() {
+		if removed[c.SrcIdAttr.String()] || removed[c.DestIdAttr.String()] {
+			if isParOf(c) && c.DestIdAttr.String() == n.ID() {
+				parent = c.SrcIdAttr.String()
+			}
+			removed[c.ModelIdAttr.String()] = true
+			continue
+		}
+		cxns = append(cxns, c)
+	}
+	if d.x.CxnLst != nil {
+		d.x.CxnLst.Cxn = cxns
+	}
+	pts := []*dgm.CT_Pt{}
+	for _, pt := range d.x.PtLst.Pt {
+		if removed[pt.ModelIdAttr.String()] || (pt.CxnIdAttr != nil && removed[pt.CxnIdAttr.String()]) {
+			removed[pt.ModelIdAttr.String()] = true
+			continue
+		}
+		pts = append(pts, pt)
+	}
+	d.x.PtLst.Pt = pts
+	if parent != "" {
+		for i, c := range d.childCxns(parent) {
+			c.SrcOrdAttr = uint32(i)
+		}
+	}
+	if d.drawing != nil {
+		shapes := []*Shape{}
+		for _, sp := range d.drawing.Shapes {
+			if !removed[sp.ModelID] {
+				shapes = append(shapes, sp)
+			}
+		}
+		d.drawing.Shapes = shapes
+	}
+}
+
+// newModelID returns a new random GUID model ID.
+func newModelID() dgm.ST_ModelId {
+	b := make([]byte, 16)
+	rand.Read(b)
+	s := fmt.Sprintf("{%X-%X-%X-%X-%X}", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	return dgm.ST_ModelId{ST_Guid: &s}
+}
+
+func modelID(s string) dgm.ST_ModelId {
+	id, _ := dgm.ParseUnionST_ModelId(s)
+	return id
+}
+
+func newTextBody(text string) *dml.CT_TextBody {
+	tb := dml.NewCT_TextBody()
+	tb.LstStyle = dml.NewCT_TextListStyle()
+	return replaceText(tb, text)
+}
+
+// replaceText replaces the paragraphs of a text body with the lines of s,
+// keeping the body properties and the formatting of the first run.
+func replaceText(tb *dml.CT_TextBody, s string) *dml.CT_TextBody {
+	if tb == nil {
+		tb = dml.NewCT_TextBody()
+	}
+	var ppr *dml.CT_TextParagraphProperties
+	var rpr *dml.CT_TextCharacterProperties
+	for _, p := range tb.P {
+		if ppr == nil {
+			ppr = p.PPr
+		}
+		for _, r := range p.EG_TextRun {
+			if rpr == nil && r.TextRunChoice.R != nil {
+				rpr = r.TextRunChoice.R.RPr
+			}
+		}
+		if rpr == nil {
+			rpr = p.EndParaRPr
+		}
+	}
+	tb.P = nil
+	for _, line := range strings.Split(s, "\n") {
+		p := dml.NewCT_TextParagraph()
+		p.PPr = ppr
+		if line == "" {
+			p.EndParaRPr = rpr
+		} else {
+			r := dml.NewEG_TextRun()
+			r.TextRunChoice.R = dml.NewCT_RegularTextRun()
+			r.TextRunChoice.R.RPr = rpr
+			r.TextRunChoice.R.T = line
+			p.EG_TextRun = append(p.EG_TextRun, r)
+		}
+		tb.P = append(tb.P, p)
+	}
+	return tb
+}
+
+func textBodyText(tb *dml.CT_TextBody) string {
+	if tb == nil {
+		return ""
+	}
+	buf := bytes.Buffer{}
+	for i, p := range tb.P {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		for _, r := range p.EG_TextRun {
+			switch {
+			case r.TextRunChoice.R != nil:
+				buf.WriteString(r.TextRunChoice.R.T)
+			case r.TextRunChoice.Fld != nil && r.TextRunChoice.Fld.T != nil:
+				buf.WriteString(*r.TextRunChoice.Fld.T)
+			case r.TextRunChoice.Br != nil:
+				buf.WriteByte('\n')
+			}
+		}
+	}
+	return buf.String()
+}
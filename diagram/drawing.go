@@ -0,0 +1,261 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package diagram
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+	"github.com/unidoc/unioffice/v2/zippkg"
+)
+
+const dmlNS = "http://schemas.openxmlformats.org/drawingml/2006/main"
+
+// Drawing is the cached drawing of a diagram (dsp:drawing), holding the shapes
+// produced by laying out the diagram nodes. Shape positions are relative to
+// the frame holding the diagram.
+type Drawing struct {
+	Shapes []*Shape
+}
+
+// Shape is a shape of a diagram drawing.
+type Shape struct {
+	// ModelID is the model ID of the data model point the shape presents.
+	ModelID string
+	ID      uint32
+	Name    string
+	SpPr    *dml.CT_ShapeProperties
+	Style   *dml.CT_ShapeStyle
+	TxBody  *dml.CT_TextBody
+	// TxXfrm is the position of the text of the shape, if it differs from
+	// the position of the shape.
+	TxXfrm *dml.CT_Transform2D
+}
+
+// ReadDrawing reads a diagram drawing part. Shapes nested in groups are
+// flattened into the drawing.
+func ReadDrawing(r io.Reader) (*Drawing, error) {
+	dr := &Drawing{}
+	if err := xml.NewDecoder(r).Decode(dr); err != nil {
+		return nil, fmt.Errorf("error reading diagram drawing: %w", err)
+	}
+	return dr, nil
+}
+
+// Write writes the diagram drawing part.
+func (dr *Drawing) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, zippkg.XMLHeader); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(dr)
+}
+
+// Shape returns the shape presenting the point with the given model ID.
+func (dr *Drawing) Shape(modelID string) (*Shape, bool) {
+	for _, sp := range dr.Shapes {
+		if sp.ModelID == modelID {
+			return sp, true
+		}
+	}
+	return nil, false
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (dr *Drawing) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	depth := 1
+	for depth > 0 {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case el.Name.Space != dspNS:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			case el.Name.Local == "spTree", el.Name.Local == "grpSp":
+				depth++
+			case el.Name.Local == "sp":
+				sp := &Shape{}
+				if err := d.DecodeElement(sp, &el); err != nil {
+					return err
+				}
+				dr.Shapes = append(dr.Shapes, sp)
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return nil
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (dr *Drawing) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "dsp:drawing"}
+	start.Attr = append(start.Attr,
+		xml.Attr{Name: xml.Name{Local: "xmlns:dgm"}, Value: dgmNS},
+		xml.Attr{Name: xml.Name{Local: "xmlns:dsp"}, Value: dspNS},
+		xml.Attr{Name: xml.Name{Local: "xmlns:a"}, Value: dmlNS})
+	e.EncodeToken(start)
+	tree := xml.StartElement{Name: xml.Name{Local: "dsp:spTree"}}
+	e.EncodeToken(tree)
+
+	nvGrpSpPr := xml.StartElement{Name: xml.Name{Local: "dsp:nvGrpSpPr"}}
+	e.EncodeToken(nvGrpSpPr)
+	cNvPr := xml.StartElement{Name: xml.Name{Local: "dsp:cNvPr"}, Attr: []xml.Attr{
+		{Name: xml.Name{Local: "id"}, Value: "0"},
+		{Name: xml.Name{Local: "name"}, Value: ""}}}
+	e.EncodeToken(cNvPr)
+	e.EncodeToken(cNvPr.End())
+	cNvGrpSpPr := xml.StartElement{Name: xml.Name{Local: "dsp:cNvGrpSpPr"}}
+	e.EncodeToken(cNvGrpSpPr)
+	e.EncodeToken(cNvGrpSpPr.End())
+	e.EncodeToken(nvGrpSpPr.End())
+	grpSpPr := xml.StartElement{Name: xml.Name{Local: "dsp:grpSpPr"}}
+	e.EncodeToken(grpSpPr)
+	e.EncodeToken(grpSpPr.End())
+
+	for _, sp := range dr.Shapes {
+		if err := e.EncodeElement(sp, xml.StartElement{Name: xml.Name{Local: "dsp:sp"}}); err != nil {
+			return err
+		}
+	}
+	e.EncodeToken(tree.End())
+	e.EncodeToken(start.End())
+	return e.Flush()
+}
+
+// UnmarshalXML implements the xml.Unmarshaler interface.
+func (sp *Shape) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "modelId" {
+			sp.ModelID = attr.Value
+		}
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			var v interface{}
+			switch el.Name {
+			case xml.Name{Space: dspNS, Local: "nvSpPr"}:
+				if err := sp.unmarshalNvSpPr(d); err != nil {
+					return err
+				}
+				continue
+			case xml.Name{Space: dspNS, Local: "spPr"}:
+				sp.SpPr = dml.NewCT_ShapeProperties()
+				v = sp.SpPr
+			case xml.Name{Space: dspNS, Local: "style"}:
+				sp.Style = dml.NewCT_ShapeStyle()
+				v = sp.Style
+			case xml.Name{Space: dspNS, Local: "txBody"}:
+				sp.TxBody = dml.NewCT_TextBody()
+				v = sp.TxBody
+			case xml.Name{Space: dspNS, Local: "txXfrm"}:
+				sp.TxXfrm = dml.NewCT_Transform2D()
+				v = sp.TxXfrm
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.DecodeElement(v, &el); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (sp *Shape) unmarshalNvSpPr(d *xml.Decoder) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if el.Name.Local == "cNvPr" {
+				for _, attr := range el.Attr {
+					switch attr.Name.Local {
+					case "id":
+						if id, err := strconv.ParseUint(attr.Value, 10, 32); err == nil {
+							sp.ID = uint32(id)
+						}
+					case "name":
+						sp.Name = attr.Value
+					}
+				}
+			}
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// MarshalXML implements the xml.Marshaler interface.
+func (sp *Shape) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "modelId"}, Value: sp.ModelID})
+	e.EncodeToken(start)
+
+	nvSpPr := xml.StartElement{Name: xml.Name{Local: "dsp:nvSpPr"}}
+	e.EncodeToken(nvSpPr)
+	cNvPr := xml.StartElement{Name: xml.Name{Local: "dsp:cNvPr"}, Attr: []xml.Attr{
+		{Name: xml.Name{Local: "id"}, Value: strconv.FormatUint(uint64(sp.ID), 10)},
+		{Name: xml.Name{Local: "name"}, Value: sp.Name}}}
+	e.EncodeToken(cNvPr)
+	e.EncodeToken(cNvPr.End())
+	cNvSpPr := xml.StartElement{Name: xml.Name{Local: "dsp:cNvSpPr"}}
+	e.EncodeToken(cNvSpPr)
+	e.EncodeToken(cNvSpPr.End())
+	e.EncodeToken(nvSpPr.End())
+
+	spPr := sp.SpPr
+	if spPr == nil {
+		spPr = dml.NewCT_ShapeProperties()
+	}
+	if err := e.EncodeElement(spPr, xml.StartElement{Name: xml.Name{Local: "dsp:spPr"}}); err != nil {
+		return err
+	}
+	if sp.Style != nil {
+		if err := e.EncodeElement(sp.Style, xml.StartElement{Name: xml.Name{Local: "dsp:style"}}); err != nil {
+			return err
+		}
+	}
+	if sp.TxBody != nil {
+		if err := e.EncodeElement(sp.TxBody, xml.StartElement{Name: xml.Name{Local: "dsp:txBody"}}); err != nil {
+			return err
+		}
+	}
+	if sp.TxXfrm != nil {
+		if err := e.EncodeElement(sp.TxXfrm, xml.StartElement{Name: xml.Name{Local: "dsp:txXfrm"}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
@@ -0,0 +1,377 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package diagram
+
+import (
+	"math"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+)
+
+// default frame size used when the diagram size is unknown
+const (
+	defaultWidth  = 6 * measurement.Inch
+	defaultHeight = 3.5 * measurement.Inch
+)
+
+// Regenerate replaces the cached drawing of the diagram with shapes laid out
+// according to the layout of the diagram, within the frame size.
+func (d *Diagram) Regenerate() { d.RegenerateAs(d.Layout()) }
+
+// RegenerateAs replaces the cached drawing of the diagram with shapes laid
+// out using the given kind of layout, within the frame size. The layout
+// definition part of the diagram is not changed, so applications that lay
+// out the diagram themselves may still use the original layout.
+func (d *Diagram) RegenerateAs(l Layout) {
+	w, h := d.w, d.h
+	if w <= 0 || h <= 0 {
+		w, h = defaultWidth, defaultHeight
+	}
+	g := &generator{d: d, dr: &Drawing{}, w: measurement.ToEMU(float64(w)), h: measurement.ToEMU(float64(h))}
+	roots := d.Roots()
+	if len(roots) > 0 {
+		switch l {
+		case LayoutProcess:
+			g.process(roots)
+		case LayoutHierarchy:
+			g.hierarchy(roots)
+		case LayoutCycle:
+			g.cycle(roots)
+		default:
+			g.list(roots)
+		}
+	}
+	d.drawing = g.dr
+}
+
+type box struct {
+	x, y, w, h int64
+}
+
+type generator struct {
+	d    *Diagram
+	dr   *Drawing
+	w, h int64
+}
+
+// list lays out the top level nodes as a grid of blocks, with the children of
+// each node as bullet points inside of its block.
+func (g *generator) list(roots []Node) {
+	n := len(roots)
+	best, cols := int64(0), 1
+	var bw, bh int64
+	for c := 1; c <= n; c++ {
+		rows := (n + c - 1) / c
+		w := g.w * 9 / 10 / int64(c)
+		h := g.h * 9 / 10 / int64(rows)
+		if w*3/5 < h {
+			h = w * 3 / 5
+		} else {
+			w = h * 5 / 3
+		}
+		if w*h > best {
+			best, cols, bw, bh = w*h, c, w, h
+		}
+	}
+	rows := (n + cols - 1) / cols
+	gapX, gapY := bw/10, bh/10
+	offX := (g.w - int64(cols)*bw - int64(cols-1)*gapX) / 2
+	offY := (g.h - int64(rows)*bh - int64(rows-1)*gapY) / 2
+	for i, nd := range roots {
+		col, row := int64(i%cols), int64(i/cols)
+		g.addNode(nd, dml.ST_ShapeTypeRect, box{offX + col*(bw+gapX), offY + row*(bh+gapY), bw, bh}, true)
+	}
+}
+
+// process lays out the top level nodes left to right, joined by arrows.
+func (g *generator) process(roots []Node) {
+	n := int64(len(roots))
+	bw := g.w * 10 / (10*n + 5*(n-1))
+	bh := bw * 3 / 5
+	for _, nd := range roots {
+		if len(nd.Children()) > 0 {
+			bh = bw
+		}
+	}
+	if bh > g.h*9/10 {
+		bh = g.h * 9 / 10
+	}
+	gap := bw / 2
+	aw, ah := gap*3/5, gap*7/10
+	if ah > bh {
+		ah = bh
+	}
+	offX := (g.w - n*bw - (n-1)*gap) / 2
+	y := (g.h - bh) / 2
+	for i, nd := range roots {
+		x := offX + int64(i)*(bw+gap)
+		g.addNode(nd, dml.ST_ShapeTypeRoundRect, box{x, y, bw, bh}, true)
+		if int64(i) < n-1 {
+			g.addArrow(box{x + bw + (gap-aw)/2, y + (bh-ah)/2, aw, ah}, 0)
+		}
+	}
+}
+
+// hierarchy lays out the nodes as a top down tree, with each parent centered
+// over its children and connected to them by lines.
+func (g *generator) hierarchy(roots []Node) {
+	var leaves func(ns []Node) int
+	var depth func(ns []Node) int
+	leaves = func(ns []Node) int {
+		cnt := 0
+		for _, n := range ns {
+			if l := leaves(n.Children()); l > 0 {
+				cnt += l
+			} else {
+				cnt++
+			}
+		}
+		return cnt
+	}
+	depth = func(ns []Node) int {
+		ret := 0
+		for _, n := range ns {
+			if d := depth(n.Children()) + 1; d > ret {
+				ret = d
+			}
+		}
+		return ret
+	}
+	slotW := g.w / int64(leaves(roots))
+	levelH := g.h / int64(depth(roots))
+	bw := slotW * 17 / 20
+	bh := levelH * 3 / 5
+	if bh > bw*2/3 {
+		bh = bw * 2 / 3
+	}
+	if bw > bh*2 {
+		bw = bh * 2
+	}
+
+	slot := int64(0)
+	// place returns the horizontal center of the boxes of the nodes
+	var place func(ns []Node, level int64) []int64
+	place = func(ns []Node, level int64) []int64 {
+		centers := []int64{}
+		for _, n := range ns {
+			cx := int64(0)
+			children := n.Children()
+			childCenters := place(children, level+1)
+			if len(childCenters) == 0 {
+				cx = slot*slotW + slotW/2
+				slot++
+			} else {
+				cx = (childCenters[0] + childCenters[len(childCenters)-1]) / 2
+			}
+			y := level*levelH + (levelH-bh)/2
+			g.addNode(n, dml.ST_ShapeTypeRoundRect, box{cx - bw/2, y, bw, bh}, false)
+			if len(childCenters) > 0 {
+				childTop := (level+1)*levelH + (levelH-bh)/2
+				mid := (y + bh + childTop) / 2
+				g.addLine(cx, y+bh, cx, mid)
+				g.addLine(childCenters[0], mid, childCenters[len(childCenters)-1], mid)
+				for _, ccx := range childCenters {
+					g.addLine(ccx, mid, ccx, childTop)
+				}
+			}
+			centers = append(centers, cx)
+		}
+		return centers
+	}
+	place(roots, 0)
+}
+
+// cycle lays out the top level nodes as circles around a circle, joined by
+// arrows in clockwise order.
+func (g *generator) cycle(roots []Node) {
+	n := float64(len(roots))
+	size := float64(g.w)
+	if float64(g.h) < size {
+		size = float64(g.h)
+	}
+	nd := 0.35 * size
+	if n > 1 {
+		f := 1.2 * math.Sin(math.Pi/n)
+		if lim := f * size / 2 / (1 + f/2); lim < nd {
+			nd = lim
+		}
+	}
+	r := size/2 - nd/2
+	cx, cy := float64(g.w)/2, float64(g.h)/2
+	for i, node := range roots {
+		a := -math.Pi/2 + 2*math.Pi*float64(i)/n
+		x, y := cx+r*math.Cos(a), cy+r*math.Sin(a)
+		g.addNode(node, dml.ST_ShapeTypeEllipse, box{int64(x - nd/2), int64(y - nd/2), int64(nd), int64(nd)}, true)
+		if n > 1 {
+			ma := a + math.Pi/n
+			aw, ah := nd*0.3, nd*0.35
+			ax, ay := cx+r*math.Cos(ma), cy+r*math.Sin(ma)
+			rot := (ma + math.Pi/2) * 180 / math.Pi
+			g.addArrow(box{int64(ax - aw/2), int64(ay - ah/2), int64(aw), int64(ah)}, rot)
+		}
+	}
+}
+
+// addNode adds a shape presenting a node and its text, followed by the text
+// of its descendants as bullet points if children is set.
+func (g *generator) addNode(n Node, geom dml.ST_ShapeType, b box, children bool) {
+	sp := g.newShape(geom, b, 0)
+	if id := g.d.textPresPoint(n.ID()); id != "" {
+		sp.ModelID = id
+	} else {
+		sp.ModelID = n.ID()
+	}
+	sp.SpPr.FillPropertiesChoice.SolidFill = schemeColorFill(dml.ST_SchemeColorValAccent1)
+	sp.SpPr.Ln = newLine(dml.ST_SchemeColorValLt1)
+
+	lines := strings.Split(n.Text(), "\n")
+	bullets := []string{}
+	if children {
+		var walk func(ns []Node)
+		walk = func(ns []Node) {
+			for _, c := range ns {
+				bullets = append(bullets, strings.Split(c.Text(), "\n")...)
+				walk(c.Children())
+			}
+		}
+		walk(n.Children())
+	}
+	size := fontSize(b, append(append([]string{}, lines...), bullets...))
+
+	tb := dml.NewCT_TextBody()
+	tb.BodyPr = dml.NewCT_TextBodyProperties()
+	tb.BodyPr.WrapAttr = dml.ST_TextWrappingTypeSquare
+	tb.BodyPr.AnchorAttr = dml.ST_TextAnchoringTypeCtr
+	tb.LstStyle = dml.NewCT_TextListStyle()
+	for _, l := range lines {
+		tb.P = append(tb.P, newParagraph(l, size, false))
+	}
+	for _, l := range bullets {
+		tb.P = append(tb.P, newParagraph(l, size*4/5, true))
+	}
+	sp.TxBody = tb
+	sp.TxXfrm = newXfrm(b, 0)
+}
+
+// addArrow adds a right arrow shape rotated clockwise by rot degrees.
+func (g *generator) addArrow(b box, rot float64) {
+	sp := g.newShape(dml.ST_ShapeTypeRightArrow, b, rot)
+	sp.ModelID = newModelID().String()
+	sp.SpPr.FillPropertiesChoice.SolidFill = schemeColorFill(dml.ST_SchemeColorValAccent1)
+	sp.SpPr.Ln = dml.NewCT_LineProperties()
+	sp.SpPr.Ln.LineFillPropertiesChoice.NoFill = dml.NewCT_NoFillProperties()
+}
+
+// addLine adds a connecting line between two points.
+func (g *generator) addLine(x1, y1, x2, y2 int64) {
+	b := box{x1, y1, x2 - x1, y2 - y1}
+	if b.w < 0 {
+		b.x, b.w = x2, -b.w
+	}
+	if b.h < 0 {
+		b.y, b.h = y2, -b.h
+	}
+	sp := g.newShape(dml.ST_ShapeTypeLine, b, 0)
+	sp.ModelID = newModelID().String()
+	sp.SpPr.FillPropertiesChoice.NoFill = dml.NewCT_NoFillProperties()
+	sp.SpPr.Ln = newLine(dml.ST_SchemeColorValAccent1)
+}
+
+func (g *generator) newShape(geom dml.ST_ShapeType, b box, rot float64) *Shape {
+	sp := &Shape{SpPr: dml.NewCT_ShapeProperties()}
+	sp.SpPr.Xfrm = newXfrm(b, rot)
+	sp.SpPr.GeometryChoice.PrstGeom = dml.NewCT_PresetGeometry2D()
+	sp.SpPr.GeometryChoice.PrstGeom.PrstAttr = geom
+	sp.SpPr.GeometryChoice.PrstGeom.AvLst = dml.NewCT_GeomGuideList()
+	g.dr.Shapes = append(g.dr.Shapes, sp)
+	return sp
+}
+
+func newXfrm(b box, rot float64) *dml.CT_Transform2D {
+	xfrm := dml.NewCT_Transform2D()
+	if rot != 0 {
+		r := int32(math.Mod(rot+360, 360) * 60000)
+		xfrm.RotAttr = &r
+	}
+	xfrm.Off = dml.NewCT_Point2D()
+	xfrm.Off.XAttr.ST_CoordinateUnqualified = unioffice.Int64(b.x)
+	xfrm.Off.YAttr.ST_CoordinateUnqualified = unioffice.Int64(b.y)
+	xfrm.Ext = dml.NewCT_PositiveSize2D()
+	xfrm.Ext.CxAttr = b.w
+	xfrm.Ext.CyAttr = b.h
+	return xfrm
+}
+
+func schemeColorFill(c dml.ST_SchemeColorVal) *dml.CT_SolidColorFillProperties {
+	f := dml.NewCT_SolidColorFillProperties()
+	f.SchemeClr = dml.NewCT_SchemeColor()
+	f.SchemeClr.ValAttr = c
+	return f
+}
+
+func newLine(c dml.ST_SchemeColorVal) *dml.CT_LineProperties {
+	ln := dml.NewCT_LineProperties()
+	ln.WAttr = unioffice.Int32(12700)
+	ln.LineFillPropertiesChoice.SolidFill = schemeColorFill(c)
+	return ln
+}
+
+// newParagraph returns a paragraph of light text with the given size in
+// hundredths of a point.
+func newParagraph(s string, size int32, bullet bool) *dml.CT_TextParagraph {
+	p := dml.NewCT_TextParagraph()
+	p.PPr = dml.NewCT_TextParagraphProperties()
+	rpr := dml.NewCT_TextCharacterProperties()
+	rpr.SzAttr = unioffice.Int32(size)
+	rpr.FillPropertiesChoice.SolidFill = schemeColorFill(dml.ST_SchemeColorValLt1)
+	if bullet {
+		p.PPr.LvlAttr = unioffice.Int32(1)
+		p.PPr.MarLAttr = unioffice.Int32(114300)
+		p.PPr.IndentAttr = unioffice.Int32(-114300)
+		p.PPr.AlgnAttr = dml.ST_TextAlignTypeL
+		p.PPr.TextBulletChoice.BuChar = dml.NewCT_TextCharBullet()
+		p.PPr.TextBulletChoice.BuChar.CharAttr = "•"
+	} else {
+		p.PPr.AlgnAttr = dml.ST_TextAlignTypeCtr
+	}
+	if s == "" {
+		p.EndParaRPr = rpr
+		return p
+	}
+	r := dml.NewEG_TextRun()
+	r.TextRunChoice.R = dml.NewCT_RegularTextRun()
+	r.TextRunChoice.R.RPr = rpr
+	r.TextRunChoice.R.T = s
+	p.EG_TextRun = append(p.EG_TextRun, r)
+	return p
+}
+
+// fontSize returns a font size in hundredths of a point that fits the lines
+// of text into the box.
+func fontSize(b box, lines []string) int32 {
+	maxLen := 1
+	for _, l := range lines {
+		if n := len([]rune(l)); n > maxLen {
+			maxLen = n
+		}
+	}
+	w := measurement.FromEMU(b.w) * 0.85
+	h := measurement.FromEMU(b.h) * 0.85
+	size := h / (float64(len(lines)) * 1.2)
+	if fit := w / (float64(maxLen) * 0.55); fit < size {
+		// long lines wrap, so allow them some extra room
+		size = math.Min(size, math.Max(fit, math.Sqrt(w*h/(float64(maxLen*len(lines))*0.66))))
+	}
+	size = math.Max(6, math.Min(size, 40))
+	return int32(size) * 100
+}
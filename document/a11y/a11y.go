@@ -0,0 +1,320 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+// Package a11y checks documents for common accessibility problems, such as
+// images without alternative text, tables without header rows, skipped heading
+// levels, low contrast text and links that don't describe their target.
+package a11y
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/document"
+	"github.com/unidoc/unioffice/v2/document/internal/semantic"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// Severity is the severity of an issue.
+type Severity byte
+
+// Severity constants.
+const (
+	// SeverityWarning is used for issues that make a document harder to use
+	// with assistive technology.
+	SeverityWarning Severity = iota
+	// SeverityError is used for content that is not accessible at all, such
+	// as an image without alternative text.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// Rule identifies the check that reported an issue.
+type Rule string
+
+// Rule constants.
+const (
+	RuleImageAltText  Rule = "image-alt-text"
+	RuleTableHeader   Rule = "table-header"
+	RuleHeadingOrder  Rule = "heading-order"
+	RuleColorContrast Rule = "color-contrast"
+	RuleEmptyLink     Rule = "empty-link"
+	RuleLinkText      Rule = "link-text"
+)
+
+// Issue is an accessibility problem found in a document.
+type Issue struct {
+	Rule     Rule
+	Severity Severity
+	Message  string
+	// Node is the paragraph, run or table the issue was found in.
+	Node document.Node
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Rule, i.Message)
+}
+
+// Minimum contrast ratios of WCAG 2 level AA, large text being at least 18
+// points, or 14 points if bold.
+const (
+	MinContrast          = 4.5
+	MinContrastLargeText = 3.0
+)
+
+// NonDescriptiveLinkText lists link texts that don't describe the link target,
+// compared case insensitively and ignoring surrounding punctuation.
+var NonDescriptiveLinkText = []string{
+	"click here", "click", "here", "link", "this link", "more", "read more",
+	"learn more", "more info", "more information", "details", "this", "go",
+}
+
+// Check returns the accessibility issues of the body of the document, in
+// document order.
+func Check(d *document.Document) []Issue {
+	c := &checker{d: d}
+	nodes := d.Nodes()
+	c.checkNodes(nodes.X())
+	return c.issues
+}
+
+type checker struct {
+	d            *document.Document
+	issues       []Issue
+	headingLevel int
+}
+
+func (c *checker) report(n document.Node, rule Rule, sev Severity, format string, args ...interface{}) {
+	c.issues = append(c.issues, Issue{Rule: rule, Severity: sev, Message: fmt.Sprintf(format, args...), Node: n})
+}
+
+func (c *checker) checkNodes(nodes []document.Node) {
+	for _, n := range nodes {
+		switch x := n.X().(type) {
+		case *document.Paragraph:
+			c.checkParagraph(n, *x)
+		case document.Run:
+			c.checkDrawings(n)
+		case *document.Table:
+			c.checkTable(n, *x)
+		}
+		c.checkNodes(n.Children)
+	}
+}
+
+func (c *checker) checkDrawings(n document.Node) {
+	for _, dr := range n.InlineDrawings {
+		if dr.AltText() == "" {
+			c.report(n, RuleImageAltText, SeverityError, "inline drawing %q has no alternative text", dr.X().DocPr.NameAttr)
+		}
+	}
+	for _, dr := range n.AnchoredDrawings {
+		if dr.AltText() == "" {
+			c.report(n, RuleImageAltText, SeverityError, "floating drawing %q has no alternative text", dr.X().DocPr.NameAttr)
+		}
+	}
+}
+
+func (c *checker) checkTable(n document.Node, t document.Table) {
+	rows := t.Rows()
+	if len(rows) < 2 {
+		return
+	}
+	if !rows[0].IsHeader() {
+		c.report(n, RuleTableHeader, SeverityWarning, "table has no header row")
+	}
+}
+
+func (c *checker) checkParagraph(n document.Node, p document.Paragraph) {
+	if lvl := p.HeadingLevel(); lvl > 0 && strings.TrimSpace(n.Text()) != "" {
+		if c.headingLevel > 0 && lvl > c.headingLevel+1 {
+			c.report(n, RuleHeadingOrder, SeverityWarning, "heading level %d follows heading level %d", lvl, c.headingLevel)
+		}
+		c.headingLevel = lvl
+	}
+	runs := map[*wml.CT_R]document.Node{}
+	for _, rn := range n.Children {
+		if r, ok := rn.X().(document.Run); ok {
+			runs[r.X()] = rn
+			c.checkContrast(rn, p, r)
+		}
+	}
+	for _, pc := range p.X().EG_PContent {
+		if h := pc.PContentChoice.Hyperlink; h != nil {
+			c.checkLink(n, h, runs)
+		}
+	}
+}
+
+func (c *checker) checkLink(n document.Node, h *wml.CT_Hyperlink, runs map[*wml.CT_R]document.Node) {
+	text := ""
+	hasAltText := false
+	found := false
+	for _, crc := range h.PContentChoice.EG_ContentRunContent {
+		rn, ok := runs[crc.ContentRunContentChoice.R]
+		if crc.ContentRunContentChoice.R == nil || !ok {
+			continue
+		}
+		if !found {
+			// report the issue at the first run of the link
+			n, found = rn, true
+		}
+		text += rn.X().(document.Run).Text()
+		for _, dr := range rn.InlineDrawings {
+			hasAltText = hasAltText || dr.AltText() != ""
+		}
+	}
+	target := ""
+	if h.IdAttr != nil {
+		target = c.d.GetTargetByRelId(*h.IdAttr)
+	} else if h.AnchorAttr != nil {
+		target = "#" + *h.AnchorAttr
+	}
+	text = strings.TrimSpace(text)
+	switch {
+	case text == "" && !hasAltText:
+		c.report(n, RuleEmptyLink, SeverityError, "link to %q has no text", target)
+	case text == "":
+	case isNonDescriptive(text):
+		c.report(n, RuleLinkText, SeverityWarning, "link text %q doesn't describe the link to %q", text, target)
+	case isURL(text):
+		c.report(n, RuleLinkText, SeverityWarning, "link text %q is a URL", text)
+	}
+}
+
+func isNonDescriptive(text string) bool {
+	text = strings.ToLower(strings.Trim(text, " .,:;!?()[]\"'…»«"))
+	for _, s := range NonDescriptiveLinkText {
+		if text == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isURL(text string) bool {
+	lt := strings.ToLower(text)
+	return !strings.ContainsAny(text, " \t") && (strings.HasPrefix(lt, "http://") || strings.HasPrefix(lt, "https://") || strings.HasPrefix(lt, "www."))
+}
+
+func (c *checker) checkContrast(n document.Node, p document.Paragraph, r document.Run) {
+	if strings.TrimSpace(r.Text()) == "" {
+		return
+	}
+	rprs := c.runProperties(p.X(), r.X())
+	fg := ""
+	for _, rpr := range rprs {
+		if rpr.Color != nil {
+			fg = hexColor(&rpr.Color.ValAttr)
+			break
+		}
+	}
+	if fg == "" {
+		// automatic colors are chosen by the application to contrast with
+		// the background
+		return
+	}
+	bg := "FFFFFF"
+	if rpr := r.X().RPr; rpr != nil && rpr.Shd != nil && hexColor(rpr.Shd.FillAttr) != "" {
+		bg = hexColor(rpr.Shd.FillAttr)
+	} else if ppr := p.X().PPr; ppr != nil && ppr.Shd != nil && hexColor(ppr.Shd.FillAttr) != "" {
+		bg = hexColor(ppr.Shd.FillAttr)
+	}
+	size, bold := 0.0, false
+	for _, rpr := range rprs {
+		if rpr.Sz != nil && rpr.Sz.ValAttr.ST_UnsignedDecimalNumber != nil {
+			size = float64(*rpr.Sz.ValAttr.ST_UnsignedDecimalNumber) / 2
+			break
+		}
+	}
+	for _, rpr := range rprs {
+		if rpr.B != nil {
+			bold = semantic.IsOn(rpr.B)
+			break
+		}
+	}
+	min := MinContrast
+	if size >= 18 || bold && size >= 14 {
+		min = MinContrastLargeText
+	}
+	if ratio := ContrastRatio(fg, bg); ratio < min {
+		c.report(n, RuleColorContrast, SeverityWarning, "text color #%s on #%s has a contrast ratio of %.2f:1, below %.1f:1", fg, bg, ratio, min)
+	}
+}
+
+// runProperties returns the properties applying to a run, from the run
+// itself, its character style, the paragraph style and the document defaults.
+func (c *checker) runProperties(p *wml.CT_P, r *wml.CT_R) []*wml.CT_RPr {
+	styles := c.d.Styles.X()
+	rprs := []*wml.CT_RPr{}
+	if r.RPr != nil {
+		rprs = append(rprs, r.RPr)
+		if r.RPr.RStyle != nil {
+			for _, s := range semantic.StyleChain(styles, r.RPr.RStyle.ValAttr) {
+				if s.RPr != nil {
+					rprs = append(rprs, s.RPr)
+				}
+			}
+		}
+	}
+	if p.PPr != nil && p.PPr.PStyle != nil {
+		for _, s := range semantic.StyleChain(styles, p.PPr.PStyle.ValAttr) {
+			if s.RPr != nil {
+				rprs = append(rprs, s.RPr)
+			}
+		}
+	}
+	if dd := styles.DocDefaults; dd != nil && dd.RPrDefault != nil && dd.RPrDefault.RPr != nil {
+		rprs = append(rprs, dd.RPrDefault.RPr)
+	}
+	return rprs
+}
+
+// hexColor returns the RRGGBB value of a color, or an empty string for
+// automatic colors.
+func hexColor(c *wml.ST_HexColor) string {
+	if c == nil || c.ST_HexColorRGB == nil || len(*c.ST_HexColorRGB) != 6 {
+		return ""
+	}
+	return strings.ToUpper(*c.ST_HexColorRGB)
+}
+
+// ContrastRatio returns the WCAG 2 contrast ratio of two RRGGBB colors, from 1
+// to 21.
+func ContrastRatio(fg, bg string) float64 {
+	l1, l2 := luminance(fg), luminance(bg)
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// luminance returns the relative luminance of an RRGGBB color.
+func luminance(hex string) float64 {
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil {
+		return 0
+	}
+	channel := func(c uint64) float64 {
+		f := float64(c) / 255
+		if f <= 0.03928 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(v>>16&0xff) + 0.7152*channel(v>>8&0xff) + 0.0722*channel(v&0xff)
+}
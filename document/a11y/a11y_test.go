@@ -0,0 +1,199 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package a11y
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/color"
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/common/license"
+	"github.com/unidoc/unioffice/v2/document"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// TestMain sets the metered license key of UNIDOC_LICENSE_API_KEY, which
+// saving and reading documents require.
+func TestMain(m *testing.M) {
+	if key := os.Getenv("UNIDOC_LICENSE_API_KEY"); key != "" {
+		if err := license.SetMeteredKey(key); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func TestContrastRatio(t *testing.T) {
+	tests := []struct {
+		fg, bg string
+		want   float64
+	}{
+		{"000000", "FFFFFF", 21},
+		{"FFFFFF", "000000", 21},
+		{"#777777", "FFFFFF", 4.478},
+		{"767676", "FFFFFF", 4.542},
+		{"FF0000", "FFFFFF", 3.998},
+		{"FFFFFF", "000080", 16.010},
+		{"123456", "123456", 1},
+	}
+	for _, tc := range tests {
+		if got := ContrastRatio(tc.fg, tc.bg); math.Abs(got-tc.want) > 0.001 {
+			t.Errorf("ContrastRatio(%s, %s) = %.3f, want %.3f", tc.fg, tc.bg, got, tc.want)
+		}
+	}
+}
+
+func TestLinkText(t *testing.T) {
+	for _, s := range []string{"click here", "Click Here!", "(more)", "Read more…"} {
+		if !isNonDescriptive(s) {
+			t.Errorf("%q is descriptive", s)
+		}
+	}
+	if isNonDescriptive("Annual report") {
+		t.Error("Annual report is not descriptive")
+	}
+	for s, want := range map[string]bool{"https://example.com": true, "WWW.example.com": true, "see https://example.com": false, "example": false} {
+		if got := isURL(s); got != want {
+			t.Errorf("isURL(%q) = %v", s, got)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	d := document.New()
+	heading := func(style, text string) {
+		p := d.AddParagraph()
+		p.SetStyle(style)
+		p.AddRun().AddText(text)
+	}
+	heading("Heading1", "Introduction")
+	heading("Heading3", "Details")
+	heading("Heading2", "Background")
+
+	// text colors, a run per color
+	p := d.AddParagraph()
+	colored := func(hex, text string, size measurement.Distance, bold bool) document.RunProperties {
+		r := p.AddRun()
+		r.AddText(text)
+		rp := r.Properties()
+		rp.SetColor(color.FromHex(hex))
+		if size > 0 {
+			rp.SetSize(size)
+		}
+		rp.SetBold(bold)
+		return rp
+	}
+	colored("777777", "gray", 0, false)
+	colored("949494", "large", 18*measurement.Point, false)
+	colored("FF0000", "bold", 14*measurement.Point, true)
+	colored("FF0000", "small", 12*measurement.Point, true)
+	colored("AAAAAA", " ", 0, false)
+	shd := wml.NewCT_Shd()
+	shd.ValAttr = wml.ST_ShdClear
+	shd.FillAttr = &wml.ST_HexColor{ST_HexColorRGB: unioffice.String("000080")}
+	colored("FFFFFF", "inverted", 0, false).X().Shd = shd
+
+	p = d.AddParagraph()
+	link := func(url, text string) {
+		hl := p.AddHyperLink()
+		hl.SetTarget(url)
+		if text != "" {
+			hl.AddRun().AddText(text)
+		}
+	}
+	link("https://example.com/report", "Annual report")
+	link("https://example.com", "click here")
+	link("https://example.com", "https://example.com")
+	link("https://example.com/empty", "")
+
+	for _, header := range []bool{false, true} {
+		tbl := d.AddTable()
+		for i := 0; i < 2; i++ {
+			row := tbl.AddRow()
+			row.AddCell().AddParagraph().AddRun().AddText("cell")
+			if i == 0 && header {
+				row.Properties().SetTblHeader(true)
+			}
+		}
+	}
+	d.AddTable().AddRow().AddCell().AddParagraph().AddRun().AddText("single row")
+
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	img, err := common.ImageFromBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := d.AddImage(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = d.AddParagraph()
+	p.AddRun().AddDrawingInline(ref)
+	described, _ := p.AddRun().AddDrawingInline(ref)
+	described.SetAltText("Company logo")
+	p.AddRun().AddDrawingAnchored(ref)
+
+	want := []Rule{
+		RuleHeadingOrder,
+		RuleColorContrast, RuleColorContrast,
+		RuleLinkText, RuleLinkText, RuleEmptyLink,
+		RuleTableHeader,
+		RuleImageAltText, RuleImageAltText,
+	}
+	rules := func(issues []Issue) []Rule {
+		ret := []Rule{}
+		for _, i := range issues {
+			ret = append(ret, i.Rule)
+		}
+		return ret
+	}
+	issues := Check(d)
+	if got := rules(issues); !reflect.DeepEqual(got, want) {
+		t.Fatalf("issues = %v, want %v", issues, want)
+	}
+	if got := issues[1].String(); got != "warning: color-contrast: text color #777777 on #FFFFFF has a contrast ratio of 4.48:1, below 4.5:1" {
+		t.Errorf("issue = %s", got)
+	}
+	if r, ok := issues[2].Node.X().(document.Run); !ok || r.Text() != "small" {
+		t.Errorf("contrast issue reported at %v", issues[2].Node.X())
+	}
+	if r, ok := issues[3].Node.X().(document.Run); !ok || r.Text() != "click here" {
+		t.Errorf("link issue reported at %v", issues[3].Node.X())
+	}
+	if issues[5].Severity != SeverityError || issues[7].Severity != SeverityError {
+		t.Error("empty links and images without alternative text are errors")
+	}
+
+	// the issues are the same once the document is saved and read
+	buf.Reset()
+	if err := d.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := document.Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rules(Check(read)); !reflect.DeepEqual(got, want) {
+		t.Errorf("read document issues = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,45 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"github.com/unidoc/unioffice/v2/document/internal/semantic"
+)
+
+// HeadingLevel returns the heading level of the paragraph, from 1 to 9, or 0
+// if it is not a heading. The level comes from the outline level of the
+// paragraph or its style, or from built-in heading style names.
+func (p Paragraph) HeadingLevel() int {
+	return semantic.HeadingLevel(p._adga.Styles.X(), p._cebfg)
+}
+
+// ListLevel returns the zero based level of a numbered or bulleted paragraph,
+// and false if the paragraph is not a list item.
+func (p Paragraph) ListLevel() (int, bool) {
+	return semantic.ListLevel(p._adga.Styles.X(), p._cebfg)
+}
+
+// IsHeader returns true if the row is a header row, repeated at the top of
+// each page the table spans.
+func (r Row) IsHeader() bool { return semantic.IsHeaderRow(r.X()) }
+
+// AltText returns the alternative text describing the drawing, its
+// description or else its title.
+func (i InlineDrawing) AltText() string { return semantic.AltText(i._edfce.DocPr) }
+
+// SetAltText sets the description of the drawing read by screen readers.
+func (i InlineDrawing) SetAltText(text string) { i._edfce.DocPr.DescrAttr = optionalString(text) }
+
+// AltText returns the alternative text describing the drawing, its
+// description or else its title.
+func (a AnchoredDrawing) AltText() string { return semantic.AltText(a._ag.DocPr) }
+
+// SetAltText sets the description of the drawing read by screen readers.
+func (a AnchoredDrawing) SetAltText(text string) { a._ag.DocPr.DescrAttr = optionalString(text) }
@@ -0,0 +1,164 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+func TestHeadingLevel(t *testing.T) {
+	d := New()
+	chapter := d.Styles.AddStyle("Chapter", wml.ST_StyleTypeParagraph, false)
+	chapter.ParagraphProperties().SetOutlineLevel(0)
+	part := d.Styles.AddStyle("ChapterPart", wml.ST_StyleTypeParagraph, false)
+	part.SetBasedOn("Chapter")
+
+	tests := []struct {
+		style   string
+		outline int64
+		want    int
+	}{
+		{"", 0, 0},
+		{"Normal", 0, 0},
+		{"Title", 0, 0},
+		{"Heading2", 0, 2},
+		{"Heading9", 0, 9},
+		{"Chapter", 0, 1},
+		{"ChapterPart", 0, 1},
+		{"", 3, 3},
+		{"Heading2", 4, 4},
+	}
+	for _, tc := range tests {
+		p := d.AddParagraph()
+		if tc.style != "" {
+			p.SetStyle(tc.style)
+		}
+		if tc.outline > 0 {
+			p.SetOutlineLvl(tc.outline)
+		}
+		if got := p.HeadingLevel(); got != tc.want {
+			t.Errorf("heading level of %q with outline level %d = %d, want %d", tc.style, tc.outline, got, tc.want)
+		}
+	}
+
+	read := roundTrip(t, d)
+	for i, p := range read.Paragraphs() {
+		if got := p.HeadingLevel(); got != tests[i].want {
+			t.Errorf("read heading level of %q = %d, want %d", tests[i].style, got, tests[i].want)
+		}
+	}
+}
+
+func TestListLevel(t *testing.T) {
+	d := New()
+	plain := d.AddParagraph()
+	item := d.AddParagraph()
+	item.SetNumberingDefinitionByID(1)
+	item.SetNumberingLevel(2)
+	removed := d.AddParagraph()
+	removed.SetNumberingDefinitionByID(0)
+
+	bullets := d.Styles.AddStyle("Bullets", wml.ST_StyleTypeParagraph, false)
+	np := wml.NewCT_NumPr()
+	np.NumId = wml.NewCT_DecimalNumber()
+	np.NumId.ValAttr = 1
+	bullets.ParagraphProperties().X().NumPr = np
+	styled := d.AddParagraph()
+	styled.SetStyle("Bullets")
+
+	check := func(prefix string, ps []Paragraph) {
+		want := []struct {
+			lvl int
+			ok  bool
+		}{{0, false}, {2, true}, {0, false}, {0, true}}
+		for i, p := range ps {
+			if lvl, ok := p.ListLevel(); lvl != want[i].lvl || ok != want[i].ok {
+				t.Errorf("%slist level of paragraph %d = %d %v, want %d %v", prefix, i, lvl, ok, want[i].lvl, want[i].ok)
+			}
+		}
+	}
+	check("", []Paragraph{plain, item, removed, styled})
+	check("read ", roundTrip(t, d).Paragraphs())
+}
+
+func TestRowIsHeader(t *testing.T) {
+	d := New()
+	tbl := d.AddTable()
+	header := tbl.AddRow()
+	header.Properties().SetTblHeader(true)
+	body := tbl.AddRow()
+	if !header.IsHeader() || body.IsHeader() {
+		t.Errorf("header rows = %v %v, want true false", header.IsHeader(), body.IsHeader())
+	}
+	header.Properties().SetTblHeader(false)
+	if header.IsHeader() {
+		t.Error("turned off header row is a header")
+	}
+	header.Properties().SetTblHeader(true)
+
+	rows := roundTrip(t, d).Tables()[0].Rows()
+	if !rows[0].IsHeader() || rows[1].IsHeader() {
+		t.Errorf("read header rows = %v %v, want true false", rows[0].IsHeader(), rows[1].IsHeader())
+	}
+}
+
+func TestAltText(t *testing.T) {
+	d := New()
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	img, err := common.ImageFromBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := d.AddImage(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := d.AddParagraph()
+	inline, err := p.AddRun().AddDrawingInline(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anchored, err := p.AddRun().AddDrawingAnchored(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inline.AltText() != "" || anchored.AltText() != "" {
+		t.Errorf("new drawings have alternative text %q %q", inline.AltText(), anchored.AltText())
+	}
+
+	// the title is used if there is no description
+	title := "Logo"
+	anchored.X().DocPr.TitleAttr = &title
+	inline.SetAltText(" Company logo ")
+	if inline.AltText() != "Company logo" || anchored.AltText() != "Logo" {
+		t.Errorf("alternative texts = %q %q", inline.AltText(), anchored.AltText())
+	}
+	anchored.SetAltText("Watermark")
+	if anchored.AltText() != "Watermark" {
+		t.Errorf("alternative text = %q, want Watermark", anchored.AltText())
+	}
+
+	runs := roundTrip(t, d).Paragraphs()[0].Runs()
+	if got := runs[0].DrawingInline()[0].AltText(); got != "Company logo" {
+		t.Errorf("read inline alternative text = %q", got)
+	}
+	if got := runs[1].DrawingAnchored()[0].AltText(); got != "Watermark" {
+		t.Errorf("read anchored alternative text = %q", got)
+	}
+}
@@ -0,0 +1,418 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/color"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/sharedTypes"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// Common caption labels used by Word.
+const (
+	CaptionLabelFigure   = "Figure"
+	CaptionLabelTable    = "Table"
+	CaptionLabelEquation = "Equation"
+)
+
+// CaptionStyleID is the ID of the paragraph style applied to captions.
+const CaptionStyleID = "Caption"
+
+// CaptionPosition controls where a caption is placed relative to the paragraph
+// it describes.
+type CaptionPosition byte
+
+// CaptionPosition constants.
+const (
+	CaptionBelow CaptionPosition = iota
+	CaptionAbove
+)
+
+// CrossReferenceKind selects what a cross-reference displays.
+type CrossReferenceKind byte
+
+// CrossReferenceKind constants.
+const (
+	// CrossReferenceText displays the text of the referenced item.
+	CrossReferenceText CrossReferenceKind = iota
+	// CrossReferencePageNumber displays the page number of the referenced item.
+	CrossReferencePageNumber
+	// CrossReferenceParagraphNumber displays the list number of the referenced
+	// paragraph.
+	CrossReferenceParagraphNumber
+	// CrossReferenceAboveBelow displays "above" or "below" depending on the
+	// position of the referenced item relative to the reference.
+	CrossReferenceAboveBelow
+)
+
+// AddCaption inserts a caption paragraph above or below the paragraph. The
+// caption consists of the label followed by a SEQ field numbering all captions
+// with the same label, e.g. "Figure 3". The label and number are wrapped in a
+// hidden _Ref bookmark so the caption can be the target of a cross-reference.
+// The returned paragraph can be extended with a description.
+func (p Paragraph) AddCaption(label string, position CaptionPosition) Paragraph {
+	d := p._adga
+	var cp Paragraph
+	if position == CaptionAbove {
+		cp = d.InsertParagraphBefore(p)
+	} else {
+		cp = d.InsertParagraphAfter(p)
+	}
+	d.ensureCaptionStyle()
+	cp.SetStyle(CaptionStyleID)
+
+	bm := cp.addStartBookmark(d.nextBookmarkID(), d.newRefBookmarkName())
+	run := cp.AddRun()
+	run.AddText(label + " ")
+	run = cp.AddRun()
+	run.addFieldWithResult(fmt.Sprintf("SEQ %s \\* ARABIC", quoteFieldArgument(label)), "1", false)
+	cp.addEndBookmark(bm.IdAttr)
+
+	d.renumberCaptions(label)
+	return cp
+}
+
+// AddCrossReference adds a REF or PAGEREF field to the run referring to the
+// target paragraph, e.g. a heading or a caption created with AddCaption. If the
+// target is not yet wrapped in a hidden _Ref bookmark one is created. The
+// bookmark used by the reference is returned.
+func (r Run) AddCrossReference(target Paragraph, kind CrossReferenceKind) Bookmark {
+	d := r._gdedf
+	bm, ok := target.refBookmark()
+	if !ok {
+		bm = target.wrapInRefBookmark(d.nextBookmarkID(), d.newRefBookmarkName())
+	}
+	name := bm.Name()
+	switch kind {
+	case CrossReferencePageNumber:
+		r.addFieldWithResult(fmt.Sprintf("PAGEREF %s \\h", name), "1", true)
+	case CrossReferenceParagraphNumber:
+		r.addFieldWithResult(fmt.Sprintf("REF %s \\r \\h", name), "", true)
+	case CrossReferenceAboveBelow:
+		result := "below"
+		if d.isBefore(target.X(), r.X()) {
+			result = "above"
+		}
+		r.addFieldWithResult(fmt.Sprintf("PAGEREF %s \\p \\h", name), result, false)
+	default:
+		r.addFieldWithResult(fmt.Sprintf("REF %s \\h", name), target.bookmarkText(bm), false)
+	}
+	return bm
+}
+
+// addFieldWithResult adds a complex field with a cached result to the run.
+func (r Run) addFieldWithResult(code, result string, isDirty bool) {
+	ic := r.newIC()
+	ic.RunInnerContentChoice.FldChar = wml.NewCT_FldChar()
+	ic.RunInnerContentChoice.FldChar.FldCharTypeAttr = wml.ST_FldCharTypeBegin
+	if isDirty {
+		ic.RunInnerContentChoice.FldChar.DirtyAttr = newOnOff(true)
+	}
+	ic = r.newIC()
+	ic.RunInnerContentChoice.InstrText = wml.NewCT_Text()
+	ic.RunInnerContentChoice.InstrText.Content = " " + code + " "
+	ic.RunInnerContentChoice.InstrText.SpaceAttr = preserveSpace()
+	ic = r.newIC()
+	ic.RunInnerContentChoice.FldChar = wml.NewCT_FldChar()
+	ic.RunInnerContentChoice.FldChar.FldCharTypeAttr = wml.ST_FldCharTypeSeparate
+	if result != "" {
+		r.AddText(result)
+	}
+	ic = r.newIC()
+	ic.RunInnerContentChoice.FldChar = wml.NewCT_FldChar()
+	ic.RunInnerContentChoice.FldChar.FldCharTypeAttr = wml.ST_FldCharTypeEnd
+}
+
+// ensureCaptionStyle adds the built-in Caption paragraph style if the document
+// does not define it yet.
+func (d *Document) ensureCaptionStyle() {
+	if _, ok := d.Styles.SearchStyleById(CaptionStyleID); ok {
+		return
+	}
+	s := d.Styles.AddStyle(CaptionStyleID, wml.ST_StyleTypeParagraph, false)
+	s.SetName("caption")
+	s.SetBasedOn("Normal")
+	s.SetNextStyle("Normal")
+	s.SetPrimaryStyle(true)
+	s.SetUnhideWhenUsed(true)
+	s.SetUISortOrder(35)
+	s.ParagraphProperties().SetSpacing(0, 10*measurement.Point)
+	rp := s.RunProperties()
+	rp.SetItalic(true)
+	rp.SetSize(9 * measurement.Point)
+	rp.SetColor(color.RGB(0x44, 0x54, 0x6A))
+}
+
+// renumberCaptions updates the cached results of all SEQ fields with the given
+// label so they reflect document order, along with the results of REF fields
+// displaying the text of those captions.
+func (d *Document) renumberCaptions(label string) {
+	n := 0
+	captions := map[string]string{}
+	paragraphs := d.paragraphsInOrder()
+	for _, p := range paragraphs {
+		inSeq, inResult, numbered := false, false, false
+		for _, r := range p.Runs() {
+			for _, ic := range r.X().EG_RunInnerContent {
+				c := ic.RunInnerContentChoice
+				switch {
+				case c.InstrText != nil:
+					inSeq = isSeqField(c.InstrText.Content, label)
+				case c.FldChar != nil && c.FldChar.FldCharTypeAttr == wml.ST_FldCharTypeSeparate:
+					if inSeq {
+						n++
+						inResult = true
+					}
+				case c.FldChar != nil && c.FldChar.FldCharTypeAttr == wml.ST_FldCharTypeEnd:
+					inSeq, inResult = false, false
+				case c.T != nil && inResult:
+					c.T.Content = strconv.Itoa(n)
+					inResult, numbered = false, true
+				}
+			}
+		}
+		if bm, ok := p.refBookmark(); ok && numbered {
+			captions[bm.Name()] = p.bookmarkText(bm)
+		}
+	}
+	if len(captions) == 0 {
+		return
+	}
+	for _, p := range paragraphs {
+		text, isRef, inResult := "", false, false
+		for _, r := range p.Runs() {
+			for _, ic := range r.X().EG_RunInnerContent {
+				c := ic.RunInnerContentChoice
+				switch {
+				case c.InstrText != nil:
+					text, isRef = captions[refFieldTarget(c.InstrText.Content)]
+				case c.FldChar != nil && c.FldChar.FldCharTypeAttr == wml.ST_FldCharTypeSeparate:
+					inResult = isRef
+				case c.FldChar != nil && c.FldChar.FldCharTypeAttr == wml.ST_FldCharTypeEnd:
+					isRef, inResult = false, false
+				case c.T != nil && inResult:
+					// the first text of the result holds the caption, any
+					// following text is left over from the previous result
+					c.T.Content = text
+					text = ""
+				}
+			}
+		}
+	}
+}
+
+// refFieldTarget returns the bookmark of a REF field displaying the text of the
+// bookmark, or an empty string if the instruction is not such a field.
+func refFieldTarget(instr string) string {
+	f := strings.Fields(instr)
+	if len(f) < 2 || !strings.EqualFold(f[0], "REF") {
+		return ""
+	}
+	for _, sw := range f[2:] {
+		switch strings.ToLower(sw) {
+		case "\\n", "\\r", "\\w", "\\p":
+			return ""
+		}
+	}
+	return f[1]
+}
+
+// isSeqField reports whether the field instruction is a SEQ field for label.
+func isSeqField(instr, label string) bool {
+	instr = strings.TrimSpace(instr)
+	if len(instr) < 4 || !strings.EqualFold(instr[:4], "SEQ ") {
+		return false
+	}
+	arg := strings.TrimSpace(instr[4:])
+	if strings.HasPrefix(arg, "\"") {
+		if end := strings.Index(arg[1:], "\""); end >= 0 {
+			return arg[1:end+1] == label
+		}
+		return false
+	}
+	if f := strings.Fields(arg); len(f) > 0 {
+		return f[0] == label
+	}
+	return false
+}
+
+// quoteFieldArgument quotes a field argument if it contains spaces.
+func quoteFieldArgument(s string) string {
+	if strings.ContainsAny(s, " \t") {
+		return "\"" + s + "\""
+	}
+	return s
+}
+
+// nextBookmarkID returns an ID that is not used by any bookmark in the document.
+func (d *Document) nextBookmarkID() int64 {
+	id := int64(0)
+	for _, b := range d.Bookmarks() {
+		if b.X().IdAttr >= id {
+			id = b.X().IdAttr + 1
+		}
+	}
+	return id
+}
+
+// newRefBookmarkName returns a unique name for a hidden reference bookmark in
+// the form Word uses, e.g. _Ref000000003. Names are numbered after the highest
+// existing one so the output is reproducible.
+func (d *Document) newRefBookmarkName() string {
+	n := int64(0)
+	for _, b := range d.Bookmarks() {
+		if !strings.HasPrefix(b.Name(), "_Ref") {
+			continue
+		}
+		if v, err := strconv.ParseInt(b.Name()[4:], 10, 64); err == nil && v > n {
+			n = v
+		}
+	}
+	return fmt.Sprintf("_Ref%09d", n+1)
+}
+
+// refBookmark returns the first hidden _Ref bookmark started in the paragraph.
+func (p Paragraph) refBookmark() (Bookmark, bool) {
+	for _, pc := range p.X().EG_PContent {
+		for _, crc := range pc.PContentChoice.EG_ContentRunContent {
+			for _, rle := range crc.ContentRunContentChoice.EG_RunLevelElts {
+				for _, rme := range rle.RunLevelEltsChoice.EG_RangeMarkupElements {
+					if bs := rme.RangeMarkupElementsChoice.BookmarkStart; bs != nil && strings.HasPrefix(bs.NameAttr, "_Ref") {
+						return Bookmark{bs}, true
+					}
+				}
+			}
+		}
+	}
+	return Bookmark{}, false
+}
+
+// wrapInRefBookmark surrounds the whole content of the paragraph with a new
+// bookmark.
+func (p Paragraph) wrapInRefBookmark(id int64, name string) Bookmark {
+	bs := p.addStartBookmark(id, name)
+	// addStartBookmark appends, move the start to the front of the paragraph
+	pc := p.X().EG_PContent
+	last := pc[len(pc)-1]
+	copy(pc[1:], pc[:len(pc)-1])
+	pc[0] = last
+	p.addEndBookmark(id)
+	return Bookmark{bs}
+}
+
+// bookmarkText returns the text of the paragraph enclosed by the bookmark.
+func (p Paragraph) bookmarkText(bm Bookmark) string {
+	sb := strings.Builder{}
+	inside := false
+	for _, pc := range p.X().EG_PContent {
+		for _, crc := range pc.PContentChoice.EG_ContentRunContent {
+			for _, rle := range crc.ContentRunContentChoice.EG_RunLevelElts {
+				for _, rme := range rle.RunLevelEltsChoice.EG_RangeMarkupElements {
+					c := rme.RangeMarkupElementsChoice
+					if c.BookmarkStart != nil && c.BookmarkStart == bm.X() {
+						inside = true
+					}
+					if c.BookmarkEnd != nil && c.BookmarkEnd.IdAttr == bm.X().IdAttr {
+						inside = false
+					}
+				}
+			}
+			if inside && crc.ContentRunContentChoice.R != nil {
+				sb.WriteString(runResultText(crc.ContentRunContentChoice.R))
+			}
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// runResultText returns the text of a run as displayed, skipping field
+// instructions.
+func runResultText(r *wml.CT_R) string {
+	sb := strings.Builder{}
+	for _, ic := range r.EG_RunInnerContent {
+		if t := ic.RunInnerContentChoice.T; t != nil {
+			sb.WriteString(t.Content)
+		}
+		if ic.RunInnerContentChoice.Tab != nil {
+			sb.WriteByte('\t')
+		}
+	}
+	return sb.String()
+}
+
+// isBefore reports whether paragraph a occurs before the paragraph containing
+// run r in document order.
+func (d *Document) isBefore(a *wml.CT_P, r *wml.CT_R) bool {
+	for _, p := range d.paragraphsInOrder() {
+		if p.X() == a {
+			return true
+		}
+		for _, pr := range p.Runs() {
+			if pr.X() == r {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// paragraphsInOrder returns the paragraphs of the document body, including
+// those nested in tables and block level content controls, in document order.
+func (d *Document) paragraphsInOrder() []Paragraph {
+	if d.X().Body == nil {
+		return nil
+	}
+	ret := []Paragraph{}
+	for _, ble := range d.X().Body.EG_BlockLevelElts {
+		ret = append(ret, d.blockParagraphs(ble.BlockLevelEltsChoice.EG_ContentBlockContent)...)
+	}
+	return ret
+}
+
+func (d *Document) blockParagraphs(cbcs []*wml.EG_ContentBlockContent) []Paragraph {
+	ret := []Paragraph{}
+	for _, cbc := range cbcs {
+		for _, p := range cbc.ContentBlockContentChoice.P {
+			ret = append(ret, Paragraph{d, p})
+		}
+		for _, tbl := range cbc.ContentBlockContentChoice.Tbl {
+			for _, crc := range tbl.EG_ContentRowContent {
+				for _, tr := range crc.ContentRowContentChoice.Tr {
+					for _, ccc := range tr.EG_ContentCellContent {
+						for _, tc := range ccc.ContentCellContentChoice.Tc {
+							for _, ble := range tc.EG_BlockLevelElts {
+								ret = append(ret, d.blockParagraphs(ble.BlockLevelEltsChoice.EG_ContentBlockContent)...)
+							}
+						}
+					}
+				}
+			}
+		}
+		if sdt := cbc.ContentBlockContentChoice.Sdt; sdt != nil && sdt.SdtContent != nil {
+			ret = append(ret, d.blockParagraphs(sdt.SdtContent.EG_ContentBlockContent)...)
+		}
+	}
+	return ret
+}
+
+func newOnOff(b bool) *sharedTypes.ST_OnOff {
+	return &sharedTypes.ST_OnOff{Bool: unioffice.Bool(b)}
+}
+
+func preserveSpace() *string {
+	s := "preserve"
+	return &s
+}
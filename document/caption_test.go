@@ -0,0 +1,157 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddCaption(t *testing.T) {
+	d := New()
+	chart := d.AddParagraph()
+	chart.AddRun().AddText("chart")
+	cp := chart.AddCaption(CaptionLabelFigure, CaptionBelow)
+	cp.AddRun().AddText(": Sales")
+	if cp.Style() != CaptionStyleID {
+		t.Errorf("caption style = %q, want %s", cp.Style(), CaptionStyleID)
+	}
+	if _, ok := d.Styles.SearchStyleById(CaptionStyleID); !ok {
+		t.Error("the Caption style wasn't added")
+	}
+
+	// a caption added before the first one is numbered first
+	photo := d.InsertParagraphBefore(chart)
+	photo.AddRun().AddText("photo")
+	photo.AddCaption(CaptionLabelFigure, CaptionAbove)
+	table := d.AddParagraph()
+	table.AddRun().AddText("table")
+	table.AddCaption(CaptionLabelTable, CaptionBelow)
+
+	want := []string{"Figure 1", "photo", "chart", "Figure 2: Sales", "table", "Table 1"}
+	if got := paragraphTexts(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("paragraphs = %q, want %q", got, want)
+	}
+	names := []string{}
+	for _, bm := range d.Bookmarks() {
+		names = append(names, bm.Name())
+	}
+	if !reflect.DeepEqual(names, []string{"_Ref000000002", "_Ref000000001", "_Ref000000003"}) {
+		t.Errorf("bookmarks = %v", names)
+	}
+
+	read := roundTrip(t, d)
+	if got := paragraphTexts(read); !reflect.DeepEqual(got, want) {
+		t.Errorf("read paragraphs = %q, want %q", got, want)
+	}
+	if got := read.Paragraphs()[0].Style(); got != CaptionStyleID {
+		t.Errorf("read caption style = %q", got)
+	}
+}
+
+func TestAddCrossReference(t *testing.T) {
+	d := New()
+	heading := d.AddParagraph()
+	heading.AddRun().AddText("Introduction")
+	figure := d.AddParagraph()
+	figure.AddRun().AddText("chart")
+	caption := figure.AddCaption(CaptionLabelFigure, CaptionBelow)
+
+	ref := d.AddParagraph()
+	ref.AddRun().AddText("See ")
+	bm := ref.AddRun().AddCrossReference(caption, CrossReferenceText)
+	ref.AddRun().AddText(" ")
+	ref.AddRun().AddCrossReference(caption, CrossReferenceAboveBelow)
+	ref.AddRun().AddText(", in ")
+	hbm := ref.AddRun().AddCrossReference(heading, CrossReferenceText)
+	ref.AddRun().AddText(" on page ")
+	ref.AddRun().AddCrossReference(heading, CrossReferencePageNumber)
+	if got := paragraphText(ref); got != "See Figure 1 above, in Introduction on page 1" {
+		t.Errorf("references = %q", got)
+	}
+	if bm.Name() != "_Ref000000001" || hbm.Name() != "_Ref000000002" {
+		t.Errorf("bookmarks = %s %s", bm.Name(), hbm.Name())
+	}
+	// the heading is wrapped in its bookmark, which is reused
+	if hb, ok := heading.refBookmark(); !ok || hb.Name() != hbm.Name() || heading.bookmarkText(hb) != "Introduction" {
+		t.Errorf("heading bookmark = %v %v", hb, ok)
+	}
+	if again := d.AddParagraph().AddRun().AddCrossReference(heading, CrossReferenceText); again.Name() != hbm.Name() {
+		t.Errorf("second reference created bookmark %s", again.Name())
+	}
+
+	// references follow the renumbering of captions
+	first := d.InsertParagraphBefore(heading)
+	first.AddRun().AddText("photo")
+	first.AddCaption(CaptionLabelFigure, CaptionBelow)
+	if got := paragraphText(ref); !strings.HasPrefix(got, "See Figure 2 above") {
+		t.Errorf("references after renumbering = %q", got)
+	}
+
+	instr := []string{}
+	for _, r := range ref.Runs() {
+		for _, ic := range r.X().EG_RunInnerContent {
+			if it := ic.RunInnerContentChoice.InstrText; it != nil {
+				instr = append(instr, strings.TrimSpace(it.Content))
+			}
+		}
+	}
+	want := []string{"REF _Ref000000001 \\h", "PAGEREF _Ref000000001 \\p \\h", "REF _Ref000000002 \\h", "PAGEREF _Ref000000002 \\h"}
+	if !reflect.DeepEqual(instr, want) {
+		t.Errorf("fields = %q, want %q", instr, want)
+	}
+
+	read := roundTrip(t, d)
+	if got := paragraphText(read.Paragraphs()[5]); !strings.HasPrefix(got, "See Figure 2 above") {
+		t.Errorf("read references = %q", got)
+	}
+}
+
+func TestCaptionFields(t *testing.T) {
+	seq := []struct {
+		instr, label string
+		want         bool
+	}{
+		{" SEQ Figure \\* ARABIC ", "Figure", true},
+		{"seq Figure", "Figure", true},
+		{"SEQ \"My Figure\" \\* ARABIC", "My Figure", true},
+		{"SEQ Figure", "Table", false},
+		{"SEQ \"My Figure", "My Figure", false},
+		{"REF Figure", "Figure", false},
+		{"SEQ", "Figure", false},
+	}
+	for _, tc := range seq {
+		if got := isSeqField(tc.instr, tc.label); got != tc.want {
+			t.Errorf("isSeqField(%q, %q) = %v", tc.instr, tc.label, got)
+		}
+	}
+	refs := []struct {
+		instr, want string
+	}{
+		{" REF _Ref1 \\h ", "_Ref1"},
+		{"ref _Ref1", "_Ref1"},
+		{"REF _Ref1 \\r \\h", ""},
+		{"REF _Ref1 \\p", ""},
+		{"PAGEREF _Ref1 \\h", ""},
+		{"REF", ""},
+	}
+	for _, tc := range refs {
+		if got := refFieldTarget(tc.instr); got != tc.want {
+			t.Errorf("refFieldTarget(%q) = %q, want %q", tc.instr, got, tc.want)
+		}
+	}
+	if got := quoteFieldArgument("My Figure"); got != "\"My Figure\"" {
+		t.Errorf("quoted argument = %s", got)
+	}
+	if got := quoteFieldArgument("Figure"); got != "Figure" {
+		t.Errorf("quoted argument = %s", got)
+	}
+}
@@ -0,0 +1,217 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+
+	"github.com/unidoc/unioffice/v2"
+	uchart "github.com/unidoc/unioffice/v2/chart"
+	"github.com/unidoc/unioffice/v2/color"
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/common/tempstorage"
+	"github.com/unidoc/unioffice/v2/measurement"
+	crt "github.com/unidoc/unioffice/v2/schema/soo/dml/chart"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+	"github.com/unidoc/unioffice/v2/spreadsheet"
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+	"github.com/unidoc/unioffice/v2/zippkg"
+)
+
+const (
+	chartGraphicDataURI     = "http://schemas.openxmlformats.org/drawingml/2006/chart"
+	packageRelationshipType = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/package"
+	xlsxContentType         = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	chartDataSheetName      = "Sheet1"
+)
+
+// ChartData is the table of values behind a chart embedded in a document. It
+// is stored in a workbook embedded in the document so that the chart data can
+// be edited in Word. Categories are written to the first column and each
+// series to one of the following columns, with series names in the first row.
+type ChartData struct {
+	Categories []string
+	Series     []ChartSeriesData
+}
+
+// ChartSeriesData is a named series of values, one per category.
+type ChartSeriesData struct {
+	Name   string
+	Values []float64
+}
+
+// ChartSeries is implemented by the category based chart series of the chart
+// package, such as bar, line, area, pie and radar chart series.
+type ChartSeries interface {
+	CategoryAxis() uchart.CategoryAxisDataSource
+	Values() uchart.NumberDataSource
+	SetText(s string)
+}
+
+// CategoryReference returns the reference to the categories in the embedded
+// workbook.
+func (c ChartData) CategoryReference() string {
+	return fmt.Sprintf("%s!$A$2:$A$%d", chartDataSheetName, len(c.Categories)+1)
+}
+
+// SeriesReference returns the reference to the values of the series with the
+// given index in the embedded workbook.
+func (c ChartData) SeriesReference(idx int) string {
+	col := reference.IndexToColumn(uint32(idx + 1))
+	return fmt.Sprintf("%s!$%s$2:$%s$%d", chartDataSheetName, col, col, len(c.Categories)+1)
+}
+
+// BindSeries sets the name, categories and values of a chart series to those
+// of the series with the given index, referencing the embedded workbook.
+func (c ChartData) BindSeries(idx int, s ChartSeries) error {
+	if idx < 0 || idx >= len(c.Series) {
+		return fmt.Errorf("series index %d out of range", idx)
+	}
+	s.SetText(c.Series[idx].Name)
+	s.CategoryAxis().SetLabelReferenceWithValues(c.CategoryReference(), c.Categories)
+	s.Values().SetReferenceWithValues(c.SeriesReference(idx), c.Series[idx].Values)
+	return nil
+}
+
+// workbook returns a workbook holding the chart data.
+func (c ChartData) workbook() *spreadsheet.Workbook {
+	wb := spreadsheet.New()
+	sheet := wb.AddSheet()
+	sheet.SetName(chartDataSheetName)
+	for i, cat := range c.Categories {
+		sheet.Cell(fmt.Sprintf("A%d", i+2)).SetString(cat)
+	}
+	for i, s := range c.Series {
+		col := reference.IndexToColumn(uint32(i + 1))
+		sheet.Cell(col + "1").SetString(s.Name)
+		for j, v := range s.Values {
+			sheet.Cell(fmt.Sprintf("%s%d", col, j+2)).SetNumber(v)
+		}
+	}
+	return wb
+}
+
+// AddChart adds an inline chart of the given size to the run. The chart data
+// is embedded in the document as a workbook and each data series is available
+// for binding to chart series with ChartData.BindSeries.
+func (d *Document) AddChart(r Run, data ChartData, w, h measurement.Distance) (uchart.Chart, InlineDrawing, error) {
+	c, gc, err := d.addChartPart(data)
+	if err != nil {
+		return uchart.Chart{}, InlineDrawing{}, err
+	}
+	inline := newGraphicInline(chartGraphicDataURI, gc, w, h)
+	ic := r.newIC()
+	ic.RunInnerContentChoice.Drawing = wml.NewCT_Drawing()
+	ic.RunInnerContentChoice.Drawing.DrawingChoice = append(ic.RunInnerContentChoice.Drawing.DrawingChoice, &wml.CT_DrawingChoice{Inline: inline})
+	return c, InlineDrawing{d, inline}, nil
+}
+
+// AddChartAnchored adds a floating chart of the given size to the run. The
+// returned AnchoredDrawing controls its position and text wrapping.
+func (d *Document) AddChartAnchored(r Run, data ChartData, w, h measurement.Distance) (uchart.Chart, AnchoredDrawing, error) {
+	c, gc, err := d.addChartPart(data)
+	if err != nil {
+		return uchart.Chart{}, AnchoredDrawing{}, err
+	}
+	anchor := newGraphicAnchor(chartGraphicDataURI, gc, w, h)
+	ic := r.newIC()
+	ic.RunInnerContentChoice.Drawing = wml.NewCT_Drawing()
+	ic.RunInnerContentChoice.Drawing.DrawingChoice = append(ic.RunInnerContentChoice.Drawing.DrawingChoice, &wml.CT_DrawingChoice{Anchor: anchor})
+	return c, AnchoredDrawing{d, anchor}, nil
+}
+
+// addChartPart creates a new chart part along with its embedded workbook and
+// returns the chart and the graphic frame content referencing it.
+func (d *Document) addChartPart(data ChartData) (uchart.Chart, *crt.Chart, error) {
+	for _, s := range data.Series {
+		if len(s.Values) != len(data.Categories) {
+			return uchart.Chart{}, nil, errors.New("chart series must have one value per category")
+		}
+	}
+	idx := len(d._dbg) + 1
+	xlsxPath := d.freeExtraFilePath("word/embeddings/Microsoft_Excel_Worksheet%d.xlsx", idx)
+	xlsx, err := writeTempFile("chart-data-", func(f tempstorage.File) error {
+		wb := data.workbook()
+		defer wb.Close()
+		return wb.Save(f)
+	})
+	if err != nil {
+		return uchart.Chart{}, nil, err
+	}
+
+	cs := crt.NewChartSpace()
+	chartRels := common.NewRelationships()
+	rel := chartRels.AddRelationship("../embeddings/"+xlsxPath[len("word/embeddings/"):], packageRelationshipType)
+	cs.ExternalData = crt.NewCT_ExternalData()
+	cs.ExternalData.IdAttr = rel.ID()
+	cs.ExternalData.AutoUpdate = crt.NewCT_Boolean()
+	cs.ExternalData.AutoUpdate.ValAttr = unioffice.Bool(false)
+
+	target := unioffice.RelativeFilename(unioffice.DocTypeDocument, unioffice.OfficeDocumentType, unioffice.ChartType, idx)
+	partPath := unioffice.AbsoluteFilename(unioffice.DocTypeDocument, unioffice.ChartType, idx)
+	rels, err := writeTempFile("chart-rels-", func(f tempstorage.File) error {
+		if _, err := f.Write([]byte(zippkg.XMLHeader)); err != nil {
+			return err
+		}
+		return xml.NewEncoder(f).Encode(chartRels.X())
+	})
+	if err != nil {
+		return uchart.Chart{}, nil, err
+	}
+	d.ExtraFiles = append(d.ExtraFiles,
+		common.ExtraFile{ZipPath: xlsxPath, StoragePath: xlsx},
+		common.ExtraFile{ZipPath: zippkg.RelationsPathFor(partPath), StoragePath: rels})
+	d.ContentTypes.AddDefault("xlsx", xlsxContentType)
+	d.ContentTypes.AddOverride(partPath, unioffice.ChartContentType)
+
+	docRel := d._ead.AddRelationship(target, unioffice.ChartType)
+	d._dbg = append(d._dbg, &chart{_agcb: cs, _bae: docRel.ID(), _bd: target})
+
+	gc := crt.NewChart()
+	gc.IdAttr = docRel.ID()
+	c := uchart.MakeChart(cs)
+	c.Properties().SetSolidFill(color.White)
+	c.SetDisplayBlanksAs(crt.ST_DispBlanksAsGap)
+	return c, gc, nil
+}
+
+// freeExtraFilePath returns the first path generated from the format and an
+// index starting at idx that is not used by an extra file.
+func (d *Document) freeExtraFilePath(format string, idx int) string {
+	for {
+		path := fmt.Sprintf(format, idx)
+		used := false
+		for _, ef := range d.ExtraFiles {
+			if ef.ZipPath == path {
+				used = true
+				break
+			}
+		}
+		if !used {
+			return path
+		}
+		idx++
+	}
+}
+
+// writeTempFile writes a file to temporary storage and returns its path.
+func writeTempFile(pattern string, write func(f tempstorage.File) error) (string, error) {
+	f, err := tempstorage.TempFile("", pattern)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := write(f); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,105 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/common/tempstorage"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/spreadsheet"
+)
+
+func TestAddChart(t *testing.T) {
+	data := ChartData{
+		Categories: []string{"Q1", "Q2", "Q3"},
+		Series: []ChartSeriesData{
+			{Name: "North", Values: []float64{1, 2, 3}},
+			{Name: "South", Values: []float64{4, 5.5, 6}},
+		},
+	}
+	if got := data.CategoryReference(); got != "Sheet1!$A$2:$A$4" {
+		t.Errorf("category reference = %s", got)
+	}
+	if got := data.SeriesReference(1); got != "Sheet1!$C$2:$C$4" {
+		t.Errorf("series reference = %s", got)
+	}
+
+	d := New()
+	c, _, err := d.AddChart(d.AddParagraph().AddRun(), data, 5*measurement.Inch, 3*measurement.Inch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bar := c.AddBarChart()
+	for i := range data.Series {
+		if err := data.BindSeries(i, bar.AddSeries()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := data.BindSeries(2, bar.AddSeries()); err == nil {
+		t.Error("bound a series out of range")
+	}
+	if _, _, err := d.AddChartAnchored(d.AddParagraph().AddRun(), data, 5*measurement.Inch, 3*measurement.Inch); err != nil {
+		t.Fatal(err)
+	}
+	bad := ChartData{Categories: []string{"Q1"}, Series: []ChartSeriesData{{Name: "North", Values: []float64{1, 2}}}}
+	if _, _, err := d.AddChart(d.AddParagraph().AddRun(), bad, measurement.Inch, measurement.Inch); err == nil {
+		t.Error("added a chart with a value missing a category")
+	}
+
+	// each chart has its own data workbook
+	paths := map[string]string{}
+	for _, ef := range d.ExtraFiles {
+		paths[ef.ZipPath] = ef.StoragePath
+	}
+	for _, p := range []string{"word/embeddings/Microsoft_Excel_Worksheet1.xlsx", "word/embeddings/Microsoft_Excel_Worksheet2.xlsx",
+		"word/charts/_rels/chart1.xml.rels", "word/charts/_rels/chart2.xml.rels"} {
+		if paths[p] == "" {
+			t.Errorf("%s wasn't added", p)
+		}
+	}
+	f, err := tempstorage.Open(paths["word/embeddings/Microsoft_Excel_Worksheet1.xlsx"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wb, err := spreadsheet.Read(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wb.Close()
+	sheet, err := wb.GetSheet("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ref, want := range map[string]string{"A1": "", "B1": "North", "C1": "South", "A3": "Q2", "C3": "5.5"} {
+		if got := sheet.Cell(ref).GetString(); got != want {
+			t.Errorf("%s = %q, want %q", ref, got, want)
+		}
+	}
+
+	read := roundTrip(t, d)
+	if len(read._dbg) != 2 {
+		t.Fatalf("read %d charts, want 2", len(read._dbg))
+	}
+	ser := read._dbg[0].X().Chart.PlotArea.PlotAreaChoice[0].BarChart.Ser
+	if len(ser) != 3 || ser[1].Val.NumDataSourceChoice.NumRef.F != data.SeriesReference(1) {
+		t.Errorf("read %d series", len(ser))
+	}
+	if read._dbg[0].X().ExternalData == nil {
+		t.Error("chart lost its data workbook")
+	}
+}
@@ -21,7 +21,7 @@ if _gaccc !=nil {return nil ,_gaccc ;};_dcfcb ,_gaccc :=_ef .Open (_aefg .Path )
 if _gaccc !=nil {return nil ,_gaccc ;};if _gb .DefaultImageEncoder !=nil {_geab .SetEncoder (_gb .DefaultImageEncoder );}else {_geab .SetEncoder (_dg .NewFlateEncoder ());if _ba .ToLower (_aefg .Format )=="\u006a\u0070\u0067"||_ba .ToLower (_aefg .Format )=="\u006a\u0070\u0065\u0067"{_geab .SetEncoder (_dg .NewDCTEncoder ());
 };};return _geab ,nil ;};return nil ,nil ;};func (_cgd *convertContext )addAbsoluteCBCs (_edfa []*_gee .EG_ContentBlockContent ,_dab []*_gee .EG_ContentBlockContent ){_dfg :="";_cdg :=false ;for _dbda :=range _de .Iterate (_dab ){if len (_dbda .P )< 1{_cdg =true ;
 break ;};for _ ,_baeg :=range _dbda .P {if len (_baeg .EG_PContent )==0{break ;};if _baeg .PPr !=nil &&_baeg .PPr .PStyle !=nil {_dfg =_baeg .PPr .PStyle .ValAttr ;break ;};};};for _abde :=range _de .Iterate (_edfa ){for _ ,_abc :=range _abde .P {_cgd .addTableGroup ();
-_cgd .newParagraph ();for _ ,_bgb :=range _abc .EG_PContent {for _ ,_ddf :=range _bgb .PContentChoice .EG_ContentRunContent {for _ ,_fcf :=range _ddf .ContentRunContentChoice .EG_RunLevelElts {for _ ,_edc :=range _fcf .RunLevelEltsChoice .EG_RangeMarkupElements {if _edc .RangeMarkupElementsChoice .BookmarkStart !=nil {_bgca :=_cb .NewPdfAnnotationLink ();
+_cgd .newParagraph ();_cgd ._debf ._fegce =_abc ;for _ ,_bgb :=range _abc .EG_PContent {for _ ,_ddf :=range _bgb .PContentChoice .EG_ContentRunContent {for _ ,_fcf :=range _ddf .ContentRunContentChoice .EG_RunLevelElts {for _ ,_edc :=range _fcf .RunLevelEltsChoice .EG_RangeMarkupElements {if _edc .RangeMarkupElementsChoice .BookmarkStart !=nil {_bgca :=_cb .NewPdfAnnotationLink ();
 _gcf :=_cb .NewBorderStyle ();_gcf .SetBorderWidth (0);_bgca .BS =_gcf .ToPdfObject ();_bgca .Dest =_dg .MakeArray (_dg .MakeInteger (int64 (len (_cgd ._gbdfa )-1)),_dg .MakeName ("\u0058\u0059\u005a"),_dg .MakeFloat (_cgd ._gged ._efaa ),_dg .MakeFloat (_cgd ._gged ._gdd ),_dg .MakeFloat (0));
 _cgd ._ddfe [_edc .RangeMarkupElementsChoice .BookmarkStart .NameAttr ]=_bgca .PdfAnnotation ;};};};};};if _abc .PPr !=nil &&_abc .PPr .PStyle ==nil {_eaa :=_cgd ._gbgdc .Styles .ParagraphStyles ();for _ ,_abe :=range _eaa {if _dgaa :=_abe .X ().DefaultAttr ;
 _dgaa !=nil {if _ebff :=_dgaa .Bool ;_ebff !=nil &&*_ebff {_abc .PPr =_gcae (_abc .PPr ,_abe .X ().PPr ,_abe .X ().RPr );};if _gac :=_dgaa .ST_OnOff1 ;_gac ==_bc .ST_OnOff1On {_abc .PPr =_gcae (_abc .PPr ,_abe .X ().PPr ,_abe .X ().RPr );};break ;};};};
@@ -46,13 +46,13 @@ _bggg ._debf ._ccc +=_gfad ._fe .Height ();_cgg =_bdge (_dabe ,"",true ,false ,f
 };}else if _afdc :=_agcdd .RunInnerContentChoice .Drawing ;_afdc !=nil {for _ ,_aadc :=range _afdc .DrawingChoice {if _aadc .Inline ==nil {continue ;};_ebg :=_aadc .Inline ;if _ffefg :=_ebg .Graphic ;_ffefg !=nil {if _dfcb :=_ffefg .GraphicData ;_dfcb !=nil {_deea :=_ebg .Extent ;
 if _deea ==nil {return false ;};_dced :=_ag .FromEMU (_deea .CxAttr );_abec :=_ag .FromEMU (_deea .CyAttr );if _fceb :=_ebg .EffectExtent ;_fceb !=nil {if _fceb .LAttr .ST_CoordinateUnqualified !=nil {_dced +=_ag .FromEMU (*_fceb .LAttr .ST_CoordinateUnqualified );
 };if _fceb .RAttr .ST_CoordinateUnqualified !=nil {_dced +=_ag .FromEMU (*_fceb .RAttr .ST_CoordinateUnqualified );};if _fceb .TAttr .ST_CoordinateUnqualified !=nil {_abec +=_ag .FromEMU (*_fceb .TAttr .ST_CoordinateUnqualified );};if _fceb .BAttr .ST_CoordinateUnqualified !=nil {_abec +=_ag .FromEMU (*_fceb .BAttr .ST_CoordinateUnqualified );
-};};for _ ,_ebbba :=range _dfcb .Any {if _ddb ,_bbe :=_ebbba .(*_cc .Pic );_bbe {_acc :=&symbol {_bae :_abec ,_bfb :_dced };_cfbb ,_accg :=_bggg .makePdfImageFromGraphics (_ddb );if _accg !=nil {_fge .Log .Debug ("C\u0061\u006e\u006e\u006ft \u0072e\u0061\u0064\u0020\u0069\u006da\u0067\u0065\u003a\u0020\u0025\u0073",_accg );
+};};for _ ,_ebbba :=range _dfcb .Any {if _ddb ,_bbe :=_ebbba .(*_cc .Pic );_bbe {_acc :=&symbol {_bae :_abec ,_bfb :_dced };_acc ._cfgda =inlineAltText (_ebg );_cfbb ,_accg :=_bggg .makePdfImageFromGraphics (_ddb );if _accg !=nil {_fge .Log .Debug ("C\u0061\u006e\u006e\u006ft \u0072e\u0061\u0064\u0020\u0069\u006da\u0067\u0065\u003a\u0020\u0025\u0073",_accg );
 };if _cfbb ==nil {_acc ._fa ="\u0020";}else {_dcge :=_ddb .BlipFill ;if _dcge .SrcRect !=nil {var _ddgb ,_dece ,_ggfd ,_gfgf float64 ;_egb :=_dcge .SrcRect ;if _egb .LAttr !=nil {_ddgb =float64 (*_egb .LAttr .ST_PercentageDecimal )/1000.0;};if _egb .RAttr !=nil {_ggfd =float64 (*_egb .RAttr .ST_PercentageDecimal )/1000.0;
 };if _egb .TAttr !=nil {_dece =float64 (*_egb .TAttr .ST_PercentageDecimal )/1000.0;};if _egb .BAttr !=nil {_gfgf =float64 (*_dcge .SrcRect .BAttr .ST_PercentageDecimal )/1000.0;};_bebg :=_cfbb .Width ();_baec :=_cfbb .Height ();_cfbb .Crop (int (_ddgb /100.0*_bebg ),int (_dece /100.0*_baec ),int (_bebg -(_ggfd /100.0*_bebg )),int (_baec -(_gfgf /100.0*_baec )));
 };_fbfe :=false ;if _ddb .SpPr !=nil &&_ddb .SpPr .Xfrm !=nil {if _ddb .SpPr .Xfrm .RotAttr !=nil {_gdeg :=_ag .DegreeFromSTAngle (*_ddb .SpPr .Xfrm .RotAttr );_cfbb .SetAngle (_gdeg );};if _ddb .SpPr .Xfrm .Ext !=nil {_fbfe =true ;};};if _fbfe {_cfbb .ScaleToWidth (_dced );
 }else {_cfbb .Scale (_dced /_cfbb .Width (),_dced /_cfbb .Width ());};_acc ._gaa =_cfbb ;_aaga =true ;};_cgg =[]*symbol {_acc };}else if _efe ,_cff :=_ebbba .(*_cab .Chart );_cff {_edg :=&symbol {_bae :_abec ,_bfb :_dced };_dbeb ,_efbe :=_bggg .makePdfBlockFromChart (_efe ,_dced ,_abec );
 if _efbe !=nil {_fge .Log .Debug ("C\u0061\u006e\u006e\u006ft \u0072e\u0061\u0064\u0020\u0062\u006co\u0063\u006b\u003a\u0020\u0025\u0073",_efbe );};if _dbeb ==nil {_edg ._fa ="\u0020";}else {_edg ._cbdg =&block {_fedg :_dbeb };_aaga =true ;};_cgg =[]*symbol {_edg };
-};};};};};}else if _bcab :=_agcdd .RunInnerContentChoice .Pict ;_bcab !=nil {for _ ,_afgg :=range _bcab .Any {if _fbda ,_gdegb :=_afgg .(*_bf .Group );_gdegb {for _ ,_aec :=range _fbda .GroupChoice {if _aec .Rect !=nil {_bggg .addRect (_aec .Rect );}else if _aec .Shape !=nil {_bbec :=_aec .Shape ;
+}else if diagramBlock ,ok :=_bggg .makePdfBlockFromDiagram (_ebbba ,_dced ,_abec );ok {diagramSymbol :=&symbol {_bae :_abec ,_bfb :_dced ,_cbdg :&block {_fedg :diagramBlock }};_aaga =true ;_cgg =[]*symbol {diagramSymbol };};};};};};}else if _bcab :=_agcdd .RunInnerContentChoice .Pict ;_bcab !=nil {for _ ,_afgg :=range _bcab .Any {if _fbda ,_gdegb :=_afgg .(*_bf .Group );_gdegb {for _ ,_aec :=range _fbda .GroupChoice {if _aec .Rect !=nil {_bggg .addRect (_aec .Rect );}else if _aec .Shape !=nil {_bbec :=_aec .Shape ;
 _cfab :=_af .NewShapeStyle ("");if _bbec .StyleAttr !=nil {_cfab =_af .NewShapeStyle (*_bbec .StyleAttr );};_dfeb :=_gb .PointsFromTwips (int64 (_cfab .Width ()));_efcaa :=_gb .PointsFromTwips (int64 (_cfab .Height ()));_dgab :=_gb .PointsFromTwips (int64 (_cfab .Left ()-_cfab .Right ()));
 _bfga :=_gb .PointsFromTwips (int64 (_cfab .Top ()-_cfab .Bottom ()));for _ ,_fgg :=range _bbec .ShapeChoice {if _fgg .ShapeElementsChoice !=nil {_aea :=_fgg .ShapeElementsChoice ;if _aea .Imagedata !=nil {_abfc :=&symbol {_bae :_dfeb ,_bfb :_efcaa };_ebdgc ,_bbg :=_bggg .makePdfImageFromRelId (_aea .Imagedata .IdAttr );
 if _bbg !=nil {_fge .Log .Debug ("C\u0061\u006e\u006e\u006ft \u0072e\u0061\u0064\u0020\u0069\u006da\u0067\u0065\u003a\u0020\u0025\u0073",_bbg );};if _ebdgc ==nil {_abfc ._fa ="\u0020";}else {_ebdgc .Scale (_dfeb /_ebdgc .Width (),_efcaa /_ebdgc .Height ());
@@ -94,7 +94,7 @@ if _geg > _ddaf ._debf ._db {_ddaf ._debf ._db =_geg ;};if !_edbg .AllowOverlapA
 };_ebdg :=false ;_bgdad :=0.0;if _cgbbe .SpPr !=nil &&_cgbbe .SpPr .Xfrm !=nil {if _cgbbe .SpPr .Xfrm .RotAttr !=nil {_bgdad =_ag .DegreeFromSTAngle (*_cgbbe .SpPr .Xfrm .RotAttr );};if _aag :=_cgbbe .SpPr .Xfrm .Ext ;_aag !=nil {_ebdg =true ;};};if _edcg !=nil {if !_ebdg {_edcg .Scale (_ffcec /_edcg .Width (),_bcba /_edcg .Height ());
 }else {_edcg .ScaleToWidth (_ffcec );};_edcg .SetAngle (_bgdad );_eeff :=&image {_gede :_edcg ,_ebf :_aee ,_eba :_ddae };if _edbg .BehindDocAttr {_ddaf ._debf ._agd =append (_ddaf ._debf ._agd ,_eeff );}else {_ddaf ._debf ._ffc =append (_ddaf ._debf ._ffc ,_eeff );
 };};}else if _egag ,_gge :=_cgbb .(*_cab .Chart );_gge {_cabe ,_dfba :=_ddaf .makePdfBlockFromChart (_egag ,_ffcec ,_bcba );if _dfba !=nil {_fge .Log .Debug ("C\u0061\u006e\u006e\u006ft \u0072e\u0061\u0064\u0020\u0062\u006co\u0063\u006b\u003a\u0020\u0025\u0073",_dfba );
-};if _cabe !=nil {_dba :=&block {_fedg :_cabe ,_bag :_aee ,_eca :_ddae };if _edbg .BehindDocAttr {_ddaf ._debf ._bgd =append (_ddaf ._debf ._bgd ,_dba );}else {_ddaf ._debf ._eec =append (_ddaf ._debf ._eec ,_dba );};};};};};};};};};};};};};var _afddg =_gcad (2.5);
+};if _cabe !=nil {_dba :=&block {_fedg :_cabe ,_bag :_aee ,_eca :_ddae };if _edbg .BehindDocAttr {_ddaf ._debf ._bgd =append (_ddaf ._debf ._bgd ,_dba );}else {_ddaf ._debf ._eec =append (_ddaf ._debf ._eec ,_dba );};};}else if diagramBlock ,ok :=_ddaf .makePdfBlockFromDiagram (_cgbb ,_ffcec ,_bcba );ok {b :=&block {_fedg :diagramBlock ,_bag :_aee ,_eca :_ddae };if _edbg .BehindDocAttr {_ddaf ._debf ._bgd =append (_ddaf ._debf ._bgd ,b );}else {_ddaf ._debf ._eec =append (_ddaf ._debf ._eec ,b );};};};};};};};};};};};};var _afddg =_gcad (2.5);
 func (_gcaf *convertContext )getSectPrHeaderAndFooterRef (_eddb *_gee .CT_SectPr ,_egeg int )([]*headerFooterRef ,[]*headerFooterRef ){var (_cgdce []*headerFooterRef ;_bbgb []*headerFooterRef ;);_gfc :=false ;if _eddb .TitlePg !=nil {if _eddb .TitlePg .ValAttr ==nil {_gfc =true ;
 }else {_eaaec :=_eddb .TitlePg .ValAttr ;if _eaaec .Bool !=nil {_gfc =*_eaaec .Bool ;}else if _eaaec .ST_OnOff1 !=_bc .ST_OnOff1Unset {_gfc =(_eaaec .ST_OnOff1 ==_bc .ST_OnOff1On );};};};_bafd :=0;_eggg :=-1;if _afdb :=len (_gcaf ._gcbc )-1;_afdb >=0{_abbd :=_gcaf ._gcbc [_afdb ]._ecbfb ;
 if _abbd !=-1{_eggg =_abbd ;_bafd =_abbd ;};};for _ ,_becc :=range _eddb .EG_HdrFtrReferences {if _becc .HdrFtrReferencesChoice ==nil {continue ;};if _gfbcc :=_becc .HdrFtrReferencesChoice .HeaderReference ;_gfbcc !=nil {_fdaefa :=&headerFooterRef {_efde :true ,_gcfdd :_gfbcc .IdAttr ,_cddc :_gfbcc .TypeAttr ,_bccf :_bafd ,_ecbfb :_egeg ,_dbfg :_gfc };
@@ -181,7 +181,7 @@ if _aacbf .Kinsoku ==nil {_aacbf .Kinsoku =_acec .Kinsoku ;};if _aacbf .WordWrap
 };if _aacbf .CnfStyle ==nil {_aacbf .CnfStyle =_acec .CnfStyle ;};if _aacbf .PPrChange ==nil {_aacbf .PPrChange =_acec .PPrChange ;};return _aacbf ;};func (_ccce *convertContext )getStyleProps (_badg string ,_cedd _dd .Style )(*_gee .CT_PPrGeneral ,*_gee .CT_RPr ){var _eadbg *_gee .CT_PPrGeneral ;
 var _gfcf *_gee .CT_RPr ;_edae :=_ccce ._gbgdc .GetStyleByID (_badg );_gbdc :=int64 (0);_gegc :=true ;if _bccb :=_edae .X ();_bccb !=nil {_eadbg =_bccb .PPr ;_gfcf =_bccb .RPr ;if _bccb .UiPriority !=nil {_gbdc =_bccb .UiPriority .ValAttr ;};if _dffe :=_bccb .BasedOn ;
 _dffe !=nil {_eefba ,_cade :=_ccce .getStyleProps (_dffe .ValAttr ,_edae );if _fadf :=_cedd .X ();_fadf !=nil {if _fadf .UiPriority !=nil &&_gbdc > 0{if _bccb .UiPriority .ValAttr > _gbdc {_gegc =false ;};};if _fadf .QFormat !=nil &&_bccb .QFormat !=nil &&_cccbbf (_fadf .QFormat )&&_cccbbf (_bccb .QFormat ){_gegc =false ;
-};};if _gegc {_eadbg =_abebf (_eadbg ,_eefba );_gfcf =_bgfe (_gfcf ,_cade );};};};return _eadbg ,_gfcf ;};func (_gfg *convertContext )addAbsoluteCRC (_cdcf []*_gee .EG_ContentRunContent ,_cgcf *_gee .CT_PPr )bool {for _ ,_ccbb :=range _cdcf {if _gdge :=_ccbb .ContentRunContentChoice .R ;
+};};if _gegc {_eadbg =_abebf (_eadbg ,_eefba );_gfcf =_bgfe (_gfcf ,_cade );};};};return _eadbg ,_gfcf ;};func (_gfg *convertContext )addAbsoluteCRC (_cdcf []*_gee .EG_ContentRunContent ,_cgcf *_gee .CT_PPr )bool {for _ ,_ccbb :=range _cdcf {if _gfg .addAbsoluteMath (_ccbb ,_cgcf ){return true ;};if _gdge :=_ccbb .ContentRunContentChoice .R ;
 _gdge !=nil {if _cgcf !=nil &&_cgcf .PStyle !=nil {_egd :=_gfg ._gbgdc .GetStyleByID (_cgcf .PStyle .ValAttr );if _edb :=_egd .X ();_edb !=nil {if _edb .QFormat !=nil &&_cccbbf (_edb .QFormat ){if _edb .RPr !=nil &&_cgcf .RPr !=nil {_cgcf .RPr =_dagb (_cgcf .RPr ,_edb .RPr );
 };};if _edb .RPr !=nil {if _edb .UiPriority !=nil &&_edb .UiPriority .ValAttr > 0&&_gdge .RPr ==nil {_cgcf .RPr =_dagb (_cgcf .RPr ,_edb .RPr );};_gdge .RPr =_bgfe (_gdge .RPr ,_edb .RPr );};if _gfg ._baefb !=nil {_ecb ,_bfd :=_gfg .getStyleProps (_cgcf .PStyle .ValAttr ,_egd );
 _cgcf =_gcae (_cgcf ,_ecb ,_bfd );_gdge .RPr =_bgfe (_gdge .RPr ,_bfd );};};};_cae :=_cgcf !=nil ||_gdge .RPr !=nil ;if len (_gdge .EG_RunInnerContent )==0&&_cae {_gfg .addEmptyLine ();};_cca :=_afeaf (_gfg ._gbgdc ,_gdge .RPr ,_cgcf );if _gfg ._baefb !=nil {_gfg .addAbsoluteRIC (nil ,_cca ,_cgcf );
@@ -189,7 +189,7 @@ _gfg ._baefb =nil ;_gfg ._debf ._gdf =true ;};for _ ,_febb :=range _gdge .EG_Run
 _dcga {if _cge :=_cad .Choice ;_cge !=nil {if _ade :=_cge .Drawing ;_ade !=nil {for _ ,_afa :=range _ade .DrawingChoice {if _afa .Inline ==nil {continue ;};_ebaf :=_afa .Inline ;_bbfa :=_ebaf .Extent ;if _bbfa ==nil {return false ;};_eeg :=_ag .FromEMU (_bbfa .CxAttr );
 _cdge :=_ag .FromEMU (_bbfa .CyAttr );if _dfcg :=_ebaf .Graphic ;_dfcg !=nil {if _egad :=_dfcg .GraphicData ;_egad !=nil {for _ ,_cbg :=range _egad .Any {if _ebce ,_ggdg :=_cbg .(*_gee .WdWsp );_ggdg {_fdff ,_dcfe :=_gfg .makeBlockFromWdWsp (_ebce );if _dcfe !=nil {_fge .Log .Debug ("C\u0061\u006e\u006e\u006ft \u0072e\u0061\u0064\u0020\u0062\u006co\u0063\u006b\u003a\u0020\u0025\u0073",_dcfe );
 };if _fdff ==nil {continue ;};_fdff ._fedg .Scale (_eeg /_fdff ._fedg .Width (),_cdge /_fdff ._fedg .Height ());_gfg .addInlineSymbol (&symbol {_bae :_cdge ,_bfb :_eeg ,_cbdg :_fdff });};};};};};};};};};};};return false ;};type line struct{_gdd float64 ;
-_efaa float64 ;_eb float64 ;_cf float64 ;_abd float64 ;_efda []*span ;_gga bool ;};func (_bfcf *convertContext )makeBlockFromTextboxContent (_gdfa *_gee .TxbxContent ,_adbd ,_fggd float64 ,_eebd *_gb .Rectangle )(*block ,error ){if _eebd ==nil {_eebd =&_gb .Rectangle {};
+_efaa float64 ;_eb float64 ;_cf float64 ;_abd float64 ;_efda []*span ;_gga bool ;_bcgeh bool ;};func (_bfcf *convertContext )makeBlockFromTextboxContent (_gdfa *_gee .TxbxContent ,_adbd ,_fggd float64 ,_eebd *_gb .Rectangle )(*block ,error ){if _eebd ==nil {_eebd =&_gb .Rectangle {};
 };for _ ,_ffec :=range _gdfa .EG_BlockLevelElts {if _aced :=_ffec .BlockLevelEltsChoice .EG_ContentBlockContent ;len (_aced )> 0{_dgce ,_gddfg :=_bfcf .makePdfBlockFromCBCs ([][]*_gee .EG_ContentBlockContent {_aced },_adbd ,_fggd ,_eebd ,false ,nil );if _gddfg !=nil {return nil ,_gddfg ;
 };_ddgf :=&block {_fedg :_dgce ,_fcab :false ,_ggb :0,_fae :_ca .ColorBlack };return _ddgf ,nil ;};};return nil ,nil ;};func _cdedd (_efcaf []*headerFooterRef )map[sectionKey ]*sectionRefs {_fbfda :=make (map[sectionKey ]*sectionRefs );for _ ,_ecfe :=range _efcaf {_cafa :=sectionKey {_bfge :_ecfe ._bccf ,_aebfd :_ecfe ._ecbfb };
 if _fbfda [_cafa ]==nil {_fbfda [_cafa ]=&sectionRefs {};};switch _ecfe ._cddc {case _gee .ST_HdrFtrFirst :_fbfda [_cafa ]._agfc =_ecfe ;case _gee .ST_HdrFtrEven :_fbfda [_cafa ]._cdfd =_ecfe ;case _gee .ST_HdrFtrDefault :_fbfda [_cafa ]._faac =_ecfe ;
@@ -232,8 +232,8 @@ _ccdb !=nil {_aagg =float64 (*_ccdb )*_ag .Pixel72 ;};var _bcdgg _ca .Color ;if
 _eebeb !=nil {_bcdgg =_ca .ColorRGBFromHex ("\u0023"+*_eebeb );};};if _geda ==_gb .BorderPositionBottom ||_geda ==_gb .BorderPositionTop {_cabeb :=&borderLine {_fffd :_geda ,_aa :_fgbf ._cgbbg ._ggd .Right -_fgbf ._cgbbg ._ggd .Left ,_fag :_dfebe ,_ddab :_bcdgg ,_ffg :_aagg };
 _efgg ._bcf =append (_efgg ._bcf ,_cabeb );}else {_afggb :=&borderLine {_fffd :_geda ,_aa :_dfebe ,_fag :_fgbf ._cgbbg ._ggd .Top -_fgbf ._cgbbg ._ggd .Bottom ,_ddab :_bcdgg ,_ffg :_aagg };_efgg ._bcf =append (_efgg ._bcf ,_afggb );};};func (_affcf *convertContext )drawHeaderFooter (){if _affcf ._affcc ==nil {return ;
 };_affcf .setPagesHeaderFooterRefs ();_affcf ._affcc .PageFinalize (func (_fbbg _ca .PageFinalizeFunctionArgs )error {_ebgac :=_affcf ._gbdfa [_fbbg .PageNum -1];_affcf ._ecbe =_fbbg .PageNum ;_affcf ._accgf =_fbbg .TotalPages ;_affcf ._cgbbg =_ebgac ;
-_affcf ._cgbbg ._ee =nil ;_affcf ._cgbbg ._dgb =nil ;_affcf .assignHeaderFooterToPage (_ebgac );_ccabg :=_ca .NewBlock (_affcf ._ccgaf [0],_affcf ._cgddf );_ccabg .SetPos (0,0);_ccabg .SetMargins (0,0,0,0);_edcf (_affcf ._affcc ,_ccabg ,_affcf ._cgbbg ._ee ,_affcf ._gbcaa ,_fbbg );
-_fbed :=_ca .NewBlock (_affcf ._ccgaf [0],_affcf ._feadd );_fbed .SetPos (0,0);_fbed .SetMargins (0,0,0,0);_edcf (_affcf ._affcc ,_fbed ,_affcf ._cgbbg ._dgb ,_affcf ._dbce ,_fbbg );_affcf ._affcc .Draw (_ccabg );_affcf ._affcc .Draw (_fbed );return nil ;
+_affcf ._cgbbg ._ee =nil ;_affcf ._cgbbg ._dgb =nil ;_affcf .assignHeaderFooterToPage (_ebgac );_ccabg :=_ca .NewBlock (_affcf ._ccgaf [0],_affcf ._cgddf );_ccabg .SetPos (0,0);_ccabg .SetMargins (0,0,0,0);_edcf (_affcf ._affcc ,_ccabg ,_affcf ._cgbbg ._ee ,_affcf ._gbcaa ,_fbbg ,_affcf .artifactSubtype (_cb .ArtifactSubtypeHeader ));
+_fbed :=_ca .NewBlock (_affcf ._ccgaf [0],_affcf ._feadd );_fbed .SetPos (0,0);_fbed .SetMargins (0,0,0,0);_edcf (_affcf ._affcc ,_fbed ,_affcf ._cgbbg ._dgb ,_affcf ._dbce ,_fbbg ,_affcf .artifactSubtype (_cb .ArtifactSubtypeFooter ));_affcf .drawArtifact (_ccabg );_affcf .drawArtifact (_fbed );return nil ;
 });};func (_deda *convertContext )addCurrentParagraphHeaderToCurrentPage (){_deda .alignParagraph ();_deda ._cgbbg ._ee =append (_deda ._cgbbg ._ee ,_deda ._debf );};func _cdaa (_fbbea *_dd .Document ,_bdeg *_gee .CT_TblPr )(*_gee .CT_TblPr ,*_gee .CT_PPrGeneral ,*_gee .CT_RPr ){_gaaf :=_gee .NewCT_PPrGeneral ();
 _gabfc :=_gee .NewCT_RPr ();if _bdeg ==nil {_bdeg =_gee .NewCT_TblPr ();}else {if _bdeg .TblStyle !=nil {_bdeg ,_gaaf ,_gabfc =_begf (_fbbea ,_bdeg .TblStyle .ValAttr ,_bdeg ,_gaaf ,_gabfc );};};return _bdeg ,_gaaf ,_gabfc ;};func (_ccag *convertContext )addEmptyLine (){_ccag .addTextSymbol (&symbol {_fa :"\u000d",_bfb :0,_bae :_ccag ._debf ._dfe });
 };func (_eced *convertContext )autofitColumns (_ebgd *_ca .Table ,_daef float64 ,_gbgd []float64 ,_dcgd []float64 ){_feac :=0.0;for _ ,_daec :=range _gbgd {_feac +=_daec ;};if _feac <=0||_daef <=0||len (_gbgd )!=len (_dcgd ){return ;};_ebfe :=make ([]float64 ,len (_gbgd ));
@@ -290,13 +290,18 @@ RtlFontFile string ;
 // Default value is nil, which will use the best suitable encoder based on image format.
 // If image is `jpg` or `jpeg` will use `DCTEncoder` if image is `png` or in other format will use `FlateEncoder`.
 // Available options are `FlateEncoder`, `DCTEncoder`, `LZWEncoder`, `JBIG2Encoder`, `CCITTFaxEncoder`, and `RawEncoder`.
-DefaultImageEncoder _dg .StreamEncoder ;};func _agdd (_cgddd string )(string ,string ){_egea :=_ffba .FindStringSubmatch (_cgddd );if len (_egea )< 3{return "","";};return _egea [1],_egea [2];};func _effe (_dbac *_dd .Document )map[string ]string {_cdgd :=[]_dd .Paragraph {};
+DefaultImageEncoder _dg .StreamEncoder ;
+
+// TaggedPDF produces a tagged PDF for accessibility, marking up headings,
+// lists, tables and figures with their alternative text in the structure tree
+// and setting the document language, default is `false`.
+TaggedPDF bool ;};func _agdd (_cgddd string )(string ,string ){_egea :=_ffba .FindStringSubmatch (_cgddd );if len (_egea )< 3{return "","";};return _egea [1],_egea [2];};func _effe (_dbac *_dd .Document )map[string ]string {_cdgd :=[]_dd .Paragraph {};
 _bded :=map[string ]string {};for _ ,_ddbfg :=range _dbac .Tables (){for _ ,_cfcfb :=range _ddbfg .Rows (){for _ ,_cgccc :=range _cfcfb .Cells (){_cdgd =append (_cdgd ,_cgccc .Paragraphs ()...);};};};_cdgd =append (_cdgd ,_dbac .Paragraphs ()...);for _ ,_ebcee :=range _dbac .Headers (){_cdgd =append (_cdgd ,_ebcee .Paragraphs ()...);
 for _ ,_cgfbc :=range _ebcee .Tables (){for _ ,_cfcg :=range _cgfbc .Rows (){for _ ,_fgdc :=range _cfcg .Cells (){_cdgd =append (_cdgd ,_fgdc .Paragraphs ()...);};};};};for _ ,_fabc :=range _dbac .Footers (){_cdgd =append (_cdgd ,_fabc .Paragraphs ()...);
 for _ ,_ddafa :=range _fabc .Tables (){for _ ,_aecff :=range _ddafa .Rows (){for _ ,_cada :=range _aecff .Cells (){_cdgd =append (_cdgd ,_cada .Paragraphs ()...);};};};};for _ ,_cgbg :=range _cdgd {for _ ,_adef :=range _cgbg .Runs (){for _ ,_gebbd :=range _adef .X ().EG_RunInnerContent {if _cdafa :=_gebbd .RunInnerContentChoice .InstrText ;
 _cdafa !=nil {_bccd ,_daeaa :=_agdd (_cdafa .Content );if _bccd !=""&&_daeaa !=""{_bded [_bccd ]=_daeaa ;};};};};};return _bded ;};func (_deag *convertContext )newLine (){if _deag ._debf ==nil {_deag .newParagraph ();};_eacc :=_deag ._debf ._dcd +_deag ._debf ._be .Top ;
 _gcfd :=&line {};if len (_deag ._debf ._dcde )==0{_gcfd ._efaa =_deag ._debf ._ad ;}else {_gcfd ._efaa =_deag ._debf ._beb ;};_gcfd ._eb =_deag ._debf ._agec ;_gcfd ._cf =_gcfd ._efaa ;_gcfd ._gdd =_eacc ;_deag ._debf ._dcde =append (_deag ._debf ._dcde ,_gcfd );
-_deag ._gged =_gcfd ;_deag .newSpan ();};type symbol struct{_fa string ;_ed float64 ;_fed float64 ;_bfb float64 ;_bae float64 ;_eff float64 ;_fbf *_ca .TextStyle ;_gaa *_ca .Image ;_cbdg *block ;_dda string ;_ebd bool ;_dcc bool ;_fba bool ;_dfc *_ca .Color ;
+_deag ._gged =_gcfd ;_deag .newSpan ();};type symbol struct{_fa string ;_cfgda string ;_ed float64 ;_fed float64 ;_bfb float64 ;_bae float64 ;_eff float64 ;_fbf *_ca .TextStyle ;_gaa *_ca .Image ;_cbdg *block ;_dda string ;_ebd bool ;_dcc bool ;_fba bool ;_dfc *_ca .Color ;
 _agef bool ;_fac bool ;_gef *_ca .Color ;};func (_dgbc *convertContext )addCellToTable (_gafag *_ca .Table ,_fede *_gee .CT_Tc ,_bedb *_gee .CT_TblPr ,_dbfa *_gee .CT_TblPrEx ,_fged ,_dgca ,_cgfc ,_ecfb int ,_cdae []*_gee .CT_TblStylePr ,_adde *_gee .CT_PPrGeneral ,_fgab *_gee .CT_RPr ,_ebge bool ,_aeed int ,_abfa []float64 )int {_fgab ,_bfda ,_bedg ,_bbaag ,_fgfe ,_deaa ,_fece :=_dgbc .getTableCellProperties (_gafag ,_bedb ,_dbfa ,_cdae ,_fged ,_fede .TcPr ,_fgab ,_dgca ,_cgfc ,_ecfb );
 _fece .SetVerticalAlignment (_bfda );_fece .SetIndent (_bedg );var _beag *_ca .StyledParagraph ;_aabb :=_fede .EG_BlockLevelElts ;_bgag :=_dgbc ._affcc .NewDivision ();_eecad :=_dgbc ._affcc .NewList ();_cdga :=true ;_bgag .SetMargins (0.0,_bbaag ,_fgfe ,_deaa );
 _gdcd :=false ;_fggf :=-1;_ffcd :=false ;for _ ,_bbeg :=range _aabb {for _bdeb :=range _de .Iterate (_bbeg .BlockLevelEltsChoice .EG_ContentBlockContent ){for _ ,_ccaa :=range _bdeb .P {_edba :=_dgbc ._affcc .NewStyledParagraph ();if _gdcd {_aaeb :=_edba .Append ("\u000a");
@@ -378,7 +383,7 @@ case _gee .WdST_AlignHLeft :_cdgb =0;};};_aedc +=_cdgb ;_abbf :=0.0;if _ebdd ._g
 case _gee .WdST_RelFromVTopMargin :_abbf =0;default:if _ebdd ._gacegg {_abbf =0;}else {_abbf =_ebdd ._cgbbg ._ggd .Top ;};};};if _dea :=_dcbf .PosVChoice ;_dea !=nil {if _dea .PosOffset !=nil {_ffea =_ag .FromEMU (int64 (*_dea .PosOffset ));};};_abbf +=_ffea ;
 _debe :=_abbf +_ggc ;_bab :=_aedc +_dgbe ;_dec :=_ffea +_ggc ;if _dec > _ebdd ._debf ._db {_ebdd ._debf ._db =_dec ;};if _aeec .WrapTypeChoice !=nil &&_aeec .WrapTypeChoice .WrapNone ==nil {_ebdd ._debf ._efa =append (_ebdd ._debf ._efa ,&zoneToSkip {_ga :&_gb .Rectangle {Top :_abbf ,Bottom :_debe ,Left :_aedc ,Right :_bab },_df :_aeec .WrapTypeChoice ,_geed :_aeec .RelativeHeightAttr });
 };if _ddfb :=_aeec .Graphic ;_ddfb !=nil {if _cbac :=_ddfb .GraphicData ;_cbac !=nil {for _ ,_gffg :=range _cbac .Any {if _cadg ,_deeg :=_gffg .(*_gee .WdWsp );_deeg {_bbbe ,_caa :=_ebdd .makeBlockFromWdWspWithDimensions (_cadg ,_dgbe ,_ggc );if _caa !=nil {_fge .Log .Debug ("C\u0061\u006e\u006e\u006ft \u0072e\u0061\u0064\u0020\u0062\u006co\u0063\u006b\u003a\u0020\u0025\u0073",_caa );
-};if _bbbe !=nil {_bbbe ._bag =_aedc ;_bbbe ._eca =_abbf ;if _aeec .BehindDocAttr {_ebdd ._debf ._bgd =append (_ebdd ._debf ._bgd ,_bbbe );}else {_ebdd ._debf ._eec =append (_ebdd ._debf ._eec ,_bbbe );};};};};};};};};};};};};};};};type convertContext struct{_affcc *_ca .Creator ;
+};if _bbbe !=nil {_bbbe ._bag =_aedc ;_bbbe ._eca =_abbf ;if _aeec .BehindDocAttr {_ebdd ._debf ._bgd =append (_ebdd ._debf ._bgd ,_bbbe );}else {_ebdd ._debf ._eec =append (_ebdd ._debf ._eec ,_bbbe );};};};};};};};};};};};};};};};type convertContext struct{_affcc *_ca .Creator ;_fbdce *tagger ;
 _gbgdc *_dd .Document ;_gdfef *_gee .CT_PPrGeneral ;_bcfb *_gee .CT_RPr ;_gbdfa []*page ;_cgbbg *page ;_gbfac *_gb .Rectangle ;_debf *paragraph ;_gged *line ;_bdbb *span ;_fdadg *word ;_ceaa *_gee .CT_Hyperlink ;_bbegf *_gee .CT_PPr ;_dbdc []note ;_baefb *prefix ;
 _gacegg bool ;_abffb bool ;_cggf float64 ;_gbcaa float64 ;_dbce float64 ;_fgcc float64 ;_gccg bool ;_dagf map[int64 ]map[int64 ]int64 ;_fbg map[string ]string ;_edcga *Options ;_gcbc []*headerFooterRef ;_bad []*headerFooterRef ;_eefb map[string ]map[int64 ]*_gee .CT_Ind ;
 _cgddf float64 ;_feadd float64 ;_ccgaf []float64 ;_bega *_gb .Rectangle ;_cffd *_gee .CT_PPr ;_cefee []*_gee .CT_Tbl ;_ffaf []float64 ;_bggb map[*_ca .TextChunk ]string ;_ddfe map[string ]*_cb .PdfAnnotation ;_fdac int ;_gdgeb *_ca .Color ;_aadf *_e .CT_ColorScheme ;
@@ -470,7 +475,7 @@ continue ;}else {return true ;};};_fdf --;};return false ;};type image struct{_g
 }else if _fedc .Bdr !=nil {_geeba .Bdr =_fedc .Bdr ;};};if _geeba .Shd ==nil {if _gbcb .Shd !=nil {_geeba .Shd =_gbcb .Shd ;}else if _aedbe .Shd !=nil {_geeba .Shd =_aedbe .Shd ;}else if _fedc .Shd !=nil {_geeba .Shd =_fedc .Shd ;};};return _geeba ;};func _cbeab (_ddeb int ,_fcfd bool )string {_bbfd :=(_ddeb -1)/26+1;
 _cbba :=byte ((_ddeb -1)%26);if _fcfd {_cbba +=byte (65);}else {_cbba +=byte (97);};_dabec :=_ge .NewBuffer ([]byte {});for _fdcfc :=0;_fdcfc < _bbfd ;_fdcfc ++{_dabec .Write ([]byte {_cbba });};return _dabec .String ();};func (_ecbd *convertContext )adjustHeights (_gafa float64 ){if _ecbd ._gged ._abd < _gafa {_ecbd ._debf ._dcd +=(_gafa -_ecbd ._gged ._abd );
 _ecbd ._gged ._abd =_gafa ;};};const (_feege ="\u006di\u006e\u006f\u0072\u0046\u006f\u006et";_bccec ="\u006da\u006a\u006f\u0072\u0046\u006f\u006et";_bccag ="\u006d\u0061\u006a\u006f\u0072\u0045\u0061\u0073\u0074\u0041\u0073\u0069a\u0046\u006f\u006e\u0074";
-_cbcf ="\u006d\u0069\u006e\u006f\u0072\u0045\u0061\u0073\u0074\u0041\u0073\u0069a\u0046\u006f\u006e\u0074";);type paragraph struct{_gd float64 ;_be *_gb .Rectangle ;_ad float64 ;_beb float64 ;_agec float64 ;_dde float64 ;_dcd float64 ;_bfa _ca .TextAlignment ;
+_cbcf ="\u006d\u0069\u006e\u006f\u0072\u0045\u0061\u0073\u0074\u0041\u0073\u0069a\u0046\u006f\u006e\u0074";);type paragraph struct{_gd float64 ;_fegce *_gee .CT_P ;_be *_gb .Rectangle ;_ad float64 ;_beb float64 ;_agec float64 ;_dde float64 ;_dcd float64 ;_bfa _ca .TextAlignment ;
 _dfe float64 ;_ffb float64 ;_dcde []*line ;_fda *tableWrapper ;_ffc []*image ;_agd []*image ;_eec []*block ;_bgd []*block ;_fbe []*note ;_ccc float64 ;_efa []*zoneToSkip ;_db float64 ;_da bool ;_bcf []*borderLine ;_gdf bool ;};func _gafaf (_dabc *_gee .EG_RunInnerContent )bool {if _baag :=_dabc .RunInnerContentChoice .Br ;
 _baag !=nil {return _baag .TypeAttr ==_gee .ST_BrTypePage ;};return false ;};
 
@@ -510,7 +515,7 @@ _feba <=_gaad ;_feba ++{_abgc :=_eaad [_feba ];_fdge :=_abgc [0]._fec ;_fggae :=
 }else {_gafe .addCellToTable (_befb ,_ffgc ._afe ,_ffgc ._eef ,_ffgc ._gf ,_ffgc ._ged ,_ffgc ._fc ,_ffgc ._bfe ,_ffgc ._dgg ,_ffgc ._fff ,_ffgc ._ddg ,_ffgc ._eecf ,_ffgc ._dfa ,_ffgc ._ae ,_ffacb );};};if _fdge {_ggcd =_befb .SetRowHeight (_befb .CurRow (),_fggae );
 if _ggcd !=nil {_fge .Log .Debug ("E\u0052\u0052\u004f\u0052\u003a\u0020\u0055\u006e\u0061b\u006c\u0065\u0020\u0074\u006f\u0020\u0073et\u0020\u0072\u006f\u0077 \u0068\u0065\u0069\u0067\u0068\u0074\u0073\u0020\u0066or\u0020\u0074a\u0062\u006c\u0065\u0020\u0028\u0025\u0073\u0029",_ggcd .Error ());
 };};};};_gaad ++;return _gbfaf ,_gaad ,_befb ,_baeb ,_gdcc ,_aba ,_bcbd ,false ;};func (_cdag *convertContext )addCurrentWordToParagraph (){for {_ecge :=_cdag ._gged ._cf ;_gbeg :=_ecge +_cdag ._fdadg ._ffd ;if _gbeg > _cdag ._gged ._eb {if len (_cdag ._fdadg ._cgc )==1&&_cdag ._fdadg ._cgc [0]._gaa !=nil {break ;
-};_cdag .newLine ();};_bccc :=_cdag ._debf ._dde +_cdag ._gged ._gdd ;_ffbge :=_bccc +_cdag ._gged ._abd ;_faa :=false ;_dcbd :=append (_cdag ._cgbbg ._gbd ,_cdag ._debf ._efa ...);for _ ,_dbec :=range _dcbd {_fagdb :=_dbec ._ga ;_fgga :=(_ecge > _fagdb .Left &&_ecge < _fagdb .Right )||(_gbeg > _fagdb .Left &&_gbeg < _fagdb .Right )||(_ecge < _fagdb .Left &&_gbeg > _fagdb .Right );
+};if _cdag .hyphenateCurrentWord (){continue ;};_cdag .newLine ();};_bccc :=_cdag ._debf ._dde +_cdag ._gged ._gdd ;_ffbge :=_bccc +_cdag ._gged ._abd ;_faa :=false ;_dcbd :=append (_cdag ._cgbbg ._gbd ,_cdag ._debf ._efa ...);for _ ,_dbec :=range _dcbd {_fagdb :=_dbec ._ga ;_fgga :=(_ecge > _fagdb .Left &&_ecge < _fagdb .Right )||(_gbeg > _fagdb .Left &&_gbeg < _fagdb .Right )||(_ecge < _fagdb .Left &&_gbeg > _fagdb .Right );
 _fdg :=(_bccc > _fagdb .Top &&_bccc < _fagdb .Bottom )||(_ffbge > _fagdb .Top &&_ffbge < _fagdb .Bottom )||(_bccc < _fagdb .Top &&_ffbge > _fagdb .Bottom );if _dbec ._df .WrapSquare !=nil &&_fgga &&_fdg {_faa =true ;if _cdag ._gged ._cf < _fagdb .Right {_cdag ._bdbb ._bcfa =_fagdb .Left ;
 _cdag ._gged ._cf =_fagdb .Right ;_cdag .newSpan ();};};if _dbec ._df .WrapTopAndBottom !=nil &&_fdg {_faa =true ;_cdag ._debf ._dde =_fagdb .Bottom ;};};if !_faa {break ;};};if !_cdag ._fdadg ._ce ||len (_cdag ._bdbb ._fca )> 0{_cdag ._fdadg ._dga =_cdag ._gged ._cf ;
 _cdag ._bdbb ._fca =append (_cdag ._bdbb ._fca ,_cdag ._fdadg );_cdag ._gged ._cf +=_cdag ._fdadg ._ffd ;if _cdag .shouldApplyContextualSpacing (_cdag ._bbegf )&&_cdag ._debf ._be .Top > 0{_cdag .adjustHeights (_cdag ._debf ._be .Top );}else {for _ ,_faccd :=range _cdag ._fdadg ._cgc {_cdag .adjustHeights (_faccd ._bae );
@@ -523,12 +528,12 @@ _bbda .newLine ();_bbda .newWord ();};_bbda .addAbsoluteHeaderFooterTable (_gdee
 };};for _ ,_dedbd :=range _bacf ._bad {if _dedbd !=nil &&_dedbd ._cdca &&_dedbd ._gcfdd !=""{_fdbe [_dedbd ._gcfdd ]=true ;};};if _ccdga :=_bacf ._gbgdc .BodySection ().X ();_ccdga !=nil {for _ ,_eddc :=range _ccdga .EG_HdrFtrReferences {if _eddc .HdrFtrReferencesChoice ==nil {continue ;
 };if _begd :=_eddc .HdrFtrReferencesChoice .HeaderReference ;_begd !=nil &&_begd .IdAttr !=""{_aaeae [_begd .IdAttr ]=true ;};if _ecfbe :=_eddc .HdrFtrReferencesChoice .FooterReference ;_ecfbe !=nil &&_ecfbe .IdAttr !=""{_fdbe [_ecfbe .IdAttr ]=true ;};
 };};_ebgg :=_bacf ._gbgdc .X ().Body ;if _ebgg ==nil {return ;};for _ ,_dbga :=range _ebgg .EG_BlockLevelElts {if _dbga ==nil ||_dbga .BlockLevelEltsChoice ==nil {continue ;};_bacf .collectRefIdsFromContentBlocks (_dbga .BlockLevelEltsChoice .EG_ContentBlockContent ,_aaeae ,_fdbe );
-};return _aaeae ,_fdbe ;};const (_age =0.67;_fb =1.15;_ab =2.5;);func _edcf (_cbfbf *_ca .Creator ,_cgec *_ca .Block ,_ddabc []*paragraph ,_edcdf float64 ,_gaffg _ca .PageFinalizeFunctionArgs )float64 {_cffg :=0.0;for _ ,_aaecb :=range _ddabc {for _ ,_geeb :=range _aaecb ._dcde {_dgdg :=0.0;
-for _ ,_caf :=range _geeb ._efda {for _ ,_ffcb :=range _caf ._fca {for _ ,_bfgd :=range _ffcb ._cgc {if _bfgd ._gaa !=nil {_bfgd ._gaa .SetPos (_ffcb ._dga +_bfgd ._ed +_dgdg ,_edcdf );_cgec .Draw (_bfgd ._gaa );}else if _bfgd ._cbdg !=nil {if _bfgd ._cbdg ._bag ==0{_bfgd ._cbdg ._bag =_ffcb ._dga +_bfgd ._ed +_dgdg ;
+};return _aaeae ,_fdbe ;};const (_age =0.67;_fb =1.15;_ab =2.5;);func _edcf (_cbfbf *_ca .Creator ,_cgec *_ca .Block ,_ddabc []*paragraph ,_edcdf float64 ,_gaffg _ca .PageFinalizeFunctionArgs ,_dcbfa _cb .ArtifactSubtype )float64 {_cffg :=0.0;for _ ,_aaecb :=range _ddabc {for _ ,_geeb :=range _aaecb ._dcde {_dgdg :=0.0;
+for _ ,_caf :=range _geeb ._efda {for _ ,_ffcb :=range _caf ._fca {for _ ,_bfgd :=range _ffcb ._cgc {if _bfgd ._gaa !=nil {_bfgd ._gaa .SetPos (_ffcb ._dga +_bfgd ._ed +_dgdg ,_edcdf );markArtifact (_bfgd ._gaa ,_dcbfa );_cgec .Draw (_bfgd ._gaa );}else if _bfgd ._cbdg !=nil {if _bfgd ._cbdg ._bag ==0{_bfgd ._cbdg ._bag =_ffcb ._dga +_bfgd ._ed +_dgdg ;
 };if _bfgd ._cbdg ._eca ==0{_bfgd ._cbdg ._eca =_aaecb ._dde +_geeb ._gdd ;};_fbb (_cbfbf ,_bfgd ._cbdg );}else {_fcgbd :=_cbfbf .NewStyledParagraph ();if _bfgd ._ebd {_bfgd ._fed =0;}else if _bfgd ._dcc {_bfgd ._fed =1.2*_geeb ._abd -_bfgd ._bae ;};_bebcg :=_ffcb ._dga +_bfgd ._ed +_dgdg ;
 _bedbf :=_edcdf +_geeb ._gdd +_bfgd ._fed +_cffg ;_fcgbd .SetPos (_bebcg ,_bedbf );_decb :=false ;_dabfb :=_bfgd ._bfb ;if _bfgd ._fa =="\u005b\u0046\u0049E\u004c\u0044\u005f\u0050\u0041\u0047\u0045\u005d"{_bfgd ._fa =_fg .Itoa (_gaffg .PageNum );_decb =true ;
 };if _bfgd ._fa =="\u005b\u0046I\u0045\u004c\u0044_\u004e\u0055\u004d\u0050\u0041\u0047\u0045\u0053\u005d"{_bfgd ._fa =_fg .Itoa (_gaffg .TotalPages );_decb =true ;};var _fggaf *_ca .TextChunk ;if _bfgd ._dda !=""{_fggaf =_fcgbd .AddExternalLink (_bfgd ._fa ,_bfgd ._dda );
-}else {_fggaf =_fcgbd .Append (_bfgd ._fa );};if _bfgd ._fbf !=nil {_fggaf .Style =*_bfgd ._fbf ;};if _decb {_dgdg +=_fcgbd .Width ()-_dabfb ;};_cgec .Draw (_fcgbd );if _bfgd ._dfc !=nil {_gacd :=_bedbf +_bfgd ._bae ;_gb .DrawLine (_cbfbf ,_bebcg ,_gacd ,_bebcg +_bfgd ._bfb ,_gacd ,1,*_bfgd ._dfc );
+}else {_fggaf =_fcgbd .Append (_bfgd ._fa );};if _bfgd ._fbf !=nil {_fggaf .Style =*_bfgd ._fbf ;};if _decb {_dgdg +=_fcgbd .Width ()-_dabfb ;};markArtifact (_fcgbd ,_dcbfa );_cgec .Draw (_fcgbd );if _bfgd ._dfc !=nil {_gacd :=_bedbf +_bfgd ._bae ;_gb .DrawLine (_cbfbf ,_bebcg ,_gacd ,_bebcg +_bfgd ._bfb ,_gacd ,1,*_bfgd ._dfc );
 };};};};};};if _aaecb ._fda !=nil {_bcffe :=_ca .NewBlock (_aaecb ._fda ._ccb ,_gaffg .PageHeight );_bcffe .SetPos (_aaecb ._ad ,_edcdf );_bcffe .Draw (_aaecb ._fda ._dce );_cgec .Draw (_bcffe );_aaecb ._dcd =_aaecb ._fda ._dce .Height ();};for _ ,_cfdd :=range _aaecb ._eec {_cdgae (_cbfbf ,_cgec ,_cfdd );
 };for _ ,_bcabc :=range _aaecb ._bgd {_cdgae (_cbfbf ,_cgec ,_bcabc );};_cffg +=_aaecb ._dcd ;};return _cffg ;};var _daad ,_ffba ,_edee *_d .Regexp ;func _cdff (_acaee ,_fefef *_gee .CT_RPr )*_gee .CT_RPr {if _acaee ==nil {return _fefef ;};if _fefef ==nil {if _acaee .B !=nil {_acaee .B =nil ;
 };if _acaee .BCs !=nil {_acaee .BCs =nil ;};if _acaee .I !=nil {_acaee .I =nil ;};if _acaee .ICs !=nil {_acaee .ICs =nil ;};return _acaee ;};if _acaee .RStyle ==nil {_acaee .RStyle =_fefef .RStyle ;};if _acaee .RFonts ==nil {_acaee .RFonts =_fefef .RFonts ;
@@ -541,15 +546,15 @@ _bedbf :=_edcdf +_geeb ._gdd +_bfgd ._fed +_cffg ;_fcgbd .SetPos (_bebcg ,_bedbf
 };if _acaee .Rtl ==nil {_acaee .Rtl =_fefef .Rtl ;};if _acaee .Cs ==nil {_acaee .Cs =_fefef .Cs ;};if _acaee .Em ==nil {_acaee .Em =_fefef .Em ;};if _acaee .Lang ==nil {_acaee .Lang =_fefef .Lang ;};if _acaee .EastAsianLayout ==nil {_acaee .EastAsianLayout =_fefef .EastAsianLayout ;
 };if _acaee .SpecVanish ==nil {_acaee .SpecVanish =_fefef .SpecVanish ;};if _acaee .OMath ==nil {_acaee .OMath =_fefef .OMath ;};if _acaee .RPrChange ==nil {_acaee .RPrChange =_fefef .RPrChange ;};return _acaee ;};func (_bdd *convertContext )drawPage (_fab *page ){if _fab ._fee {_fegg :=_fab ._ggd .Top +_cbd *_age ;
 _gde :=_fab ._ggd .Left ;_fdc :=_fab ._ggd .Right ;_gb .DrawLine (_bdd ._affcc ,_gde ,_fegg ,_fdc ,_fegg ,_gc ,_ca .ColorBlack );};for _ ,_deg :=range _fab ._ec {_cbf (_bdd ._affcc ,_deg );};for _ ,_ffa :=range _fab ._dca {_fbb (_bdd ._affcc ,_ffa );};
-for _ ,_gae :=range _fab ._efd {if _gae ._da {_faca :=_gae ._dde +_cbd *_age ;_bdf :=_fab ._ggd .Left ;_dbca :=_bdf +_gcad (50);_gb .DrawLine (_bdd ._affcc ,_bdf ,_faca ,_dbca ,_faca ,_gc ,_ca .ColorBlack );}else {for _ ,_aab :=range _gae ._dcde {if _aab ._gga {_bdd .processRtlLine (_aab );
-};_fdd :=0.0;for _ ,_bbb :=range _aab ._efda {for _ ,_bgc :=range _bbb ._fca {for _ ,_acb :=range _bgc ._cgc {if _acb ._gaa !=nil {_acb ._gaa .SetPos (_bgc ._dga +_acb ._ed +_fdd ,_gae ._dde +_aab ._gdd );_abf :=_bdd ._affcc .Draw (_acb ._gaa );if _abf !=nil {_fge .Log .Debug ("\u0045\u0072\u0072or\u0020\u0064\u0072\u0061\u0077\u0069\u006e\u0067\u0020\u0069\u006d\u0061\u0067\u0065\u003a\u0020\u0025\u0073",_abf );
+for _ ,_gae :=range _fab ._efd {_bdd .tagParagraph (_gae );if _gae ._da {_faca :=_gae ._dde +_cbd *_age ;_bdf :=_fab ._ggd .Left ;_dbca :=_bdf +_gcad (50);_gb .DrawLine (_bdd ._affcc ,_bdf ,_faca ,_dbca ,_faca ,_gc ,_ca .ColorBlack );}else {for _ ,_aab :=range _gae ._dcde {if _aab ._gga {_bdd .processRtlLine (_aab );
+};_fdd :=0.0;for _ ,_bbb :=range _aab ._efda {for _ ,_bgc :=range _bbb ._fca {for _ ,_acb :=range _bgc ._cgc {if _acb ._gaa !=nil {_acb ._gaa .SetPos (_bgc ._dga +_acb ._ed +_fdd ,_gae ._dde +_aab ._gdd );_abf :=_bdd .drawTagged (_acb ._gaa ,_acb ._cfgda );if _abf !=nil {_fge .Log .Debug ("\u0045\u0072\u0072or\u0020\u0064\u0072\u0061\u0077\u0069\u006e\u0067\u0020\u0069\u006d\u0061\u0067\u0065\u003a\u0020\u0025\u0073",_abf );
 };}else if _acb ._cbdg !=nil {_acb ._cbdg ._bag =_bgc ._dga +_acb ._ed +_fdd ;_acb ._cbdg ._eca =_gae ._dde +_aab ._gdd ;_fbb (_bdd ._affcc ,_acb ._cbdg );}else {_aef :=_bdd ._affcc .NewStyledParagraph ();if _acb ._ebd {_acb ._fed =0;}else if _acb ._dcc {_acb ._fed =1.2*_aab ._abd -_acb ._bae ;
 };_dcg :=_bgc ._dga +_acb ._ed +_fdd ;_ecc :=_gae ._dde +_aab ._gdd +_acb ._fed ;_aef .SetPos (_dcg ,_ecc );var _bfg *_ca .TextChunk ;_ece :=_acb ._fa ;_bba :=false ;_ede :=_acb ._bfb ;if _acb ._fa =="\u005b\u0046\u0049E\u004c\u0044\u005f\u0050\u0041\u0047\u0045\u005d"&&_bdd ._ecbe > 0{_ece =_fg .Itoa (_bdd ._ecbe );
 _bba =true ;};if _acb ._fa =="\u005b\u0046I\u0045\u004c\u0044_\u004e\u0055\u004d\u0050\u0041\u0047\u0045\u0053\u005d"&&_bdd ._accgf > 0{_ece =_fg .Itoa (_bdd ._accgf );_bba =true ;};if _acb ._dda !=""{_bfg =_aef .AddExternalLink (_ece ,_acb ._dda );}else {_bfg =_aef .Append (_ece );
-};if _acb ._fbf !=nil {_bfg .Style =*_acb ._fbf ;};if _acb ._gef !=nil {_bfg .Highlight (*_acb ._gef ,1.0);};if _bba {_fdd +=_aef .Width ()-_ede ;};_bcb :=_bdd ._affcc .Draw (_aef );if _bcb !=nil {_fge .Log .Debug ("\u0045\u0072\u0072\u006fr \u0064\u0072\u0061\u0077\u0069\u006e\u0067\u0020\u0074\u0065\u0078\u0074\u003a\u0020%\u0073",_bcb );
+};if _acb ._fbf !=nil {_bfg .Style =*_acb ._fbf ;};if _acb ._gef !=nil {_bfg .Highlight (*_acb ._gef ,1.0);};if _bba {_fdd +=_aef .Width ()-_ede ;};_bcb :=_bdd .drawTagged (_aef ,"");if _bcb !=nil {_fge .Log .Debug ("\u0045\u0072\u0072\u006fr \u0064\u0072\u0061\u0077\u0069\u006e\u0067\u0020\u0074\u0065\u0078\u0074\u003a\u0020%\u0073",_bcb );
 };if _acb ._dfc !=nil {_gdg :=_ecc +_acb ._eff +2.0;_gb .DrawLine (_bdd ._affcc ,_dcg ,_gdg ,_dcg +_acb ._bfb ,_gdg ,1,*_acb ._dfc );};};};};};};if _gae ._fda !=nil {switch _gae ._fda ._gcc {case _ca .HorizontalAlignmentCenter :_gae ._fda ._dce .SetPos (_gae ._ad +(_fab ._ggd .Right -_fab ._ggd .Left -_gae ._fda ._ccb )/2,_gae ._dde +_gae ._be .Top );
 case _ca .HorizontalAlignmentRight :_gae ._fda ._dce .SetPos (_fab ._ggd .Right -_gae ._fda ._ccb -_gae ._be .Right ,_gae ._dde +_gae ._be .Top );default:_dag :=_gae ._fda ._ac ;if _dag ==0{_dag =_gae ._ad ;};_gae ._fda ._dce .SetPos (_dag ,_gae ._dde +_gae ._be .Top );
-};_dfad :=_ca .NewBlock (_gae ._fda ._ccb ,_bdd ._affcc .Height ());_dfad .SetPos (0,0);_ =_dfad .Draw (_gae ._fda ._dce );_ =_bdd ._affcc .Draw (_dfad );};if _gae ._bcf !=nil {_dad :=(_fab ._ggd .Left /_gb .DefaultFontSize -1);_baf :=1.5;for _ ,_eg :=range _gae ._bcf {_gaef :=_gae ._beb +_eg ._aa +_dad ;
+};_dfad :=_ca .NewBlock (_gae ._fda ._ccb ,_bdd ._affcc .Height ());_dfad .SetPos (0,0);_bdd .tagTable (_gae ._fda ._dce );_ =_dfad .Draw (_gae ._fda ._dce );_ =_bdd ._affcc .Draw (_dfad );};if _gae ._bcf !=nil {_dad :=(_fab ._ggd .Left /_gb .DefaultFontSize -1);_baf :=1.5;for _ ,_eg :=range _gae ._bcf {_gaef :=_gae ._beb +_eg ._aa +_dad ;
 if _gaef > _gae ._agec +_dad {_gaef =_gae ._agec +_dad ;};switch _eg ._fffd {case _gb .BorderPositionTop :_adb :=_gae ._dde +_eg ._ffg ;_gb .DrawLine (_bdd ._affcc ,_gae ._beb -_dad ,_adb ,_gaef ,_adb ,_eg ._fag ,_eg ._ddab );case _gb .BorderPositionLeft :_fabe :=_gae ._dde +_gae ._dcd -_gae ._be .Top -_gae ._be .Bottom -_eg ._ffg -_baf ;
 _fagd :=_fabe +_gae ._dcd +_gae ._be .Top +_gae ._be .Bottom ;_cba :=_gae ._beb -_dad ;_gb .DrawLine (_bdd ._affcc ,_cba ,_fabe ,_cba ,_fagd ,_eg ._aa ,_eg ._ddab );case _gb .BorderPositionBottom :_dbd :=_gae ._dde +_eg ._ffg +_gae ._be .Top +_gae ._dcd +_gae ._be .Bottom ;
 _gb .DrawLine (_bdd ._affcc ,_gae ._beb -_dad ,_dbd ,_gaef ,_dbd ,_eg ._fag ,_eg ._ddab );case _gb .BorderPositionRight :_ggf :=_gae ._dde +_gae ._dcd -_gae ._be .Top -_gae ._be .Bottom -_eg ._ffg -_baf ;_gaae :=_ggf +_gae ._dcd +_gae ._be .Top +_gae ._be .Bottom ;
@@ -561,22 +566,14 @@ _feg .SetFillColor (*_bb ._cgf );_feg .SetBorderColor (*_bb ._cgf );_feg .SetBor
 if _bb ._fcab {_gb .DrawRectangle (_efc ,&_gb .Rectangle {Top :_bb ._eca ,Bottom :_bb ._eca +_bb ._fedg .Height (),Left :_bb ._bag ,Right :_bb ._bag +_bb ._fedg .Width ()},_bb ._ggb ,_bb ._fae );};};
 
 // ConvertToPdfWithOptions convert the document to PDF with given options.
-func ConvertToPdfWithOptions (d *_dd .Document ,opts *Options )*_ca .Creator {var _cbdaa map[string ]string ;_gb .DefaultFontSize =12;if opts !=nil {if opts .ProcessFields {_cbdaa =_effe (d );};if len (opts .FontFiles )> 0{_bbcd :=_gb .RegisterFontsFromFiles (opts .FontFiles );
-if _bbcd !=nil {_fge .Log .Debug ("\u0046\u0061\u0069\u006c t\u006f\u0020\u006c\u006f\u0061\u0064\u0020\u0066\u006f\u006e\u0074\u0073\u003a\u0020%\u0076",opts .FontDirectory );};};if opts .FontDirectory !=""{_cgcc :=_gb .RegisterFontsFromDirectory (opts .FontDirectory );
-if _cgcc !=nil {_fge .Log .Debug ("\u0046\u0061\u0069l\u0020\u0074\u006f\u0020l\u006f\u0061\u0064\u0020\u0066\u006f\u006et\u0020\u0064\u0069\u0072\u0065\u0063\u0074\u006f\u0072\u0079\u003a\u0020\u0025\u0076",_cgcc .Error ());};};if opts .DefaultFontSize > 0{_gb .DefaultFontSize =float64 (opts .DefaultFontSize );
-};if len (opts .RtlFontFile )> 0{_gb .RtlFontFile ,_ =_gb .LoadFontFromFile (opts .RtlFontFile );};if opts .DefaultImageEncoder !=nil {_gb .DefaultImageEncoder =opts .DefaultImageEncoder ;};};_edfeg :=_gb .RegisterEmbeddedFonts (d );if _edfeg !=nil {_fge .Log .Debug ("\u0046\u0061\u0069l\u0020\u0074\u006f\u0020l\u006f\u0061\u0064\u0020\u0065\u006d\u0062e\u0064\u0064\u0065\u0064\u0020\u0066\u006f\u006e\u0074\u0073\u003a\u0020\u0025\u0076",_edfeg .Error ());
-};var (_dbfcf *_gee .CT_PPrGeneral ;_efge *_gee .CT_RPr ;);if _edbc :=d .Styles .X ().DocDefaults ;_edbc !=nil {if _gafg :=_edbc .PPrDefault ;_gafg !=nil {_dbfcf =_gafg .PPr ;};if _ageb :=_edbc .RPrDefault ;_ageb !=nil {_efge =_ageb .RPr ;};};_fbfc :=_gb .GetDefaultPageSize ();
-if opts !=nil &&opts .DefaultPageSize !=_gb .DefaultPageSize {_fbfc =_gb .GetPageDimensions (opts .DefaultPageSize );};_gbaa :=_fbfc [0];_aefcg :=_fbfc [1];_cbc :=_ag .Inch *1.0;_gbde :=_ag .Inch *0.5;_bgeg ,_abggc ,_cfgd ,_fega :=_cbc ,_cbc ,_cbc ,_cbc ;
-_cdef ,_aafdc :=_gbde ,_gbde ;if _efba :=d .BodySection ().X ();_efba !=nil {if _dcdegc :=_efba .PgMar ;_dcdegc !=nil {if _dcdegc .LeftAttr .ST_UnsignedDecimalNumber !=nil {_bgeg =_gb .PointsFromTwips (int64 (*_dcdegc .LeftAttr .ST_UnsignedDecimalNumber ));
-};if _dcdegc .LeftAttr .ST_UnsignedDecimalNumber !=nil {_abggc =_gb .PointsFromTwips (int64 (*_dcdegc .RightAttr .ST_UnsignedDecimalNumber ));};if _dcdegc .TopAttr .Int64 !=nil {_cfgd =_gb .PointsFromTwips (*_dcdegc .TopAttr .Int64 );};if _dcdegc .BottomAttr .Int64 !=nil {_fega =_gb .PointsFromTwips (*_dcdegc .BottomAttr .Int64 );
-};if _dcdegc .HeaderAttr .ST_UnsignedDecimalNumber !=nil {_cdef =_gb .PointsFromTwips (int64 (*_dcdegc .HeaderAttr .ST_UnsignedDecimalNumber ));};if _dcdegc .FooterAttr .ST_UnsignedDecimalNumber !=nil {_aafdc =_gb .PointsFromTwips (int64 (*_dcdegc .FooterAttr .ST_UnsignedDecimalNumber ));
-};};if _bbdgc :=_efba .PgSz ;_bbdgc !=nil {if _bbdgc .WAttr !=nil {_gbaa =_gb .PointsFromTwips (int64 (*_bbdgc .WAttr .ST_UnsignedDecimalNumber ));};if _bbdgc .HAttr !=nil {_aefcg =_gb .PointsFromTwips (int64 (*_bbdgc .HAttr .ST_UnsignedDecimalNumber ));
-};};};if d .Settings .X ().DefaultTabStop ==nil {_adgd =_gcad (12.7);}else {_adgd =_gb .PointsFromTwips (int64 (*d .Settings .X ().DefaultTabStop .ValAttr .ST_UnsignedDecimalNumber ));};_bbad :=_ca .New ();_bbad .SetPageSize (_ca .PageSize {_gbaa ,_aefcg });
-_bbad .SetPageMargins (_bgeg ,_abggc ,_cfgd ,_fega );_afcg :=&convertContext {_affcc :_bbad ,_gbgdc :d ,_gdfef :_dbfcf ,_bcfb :_efge ,_gbfac :&_gb .Rectangle {Top :_cfgd ,Bottom :_aefcg -_fega ,Left :_bgeg ,Right :_gbaa -_abggc },_bega :&_gb .Rectangle {Top :_cfgd ,Bottom :_fega ,Left :_bgeg ,Right :_abggc },_dbdc :[]note {},_dagf :map[int64 ]map[int64 ]int64 {},_fbg :_cbdaa ,_edcga :opts ,_gcbc :[]*headerFooterRef {},_bad :[]*headerFooterRef {},_gbcaa :_cdef ,_cgddf :_cfgd ,_dbce :_aefcg -_aafdc ,_feadd :_fega ,_cggf :_bgeg ,_eefb :map[string ]map[int64 ]*_gee .CT_Ind {},_ccgaf :[]float64 {_gbaa ,_aefcg },_cefee :[]*_gee .CT_Tbl {},_bggb :map[*_ca .TextChunk ]string {},_ddfe :map[string ]*_cb .PdfAnnotation {}};
-_afcg .calculateHdrFtrContentHeight ();_fcgb :=d .X ().Body .EG_BlockLevelElts ;_dfda :=len (_fcgb );_afcg ._cffd =nil ;for _bggc ,_ecbc :=range _fcgb {var _ffgcb []*_gee .EG_ContentBlockContent ;if _bggc < _dfda -1{_bbegc :=_fcgb [_bggc +1];_ffgcb =_bbegc .BlockLevelEltsChoice .EG_ContentBlockContent ;
+func ConvertToPdfWithOptions (d *_dd .Document ,opts *Options )*_ca .Creator {return layoutDocument (d ,opts )._affcc ;};
+
+// layoutDocument lays out the document and draws it, returning the conversion
+// context holding the laid out pages.
+func layoutDocument (d *_dd .Document ,opts *Options )*convertContext {_afcg :=newLayoutContext (d ,opts );_fcgb :=d .X ().Body .EG_BlockLevelElts ;_dfda :=len (_fcgb );_afcg ._cffd =nil ;for _bggc ,_ecbc :=range _fcgb {var _ffgcb []*_gee .EG_ContentBlockContent ;if _bggc < _dfda -1{_bbegc :=_fcgb [_bggc +1];_ffgcb =_bbegc .BlockLevelEltsChoice .EG_ContentBlockContent ;
 };_afcg .addAbsoluteCBCs (_ecbc .BlockLevelEltsChoice .EG_ContentBlockContent ,_ffgcb );};_afcg .processInternalLinks ();_afcg .addTableGroup ();_afcg ._cffd =nil ;_afcg .addEndnotes ();_afcg .alignSymbolsVertically ();_afcg .drawPages ();if _fbfd :=d .BodySection ().X ();
 _fbfd !=nil {_ebeb ,_febc :=_afcg .getSectPrHeaderAndFooterRef (_fbfd ,len (_afcg ._gbdfa )-1);for _ ,_gbdd :=range _ebeb {_gbdd ._ecbfb =-1;};for _ ,_efef :=range _febc {_efef ._ecbfb =-1;};_afcg ._gcbc =append (_afcg ._gcbc ,_ebeb ...);_afcg ._bad =append (_afcg ._bad ,_febc ...);
-};_afcg .drawHeaderFooter ();return _bbad ;};func _adge (_beed *_gee .EG_RunInnerContent )bool {if _ffae :=_beed .RunInnerContentChoice .Br ;_ffae !=nil {return _ffae .TypeAttr ==_gee .ST_BrTypeTextWrapping ||_ffae .TypeAttr ==_gee .ST_BrTypeUnset ;};return false ;
+};_afcg .drawHeaderFooter ();_afcg .finishTagging ();return _afcg ;};func _adge (_beed *_gee .EG_RunInnerContent )bool {if _ffae :=_beed .RunInnerContentChoice .Br ;_ffae !=nil {return _ffae .TypeAttr ==_gee .ST_BrTypeTextWrapping ||_ffae .TypeAttr ==_gee .ST_BrTypeUnset ;};return false ;
 };type block struct{_fedg *_ca .Block ;_bag float64 ;_eca float64 ;_fcab bool ;_ggb float64 ;_fae _ca .Color ;_cgf *_ca .Color ;};func (_ggff *convertContext )getTableCellProperties (_bbea *_ca .Table ,_geef *_gee .CT_TblPr ,_aabbb *_gee .CT_TblPrEx ,_affa []*_gee .CT_TblStylePr ,_bedf int ,_caeed *_gee .CT_TcPr ,_dcdg *_gee .CT_RPr ,_ccga int ,_cddeb int ,_aagc int )(*_gee .CT_RPr ,_ca .CellVerticalAlignment ,float64 ,float64 ,float64 ,float64 ,*_ca .TableCell ){var _feeb *_ca .TableCell ;
 _ccdd :=1;_ddad :=_gee .NewCT_RPr ();var _gca ,_cgdbb int64 ;for _ ,_dddd :=range _affa {if _bedf ==0&&_dddd .TypeAttr ==_gee .ST_TblStyleOverrideTypeFirstRow {_cabgb (_dddd .PPr ,&_gca ,&_cgdbb );_caeed =_cggcc (_caeed ,_dddd .TcPr );_dcdg =_bgfe (_ddad ,_dddd .RPr );
 break ;};if _ccga ==0&&_dddd .TypeAttr ==_gee .ST_TblStyleOverrideTypeFirstCol {_cabgb (_dddd .PPr ,&_gca ,&_cgdbb );_caeed =_cggcc (_caeed ,_dddd .TcPr );_dcdg =_bgfe (_ddad ,_dddd .RPr );};if _bedf ==_cddeb -1&&_dddd .TypeAttr ==_gee .ST_TblStyleOverrideTypeLastRow {_cabgb (_dddd .PPr ,&_gca ,&_cgdbb );
@@ -613,4 +610,20 @@ _efga !=nil {if _bfedb :=_efga .ValAttr ;_bfedb !=_gee .ST_NumberFormatNone &&_b
 if _ ,_ebfgd :=_gafac ._dagf [_fada ];!_ebfgd {_gafac ._dagf [_fada ]=map[int64 ]int64 {};};if _ ,_cabc :=_gafac ._dagf [_fada ][_fdcdb ];!_cabc {_gafac ._dagf [_fada ][_fdcdb ]=1;if _fbga :=_cbbc .Start ;_fbga !=nil {_gafac ._dagf [_fada ][_fdcdb ]=_fbga .ValAttr ;
 };};if _ ,_feegb :=_gafac ._dagf [_fada ][_fdcdb +1];_feegb {_gafac ._dagf [_fada ][_fdcdb +1]=1;};_bcee :=_gafac ._dagf [_fada ][_fdcdb ];_gacf :=_bg .FormatNumberingText (int64 (_bcee ),_cbbc .IlvlAttr ,*_cbbc .LvlText .ValAttr ,_cbbc .NumFmt ,_gafac ._dagf [_fada ]);
 _gafac ._dagf [_fada ][_fdcdb ]++;_faefd ._beeg =_gacf ;};};};};};};return _gaefg ,_faefd ;};func (_acea *convertContext )currentParagraphOverflowsCurrentPage ()bool {_fedad :=_acea ._debf ._dde +_acea ._debf ._be .Top +_acea ._debf ._be .Bottom ;_gaac :=_acea ._cgbbg ._ggd .Bottom -_acea ._debf ._ccc ;
-if len (_acea ._cgbbg ._aga )==0&&len (_acea ._debf ._fbe )> 0{_gaac -=_cbd ;};return _fedad +_acea ._debf ._dcd > _gaac ||_fedad +_acea ._debf ._db > _gaac ;};
\ No newline at end of file
+if len (_acea ._cgbbg ._aga )==0&&len (_acea ._debf ._fbe )> 0{_gaac -=_cbd ;};return _fedad +_acea ._debf ._dcd > _gaac ||_fedad +_acea ._debf ._db > _gaac ;};
+
+// newLayoutContext returns a conversion context for the document with the page
+// size, margins and fonts set up for layout.
+func newLayoutContext (d *_dd .Document ,opts *Options )*convertContext {var _cbdaa map[string ]string ;_gb .DefaultFontSize =12;if opts !=nil {if opts .ProcessFields {_cbdaa =_effe (d );};if len (opts .FontFiles )> 0{_bbcd :=_gb .RegisterFontsFromFiles (opts .FontFiles );
+if _bbcd !=nil {_fge .Log .Debug ("\u0046\u0061\u0069\u006c t\u006f\u0020\u006c\u006f\u0061\u0064\u0020\u0066\u006f\u006e\u0074\u0073\u003a\u0020%\u0076",opts .FontDirectory );};};if opts .FontDirectory !=""{_cgcc :=_gb .RegisterFontsFromDirectory (opts .FontDirectory );
+if _cgcc !=nil {_fge .Log .Debug ("\u0046\u0061\u0069l\u0020\u0074\u006f\u0020l\u006f\u0061\u0064\u0020\u0066\u006f\u006et\u0020\u0064\u0069\u0072\u0065\u0063\u0074\u006f\u0072\u0079\u003a\u0020\u0025\u0076",_cgcc .Error ());};};if opts .DefaultFontSize > 0{_gb .DefaultFontSize =float64 (opts .DefaultFontSize );
+};if len (opts .RtlFontFile )> 0{_gb .RtlFontFile ,_ =_gb .LoadFontFromFile (opts .RtlFontFile );};if opts .DefaultImageEncoder !=nil {_gb .DefaultImageEncoder =opts .DefaultImageEncoder ;};};_edfeg :=_gb .RegisterEmbeddedFonts (d );if _edfeg !=nil {_fge .Log .Debug ("\u0046\u0061\u0069l\u0020\u0074\u006f\u0020l\u006f\u0061\u0064\u0020\u0065\u006d\u0062e\u0064\u0064\u0065\u0064\u0020\u0066\u006f\u006e\u0074\u0073\u003a\u0020\u0025\u0076",_edfeg .Error ());
+};var (_dbfcf *_gee .CT_PPrGeneral ;_efge *_gee .CT_RPr ;);if _edbc :=d .Styles .X ().DocDefaults ;_edbc !=nil {if _gafg :=_edbc .PPrDefault ;_gafg !=nil {_dbfcf =_gafg .PPr ;};if _ageb :=_edbc .RPrDefault ;_ageb !=nil {_efge =_ageb .RPr ;};};_fbfc :=_gb .GetDefaultPageSize ();
+if opts !=nil &&opts .DefaultPageSize !=_gb .DefaultPageSize {_fbfc =_gb .GetPageDimensions (opts .DefaultPageSize );};_gbaa :=_fbfc [0];_aefcg :=_fbfc [1];_cbc :=_ag .Inch *1.0;_gbde :=_ag .Inch *0.5;_bgeg ,_abggc ,_cfgd ,_fega :=_cbc ,_cbc ,_cbc ,_cbc ;
+_cdef ,_aafdc :=_gbde ,_gbde ;if _efba :=d .BodySection ().X ();_efba !=nil {if _dcdegc :=_efba .PgMar ;_dcdegc !=nil {if _dcdegc .LeftAttr .ST_UnsignedDecimalNumber !=nil {_bgeg =_gb .PointsFromTwips (int64 (*_dcdegc .LeftAttr .ST_UnsignedDecimalNumber ));
+};if _dcdegc .LeftAttr .ST_UnsignedDecimalNumber !=nil {_abggc =_gb .PointsFromTwips (int64 (*_dcdegc .RightAttr .ST_UnsignedDecimalNumber ));};if _dcdegc .TopAttr .Int64 !=nil {_cfgd =_gb .PointsFromTwips (*_dcdegc .TopAttr .Int64 );};if _dcdegc .BottomAttr .Int64 !=nil {_fega =_gb .PointsFromTwips (*_dcdegc .BottomAttr .Int64 );
+};if _dcdegc .HeaderAttr .ST_UnsignedDecimalNumber !=nil {_cdef =_gb .PointsFromTwips (int64 (*_dcdegc .HeaderAttr .ST_UnsignedDecimalNumber ));};if _dcdegc .FooterAttr .ST_UnsignedDecimalNumber !=nil {_aafdc =_gb .PointsFromTwips (int64 (*_dcdegc .FooterAttr .ST_UnsignedDecimalNumber ));
+};};if _bbdgc :=_efba .PgSz ;_bbdgc !=nil {if _bbdgc .WAttr !=nil {_gbaa =_gb .PointsFromTwips (int64 (*_bbdgc .WAttr .ST_UnsignedDecimalNumber ));};if _bbdgc .HAttr !=nil {_aefcg =_gb .PointsFromTwips (int64 (*_bbdgc .HAttr .ST_UnsignedDecimalNumber ));
+};};};if d .Settings .X ().DefaultTabStop ==nil {_adgd =_gcad (12.7);}else {_adgd =_gb .PointsFromTwips (int64 (*d .Settings .X ().DefaultTabStop .ValAttr .ST_UnsignedDecimalNumber ));};_bbad :=_ca .New ();_bbad .SetPageSize (_ca .PageSize {_gbaa ,_aefcg });
+_bbad .SetPageMargins (_bgeg ,_abggc ,_cfgd ,_fega );_afcg :=&convertContext {_affcc :_bbad ,_gbgdc :d ,_gdfef :_dbfcf ,_bcfb :_efge ,_gbfac :&_gb .Rectangle {Top :_cfgd ,Bottom :_aefcg -_fega ,Left :_bgeg ,Right :_gbaa -_abggc },_bega :&_gb .Rectangle {Top :_cfgd ,Bottom :_fega ,Left :_bgeg ,Right :_abggc },_dbdc :[]note {},_dagf :map[int64 ]map[int64 ]int64 {},_fbg :_cbdaa ,_edcga :opts ,_gcbc :[]*headerFooterRef {},_bad :[]*headerFooterRef {},_gbcaa :_cdef ,_cgddf :_cfgd ,_dbce :_aefcg -_aafdc ,_feadd :_fega ,_cggf :_bgeg ,_eefb :map[string ]map[int64 ]*_gee .CT_Ind {},_ccgaf :[]float64 {_gbaa ,_aefcg },_cefee :[]*_gee .CT_Tbl {},_bggb :map[*_ca .TextChunk ]string {},_ddfe :map[string ]*_cb .PdfAnnotation {}};if opts !=nil &&opts .TaggedPDF {_afcg .enableTagging ();};
+_afcg .calculateHdrFtrContentHeight ();return _afcg ;};
@@ -0,0 +1,42 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package convert
+
+import (
+	"github.com/unidoc/unioffice/v2/common/logger"
+	"github.com/unidoc/unioffice/v2/internal/convertutils"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+	dgm "github.com/unidoc/unioffice/v2/schema/soo/dml/diagram"
+	"github.com/unidoc/unipdf/v4/creator"
+)
+
+// makePdfBlockFromDiagram renders the cached drawing of the SmartArt diagram
+// referenced by a graphic frame element. It returns false if the element is
+// not a diagram or the diagram has no drawing to render.
+func (c *convertContext) makePdfBlockFromDiagram(el interface{}, w, h float64) (*creator.Block, bool) {
+	ids, ok := el.(*dgm.RelIds)
+	if !ok {
+		return nil, false
+	}
+	dg, ok := c._gbgdc.GetDiagramByRelIds(&ids.CT_RelIds)
+	if !ok || dg.Drawing() == nil {
+		return nil, false
+	}
+	var theme *dml.Theme
+	if themes := c._gbgdc.Themes(); len(themes) > 0 {
+		theme = themes[0]
+	}
+	blk, err := convertutils.MakeBlockFromDiagramDrawing(dg.Drawing(), w, h, theme)
+	if err != nil {
+		logger.Log.Debug("Cannot make diagram block: %s", err)
+		return nil, false
+	}
+	return blk, true
+}
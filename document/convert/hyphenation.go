@@ -0,0 +1,177 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package convert
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/unidoc/unioffice/v2/internal/hyphenation"
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/sharedTypes"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+	"github.com/unidoc/unipdf/v4/creator"
+)
+
+var (
+	hyphenatorsMu sync.RWMutex
+	hyphenators   = map[string]*hyphenation.Hyphenator{}
+)
+
+// RegisterHyphenationPatterns registers TeX hyphenation patterns, such as the
+// hyph-*.pat.txt files of the hyph-utf8 project, for a language like "en-US".
+// Patterns registered for a language without a region, e.g. "en", are used for
+// all its regions. Justified text of documents with automatic hyphenation
+// enabled is hyphenated with the patterns of the document's default language.
+func RegisterHyphenationPatterns(lang string, patterns io.Reader) error {
+	h, err := hyphenation.Parse(patterns)
+	if err != nil {
+		return err
+	}
+	hyphenatorsMu.Lock()
+	hyphenators[strings.ToLower(lang)] = h
+	hyphenatorsMu.Unlock()
+	return nil
+}
+
+func hyphenatorFor(lang string) *hyphenation.Hyphenator {
+	lang = strings.ToLower(lang)
+	hyphenatorsMu.RLock()
+	defer hyphenatorsMu.RUnlock()
+	if h, ok := hyphenators[lang]; ok {
+		return h
+	}
+	if i := strings.IndexByte(lang, '-'); i > 0 {
+		return hyphenators[lang[:i]]
+	}
+	return nil
+}
+
+// documentLanguage returns the default language of the document text.
+func (c *convertContext) documentLanguage() string {
+	if dd := c._gbgdc.Styles.X().DocDefaults; dd != nil && dd.RPrDefault != nil && dd.RPrDefault.RPr != nil {
+		if l := dd.RPrDefault.RPr.Lang; l != nil && l.ValAttr != nil {
+			return *l.ValAttr
+		}
+	}
+	return "en-US"
+}
+
+// hyphenateCurrentWord splits the current word, which doesn't fit on the
+// current line, at the last hyphenation point that lets its first part and a
+// hyphen fit. The first part is added to the line and the current word is left
+// holding the rest. It returns false if the word can't be hyphenated.
+func (c *convertContext) hyphenateCurrentWord() bool {
+	d := c._gbgdc
+	l, w := c._gged, c._fdadg
+	if !d.Settings.AutoHyphenation() || c._debf == nil || c._debf._bfa != creator.TextAlignmentJustify || l == nil || l._bcgeh {
+		return false
+	}
+	if ppr := c._bbegf; ppr != nil && isOn(ppr.SuppressAutoHyphens) {
+		return false
+	}
+	if l._eb-l._cf < float64(d.Settings.HyphenationZone()) {
+		return false
+	}
+	if limit := d.Settings.ConsecutiveHyphenLimit(); limit > 0 {
+		lines := c._debf._dcde
+		n := 0
+		for i := len(lines) - 2; i >= 0 && lines[i]._bcgeh; i-- {
+			n++
+		}
+		if n >= limit {
+			return false
+		}
+	}
+
+	text := []rune{}
+	for _, s := range w._cgc {
+		if s._gaa != nil || s._cbdg != nil || s._fba || utf8.RuneCountInString(s._fa) != 1 {
+			return false
+		}
+		r, _ := utf8.DecodeRuneInString(s._fa)
+		text = append(text, r)
+	}
+	// hyphenate the leading letters, trailing punctuation stays with the
+	// last part
+	n := 0
+	for n < len(text) && unicode.IsLetter(text[n]) {
+		n++
+	}
+	for _, r := range text[n:] {
+		if unicode.IsLetter(r) {
+			return false
+		}
+	}
+	letters := string(text[:n])
+	if d.Settings.DoNotHyphenateCaps() && letters == strings.ToUpper(letters) {
+		return false
+	}
+	h := hyphenatorFor(c.documentLanguage())
+	if h == nil {
+		return false
+	}
+	breaks := h.Hyphenate(letters)
+	for i := len(breaks) - 1; i >= 0; i-- {
+		k := breaks[i]
+		width := w._cgc[k]._ed
+		hy := hyphenSymbol(w._cgc[k-1])
+		if l._cf+width+hy._bfb > l._eb {
+			continue
+		}
+		hy._ed = width
+		head := &word{_cgc: append(append([]*symbol{}, w._cgc[:k]...), hy), _ffd: width + hy._bfb, _dga: l._cf}
+		tail := &word{_cgc: w._cgc[k:]}
+		for _, s := range tail._cgc {
+			s._ed = tail._ffd
+			tail._ffd += s._bfb
+		}
+		c._bdbb._fca = append(c._bdbb._fca, head)
+		l._cf += head._ffd
+		for _, s := range head._cgc {
+			c.adjustHeights(s._bae)
+		}
+		l._bcgeh = true
+		c._fdadg = tail
+		return true
+	}
+	return false
+}
+
+// hyphenSymbol returns a hyphen symbol with the style of the symbol.
+func hyphenSymbol(ref *symbol) *symbol {
+	hy := *ref
+	hy._fa = "-"
+	sp := creator.New().NewStyledParagraph()
+	sp.SetMargins(0, 0, 0, 0)
+	chunk := sp.Append(hy._fa)
+	spacing := 0.0
+	if hy._fbf != nil {
+		chunk.Style = *hy._fbf
+		spacing = hy._fbf.CharSpacing
+	}
+	hy._bfb = sp.Width() + spacing
+	return &hy
+}
+
+func isOn(v *wml.CT_OnOff) bool {
+	if v == nil {
+		return false
+	}
+	if v.ValAttr == nil {
+		return true
+	}
+	if v.ValAttr.Bool != nil {
+		return *v.ValAttr.Bool
+	}
+	return v.ValAttr.ST_OnOff1 == sharedTypes.ST_OnOff1On
+}
@@ -0,0 +1,42 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package convert
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegisterHyphenationPatterns(t *testing.T) {
+	patterns := "hy3ph he2n hena4 hen5at 1na n2at 1tio 2io o2n"
+	if err := RegisterHyphenationPatterns("en", strings.NewReader(patterns)); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterHyphenationPatterns("en-US", strings.NewReader("1tio")); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterHyphenationPatterns("fr", strings.NewReader(`\input hyph-fr`)); err == nil {
+		t.Error("registered invalid patterns")
+	}
+
+	// languages fall back to the patterns registered without a region
+	if h := hyphenatorFor("EN-gb"); h == nil || !reflect.DeepEqual(h.Hyphenate("hyphenation"), []int{2, 6}) {
+		t.Error("en-GB doesn't use the en patterns")
+	}
+	if h := hyphenatorFor("en-us"); h == nil || !reflect.DeepEqual(h.Hyphenate("hyphenation"), []int{7}) {
+		t.Error("en-US doesn't use its own patterns")
+	}
+	for _, lang := range []string{"fr-FR", "de", ""} {
+		if hyphenatorFor(lang) != nil {
+			t.Errorf("found patterns for %q", lang)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package convert
+
+import (
+	docmath "github.com/unidoc/unioffice/v2/document/math"
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/sharedTypes"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// addAbsoluteMath renders the equations contained in crc as text runs. This is
+// an approximation of Word's math layout, see math.Equation.Segments: scripts
+// are raised and lowered and bold and italic letters keep their style, while
+// fractions and radicals are drawn in linear form such as "(a+b)/2". Display
+// math is placed on its own lines.
+func (c *convertContext) addAbsoluteMath(crc *wml.EG_ContentRunContent, pPr *wml.CT_PPr) bool {
+	runs := []*wml.EG_ContentRunContent{}
+	for _, rle := range crc.ContentRunContentChoice.EG_RunLevelElts {
+		for _, mc := range rle.RunLevelEltsChoice.EG_MathContent {
+			if om := mc.MathContentChoice.OMath; om != nil {
+				runs = append(runs, mathRuns(docmath.FromX(&om.CT_OMath), false)...)
+			}
+			if mp := mc.MathContentChoice.OMathPara; mp != nil {
+				for _, eq := range docmath.ParagraphFromX(mp).Equations() {
+					runs = append(runs, mathRuns(eq, true)...)
+				}
+			}
+		}
+	}
+	if len(runs) == 0 {
+		return false
+	}
+	return c.addAbsoluteCRC(runs, pPr)
+}
+
+// mathRuns returns a run for each segment of an equation, optionally preceded
+// by a line break.
+func mathRuns(eq *docmath.Equation, newLine bool) []*wml.EG_ContentRunContent {
+	ret := []*wml.EG_ContentRunContent{}
+	if newLine {
+		r := wml.NewCT_R()
+		ric := wml.NewEG_RunInnerContent()
+		ric.RunInnerContentChoice.Br = wml.NewCT_Br()
+		r.EG_RunInnerContent = append(r.EG_RunInnerContent, ric)
+		ret = append(ret, contentRun(r))
+	}
+	for _, seg := range eq.Segments() {
+		r := wml.NewCT_R()
+		r.RPr = wml.NewCT_RPr()
+		if seg.Bold {
+			r.RPr.B = wml.NewCT_OnOff()
+		}
+		if seg.Italic {
+			r.RPr.I = wml.NewCT_OnOff()
+		}
+		switch {
+		case seg.Superscript:
+			r.RPr.VertAlign = &wml.CT_VerticalAlignRun{ValAttr: sharedTypes.ST_VerticalAlignRunSuperscript}
+		case seg.Subscript:
+			r.RPr.VertAlign = &wml.CT_VerticalAlignRun{ValAttr: sharedTypes.ST_VerticalAlignRunSubscript}
+		}
+		ric := wml.NewEG_RunInnerContent()
+		ric.RunInnerContentChoice.T = wml.NewCT_Text()
+		ric.RunInnerContentChoice.T.Content = seg.Text
+		r.EG_RunInnerContent = append(r.EG_RunInnerContent, ric)
+		ret = append(ret, contentRun(r))
+	}
+	return ret
+}
+
+func contentRun(r *wml.CT_R) *wml.EG_ContentRunContent {
+	crc := wml.NewEG_ContentRunContent()
+	crc.ContentRunContentChoice.R = r
+	return crc
+}
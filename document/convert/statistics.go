@@ -0,0 +1,116 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package convert
+
+import (
+	"github.com/unidoc/unioffice/v2/document"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+func init() {
+	document.RegisterLayoutCounter(countLayout)
+}
+
+// defaultCellMargin is the left and right margin of table cells used by Word
+// if the table doesn't set one.
+const defaultCellMargin = 108 * measurement.Twips
+
+// countLayout lays out the document as for conversion to PDF and returns the
+// number of pages and of lines of body text, including the lines of text in
+// tables.
+func countLayout(d *document.Document) (pages, lines int) {
+	c := layoutDocument(d, nil)
+	pages, lines = len(c._gbdfa), countLines(c)
+	// table cells are drawn by the PDF table, lay out their content again at
+	// the width of each cell to count the lines
+	tables := contentTables(d.X().Body.EG_BlockLevelElts)
+	if len(tables) == 0 {
+		return pages, lines
+	}
+	cells := newLayoutContext(d, nil)
+	for _, tbl := range tables {
+		cells.addTableCells(tbl)
+	}
+	return pages, lines + countLines(cells)
+}
+
+// countLines returns the number of lines of the paragraphs laid out in c.
+func countLines(c *convertContext) int {
+	n := 0
+	for _, p := range c._gbdfa {
+		for _, para := range p._efd {
+			n += len(para._dcde)
+		}
+	}
+	return n
+}
+
+// addTableCells lays out the content of the cells of tbl, including nested
+// tables, each at the width of its cell.
+func (c *convertContext) addTableCells(tbl *wml.CT_Tbl) {
+	cols := []float64{}
+	if tbl.TblGrid != nil {
+		for _, gc := range tbl.TblGrid.GridCol {
+			w := 0.0
+			if gc.WAttr != nil && gc.WAttr.ST_UnsignedDecimalNumber != nil {
+				w = float64(*gc.WAttr.ST_UnsignedDecimalNumber) * measurement.Twips
+			}
+			cols = append(cols, w)
+		}
+	}
+	for _, crc := range tbl.EG_ContentRowContent {
+		for _, tr := range crc.ContentRowContentChoice.Tr {
+			col := 0
+			for _, ccc := range tr.EG_ContentCellContent {
+				for _, tc := range ccc.ContentCellContentChoice.Tc {
+					span := 1
+					if tc.TcPr != nil && tc.TcPr.GridSpan != nil && tc.TcPr.GridSpan.ValAttr > 1 {
+						span = int(tc.TcPr.GridSpan.ValAttr)
+					}
+					width := 0.0
+					for i := col; i < col+span && i < len(cols); i++ {
+						width += cols[i]
+					}
+					col += span
+					if width <= 2*defaultCellMargin {
+						continue
+					}
+					c._gbfac.Right = c._gbfac.Left + width - 2*defaultCellMargin
+					for _, ble := range tc.EG_BlockLevelElts {
+						c.addAbsoluteCBCs(ble.BlockLevelEltsChoice.EG_ContentBlockContent, nil)
+					}
+					for _, nested := range contentTables(tc.EG_BlockLevelElts) {
+						c.addTableCells(nested)
+					}
+				}
+			}
+		}
+	}
+}
+
+// contentTables returns the tables of the block level content, including those
+// in block level content controls but not tables nested in tables.
+func contentTables(bles []*wml.EG_BlockLevelElts) []*wml.CT_Tbl {
+	ret := []*wml.CT_Tbl{}
+	var add func(cbcs []*wml.EG_ContentBlockContent)
+	add = func(cbcs []*wml.EG_ContentBlockContent) {
+		for _, cbc := range cbcs {
+			ret = append(ret, cbc.ContentBlockContentChoice.Tbl...)
+			if sdt := cbc.ContentBlockContentChoice.Sdt; sdt != nil && sdt.SdtContent != nil {
+				add(sdt.SdtContent.EG_ContentBlockContent)
+			}
+		}
+	}
+	for _, ble := range bles {
+		add(ble.BlockLevelEltsChoice.EG_ContentBlockContent)
+	}
+	return ret
+}
@@ -0,0 +1,52 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/document"
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/sharedTypes"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+func TestCountLayout(t *testing.T) {
+	d := document.New()
+	d.AddParagraph().AddRun().AddText("First paragraph.")
+	d.AddParagraph().AddRun().AddText("Second paragraph.")
+	s := d.StatisticsWithLayout()
+	if s.Pages != 1 || s.Lines != 2 {
+		t.Errorf("counted %d pages and %d lines, want 1 and 2", s.Pages, s.Lines)
+	}
+	if plain := d.Statistics(); plain.Pages != 0 || plain.Lines != 0 {
+		t.Errorf("Statistics laid out the document")
+	}
+
+	// the lines of table cells are counted too
+	tbl := d.AddTable()
+	tbl.X().TblGrid = wml.NewCT_TblGrid()
+	for i := 0; i < 2; i++ {
+		w := uint64(2880)
+		gc := wml.NewCT_TblGridCol()
+		gc.WAttr = &sharedTypes.ST_TwipsMeasure{ST_UnsignedDecimalNumber: &w}
+		tbl.X().TblGrid.GridCol = append(tbl.X().TblGrid.GridCol, gc)
+	}
+	row := tbl.AddRow()
+	row.AddCell().AddParagraph().AddRun().AddText("left")
+	row.AddCell().AddParagraph().AddRun().AddText("right")
+	s = d.UpdateAppProperties()
+	if s.Pages != 1 || s.Lines != 4 {
+		t.Errorf("counted %d pages and %d lines with a table, want 1 and 4", s.Pages, s.Lines)
+	}
+	x := d.AppProperties.X()
+	if x.Pages == nil || *x.Pages != 1 || x.Lines == nil || *x.Lines != 4 {
+		t.Errorf("app properties pages %v, lines %v", x.Pages, x.Lines)
+	}
+}
@@ -0,0 +1,255 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package convert
+
+import (
+	"fmt"
+
+	"github.com/unidoc/unioffice/v2/document/internal/semantic"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+	"github.com/unidoc/unipdf/v4/core"
+	"github.com/unidoc/unipdf/v4/creator"
+	"github.com/unidoc/unipdf/v4/model"
+)
+
+// tableMCIDBase is the first marked content ID of table contents. Tables are
+// tagged by unipdf, which numbers their rows and cells from the table's ID,
+// so they are kept clear of the IDs the creator assigns to other content.
+const tableMCIDBase = 1 << 20
+
+// tagger builds the structure tree of a tagged PDF as the pages are drawn.
+// The creator tags each drawn component and appends its element to the
+// document element, the tagger then moves the elements of glyphs and images
+// into the heading, paragraph, list and figure elements of the paragraph
+// being drawn.
+type tagger struct {
+	doc *model.KDict
+	// para is the source of the paragraph being drawn and elem its structure
+	// element, created when its first content is drawn, and parent the
+	// element containing it.
+	para         *wml.CT_P
+	elem, parent *model.KDict
+	// lists holds the open list and its last item for each list level.
+	lists     []listTag
+	tableMCID int64
+}
+
+type listTag struct {
+	list, item *model.KDict
+}
+
+// enableTagging makes the conversion output a tagged PDF.
+func (c *convertContext) enableTagging() {
+	root := model.NewStructTreeRoot()
+	c._affcc.SetStructTreeRoot(root)
+	c._affcc.TagComponents(true)
+	c._affcc.SetLanguage(c.documentLanguage())
+	c._fbdce = &tagger{doc: root.K[0], tableMCID: tableMCIDBase}
+}
+
+// tagParagraph starts the structure element of a paragraph about to be drawn.
+func (c *convertContext) tagParagraph(p *paragraph) {
+	if t := c._fbdce; t != nil {
+		t.para, t.elem = p._fegce, nil
+	}
+}
+
+// drawTagged draws a glyph or image of the current paragraph, adding it to
+// the structure element of the paragraph. Images are tagged as figures with
+// the alternative text of their drawing.
+func (c *convertContext) drawTagged(d creator.Drawable, altText string) error {
+	t := c._fbdce
+	if t == nil {
+		return c._affcc.Draw(d)
+	}
+	switch v := d.(type) {
+	case *creator.StyledParagraph:
+		v.SetStructureType(model.StructureTypeSpan)
+	case *creator.Image:
+		v.SetStructureType(model.StructureTypeFigure)
+	}
+	n := len(t.doc.GetChildren())
+	if err := c._affcc.Draw(d); err != nil {
+		return err
+	}
+	added := append([]*model.KValue{}, t.doc.GetChildren()[n:]...)
+	for len(t.doc.GetChildren()) > n {
+		t.doc.RemoveChildAt(n)
+	}
+	for _, kv := range added {
+		kd := kv.GetKDict()
+		if kd == nil {
+			continue
+		}
+		elem := c.paragraphTag()
+		page := kd.GetPageNumber()
+		if p := elem.GetPageNumber(); p != 0 && page != 0 && p != page {
+			// a structure element refers to the marked content of a single
+			// page, the paragraph continues on the next one in a new element
+			elem = t.continueTag()
+		}
+		if elem.GetPageNumber() == 0 {
+			elem.SetPageNumber(page)
+		}
+		if name, _ := core.GetNameVal(kd.S); name == string(model.StructureTypeSpan) {
+			if mcid, ok := core.GetIntVal(kd.K); ok {
+				elem.AddMCIDChild(mcid)
+				continue
+			}
+		}
+		if altText != "" {
+			kd.Alt = core.MakeString(altText)
+		}
+		elem.AddKChild(kd)
+	}
+	return nil
+}
+
+// paragraphTag returns the structure element of the current paragraph,
+// creating it from the role of the paragraph: a heading, a list item body or
+// a plain paragraph.
+func (c *convertContext) paragraphTag() *model.KDict {
+	t := c._fbdce
+	if t.elem != nil {
+		return t.elem
+	}
+	styles := c._gbgdc.Styles.X()
+	if lvl, ok := semantic.ListLevel(styles, t.para); ok {
+		if len(t.lists) > lvl+1 {
+			t.lists = t.lists[:lvl+1]
+		}
+		for len(t.lists) < lvl+1 {
+			parent := t.doc
+			if n := len(t.lists); n > 0 {
+				// nested lists belong to the last item of the enclosing list
+				if parent = t.lists[n-1].item; parent == nil {
+					parent = t.lists[n-1].list
+				}
+			}
+			l := newTag(model.StructureTypeList)
+			parent.AddKChild(l)
+			t.lists = append(t.lists, listTag{list: l})
+		}
+		li := newTag(model.StructureTypeListItem)
+		t.lists[lvl].list.AddKChild(li)
+		t.lists[lvl].item = li
+		t.elem, t.parent = newTag(model.StructureTypeListBody), li
+		li.AddKChild(t.elem)
+		return t.elem
+	}
+	t.lists = nil
+	typ := model.StructureTypeParagraph
+	if lvl := semantic.HeadingLevel(styles, t.para); lvl > 0 {
+		// PDF has six heading levels, deeper headings are tagged H6
+		if lvl > 6 {
+			lvl = 6
+		}
+		typ = model.StructureType(fmt.Sprintf("H%d", lvl))
+	}
+	t.elem, t.parent = newTag(typ), t.doc
+	t.doc.AddKChild(t.elem)
+	return t.elem
+}
+
+// continueTag starts a new element of the type of the current paragraph's
+// element for its content on the next page.
+func (t *tagger) continueTag() *model.KDict {
+	elem := model.NewKDictionary()
+	elem.S = t.elem.S
+	t.parent.AddKChild(elem)
+	t.elem = elem
+	return elem
+}
+
+// tagTable tags the rows and cells of a table about to be drawn.
+func (c *convertContext) tagTable(tbl *creator.Table) {
+	t := c._fbdce
+	if t == nil {
+		return
+	}
+	t.lists = nil
+	page := int64(c._affcc.Context().Page)
+	tbl.SetStructureType(model.StructureTypeTable)
+	tbl.SetStructPageNumber(&page)
+	tbl.SetMarkedContentID(t.tableMCID)
+	tbl.AddTag(t.doc)
+	t.tableMCID += int64(1 + tbl.Rows()*(tbl.Cols()+1))
+}
+
+// artifactSubtype returns s if the output is tagged and an empty subtype,
+// which leaves the content unmarked, otherwise.
+func (c *convertContext) artifactSubtype(s model.ArtifactSubtype) model.ArtifactSubtype {
+	if c._fbdce == nil {
+		return ""
+	}
+	return s
+}
+
+// markArtifact marks a glyph or image of a header or footer as a pagination
+// artifact of the given subtype, keeping it out of the document's content
+// for assistive technology.
+func markArtifact(d creator.Drawable, subtype model.ArtifactSubtype) {
+	if subtype == "" {
+		return
+	}
+	var a *model.Artifact
+	switch v := d.(type) {
+	case *creator.StyledParagraph:
+		a = v.MarkAsArtifact(model.ArtifactTypePagination)
+	case *creator.Image:
+		a = v.MarkAsArtifact(model.ArtifactTypePagination)
+	default:
+		return
+	}
+	a.Subtype = string(subtype)
+}
+
+// drawArtifact draws the block of a header or footer. Its content is marked
+// as artifacts, so the elements the creator adds for it are removed from the
+// structure tree.
+func (c *convertContext) drawArtifact(b *creator.Block) error {
+	t := c._fbdce
+	if t == nil {
+		return c._affcc.Draw(b)
+	}
+	n := len(t.doc.GetChildren())
+	err := c._affcc.Draw(b)
+	for len(t.doc.GetChildren()) > n {
+		t.doc.RemoveChildAt(n)
+	}
+	return err
+}
+
+// finishTagging gives the elements of content drawn outside of paragraphs,
+// e.g. borders, a structure type if the creator left it empty.
+func (c *convertContext) finishTagging() {
+	t := c._fbdce
+	if t == nil {
+		return
+	}
+	for _, kv := range t.doc.GetChildren() {
+		if kd := kv.GetKDict(); kd != nil {
+			if name, _ := core.GetNameVal(kd.S); name == "" {
+				kd.S = core.MakeName(string(model.StructureTypeNonStructural))
+			}
+		}
+	}
+}
+
+func newTag(typ model.StructureType) *model.KDict {
+	kd := model.NewKDictionary()
+	kd.S = core.MakeName(string(typ))
+	return kd
+}
+
+// inlineAltText returns the alternative text of an inline drawing.
+func inlineAltText(inline *wml.WdInline) string {
+	return semantic.AltText(inline.DocPr)
+}
@@ -0,0 +1,195 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"path"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/common/logger"
+	"github.com/unidoc/unioffice/v2/common/tempstorage"
+	"github.com/unidoc/unioffice/v2/diagram"
+	"github.com/unidoc/unioffice/v2/measurement"
+	dgm "github.com/unidoc/unioffice/v2/schema/soo/dml/diagram"
+)
+
+// diagramPart is a SmartArt diagram loaded from the document, written back
+// when the document is saved.
+type diagramPart struct {
+	dg          *diagram.Diagram
+	dataPath    string
+	drawingPath string
+}
+
+// Diagrams returns the SmartArt diagrams in the document body in document
+// order. Changes made to the diagrams are written when the document is saved.
+func (d *Document) Diagrams() []*diagram.Diagram {
+	ret := []*diagram.Diagram{}
+	for _, p := range d.paragraphsInOrder() {
+		for _, r := range p.Runs() {
+			for _, dr := range r.DrawingInline() {
+				if dg, ok := dr.Diagram(); ok {
+					ret = append(ret, dg)
+				}
+			}
+			for _, dr := range r.DrawingAnchored() {
+				if dg, ok := dr.Diagram(); ok {
+					ret = append(ret, dg)
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// Diagram returns the SmartArt diagram of an inline drawing, if it holds one.
+func (i InlineDrawing) Diagram() (*diagram.Diagram, bool) {
+	if i._edfce.Graphic == nil || i._edfce.Graphic.GraphicData == nil {
+		return nil, false
+	}
+	for _, a := range i._edfce.Graphic.GraphicData.Any {
+		if ids, ok := a.(*dgm.RelIds); ok {
+			return i._affc.diagram(&ids.CT_RelIds, i._edfce.Extent.CxAttr, i._edfce.Extent.CyAttr)
+		}
+	}
+	return nil, false
+}
+
+// Diagram returns the SmartArt diagram of an anchored drawing, if it holds
+// one.
+func (a AnchoredDrawing) Diagram() (*diagram.Diagram, bool) {
+	if a._ag.Graphic == nil || a._ag.Graphic.GraphicData == nil {
+		return nil, false
+	}
+	for _, el := range a._ag.Graphic.GraphicData.Any {
+		if ids, ok := el.(*dgm.RelIds); ok {
+			return a._dc.diagram(&ids.CT_RelIds, a._ag.Extent.CxAttr, a._ag.Extent.CyAttr)
+		}
+	}
+	return nil, false
+}
+
+// GetDiagramByRelIds returns the SmartArt diagram referenced by the
+// relationship IDs of a diagram graphic frame.
+func (d *Document) GetDiagramByRelIds(ids *dgm.CT_RelIds) (*diagram.Diagram, bool) {
+	return d.diagram(ids, 0, 0)
+}
+
+// diagram returns the diagram with the given relationship IDs, loading it
+// from the document parts on first use. Non-zero sizes in EMU set the size of
+// the diagram frame.
+func (d *Document) diagram(ids *dgm.CT_RelIds, cx, cy int64) (*diagram.Diagram, bool) {
+	dataPath, storagePath := d.relPartPath(ids.DmAttr)
+	if storagePath == "" {
+		return nil, false
+	}
+	var dg *diagram.Diagram
+	for _, p := range d.diagrams {
+		if p.dataPath == dataPath {
+			dg = p.dg
+		}
+	}
+	if dg == nil {
+		f, err := tempstorage.Open(storagePath)
+		if err != nil {
+			logger.Log.Debug("unable to open diagram data: %s", err)
+			return nil, false
+		}
+		dg, err = diagram.Read(f)
+		f.Close()
+		if err != nil {
+			logger.Log.Debug("unable to read diagram data: %s", err)
+			return nil, false
+		}
+		part := &diagramPart{dg: dg, dataPath: dataPath}
+		if drawingPath, drawingStorage := d.relPartPath(dg.DrawingRelID()); drawingStorage != "" {
+			if f, err := tempstorage.Open(drawingStorage); err == nil {
+				dr, err := diagram.ReadDrawing(f)
+				f.Close()
+				if err == nil {
+					dg.SetDrawing(dr)
+					part.drawingPath = drawingPath
+				} else {
+					logger.Log.Debug("unable to read diagram drawing: %s", err)
+				}
+			}
+		}
+		d.diagrams = append(d.diagrams, part)
+	}
+	if cx > 0 && cy > 0 {
+		dg.SetSize(measurement.Distance(cx)*measurement.EMU, measurement.Distance(cy)*measurement.EMU)
+	}
+	return dg, true
+}
+
+// relPartPath returns the package path and the storage path of the
+// part with the given relationship ID, read as an extra file.
+func (d *Document) relPartPath(relID string) (string, string) {
+	if relID == "" {
+		return "", ""
+	}
+	target := d._ead.GetTargetByRelId(relID)
+	if target == "" {
+		return "", ""
+	}
+	zipPath := strings.TrimPrefix(target, "/")
+	if !strings.HasPrefix(target, "/") {
+		zipPath = path.Join("word", target)
+	}
+	for _, ef := range d.ExtraFiles {
+		if ef.ZipPath == zipPath {
+			return zipPath, ef.StoragePath
+		}
+	}
+	return zipPath, ""
+}
+
+// saveDiagrams writes the loaded diagrams back to their parts, adding a
+// drawing part to diagrams that have a drawing but had none when loaded.
+func (d *Document) saveDiagrams() error {
+	for _, p := range d.diagrams {
+		if p.dg.Drawing() != nil {
+			if p.drawingPath == "" {
+				p.drawingPath = d.freeExtraFilePath("word/diagrams/drawing%d.xml", 1)
+				rel := d._ead.AddRelationship(strings.TrimPrefix(p.drawingPath, "word/"), diagram.DrawingType)
+				p.dg.SetDrawingRelID(rel.ID())
+				d.ContentTypes.AddOverride("/"+p.drawingPath, diagram.DrawingContentType)
+			}
+			storagePath, err := writeTempFile("diagram-drawing-", func(f tempstorage.File) error {
+				return p.dg.Drawing().Write(f)
+			})
+			if err != nil {
+				return err
+			}
+			d.setExtraFile(p.drawingPath, storagePath)
+		}
+		storagePath, err := writeTempFile("diagram-data-", func(f tempstorage.File) error {
+			return p.dg.Write(f)
+		})
+		if err != nil {
+			return err
+		}
+		d.setExtraFile(p.dataPath, storagePath)
+	}
+	return nil
+}
+
+// setExtraFile sets the storage path of the extra file with the given package
+// path, adding the extra file if it doesn't exist.
+func (d *Document) setExtraFile(zipPath, storagePath string) {
+	for i, ef := range d.ExtraFiles {
+		if ef.ZipPath == zipPath {
+			d.ExtraFiles[i].StoragePath = storagePath
+			return
+		}
+	}
+	d.ExtraFiles = append(d.ExtraFiles, common.ExtraFile{ZipPath: zipPath, StoragePath: storagePath})
+}
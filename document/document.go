@@ -1161,7 +1161,7 @@ func (_gafgd RunProperties )SetAllCaps (b bool ){if !b {_gafgd ._ccfdc .Caps =ni
 // format. It can be opened from a file on disk and modified, or created from
 // scratch.
 type Document struct{_da .DocBase ;_bbe *_cc .Document ;Settings Settings ;Numbering Numbering ;Styles Styles ;_ade []*_cc .Hdr ;_bbcd []_da .Relationships ;_bgc []*_cc .Ftr ;_dga []_da .Relationships ;_ead _da .Relationships ;_bdc []*_ab .Theme ;_cdac *_cc .WebSettings ;
-_ecbgf *_cc .Fonts ;_aaff _da .Relationships ;_bdcb *_cc .Endnotes ;_gbd *_cc .Footnotes ;_efa []*_fd .Control ;_dbg []*chart ;_ebd *_cc .Comments ;_cdbe *_bc .CommentsEx ;_bcae *_ce .CommentsExtensible ;_fgcd *_ca .CommentsIds ;_edgg string ;};func (_ceba *Document )ensureTableGrids (){for _ ,_cfag :=range _ceba .Tables (){_cfag .EnsureGridColumns ();
+_ecbgf *_cc .Fonts ;_aaff _da .Relationships ;_bdcb *_cc .Endnotes ;_gbd *_cc .Footnotes ;_efa []*_fd .Control ;_dbg []*chart ;diagrams []*diagramPart ;glossaryPart *glossaryPart ;_ebd *_cc .Comments ;_cdbe *_bc .CommentsEx ;_bcae *_ce .CommentsExtensible ;_fgcd *_ca .CommentsIds ;_edgg string ;};func (_ceba *Document )ensureTableGrids (){for _ ,_cfag :=range _ceba .Tables (){_cfag .EnsureGridColumns ();
 };for _ ,_fbcg :=range _ceba .Headers (){for _ ,_facgc :=range _fbcg .Tables (){_facgc .EnsureGridColumns ();};};for _ ,_bgec :=range _ceba .Footers (){for _ ,_fgdb :=range _bgec .Tables (){_fgdb .EnsureGridColumns ();};};};
 
 // Style return the table style.
@@ -1924,7 +1924,7 @@ _fabbc :=_cd .Sprintf ("\u006d\u0065d\u0069\u0061\u002fi\u006d\u0061\u0067\u0065
 type RunProperties struct{_ccfdc *_cc .CT_RPr };
 
 // IsBold returns true if the run has been set to bold.
-func (_edgc RunProperties )IsBold ()bool {return _edgc .BoldValue ()==OnOffValueOn };func (_gafac *Document )save (_gdfa _b .Writer ,_bbba string )error {const _gfc ="\u0064o\u0063u\u006d\u0065\u006e\u0074\u003a\u0064\u002e\u0053\u0061\u0076\u0065";_gafac .ensureTableGrids ();
+func (_edgc RunProperties )IsBold ()bool {return _edgc .BoldValue ()==OnOffValueOn };func (_gafac *Document )save (_gdfa _b .Writer ,_bbba string )error {const _gfc ="\u0064o\u0063u\u006d\u0065\u006e\u0074\u003a\u0064\u002e\u0053\u0061\u0076\u0065";_gafac .ensureTableGrids ();if err :=_gafac .saveDiagrams ();err !=nil {return err ;};if _dcbfe :=_gafac .saveGlossary ();_dcbfe !=nil {return _dcbfe ;};
 if _ecbb :=_gafac ._bbe .Validate ();_ecbb !=nil {_gbg .Log .Warning ("\u0076\u0061\u006c\u0069\u0064\u0061\u0074\u0069\u006f\u006e\u0020\u0065\u0072\u0072\u006fr\u0020i\u006e\u0020\u0064\u006f\u0063\u0075\u006d\u0065\u006e\u0074\u003a\u0020\u0025\u0073",_ecbb );
 };_dfcc :=_c .DocTypeDocument ;if !_gae .GetLicenseKey ().IsLicensed ()&&!_gebe {_cd .Println ("\u0055\u006e\u006ci\u0063\u0065\u006e\u0073e\u0064\u0020\u0076\u0065\u0072\u0073\u0069o\u006e\u0020\u006f\u0066\u0020\u0055\u006e\u0069\u004f\u0066\u0066\u0069\u0063\u0065");
 _cd .Println ("\u002d\u0020\u0047e\u0074\u0020\u0061\u0020\u0074\u0072\u0069\u0061\u006c\u0020\u006c\u0069\u0063\u0065\u006e\u0073\u0065\u0020\u006f\u006e\u0020\u0068\u0074\u0074\u0070\u0073\u003a\u002f\u002fu\u006e\u0069\u0064\u006f\u0063\u002e\u0069\u006f");
@@ -0,0 +1,96 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	docmath "github.com/unidoc/unioffice/v2/document/math"
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/math"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// AddEquation adds an inline equation at the end of the paragraph. The
+// returned equation refers to the inserted copy and can be further edited.
+func (p Paragraph) AddEquation(eq *docmath.Equation) *docmath.Equation {
+	om := math.NewOMath()
+	if eq != nil {
+		om.CT_OMath = *eq.X()
+	}
+	mc := p.addMathContent()
+	mc.MathContentChoice.OMath = om
+	return docmath.FromX(&om.CT_OMath)
+}
+
+// AddLaTeXEquation parses a LaTeX math expression and adds it as an inline
+// equation at the end of the paragraph.
+func (p Paragraph) AddLaTeXEquation(latex string) (*docmath.Equation, error) {
+	eq, err := docmath.ParseLaTeX(latex)
+	if err != nil {
+		return nil, err
+	}
+	return p.AddEquation(eq), nil
+}
+
+// AddDisplayEquation adds a display (block) math paragraph holding the given
+// equations, one per line, justified as specified.
+func (p Paragraph) AddDisplayEquation(jc math.ST_Jc, eqs ...*docmath.Equation) docmath.Paragraph {
+	mp := docmath.NewParagraph(jc, eqs...)
+	mc := p.addMathContent()
+	mc.MathContentChoice.OMathPara = mp.X()
+	return mp
+}
+
+// Equations returns the equations contained in the paragraph, including the
+// individual equations of display math.
+func (p Paragraph) Equations() []*docmath.Equation {
+	ret := []*docmath.Equation{}
+	for _, mc := range p.mathContent() {
+		if om := mc.MathContentChoice.OMath; om != nil {
+			ret = append(ret, docmath.FromX(&om.CT_OMath))
+		}
+		if mp := mc.MathContentChoice.OMathPara; mp != nil {
+			ret = append(ret, docmath.ParagraphFromX(mp).Equations()...)
+		}
+	}
+	return ret
+}
+
+// Equations returns all of the equations in the document body in document
+// order.
+func (d *Document) Equations() []*docmath.Equation {
+	ret := []*docmath.Equation{}
+	for _, p := range d.paragraphsInOrder() {
+		ret = append(ret, p.Equations()...)
+	}
+	return ret
+}
+
+func (p Paragraph) addMathContent() *wml.EG_MathContent {
+	pc := wml.NewEG_PContent()
+	p._cebfg.EG_PContent = append(p._cebfg.EG_PContent, pc)
+	crc := wml.NewEG_ContentRunContent()
+	pc.PContentChoice.EG_ContentRunContent = append(pc.PContentChoice.EG_ContentRunContent, crc)
+	rle := wml.NewEG_RunLevelElts()
+	crc.ContentRunContentChoice.EG_RunLevelElts = append(crc.ContentRunContentChoice.EG_RunLevelElts, rle)
+	mc := wml.NewEG_MathContent()
+	rle.RunLevelEltsChoice.EG_MathContent = append(rle.RunLevelEltsChoice.EG_MathContent, mc)
+	return mc
+}
+
+func (p Paragraph) mathContent() []*wml.EG_MathContent {
+	ret := []*wml.EG_MathContent{}
+	for _, pc := range p._cebfg.EG_PContent {
+		for _, crc := range pc.PContentChoice.EG_ContentRunContent {
+			for _, rle := range crc.ContentRunContentChoice.EG_RunLevelElts {
+				ret = append(ret, rle.RunLevelEltsChoice.EG_MathContent...)
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,52 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"reflect"
+	"testing"
+
+	docmath "github.com/unidoc/unioffice/v2/document/math"
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/math"
+)
+
+func TestEquations(t *testing.T) {
+	d := New()
+	p := d.AddParagraph()
+	p.AddRun().AddText("where ")
+	eq, err := p.AddLaTeXEquation(`\frac{a}{b}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the returned equation refers to the document
+	eq.Append(docmath.Text("+c"))
+	if _, err := p.AddLaTeXEquation(`\frac{a}`); err == nil {
+		t.Error("added an invalid equation")
+	}
+	d.AddParagraph().AddDisplayEquation(math.ST_JcCenter,
+		docmath.New(docmath.Sup(docmath.Text("x"), docmath.Text("2"))),
+		docmath.New(docmath.Sqrt(docmath.Text("y"))))
+
+	check := func(d *Document) {
+		t.Helper()
+		got := []string{}
+		for _, eq := range d.Equations() {
+			got = append(got, eq.LaTeX())
+		}
+		if want := []string{`\frac{a}{b}+c`, "x^{2}", `\sqrt{y}`}; !reflect.DeepEqual(got, want) {
+			t.Errorf("equations = %q, want %q", got, want)
+		}
+		if n := len(d.Paragraphs()[0].Equations()); n != 1 {
+			t.Errorf("first paragraph has %d equations, want 1", n)
+		}
+	}
+	check(d)
+	check(roundTrip(t, d))
+}
@@ -0,0 +1,629 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"errors"
+	"regexp"
+	"time"
+	"unicode/utf8"
+
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// FindScope selects the parts of a document that are searched.
+type FindScope uint16
+
+// FindScope constants, which can be combined.
+const (
+	FindInBody FindScope = 1 << iota
+	FindInHeaders
+	FindInFooters
+	FindInFootnotes
+	FindInEndnotes
+	FindInComments
+	// FindInTextBoxes searches the text boxes of shapes anchored in the other
+	// searched parts.
+	FindInTextBoxes
+
+	FindEverywhere = FindInBody | FindInHeaders | FindInFooters | FindInFootnotes |
+		FindInEndnotes | FindInComments | FindInTextBoxes
+)
+
+// FindOptions controls how text is searched.
+type FindOptions struct {
+	// MatchCase makes the search case sensitive.
+	MatchCase bool
+	// WholeWord only matches the pattern at word boundaries.
+	WholeWord bool
+	// Regexp interprets the pattern as a regular expression. Replacement text
+	// can then refer to submatches as $1 or ${name}.
+	Regexp bool
+	// Scope selects the searched parts of the document, zero searches
+	// everywhere.
+	Scope FindScope
+}
+
+// TextPosition is a character position in the text of a run, counting tabs
+// as one character.
+type TextPosition struct {
+	Run    Run
+	Offset int
+}
+
+// Match is a match of a search in the text of a paragraph. A match may span
+// several runs, End is the position just after the last matched character.
+type Match struct {
+	Paragraph Paragraph
+	Start     TextPosition
+	End       TextPosition
+	Text      string
+	// Groups are the submatches of a regular expression search.
+	Groups []string
+
+	re   *regexp.Regexp
+	src  string
+	idx  []int
+	revs *revisionIDs
+}
+
+// ReplaceOptions controls how a match is replaced.
+type ReplaceOptions struct {
+	// Properties are applied to the replacement runs. If nil, the replacement
+	// takes the properties of the first replaced run.
+	Properties *RunProperties
+	// TrackChanges records the replacement as a tracked deletion of the match
+	// and insertion of the replacement.
+	TrackChanges bool
+	// Author and Date are recorded with tracked changes, Date defaults to the
+	// current time.
+	Author string
+	Date   time.Time
+}
+
+// FindAll returns all the matches of the pattern in the document, in the
+// order of the searched parts. Matches don't cross paragraph boundaries but
+// may span several runs.
+func (d *Document) FindAll(pattern string, opts FindOptions) ([]Match, error) {
+	re, err := compileFindPattern(pattern, opts)
+	if err != nil {
+		return nil, err
+	}
+	revs := &revisionIDs{d: d}
+	ret := []Match{}
+	for _, p := range d.searchParagraphs(opts.Scope) {
+		ret = append(ret, p.findAll(re, opts.Regexp, revs)...)
+	}
+	return ret, nil
+}
+
+// ReplaceAll replaces all the matches of the pattern with text and returns the
+// number of replacements.
+func (d *Document) ReplaceAll(pattern, text string, opts FindOptions, ropts ReplaceOptions) (int, error) {
+	matches, err := d.FindAll(pattern, opts)
+	if err != nil {
+		return 0, err
+	}
+	// replace from the end so that the positions of earlier matches in the
+	// same runs stay valid
+	for i := len(matches) - 1; i >= 0; i-- {
+		matches[i].ReplaceWithText(text, ropts)
+	}
+	return len(matches), nil
+}
+
+func compileFindPattern(pattern string, opts FindOptions) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, errors.New("empty search pattern")
+	}
+	if !opts.Regexp {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.WholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !opts.MatchCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// searchParagraphs returns the paragraphs of the parts selected by the scope.
+func (d *Document) searchParagraphs(scope FindScope) []Paragraph {
+	if scope == 0 {
+		scope = FindEverywhere
+	}
+	ret := []Paragraph{}
+	if scope&FindInBody != 0 {
+		ret = append(ret, d.paragraphsInOrder()...)
+	}
+	if scope&FindInHeaders != 0 {
+		for _, h := range d.Headers() {
+			ret = append(ret, d.storyParagraphs(h.X().EG_BlockLevelElts)...)
+		}
+	}
+	if scope&FindInFooters != 0 {
+		for _, f := range d.Footers() {
+			ret = append(ret, d.storyParagraphs(f.X().EG_BlockLevelElts)...)
+		}
+	}
+	if scope&FindInFootnotes != 0 && d._gbd != nil {
+		for _, f := range d.Footnotes() {
+			ret = append(ret, d.blockParagraphs(f.content())...)
+		}
+	}
+	if scope&FindInEndnotes != 0 && d._bdcb != nil {
+		for _, e := range d.Endnotes() {
+			ret = append(ret, d.blockParagraphs(e.content())...)
+		}
+	}
+	if scope&FindInComments != 0 {
+		for _, c := range d.Comments() {
+			ret = append(ret, d.storyParagraphs(c.X().EG_BlockLevelElts)...)
+		}
+	}
+	if scope&FindInTextBoxes != 0 {
+		ret = append(ret, d.textBoxParagraphs(ret)...)
+	}
+	return ret
+}
+
+func (d *Document) storyParagraphs(bles []*wml.EG_BlockLevelElts) []Paragraph {
+	ret := []Paragraph{}
+	for _, ble := range bles {
+		ret = append(ret, d.blockParagraphs(ble.BlockLevelEltsChoice.EG_ContentBlockContent)...)
+	}
+	return ret
+}
+
+// textBoxParagraphs returns the paragraphs of the text boxes of shapes
+// anchored in the paragraphs, including nested text boxes.
+func (d *Document) textBoxParagraphs(ps []Paragraph) []Paragraph {
+	ret := []Paragraph{}
+	for _, p := range ps {
+		for _, r := range p.Runs() {
+			shapes := r.Shapes()
+			for _, g := range r.ShapeGroups() {
+				shapes = append(shapes, g.AllShapes()...)
+			}
+			for _, s := range shapes {
+				if tc := s.txbxContent(false); tc != nil {
+					tps := d.storyParagraphs(tc.EG_BlockLevelElts)
+					ret = append(ret, tps...)
+					ret = append(ret, d.textBoxParagraphs(tps)...)
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// findAll returns the matches of re in the text of the paragraph.
+func (p Paragraph) findAll(re *regexp.Regexp, expand bool, revs *revisionIDs) []Match {
+	runs := p.Runs()
+	if len(runs) == 0 {
+		return nil
+	}
+	text := ""
+	// starts and ends hold the byte offsets of the text of each run
+	starts := make([]int, len(runs))
+	ends := make([]int, len(runs))
+	for i, r := range runs {
+		starts[i] = len(text)
+		text += r.Text()
+		ends[i] = len(text)
+	}
+	// position returns the position of the character starting at off, or
+	// ending at off if end is set
+	position := func(off int, end bool) TextPosition {
+		i := 0
+		for i < len(runs)-1 && (!end && off >= ends[i] || end && off > ends[i]) {
+			i++
+		}
+		return TextPosition{runs[i], utf8.RuneCountInString(text[starts[i]:off])}
+	}
+
+	ret := []Match{}
+	for _, idx := range re.FindAllStringSubmatchIndex(text, -1) {
+		if idx[0] == idx[1] {
+			continue
+		}
+		m := Match{
+			Paragraph: p,
+			Start:     position(idx[0], false),
+			End:       position(idx[1], true),
+			Text:      text[idx[0]:idx[1]],
+			src:       text,
+			idx:       idx,
+			revs:      revs,
+		}
+		for g := 2; g+1 < len(idx); g += 2 {
+			if idx[g] >= 0 {
+				m.Groups = append(m.Groups, text[idx[g]:idx[g+1]])
+			} else {
+				m.Groups = append(m.Groups, "")
+			}
+		}
+		if expand {
+			m.re = re
+		}
+		ret = append(ret, m)
+	}
+	return ret
+}
+
+// ReplaceWithText replaces the match with text and returns the run holding
+// the replacement. For regular expression searches the text can refer to
+// submatches as $1 or ${name}.
+func (m Match) ReplaceWithText(text string, opts ReplaceOptions) Run {
+	if m.re != nil {
+		text = string(m.re.ExpandString(nil, text, m.src, m.idx))
+	}
+	var ret Run
+	m.replace(opts, func(p Paragraph) {
+		ret = p.AddRun()
+		ret.AddText(text)
+	})
+	return ret
+}
+
+// ReplaceWithHyperlink replaces the match with a hyperlink to url displaying
+// text. Matches inside hyperlinks or content controls can't be replaced by a
+// hyperlink.
+func (m Match) ReplaceWithHyperlink(url, text string, opts ReplaceOptions) (HyperLink, error) {
+	if _, _, top := m.Paragraph.runContainer(m.Start.Run._bbdb); !top {
+		return HyperLink{}, errors.New("cannot insert a hyperlink inside a hyperlink or content control")
+	}
+	var ret HyperLink
+	m.replace(opts, func(p Paragraph) {
+		ret = p.AddHyperLink()
+		ret.SetTarget(url)
+		ret.AddRun().AddText(text)
+	})
+	return ret, nil
+}
+
+// ReplaceWithImage replaces the match with an inline image. The image must
+// have been added to the document with AddImage.
+func (m Match) ReplaceWithImage(img common.ImageRef, opts ReplaceOptions) (InlineDrawing, error) {
+	var ret InlineDrawing
+	var err error
+	m.replace(opts, func(p Paragraph) {
+		ret, err = p.AddRun().AddDrawingInline(img)
+	})
+	return ret, err
+}
+
+// ReplaceWithField replaces the match with a field, such as "PAGE" or
+// "DATE \@ \"d MMMM yyyy\"". The field is marked to be updated when the
+// document is opened.
+func (m Match) ReplaceWithField(code string, opts ReplaceOptions) Run {
+	var ret Run
+	m.replace(opts, func(p Paragraph) {
+		ret = p.AddRun()
+		ret.AddField(code)
+	})
+	return ret
+}
+
+// replace removes the matched text, or marks it as deleted when tracking
+// changes, and inserts the content added by build to a scratch paragraph in
+// its place.
+func (m Match) replace(opts ReplaceOptions, build func(p Paragraph)) {
+	p := m.Paragraph
+	start, end := m.Start.Run, m.End.Run
+	if end.textLen() > m.End.Offset {
+		p.splitRun(end, m.End.Offset)
+	}
+	if m.Start.Offset > 0 {
+		sameRun := start._bbdb == end._bbdb
+		start = p.splitRun(start, m.Start.Offset)
+		if sameRun {
+			end = start
+		}
+	}
+
+	matched := []Run{}
+	in := false
+	for _, r := range p.Runs() {
+		if r._bbdb == start._bbdb {
+			in = true
+		}
+		if in {
+			matched = append(matched, r)
+		}
+		if r._bbdb == end._bbdb {
+			break
+		}
+	}
+	if len(matched) == 0 {
+		return
+	}
+
+	scratch := Paragraph{p._adga, wml.NewCT_P()}
+	build(scratch)
+	rpr := matched[0]._bbdb.RPr
+	if opts.Properties != nil {
+		rpr = opts.Properties._ccfdc
+	}
+	if rpr != nil {
+		for _, r := range scratch.Runs() {
+			r.SetProperties(RunProperties{rpr})
+		}
+	}
+
+	if opts.TrackChanges {
+		date := opts.Date
+		if date.IsZero() {
+			date = time.Now()
+		}
+		for _, r := range matched {
+			for _, ic := range r._bbdb.EG_RunInnerContent {
+				if t := ic.RunInnerContentChoice.T; t != nil {
+					ic.RunInnerContentChoice.DelText = t
+					ic.RunInnerContentChoice.T = nil
+				}
+			}
+			if c, i, _ := p.runContainer(r._bbdb); c != nil {
+				(*c)[i] = m.trackedRun(r._bbdb, opts.Author, date, true)
+			}
+		}
+		for _, pc := range scratch._cebfg.EG_PContent {
+			crcs := pc.PContentChoice.EG_ContentRunContent
+			if hl := pc.PContentChoice.Hyperlink; hl != nil {
+				crcs = hl.PContentChoice.EG_ContentRunContent
+			}
+			for i, crc := range crcs {
+				if r := crc.ContentRunContentChoice.R; r != nil {
+					crcs[i] = m.trackedRun(r, opts.Author, date, false)
+				}
+			}
+		}
+		p.insertContentAfterTracked(matched[len(matched)-1]._bbdb, scratch._cebfg.EG_PContent)
+		return
+	}
+
+	first := matched[0]._bbdb
+	for _, r := range matched[1:] {
+		if c, i, _ := p.runContainer(r._bbdb); c != nil {
+			*c = append((*c)[:i], (*c)[i+1:]...)
+		}
+	}
+	p.insertContentAt(first, scratch._cebfg.EG_PContent, true)
+}
+
+// trackedRun wraps a run in a tracked deletion or insertion.
+func (m Match) trackedRun(r *wml.CT_R, author string, date time.Time, deleted bool) *wml.EG_ContentRunContent {
+	tc := wml.NewCT_RunTrackChange()
+	tc.AuthorAttr = author
+	tc.DateAttr = &date
+	tc.IdAttr = m.revs.next()
+	choice := wml.NewCT_RunTrackChangeChoice()
+	choice.ContentRunContentChoice = wml.NewEG_ContentRunContentChoice()
+	choice.ContentRunContentChoice.R = r
+	tc.RunTrackChangeChoice = append(tc.RunTrackChangeChoice, choice)
+
+	rle := wml.NewEG_RunLevelElts()
+	if deleted {
+		rle.RunLevelEltsChoice.Del = tc
+	} else {
+		rle.RunLevelEltsChoice.Ins = tc
+	}
+	crc := wml.NewEG_ContentRunContent()
+	crc.ContentRunContentChoice.EG_RunLevelElts = append(crc.ContentRunContentChoice.EG_RunLevelElts, rle)
+	return crc
+}
+
+// findTracked returns the run content container and index of the tracked
+// deletion holding the run.
+func (p Paragraph) findTracked(r *wml.CT_R) (*[]*wml.EG_ContentRunContent, int, bool) {
+	var find func(crcs *[]*wml.EG_ContentRunContent) (*[]*wml.EG_ContentRunContent, int)
+	find = func(crcs *[]*wml.EG_ContentRunContent) (*[]*wml.EG_ContentRunContent, int) {
+		for i, crc := range *crcs {
+			for _, rle := range crc.ContentRunContentChoice.EG_RunLevelElts {
+				if del := rle.RunLevelEltsChoice.Del; del != nil {
+					for _, c := range del.RunTrackChangeChoice {
+						if c.ContentRunContentChoice != nil && c.ContentRunContentChoice.R == r {
+							return crcs, i
+						}
+					}
+				}
+			}
+			if sdt := crc.ContentRunContentChoice.Sdt; sdt != nil && sdt.SdtContent != nil {
+				for _, pc := range sdt.SdtContent.EG_PContent {
+					if c, j := find(&pc.PContentChoice.EG_ContentRunContent); c != nil {
+						return c, j
+					}
+				}
+			}
+		}
+		return nil, 0
+	}
+	for _, pc := range p._cebfg.EG_PContent {
+		if hl := pc.PContentChoice.Hyperlink; hl != nil {
+			if c, i := find(&hl.PContentChoice.EG_ContentRunContent); c != nil {
+				return c, i, false
+			}
+		}
+		if c, i := find(&pc.PContentChoice.EG_ContentRunContent); c != nil {
+			return c, i, c == &pc.PContentChoice.EG_ContentRunContent
+		}
+	}
+	return nil, 0, false
+}
+
+// insertContentAfterTracked inserts paragraph content after the tracked
+// deletion holding the run.
+func (p Paragraph) insertContentAfterTracked(r *wml.CT_R, pcs []*wml.EG_PContent) {
+	c, i, top := p.findTracked(r)
+	if c == nil {
+		p._cebfg.EG_PContent = append(p._cebfg.EG_PContent, pcs...)
+		return
+	}
+	p.spliceContent(c, i+1, top, pcs)
+}
+
+// insertContentAt inserts paragraph content in place of the run, or after it
+// if replace is false.
+func (p Paragraph) insertContentAt(r *wml.CT_R, pcs []*wml.EG_PContent, replace bool) {
+	c, i, top := p.runContainer(r)
+	if c == nil {
+		p._cebfg.EG_PContent = append(p._cebfg.EG_PContent, pcs...)
+		return
+	}
+	if replace {
+		*c = append((*c)[:i], (*c)[i+1:]...)
+	} else {
+		i++
+	}
+	p.spliceContent(c, i, top, pcs)
+}
+
+// spliceContent inserts paragraph content at index i of a run content
+// container. Run content is inserted directly, other content such as
+// hyperlinks requires a top level container, which is split in two around the
+// inserted content.
+func (p Paragraph) spliceContent(c *[]*wml.EG_ContentRunContent, i int, top bool, pcs []*wml.EG_PContent) {
+	runsOnly := true
+	crcs := []*wml.EG_ContentRunContent{}
+	for _, pc := range pcs {
+		if pc.PContentChoice.Hyperlink != nil || pc.PContentChoice.FldSimple != nil {
+			runsOnly = false
+		}
+		crcs = append(crcs, pc.PContentChoice.EG_ContentRunContent...)
+	}
+	if runsOnly || !top {
+		*c = append((*c)[:i], append(crcs, (*c)[i:]...)...)
+		return
+	}
+	for j, pc := range p._cebfg.EG_PContent {
+		if &pc.PContentChoice.EG_ContentRunContent != c {
+			continue
+		}
+		tail := wml.NewEG_PContent()
+		tail.PContentChoice.EG_ContentRunContent = append(tail.PContentChoice.EG_ContentRunContent, (*c)[i:]...)
+		*c = (*c)[:i]
+		rest := append(pcs, tail)
+		rest = append(rest, p._cebfg.EG_PContent[j+1:]...)
+		p._cebfg.EG_PContent = append(p._cebfg.EG_PContent[:j+1], rest...)
+		return
+	}
+}
+
+// runContainer returns the run content container holding the run and its
+// index in the container. top is true if the container is the top level
+// content of the paragraph, rather than a hyperlink or content control.
+func (p Paragraph) runContainer(r *wml.CT_R) (c *[]*wml.EG_ContentRunContent, i int, top bool) {
+	var find func(crcs *[]*wml.EG_ContentRunContent) (*[]*wml.EG_ContentRunContent, int)
+	find = func(crcs *[]*wml.EG_ContentRunContent) (*[]*wml.EG_ContentRunContent, int) {
+		for i, crc := range *crcs {
+			if crc.ContentRunContentChoice.R == r {
+				return crcs, i
+			}
+			if sdt := crc.ContentRunContentChoice.Sdt; sdt != nil && sdt.SdtContent != nil {
+				for _, pc := range sdt.SdtContent.EG_PContent {
+					if c, j := find(&pc.PContentChoice.EG_ContentRunContent); c != nil {
+						return c, j
+					}
+				}
+			}
+		}
+		return nil, 0
+	}
+	for _, pc := range p._cebfg.EG_PContent {
+		if hl := pc.PContentChoice.Hyperlink; hl != nil {
+			if c, i := find(&hl.PContentChoice.EG_ContentRunContent); c != nil {
+				return c, i, false
+			}
+		}
+		if c, i := find(&pc.PContentChoice.EG_ContentRunContent); c != nil {
+			return c, i, c == &pc.PContentChoice.EG_ContentRunContent
+		}
+	}
+	return nil, 0, false
+}
+
+// textLen returns the number of characters of the run text, counting tabs as
+// one character.
+func (r Run) textLen() int { return utf8.RuneCountInString(r.Text()) }
+
+// splitRun splits the run at the character offset, moving the content after
+// the offset to a new run with the same properties that is inserted after the
+// run. The new run is returned.
+func (p Paragraph) splitRun(r Run, off int) Run {
+	left, right := []*wml.EG_RunInnerContent{}, []*wml.EG_RunInnerContent{}
+	pos := 0
+	for _, ic := range r._bbdb.EG_RunInnerContent {
+		n := 0
+		if t := ic.RunInnerContentChoice.T; t != nil {
+			n = utf8.RuneCountInString(t.Content)
+		} else if ic.RunInnerContentChoice.Tab != nil {
+			n = 1
+		}
+		switch {
+		case pos+n <= off:
+			left = append(left, ic)
+		case pos >= off:
+			right = append(right, ic)
+		default:
+			rs := []rune(ic.RunInnerContentChoice.T.Content)
+			l := wml.NewEG_RunInnerContent()
+			l.RunInnerContentChoice.T = wml.NewCT_Text()
+			l.RunInnerContentChoice.T.Content = string(rs[:off-pos])
+			l.RunInnerContentChoice.T.SpaceAttr = preserveSpace()
+			rt := wml.NewEG_RunInnerContent()
+			rt.RunInnerContentChoice.T = wml.NewCT_Text()
+			rt.RunInnerContentChoice.T.Content = string(rs[off-pos:])
+			rt.RunInnerContentChoice.T.SpaceAttr = preserveSpace()
+			left = append(left, l)
+			right = append(right, rt)
+		}
+		pos += n
+	}
+	r._bbdb.EG_RunInnerContent = left
+
+	nr := Run{r._gdedf, wml.NewCT_R()}
+	if r._bbdb.RPr != nil {
+		nr.SetProperties(RunProperties{r._bbdb.RPr})
+	}
+	nr._bbdb.EG_RunInnerContent = right
+	crc := wml.NewEG_ContentRunContent()
+	crc.ContentRunContentChoice.R = nr._bbdb
+	if c, i, _ := p.runContainer(r._bbdb); c != nil {
+		*c = append((*c)[:i+1], append([]*wml.EG_ContentRunContent{crc}, (*c)[i+1:]...)...)
+	}
+	return nr
+}
+
+// revisionIDs hands out IDs for tracked changes that don't collide with the
+// IDs of existing tracked changes.
+type revisionIDs struct {
+	d    *Document
+	id   int64
+	init bool
+}
+
+func (r *revisionIDs) next() int64 {
+	if !r.init {
+		r.init = true
+		for _, p := range r.d.searchParagraphs(FindEverywhere) {
+			for _, rle := range p.runLevelElts() {
+				for _, tc := range []*wml.CT_RunTrackChange{rle.RunLevelEltsChoice.Ins, rle.RunLevelEltsChoice.Del} {
+					if tc != nil && tc.IdAttr >= r.id {
+						r.id = tc.IdAttr + 1
+					}
+				}
+			}
+		}
+	}
+	r.id++
+	return r.id - 1
+}
@@ -0,0 +1,273 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// addRuns adds a paragraph with a run for each of the texts.
+func addRuns(d *Document, texts ...string) Paragraph {
+	p := d.AddParagraph()
+	for _, s := range texts {
+		p.AddRun().AddText(s)
+	}
+	return p
+}
+
+// trackedText returns the text of the paragraph with tracked deletions written
+// as [-text] and tracked insertions as [+text].
+func trackedText(p Paragraph) string {
+	sb := strings.Builder{}
+	for _, pc := range p.X().EG_PContent {
+		for _, crc := range pc.PContentChoice.EG_ContentRunContent {
+			if r := crc.ContentRunContentChoice.R; r != nil {
+				sb.WriteString(Run{p._adga, r}.Text())
+			}
+			for _, rle := range crc.ContentRunContentChoice.EG_RunLevelElts {
+				if del := rle.RunLevelEltsChoice.Del; del != nil {
+					sb.WriteString("[-")
+					for _, c := range del.RunTrackChangeChoice {
+						for _, ic := range c.ContentRunContentChoice.R.EG_RunInnerContent {
+							if dt := ic.RunInnerContentChoice.DelText; dt != nil {
+								sb.WriteString(dt.Content)
+							}
+						}
+					}
+					sb.WriteString("]")
+				}
+				if ins := rle.RunLevelEltsChoice.Ins; ins != nil {
+					sb.WriteString("[+")
+					for _, c := range ins.RunTrackChangeChoice {
+						sb.WriteString(Run{p._adga, c.ContentRunContentChoice.R}.Text())
+					}
+					sb.WriteString("]")
+				}
+			}
+		}
+	}
+	return sb.String()
+}
+
+func TestFindAll(t *testing.T) {
+	d := New()
+	p := addRuns(d, "Hello Wo", "rld", "! hello world")
+	d.AddHeader().AddParagraph().AddRun().AddText("hello header")
+	d.AddFooter().AddParagraph().AddRun().AddText("hello footer")
+	runs := p.Runs()
+
+	matches, err := d.FindAll("world", FindOptions{Scope: FindInBody})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("found %d matches, want 2", len(matches))
+	}
+	m := matches[0]
+	if m.Text != "World" || m.Start.Run.X() != runs[0].X() || m.Start.Offset != 6 ||
+		m.End.Run.X() != runs[1].X() || m.End.Offset != 3 {
+		t.Errorf("match across runs = %q from %d to %d", m.Text, m.Start.Offset, m.End.Offset)
+	}
+	if m := matches[1]; m.Start.Run.X() != runs[2].X() || m.Start.Offset != 8 || m.End.Offset != 13 {
+		t.Errorf("match in run = %d to %d", m.Start.Offset, m.End.Offset)
+	}
+
+	tests := []struct {
+		pattern string
+		opts    FindOptions
+		want    []string
+	}{
+		{"hello", FindOptions{}, []string{"Hello", "hello", "hello", "hello"}},
+		{"hello", FindOptions{MatchCase: true}, []string{"hello", "hello", "hello"}},
+		{"hello", FindOptions{Scope: FindInBody}, []string{"Hello", "hello"}},
+		{"hello", FindOptions{Scope: FindInHeaders | FindInFooters}, []string{"hello", "hello"}},
+		{"hello", FindOptions{Scope: FindInComments}, []string{}},
+		{"wor", FindOptions{}, []string{"Wor", "wor"}},
+		{"wor", FindOptions{WholeWord: true}, []string{}},
+		{"o.", FindOptions{}, []string{}},
+		{"o.", FindOptions{Regexp: true, Scope: FindInBody}, []string{"o ", "or", "o ", "or"}},
+		{`h(\w+)r`, FindOptions{Regexp: true, WholeWord: true}, []string{"header"}},
+	}
+	for _, tc := range tests {
+		matches, err := d.FindAll(tc.pattern, tc.opts)
+		if err != nil {
+			t.Errorf("FindAll(%q): %s", tc.pattern, err)
+			continue
+		}
+		got := []string{}
+		for _, m := range matches {
+			got = append(got, m.Text)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("FindAll(%q, %+v) = %q, want %q", tc.pattern, tc.opts, got, tc.want)
+		}
+	}
+
+	matches, _ = d.FindAll(`(\w+) (?P<word>\w+)`, FindOptions{Regexp: true, Scope: FindInHeaders})
+	if len(matches) != 1 || !reflect.DeepEqual(matches[0].Groups, []string{"hello", "header"}) {
+		t.Errorf("submatches = %+v", matches)
+	}
+	if _, err := d.FindAll("", FindOptions{}); err == nil {
+		t.Error("searched for an empty pattern")
+	}
+	if _, err := d.FindAll("(", FindOptions{Regexp: true}); err == nil {
+		t.Error("searched for an invalid expression")
+	}
+}
+
+func TestReplaceAll(t *testing.T) {
+	d := New()
+	p := addRuns(d, "Hello Wo", "rld")
+	p.Runs()[0].Properties().SetBold(true)
+	mail := addRuns(d, "Contact: bob@", "example.com", " or ", "ann@example.com")
+
+	n, err := d.ReplaceAll("world", "there", FindOptions{}, ReplaceOptions{})
+	if err != nil || n != 1 {
+		t.Fatalf("ReplaceAll = %d, %v", n, err)
+	}
+	runs := p.Runs()
+	if len(runs) != 2 || runs[0].Text() != "Hello " || runs[1].Text() != "there" {
+		t.Errorf("runs after replacement = %q", paragraphText(p))
+	}
+	if !runs[1].Properties().IsBold() {
+		t.Error("replacement didn't take the properties of the replaced run")
+	}
+
+	if n, _ := d.ReplaceAll(`(\w+)@example\.com`, "${1} at example", FindOptions{Regexp: true}, ReplaceOptions{}); n != 2 {
+		t.Errorf("replaced %d addresses, want 2", n)
+	}
+	if got := paragraphText(mail); got != "Contact: bob at example or ann at example" {
+		t.Errorf("expanded replacement = %q", got)
+	}
+
+	rp := d.AddParagraph().AddRun().Properties()
+	rp.SetItalic(true)
+	if n, _ := d.ReplaceAll("there", "world", FindOptions{MatchCase: true}, ReplaceOptions{Properties: &rp}); n != 1 {
+		t.Errorf("replaced %d, want 1", n)
+	}
+	if r := p.Runs()[1]; r.Text() != "world" || !r.Properties().IsItalic() || r.Properties().IsBold() {
+		t.Error("replacement didn't take the given properties")
+	}
+
+	read := roundTrip(t, d)
+	want := []string{"Hello world", "Contact: bob at example or ann at example", ""}
+	if got := paragraphTexts(read); !reflect.DeepEqual(got, want) {
+		t.Errorf("read paragraphs = %q, want %q", got, want)
+	}
+}
+
+func TestReplaceTracked(t *testing.T) {
+	d := New()
+	p := addRuns(d, "say hello and hello")
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	n, err := d.ReplaceAll("hello", "bye", FindOptions{}, ReplaceOptions{TrackChanges: true, Author: "Ann", Date: date})
+	if err != nil || n != 2 {
+		t.Fatalf("ReplaceAll = %d, %v", n, err)
+	}
+	want := "say [-hello][+bye] and [-hello][+bye]"
+	if got := trackedText(p); got != want {
+		t.Errorf("tracked replacement = %q, want %q", got, want)
+	}
+	// the tracked runs are no longer found
+	if matches, _ := d.FindAll("hello", FindOptions{}); len(matches) != 0 {
+		t.Errorf("found %d deleted matches", len(matches))
+	}
+
+	ids := map[int64]bool{}
+	for _, rle := range p.runLevelElts() {
+		tc := rle.RunLevelEltsChoice.Del
+		if tc == nil {
+			tc = rle.RunLevelEltsChoice.Ins
+		}
+		if tc.AuthorAttr != "Ann" || tc.DateAttr == nil || !tc.DateAttr.Equal(date) {
+			t.Errorf("tracked change by %s at %v", tc.AuthorAttr, tc.DateAttr)
+		}
+		ids[tc.IdAttr] = true
+	}
+	if len(ids) != 4 {
+		t.Errorf("tracked changes have IDs %v, want 4 distinct", ids)
+	}
+
+	// later changes don't reuse the IDs
+	if n, _ := d.ReplaceAll("say", "tell", FindOptions{}, ReplaceOptions{TrackChanges: true}); n != 1 {
+		t.Errorf("replaced %d, want 1", n)
+	}
+	if got := trackedText(p); got != "[-say][+tell] [-hello][+bye] and [-hello][+bye]" {
+		t.Errorf("second tracked replacement = %q", got)
+	}
+	seen := map[int64]bool{}
+	for _, rle := range p.runLevelElts() {
+		tc := rle.RunLevelEltsChoice.Del
+		if tc == nil {
+			tc = rle.RunLevelEltsChoice.Ins
+		}
+		if seen[tc.IdAttr] {
+			t.Errorf("tracked change ID %d used twice", tc.IdAttr)
+		}
+		seen[tc.IdAttr] = true
+	}
+
+	read := roundTrip(t, d)
+	if got := trackedText(read.Paragraphs()[0]); got != "[-say][+tell] [-hello][+bye] and [-hello][+bye]" {
+		t.Errorf("read tracked replacement = %q", got)
+	}
+}
+
+func TestReplaceWith(t *testing.T) {
+	d := New()
+	p := addRuns(d, "See {link} on page {page}.")
+	link := d.AddParagraph().AddHyperLink()
+	link.SetTarget("https://example.com")
+	link.AddRun().AddText("{link}")
+
+	matches, _ := d.FindAll("{link}", FindOptions{})
+	hl, err := matches[0].ReplaceWithHyperlink("https://unidoc.io", "UniDoc", ReplaceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hl.X().IdAttr == nil {
+		t.Error("hyperlink has no target")
+	}
+	if _, err := matches[1].ReplaceWithHyperlink("https://unidoc.io", "UniDoc", ReplaceOptions{}); err == nil {
+		t.Error("replaced a match inside a hyperlink with a hyperlink")
+	}
+	if got := paragraphText(p); got != "See UniDoc on page {page}." {
+		t.Errorf("paragraph with hyperlink = %q", got)
+	}
+	// the text around the hyperlink stays in order
+	if pcs := p.X().EG_PContent; len(pcs) != 3 || pcs[1].PContentChoice.Hyperlink == nil {
+		t.Errorf("paragraph content has %d parts", len(pcs))
+	}
+
+	matches, _ = d.FindAll("{page}", FindOptions{})
+	matches[0].ReplaceWithField("PAGE", ReplaceOptions{})
+	instr := []string{}
+	for _, r := range p.Runs() {
+		for _, ic := range r.X().EG_RunInnerContent {
+			if it := ic.RunInnerContentChoice.InstrText; it != nil {
+				instr = append(instr, strings.TrimSpace(it.Content))
+			}
+		}
+	}
+	if !reflect.DeepEqual(instr, []string{"PAGE"}) {
+		t.Errorf("fields = %q", instr)
+	}
+	if got := paragraphText(p); got != "See UniDoc on page ." {
+		t.Errorf("paragraph with field = %q", got)
+	}
+
+	read := roundTrip(t, d)
+	if got := paragraphText(read.Paragraphs()[0]); got != "See UniDoc on page ." {
+		t.Errorf("read paragraph = %q", got)
+	}
+}
@@ -0,0 +1,534 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/common/logger"
+	"github.com/unidoc/unioffice/v2/common/tempstorage"
+	"github.com/unidoc/unioffice/v2/schema/soo/pkg/relationships"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+	"github.com/unidoc/unioffice/v2/zippkg"
+)
+
+const (
+	glossaryDocumentType    = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/glossaryDocument"
+	glossaryContentType     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document.glossary+xml"
+	glossaryPath            = "word/glossary/document.xml"
+	defaultBuildingCategory = "General"
+	relationshipsNamespace  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+)
+
+// glossaryPart is the glossary document holding the building blocks of the
+// document, loaded on first use and written back when the document is saved.
+type glossaryPart struct {
+	gd   *wml.GlossaryDocument
+	rels common.Relationships
+	path string
+}
+
+// BuildingBlock is an entry of the glossary document of a document or
+// template, such as an AutoText entry, a Quick Part or a cover page. Its
+// content is kept apart from the document body and can be inserted into the
+// body with Document.InsertBuildingBlock.
+type BuildingBlock struct {
+	doc *Document
+	x   *wml.CT_DocPart
+}
+
+// X returns the inner wrapped XML type.
+func (b BuildingBlock) X() *wml.CT_DocPart { return b.x }
+
+func (b BuildingBlock) properties() *wml.CT_DocPartPr {
+	if b.x.DocPartPr == nil {
+		b.x.DocPartPr = wml.NewCT_DocPartPr()
+	}
+	return b.x.DocPartPr
+}
+
+// Name returns the name of the building block.
+func (b BuildingBlock) Name() string {
+	if pr := b.x.DocPartPr; pr != nil && pr.Name != nil {
+		return pr.Name.ValAttr
+	}
+	return ""
+}
+
+// SetName sets the name of the building block.
+func (b BuildingBlock) SetName(name string) {
+	pr := b.properties()
+	if pr.Name == nil {
+		pr.Name = wml.NewCT_DocPartName()
+	}
+	pr.Name.ValAttr = name
+}
+
+// Category returns the category the building block is listed under in its
+// gallery.
+func (b BuildingBlock) Category() string {
+	if pr := b.x.DocPartPr; pr != nil && pr.Category != nil && pr.Category.Name != nil {
+		return pr.Category.Name.ValAttr
+	}
+	return ""
+}
+
+// SetCategory sets the category the building block is listed under in its
+// gallery.
+func (b BuildingBlock) SetCategory(category string) {
+	b.category().Name.ValAttr = category
+}
+
+// Gallery returns the gallery of the building block, e.g.
+// wml.ST_DocPartGalleryAutoTxt for AutoText, wml.ST_DocPartGalleryDocParts for
+// Quick Parts or wml.ST_DocPartGalleryCoverPg for cover pages.
+func (b BuildingBlock) Gallery() wml.ST_DocPartGallery {
+	if pr := b.x.DocPartPr; pr != nil && pr.Category != nil && pr.Category.Gallery != nil {
+		return pr.Category.Gallery.ValAttr
+	}
+	return wml.ST_DocPartGalleryUnset
+}
+
+// SetGallery sets the gallery of the building block. Custom galleries are
+// wml.ST_DocPartGalleryCustom1 to wml.ST_DocPartGalleryCustom5 and the
+// wml.ST_DocPartGalleryCust* galleries.
+func (b BuildingBlock) SetGallery(gallery wml.ST_DocPartGallery) {
+	b.category().Gallery.ValAttr = gallery
+}
+
+func (b BuildingBlock) category() *wml.CT_DocPartCategory {
+	pr := b.properties()
+	if pr.Category == nil {
+		pr.Category = wml.NewCT_DocPartCategory()
+	}
+	if pr.Category.Name == nil {
+		pr.Category.Name = wml.NewCT_String()
+	}
+	if pr.Category.Gallery == nil {
+		pr.Category.Gallery = wml.NewCT_DocPartGallery()
+	}
+	return pr.Category
+}
+
+// Description returns the description of the building block.
+func (b BuildingBlock) Description() string {
+	if pr := b.x.DocPartPr; pr != nil && pr.Description != nil {
+		return pr.Description.ValAttr
+	}
+	return ""
+}
+
+// SetDescription sets the description of the building block, shown as a tip
+// in the gallery.
+func (b BuildingBlock) SetDescription(description string) {
+	pr := b.properties()
+	if description == "" {
+		pr.Description = nil
+		return
+	}
+	if pr.Description == nil {
+		pr.Description = wml.NewCT_String()
+	}
+	pr.Description.ValAttr = description
+}
+
+func (b BuildingBlock) body() *wml.CT_Body {
+	if b.x.DocPartBody == nil {
+		b.x.DocPartBody = wml.NewCT_Body()
+	}
+	return b.x.DocPartBody
+}
+
+// Paragraphs returns the top level paragraphs of the building block content.
+func (b BuildingBlock) Paragraphs() []Paragraph {
+	ret := []Paragraph{}
+	for _, ble := range b.body().EG_BlockLevelElts {
+		for _, cbc := range ble.BlockLevelEltsChoice.EG_ContentBlockContent {
+			for _, p := range cbc.ContentBlockContentChoice.P {
+				ret = append(ret, Paragraph{b.doc, p})
+			}
+		}
+	}
+	return ret
+}
+
+// Tables returns the top level tables of the building block content.
+func (b BuildingBlock) Tables() []Table {
+	ret := []Table{}
+	for _, ble := range b.body().EG_BlockLevelElts {
+		for _, cbc := range ble.BlockLevelEltsChoice.EG_ContentBlockContent {
+			for _, t := range cbc.ContentBlockContentChoice.Tbl {
+				ret = append(ret, Table{b.doc, t})
+			}
+		}
+	}
+	return ret
+}
+
+// AddParagraph adds a paragraph to the end of the building block content.
+func (b BuildingBlock) AddParagraph() Paragraph {
+	ble := wml.NewEG_BlockLevelElts()
+	b.body().EG_BlockLevelElts = append(b.body().EG_BlockLevelElts, ble)
+	cbc := wml.NewEG_ContentBlockContent()
+	ble.BlockLevelEltsChoice.EG_ContentBlockContent = append(ble.BlockLevelEltsChoice.EG_ContentBlockContent, cbc)
+	p := wml.NewCT_P()
+	cbc.ContentBlockContentChoice.P = append(cbc.ContentBlockContentChoice.P, p)
+	return Paragraph{b.doc, p}
+}
+
+// AddTable adds a table to the end of the building block content.
+func (b BuildingBlock) AddTable() Table {
+	ble := wml.NewEG_BlockLevelElts()
+	b.body().EG_BlockLevelElts = append(b.body().EG_BlockLevelElts, ble)
+	cbc := wml.NewEG_ContentBlockContent()
+	ble.BlockLevelEltsChoice.EG_ContentBlockContent = append(ble.BlockLevelEltsChoice.EG_ContentBlockContent, cbc)
+	t := wml.NewCT_Tbl()
+	cbc.ContentBlockContentChoice.Tbl = append(cbc.ContentBlockContentChoice.Tbl, t)
+	return Table{b.doc, t}
+}
+
+// BuildingBlocks returns the building blocks of the document, such as the
+// AutoText entries and Quick Parts distributed with a template.
+func (d *Document) BuildingBlocks() []BuildingBlock {
+	gd := d.glossary(false)
+	if gd == nil || gd.DocParts == nil {
+		return nil
+	}
+	ret := []BuildingBlock{}
+	for _, dp := range gd.DocParts.DocPart {
+		ret = append(ret, BuildingBlock{d, dp})
+	}
+	return ret
+}
+
+// BuildingBlock returns the first building block with the given name.
+func (d *Document) BuildingBlock(name string) (BuildingBlock, bool) {
+	for _, b := range d.BuildingBlocks() {
+		if b.Name() == name {
+			return b, true
+		}
+	}
+	return BuildingBlock{}, false
+}
+
+// AddBuildingBlock adds a building block with empty content to a gallery,
+// creating the glossary document if needed. An empty category is stored as
+// "General", the category Word uses by default.
+func (d *Document) AddBuildingBlock(name, category string, gallery wml.ST_DocPartGallery) BuildingBlock {
+	gd := d.glossary(true)
+	if gd.DocParts == nil {
+		gd.DocParts = wml.NewCT_DocParts()
+	}
+	dp := wml.NewCT_DocPart()
+	gd.DocParts.DocPart = append(gd.DocParts.DocPart, dp)
+	b := BuildingBlock{d, dp}
+	if category == "" {
+		category = defaultBuildingCategory
+	}
+	b.SetName(name)
+	b.SetCategory(category)
+	b.SetGallery(gallery)
+	pr := b.properties()
+	pr.Guid = wml.NewCT_Guid()
+	guid := newGUID()
+	pr.Guid.ValAttr = &guid
+	b.body()
+	return b
+}
+
+// RemoveBuildingBlock removes a building block from the glossary document.
+func (d *Document) RemoveBuildingBlock(b BuildingBlock) {
+	gd := d.glossary(false)
+	if gd == nil || gd.DocParts == nil {
+		return
+	}
+	dps := gd.DocParts.DocPart
+	for i, dp := range dps {
+		if dp == b.x {
+			gd.DocParts.DocPart = append(dps[:i], dps[i+1:]...)
+			return
+		}
+	}
+}
+
+// InsertBuildingBlock appends a copy of the content of the building block to
+// the end of the document body. Parts of the glossary referenced by the
+// content, such as images, are copied to the document and hyperlinks are added
+// to its relationships. An error is returned if a referenced part has
+// relationships of its own, e.g. a chart.
+func (d *Document) InsertBuildingBlock(b BuildingBlock) error {
+	bles, err := d.copyBuildingBlock(b)
+	if err != nil {
+		return err
+	}
+	d._bbe.Body.EG_BlockLevelElts = append(d._bbe.Body.EG_BlockLevelElts, bles...)
+	return nil
+}
+
+// InsertBuildingBlockAfter inserts a copy of the content of the building block
+// after the relativeTo paragraph, which may be in the body or a table cell.
+// Referenced parts are copied as by InsertBuildingBlock.
+func (d *Document) InsertBuildingBlockAfter(relativeTo Paragraph, b BuildingBlock) error {
+	s, ok := d.locateParagraph(relativeTo.X())
+	if !ok {
+		return errors.New("paragraph not found in document")
+	}
+	bles, err := d.copyBuildingBlock(b)
+	if err != nil {
+		return err
+	}
+	cbcs := []*wml.EG_ContentBlockContent{}
+	for _, ble := range bles {
+		cbcs = append(cbcs, ble.BlockLevelEltsChoice.EG_ContentBlockContent...)
+	}
+	// paragraphs after relativeTo that share its block content move to a
+	// block content of their own following the inserted content
+	cbc := (*s.cbcs)[s.cbc]
+	ps := cbc.ContentBlockContentChoice.P
+	for i, p := range ps {
+		if p == relativeTo.X() && i < len(ps)-1 {
+			rest := wml.NewEG_ContentBlockContent()
+			rest.ContentBlockContentChoice.P = append([]*wml.CT_P{}, ps[i+1:]...)
+			cbc.ContentBlockContentChoice.P = ps[:i+1]
+			cbcs = append(cbcs, rest)
+			break
+		}
+	}
+	tail := append(cbcs, (*s.cbcs)[s.cbc+1:]...)
+	*s.cbcs = append((*s.cbcs)[:s.cbc+1], tail...)
+	return nil
+}
+
+// copyBuildingBlock returns a deep copy of the content of a building block,
+// with its relationship IDs replaced by those of copies of the relationships
+// in the document.
+func (d *Document) copyBuildingBlock(b BuildingBlock) ([]*wml.EG_BlockLevelElts, error) {
+	// the content is copied by round tripping it through XML, wrapped in a
+	// document that declares the namespaces
+	src := wml.NewDocument()
+	src.Body = wml.NewCT_Body()
+	src.Body.EG_BlockLevelElts = b.body().EG_BlockLevelElts
+	buf := bytes.Buffer{}
+	if err := xml.NewEncoder(&buf).Encode(src); err != nil {
+		return nil, err
+	}
+	remapped := bytes.Buffer{}
+	ids := map[string]string{}
+	if err := remapRelationshipIDs(&remapped, &buf, func(id string) (string, error) {
+		if newID, ok := ids[id]; ok {
+			return newID, nil
+		}
+		newID, err := d.copyGlossaryRelationship(id)
+		ids[id] = newID
+		return newID, err
+	}); err != nil {
+		return nil, err
+	}
+	dst := wml.NewDocument()
+	if err := xml.NewDecoder(&remapped).Decode(dst); err != nil {
+		return nil, err
+	}
+	if dst.Body == nil {
+		return nil, nil
+	}
+	return dst.Body.EG_BlockLevelElts, nil
+}
+
+// remapRelationshipIDs copies the XML of r to w, replacing the values of the
+// attributes in the relationships namespace, such as r:id and r:embed, by
+// those returned by remap.
+func remapRelationshipIDs(w io.Writer, r io.Reader, remap func(id string) (string, error)) error {
+	dec := xml.NewDecoder(r)
+	enc := xml.NewEncoder(w)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return enc.Flush()
+		}
+		if err != nil {
+			return err
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			attrs := []xml.Attr{}
+			for _, a := range se.Attr {
+				// the encoder declares the namespaces of the elements and
+				// attributes it writes
+				if a.Name.Space == "xmlns" || a.Name.Space == "" && a.Name.Local == "xmlns" {
+					continue
+				}
+				if a.Name.Space == relationshipsNamespace && a.Value != "" {
+					if a.Value, err = remap(a.Value); err != nil {
+						return err
+					}
+				}
+				attrs = append(attrs, a)
+			}
+			se.Attr = attrs
+			tok = se
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			return err
+		}
+	}
+}
+
+// copyGlossaryRelationship adds a copy of the glossary relationship with the
+// given ID to the document relationships, copying the part it targets, and
+// returns the ID of the copy.
+func (d *Document) copyGlossaryRelationship(id string) (string, error) {
+	var rel common.Relationship
+	if gp := d.glossaryPart; gp != nil {
+		rel = gp.rels.GetByRelId(id)
+	}
+	if rel.X() == nil {
+		return "", fmt.Errorf("building block references unknown relationship %s", id)
+	}
+	if rel.X().TargetModeAttr == relationships.ST_TargetModeExternal {
+		cp := d._ead.AddRelationship(rel.Target(), rel.Type())
+		cp.X().TargetModeAttr = relationships.ST_TargetModeExternal
+		return cp.ID(), nil
+	}
+	srcPath := strings.TrimPrefix(rel.Target(), "/")
+	if !strings.HasPrefix(rel.Target(), "/") {
+		srcPath = path.Join(path.Dir(d.glossaryPart.path), rel.Target())
+	}
+	storagePath := ""
+	for _, ef := range d.ExtraFiles {
+		switch ef.ZipPath {
+		case srcPath:
+			storagePath = ef.StoragePath
+		case zippkg.RelationsPathFor(srcPath):
+			return "", fmt.Errorf("building block part %s has relationships, copying it is not supported", srcPath)
+		}
+	}
+	if storagePath == "" {
+		return "", fmt.Errorf("building block part %s not found", srcPath)
+	}
+	// the copy is stored under word/ as the parts of the document are,
+	// e.g. media/image1.png of the glossary is copied to word/media/imageN.png
+	rest := strings.TrimPrefix(srcPath, path.Dir(d.glossaryPart.path)+"/")
+	ext := path.Ext(rest)
+	stem := strings.TrimRight(strings.TrimSuffix(rest, ext), "0123456789")
+	dstPath := d.freeExtraFilePath("word/"+strings.ReplaceAll(stem, "%", "%%")+"%d"+ext, 1)
+	dstStorage, err := writeTempFile("glossary-part-", func(f tempstorage.File) error {
+		src, err := tempstorage.Open(storagePath)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(f, src)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	d.setExtraFile(dstPath, dstStorage)
+	d.ContentTypes.CopyOverride(srcPath, dstPath)
+	return d._ead.AddRelationship(strings.TrimPrefix(dstPath, "word/"), rel.Type()).ID(), nil
+}
+
+// glossary returns the glossary document, loading it from its part on first
+// use. If create is true a glossary document is added to documents without
+// one.
+func (d *Document) glossary(create bool) *wml.GlossaryDocument {
+	if d.glossaryPart != nil {
+		return d.glossaryPart.gd
+	}
+	for _, rel := range d._ead.Relationships() {
+		if rel.Type() != glossaryDocumentType {
+			continue
+		}
+		zipPath, storagePath := d.relPartPath(rel.ID())
+		if storagePath == "" {
+			continue
+		}
+		f, err := tempstorage.Open(storagePath)
+		if err != nil {
+			logger.Log.Debug("unable to open glossary document: %s", err)
+			return nil
+		}
+		gd := wml.NewGlossaryDocument()
+		err = xml.NewDecoder(f).Decode(gd)
+		f.Close()
+		if err != nil {
+			logger.Log.Debug("unable to read glossary document: %s", err)
+			return nil
+		}
+		d.glossaryPart = &glossaryPart{gd: gd, rels: d.readGlossaryRelationships(zipPath), path: zipPath}
+		return gd
+	}
+	if !create {
+		return nil
+	}
+	gd := wml.NewGlossaryDocument()
+	gd.DocParts = wml.NewCT_DocParts()
+	d._ead.AddRelationship(glossaryPath[len("word/"):], glossaryDocumentType)
+	d.ContentTypes.AddOverride("/"+glossaryPath, glossaryContentType)
+	d.glossaryPart = &glossaryPart{gd: gd, rels: common.NewRelationships(), path: glossaryPath}
+	return gd
+}
+
+// readGlossaryRelationships returns the relationships of the glossary part,
+// which are kept as an extra file.
+func (d *Document) readGlossaryRelationships(zipPath string) common.Relationships {
+	rels := common.NewRelationships()
+	relsPath := zippkg.RelationsPathFor(zipPath)
+	for _, ef := range d.ExtraFiles {
+		if ef.ZipPath != relsPath {
+			continue
+		}
+		f, err := tempstorage.Open(ef.StoragePath)
+		if err != nil {
+			logger.Log.Debug("unable to open glossary relationships: %s", err)
+			break
+		}
+		if err := xml.NewDecoder(f).Decode(rels.X()); err != nil {
+			logger.Log.Debug("unable to read glossary relationships: %s", err)
+		}
+		f.Close()
+		break
+	}
+	return rels
+}
+
+// saveGlossary writes the loaded glossary document back to its part.
+func (d *Document) saveGlossary() error {
+	if d.glossaryPart == nil {
+		return nil
+	}
+	storagePath, err := writeTempFile("glossary-", func(f tempstorage.File) error {
+		if _, err := f.Write([]byte(zippkg.XMLHeader)); err != nil {
+			return err
+		}
+		return xml.NewEncoder(f).Encode(d.glossaryPart.gd)
+	})
+	if err != nil {
+		return err
+	}
+	d.setExtraFile(d.glossaryPart.path, storagePath)
+	return nil
+}
+
+// newGUID returns a random GUID in the braced form used by Word.
+func newGUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("{%X-%X-%X-%X-%X}", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
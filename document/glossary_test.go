@@ -0,0 +1,236 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/common/tempstorage"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml/picture"
+	"github.com/unidoc/unioffice/v2/schema/soo/pkg/relationships"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// blockTexts returns the names of the building blocks of a document and the
+// text of their paragraphs.
+func blockTexts(d *Document) map[string][]string {
+	ret := map[string][]string{}
+	for _, b := range d.BuildingBlocks() {
+		texts := []string{}
+		for _, p := range b.Paragraphs() {
+			texts = append(texts, paragraphText(p))
+		}
+		ret[b.Name()] = texts
+	}
+	return ret
+}
+
+func TestBuildingBlocks(t *testing.T) {
+	d := New()
+	if d.BuildingBlocks() != nil {
+		t.Error("new document has building blocks")
+	}
+	if _, ok := d.BuildingBlock("Signature"); ok {
+		t.Error("found building block in new document")
+	}
+
+	sig := d.AddBuildingBlock("Signature", "", wml.ST_DocPartGalleryAutoTxt)
+	sig.SetDescription("Closing of letters")
+	sig.AddParagraph().AddRun().AddText("Kind regards,")
+	sig.AddParagraph().AddRun().AddText("Jane")
+	sig.AddTable().AddRow().AddCell().AddParagraph().AddRun().AddText("phone")
+	cover := d.AddBuildingBlock("Cover", "Reports", wml.ST_DocPartGalleryCoverPg)
+	cover.AddParagraph().AddRun().AddText("Annual report")
+
+	guid := regexp.MustCompile(`^\{[0-9A-F]{8}-[0-9A-F]{4}-4[0-9A-F]{3}-[89AB][0-9A-F]{3}-[0-9A-F]{12}\}$`)
+	if g := *sig.X().DocPartPr.Guid.ValAttr; !guid.MatchString(g) || g == *cover.X().DocPartPr.Guid.ValAttr {
+		t.Errorf("GUID = %s", g)
+	}
+
+	check := func(prefix string, d *Document) {
+		t.Helper()
+		b, ok := d.BuildingBlock("Signature")
+		if !ok {
+			t.Fatalf("%sSignature building block not found", prefix)
+		}
+		if b.Category() != "General" || b.Gallery() != wml.ST_DocPartGalleryAutoTxt || b.Description() != "Closing of letters" {
+			t.Errorf("%sSignature = %s %s %q", prefix, b.Category(), b.Gallery(), b.Description())
+		}
+		if len(b.Tables()) != 1 || len(b.Tables()[0].Rows()) != 1 {
+			t.Errorf("%sSignature tables = %d", prefix, len(b.Tables()))
+		}
+		b, _ = d.BuildingBlock("Cover")
+		if b.Category() != "Reports" || b.Gallery() != wml.ST_DocPartGalleryCoverPg || b.Description() != "" {
+			t.Errorf("%sCover = %s %s %q", prefix, b.Category(), b.Gallery(), b.Description())
+		}
+		want := map[string][]string{"Signature": {"Kind regards,", "Jane"}, "Cover": {"Annual report"}}
+		if got := blockTexts(d); !reflect.DeepEqual(got, want) {
+			t.Errorf("%sbuilding blocks = %q, want %q", prefix, got, want)
+		}
+	}
+	check("", d)
+	read := roundTrip(t, d)
+	check("read ", read)
+
+	// blocks are edited and removed in a read document
+	b, _ := read.BuildingBlock("Cover")
+	b.SetName("Title page")
+	b.SetCategory("Covers")
+	b.SetGallery(wml.ST_DocPartGalleryCustom1)
+	b.SetDescription("")
+	sig, _ = read.BuildingBlock("Signature")
+	read.RemoveBuildingBlock(sig)
+	read = roundTrip(t, read)
+	if got := blockTexts(read); !reflect.DeepEqual(got, map[string][]string{"Title page": {"Annual report"}}) {
+		t.Errorf("edited building blocks = %q", got)
+	}
+	if b, _ := read.BuildingBlock("Title page"); b.Category() != "Covers" || b.Gallery() != wml.ST_DocPartGalleryCustom1 {
+		t.Errorf("edited building block = %s %s", b.Category(), b.Gallery())
+	}
+}
+
+func TestInsertBuildingBlock(t *testing.T) {
+	d := New()
+	first := d.AddParagraph()
+	first.AddRun().AddText("first")
+	d.AddParagraph().AddRun().AddText("last")
+	// a paragraph sharing the block content of first
+	shared := wml.NewCT_P()
+	cbc := d.X().Body.EG_BlockLevelElts[0].BlockLevelEltsChoice.EG_ContentBlockContent[0]
+	cbc.ContentBlockContentChoice.P = append(cbc.ContentBlockContentChoice.P, shared)
+	Paragraph{d, shared}.AddRun().AddText("shared")
+
+	b := d.AddBuildingBlock("Block", "", wml.ST_DocPartGalleryDocParts)
+	b.AddParagraph().AddRun().AddText("one")
+	b.AddParagraph().AddRun().AddText("two")
+
+	if err := d.InsertBuildingBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.InsertBuildingBlockAfter(first, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.InsertBuildingBlockAfter(Paragraph{d, wml.NewCT_P()}, b); err == nil {
+		t.Error("inserted after a paragraph not in the document")
+	}
+	// the inserted content is a copy
+	b.Paragraphs()[0].AddRun().AddText(" more")
+
+	want := []string{"first", "one", "two", "shared", "last", "one", "two"}
+	if got := paragraphTexts(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("paragraphs = %q, want %q", got, want)
+	}
+	read := roundTrip(t, d)
+	if got := paragraphTexts(read); !reflect.DeepEqual(got, want) {
+		t.Errorf("read paragraphs = %q, want %q", got, want)
+	}
+	if got := blockTexts(read)["Block"]; !reflect.DeepEqual(got, []string{"one more", "two"}) {
+		t.Errorf("read building block = %q", got)
+	}
+}
+
+func TestInsertBuildingBlockRelationships(t *testing.T) {
+	d := New()
+	b := d.AddBuildingBlock("Logo", "", wml.ST_DocPartGalleryDocParts)
+	rels := d.glossaryPart.rels
+	link := rels.AddRelationship("https://example.com", unioffice.HyperLinkType)
+	link.X().TargetModeAttr = relationships.ST_TargetModeExternal
+	logo := rels.AddRelationship("media/logo.png", unioffice.ImageType)
+
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	storagePath, err := writeTempFile("logo-", func(f tempstorage.File) error {
+		_, err := f.Write(buf.Bytes())
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.setExtraFile("word/glossary/media/logo.png", storagePath)
+	img, err := common.ImageFromBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := d.AddImage(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := b.AddParagraph()
+	hl := p.AddHyperLink()
+	hl.AddRun().AddText("Example")
+	hl.X().IdAttr = unioffice.String(link.ID())
+	inline, err := p.AddRun().AddDrawingInline(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pic := inline.X().Graphic.GraphicData.Any[0].(*picture.Pic)
+	pic.BlipFill.Blip.EmbedAttr = unioffice.String(logo.ID())
+
+	// the block is inserted twice, copying the image each time
+	for i := 0; i < 2; i++ {
+		if err := d.InsertBuildingBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	targets := func(d *Document) []string {
+		ret := []string{}
+		for _, p := range d.Paragraphs() {
+			for _, pc := range p.X().EG_PContent {
+				if h := pc.PContentChoice.Hyperlink; h != nil {
+					ret = append(ret, d.GetTargetByRelId(*h.IdAttr))
+				}
+			}
+			for _, r := range p.Runs() {
+				for _, dr := range r.DrawingInline() {
+					pic := dr.X().Graphic.GraphicData.Any[0].(*picture.Pic)
+					ret = append(ret, d.GetTargetByRelId(*pic.BlipFill.Blip.EmbedAttr))
+				}
+			}
+		}
+		return ret
+	}
+	want := []string{"https://example.com", "media/logo1.png", "https://example.com", "media/logo2.png"}
+	if got := targets(d); !reflect.DeepEqual(got, want) {
+		t.Errorf("targets = %q, want %q", got, want)
+	}
+	copied := 0
+	for _, ef := range d.ExtraFiles {
+		if ef.ZipPath == "word/media/logo1.png" || ef.ZipPath == "word/media/logo2.png" {
+			copied++
+		}
+	}
+	if copied != 2 {
+		t.Errorf("copied %d images, want 2", copied)
+	}
+	// images are renamed when a document is read
+	got := targets(roundTrip(t, d))
+	if len(got) != 4 || got[0] != want[0] || got[2] != want[2] || got[1] == "" || got[1] == got[3] {
+		t.Errorf("read targets = %q", got)
+	}
+
+	// content referring to an unknown relationship isn't inserted
+	hl.X().IdAttr = unioffice.String("rId99")
+	if err := d.InsertBuildingBlock(b); err == nil {
+		t.Error("inserted a building block referring to an unknown relationship")
+	}
+	if n := len(d.Paragraphs()); n != 2 {
+		t.Errorf("document has %d paragraphs, want 2", n)
+	}
+}
@@ -0,0 +1,725 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/sharedTypes"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// TableGrid is a logical view of a table that maps grid coordinates (row,
+// column) to the cells covering them, taking horizontally merged cells
+// (gridSpan) and vertically merged cells (vMerge) into account. The grid is
+// tied to the table it was created from, operations on the grid modify the
+// table and keep its grid column definitions (tblGrid) consistent.
+type TableGrid struct {
+	t     Table
+	rows  []Row
+	slots [][]gridSlot
+	cols  int
+}
+
+// gridSlot is the physical cell covering a grid position.
+type gridSlot struct {
+	tc   *wml.CT_Tc
+	col  int
+	span int
+}
+
+// GridCell is a cell of a table grid. Merged cells cover several grid rows
+// or columns, Cell holds their content.
+type GridCell struct {
+	Cell    Cell
+	Row     int
+	Col     int
+	RowSpan int
+	ColSpan int
+}
+
+// Grid returns the logical grid of the table.
+func (t Table) Grid() *TableGrid {
+	g := &TableGrid{t: t}
+	g.refresh()
+	return g
+}
+
+func (g *TableGrid) refresh() {
+	g.rows = g.t.Rows()
+	g.slots = make([][]gridSlot, len(g.rows))
+	g.cols = 0
+	if g.t._fadb.TblGrid != nil {
+		g.cols = len(g.t._fadb.TblGrid.GridCol)
+	}
+	for r, row := range g.rows {
+		col := 0
+		for _, c := range row.Cells() {
+			span := cellSpan(c._cdea)
+			for i := 0; i < span; i++ {
+				g.slots[r] = append(g.slots[r], gridSlot{c._cdea, col, span})
+			}
+			col += span
+		}
+		if col > g.cols {
+			g.cols = col
+		}
+	}
+	for r := range g.slots {
+		for len(g.slots[r]) < g.cols {
+			g.slots[r] = append(g.slots[r], gridSlot{col: len(g.slots[r]), span: 1})
+		}
+	}
+}
+
+// Rows returns the number of rows of the grid.
+func (g *TableGrid) Rows() int { return len(g.rows) }
+
+// Cols returns the number of grid columns.
+func (g *TableGrid) Cols() int { return g.cols }
+
+// Row returns the table row at the given grid row.
+func (g *TableGrid) Row(row int) Row { return g.rows[row] }
+
+// At returns the cell covering the grid position, returning false if the
+// position is out of range or not covered by a cell.
+func (g *TableGrid) At(row, col int) (GridCell, bool) {
+	if row < 0 || row >= len(g.rows) || col < 0 || col >= g.cols {
+		return GridCell{}, false
+	}
+	s := g.slots[row][col]
+	if s.tc == nil {
+		return GridCell{}, false
+	}
+	first := row
+	for first > 0 && isVMergeContinue(g.slots[first][col].tc) && g.sameArea(first-1, first, col) {
+		first--
+	}
+	last := first
+	for last+1 < len(g.rows) && isVMergeContinue(g.slots[last+1][col].tc) && g.sameArea(last, last+1, col) {
+		last++
+	}
+	return GridCell{
+		Cell:    Cell{g.t._efaab, g.slots[first][col].tc},
+		Row:     first,
+		Col:     s.col,
+		RowSpan: last - first + 1,
+		ColSpan: s.span,
+	}, true
+}
+
+// Cells returns the cells of the grid in row-major order, each merged cell
+// once.
+func (g *TableGrid) Cells() []GridCell {
+	ret := []GridCell{}
+	for r := range g.slots {
+		for c := 0; c < g.cols; c++ {
+			gc, ok := g.At(r, c)
+			if ok && gc.Row == r && gc.Col == c {
+				ret = append(ret, gc)
+			}
+		}
+	}
+	return ret
+}
+
+// sameArea returns true if the cells of two rows at a grid column cover the
+// same grid columns, i.e. can be vertically merged.
+func (g *TableGrid) sameArea(r1, r2, col int) bool {
+	a, b := g.slots[r1][col], g.slots[r2][col]
+	return a.tc != nil && b.tc != nil && a.col == b.col && a.span == b.span
+}
+
+// MergeCells merges the cells covering the grid rows r1 to r2 and columns c1
+// to c2 (inclusive) into a single cell. The content of the merged cells is
+// moved into the resulting cell. Merged cells crossing the border of the range
+// cause an error.
+func (g *TableGrid) MergeCells(r1, c1, r2, c2 int) error {
+	if r1 > r2 {
+		r1, r2 = r2, r1
+	}
+	if c1 > c2 {
+		c1, c2 = c2, c1
+	}
+	if r1 < 0 || r2 >= len(g.rows) || c1 < 0 || c2 >= g.cols {
+		return fmt.Errorf("merge range (%d,%d)-(%d,%d) out of range", r1, c1, r2, c2)
+	}
+	for r := r1; r <= r2; r++ {
+		for c := c1; c <= c2; c++ {
+			gc, ok := g.At(r, c)
+			if !ok {
+				return fmt.Errorf("no cell at (%d,%d)", r, c)
+			}
+			if gc.Row < r1 || gc.Row+gc.RowSpan-1 > r2 || gc.Col < c1 || gc.Col+gc.ColSpan-1 > c2 {
+				return errors.New("merge range partially overlaps merged cells")
+			}
+		}
+	}
+	g.ensureGrid()
+
+	target := g.slots[r1][c1].tc
+	for r := r1; r <= r2; r++ {
+		first := g.slots[r][c1].tc
+		for c := c1; c <= c2; c++ {
+			tc := g.slots[r][c].tc
+			if c > c1 && tc == g.slots[r][c-1].tc {
+				continue
+			}
+			if tc != target {
+				g.moveCellContent(target, tc)
+			}
+			if tc != first {
+				removeTc(&g.rows[r]._dfcff.EG_ContentCellContent, tc)
+			}
+		}
+		p := Cell{g.t._efaab, first}.Properties()
+		p.SetColumnSpan(0)
+		if c2 > c1 {
+			p.SetColumnSpan(c2 - c1 + 1)
+		}
+		switch {
+		case r1 == r2:
+			p.SetVerticalMerge(wml.ST_MergeUnset)
+		case r == r1:
+			p.SetVerticalMerge(wml.ST_MergeRestart)
+		default:
+			p.SetVerticalMerge(wml.ST_MergeContinue)
+		}
+		g.setCellWidth(first, c1, c2-c1+1)
+	}
+	g.refresh()
+	return nil
+}
+
+// SplitCell splits the (possibly merged) cell covering the grid position into
+// the given number of rows and columns. Grid columns and table rows are added
+// if the cell doesn't span enough of them, cells sharing the added grid
+// columns or rows are widened or extended to cover them. The content of the
+// cell stays in the first of the resulting cells.
+func (g *TableGrid) SplitCell(row, col, rows, cols int) error {
+	if rows < 1 || cols < 1 {
+		return fmt.Errorf("invalid split size %dx%d", rows, cols)
+	}
+	gc, ok := g.At(row, col)
+	if !ok {
+		return fmt.Errorf("no cell at (%d,%d)", row, col)
+	}
+	g.ensureGrid()
+
+	if gc.ColSpan < cols {
+		g.insertGridColumns(gc.Col+gc.ColSpan-1, cols-gc.ColSpan, true, true)
+		gc.ColSpan = cols
+	}
+	if gc.RowSpan < rows {
+		g.extendRows(gc, rows-gc.RowSpan)
+		gc.RowSpan = rows
+	}
+
+	colSpans := splitEvenly(gc.ColSpan, cols)
+	rowSpans := splitEvenly(gc.RowSpan, rows)
+	r := gc.Row
+	for _, rs := range rowSpans {
+		for i := 0; i < rs; i++ {
+			vm := wml.ST_MergeUnset
+			if rs > 1 && i == 0 {
+				vm = wml.ST_MergeRestart
+			} else if rs > 1 {
+				vm = wml.ST_MergeContinue
+			}
+			tc := g.slots[r][gc.Col].tc
+			c := gc.Col
+			for j, cs := range colSpans {
+				if j > 0 {
+					ntc := g.newCell()
+					insertTcAfter(&g.rows[r]._dfcff.EG_ContentCellContent, tc, ntc)
+					tc = ntc
+				}
+				p := Cell{g.t._efaab, tc}.Properties()
+				p.SetColumnSpan(0)
+				if cs > 1 {
+					p.SetColumnSpan(cs)
+				}
+				p.SetVerticalMerge(vm)
+				g.setCellWidth(tc, c, cs)
+				c += cs
+			}
+			r++
+		}
+	}
+	g.refresh()
+	return nil
+}
+
+// splitEvenly splits n into parts as evenly sized as possible.
+func splitEvenly(n, parts int) []int {
+	ret := make([]int, parts)
+	for i := range ret {
+		ret[i] = n / parts
+		if i < n%parts {
+			ret[i]++
+		}
+	}
+	return ret
+}
+
+// extendRows inserts n rows below the merged cell gc, extending the cell and
+// the vertically merged cells sharing its last row.
+func (g *TableGrid) extendRows(gc GridCell, n int) {
+	last := gc.Row + gc.RowSpan - 1
+	for i := 0; i < n; i++ {
+		g.insertRow(last+i+1, last+i, true)
+		g.refresh()
+	}
+}
+
+// InsertRow inserts a new row before the grid row, or after the last row if
+// row equals Rows(). Cells of the new row copy the column spans of the row
+// they are inserted next to, vertically merged cells surrounding the new row
+// are extended to cover it.
+func (g *TableGrid) InsertRow(row int) (Row, error) {
+	if row < 0 || row > len(g.rows) {
+		return Row{}, fmt.Errorf("row %d out of range", row)
+	}
+	if len(g.rows) == 0 {
+		return g.t.AddRow(), nil
+	}
+	ref := row
+	if ref == len(g.rows) {
+		ref--
+	}
+	g.ensureGrid()
+	r := g.insertRow(row, ref, false)
+	g.refresh()
+	return r, nil
+}
+
+// insertRow inserts a new row at grid row pos, laid out like the row ref.
+// Vertically merged cells of ref are extended into the new row if the row is
+// inserted inside them, or always if extend is set.
+func (g *TableGrid) insertRow(pos, ref int, extend bool) Row {
+	tr := wml.NewCT_Row()
+	if pos < len(g.rows) {
+		insertTr(&g.t._fadb.EG_ContentRowContent, g.rows[pos]._dfcff, tr, false)
+	} else {
+		insertTr(&g.t._fadb.EG_ContentRowContent, g.rows[len(g.rows)-1]._dfcff, tr, true)
+	}
+	nr := Row{g.t._efaab, tr}
+	for c := 0; c < g.cols; {
+		s := g.slots[ref][c]
+		if s.tc == nil {
+			break
+		}
+		gc, _ := g.At(ref, c)
+		cell := nr.AddCell()
+		cell.AddParagraph()
+		g.copyCellLayout(cell._cdea, s.tc)
+		if extend || gc.Row < pos && pos < gc.Row+gc.RowSpan {
+			first := Cell{g.t._efaab, g.slots[gc.Row][c].tc}.Properties()
+			first.SetVerticalMerge(wml.ST_MergeRestart)
+			cell.Properties().SetVerticalMerge(wml.ST_MergeContinue)
+		}
+		c += s.span
+	}
+	return nr
+}
+
+// copyCellLayout copies the column span and width of a cell.
+func (g *TableGrid) copyCellLayout(dst, src *wml.CT_Tc) {
+	c := Cell{g.t._efaab, dst}
+	if span := cellSpan(src); span > 1 {
+		c.Properties().SetColumnSpan(span)
+	}
+	if src.TcPr != nil && src.TcPr.TcW != nil {
+		w := *src.TcPr.TcW
+		c.Properties().X().TcW = &w
+	}
+}
+
+// RemoveRow removes the grid row. Vertically merged cells starting in the row
+// continue in the next row, which receives their content.
+func (g *TableGrid) RemoveRow(row int) error {
+	if row < 0 || row >= len(g.rows) {
+		return fmt.Errorf("row %d out of range", row)
+	}
+	for c := 0; c < g.cols; {
+		s := g.slots[row][c]
+		if s.tc == nil {
+			break
+		}
+		gc, _ := g.At(row, c)
+		if gc.RowSpan > 1 {
+			if gc.Row == row {
+				next := g.slots[row+1][c].tc
+				g.moveCellContent(next, s.tc)
+				if gc.RowSpan > 2 {
+					Cell{g.t._efaab, next}.Properties().SetVerticalMerge(wml.ST_MergeRestart)
+				} else {
+					Cell{g.t._efaab, next}.Properties().SetVerticalMerge(wml.ST_MergeUnset)
+				}
+			} else if gc.RowSpan == 2 {
+				Cell{g.t._efaab, g.slots[gc.Row][c].tc}.Properties().SetVerticalMerge(wml.ST_MergeUnset)
+			}
+		}
+		c += s.span
+	}
+	removeTr(&g.t._fadb.EG_ContentRowContent, g.rows[row]._dfcff)
+	g.refresh()
+	return nil
+}
+
+// InsertColumn inserts a new grid column before the grid column col, or
+// after the last column if col equals Cols(). Cells spanning across the
+// position are widened, other rows receive a new cell. The new column has the
+// width of the column it is inserted next to.
+func (g *TableGrid) InsertColumn(col int) error {
+	if col < 0 || col > g.cols {
+		return fmt.Errorf("column %d out of range", col)
+	}
+	g.ensureGrid()
+	if g.cols == 0 {
+		for _, row := range g.rows {
+			g.newCellIn(row)
+		}
+		g.t._fadb.TblGrid.GridCol = append(g.t._fadb.TblGrid.GridCol, wml.NewCT_TblGridCol())
+		g.refresh()
+		return nil
+	}
+	ref := col
+	if ref == g.cols {
+		ref--
+	}
+	g.insertGridColumns(ref, 1, col > ref, false)
+	g.refresh()
+	return nil
+}
+
+// insertGridColumns inserts n grid columns next to the grid column ref,
+// after it if after is set. Cells spanning across the insertion point are
+// widened, other cells covering ref get new neighbours. The new columns copy
+// the width of ref. If split is set the new columns are split off ref instead,
+// sharing its width, and every cell covering ref is widened.
+func (g *TableGrid) insertGridColumns(ref, n int, after, split bool) {
+	for r, row := range g.rows {
+		s := g.slots[r][ref]
+		if s.tc == nil {
+			continue
+		}
+		if split || after && ref < s.col+s.span-1 || !after && ref > s.col {
+			Cell{g.t._efaab, s.tc}.Properties().SetColumnSpan(s.span + n)
+			continue
+		}
+		for i := 0; i < n; i++ {
+			tc := g.newCell()
+			if s.tc.TcPr != nil && s.tc.TcPr.VMerge != nil {
+				// keep the new cells merged like their neighbour
+				Cell{g.t._efaab, tc}.Properties().SetVerticalMerge(s.tc.TcPr.VMerge.ValAttr)
+			}
+			if after {
+				insertTcAfter(&row._dfcff.EG_ContentCellContent, s.tc, tc)
+			} else {
+				insertTcBefore(&row._dfcff.EG_ContentCellContent, s.tc, tc)
+			}
+		}
+	}
+
+	grid := g.t._fadb.TblGrid
+	w, hasWidth := g.gridWidth(ref)
+	cw := w
+	if split {
+		cw = w / uint64(n+1)
+		if hasWidth {
+			grid.GridCol[ref].WAttr = twipsMeasure(w - cw*uint64(n))
+		}
+	}
+	newCols := make([]*wml.CT_TblGridCol, n)
+	for i := range newCols {
+		newCols[i] = wml.NewCT_TblGridCol()
+		if hasWidth {
+			newCols[i].WAttr = twipsMeasure(cw)
+		}
+	}
+	pos := ref
+	if after {
+		pos++
+	}
+	grid.GridCol = append(grid.GridCol[:pos], append(newCols, grid.GridCol[pos:]...)...)
+	g.refresh()
+	g.updateCellWidths()
+}
+
+// RemoveColumn removes the grid column. Cells spanning the column are
+// narrowed, other cells covering it are removed.
+func (g *TableGrid) RemoveColumn(col int) error {
+	if col < 0 || col >= g.cols {
+		return fmt.Errorf("column %d out of range", col)
+	}
+	if g.cols == 1 {
+		return errors.New("cannot remove the last column of a table")
+	}
+	g.ensureGrid()
+	for r, row := range g.rows {
+		s := g.slots[r][col]
+		if s.tc == nil {
+			continue
+		}
+		if s.span > 1 {
+			Cell{g.t._efaab, s.tc}.Properties().SetColumnSpan(0)
+			if s.span > 2 {
+				Cell{g.t._efaab, s.tc}.Properties().SetColumnSpan(s.span - 1)
+			}
+			continue
+		}
+		removeTc(&row._dfcff.EG_ContentCellContent, s.tc)
+		if len(row.Cells()) == 0 {
+			// keep the row valid, a row needs at least one cell
+			g.newCellIn(row)
+		}
+	}
+	grid := g.t._fadb.TblGrid
+	if col < len(grid.GridCol) {
+		grid.GridCol = append(grid.GridCol[:col], grid.GridCol[col+1:]...)
+	}
+	g.refresh()
+	g.updateCellWidths()
+	return nil
+}
+
+// ColumnWidth returns the width of the grid column, returning false if it is
+// not defined.
+func (g *TableGrid) ColumnWidth(col int) (measurement.Distance, bool) {
+	w, ok := g.gridWidth(col)
+	return measurement.Distance(w) * measurement.Twips, ok
+}
+
+// SetColumnWidth sets the width of the grid column, updating the widths of the
+// cells covering it.
+func (g *TableGrid) SetColumnWidth(col int, w measurement.Distance) error {
+	if col < 0 || col >= g.cols {
+		return fmt.Errorf("column %d out of range", col)
+	}
+	g.ensureGrid()
+	g.t._fadb.TblGrid.GridCol[col].WAttr = twipsMeasure(uint64(w / measurement.Twips))
+	g.updateCellWidths()
+	return nil
+}
+
+// ensureGrid makes sure the table has a grid column definition for every
+// grid column.
+func (g *TableGrid) ensureGrid() {
+	if g.t._fadb.TblGrid == nil {
+		g.t._fadb.TblGrid = wml.NewCT_TblGrid()
+	}
+	g.t.EnsureGridColumns()
+	for len(g.t._fadb.TblGrid.GridCol) < g.cols {
+		g.t._fadb.TblGrid.GridCol = append(g.t._fadb.TblGrid.GridCol, wml.NewCT_TblGridCol())
+	}
+}
+
+// gridWidth returns the width of the grid column in twips.
+func (g *TableGrid) gridWidth(col int) (uint64, bool) {
+	grid := g.t._fadb.TblGrid
+	if grid == nil || col < 0 || col >= len(grid.GridCol) {
+		return 0, false
+	}
+	w := grid.GridCol[col].WAttr
+	if w == nil || w.ST_UnsignedDecimalNumber == nil {
+		return 0, false
+	}
+	return *w.ST_UnsignedDecimalNumber, true
+}
+
+// updateCellWidths sets the width of every cell to the width of the grid
+// columns it covers.
+func (g *TableGrid) updateCellWidths() {
+	for r := range g.slots {
+		for c := 0; c < g.cols; c++ {
+			if s := g.slots[r][c]; s.tc != nil && s.col == c {
+				g.setCellWidth(s.tc, s.col, s.span)
+			}
+		}
+	}
+}
+
+// setCellWidth sets the width of a cell to the width of the grid columns it
+// covers, if all of them have a width.
+func (g *TableGrid) setCellWidth(tc *wml.CT_Tc, col, span int) {
+	if tc == nil {
+		return
+	}
+	total := uint64(0)
+	for c := col; c < col+span; c++ {
+		w, ok := g.gridWidth(c)
+		if !ok {
+			return
+		}
+		total += w
+	}
+	Cell{g.t._efaab, tc}.Properties().SetWidth(measurement.Distance(total) * measurement.Twips)
+}
+
+func twipsMeasure(w uint64) *sharedTypes.ST_TwipsMeasure {
+	return &sharedTypes.ST_TwipsMeasure{ST_UnsignedDecimalNumber: &w}
+}
+
+// newCell returns a new empty cell.
+func (g *TableGrid) newCell() *wml.CT_Tc {
+	tc := wml.NewCT_Tc()
+	Cell{g.t._efaab, tc}.AddParagraph()
+	return tc
+}
+
+func (g *TableGrid) newCellIn(row Row) {
+	row.AddCell().AddParagraph()
+}
+
+// moveCellContent moves the content of src to the end of dst, leaving an
+// empty paragraph in src. Empty cells are not moved.
+func (g *TableGrid) moveCellContent(dst, src *wml.CT_Tc) {
+	if dst == nil || src == nil || dst == src || g.cellIsEmpty(src) {
+		return
+	}
+	if g.cellIsEmpty(dst) {
+		dst.EG_BlockLevelElts = nil
+	}
+	dst.EG_BlockLevelElts = append(dst.EG_BlockLevelElts, src.EG_BlockLevelElts...)
+	src.EG_BlockLevelElts = nil
+	Cell{g.t._efaab, src}.AddParagraph()
+}
+
+// cellIsEmpty returns true if the cell only holds paragraphs without runs.
+func (g *TableGrid) cellIsEmpty(tc *wml.CT_Tc) bool {
+	for _, ble := range tc.EG_BlockLevelElts {
+		for _, cbc := range ble.BlockLevelEltsChoice.EG_ContentBlockContent {
+			if len(cbc.ContentBlockContentChoice.Tbl) > 0 || cbc.ContentBlockContentChoice.Sdt != nil {
+				return false
+			}
+			for _, p := range cbc.ContentBlockContentChoice.P {
+				if len(Paragraph{g.t._efaab, p}.Runs()) > 0 {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func cellSpan(tc *wml.CT_Tc) int {
+	if tc.TcPr != nil && tc.TcPr.GridSpan != nil && tc.TcPr.GridSpan.ValAttr > 1 {
+		return int(tc.TcPr.GridSpan.ValAttr)
+	}
+	return 1
+}
+
+// isVMergeContinue returns true if the cell continues a vertically merged
+// cell of the row above.
+func isVMergeContinue(tc *wml.CT_Tc) bool {
+	return tc != nil && tc.TcPr != nil && tc.TcPr.VMerge != nil && tc.TcPr.VMerge.ValAttr != wml.ST_MergeRestart
+}
+
+// removeTc removes a cell from the cell content of a row.
+func removeTc(ccs *[]*wml.EG_ContentCellContent, tc *wml.CT_Tc) bool {
+	for i, cc := range *ccs {
+		for j, c := range cc.ContentCellContentChoice.Tc {
+			if c == tc {
+				cc.ContentCellContentChoice.Tc = append(cc.ContentCellContentChoice.Tc[:j], cc.ContentCellContentChoice.Tc[j+1:]...)
+				if len(cc.ContentCellContentChoice.Tc) == 0 && cc.ContentCellContentChoice.Sdt == nil {
+					*ccs = append((*ccs)[:i], (*ccs)[i+1:]...)
+				}
+				return true
+			}
+		}
+		if sdt := cc.ContentCellContentChoice.Sdt; sdt != nil && sdt.SdtContent != nil {
+			if removeTc(&sdt.SdtContent.EG_ContentCellContent, tc) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func insertTcBefore(ccs *[]*wml.EG_ContentCellContent, ref, tc *wml.CT_Tc) bool {
+	return insertTc(ccs, ref, tc, 0)
+}
+
+func insertTcAfter(ccs *[]*wml.EG_ContentCellContent, ref, tc *wml.CT_Tc) bool {
+	return insertTc(ccs, ref, tc, 1)
+}
+
+// insertTc inserts a cell next to the cell ref, offset is 0 to insert it
+// before ref and 1 to insert it after ref.
+func insertTc(ccs *[]*wml.EG_ContentCellContent, ref, tc *wml.CT_Tc, offset int) bool {
+	for _, cc := range *ccs {
+		tcs := cc.ContentCellContentChoice.Tc
+		for j, c := range tcs {
+			if c == ref {
+				tcs = append(tcs, nil)
+				copy(tcs[j+offset+1:], tcs[j+offset:])
+				tcs[j+offset] = tc
+				cc.ContentCellContentChoice.Tc = tcs
+				return true
+			}
+		}
+		if sdt := cc.ContentCellContentChoice.Sdt; sdt != nil && sdt.SdtContent != nil {
+			if insertTc(&sdt.SdtContent.EG_ContentCellContent, ref, tc, offset) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeTr removes a row from the row content of a table.
+func removeTr(rcs *[]*wml.EG_ContentRowContent, tr *wml.CT_Row) bool {
+	for i, rc := range *rcs {
+		for j, r := range rc.ContentRowContentChoice.Tr {
+			if r == tr {
+				rc.ContentRowContentChoice.Tr = append(rc.ContentRowContentChoice.Tr[:j], rc.ContentRowContentChoice.Tr[j+1:]...)
+				if len(rc.ContentRowContentChoice.Tr) == 0 && rc.ContentRowContentChoice.Sdt == nil {
+					*rcs = append((*rcs)[:i], (*rcs)[i+1:]...)
+				}
+				return true
+			}
+		}
+		if sdt := rc.ContentRowContentChoice.Sdt; sdt != nil && sdt.SdtContent != nil {
+			if removeTr(&sdt.SdtContent.EG_ContentRowContent, tr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// insertTr inserts a row before the row ref, or after it if after is set.
+func insertTr(rcs *[]*wml.EG_ContentRowContent, ref, tr *wml.CT_Row, after bool) bool {
+	offset := 0
+	if after {
+		offset = 1
+	}
+	for _, rc := range *rcs {
+		trs := rc.ContentRowContentChoice.Tr
+		for j, r := range trs {
+			if r == ref {
+				trs = append(trs, nil)
+				copy(trs[j+offset+1:], trs[j+offset:])
+				trs[j+offset] = tr
+				rc.ContentRowContentChoice.Tr = trs
+				return true
+			}
+		}
+		if sdt := rc.ContentRowContentChoice.Sdt; sdt != nil && sdt.SdtContent != nil {
+			if insertTr(&sdt.SdtContent.EG_ContentRowContent, ref, tr, after) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// newGridTable adds a table with a cell for each of the rows of texts and
+// grid columns of an inch.
+func newGridTable(d *Document, rows ...[]string) Table {
+	t := d.AddTable()
+	t.X().TblGrid = wml.NewCT_TblGrid()
+	for range rows[0] {
+		gc := wml.NewCT_TblGridCol()
+		gc.WAttr = twipsMeasure(1440)
+		t.X().TblGrid.GridCol = append(t.X().TblGrid.GridCol, gc)
+	}
+	for _, texts := range rows {
+		row := t.AddRow()
+		for _, s := range texts {
+			row.AddCell().AddParagraph().AddRun().AddText(s)
+		}
+	}
+	return t
+}
+
+// gridLayout describes the grid one row per line. Cells are written at their
+// first grid position as their paragraph texts, "_" if empty, followed by
+// [rows x cols] if merged. Positions covered by a merged cell are written as
+// "^" and positions without a cell as "-".
+func gridLayout(g *TableGrid) []string {
+	ret := []string{}
+	for r := 0; r < g.Rows(); r++ {
+		cells := []string{}
+		for c := 0; c < g.Cols(); c++ {
+			gc, ok := g.At(r, c)
+			switch {
+			case !ok:
+				cells = append(cells, "-")
+			case gc.Row != r || gc.Col != c:
+				cells = append(cells, "^")
+			default:
+				texts := []string{}
+				for _, p := range gc.Cell.Paragraphs() {
+					texts = append(texts, paragraphText(p))
+				}
+				s := strings.Join(texts, "/")
+				if s == "" {
+					s = "_"
+				}
+				if gc.RowSpan > 1 || gc.ColSpan > 1 {
+					s += fmt.Sprintf("[%dx%d]", gc.RowSpan, gc.ColSpan)
+				}
+				cells = append(cells, s)
+			}
+		}
+		ret = append(ret, strings.Join(cells, " "))
+	}
+	return ret
+}
+
+func checkLayout(t *testing.T, g *TableGrid, want ...string) {
+	t.Helper()
+	if got := gridLayout(g); !reflect.DeepEqual(got, want) {
+		t.Errorf("layout = %q\nwant %q", got, want)
+	}
+}
+
+func TestGridMerge(t *testing.T) {
+	d := New()
+	g := newGridTable(d, []string{"a", "b", "c"}, []string{"d", "e", "f"}, []string{"g", "h", "i"}).Grid()
+	if err := g.MergeCells(1, 1, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	checkLayout(t, g, "a/b/d/e[2x2] ^ c", "^ ^ f", "g h i")
+	gc, _ := g.At(1, 1)
+	if gc.Row != 0 || gc.Col != 0 || gc.RowSpan != 2 || gc.ColSpan != 2 {
+		t.Errorf("At(1, 1) = %+v", gc)
+	}
+	if n := len(g.Cells()); n != 6 {
+		t.Errorf("grid has %d cells, want 6", n)
+	}
+	if w := gc.Cell.Properties().X().TcW; w == nil || *w.WAttr.ST_DecimalNumberOrPercent.ST_UnqualifiedPercentage != 2880 {
+		t.Error("merged cell doesn't have the width of both columns")
+	}
+
+	if err := g.MergeCells(0, 1, 0, 2); err == nil {
+		t.Error("merged a range crossing a merged cell")
+	}
+	if err := g.MergeCells(0, 0, 3, 0); err == nil {
+		t.Error("merged a range out of the grid")
+	}
+
+	read := roundTrip(t, d)
+	checkLayout(t, read.Tables()[0].Grid(), "a/b/d/e[2x2] ^ c", "^ ^ f", "g h i")
+}
+
+func TestGridSplit(t *testing.T) {
+	d := New()
+	g := newGridTable(d, []string{"a", "b", "c"}, []string{"d", "e", "f"}, []string{"g", "h", "i"}).Grid()
+	// the column of a is split, the cells below it are widened
+	if err := g.SplitCell(0, 0, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	checkLayout(t, g, "a _ b c", "d[1x2] ^ e f", "g[1x2] ^ h i")
+	for c, want := range []measurement.Distance{720, 720, 1440, 1440} {
+		if w, ok := g.ColumnWidth(c); !ok || w != want*measurement.Twips {
+			t.Errorf("column %d width = %v, want %v twips", c, w/measurement.Twips, want)
+		}
+	}
+	// the row of f is split, the other cells of the row are extended
+	if err := g.SplitCell(1, 3, 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	checkLayout(t, g, "a _ b c", "d[2x2] ^ e[2x1] f", "^ ^ ^ _", "g[1x2] ^ h i")
+	// a merged cell is split within its area
+	if err := g.SplitCell(1, 0, 2, 2); err != nil {
+		t.Fatal(err)
+	}
+	checkLayout(t, g, "a _ b c", "d _ e[2x1] f", "_ _ ^ _", "g[1x2] ^ h i")
+	if err := g.SplitCell(0, 0, 0, 1); err == nil {
+		t.Error("split a cell into no rows")
+	}
+
+	read := roundTrip(t, d)
+	checkLayout(t, read.Tables()[0].Grid(), "a _ b c", "d _ e[2x1] f", "_ _ ^ _", "g[1x2] ^ h i")
+}
+
+func TestGridRowsAndColumns(t *testing.T) {
+	d := New()
+	g := newGridTable(d, []string{"a", "b", "c"}, []string{"d", "e", "f"}, []string{"g", "h", "i"}).Grid()
+	if err := g.MergeCells(0, 0, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	// a row inserted inside a merged cell extends it
+	if _, err := g.InsertRow(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.InsertRow(4); err != nil {
+		t.Fatal(err)
+	}
+	checkLayout(t, g, "a/d[3x1] b c", "^ _ _", "^ e f", "g h i", "_ _ _")
+	if _, err := g.InsertRow(6); err == nil {
+		t.Error("inserted a row out of range")
+	}
+	// the content of a merged cell moves with its first row
+	if err := g.RemoveRow(0); err != nil {
+		t.Fatal(err)
+	}
+	checkLayout(t, g, "a/d[2x1] _ _", "^ e f", "g h i", "_ _ _")
+	if err := g.RemoveRow(1); err != nil {
+		t.Fatal(err)
+	}
+	checkLayout(t, g, "a/d _ _", "g h i", "_ _ _")
+
+	if err := g.MergeCells(1, 0, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	// a column inserted inside a merged cell widens it
+	if err := g.InsertColumn(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.InsertColumn(4); err != nil {
+		t.Fatal(err)
+	}
+	checkLayout(t, g, "a/d _ _ _ _", "g/h[1x3] ^ ^ i _", "_ _ _ _ _")
+	if w, ok := g.ColumnWidth(4); !ok || w != 1440*measurement.Twips {
+		t.Errorf("inserted column width = %v", w)
+	}
+	if err := g.RemoveColumn(1); err != nil {
+		t.Fatal(err)
+	}
+	checkLayout(t, g, "a/d _ _ _", "g/h[1x2] ^ i _", "_ _ _ _")
+	if err := g.RemoveColumn(4); err == nil {
+		t.Error("removed a column out of range")
+	}
+
+	if err := g.SetColumnWidth(0, 2*measurement.Inch); err != nil {
+		t.Fatal(err)
+	}
+	read := roundTrip(t, d)
+	rg := read.Tables()[0].Grid()
+	checkLayout(t, rg, "a/d _ _ _", "g/h[1x2] ^ i _", "_ _ _ _")
+	if w, ok := rg.ColumnWidth(0); !ok || w != 2*measurement.Inch {
+		t.Errorf("read column width = %v", w)
+	}
+
+	single := newGridTable(d, []string{"x"}).Grid()
+	if err := single.RemoveColumn(0); err == nil {
+		t.Error("removed the last column")
+	}
+}
@@ -0,0 +1,186 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+// Package semantic determines the role of document content, e.g. headings,
+// list items and table header rows, for accessibility checks and tagged PDF
+// output.
+package semantic
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/sharedTypes"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// maxStyleDepth limits the basedOn chains that are followed, guarding against
+// cyclic style definitions.
+const maxStyleDepth = 16
+
+// Style returns the style with the given ID, or nil.
+func Style(styles *wml.Styles, id string) *wml.CT_Style {
+	if styles == nil || id == "" {
+		return nil
+	}
+	for _, s := range styles.Style {
+		if s.StyleIdAttr != nil && *s.StyleIdAttr == id {
+			return s
+		}
+	}
+	return nil
+}
+
+// StyleChain returns the style with the given ID followed by the styles it is
+// based on.
+func StyleChain(styles *wml.Styles, id string) []*wml.CT_Style {
+	chain := []*wml.CT_Style{}
+	for s := Style(styles, id); s != nil && len(chain) < maxStyleDepth; {
+		chain = append(chain, s)
+		if s.BasedOn == nil {
+			break
+		}
+		s = Style(styles, s.BasedOn.ValAttr)
+	}
+	return chain
+}
+
+// HeadingLevel returns the heading level of a paragraph, from 1 to 9, or 0 if
+// the paragraph is not a heading. The level is the outline level of the
+// paragraph or of its style, falling back to built-in heading style names
+// such as "heading 2".
+func HeadingLevel(styles *wml.Styles, p *wml.CT_P) int {
+	if p == nil {
+		return 0
+	}
+	if p.PPr != nil && p.PPr.OutlineLvl != nil {
+		return outlineLevel(p.PPr.OutlineLvl)
+	}
+	if p.PPr == nil || p.PPr.PStyle == nil {
+		return 0
+	}
+	for _, s := range StyleChain(styles, p.PPr.PStyle.ValAttr) {
+		if s.PPr != nil && s.PPr.OutlineLvl != nil {
+			return outlineLevel(s.PPr.OutlineLvl)
+		}
+		name := ""
+		if s.Name != nil {
+			name = s.Name.ValAttr
+		}
+		if lvl := headingStyleLevel(name); lvl > 0 {
+			return lvl
+		}
+		if lvl := headingStyleLevel(*s.StyleIdAttr); lvl > 0 {
+			return lvl
+		}
+	}
+	return 0
+}
+
+// outlineLevel converts a zero based outline level to a heading level, level
+// 9 being body text.
+func outlineLevel(v *wml.CT_DecimalNumber) int {
+	if v.ValAttr < 0 || v.ValAttr > 8 {
+		return 0
+	}
+	return int(v.ValAttr) + 1
+}
+
+// headingStyleLevel returns the level of built-in heading style names and IDs
+// such as "heading 1" and "Heading1".
+func headingStyleLevel(name string) int {
+	name = strings.ToLower(strings.Replace(name, " ", "", -1))
+	if !strings.HasPrefix(name, "heading") {
+		return 0
+	}
+	lvl, err := strconv.Atoi(strings.TrimPrefix(name, "heading"))
+	if err != nil || lvl < 1 || lvl > 9 {
+		return 0
+	}
+	return lvl
+}
+
+// ListLevel returns the zero based list level of a numbered or bulleted
+// paragraph, and false if the paragraph is not a list item.
+func ListLevel(styles *wml.Styles, p *wml.CT_P) (int, bool) {
+	if p == nil || p.PPr == nil {
+		return 0, false
+	}
+	if np := p.PPr.NumPr; np != nil && np.NumId != nil {
+		// numId 0 removes the numbering inherited from the style
+		if np.NumId.ValAttr == 0 {
+			return 0, false
+		}
+		lvl := 0
+		if np.Ilvl != nil {
+			lvl = int(np.Ilvl.ValAttr)
+		}
+		return lvl, true
+	}
+	if p.PPr.PStyle == nil {
+		return 0, false
+	}
+	for _, s := range StyleChain(styles, p.PPr.PStyle.ValAttr) {
+		if s.PPr == nil || s.PPr.NumPr == nil || s.PPr.NumPr.NumId == nil {
+			continue
+		}
+		if s.PPr.NumPr.NumId.ValAttr == 0 {
+			return 0, false
+		}
+		lvl := 0
+		if s.PPr.NumPr.Ilvl != nil {
+			lvl = int(s.PPr.NumPr.Ilvl.ValAttr)
+		}
+		return lvl, true
+	}
+	return 0, false
+}
+
+// IsHeaderRow returns true if the row is marked to repeat as a header row.
+func IsHeaderRow(row *wml.CT_Row) bool {
+	if row == nil || row.TrPr == nil {
+		return false
+	}
+	for _, c := range row.TrPr.TrPrBaseChoice {
+		if c.TblHeader != nil {
+			return IsOn(c.TblHeader)
+		}
+	}
+	return false
+}
+
+// AltText returns the alternative text of a drawing, preferring its
+// description over its title.
+func AltText(docPr *dml.CT_NonVisualDrawingProps) string {
+	if docPr == nil {
+		return ""
+	}
+	if docPr.DescrAttr != nil && strings.TrimSpace(*docPr.DescrAttr) != "" {
+		return strings.TrimSpace(*docPr.DescrAttr)
+	}
+	if docPr.TitleAttr != nil {
+		return strings.TrimSpace(*docPr.TitleAttr)
+	}
+	return ""
+}
+
+// IsOn returns true if an on/off property is present and not turned off.
+func IsOn(v *wml.CT_OnOff) bool {
+	if v == nil {
+		return false
+	}
+	if v.ValAttr == nil {
+		return true
+	}
+	if v.ValAttr.Bool != nil {
+		return *v.ValAttr.Bool
+	}
+	return v.ValAttr.ST_OnOff1 == sharedTypes.ST_OnOff1On
+}
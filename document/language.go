@@ -0,0 +1,355 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"unicode"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// SetLanguage sets the language of Latin and other text that is neither East
+// Asian nor right to left, e.g. "en-US". An empty string removes it.
+func (r RunProperties) SetLanguage(lang string) {
+	r.language(lang != "").ValAttr = optionalString(lang)
+	r.clearEmptyLanguage()
+}
+
+// Language returns the language of Latin text, or an empty string if the run
+// doesn't specify it.
+func (r RunProperties) Language() string {
+	if r._ccfdc.Lang == nil || r._ccfdc.Lang.ValAttr == nil {
+		return ""
+	}
+	return *r._ccfdc.Lang.ValAttr
+}
+
+// SetEastAsianLanguage sets the language of East Asian text, e.g. "ja-JP". An
+// empty string removes it.
+func (r RunProperties) SetEastAsianLanguage(lang string) {
+	r.language(lang != "").EastAsiaAttr = optionalString(lang)
+	r.clearEmptyLanguage()
+}
+
+// EastAsianLanguage returns the language of East Asian text, or an empty
+// string if the run doesn't specify it.
+func (r RunProperties) EastAsianLanguage() string {
+	if r._ccfdc.Lang == nil || r._ccfdc.Lang.EastAsiaAttr == nil {
+		return ""
+	}
+	return *r._ccfdc.Lang.EastAsiaAttr
+}
+
+// SetBidiLanguage sets the language of right to left text, e.g. "ar-SA". An
+// empty string removes it.
+func (r RunProperties) SetBidiLanguage(lang string) {
+	r.language(lang != "").BidiAttr = optionalString(lang)
+	r.clearEmptyLanguage()
+}
+
+// BidiLanguage returns the language of right to left text, or an empty string
+// if the run doesn't specify it.
+func (r RunProperties) BidiLanguage() string {
+	if r._ccfdc.Lang == nil || r._ccfdc.Lang.BidiAttr == nil {
+		return ""
+	}
+	return *r._ccfdc.Lang.BidiAttr
+}
+
+func (r RunProperties) language(create bool) *wml.CT_Language {
+	if r._ccfdc.Lang == nil {
+		if !create {
+			return wml.NewCT_Language()
+		}
+		r._ccfdc.Lang = wml.NewCT_Language()
+	}
+	return r._ccfdc.Lang
+}
+
+func (r RunProperties) clearEmptyLanguage() {
+	if l := r._ccfdc.Lang; l != nil && l.ValAttr == nil && l.EastAsiaAttr == nil && l.BidiAttr == nil {
+		r._ccfdc.Lang = nil
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return unioffice.String(s)
+}
+
+// SetNoProof controls whether spelling and grammar errors are ignored in the
+// run.
+func (r RunProperties) SetNoProof(b bool) {
+	if !b {
+		r._ccfdc.NoProof = nil
+	} else {
+		r._ccfdc.NoProof = wml.NewCT_OnOff()
+	}
+}
+
+// NoProof returns true if spelling and grammar errors are ignored in the run.
+func (r RunProperties) NoProof() bool { return onOffValue(r._ccfdc.NoProof) }
+
+// SetSuppressAutoHyphens controls whether automatic hyphenation is disabled
+// for the paragraph.
+func (p ParagraphProperties) SetSuppressAutoHyphens(b bool) {
+	if !b {
+		p._cfcdc.SuppressAutoHyphens = nil
+	} else {
+		p._cfcdc.SuppressAutoHyphens = wml.NewCT_OnOff()
+	}
+}
+
+// SuppressAutoHyphens returns true if automatic hyphenation is disabled for
+// the paragraph.
+func (p ParagraphProperties) SuppressAutoHyphens() bool {
+	return onOffValue(p._cfcdc.SuppressAutoHyphens)
+}
+
+// SetAutoHyphenation controls whether the document is hyphenated
+// automatically.
+func (s Settings) SetAutoHyphenation(b bool) {
+	if !b {
+		s._egdbfa.AutoHyphenation = nil
+	} else {
+		s._egdbfa.AutoHyphenation = wml.NewCT_OnOff()
+	}
+}
+
+// AutoHyphenation returns true if the document is hyphenated automatically.
+func (s Settings) AutoHyphenation() bool { return onOffValue(s._egdbfa.AutoHyphenation) }
+
+// SetHyphenationZone sets the distance from the right margin within which
+// words are not hyphenated. Zero removes the setting, in which case Word uses
+// a quarter of an inch.
+func (s Settings) SetHyphenationZone(d measurement.Distance) {
+	if d == 0 {
+		s._egdbfa.HyphenationZone = nil
+		return
+	}
+	s._egdbfa.HyphenationZone = wml.NewCT_TwipsMeasure()
+	s._egdbfa.HyphenationZone.ValAttr.ST_UnsignedDecimalNumber = unioffice.Uint64(uint64(d / measurement.Twips))
+}
+
+// HyphenationZone returns the hyphenation zone of the document.
+func (s Settings) HyphenationZone() measurement.Distance {
+	if hz := s._egdbfa.HyphenationZone; hz != nil && hz.ValAttr.ST_UnsignedDecimalNumber != nil {
+		return measurement.Distance(*hz.ValAttr.ST_UnsignedDecimalNumber) * measurement.Twips
+	}
+	return 0.25 * measurement.Inch
+}
+
+// SetConsecutiveHyphenLimit sets the maximum number of consecutive lines that
+// may end with a hyphen, zero meaning no limit.
+func (s Settings) SetConsecutiveHyphenLimit(n int) {
+	if n <= 0 {
+		s._egdbfa.ConsecutiveHyphenLimit = nil
+		return
+	}
+	s._egdbfa.ConsecutiveHyphenLimit = wml.NewCT_DecimalNumber()
+	s._egdbfa.ConsecutiveHyphenLimit.ValAttr = int64(n)
+}
+
+// ConsecutiveHyphenLimit returns the maximum number of consecutive hyphenated
+// lines, zero meaning no limit.
+func (s Settings) ConsecutiveHyphenLimit() int {
+	if s._egdbfa.ConsecutiveHyphenLimit == nil {
+		return 0
+	}
+	return int(s._egdbfa.ConsecutiveHyphenLimit.ValAttr)
+}
+
+// SetDoNotHyphenateCaps controls whether words in capital letters are
+// excluded from automatic hyphenation.
+func (s Settings) SetDoNotHyphenateCaps(b bool) {
+	if !b {
+		s._egdbfa.DoNotHyphenateCaps = nil
+	} else {
+		s._egdbfa.DoNotHyphenateCaps = wml.NewCT_OnOff()
+	}
+}
+
+// DoNotHyphenateCaps returns true if words in capital letters are not
+// hyphenated.
+func (s Settings) DoNotHyphenateCaps() bool { return onOffValue(s._egdbfa.DoNotHyphenateCaps) }
+
+func onOffValue(v *wml.CT_OnOff) bool {
+	return v != nil && (v.ValAttr == nil || isOnOff(v.ValAttr))
+}
+
+// Script is a writing system detected in text.
+type Script byte
+
+// Script constants.
+const (
+	ScriptUnknown Script = iota
+	ScriptLatin
+	ScriptCyrillic
+	ScriptGreek
+	ScriptArabic
+	ScriptHebrew
+	ScriptHan
+	ScriptKana
+	ScriptHangul
+	ScriptThai
+	ScriptDevanagari
+)
+
+var scriptTables = []struct {
+	script Script
+	table  *unicode.RangeTable
+}{
+	{ScriptLatin, unicode.Latin},
+	{ScriptCyrillic, unicode.Cyrillic},
+	{ScriptGreek, unicode.Greek},
+	{ScriptArabic, unicode.Arabic},
+	{ScriptHebrew, unicode.Hebrew},
+	{ScriptHan, unicode.Han},
+	{ScriptKana, unicode.Hiragana},
+	{ScriptKana, unicode.Katakana},
+	{ScriptHangul, unicode.Hangul},
+	{ScriptThai, unicode.Thai},
+	{ScriptDevanagari, unicode.Devanagari},
+}
+
+// ScriptOf returns the script of a character, or ScriptUnknown for characters
+// such as digits and punctuation that are common to all scripts.
+func ScriptOf(c rune) Script {
+	for _, st := range scriptTables {
+		if unicode.Is(st.table, c) {
+			return st.script
+		}
+	}
+	return ScriptUnknown
+}
+
+// DetectScript returns the script of most of the letters of the text.
+func DetectScript(text string) Script {
+	counts := map[Script]int{}
+	best := ScriptUnknown
+	for _, c := range text {
+		if s := ScriptOf(c); s != ScriptUnknown {
+			counts[s]++
+			if counts[s] > counts[best] || best == ScriptUnknown {
+				best = s
+			}
+		}
+	}
+	return best
+}
+
+// IsEastAsian returns true for scripts whose language is set with
+// SetEastAsianLanguage.
+func (s Script) IsEastAsian() bool {
+	return s == ScriptHan || s == ScriptKana || s == ScriptHangul
+}
+
+// IsRightToLeft returns true for scripts whose language is set with
+// SetBidiLanguage.
+func (s Script) IsRightToLeft() bool {
+	return s == ScriptArabic || s == ScriptHebrew
+}
+
+// ScriptLanguages maps scripts to the languages tagged on text written in
+// them.
+type ScriptLanguages map[Script]string
+
+// DefaultScriptLanguages are common languages for each script.
+var DefaultScriptLanguages = ScriptLanguages{
+	ScriptLatin:      "en-US",
+	ScriptCyrillic:   "ru-RU",
+	ScriptGreek:      "el-GR",
+	ScriptArabic:     "ar-SA",
+	ScriptHebrew:     "he-IL",
+	ScriptHan:        "zh-CN",
+	ScriptKana:       "ja-JP",
+	ScriptHangul:     "ko-KR",
+	ScriptThai:       "th-TH",
+	ScriptDevanagari: "hi-IN",
+}
+
+// TagLanguage sets the language of the run from the script of its text, in
+// the East Asian or right to left language attribute as appropriate. Runs
+// without letters of a mapped script are left unchanged.
+func (r Run) TagLanguage(langs ScriptLanguages) {
+	s := DetectScript(r.Text())
+	lang, ok := langs[s]
+	if !ok || lang == "" {
+		return
+	}
+	rp := r.Properties()
+	switch {
+	case s.IsEastAsian():
+		rp.SetEastAsianLanguage(lang)
+	case s.IsRightToLeft():
+		rp.SetBidiLanguage(lang)
+	default:
+		rp.SetLanguage(lang)
+	}
+}
+
+// TagLanguages splits the runs of the paragraph where the script of the text
+// changes between scripts whose languages share a language attribute, e.g.
+// Latin and Cyrillic, and tags each run with the language of its script.
+// Characters common to all scripts stay with the preceding text.
+func (p Paragraph) TagLanguages(langs ScriptLanguages) {
+	for _, r := range p.Runs() {
+		for {
+			off := scriptChange(r.Text(), langs)
+			if off < 0 {
+				r.TagLanguage(langs)
+				break
+			}
+			next := p.splitRun(r, off)
+			r.TagLanguage(langs)
+			r = next
+		}
+	}
+}
+
+// TagLanguages tags the runs of the body, headers and footers with the
+// languages of their scripts, see Paragraph.TagLanguages.
+func (d *Document) TagLanguages(langs ScriptLanguages) {
+	for _, p := range d.searchParagraphs(FindInBody | FindInHeaders | FindInFooters | FindInTextBoxes) {
+		p.TagLanguages(langs)
+	}
+}
+
+// scriptChange returns the character offset at which the text switches to a
+// script with another language in the same language attribute, or -1.
+func scriptChange(text string, langs ScriptLanguages) int {
+	attr := func(s Script) int {
+		switch {
+		case s.IsEastAsian():
+			return 1
+		case s.IsRightToLeft():
+			return 2
+		}
+		return 0
+	}
+	last := map[int]Script{}
+	i := 0
+	for _, c := range text {
+		s := ScriptOf(c)
+		if _, ok := langs[s]; ok && s != ScriptUnknown {
+			a := attr(s)
+			if prev, ok := last[a]; ok && langs[prev] != langs[s] {
+				return i
+			}
+			last[a] = s
+		}
+		i++
+	}
+	return -1
+}
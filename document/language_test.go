@@ -0,0 +1,174 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/measurement"
+)
+
+func TestRunLanguage(t *testing.T) {
+	d := New()
+	p := d.AddParagraph()
+	rp := p.AddRun().Properties()
+	rp.SetLanguage("en-GB")
+	rp.SetEastAsianLanguage("ja-JP")
+	rp.SetBidiLanguage("ar-SA")
+	rp.SetNoProof(true)
+	if rp.Language() != "en-GB" || rp.EastAsianLanguage() != "ja-JP" || rp.BidiLanguage() != "ar-SA" || !rp.NoProof() {
+		t.Errorf("languages = %q %q %q", rp.Language(), rp.EastAsianLanguage(), rp.BidiLanguage())
+	}
+	p.Properties().SetSuppressAutoHyphens(true)
+
+	read := roundTrip(t, d)
+	rrp := read.Paragraphs()[0].Runs()[0].Properties()
+	if rrp.Language() != "en-GB" || rrp.EastAsianLanguage() != "ja-JP" || rrp.BidiLanguage() != "ar-SA" || !rrp.NoProof() {
+		t.Errorf("read languages = %q %q %q", rrp.Language(), rrp.EastAsianLanguage(), rrp.BidiLanguage())
+	}
+	if !read.Paragraphs()[0].Properties().SuppressAutoHyphens() {
+		t.Error("read paragraph doesn't suppress hyphens")
+	}
+
+	rp.SetLanguage("")
+	if rp.Language() != "" || rp.EastAsianLanguage() != "ja-JP" {
+		t.Error("removing the language removed the others")
+	}
+	rp.SetEastAsianLanguage("")
+	rp.SetBidiLanguage("")
+	if rp.X().Lang != nil {
+		t.Error("empty language element kept")
+	}
+	// removing a missing language doesn't add the element
+	rp.SetBidiLanguage("")
+	rp.SetNoProof(false)
+	if rp.X().Lang != nil || rp.X().NoProof != nil || rp.NoProof() {
+		t.Error("properties not removed")
+	}
+	p.Properties().SetSuppressAutoHyphens(false)
+	if p.Properties().SuppressAutoHyphens() {
+		t.Error("paragraph still suppresses hyphens")
+	}
+}
+
+func TestHyphenationSettings(t *testing.T) {
+	d := New()
+	s := d.Settings
+	if s.AutoHyphenation() || s.DoNotHyphenateCaps() || s.ConsecutiveHyphenLimit() != 0 {
+		t.Error("hyphenation settings set in a new document")
+	}
+	if s.HyphenationZone() != 0.25*measurement.Inch {
+		t.Errorf("default hyphenation zone = %v", s.HyphenationZone())
+	}
+	s.SetAutoHyphenation(true)
+	s.SetDoNotHyphenateCaps(true)
+	s.SetConsecutiveHyphenLimit(2)
+	s.SetHyphenationZone(0.5 * measurement.Inch)
+
+	read := roundTrip(t, d)
+	rs := read.Settings
+	if !rs.AutoHyphenation() || !rs.DoNotHyphenateCaps() || rs.ConsecutiveHyphenLimit() != 2 || rs.HyphenationZone() != 0.5*measurement.Inch {
+		t.Errorf("read settings = %v %v %d %v", rs.AutoHyphenation(), rs.DoNotHyphenateCaps(), rs.ConsecutiveHyphenLimit(), rs.HyphenationZone())
+	}
+
+	s.SetAutoHyphenation(false)
+	s.SetDoNotHyphenateCaps(false)
+	s.SetConsecutiveHyphenLimit(0)
+	s.SetHyphenationZone(0)
+	x := s.X()
+	if x.AutoHyphenation != nil || x.DoNotHyphenateCaps != nil || x.ConsecutiveHyphenLimit != nil || x.HyphenationZone != nil {
+		t.Error("hyphenation settings not removed")
+	}
+}
+
+func TestScripts(t *testing.T) {
+	scripts := []struct {
+		c    rune
+		want Script
+	}{
+		{'a', ScriptLatin}, {'é', ScriptLatin}, {'Ж', ScriptCyrillic}, {'α', ScriptGreek},
+		{'ع', ScriptArabic}, {'ש', ScriptHebrew}, {'漢', ScriptHan}, {'か', ScriptKana},
+		{'カ', ScriptKana}, {'한', ScriptHangul}, {'ก', ScriptThai}, {'क', ScriptDevanagari},
+		{'1', ScriptUnknown}, {'.', ScriptUnknown}, {' ', ScriptUnknown},
+	}
+	for _, tc := range scripts {
+		if got := ScriptOf(tc.c); got != tc.want {
+			t.Errorf("ScriptOf(%q) = %d, want %d", tc.c, got, tc.want)
+		}
+	}
+	texts := []struct {
+		text string
+		want Script
+	}{
+		{"Привет, world", ScriptCyrillic},
+		{"日本語のテキスト", ScriptKana},
+		{"2024-01-01", ScriptUnknown},
+		{"", ScriptUnknown},
+	}
+	for _, tc := range texts {
+		if got := DetectScript(tc.text); got != tc.want {
+			t.Errorf("DetectScript(%q) = %d, want %d", tc.text, got, tc.want)
+		}
+	}
+	if !ScriptHangul.IsEastAsian() || ScriptThai.IsEastAsian() || !ScriptHebrew.IsRightToLeft() || ScriptLatin.IsRightToLeft() {
+		t.Error("wrong script classification")
+	}
+}
+
+func TestTagLanguages(t *testing.T) {
+	d := New()
+	p := d.AddParagraph()
+	p.AddRun().AddText("Hello Мир and 日本")
+	p.AddRun().AddText("こんにちは")
+	p.AddRun().AddText("שלום")
+	p.AddRun().AddText("123")
+	d.AddHeader().AddParagraph().AddRun().AddText("Bonjour")
+
+	d.TagLanguages(DefaultScriptLanguages)
+	type lang struct{ text, lang, eastAsian, bidi string }
+	langs := func(p Paragraph) []lang {
+		ret := []lang{}
+		for _, r := range p.Runs() {
+			rp := r.Properties()
+			ret = append(ret, lang{r.Text(), rp.Language(), rp.EastAsianLanguage(), rp.BidiLanguage()})
+		}
+		return ret
+	}
+	want := []lang{
+		{"Hello ", "en-US", "", ""},
+		{"Мир ", "ru-RU", "", ""},
+		{"and 日本", "en-US", "", ""},
+		{"こんにちは", "", "ja-JP", ""},
+		{"שלום", "", "", "he-IL"},
+		{"123", "", "", ""},
+	}
+	if got := langs(p); !reflect.DeepEqual(got, want) {
+		t.Errorf("languages = %+v\nwant %+v", got, want)
+	}
+	header := d.Headers()[0].Paragraphs()[0]
+	if got := header.Runs()[0].Properties().Language(); got != "en-US" {
+		t.Errorf("header language = %q", got)
+	}
+
+	// scripts sharing a language aren't split
+	header.TagLanguages(ScriptLanguages{ScriptLatin: "fr-FR", ScriptCyrillic: "fr-FR"})
+	if got := langs(header); !reflect.DeepEqual(got, []lang{{"Bonjour", "fr-FR", "", ""}}) {
+		t.Errorf("retagged header = %+v", got)
+	}
+	if off := scriptChange("abc где", ScriptLanguages{ScriptLatin: "x", ScriptCyrillic: "x"}); off != -1 {
+		t.Errorf("split scripts of the same language at %d", off)
+	}
+
+	read := roundTrip(t, d)
+	if got := langs(read.Paragraphs()[0]); !reflect.DeepEqual(got, want) {
+		t.Errorf("read languages = %+v", got)
+	}
+}
@@ -0,0 +1,668 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package math
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/math"
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/sharedTypes"
+)
+
+// LaTeX returns the equation as a LaTeX math expression.
+func (e *Equation) LaTeX() string {
+	sb := &strings.Builder{}
+	writeLaTeX(sb, e.x.EG_OMathElements)
+	return strings.TrimSpace(sb.String())
+}
+
+// Text returns a linear, plain text representation of the equation using
+// Unicode characters, e.g. "x=(−b±√(b²−4ac))/(2a)". It is used when rendering
+// equations to formats that have no math layout support.
+func (e *Equation) Text() string {
+	sb := &strings.Builder{}
+	writeText(sb, e.x.EG_OMathElements)
+	return sb.String()
+}
+
+// LaTeX returns the element as a LaTeX math expression.
+func (e Element) LaTeX() string {
+	sb := &strings.Builder{}
+	writeLaTeX(sb, e.x)
+	return strings.TrimSpace(sb.String())
+}
+
+// Text returns a linear, plain text representation of the element.
+func (e Element) Text() string {
+	sb := &strings.Builder{}
+	writeText(sb, e.x)
+	return sb.String()
+}
+
+func choices(elems []*math.EG_OMathElements) []*math.EG_OMathMathElementsChoice {
+	ret := []*math.EG_OMathMathElementsChoice{}
+	for _, el := range elems {
+		if el == nil || el.OMathElementsChoice == nil || el.OMathElementsChoice.OMathMathElementsChoice == nil {
+			continue
+		}
+		ret = append(ret, el.OMathElementsChoice.OMathMathElementsChoice)
+	}
+	return ret
+}
+
+func argElements(a *math.CT_OMathArg) []*math.EG_OMathElements {
+	if a == nil {
+		return nil
+	}
+	return a.EG_OMathElements
+}
+
+// choiceArgs returns the arguments of the structure held by c.
+func choiceArgs(c *math.EG_OMathMathElementsChoice) []*math.CT_OMathArg {
+	switch {
+	case c.F != nil:
+		return []*math.CT_OMathArg{c.F.Num, c.F.Den}
+	case c.Rad != nil:
+		return []*math.CT_OMathArg{c.Rad.Deg, c.Rad.E}
+	case c.SSup != nil:
+		return []*math.CT_OMathArg{c.SSup.E, c.SSup.Sup}
+	case c.SSub != nil:
+		return []*math.CT_OMathArg{c.SSub.E, c.SSub.Sub}
+	case c.SSubSup != nil:
+		return []*math.CT_OMathArg{c.SSubSup.E, c.SSubSup.Sub, c.SSubSup.Sup}
+	case c.SPre != nil:
+		return []*math.CT_OMathArg{c.SPre.Sub, c.SPre.Sup, c.SPre.E}
+	case c.Nary != nil:
+		return []*math.CT_OMathArg{c.Nary.Sub, c.Nary.Sup, c.Nary.E}
+	case c.D != nil:
+		return c.D.E
+	case c.M != nil:
+		ret := []*math.CT_OMathArg{}
+		for _, r := range c.M.Mr {
+			ret = append(ret, r.E...)
+		}
+		return ret
+	case c.EqArr != nil:
+		return c.EqArr.E
+	case c.Acc != nil:
+		return []*math.CT_OMathArg{c.Acc.E}
+	case c.Bar != nil:
+		return []*math.CT_OMathArg{c.Bar.E}
+	case c.Func != nil:
+		return []*math.CT_OMathArg{c.Func.FName, c.Func.E}
+	case c.LimLow != nil:
+		return []*math.CT_OMathArg{c.LimLow.E, c.LimLow.Lim}
+	case c.LimUpp != nil:
+		return []*math.CT_OMathArg{c.LimUpp.E, c.LimUpp.Lim}
+	case c.GroupChr != nil:
+		return []*math.CT_OMathArg{c.GroupChr.E}
+	case c.Box != nil:
+		return []*math.CT_OMathArg{c.Box.E}
+	case c.BorderBox != nil:
+		return []*math.CT_OMathArg{c.BorderBox.E}
+	case c.Phant != nil:
+		return []*math.CT_OMathArg{c.Phant.E}
+	}
+	return nil
+}
+
+func runText(r *math.CT_R) string {
+	sb := strings.Builder{}
+	for _, c := range r.RChoice {
+		if c.T != nil {
+			sb.WriteString(c.T.Content)
+		}
+	}
+	return sb.String()
+}
+
+func isOn(v *math.CT_OnOff) bool {
+	if v == nil {
+		return false
+	}
+	if v.ValAttr == nil {
+		return true
+	}
+	if v.ValAttr.Bool != nil {
+		return *v.ValAttr.Bool
+	}
+	return v.ValAttr.ST_OnOff1 != sharedTypes.ST_OnOff1Off
+}
+
+func charOr(c *math.CT_Char, def string) string {
+	if c == nil {
+		return def
+	}
+	return c.ValAttr
+}
+
+// writeLaTeX writes the LaTeX form of elems to sb.
+func writeLaTeX(sb *strings.Builder, elems []*math.EG_OMathElements) {
+	for _, c := range choices(elems) {
+		switch {
+		case c.R != nil:
+			writeLaTeXRun(sb, c.R)
+		case c.F != nil:
+			if c.F.FPr != nil && c.F.FPr.Type != nil && c.F.FPr.Type.ValAttr == math.ST_FTypeNoBar {
+				sb.WriteString("\\genfrac{}{}{0pt}{}")
+			} else {
+				sb.WriteString("\\frac")
+			}
+			writeLaTeXGroup(sb, argElements(c.F.Num))
+			writeLaTeXGroup(sb, argElements(c.F.Den))
+		case c.Rad != nil:
+			sb.WriteString("\\sqrt")
+			if deg := argElements(c.Rad.Deg); len(deg) > 0 && !(c.Rad.RadPr != nil && isOn(c.Rad.RadPr.DegHide)) {
+				sb.WriteString("[")
+				writeLaTeX(sb, deg)
+				sb.WriteString("]")
+			}
+			writeLaTeXGroup(sb, argElements(c.Rad.E))
+		case c.SSup != nil:
+			writeLaTeXBase(sb, argElements(c.SSup.E))
+			sb.WriteString("^")
+			writeLaTeXGroup(sb, argElements(c.SSup.Sup))
+		case c.SSub != nil:
+			writeLaTeXBase(sb, argElements(c.SSub.E))
+			sb.WriteString("_")
+			writeLaTeXGroup(sb, argElements(c.SSub.Sub))
+		case c.SSubSup != nil:
+			writeLaTeXBase(sb, argElements(c.SSubSup.E))
+			sb.WriteString("_")
+			writeLaTeXGroup(sb, argElements(c.SSubSup.Sub))
+			sb.WriteString("^")
+			writeLaTeXGroup(sb, argElements(c.SSubSup.Sup))
+		case c.SPre != nil:
+			sb.WriteString("{}_")
+			writeLaTeXGroup(sb, argElements(c.SPre.Sub))
+			sb.WriteString("^")
+			writeLaTeXGroup(sb, argElements(c.SPre.Sup))
+			writeLaTeXBase(sb, argElements(c.SPre.E))
+		case c.Nary != nil:
+			writeLaTeXNary(sb, c.Nary)
+		case c.D != nil:
+			writeLaTeXDelimiter(sb, c.D)
+		case c.M != nil:
+			writeLaTeXMatrix(sb, c.M)
+		case c.Acc != nil:
+			chr := AccentHat
+			if c.Acc.AccPr != nil {
+				chr = charOr(c.Acc.AccPr.Chr, AccentHat)
+			}
+			cmd := "hat"
+			for name, a := range latexAccents {
+				if a == chr && !strings.HasPrefix(name, "wide") {
+					cmd = name
+				}
+			}
+			sb.WriteString("\\" + cmd)
+			writeLaTeXGroup(sb, argElements(c.Acc.E))
+		case c.Bar != nil:
+			if c.Bar.BarPr != nil && c.Bar.BarPr.Pos != nil && c.Bar.BarPr.Pos.ValAttr == math.ST_TopBotTop {
+				sb.WriteString("\\overline")
+			} else {
+				sb.WriteString("\\underline")
+			}
+			writeLaTeXGroup(sb, argElements(c.Bar.E))
+		case c.Func != nil:
+			writeLaTeXFunctionName(sb, argElements(c.Func.FName))
+			sb.WriteString(" ")
+			writeLaTeX(sb, argElements(c.Func.E))
+		case c.LimLow != nil:
+			writeLaTeXFunctionName(sb, argElements(c.LimLow.E))
+			sb.WriteString("_")
+			writeLaTeXGroup(sb, argElements(c.LimLow.Lim))
+		case c.LimUpp != nil:
+			writeLaTeXFunctionName(sb, argElements(c.LimUpp.E))
+			sb.WriteString("^")
+			writeLaTeXGroup(sb, argElements(c.LimUpp.Lim))
+		case c.GroupChr != nil:
+			cmd := "\\underbrace"
+			if c.GroupChr.GroupChrPr != nil && c.GroupChr.GroupChrPr.Pos != nil && c.GroupChr.GroupChrPr.Pos.ValAttr == math.ST_TopBotTop {
+				cmd = "\\overbrace"
+			}
+			sb.WriteString(cmd)
+			writeLaTeXGroup(sb, argElements(c.GroupChr.E))
+		case c.EqArr != nil:
+			writeLaTeXEqArr(sb, c.EqArr, "aligned")
+		case c.Box != nil:
+			writeLaTeX(sb, argElements(c.Box.E))
+		case c.BorderBox != nil:
+			sb.WriteString("\\boxed")
+			writeLaTeXGroup(sb, argElements(c.BorderBox.E))
+		case c.Phant != nil:
+			sb.WriteString("\\phantom")
+			writeLaTeXGroup(sb, argElements(c.Phant.E))
+		}
+	}
+}
+
+func writeLaTeXGroup(sb *strings.Builder, elems []*math.EG_OMathElements) {
+	sb.WriteString("{")
+	writeLaTeX(sb, elems)
+	sb.WriteString("}")
+}
+
+// writeLaTeXBase writes the base of a script, grouping it unless it is a
+// single character or a function name, e.g. the log of \log_2.
+func writeLaTeXBase(sb *strings.Builder, elems []*math.EG_OMathElements) {
+	cs := choices(elems)
+	switch {
+	case isFunctionName(cs):
+		writeLaTeXFunctionName(sb, elems)
+	case len(cs) == 1 && cs[0].R != nil && utf8.RuneCountInString(runText(cs[0].R)) == 1:
+		writeLaTeX(sb, elems)
+	default:
+		writeLaTeXGroup(sb, elems)
+	}
+}
+
+// isFunctionName reports whether cs is a single run of normal text naming a
+// LaTeX function.
+func isFunctionName(cs []*math.EG_OMathMathElementsChoice) bool {
+	if len(cs) != 1 || cs[0].R == nil || cs[0].R.RPr == nil || !isOn(cs[0].R.RPr.Nor) {
+		return false
+	}
+	name := runText(cs[0].R)
+	return latexFunctions[name] || latexLimits[name]
+}
+
+func writeLaTeXRun(sb *strings.Builder, r *math.CT_R) {
+	text := runText(r)
+	if r.RPr != nil && isOn(r.RPr.Nor) {
+		sb.WriteString("\\text{" + text + "}")
+		return
+	}
+	style := letterPlain
+	for _, ch := range text {
+		plain, st := plainLetter(ch)
+		if st == letterItalic {
+			// italic is the default style of math letters
+			st = letterPlain
+		}
+		if st != style {
+			if style != letterPlain {
+				sb.WriteString("}")
+			}
+			switch st {
+			case letterBold:
+				sb.WriteString("\\mathbf{")
+			case letterBoldItalic:
+				sb.WriteString("\\boldsymbol{")
+			}
+			style = st
+		}
+		s := string(plain)
+		switch {
+		case s == "−":
+			sb.WriteString("-")
+		case s == "′":
+			sb.WriteString("'")
+		case s == " ":
+			sb.WriteString("\\ ")
+		case s == "{" || s == "}" || s == "%" || s == "#" || s == "_" || s == "$":
+			sb.WriteString("\\" + s)
+		case symbolCommands[s] != "":
+			sb.WriteString("\\" + symbolCommands[s] + " ")
+		default:
+			sb.WriteString(s)
+		}
+	}
+	if style != letterPlain {
+		sb.WriteString("}")
+	}
+}
+
+func writeLaTeXFunctionName(sb *strings.Builder, elems []*math.EG_OMathElements) {
+	cs := choices(elems)
+	if len(cs) == 1 && cs[0].R != nil {
+		name := runText(cs[0].R)
+		if latexFunctions[name] || latexLimits[name] {
+			sb.WriteString("\\" + name)
+		} else {
+			sb.WriteString("\\operatorname{" + name + "}")
+		}
+		return
+	}
+	writeLaTeX(sb, elems)
+}
+
+func writeLaTeXNary(sb *strings.Builder, n *math.CT_Nary) {
+	chr := OperatorIntegral
+	var pr *math.CT_NaryPr
+	if n.NaryPr != nil {
+		pr = n.NaryPr
+		chr = charOr(pr.Chr, OperatorIntegral)
+	}
+	cmd := ""
+	for name, op := range latexNary {
+		if op == chr {
+			cmd = "\\" + name
+		}
+	}
+	if cmd == "" {
+		cmd = chr
+	}
+	sb.WriteString(cmd)
+	if sub := argElements(n.Sub); len(sub) > 0 && !(pr != nil && isOn(pr.SubHide)) {
+		sb.WriteString("_")
+		writeLaTeXGroup(sb, sub)
+	}
+	if sup := argElements(n.Sup); len(sup) > 0 && !(pr != nil && isOn(pr.SupHide)) {
+		sb.WriteString("^")
+		writeLaTeXGroup(sb, sup)
+	}
+	sb.WriteString(" ")
+	writeLaTeX(sb, argElements(n.E))
+}
+
+func latexDelimiter(s string) string {
+	switch s {
+	case "":
+		return "."
+	case "{", "}":
+		return "\\" + s
+	case "‖":
+		return "\\|"
+	}
+	if cmd := symbolCommands[s]; cmd != "" {
+		return "\\" + cmd
+	}
+	return s
+}
+
+func delimiters(d *math.CT_D) (beg, sep, end string) {
+	beg, sep, end = "(", "|", ")"
+	if d.DPr != nil {
+		beg = charOr(d.DPr.BegChr, beg)
+		sep = charOr(d.DPr.SepChr, sep)
+		end = charOr(d.DPr.EndChr, end)
+	}
+	return beg, sep, end
+}
+
+func writeLaTeXDelimiter(sb *strings.Builder, d *math.CT_D) {
+	beg, sep, end := delimiters(d)
+	// a matrix inside brackets maps to the matching matrix environment and an
+	// equation array in a left brace to a cases environment
+	if len(d.E) == 1 {
+		cs := choices(d.E[0].EG_OMathElements)
+		if len(cs) == 1 && cs[0].EqArr != nil && beg == "{" && end == "" {
+			writeLaTeXEqArr(sb, cs[0].EqArr, "cases")
+			return
+		}
+		if len(cs) == 1 && cs[0].M != nil {
+			for env, delims := range latexMatrices {
+				if delims[0] == beg && delims[1] == end && env != "matrix" && env != "smallmatrix" {
+					writeLaTeXMatrixEnv(sb, cs[0].M, env)
+					return
+				}
+			}
+		}
+	}
+	sb.WriteString("\\left" + latexDelimiter(beg))
+	for i, e := range d.E {
+		if i > 0 {
+			sb.WriteString("\\middle" + latexDelimiter(sep))
+		}
+		writeLaTeX(sb, argElements(e))
+	}
+	sb.WriteString("\\right" + latexDelimiter(end))
+}
+
+func writeLaTeXEqArr(sb *strings.Builder, a *math.CT_EqArr, env string) {
+	sb.WriteString("\\begin{" + env + "}")
+	for i, e := range a.E {
+		if i > 0 {
+			sb.WriteString(" \\\\ ")
+		}
+		writeLaTeX(sb, argElements(e))
+	}
+	sb.WriteString("\\end{" + env + "}")
+}
+
+func writeLaTeXMatrix(sb *strings.Builder, m *math.CT_M) { writeLaTeXMatrixEnv(sb, m, "matrix") }
+
+func writeLaTeXMatrixEnv(sb *strings.Builder, m *math.CT_M, env string) {
+	sb.WriteString("\\begin{" + env + "}")
+	for i, r := range m.Mr {
+		if i > 0 {
+			sb.WriteString(" \\\\ ")
+		}
+		for j, e := range r.E {
+			if j > 0 {
+				sb.WriteString(" & ")
+			}
+			writeLaTeX(sb, argElements(e))
+		}
+	}
+	sb.WriteString("\\end{" + env + "}")
+}
+
+var superscripts = map[rune]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶',
+	'7': '⁷', '8': '⁸', '9': '⁹', '+': '⁺', '−': '⁻', '-': '⁻', '=': '⁼',
+	'(': '⁽', ')': '⁾', 'n': 'ⁿ', 'i': 'ⁱ',
+}
+
+var subscripts = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆',
+	'7': '₇', '8': '₈', '9': '₉', '+': '₊', '−': '₋', '-': '₋', '=': '₌',
+	'(': '₍', ')': '₎', 'a': 'ₐ', 'e': 'ₑ', 'i': 'ᵢ', 'j': 'ⱼ', 'k': 'ₖ',
+	'n': 'ₙ', 'm': 'ₘ', 'o': 'ₒ', 'x': 'ₓ',
+}
+
+// mapScript maps s to Unicode super- or subscript characters if every
+// character has such a form.
+func mapScript(s string, table map[rune]rune) (string, bool) {
+	sb := strings.Builder{}
+	for _, r := range s {
+		m, ok := table[r]
+		if !ok {
+			return "", false
+		}
+		sb.WriteRune(m)
+	}
+	return sb.String(), true
+}
+
+func textOf(elems []*math.EG_OMathElements) string {
+	sb := &strings.Builder{}
+	writeText(sb, elems)
+	return sb.String()
+}
+
+// scriptBase returns the text of the base of a script, grouped unless it is a
+// function name.
+func scriptBase(elems []*math.EG_OMathElements) string {
+	if isFunctionName(choices(elems)) {
+		return textOf(elems)
+	}
+	return grouped(textOf(elems))
+}
+
+// grouped returns s wrapped in parentheses unless it is a single symbol or
+// already parenthesized.
+func grouped(s string) string {
+	if utf8.RuneCountInString(s) <= 1 || isNumber(s) || isParenthesized(s) {
+		return s
+	}
+	return "(" + s + ")"
+}
+
+func isParenthesized(s string) bool {
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return false
+	}
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i != len(s)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != '.' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func writeScript(sb *strings.Builder, s string, table map[rune]rune, marker string) {
+	if s == "" {
+		return
+	}
+	if m, ok := mapScript(s, table); ok {
+		sb.WriteString(m)
+		return
+	}
+	sb.WriteString(marker + grouped(s))
+}
+
+// writeText writes the linear plain text form of elems to sb.
+func writeText(sb *strings.Builder, elems []*math.EG_OMathElements) {
+	for _, c := range choices(elems) {
+		switch {
+		case c.R != nil:
+			sb.WriteString(runText(c.R))
+		case c.F != nil:
+			sb.WriteString(grouped(textOf(argElements(c.F.Num))))
+			sb.WriteString("/")
+			sb.WriteString(grouped(textOf(argElements(c.F.Den))))
+		case c.Rad != nil:
+			deg := ""
+			if c.Rad.RadPr == nil || !isOn(c.Rad.RadPr.DegHide) {
+				deg = textOf(argElements(c.Rad.Deg))
+			}
+			switch deg {
+			case "", "2":
+				sb.WriteString("√")
+			case "3":
+				sb.WriteString("∛")
+			case "4":
+				sb.WriteString("∜")
+			default:
+				writeScript(sb, deg, superscripts, "")
+				sb.WriteString("√")
+			}
+			sb.WriteString(grouped(textOf(argElements(c.Rad.E))))
+		case c.SSup != nil:
+			sb.WriteString(scriptBase(argElements(c.SSup.E)))
+			writeScript(sb, textOf(argElements(c.SSup.Sup)), superscripts, "^")
+		case c.SSub != nil:
+			sb.WriteString(scriptBase(argElements(c.SSub.E)))
+			writeScript(sb, textOf(argElements(c.SSub.Sub)), subscripts, "_")
+		case c.SSubSup != nil:
+			sb.WriteString(scriptBase(argElements(c.SSubSup.E)))
+			writeScript(sb, textOf(argElements(c.SSubSup.Sub)), subscripts, "_")
+			writeScript(sb, textOf(argElements(c.SSubSup.Sup)), superscripts, "^")
+		case c.SPre != nil:
+			writeScript(sb, textOf(argElements(c.SPre.Sub)), subscripts, "_")
+			writeScript(sb, textOf(argElements(c.SPre.Sup)), superscripts, "^")
+			sb.WriteString(grouped(textOf(argElements(c.SPre.E))))
+		case c.Nary != nil:
+			chr := OperatorIntegral
+			if c.Nary.NaryPr != nil {
+				chr = charOr(c.Nary.NaryPr.Chr, OperatorIntegral)
+			}
+			sb.WriteString(chr)
+			writeScript(sb, textOf(argElements(c.Nary.Sub)), subscripts, "_")
+			writeScript(sb, textOf(argElements(c.Nary.Sup)), superscripts, "^")
+			sb.WriteString(" ")
+			writeText(sb, argElements(c.Nary.E))
+		case c.D != nil:
+			beg, sep, end := delimiters(c.D)
+			sb.WriteString(beg)
+			for i, e := range c.D.E {
+				if i > 0 {
+					sb.WriteString(sep)
+				}
+				if cs := choices(argElements(e)); len(c.D.E) == 1 && len(cs) == 1 && cs[0].M != nil {
+					writeTextMatrix(sb, cs[0].M)
+					continue
+				}
+				writeText(sb, argElements(e))
+			}
+			sb.WriteString(end)
+		case c.M != nil:
+			sb.WriteString("[")
+			writeTextMatrix(sb, c.M)
+			sb.WriteString("]")
+		case c.Acc != nil:
+			chr := AccentHat
+			if c.Acc.AccPr != nil {
+				chr = charOr(c.Acc.AccPr.Chr, AccentHat)
+			}
+			sb.WriteString(textOf(argElements(c.Acc.E)) + chr)
+		case c.Bar != nil:
+			for _, r := range textOf(argElements(c.Bar.E)) {
+				sb.WriteRune(r)
+				if c.Bar.BarPr != nil && c.Bar.BarPr.Pos != nil && c.Bar.BarPr.Pos.ValAttr == math.ST_TopBotTop {
+					sb.WriteString("̅")
+				} else {
+					sb.WriteString("̲")
+				}
+			}
+		case c.Func != nil:
+			writeText(sb, argElements(c.Func.FName))
+			arg := textOf(argElements(c.Func.E))
+			if arg != "" && !strings.HasPrefix(arg, "(") {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(arg)
+		case c.LimLow != nil:
+			writeText(sb, argElements(c.LimLow.E))
+			writeScript(sb, textOf(argElements(c.LimLow.Lim)), subscripts, "_")
+		case c.LimUpp != nil:
+			writeText(sb, argElements(c.LimUpp.E))
+			writeScript(sb, textOf(argElements(c.LimUpp.Lim)), superscripts, "^")
+		case c.GroupChr != nil:
+			writeText(sb, argElements(c.GroupChr.E))
+		case c.EqArr != nil:
+			for i, e := range c.EqArr.E {
+				if i > 0 {
+					sb.WriteString("; ")
+				}
+				sb.WriteString(strings.ReplaceAll(textOf(argElements(e)), "&", " "))
+			}
+		case c.Box != nil:
+			writeText(sb, argElements(c.Box.E))
+		case c.BorderBox != nil:
+			writeText(sb, argElements(c.BorderBox.E))
+		case c.Phant != nil:
+			writeText(sb, argElements(c.Phant.E))
+		}
+	}
+}
+
+func writeTextMatrix(sb *strings.Builder, m *math.CT_M) {
+	for i, r := range m.Mr {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		for j, e := range r.E {
+			if j > 0 {
+				sb.WriteString(" ")
+			}
+			writeText(sb, argElements(e))
+		}
+	}
+}
@@ -0,0 +1,687 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package math
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/math"
+)
+
+// ParseLaTeX converts a LaTeX math expression, without the surrounding $
+// signs, to an equation. Fractions, radicals, scripts, large operators,
+// matrix environments, \left/\right delimiters, accents, function names,
+// Greek letters and common symbols are supported.
+func ParseLaTeX(s string) (*Equation, error) {
+	p := &latexParser{src: strings.TrimSpace(s)}
+	elems, err := p.parseSequence(func(t latexToken) bool { return false })
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.peek().text, p.pos)
+	}
+	return New(elems...), nil
+}
+
+type latexTokenKind byte
+
+const (
+	tokEOF latexTokenKind = iota
+	tokChar
+	tokCommand
+	tokOpen
+	tokClose
+	tokSup
+	tokSub
+	tokAmp
+	tokNewline
+)
+
+type latexToken struct {
+	kind latexTokenKind
+	text string
+}
+
+type latexParser struct {
+	src string
+	pos int
+}
+
+func (p *latexParser) eof() bool {
+	p.skipSpace()
+	return p.pos >= len(p.src)
+}
+
+func (p *latexParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t' || p.src[p.pos] == '\n' || p.src[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+// scan returns the next token and its length without consuming it.
+func (p *latexParser) scan() (latexToken, int) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return latexToken{kind: tokEOF}, 0
+	}
+	c := p.src[p.pos]
+	switch c {
+	case '{':
+		return latexToken{tokOpen, "{"}, 1
+	case '}':
+		return latexToken{tokClose, "}"}, 1
+	case '^':
+		return latexToken{tokSup, "^"}, 1
+	case '_':
+		return latexToken{tokSub, "_"}, 1
+	case '&':
+		return latexToken{tokAmp, "&"}, 1
+	case '\\':
+		if p.pos+1 >= len(p.src) {
+			return latexToken{tokChar, "\\"}, 1
+		}
+		if p.src[p.pos+1] == '\\' {
+			return latexToken{tokNewline, "\\\\"}, 2
+		}
+		end := p.pos + 1
+		for end < len(p.src) && isASCIILetter(p.src[end]) {
+			end++
+		}
+		if end == p.pos+1 {
+			_, n := utf8.DecodeRuneInString(p.src[end:])
+			end += n
+		}
+		return latexToken{tokCommand, p.src[p.pos+1 : end]}, end - p.pos
+	}
+	if c >= '0' && c <= '9' {
+		end := p.pos
+		for end < len(p.src) && (p.src[end] >= '0' && p.src[end] <= '9' || p.src[end] == '.') {
+			end++
+		}
+		return latexToken{tokChar, p.src[p.pos:end]}, end - p.pos
+	}
+	r, n := utf8.DecodeRuneInString(p.src[p.pos:])
+	if r == '-' {
+		return latexToken{tokChar, "−"}, n
+	}
+	if r == '\'' {
+		return latexToken{tokChar, "′"}, n
+	}
+	return latexToken{tokChar, string(r)}, n
+}
+
+func (p *latexParser) peek() latexToken {
+	t, _ := p.scan()
+	return t
+}
+
+func (p *latexParser) next() latexToken {
+	t, n := p.scan()
+	p.pos += n
+	return t
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+var errUnbalanced = errors.New("unbalanced braces")
+
+// parseSequence parses elements until stop reports true for the next token or
+// the input ends.
+func (p *latexParser) parseSequence(stop func(t latexToken) bool) ([]Element, error) {
+	ret := []Element{}
+	for {
+		t := p.peek()
+		if t.kind == tokEOF || stop(t) {
+			return mergeRuns(ret), nil
+		}
+		if t.kind == tokClose {
+			return nil, errUnbalanced
+		}
+		if t.kind == tokCommand && latexNary[t.text] != "" {
+			p.next()
+			el, err := p.parseNary(latexNary[t.text], stop)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, el)
+			continue
+		}
+		el, err := p.parseScripted()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, el...)
+	}
+}
+
+// parseScripted parses an atom together with any sub- and superscripts.
+func (p *latexParser) parseScripted() ([]Element, error) {
+	t := p.peek()
+	if t.kind == tokCommand && (latexLimits[t.text] || latexFunctions[t.text]) {
+		p.next()
+		return p.parseFunction(t.text)
+	}
+	base, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	sub, sup, err := p.parseScripts()
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil && sup == nil {
+		return base, nil
+	}
+	// scripts only apply to the last character of a run of text
+	var prefix []Element
+	if len(base) == 0 {
+		base = []Element{Text("")}
+	}
+	prefix, last := base[:len(base)-1], base[len(base)-1]
+	var el Element
+	switch {
+	case sub != nil && sup != nil:
+		el = SubSup(last, *sub, *sup)
+	case sub != nil:
+		el = Sub(last, *sub)
+	default:
+		el = Sup(last, *sup)
+	}
+	return append(append([]Element{}, prefix...), el), nil
+}
+
+func (p *latexParser) parseScripts() (sub, sup *Element, err error) {
+	for {
+		t := p.peek()
+		if t.kind != tokSub && t.kind != tokSup {
+			return sub, sup, nil
+		}
+		p.next()
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, nil, err
+		}
+		if t.kind == tokSub {
+			if sub != nil {
+				return nil, nil, errors.New("double subscript")
+			}
+			sub = &arg
+		} else {
+			if sup != nil {
+				return nil, nil, errors.New("double superscript")
+			}
+			sup = &arg
+		}
+	}
+}
+
+// parseArgument parses a braced group or a single token. As in LaTeX, an
+// argument that isn't braced is a single digit of a number, e.g. \frac12.
+func (p *latexParser) parseArgument() (Element, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokEOF:
+		return Element{}, errors.New("missing argument")
+	case tokOpen:
+		return p.parseGroup()
+	}
+	if t.kind == tokChar && len(t.text) > 1 && isNumber(t.text) {
+		p.pos++
+		return Text(t.text[:1]), nil
+	}
+	el, err := p.parseAtom()
+	if err != nil {
+		return Element{}, err
+	}
+	return Row(el...), nil
+}
+
+func (p *latexParser) parseGroup() (Element, error) {
+	if t := p.next(); t.kind != tokOpen {
+		return Element{}, fmt.Errorf("expected { at offset %d", p.pos)
+	}
+	elems, err := p.parseSequence(func(t latexToken) bool { return t.kind == tokClose })
+	if err != nil {
+		return Element{}, err
+	}
+	if t := p.next(); t.kind != tokClose {
+		return Element{}, errUnbalanced
+	}
+	return Row(elems...), nil
+}
+
+// parseRawGroup returns the literal text of a braced group.
+func (p *latexParser) parseRawGroup() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != '{' {
+		return "", fmt.Errorf("expected { at offset %d", p.pos)
+	}
+	depth := 0
+	for i := p.pos; i < len(p.src); i++ {
+		switch p.src[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				s := p.src[p.pos+1 : i]
+				p.pos = i + 1
+				return s, nil
+			}
+		}
+	}
+	return "", errUnbalanced
+}
+
+func (p *latexParser) parseAtom() ([]Element, error) {
+	t := p.next()
+	switch t.kind {
+	case tokChar:
+		return []Element{Text(t.text)}, nil
+	case tokOpen:
+		p.pos--
+		g, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return []Element{g}, nil
+	case tokCommand:
+		return p.parseCommand(t.text)
+	case tokSub, tokSup:
+		// script without a base, e.g. ^2 at the start of a group
+		p.pos--
+		return []Element{}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.text, p.pos)
+}
+
+func (p *latexParser) parseCommand(cmd string) ([]Element, error) {
+	if sym, ok := latexSymbols[cmd]; ok {
+		return []Element{Text(sym)}, nil
+	}
+	if acc, ok := latexAccents[cmd]; ok {
+		a, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		return []Element{Accent(acc, a)}, nil
+	}
+	switch cmd {
+	case "frac", "dfrac", "tfrac", "cfrac", "binom":
+		num, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		den, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		if cmd == "binom" {
+			return []Element{Parentheses(FractionOfType(num, den, FractionNoBar))}, nil
+		}
+		return []Element{Fraction(num, den)}, nil
+	case "genfrac":
+		// delimiters, bar thickness and style, of which only a zero
+		// thickness is kept
+		opts := [4]string{}
+		for i := range opts {
+			s, err := p.parseRawGroup()
+			if err != nil {
+				return nil, err
+			}
+			opts[i] = strings.TrimSpace(s)
+		}
+		num, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		den, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		typ := fractionDefault
+		if strings.TrimRight(opts[2], "ptemx") == "0" {
+			typ = FractionNoBar
+		}
+		f := FractionOfType(num, den, typ)
+		if opts[0] != "" || opts[1] != "" {
+			f = Delimited(opts[0], opts[1], f)
+		}
+		return []Element{f}, nil
+	case "sqrt":
+		p.skipSpace()
+		if p.pos < len(p.src) && p.src[p.pos] == '[' {
+			p.pos++
+			deg, err := p.parseSequence(func(t latexToken) bool { return t.kind == tokChar && t.text == "]" })
+			if err != nil {
+				return nil, err
+			}
+			p.next()
+			e, err := p.parseArgument()
+			if err != nil {
+				return nil, err
+			}
+			return []Element{Root(Row(deg...), e)}, nil
+		}
+		e, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		return []Element{Sqrt(e)}, nil
+	case "overline":
+		e, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		return []Element{Overline(e)}, nil
+	case "underline":
+		e, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		return []Element{Underline(e)}, nil
+	case "text", "textrm", "mathrm", "textnormal", "operatorname", "mbox":
+		s, err := p.parseRawGroup()
+		if err != nil {
+			return nil, err
+		}
+		if cmd == "operatorname" {
+			return p.parseFunction(s)
+		}
+		return []Element{NormalText(s)}, nil
+	case "mathit", "mathbf", "boldsymbol", "mathbb", "mathcal", "mathsf", "mathtt", "displaystyle", "textstyle":
+		if cmd == "displaystyle" || cmd == "textstyle" {
+			return []Element{}, nil
+		}
+		e, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		switch cmd {
+		case "mathbf":
+			styleLetters(e.x, letterBold)
+		case "boldsymbol":
+			styleLetters(e.x, letterBoldItalic)
+		}
+		return []Element{e}, nil
+	case "left":
+		return p.parseLeftRight()
+	case "begin":
+		return p.parseEnvironment()
+	}
+	return nil, fmt.Errorf("unsupported command \\%s", cmd)
+}
+
+// parseFunction parses a function name such as \sin or \lim together with its
+// argument.
+func (p *latexParser) parseFunction(name string) ([]Element, error) {
+	sub, sup, err := p.parseScripts()
+	if err != nil {
+		return nil, err
+	}
+	fname := NormalText(name)
+	switch {
+	case sub != nil && latexLimits[name]:
+		fname = LowerLimit(fname, *sub)
+		if sup != nil {
+			fname = UpperLimit(fname, *sup)
+		}
+	case sub != nil && sup != nil:
+		fname = SubSup(fname, *sub, *sup)
+	case sub != nil:
+		fname = Sub(fname, *sub)
+	case sup != nil:
+		fname = Sup(fname, *sup)
+	}
+	switch p.peek().kind {
+	case tokEOF, tokClose, tokAmp, tokNewline:
+		return []Element{functionOf(fname, Element{})}, nil
+	}
+	arg, err := p.parseScripted()
+	if err != nil {
+		return nil, err
+	}
+	return []Element{functionOf(fname, Row(arg...))}, nil
+}
+
+// parseNary parses the limits and operand of a large operator. The operand
+// extends up to the next relation or additive operator.
+func (p *latexParser) parseNary(op string, stop func(t latexToken) bool) (Element, error) {
+	sub, sup, err := p.parseScripts()
+	if err != nil {
+		return Element{}, err
+	}
+	lower, upper := Element{}, Element{}
+	if sub != nil {
+		lower = *sub
+	}
+	if sup != nil {
+		upper = *sup
+	}
+	operand, err := p.parseSequence(func(t latexToken) bool {
+		if stop(t) || t.kind == tokAmp || t.kind == tokNewline {
+			return true
+		}
+		if t.kind == tokChar {
+			switch t.text {
+			case "+", "−", "=", "<", ">", ",", ";":
+				return true
+			}
+		}
+		if t.kind == tokCommand {
+			switch t.text {
+			case "right", "end", "le", "leq", "ge", "geq", "ne", "neq", "approx", "equiv", "pm", "mp":
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return Element{}, err
+	}
+	return Nary(op, lower, upper, Row(operand...), op == OperatorIntegral || op == OperatorDoubleIntegral ||
+		op == OperatorTripleIntegral || op == OperatorContourIntegral), nil
+}
+
+// parseDelimiter reads the delimiter following \left or \right.
+func (p *latexParser) parseDelimiter() (string, error) {
+	t := p.next()
+	switch t.kind {
+	case tokChar:
+		if t.text == "." {
+			return "", nil
+		}
+		return t.text, nil
+	case tokCommand:
+		if sym, ok := latexSymbols[t.text]; ok {
+			return sym, nil
+		}
+	}
+	return "", fmt.Errorf("invalid delimiter %q", t.text)
+}
+
+func (p *latexParser) parseLeftRight() ([]Element, error) {
+	beg, err := p.parseDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	var parts []Element
+	var cur []Element
+	for {
+		seq, err := p.parseSequence(func(t latexToken) bool {
+			return t.kind == tokCommand && (t.text == "right" || t.text == "middle")
+		})
+		if err != nil {
+			return nil, err
+		}
+		cur = append(cur, seq...)
+		t := p.next()
+		if t.kind != tokCommand {
+			return nil, errors.New("missing \\right")
+		}
+		if t.text == "middle" {
+			if _, err := p.parseDelimiter(); err != nil {
+				return nil, err
+			}
+			parts = append(parts, Row(cur...))
+			cur = nil
+			continue
+		}
+		end, err := p.parseDelimiter()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, Row(cur...))
+		return []Element{Delimited(beg, end, parts...)}, nil
+	}
+}
+
+func (p *latexParser) parseEnvironment() ([]Element, error) {
+	name, err := p.parseRawGroup()
+	if err != nil {
+		return nil, err
+	}
+	if name == "array" {
+		// column specification is not needed
+		if _, err := p.parseRawGroup(); err != nil {
+			return nil, err
+		}
+	}
+	var rows [][]Element
+	row := []Element{}
+	for {
+		cell, err := p.parseSequence(func(t latexToken) bool {
+			return t.kind == tokAmp || t.kind == tokNewline || t.kind == tokCommand && t.text == "end"
+		})
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, Row(cell...))
+		t := p.next()
+		switch {
+		case t.kind == tokAmp:
+			continue
+		case t.kind == tokNewline:
+			rows = append(rows, row)
+			row = []Element{}
+			continue
+		case t.kind == tokCommand && t.text == "end":
+			endName, err := p.parseRawGroup()
+			if err != nil {
+				return nil, err
+			}
+			if endName != name {
+				return nil, fmt.Errorf("\\begin{%s} ended by \\end{%s}", name, endName)
+			}
+			if len(row) > 1 || len(row[0].x) > 0 {
+				rows = append(rows, row)
+			}
+		default:
+			return nil, fmt.Errorf("unterminated environment %s", name)
+		}
+		break
+	}
+	switch name {
+	case "aligned", "align", "align*", "gather", "gather*", "split", "eqnarray", "cases":
+		// cells of an equation array are separated by the & alignment mark
+		lines := []Element{}
+		for _, r := range rows {
+			parts := []Element{}
+			for i, c := range r {
+				if i > 0 {
+					parts = append(parts, Text("&"))
+				}
+				parts = append(parts, c)
+			}
+			lines = append(lines, Row(mergeRuns(parts)...))
+		}
+		if name == "cases" {
+			return []Element{Delimited("{", "", EquationArray(lines...))}, nil
+		}
+		return []Element{EquationArray(lines...)}, nil
+	}
+	m := Matrix(rows)
+	if d, ok := latexMatrices[name]; ok && (d[0] != "" || d[1] != "") {
+		return []Element{Delimited(d[0], d[1], m)}, nil
+	}
+	return []Element{m}, nil
+}
+
+// functionOf returns a function application with an arbitrary name element,
+// e.g. a name with limits.
+func functionOf(name, e Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		f := math.NewCT_Func()
+		f.FName = arg(name)
+		f.E = arg(e)
+		c.Func = f
+	})
+}
+
+// mergeRuns joins adjacent plain runs with the same style into a single run,
+// which is how Word stores typed equations.
+func mergeRuns(elems []Element) []Element {
+	ret := []Element{}
+	for _, e := range elems {
+		if len(ret) > 0 && canMerge(ret[len(ret)-1], e) {
+			prev := ret[len(ret)-1].x[0].OMathElementsChoice.OMathMathElementsChoice.R
+			cur := e.x[0].OMathElementsChoice.OMathMathElementsChoice.R
+			prev.RChoice[0].T.Content += cur.RChoice[0].T.Content
+			continue
+		}
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+func canMerge(a, b Element) bool {
+	ra, rb := singleRun(a), singleRun(b)
+	if ra == nil || rb == nil {
+		return false
+	}
+	return (ra.RPr == nil) == (rb.RPr == nil) && len(ra.RChoice) == 1 && len(rb.RChoice) == 1 &&
+		ra.RChoice[0].T != nil && rb.RChoice[0].T != nil
+}
+
+// singleRun returns the run if the element consists of exactly one run.
+func singleRun(e Element) *math.CT_R {
+	if len(e.x) != 1 || e.x[0].OMathElementsChoice == nil || e.x[0].OMathElementsChoice.OMathMathElementsChoice == nil {
+		return nil
+	}
+	return e.x[0].OMathElementsChoice.OMathMathElementsChoice.R
+}
+
+// styleLetters replaces the letters and digits of the math runs in elems,
+// including nested ones, by the mathematical alphanumeric symbols of style s.
+// Normal text runs are left unchanged.
+func styleLetters(elems []*math.EG_OMathElements, s letterStyle) {
+	for _, c := range choices(elems) {
+		if c.R == nil {
+			for _, a := range choiceArgs(c) {
+				styleLetters(argElements(a), s)
+			}
+			continue
+		}
+		if c.R.RPr != nil && isOn(c.R.RPr.Nor) {
+			continue
+		}
+		for _, rc := range c.R.RChoice {
+			if rc.T != nil {
+				rc.T.Content = strings.Map(func(r rune) rune { return styledLetter(r, s) }, rc.T.Content)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package math
+
+import "testing"
+
+var latexTests = []struct {
+	src, latex, text string
+}{
+	{`x^2+y_1`, `x^{2}+y_{1}`, "x²+y₁"},
+	{`a^b_c`, `a_{c}^{b}`, "a_c^b"},
+	{`e^{i\pi}`, `e^{i\pi }`, "e^(iπ)"},
+	{`\frac{a+b}{2}`, `\frac{a+b}{2}`, "(a+b)/2"},
+	{`\frac12`, `\frac{1}{2}`, "1/2"},
+	{`x = \frac{-b \pm \sqrt{b^2-4ac}}{2a}`, `x=\frac{-b\pm \sqrt{b^{2}-4ac}}{2a}`, "x=(−b±√(b²−4ac))/(2a)"},
+	{`\binom{n}{k}`, `\left(\genfrac{}{}{0pt}{}{n}{k}\right)`, "(n/k)"},
+	{`\sqrt{x+1}`, `\sqrt{x+1}`, "√(x+1)"},
+	{`\sqrt[3]{x}`, `\sqrt[3]{x}`, "∛x"},
+	{`\sum_{i=1}^{n} i^2`, `\sum_{i=1}^{n} i^{2}`, "∑ᵢ₌₁ⁿ i²"},
+	{`\prod_{k=1}^{n} k`, `\prod_{k=1}^{n} k`, "∏ₖ₌₁ⁿ k"},
+	{`\int_0^1 x\,dx`, `\int_{0}^{1} x\ dx`, "∫₀¹ x dx"},
+	{`\alpha+\beta`, `\alpha +\beta`, "α+β"},
+	{`\left( x \right)`, `\left(x\right)`, "(x)"},
+	{`\left[ a, b \right)`, `\left[a,b\right)`, "[a,b)"},
+	{`\begin{pmatrix} a & b \\ c & d \end{pmatrix}`, `\begin{pmatrix}a & b \\ c & d\end{pmatrix}`, "(a b; c d)"},
+	{`\begin{bmatrix} 1 \\ 2 \end{bmatrix}`, `\begin{bmatrix}1 \\ 2\end{bmatrix}`, "[1; 2]"},
+	{`\begin{cases} 1 & x>0 \\ 0 & x\le 0 \end{cases}`, `\begin{cases}1&x>0 \\ 0&x\le 0\end{cases}`, "{1 x>0; 0 x≤0"},
+	{`\sin x`, `\sin x`, "sin x"},
+	{`\log_2 n`, `\log_{2} n`, "log₂ n"},
+	{`\lim_{n\to\infty} a_n`, `\lim_{n\to \infty } a_{n}`, "lim_(n→∞) aₙ"},
+	{`\hat{a}`, `\hat{a}`, "a\u0302"},
+	{`\vec{v}`, `\vec{v}`, "v⃗"},
+	{`\overline{z}`, `\overline{z}`, "z̅"},
+	{`\mathbf{v}`, `\mathbf{v}`, "𝐯"},
+	{`\text{if } x`, `\text{if }x`, "if x"},
+}
+
+func TestParseLaTeX(t *testing.T) {
+	for _, tc := range latexTests {
+		eq, err := ParseLaTeX(tc.src)
+		if err != nil {
+			t.Errorf("ParseLaTeX(%q): %s", tc.src, err)
+			continue
+		}
+		if got := eq.LaTeX(); got != tc.latex {
+			t.Errorf("ParseLaTeX(%q).LaTeX() = %q, want %q", tc.src, got, tc.latex)
+		}
+		if got := eq.Text(); got != tc.text {
+			t.Errorf("ParseLaTeX(%q).Text() = %q, want %q", tc.src, got, tc.text)
+		}
+		// the exported LaTeX parses to the same equation
+		again, err := ParseLaTeX(tc.latex)
+		if err != nil {
+			t.Errorf("ParseLaTeX(%q): %s", tc.latex, err)
+		} else if got := again.LaTeX(); got != tc.latex {
+			t.Errorf("ParseLaTeX(%q).LaTeX() = %q", tc.latex, got)
+		}
+	}
+}
+
+func TestParseLaTeXErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src, err string
+	}{
+		{`{a`, "unbalanced braces"},
+		{`a}`, "unbalanced braces"},
+		{`x^`, "missing argument"},
+		{`\frac{a}`, "missing argument"},
+		{`\sqrt[3`, "missing argument"},
+		{`\foo`, `unsupported command \foo`},
+		{`\left( x`, `missing \right`},
+		{`x_1_2`, "double subscript"},
+		{`\begin{matrix} a \end{pmatrix}`, `\begin{matrix} ended by \end{pmatrix}`},
+	} {
+		_, err := ParseLaTeX(tc.src)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("ParseLaTeX(%q) error = %v, want %s", tc.src, err, tc.err)
+		}
+	}
+}
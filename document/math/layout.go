@@ -0,0 +1,184 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package math
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/math"
+)
+
+// Segment is a piece of equation text together with the formatting used to
+// display it.
+type Segment struct {
+	Text        string
+	Bold        bool
+	Italic      bool
+	Superscript bool
+	Subscript   bool
+}
+
+// Segments returns the equation as a sequence of formatted text segments for
+// renderers that lay out text runs but have no math layout support. It is an
+// approximation of the layout Word uses: sub- and superscripts, including the
+// limits of n-ary operators, become raised and lowered segments and letters
+// are italic, or bold for \mathbf, as in Word. Fractions, radicals and all
+// other two dimensional structures, as well as scripts of scripts, are written
+// in the linear format of Text, e.g. "(a+b)/2" and "√(x+1)".
+func (e *Equation) Segments() []Segment {
+	w := &segmentWriter{}
+	w.elements(e.x.EG_OMathElements)
+	return w.segs
+}
+
+func (s Segment) sameFormat(o Segment) bool {
+	return s.Bold == o.Bold && s.Italic == o.Italic && s.Superscript == o.Superscript && s.Subscript == o.Subscript
+}
+
+type segmentWriter struct {
+	segs []Segment
+}
+
+// add appends text with the given formatting, merging it with the previous
+// segment if the formatting matches.
+func (w *segmentWriter) add(text string, normal, superscript, subscript bool) {
+	for _, r := range text {
+		plain, style := plainLetter(r)
+		seg := Segment{
+			Text:        string(plain),
+			Bold:        style == letterBold || style == letterBoldItalic,
+			Italic:      style == letterItalic || style == letterBoldItalic || !normal && style == letterPlain && unicode.IsLetter(plain),
+			Superscript: superscript,
+			Subscript:   subscript,
+		}
+		if n := len(w.segs); n > 0 && w.segs[n-1].sameFormat(seg) {
+			w.segs[n-1].Text += seg.Text
+			continue
+		}
+		w.segs = append(w.segs, seg)
+	}
+}
+
+func (w *segmentWriter) elements(elems []*math.EG_OMathElements) {
+	for _, c := range choices(elems) {
+		switch {
+		case c.R != nil:
+			w.add(runText(c.R), c.R.RPr != nil && isOn(c.R.RPr.Nor), false, false)
+		case c.F != nil:
+			w.group(argElements(c.F.Num))
+			w.add("/", true, false, false)
+			w.group(argElements(c.F.Den))
+		case c.Rad != nil:
+			deg := ""
+			if c.Rad.RadPr == nil || !isOn(c.Rad.RadPr.DegHide) {
+				deg = textOf(argElements(c.Rad.Deg))
+			}
+			switch deg {
+			case "", "2":
+				w.add("√", true, false, false)
+			case "3":
+				w.add("∛", true, false, false)
+			case "4":
+				w.add("∜", true, false, false)
+			default:
+				w.add(deg, false, true, false)
+				w.add("√", true, false, false)
+			}
+			w.group(argElements(c.Rad.E))
+		case c.SSup != nil:
+			w.group(argElements(c.SSup.E))
+			w.script(argElements(c.SSup.Sup), true)
+		case c.SSub != nil:
+			w.group(argElements(c.SSub.E))
+			w.script(argElements(c.SSub.Sub), false)
+		case c.SSubSup != nil:
+			w.group(argElements(c.SSubSup.E))
+			w.script(argElements(c.SSubSup.Sub), false)
+			w.script(argElements(c.SSubSup.Sup), true)
+		case c.SPre != nil:
+			w.script(argElements(c.SPre.Sub), false)
+			w.script(argElements(c.SPre.Sup), true)
+			w.group(argElements(c.SPre.E))
+		case c.Nary != nil:
+			chr := OperatorIntegral
+			if c.Nary.NaryPr != nil {
+				chr = charOr(c.Nary.NaryPr.Chr, OperatorIntegral)
+			}
+			w.add(chr, true, false, false)
+			w.script(argElements(c.Nary.Sub), false)
+			w.script(argElements(c.Nary.Sup), true)
+			w.add(" ", true, false, false)
+			w.elements(argElements(c.Nary.E))
+		case c.D != nil && !isMatrixDelimiter(c.D):
+			beg, sep, end := delimiters(c.D)
+			w.add(beg, true, false, false)
+			for i, e := range c.D.E {
+				if i > 0 {
+					w.add(sep, true, false, false)
+				}
+				w.elements(argElements(e))
+			}
+			w.add(end, true, false, false)
+		case c.Func != nil:
+			w.elements(argElements(c.Func.FName))
+			if arg := textOf(argElements(c.Func.E)); arg != "" && !strings.HasPrefix(arg, "(") {
+				w.add(" ", true, false, false)
+			}
+			w.elements(argElements(c.Func.E))
+		case c.LimLow != nil:
+			w.elements(argElements(c.LimLow.E))
+			w.script(argElements(c.LimLow.Lim), false)
+		case c.LimUpp != nil:
+			w.elements(argElements(c.LimUpp.E))
+			w.script(argElements(c.LimUpp.Lim), true)
+		case c.GroupChr != nil:
+			w.elements(argElements(c.GroupChr.E))
+		case c.Box != nil:
+			w.elements(argElements(c.Box.E))
+		case c.BorderBox != nil:
+			w.elements(argElements(c.BorderBox.E))
+		case c.Phant != nil:
+			w.elements(argElements(c.Phant.E))
+		default:
+			// matrices, equation arrays, accents and bars
+			sb := &strings.Builder{}
+			writeText(sb, []*math.EG_OMathElements{{OMathElementsChoice: &math.EG_OMathElementsChoice{OMathMathElementsChoice: c}}})
+			w.add(sb.String(), false, false, false)
+		}
+	}
+}
+
+// group writes elems, wrapped in parentheses where the linear format needs
+// them.
+func (w *segmentWriter) group(elems []*math.EG_OMathElements) {
+	if s := textOf(elems); grouped(s) != s {
+		w.add("(", true, false, false)
+		w.elements(elems)
+		w.add(")", true, false, false)
+		return
+	}
+	w.elements(elems)
+}
+
+// script writes elems as a single raised or lowered segment.
+func (w *segmentWriter) script(elems []*math.EG_OMathElements, superscript bool) {
+	w.add(textOf(elems), false, superscript, !superscript)
+}
+
+// isMatrixDelimiter reports whether d encloses a single matrix, for which the
+// linear format uses the delimiters in place of the matrix brackets.
+func isMatrixDelimiter(d *math.CT_D) bool {
+	if len(d.E) != 1 {
+		return false
+	}
+	cs := choices(argElements(d.E[0]))
+	return len(cs) == 1 && cs[0].M != nil
+}
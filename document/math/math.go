@@ -0,0 +1,455 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+/*
+Package math provides building, LaTeX conversion and plain text rendering of
+Office Math (OMML) equations for use in Word documents.
+
+Example:
+
+	eq := math.New(
+		math.Text("x="),
+		math.Fraction(
+			math.Row(math.Text("−b±"), math.Sqrt(math.Row(math.Sup(math.Text("b"), math.Text("2")), math.Text("−4ac")))),
+			math.Text("2a")),
+	)
+	doc.AddParagraph().AddDisplayEquation(eq.X())
+
+	eq2, err := math.ParseLaTeX(`\sum_{i=1}^{n} i^2`)
+*/
+package math
+
+import (
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/math"
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/sharedTypes"
+)
+
+// Element is a piece of an equation, e.g. a run of text, a fraction or a
+// matrix. Elements are combined using the constructor functions of this
+// package.
+type Element struct {
+	x []*math.EG_OMathElements
+}
+
+// X returns the inner wrapped XML types.
+func (e Element) X() []*math.EG_OMathElements { return e.x }
+
+// Equation is an Office Math equation (m:oMath).
+type Equation struct {
+	x *math.CT_OMath
+}
+
+// New constructs a new equation from the given elements.
+func New(elems ...Element) *Equation {
+	eq := &Equation{math.NewCT_OMath()}
+	eq.Append(elems...)
+	return eq
+}
+
+// FromX wraps an existing Office Math equation, e.g. one read from a document.
+func FromX(x *math.CT_OMath) *Equation { return &Equation{x} }
+
+// X returns the inner wrapped XML type.
+func (e *Equation) X() *math.CT_OMath { return e.x }
+
+// Append adds elements to the end of the equation.
+func (e *Equation) Append(elems ...Element) {
+	for _, el := range elems {
+		e.x.EG_OMathElements = append(e.x.EG_OMathElements, el.x...)
+	}
+}
+
+// Elements returns the top level content of the equation as an element.
+func (e *Equation) Elements() Element { return Element{e.x.EG_OMathElements} }
+
+// FractionType is the way a fraction is displayed.
+type FractionType = math.ST_FType
+
+// FractionType constants.
+const (
+	FractionBar     FractionType = math.ST_FTypeBar
+	FractionSkewed  FractionType = math.ST_FTypeSkw
+	FractionLinear  FractionType = math.ST_FTypeLin
+	FractionNoBar   FractionType = math.ST_FTypeNoBar
+	fractionDefault FractionType = math.ST_FTypeUnset
+)
+
+// Common n-ary operators.
+const (
+	OperatorSum             = "∑"
+	OperatorProduct         = "∏"
+	OperatorCoproduct       = "∐"
+	OperatorIntegral        = "∫"
+	OperatorDoubleIntegral  = "∬"
+	OperatorTripleIntegral  = "∭"
+	OperatorContourIntegral = "∮"
+	OperatorUnion           = "⋃"
+	OperatorIntersection    = "⋂"
+)
+
+// Common accent characters.
+const (
+	AccentHat    = "̂"
+	AccentTilde  = "̃"
+	AccentBar    = "̅"
+	AccentDot    = "̇"
+	AccentDDot   = "̈"
+	AccentVector = "⃗"
+)
+
+func choice(fn func(c *math.EG_OMathMathElementsChoice)) Element {
+	el := math.NewEG_OMathElements()
+	fn(el.OMathElementsChoice.OMathMathElementsChoice)
+	return Element{[]*math.EG_OMathElements{el}}
+}
+
+func arg(e Element) *math.CT_OMathArg {
+	a := math.NewCT_OMathArg()
+	a.EG_OMathElements = e.x
+	return a
+}
+
+func onOff(b bool) *math.CT_OnOff {
+	return &math.CT_OnOff{ValAttr: &sharedTypes.ST_OnOff{Bool: &b}}
+}
+
+// Row combines several elements into a single element.
+func Row(elems ...Element) Element {
+	ret := Element{}
+	for _, e := range elems {
+		ret.x = append(ret.x, e.x...)
+	}
+	return ret
+}
+
+// Text returns a math run displaying s in math italic style.
+func Text(s string) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		c.R = newRun(s, false)
+	})
+}
+
+// NormalText returns a math run displaying s as normal, non-math text, e.g.
+// for words or function names.
+func NormalText(s string) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		c.R = newRun(s, true)
+	})
+}
+
+func newRun(s string, normal bool) *math.CT_R {
+	r := math.NewCT_R()
+	t := math.NewCT_Text()
+	t.Content = s
+	if len(s) > 0 && (s[0] == ' ' || s[len(s)-1] == ' ') {
+		preserve := "preserve"
+		t.SpaceAttr = &preserve
+	}
+	r.RChoice = append(r.RChoice, &math.CT_RChoice{T: t})
+	if normal {
+		r.RPr = math.NewCT_RPR()
+		r.RPr.Nor = onOff(true)
+	}
+	return r
+}
+
+// Fraction returns a stacked fraction num/den.
+func Fraction(num, den Element) Element {
+	return FractionOfType(num, den, fractionDefault)
+}
+
+// FractionOfType returns a fraction num/den displayed with the given type.
+func FractionOfType(num, den Element, t FractionType) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		f := math.NewCT_F()
+		if t != fractionDefault {
+			f.FPr = math.NewCT_FPr()
+			f.FPr.Type = &math.CT_FType{ValAttr: t}
+		}
+		f.Num = arg(num)
+		f.Den = arg(den)
+		c.F = f
+	})
+}
+
+// Sqrt returns the square root of e.
+func Sqrt(e Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		r := math.NewCT_Rad()
+		r.RadPr = math.NewCT_RadPr()
+		r.RadPr.DegHide = onOff(true)
+		r.Deg = math.NewCT_OMathArg()
+		r.E = arg(e)
+		c.Rad = r
+	})
+}
+
+// Root returns the radical of e with the given degree.
+func Root(degree, e Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		r := math.NewCT_Rad()
+		r.Deg = arg(degree)
+		r.E = arg(e)
+		c.Rad = r
+	})
+}
+
+// Sup returns base with a superscript.
+func Sup(base, sup Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		s := math.NewCT_SSup()
+		s.E = arg(base)
+		s.Sup = arg(sup)
+		c.SSup = s
+	})
+}
+
+// Sub returns base with a subscript.
+func Sub(base, sub Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		s := math.NewCT_SSub()
+		s.E = arg(base)
+		s.Sub = arg(sub)
+		c.SSub = s
+	})
+}
+
+// SubSup returns base with both a subscript and a superscript.
+func SubSup(base, sub, sup Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		s := math.NewCT_SSubSup()
+		s.E = arg(base)
+		s.Sub = arg(sub)
+		s.Sup = arg(sup)
+		c.SSubSup = s
+	})
+}
+
+// PreSubSup returns base with a subscript and superscript placed before it.
+func PreSubSup(base, sub, sup Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		s := math.NewCT_SPre()
+		s.E = arg(base)
+		s.Sub = arg(sub)
+		s.Sup = arg(sup)
+		c.SPre = s
+	})
+}
+
+// Nary returns an n-ary operator such as OperatorSum or OperatorIntegral
+// applied to e. Empty lower or upper limits are hidden. Limits are placed
+// above and below the operator unless limitsAsScripts is set, in which case
+// they are placed as sub- and superscripts.
+func Nary(operator string, lower, upper, e Element, limitsAsScripts bool) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		n := math.NewCT_Nary()
+		n.NaryPr = math.NewCT_NaryPr()
+		if operator != OperatorIntegral {
+			n.NaryPr.Chr = &math.CT_Char{ValAttr: operator}
+		}
+		n.NaryPr.LimLoc = math.NewCT_LimLoc()
+		if limitsAsScripts {
+			n.NaryPr.LimLoc.ValAttr = math.ST_LimLocSubSup
+		} else {
+			n.NaryPr.LimLoc.ValAttr = math.ST_LimLocUndOvr
+		}
+		if len(lower.x) == 0 {
+			n.NaryPr.SubHide = onOff(true)
+		}
+		if len(upper.x) == 0 {
+			n.NaryPr.SupHide = onOff(true)
+		}
+		n.Sub = arg(lower)
+		n.Sup = arg(upper)
+		n.E = arg(e)
+		c.Nary = n
+	})
+}
+
+// Sum returns a summation of e from lower to upper.
+func Sum(lower, upper, e Element) Element {
+	return Nary(OperatorSum, lower, upper, e, false)
+}
+
+// Product returns a product of e from lower to upper.
+func Product(lower, upper, e Element) Element {
+	return Nary(OperatorProduct, lower, upper, e, false)
+}
+
+// Integral returns the integral of e from lower to upper.
+func Integral(lower, upper, e Element) Element {
+	return Nary(OperatorIntegral, lower, upper, e, true)
+}
+
+// Matrix returns a matrix with the given rows. Use Delimited to surround the
+// matrix with brackets.
+func Matrix(rows [][]Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		m := math.NewCT_M()
+		cols := 0
+		for _, row := range rows {
+			mr := math.NewCT_MR()
+			for _, cell := range row {
+				mr.E = append(mr.E, arg(cell))
+			}
+			if len(row) > cols {
+				cols = len(row)
+			}
+			m.Mr = append(m.Mr, mr)
+		}
+		m.MPr = math.NewCT_MPr()
+		m.MPr.Mcs = math.NewCT_MCS()
+		mc := math.NewCT_MC()
+		mc.McPr = math.NewCT_MCPr()
+		mc.McPr.Count = &math.CT_Integer255{ValAttr: int64(cols)}
+		mc.McPr.McJc = &math.CT_XAlign{ValAttr: sharedTypes.ST_XAlignCenter}
+		m.MPr.Mcs.Mc = append(m.MPr.Mcs.Mc, mc)
+		c.M = m
+	})
+}
+
+// Delimited returns elems surrounded by the begin and end characters and
+// separated by "|". An empty begin or end string hides that delimiter.
+func Delimited(begin, end string, elems ...Element) Element {
+	return DelimitedWithSeparator(begin, "|", end, elems...)
+}
+
+// DelimitedWithSeparator returns elems surrounded by the begin and end
+// characters and separated by sep.
+func DelimitedWithSeparator(begin, sep, end string, elems ...Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		d := math.NewCT_D()
+		d.DPr = math.NewCT_DPr()
+		if begin != "(" {
+			d.DPr.BegChr = &math.CT_Char{ValAttr: begin}
+		}
+		if end != ")" {
+			d.DPr.EndChr = &math.CT_Char{ValAttr: end}
+		}
+		if sep != "|" {
+			d.DPr.SepChr = &math.CT_Char{ValAttr: sep}
+		}
+		for _, e := range elems {
+			d.E = append(d.E, arg(e))
+		}
+		if len(d.E) == 0 {
+			d.E = append(d.E, math.NewCT_OMathArg())
+		}
+		c.D = d
+	})
+}
+
+// Parentheses returns elems surrounded by round brackets.
+func Parentheses(elems ...Element) Element { return Delimited("(", ")", elems...) }
+
+// Accent returns e with an accent character, e.g. AccentHat, placed above it.
+func Accent(accent string, e Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		a := math.NewCT_Acc()
+		if accent != AccentHat {
+			a.AccPr = math.NewCT_AccPr()
+			a.AccPr.Chr = &math.CT_Char{ValAttr: accent}
+		}
+		a.E = arg(e)
+		c.Acc = a
+	})
+}
+
+// Overline returns e with a bar drawn above it.
+func Overline(e Element) Element { return bar(e, math.ST_TopBotTop) }
+
+// Underline returns e with a bar drawn below it.
+func Underline(e Element) Element { return bar(e, math.ST_TopBotBot) }
+
+func bar(e Element, pos math.ST_TopBot) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		b := math.NewCT_Bar()
+		b.BarPr = math.NewCT_BarPr()
+		b.BarPr.Pos = &math.CT_TopBot{ValAttr: pos}
+		b.E = arg(e)
+		c.Bar = b
+	})
+}
+
+// Function returns a function application such as sin x, with the name
+// displayed as normal text.
+func Function(name string, e Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		f := math.NewCT_Func()
+		f.FName = arg(NormalText(name))
+		f.E = arg(e)
+		c.Func = f
+	})
+}
+
+// LowerLimit returns base with a limit placed below it, e.g. lim with n→∞.
+func LowerLimit(base, limit Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		l := math.NewCT_LimLow()
+		l.E = arg(base)
+		l.Lim = arg(limit)
+		c.LimLow = l
+	})
+}
+
+// UpperLimit returns base with a limit placed above it.
+func UpperLimit(base, limit Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		l := math.NewCT_LimUpp()
+		l.E = arg(base)
+		l.Lim = arg(limit)
+		c.LimUpp = l
+	})
+}
+
+// EquationArray returns vertically stacked equations, one per line.
+func EquationArray(lines ...Element) Element {
+	return choice(func(c *math.EG_OMathMathElementsChoice) {
+		a := math.NewCT_EqArr()
+		for _, l := range lines {
+			a.E = append(a.E, arg(l))
+		}
+		c.EqArr = a
+	})
+}
+
+// Paragraph is a display math paragraph (m:oMathPara) containing one or more
+// equations.
+type Paragraph struct {
+	x *math.OMathPara
+}
+
+// ParagraphFromX wraps an existing display math paragraph.
+func ParagraphFromX(x *math.OMathPara) Paragraph { return Paragraph{x} }
+
+// NewParagraph constructs a display math paragraph with the given
+// justification.
+func NewParagraph(jc math.ST_Jc, eqs ...*Equation) Paragraph {
+	p := Paragraph{math.NewOMathPara()}
+	if jc != math.ST_JcUnset {
+		p.x.OMathParaPr = math.NewCT_OMathParaPr()
+		p.x.OMathParaPr.Jc = &math.CT_OMathJc{ValAttr: jc}
+	}
+	for _, eq := range eqs {
+		p.x.OMath = append(p.x.OMath, eq.x)
+	}
+	return p
+}
+
+// X returns the inner wrapped XML type.
+func (p Paragraph) X() *math.OMathPara { return p.x }
+
+// Equations returns the equations of the paragraph.
+func (p Paragraph) Equations() []*Equation {
+	ret := []*Equation{}
+	for _, o := range p.x.OMath {
+		ret = append(ret, &Equation{o})
+	}
+	return ret
+}
@@ -0,0 +1,119 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package math
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/math"
+)
+
+func TestBuilders(t *testing.T) {
+	for _, tc := range []struct {
+		el          Element
+		latex, text string
+	}{
+		{Row(Text("x="), Fraction(Row(Text("−b±"), Sqrt(Row(Sup(Text("b"), Text("2")), Text("−4ac")))), Text("2a"))),
+			`x=\frac{-b\pm \sqrt{b^{2}-4ac}}{2a}`, "x=(−b±√(b²−4ac))/(2a)"},
+		{FractionOfType(Text("a"), Text("b"), FractionLinear), `\frac{a}{b}`, "a/b"},
+		{Root(Text("3"), Text("y")), `\sqrt[3]{y}`, "∛y"},
+		{SubSup(Text("x"), Text("i"), Text("2")), `x_{i}^{2}`, "xᵢ²"},
+		{PreSubSup(Text("C"), Text("n"), Text("k")), `{}_{n}^{k}C`, "ₙ^kC"},
+		{Integral(Text("0"), Text("1"), Text("f")), `\int_{0}^{1} f`, "∫₀¹ f"},
+		{Sum(Element{}, Element{}, Text("a")), `\sum a`, "∑ a"},
+		{Product(Text("k"), Element{}, Text("k")), `\prod_{k} k`, "∏ₖ k"},
+		{Matrix([][]Element{{Text("1"), Text("0")}, {Text("0"), Text("1")}}), `\begin{matrix}1 & 0 \\ 0 & 1\end{matrix}`, "[1 0; 0 1]"},
+		{Parentheses(Matrix([][]Element{{Text("a")}, {Text("b")}})), `\begin{pmatrix}a \\ b\end{pmatrix}`, "(a; b)"},
+		{Delimited("[", "]", Text("a"), Text("b")), `\left[a\middle|b\right]`, "[a|b]"},
+		{Function("sin", Text("x")), `\sin x`, "sin x"},
+		{Function("sgn", Text("x")), `\operatorname{sgn} x`, "sgn x"},
+		{Accent(AccentVector, Text("v")), `\vec{v}`, "v⃗"},
+		{Overline(Text("z")), `\overline{z}`, "z̅"},
+		{Underline(Text("u")), `\underline{u}`, "u̲"},
+		{LowerLimit(NormalText("lim"), Text("n→∞")), `\lim_{n\to \infty }`, "lim_(n→∞)"},
+		{EquationArray(Text("a=1"), Text("b=2")), `\begin{aligned}a=1 \\ b=2\end{aligned}`, "a=1; b=2"},
+		{NormalText("if"), `\text{if}`, "if"},
+	} {
+		if got := tc.el.LaTeX(); got != tc.latex {
+			t.Errorf("LaTeX() = %q, want %q", got, tc.latex)
+		}
+		if got := tc.el.Text(); got != tc.text {
+			t.Errorf("Text() = %q, want %q", got, tc.text)
+		}
+	}
+}
+
+func TestEquationXML(t *testing.T) {
+	for _, tc := range latexTests {
+		eq, err := ParseLaTeX(tc.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		om := math.NewOMath()
+		om.CT_OMath = *eq.X()
+		data, err := xml.Marshal(om)
+		if err != nil {
+			t.Fatalf("marshaling %q: %s", tc.src, err)
+		}
+		read := math.NewOMath()
+		if err := xml.Unmarshal(data, read); err != nil {
+			t.Fatalf("unmarshaling %q: %s", tc.src, err)
+		}
+		if got := FromX(&read.CT_OMath).LaTeX(); got != tc.latex {
+			t.Errorf("read %q = %q, want %q", tc.src, got, tc.latex)
+		}
+	}
+}
+
+func TestParagraphXML(t *testing.T) {
+	p := NewParagraph(math.ST_JcCenter, New(Text("x")), New(Sup(Text("y"), Text("2"))))
+	data, err := xml.Marshal(p.X())
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := math.NewOMathPara()
+	if err := xml.Unmarshal(data, read); err != nil {
+		t.Fatal(err)
+	}
+	if read.OMathParaPr == nil || read.OMathParaPr.Jc == nil || read.OMathParaPr.Jc.ValAttr != math.ST_JcCenter {
+		t.Error("the justification wasn't read")
+	}
+	got := []string{}
+	for _, eq := range ParagraphFromX(read).Equations() {
+		got = append(got, eq.LaTeX())
+	}
+	if want := []string{"x", "y^{2}"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("equations = %q, want %q", got, want)
+	}
+}
+
+func TestSegments(t *testing.T) {
+	eq, err := ParseLaTeX(`\sum_{i=1}^{n} \mathbf{v}_i + \frac{a}{2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Segment{
+		{Text: "∑"},
+		{Text: "i", Italic: true, Subscript: true},
+		{Text: "=1", Subscript: true},
+		{Text: "n", Italic: true, Superscript: true},
+		{Text: " "},
+		{Text: "v", Bold: true},
+		{Text: "i", Italic: true, Subscript: true},
+		{Text: "+"},
+		{Text: "a", Italic: true},
+		{Text: "/2"},
+	}
+	if got := eq.Segments(); !reflect.DeepEqual(got, want) {
+		t.Errorf("segments = %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package math
+
+// latexSymbols maps LaTeX commands to the Unicode characters used in OMML.
+var latexSymbols = map[string]string{
+	// lower case Greek
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ",
+	"varepsilon": "ε", "zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ",
+	"iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ",
+	"pi": "π", "varpi": "ϖ", "rho": "ρ", "varrho": "ϱ", "sigma": "σ",
+	"varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ", "varphi": "φ",
+	"chi": "χ", "psi": "ψ", "omega": "ω",
+	// upper case Greek
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ",
+	"Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ",
+	"Omega": "Ω",
+	// binary operators and relations
+	"pm": "±", "mp": "∓", "times": "×", "div": "÷", "cdot": "⋅", "ast": "∗",
+	"star": "⋆", "circ": "∘", "bullet": "∙", "oplus": "⊕", "ominus": "⊖",
+	"otimes": "⊗", "cup": "∪", "cap": "∩", "wedge": "∧", "land": "∧",
+	"vee": "∨", "lor": "∨", "setminus": "∖",
+	"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "ne": "≠", "neq": "≠",
+	"ll": "≪", "gg": "≫", "approx": "≈", "equiv": "≡", "sim": "∼",
+	"simeq": "≃", "cong": "≅", "propto": "∝", "in": "∈", "notin": "∉",
+	"ni": "∋", "subset": "⊂", "supset": "⊃", "subseteq": "⊆",
+	"supseteq": "⊇", "perp": "⊥", "parallel": "∥", "mid": "∣",
+	// arrows
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←",
+	"leftrightarrow": "↔", "Rightarrow": "⇒", "Leftarrow": "⇐",
+	"Leftrightarrow": "⇔", "implies": "⟹", "iff": "⟺", "mapsto": "↦",
+	"uparrow": "↑", "downarrow": "↓",
+	// miscellaneous
+	"infty": "∞", "partial": "∂", "nabla": "∇", "forall": "∀",
+	"exists": "∃", "neg": "¬", "lnot": "¬", "emptyset": "∅",
+	"varnothing": "∅", "angle": "∠", "triangle": "△", "hbar": "ℏ",
+	"ell": "ℓ", "Re": "ℜ", "Im": "ℑ", "aleph": "ℵ", "prime": "′",
+	"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+	"degree": "°", "langle": "⟨", "rangle": "⟩", "lfloor": "⌊",
+	"rfloor": "⌋", "lceil": "⌈", "rceil": "⌉", "lbrace": "{", "rbrace": "}",
+	"vert": "|", "Vert": "‖", "backslash": "\\",
+	// spaces
+	",": " ", ";": " ", ":": " ", "quad": " ", "qquad": "  ", " ": " ",
+	"{": "{", "}": "}", "%": "%", "$": "$", "#": "#", "&": "&", "_": "_",
+	"|": "‖",
+}
+
+// latexNary maps LaTeX large operators to n-ary operator characters.
+var latexNary = map[string]string{
+	"sum": OperatorSum, "prod": OperatorProduct, "coprod": OperatorCoproduct,
+	"int": OperatorIntegral, "iint": OperatorDoubleIntegral,
+	"iiint": OperatorTripleIntegral, "oint": OperatorContourIntegral,
+	"bigcup": OperatorUnion, "bigcap": OperatorIntersection,
+}
+
+// latexAccents maps LaTeX accent commands to combining accent characters.
+var latexAccents = map[string]string{
+	"hat": AccentHat, "widehat": AccentHat, "tilde": AccentTilde,
+	"widetilde": AccentTilde, "bar": AccentBar, "dot": AccentDot,
+	"ddot": AccentDDot, "vec": AccentVector,
+}
+
+// latexFunctions lists the function names LaTeX typesets upright.
+var latexFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true,
+	"csc": true, "arcsin": true, "arccos": true, "arctan": true, "sinh": true,
+	"cosh": true, "tanh": true, "coth": true, "log": true, "ln": true,
+	"lg": true, "exp": true, "det": true, "dim": true, "ker": true,
+	"deg": true, "arg": true, "gcd": true, "Pr": true, "hom": true,
+}
+
+// latexLimits lists the operators whose subscript is set below them.
+var latexLimits = map[string]bool{
+	"lim": true, "max": true, "min": true, "sup": true, "inf": true,
+	"limsup": true, "liminf": true,
+}
+
+// latexMatrices maps matrix environments to their delimiters.
+var latexMatrices = map[string][2]string{
+	"matrix": {"", ""}, "pmatrix": {"(", ")"}, "bmatrix": {"[", "]"},
+	"Bmatrix": {"{", "}"}, "vmatrix": {"|", "|"}, "Vmatrix": {"‖", "‖"},
+	"smallmatrix": {"", ""},
+}
+
+var symbolCommands map[string]string
+
+func init() {
+	symbolCommands = map[string]string{}
+	for cmd, sym := range latexSymbols {
+		if len(cmd) < 2 {
+			continue
+		}
+		// prefer the canonical, shortest command for reverse mapping
+		if prev, ok := symbolCommands[sym]; !ok || len(cmd) < len(prev) || len(cmd) == len(prev) && cmd < prev {
+			symbolCommands[sym] = cmd
+		}
+	}
+	for _, s := range []string{" ", "  ", "{", "}", "|", "\\"} {
+		delete(symbolCommands, s)
+	}
+}
+
+// letterStyle is a style of the Unicode mathematical alphanumeric symbols
+// which OMML uses for bold and italic letters.
+type letterStyle byte
+
+const (
+	letterPlain letterStyle = iota
+	letterBold
+	letterItalic
+	letterBoldItalic
+)
+
+// first capital and small letter of each style, capitals are followed by the
+// 26 small letters
+var letterStyles = map[letterStyle]rune{
+	letterBold:       0x1D400,
+	letterItalic:     0x1D434,
+	letterBoldItalic: 0x1D468,
+}
+
+// boldDigits is the mathematical bold digit zero.
+const boldDigits = 0x1D7CE
+
+// italicSmallH is the italic h, which is not part of the contiguous block.
+const italicSmallH = 'ℎ'
+
+// styledLetter returns the mathematical alphanumeric symbol for r in style s,
+// or r itself if there is none. Bold italic digits use the bold digits.
+func styledLetter(r rune, s letterStyle) rune {
+	base, ok := letterStyles[s]
+	switch {
+	case !ok:
+		return r
+	case r >= 'A' && r <= 'Z':
+		return base + r - 'A'
+	case r == 'h' && s == letterItalic:
+		return italicSmallH
+	case r >= 'a' && r <= 'z':
+		return base + 26 + r - 'a'
+	case r >= '0' && r <= '9' && s != letterItalic:
+		return boldDigits + r - '0'
+	}
+	return r
+}
+
+// plainLetter is the inverse of styledLetter.
+func plainLetter(r rune) (rune, letterStyle) {
+	if r == italicSmallH {
+		return 'h', letterItalic
+	}
+	if r >= boldDigits && r < boldDigits+10 {
+		return '0' + r - boldDigits, letterBold
+	}
+	for s, base := range letterStyles {
+		switch {
+		case r >= base && r < base+26:
+			return 'A' + r - base, s
+		case r >= base+26 && r < base+52:
+			return 'a' + r - base - 26, s
+		}
+	}
+	return r, letterPlain
+}
@@ -0,0 +1,515 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf16"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/schema/soo/ofc/sharedTypes"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// ProtectionType is the kind of editing restriction applied to a protected
+// document.
+type ProtectionType byte
+
+// ProtectionType constants.
+const (
+	// ProtectionNone applies no editing restriction.
+	ProtectionNone ProtectionType = iota
+	// ProtectionReadOnly allows no edits outside of editable ranges.
+	ProtectionReadOnly
+	// ProtectionComments allows only comments to be added.
+	ProtectionComments
+	// ProtectionTrackedChanges allows edits that are recorded as tracked
+	// changes.
+	ProtectionTrackedChanges
+	// ProtectionForms allows only form fields and content controls to be
+	// filled in.
+	ProtectionForms
+)
+
+// String returns the name of the protection type.
+func (p ProtectionType) String() string {
+	switch p {
+	case ProtectionReadOnly:
+		return "read-only"
+	case ProtectionComments:
+		return "comments"
+	case ProtectionTrackedChanges:
+		return "tracked changes"
+	case ProtectionForms:
+		return "forms"
+	}
+	return "none"
+}
+
+// passwordSpinCount is the number of hash iterations used for new passwords,
+// matching the value written by Word.
+const passwordSpinCount = 100000
+
+// DocumentProtection controls the editing restrictions of a document
+// (w:documentProtection).
+type DocumentProtection struct {
+	x *wml.CT_DocProtect
+}
+
+// X returns the inner wrapped XML type.
+func (p DocumentProtection) X() *wml.CT_DocProtect { return p.x }
+
+// Protection returns the document protection settings, creating them if
+// they don't exist. The protection is not enforced until SetEnforced is
+// called.
+func (s Settings) Protection() DocumentProtection {
+	if s._egdbfa.DocumentProtection == nil {
+		s._egdbfa.DocumentProtection = wml.NewCT_DocProtect()
+	}
+	return DocumentProtection{s._egdbfa.DocumentProtection}
+}
+
+// ClearProtection removes the document protection settings.
+func (s Settings) ClearProtection() { s._egdbfa.DocumentProtection = nil }
+
+// IsProtected returns true if editing restrictions are enforced on the
+// document.
+func (s Settings) IsProtected() bool {
+	return s._egdbfa.DocumentProtection != nil && DocumentProtection{s._egdbfa.DocumentProtection}.IsEnforced()
+}
+
+// SetType sets the kind of editing restriction.
+func (p DocumentProtection) SetType(t ProtectionType) {
+	switch t {
+	case ProtectionReadOnly:
+		p.x.EditAttr = wml.ST_DocProtectReadOnly
+	case ProtectionComments:
+		p.x.EditAttr = wml.ST_DocProtectComments
+	case ProtectionTrackedChanges:
+		p.x.EditAttr = wml.ST_DocProtectTrackedChanges
+	case ProtectionForms:
+		p.x.EditAttr = wml.ST_DocProtectForms
+	default:
+		p.x.EditAttr = wml.ST_DocProtectNone
+	}
+}
+
+// Type returns the kind of editing restriction.
+func (p DocumentProtection) Type() ProtectionType {
+	switch p.x.EditAttr {
+	case wml.ST_DocProtectReadOnly:
+		return ProtectionReadOnly
+	case wml.ST_DocProtectComments:
+		return ProtectionComments
+	case wml.ST_DocProtectTrackedChanges:
+		return ProtectionTrackedChanges
+	case wml.ST_DocProtectForms:
+		return ProtectionForms
+	}
+	return ProtectionNone
+}
+
+// SetEnforced controls if the editing restriction is enforced.
+func (p DocumentProtection) SetEnforced(b bool) {
+	if !b {
+		p.x.EnforcementAttr = nil
+	} else {
+		p.x.EnforcementAttr = newOnOff(true)
+	}
+}
+
+// IsEnforced returns true if the editing restriction is enforced.
+func (p DocumentProtection) IsEnforced() bool { return isOnOff(p.x.EnforcementAttr) }
+
+// SetFormattingRestricted controls if formatting is limited to the styles
+// allowed by the document.
+func (p DocumentProtection) SetFormattingRestricted(b bool) {
+	if !b {
+		p.x.FormattingAttr = nil
+	} else {
+		p.x.FormattingAttr = newOnOff(true)
+	}
+}
+
+// IsFormattingRestricted returns true if formatting is limited to the styles
+// allowed by the document.
+func (p DocumentProtection) IsFormattingRestricted() bool { return isOnOff(p.x.FormattingAttr) }
+
+// SetPassword sets the password required to remove the protection. The
+// password is stored as a salted SHA-512 hash. An empty password removes the
+// password.
+func (p DocumentProtection) SetPassword(pw string) error {
+	if pw == "" {
+		p.ClearPassword()
+		return nil
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("error generating password salt: %w", err)
+	}
+	p.SetPasswordHash(hashDocumentPassword(pw, salt, passwordSpinCount), salt, passwordSpinCount)
+	return nil
+}
+
+// SetPasswordHash sets a precomputed SHA-512 password hash along with the salt
+// and the number of iterations used to compute it.
+func (p DocumentProtection) SetPasswordHash(hash, salt []byte, spinCount int32) {
+	p.ClearPassword()
+	p.x.AlgorithmNameAttr = unioffice.String("SHA-512")
+	p.x.HashValueAttr = unioffice.String(base64.StdEncoding.EncodeToString(hash))
+	p.x.SaltValueAttr = unioffice.String(base64.StdEncoding.EncodeToString(salt))
+	p.x.SpinCountAttr = unioffice.Int32(spinCount)
+}
+
+// ClearPassword removes the password, allowing the protection to be removed
+// without one.
+func (p DocumentProtection) ClearPassword() {
+	x := wml.NewCT_DocProtect()
+	x.EditAttr = p.x.EditAttr
+	x.FormattingAttr = p.x.FormattingAttr
+	x.EnforcementAttr = p.x.EnforcementAttr
+	*p.x = *x
+}
+
+// HasPassword returns true if a password is required to remove the
+// protection.
+func (p DocumentProtection) HasPassword() bool { return p.x.HashValueAttr != nil }
+
+// VerifyPassword returns true if pw is the password of the protection. Only
+// SHA-512 password hashes can be verified, other algorithms report false.
+func (p DocumentProtection) VerifyPassword(pw string) bool {
+	if !p.HasPassword() {
+		return true
+	}
+	if p.x.SaltValueAttr == nil || p.x.AlgorithmNameAttr == nil || *p.x.AlgorithmNameAttr != "SHA-512" {
+		return false
+	}
+	hash, err := base64.StdEncoding.DecodeString(*p.x.HashValueAttr)
+	if err != nil {
+		return false
+	}
+	salt, err := base64.StdEncoding.DecodeString(*p.x.SaltValueAttr)
+	if err != nil {
+		return false
+	}
+	spinCount := int32(0)
+	if p.x.SpinCountAttr != nil {
+		spinCount = *p.x.SpinCountAttr
+	}
+	return bytes.Equal(hash, hashDocumentPassword(pw, salt, spinCount))
+}
+
+// Protect enforces an editing restriction on the document, protected by the
+// password if it is not empty.
+func (d *Document) Protect(t ProtectionType, password string) error {
+	p := d.Settings.Protection()
+	p.SetType(t)
+	if err := p.SetPassword(password); err != nil {
+		return err
+	}
+	p.SetEnforced(t != ProtectionNone)
+	return nil
+}
+
+// Unprotect removes the editing restriction from the document if the
+// password matches.
+func (d *Document) Unprotect(password string) error {
+	if d.Settings.X().DocumentProtection == nil {
+		return nil
+	}
+	if !d.Settings.Protection().VerifyPassword(password) {
+		return errors.New("invalid document protection password")
+	}
+	d.Settings.ClearProtection()
+	return nil
+}
+
+// ProtectionState reports the editing restrictions of a document.
+type ProtectionState struct {
+	// Type is the kind of editing restriction, ProtectionNone if the document
+	// is not protected.
+	Type ProtectionType
+	// Enforced is true if the restriction is enforced by editors.
+	Enforced bool
+	// HasPassword is true if a password is required to remove the
+	// restriction.
+	HasPassword bool
+	// FormattingRestricted is true if formatting is limited to the allowed
+	// styles.
+	FormattingRestricted bool
+	// EditableRanges are the ranges exempt from the restriction.
+	EditableRanges []EditableRange
+}
+
+// ProtectionState returns the current editing restrictions of the document.
+func (d *Document) ProtectionState() ProtectionState {
+	ps := ProtectionState{EditableRanges: d.EditableRanges()}
+	if d.Settings.X().DocumentProtection == nil {
+		return ps
+	}
+	p := d.Settings.Protection()
+	ps.Type = p.Type()
+	ps.Enforced = p.IsEnforced()
+	ps.HasPassword = p.HasPassword()
+	ps.FormattingRestricted = p.IsFormattingRestricted()
+	return ps
+}
+
+// CanEdit returns true if content outside of editable ranges may be changed
+// freely, i.e. no editing restriction is enforced.
+func (ps ProtectionState) CanEdit() bool {
+	return !ps.Enforced || ps.Type == ProtectionNone
+}
+
+// hashDocumentPassword computes the password hash used by Word for document
+// protection. The password is first reduced to the legacy 32-bit key, whose
+// byte-reversed hex form is then hashed with SHA-512 together with the salt
+// and iterated spinCount times.
+func hashDocumentPassword(pw string, salt []byte, spinCount int32) []byte {
+	key := legacyPasswordKey(pw)
+	hex := fmt.Sprintf("%02X%02X%02X%02X", key&0xff, (key>>8)&0xff, (key>>16)&0xff, key>>24)
+
+	buf := bytes.Buffer{}
+	buf.Write(salt)
+	for _, c := range utf16.Encode([]rune(hex)) {
+		binary.Write(&buf, binary.LittleEndian, c)
+	}
+	h := sha512.Sum512(buf.Bytes())
+	hash := h[:]
+	iter := make([]byte, 4)
+	for i := int32(0); i < spinCount; i++ {
+		binary.LittleEndian.PutUint32(iter, uint32(i))
+		h = sha512.Sum512(append(hash, iter...))
+		hash = h[:]
+	}
+	return hash
+}
+
+var passwordInitialCode = [...]uint16{
+	0xE1F0, 0x1D0F, 0xCC9C, 0x84C0, 0x110C, 0x0E10, 0xF1CE, 0x313E,
+	0x1872, 0xE139, 0xD40F, 0x84F9, 0x280C, 0xA96A, 0x4EC3,
+}
+
+var passwordEncryptionMatrix = [15][7]uint16{
+	{0xAEFC, 0x4DD9, 0x9BB2, 0x2745, 0x4E8A, 0x9D14, 0x2A09},
+	{0x7B61, 0xF6C2, 0xFDA5, 0xEB6B, 0xC6F7, 0x9DCF, 0x2BBF},
+	{0x4563, 0x8AC6, 0x05AD, 0x0B5A, 0x16B4, 0x2D68, 0x5AD0},
+	{0x0375, 0x06EA, 0x0DD4, 0x1BA8, 0x3750, 0x6EA0, 0xDD40},
+	{0xD849, 0xA0B3, 0x5147, 0xA28E, 0x553D, 0xAA7A, 0x44D5},
+	{0x6F45, 0xDE8A, 0xAD35, 0x4A4B, 0x9496, 0x390D, 0x721A},
+	{0xEB23, 0xC667, 0x9CEF, 0x29FF, 0x53FE, 0xA7FC, 0x5FD9},
+	{0x47D3, 0x8FA6, 0x0F6D, 0x1EDA, 0x3DB4, 0x7B68, 0xF6D0},
+	{0xB861, 0x60E3, 0xC1C6, 0x93AD, 0x377B, 0x6EF6, 0xDDEC},
+	{0x45A0, 0x8B40, 0x06A1, 0x0D42, 0x1A84, 0x3508, 0x6A10},
+	{0xAA51, 0x4483, 0x8906, 0x022D, 0x045A, 0x08B4, 0x1168},
+	{0x76B4, 0xED68, 0xCAF1, 0x85C3, 0x1BA7, 0x374E, 0x6E9C},
+	{0x3730, 0x6E60, 0xDCC0, 0xA9A1, 0x4363, 0x86C6, 0x1DAD},
+	{0x3331, 0x6662, 0xCCC4, 0x89A9, 0x0373, 0x06E6, 0x0DCC},
+	{0x1021, 0x2042, 0x4084, 0x8108, 0x1231, 0x2462, 0x48C4},
+}
+
+// legacyPasswordKey computes the 32-bit password key of ECMA-376 Part 4,
+// section 2.15.1.28 (w:documentProtection).
+func legacyPasswordKey(pw string) uint32 {
+	chars := []byte{}
+	for _, r := range pw {
+		if len(chars) == 15 {
+			break
+		}
+		c := uint16(r)
+		if c&0xff != 0 {
+			chars = append(chars, byte(c))
+		} else {
+			chars = append(chars, byte(c>>8))
+		}
+	}
+	if len(chars) == 0 {
+		return 0
+	}
+
+	high := passwordInitialCode[len(chars)-1]
+	for i, c := range chars {
+		row := 15 - len(chars) + i
+		for bit := 0; bit < 7; bit++ {
+			if c&(1<<uint(bit)) != 0 {
+				high ^= passwordEncryptionMatrix[row][bit]
+			}
+		}
+	}
+
+	low := uint16(0)
+	for i := len(chars) - 1; i >= 0; i-- {
+		low = ((low >> 14) & 0x01) | ((low << 1) & 0x7fff)
+		low ^= uint16(chars[i])
+	}
+	low = ((low >> 14) & 0x01) | ((low << 1) & 0x7fff)
+	low ^= uint16(len(chars))
+	low ^= 0x8000 | ('N' << 8) | 'K'
+	return uint32(high)<<16 | uint32(low)
+}
+
+func isOnOff(v *sharedTypes.ST_OnOff) bool {
+	if v == nil {
+		return false
+	}
+	if v.Bool != nil {
+		return *v.Bool
+	}
+	return v.ST_OnOff1 == sharedTypes.ST_OnOff1On
+}
+
+// EditorGroup is a group of users allowed to edit an editable range.
+type EditorGroup byte
+
+// EditorGroup constants.
+const (
+	EditorGroupUnset EditorGroup = iota
+	EditorGroupEveryone
+	EditorGroupEditors
+	EditorGroupOwners
+	EditorGroupContributors
+	EditorGroupAdministrators
+	EditorGroupCurrent
+)
+
+var editorGroups = map[EditorGroup]wml.ST_EdGrp{
+	EditorGroupEveryone:       wml.ST_EdGrpEveryone,
+	EditorGroupEditors:        wml.ST_EdGrpEditors,
+	EditorGroupOwners:         wml.ST_EdGrpOwners,
+	EditorGroupContributors:   wml.ST_EdGrpContributors,
+	EditorGroupAdministrators: wml.ST_EdGrpAdministrators,
+	EditorGroupCurrent:        wml.ST_EdGrpCurrent,
+}
+
+// EditableRange is a range of a protected document that specific users or
+// groups may edit (w:permStart/w:permEnd).
+type EditableRange struct {
+	ID string
+	// User is the user allowed to edit the range, empty if the range is
+	// editable by a group.
+	User string
+	// Group is the group allowed to edit the range.
+	Group EditorGroup
+	// Start is the paragraph containing the start of the range.
+	Start Paragraph
+	x     *wml.CT_PermStart
+}
+
+// X returns the inner wrapped XML type.
+func (e EditableRange) X() *wml.CT_PermStart { return e.x }
+
+// AddEditableRangeForUser marks the paragraphs from start to end (inclusive)
+// as editable by a single user, identified by an e-mail address or a
+// DOMAIN\user name, when the document is protected.
+func (d *Document) AddEditableRangeForUser(start, end Paragraph, user string) EditableRange {
+	ps := wml.NewCT_PermStart()
+	ps.EdAttr = unioffice.String(user)
+	return d.addEditableRange(start, end, ps)
+}
+
+// AddEditableRangeForGroup marks the paragraphs from start to end (inclusive)
+// as editable by a group of users when the document is protected.
+func (d *Document) AddEditableRangeForGroup(start, end Paragraph, g EditorGroup) EditableRange {
+	ps := wml.NewCT_PermStart()
+	ps.EdGrpAttr = editorGroups[g]
+	return d.addEditableRange(start, end, ps)
+}
+
+func (d *Document) addEditableRange(start, end Paragraph, ps *wml.CT_PermStart) EditableRange {
+	id := 0
+	for _, er := range d.EditableRanges() {
+		if n, err := strconv.Atoi(er.ID); err == nil && n >= id {
+			id = n + 1
+		}
+	}
+	ps.IdAttr = strconv.Itoa(id)
+	pe := wml.NewCT_Perm()
+	pe.IdAttr = ps.IdAttr
+
+	rle := wml.NewEG_RunLevelElts()
+	rle.RunLevelEltsChoice.PermStart = ps
+	start._cebfg.EG_PContent = append([]*wml.EG_PContent{newRunLevelPContent(rle)}, start._cebfg.EG_PContent...)
+
+	rle = wml.NewEG_RunLevelElts()
+	rle.RunLevelEltsChoice.PermEnd = pe
+	end._cebfg.EG_PContent = append(end._cebfg.EG_PContent, newRunLevelPContent(rle))
+	return newEditableRange(start, ps)
+}
+
+func newRunLevelPContent(rle *wml.EG_RunLevelElts) *wml.EG_PContent {
+	pc := wml.NewEG_PContent()
+	crc := wml.NewEG_ContentRunContent()
+	crc.ContentRunContentChoice.EG_RunLevelElts = append(crc.ContentRunContentChoice.EG_RunLevelElts, rle)
+	pc.PContentChoice.EG_ContentRunContent = append(pc.PContentChoice.EG_ContentRunContent, crc)
+	return pc
+}
+
+func newEditableRange(p Paragraph, ps *wml.CT_PermStart) EditableRange {
+	er := EditableRange{ID: ps.IdAttr, Start: p, x: ps}
+	if ps.EdAttr != nil {
+		er.User = *ps.EdAttr
+	}
+	for g, v := range editorGroups {
+		if ps.EdGrpAttr == v {
+			er.Group = g
+		}
+	}
+	return er
+}
+
+// EditableRanges returns the editable ranges of the document body.
+func (d *Document) EditableRanges() []EditableRange {
+	ret := []EditableRange{}
+	for _, p := range d.paragraphsInOrder() {
+		for _, rle := range p.runLevelElts() {
+			if ps := rle.RunLevelEltsChoice.PermStart; ps != nil {
+				ret = append(ret, newEditableRange(p, ps))
+			}
+		}
+	}
+	return ret
+}
+
+// RemoveEditableRange removes the editable range with the given ID, returning
+// false if there is no such range.
+func (d *Document) RemoveEditableRange(id string) bool {
+	found := false
+	for _, p := range d.paragraphsInOrder() {
+		for _, rle := range p.runLevelElts() {
+			if ps := rle.RunLevelEltsChoice.PermStart; ps != nil && ps.IdAttr == id {
+				rle.RunLevelEltsChoice.PermStart = nil
+				found = true
+			}
+			if pe := rle.RunLevelEltsChoice.PermEnd; pe != nil && pe.IdAttr == id {
+				rle.RunLevelEltsChoice.PermEnd = nil
+			}
+		}
+	}
+	return found
+}
+
+// runLevelElts returns the run level elements directly contained in the
+// paragraph.
+func (p Paragraph) runLevelElts() []*wml.EG_RunLevelElts {
+	ret := []*wml.EG_RunLevelElts{}
+	for _, pc := range p._cebfg.EG_PContent {
+		for _, crc := range pc.PContentChoice.EG_ContentRunContent {
+			ret = append(ret, crc.ContentRunContentChoice.EG_RunLevelElts...)
+		}
+	}
+	return ret
+}
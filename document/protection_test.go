@@ -0,0 +1,176 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2"
+)
+
+func TestPasswordHash(t *testing.T) {
+	for _, tc := range []struct {
+		pw  string
+		key uint32
+	}{
+		// the low word is the legacy hash of Excel
+		{"password", 0x147A83AF},
+		{"a", 0x9D77CE88},
+		{"Example", 0x64CEED7E},
+		// characters past the 15th are ignored
+		{"0123456789abcdefXYZ", 0x2D7BB7C4},
+		{"0123456789abcde", 0x2D7BB7C4},
+		{"", 0},
+	} {
+		if got := legacyPasswordKey(tc.pw); got != tc.key {
+			t.Errorf("legacyPasswordKey(%q) = %08X, want %08X", tc.pw, got, tc.key)
+		}
+	}
+	hash := hashDocumentPassword("password", []byte("0123456789abcdef"), 3)
+	if got := base64.StdEncoding.EncodeToString(hash); got != "hNWQLPjOs6ZIoYt1iDdsj2JXc6fADzfIJ2BYkuRjrVAzX+xez2VowUV+WtpFGDzOqrn2mt9pbsAOZ43hx9JkLA==" {
+		t.Errorf("hash = %s", got)
+	}
+}
+
+func TestProtect(t *testing.T) {
+	d := New()
+	d.AddParagraph().AddRun().AddText("text")
+	if d.Settings.IsProtected() || !d.ProtectionState().CanEdit() {
+		t.Error("new document is protected")
+	}
+	if err := d.Protect(ProtectionReadOnly, "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	read := roundTrip(t, d)
+	ps := read.ProtectionState()
+	if ps.Type != ProtectionReadOnly || !ps.Enforced || !ps.HasPassword || ps.CanEdit() {
+		t.Errorf("protection state = %+v", ps)
+	}
+	p := read.Settings.Protection()
+	if *p.X().AlgorithmNameAttr != "SHA-512" || *p.X().SpinCountAttr != passwordSpinCount {
+		t.Errorf("password hash = %s, %d iterations", *p.X().AlgorithmNameAttr, *p.X().SpinCountAttr)
+	}
+	if p.VerifyPassword("Secret") || !p.VerifyPassword("secret") {
+		t.Error("password wasn't verified")
+	}
+	if err := read.Unprotect("wrong"); err == nil {
+		t.Error("unprotected with a wrong password")
+	}
+	if !read.Settings.IsProtected() {
+		t.Error("a wrong password removed the protection")
+	}
+	if err := read.Unprotect("secret"); err != nil {
+		t.Error(err)
+	}
+	if read.Settings.IsProtected() || read.Settings.X().DocumentProtection != nil {
+		t.Error("protection wasn't removed")
+	}
+
+	// a protection without a password is removed by any password
+	if err := d.Protect(ProtectionComments, ""); err != nil {
+		t.Fatal(err)
+	}
+	if d.ProtectionState().HasPassword {
+		t.Error("protection has a password")
+	}
+	if err := d.Unprotect("anything"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDocumentProtection(t *testing.T) {
+	d := New()
+	p := d.Settings.Protection()
+	if p.IsEnforced() || p.Type() != ProtectionNone {
+		t.Error("protection is enforced before SetEnforced")
+	}
+	for _, typ := range []ProtectionType{ProtectionNone, ProtectionReadOnly, ProtectionComments, ProtectionTrackedChanges, ProtectionForms} {
+		p.SetType(typ)
+		if got := p.Type(); got != typ {
+			t.Errorf("Type() = %s, want %s", got, typ)
+		}
+	}
+	if ProtectionTrackedChanges.String() != "tracked changes" {
+		t.Errorf("String() = %s", ProtectionTrackedChanges)
+	}
+
+	p.SetEnforced(true)
+	p.SetFormattingRestricted(true)
+	p.SetPasswordHash(hashDocumentPassword("pw", []byte("salt"), 10), []byte("salt"), 10)
+	if !p.HasPassword() || !p.VerifyPassword("pw") {
+		t.Error("precomputed hash wasn't verified")
+	}
+	// the type and enforcement are kept without the password
+	p.ClearPassword()
+	if p.HasPassword() || !p.IsEnforced() || !p.IsFormattingRestricted() || p.Type() != ProtectionForms {
+		t.Errorf("protection after ClearPassword = %+v", d.ProtectionState())
+	}
+	if !p.VerifyPassword("anything") {
+		t.Error("protection without a password rejected a password")
+	}
+
+	// passwords hashed with other algorithms can't be verified
+	p.SetPasswordHash([]byte("hash"), []byte("salt"), 1)
+	p.X().AlgorithmNameAttr = unioffice.String("SHA-1")
+	if p.VerifyPassword("pw") {
+		t.Error("verified a SHA-1 hash")
+	}
+	if err := p.SetPassword(""); err != nil || p.HasPassword() {
+		t.Errorf("empty password kept the hash: %v", err)
+	}
+	p.SetFormattingRestricted(false)
+	p.SetEnforced(false)
+	if p.IsFormattingRestricted() || d.Settings.IsProtected() {
+		t.Error("restrictions weren't removed")
+	}
+}
+
+func TestEditableRanges(t *testing.T) {
+	d := New()
+	p1 := d.AddParagraph()
+	p1.AddRun().AddText("one")
+	p2 := d.AddParagraph()
+	p3 := d.AddParagraph()
+	d.AddEditableRangeForUser(p1, p2, "jane@example.com")
+	er := d.AddEditableRangeForGroup(p3, p3, EditorGroupEveryone)
+	if er.ID != "1" || er.Group != EditorGroupEveryone || er.User != "" {
+		t.Errorf("group range = %+v", er)
+	}
+	if err := d.Protect(ProtectionReadOnly, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	read := roundTrip(t, d)
+	ranges := read.ProtectionState().EditableRanges
+	if len(ranges) != 2 {
+		t.Fatalf("read %d editable ranges, want 2", len(ranges))
+	}
+	if ranges[0].ID != "0" || ranges[0].User != "jane@example.com" || ranges[0].Group != EditorGroupUnset || paragraphText(ranges[0].Start) != "one" {
+		t.Errorf("user range = %+v", ranges[0])
+	}
+	if ranges[1].Group != EditorGroupEveryone {
+		t.Errorf("group range group = %v", ranges[1].Group)
+	}
+	if read.RemoveEditableRange("7") {
+		t.Error("removed a missing range")
+	}
+	if !read.RemoveEditableRange("0") {
+		t.Error("range wasn't removed")
+	}
+	if ranges := read.EditableRanges(); len(ranges) != 1 || ranges[0].ID != "1" {
+		t.Errorf("ranges after removal = %+v", ranges)
+	}
+	// the run text doesn't include the range markers
+	if got := paragraphText(read.Paragraphs()[0]); got != "one" {
+		t.Errorf("paragraph text = %q", got)
+	}
+}
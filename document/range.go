@@ -0,0 +1,636 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// Range is a span of text that may cross paragraph boundaries. Its ends are
+// character offsets in the text of the start and end paragraphs, counting tabs
+// as one character. Runs are split at the ends of the range as needed when it
+// is modified.
+type Range struct {
+	d           *Document
+	start, end  Paragraph
+	startOffset int
+	endOffset   int
+}
+
+// NewRange returns the range from the character at startOffset in the start
+// paragraph to the character before endOffset in the end paragraph. Both
+// paragraphs must belong to the same part of the document, e.g. the body or a
+// header.
+func (d *Document) NewRange(start Paragraph, startOffset int, end Paragraph, endOffset int) (*Range, error) {
+	r := &Range{d: d, start: start, end: end, startOffset: startOffset, endOffset: endOffset}
+	ps := r.Paragraphs()
+	if len(ps) == 0 {
+		return nil, errors.New("range start and end are not in the same story, or end is before start")
+	}
+	if startOffset < 0 || startOffset > paragraphTextLen(start) {
+		return nil, fmt.Errorf("start offset %d out of range", startOffset)
+	}
+	if endOffset < 0 || endOffset > paragraphTextLen(end) {
+		return nil, fmt.Errorf("end offset %d out of range", endOffset)
+	}
+	if len(ps) == 1 && endOffset < startOffset {
+		return nil, errors.New("range end is before start")
+	}
+	return r, nil
+}
+
+// RangeAt returns the range between character offsets of two body paragraphs,
+// identified by their zero based index among the body paragraphs in document
+// order, including those in tables.
+func (d *Document) RangeAt(startParagraph, startOffset, endParagraph, endOffset int) (*Range, error) {
+	ps := d.paragraphsInOrder()
+	if startParagraph < 0 || startParagraph >= len(ps) {
+		return nil, fmt.Errorf("start paragraph %d out of range", startParagraph)
+	}
+	if endParagraph < 0 || endParagraph >= len(ps) {
+		return nil, fmt.Errorf("end paragraph %d out of range", endParagraph)
+	}
+	return d.NewRange(ps[startParagraph], startOffset, ps[endParagraph], endOffset)
+}
+
+// Range returns the range of the whole text of the paragraph.
+func (p Paragraph) Range() *Range {
+	return &Range{d: p._adga, start: p, end: p, endOffset: paragraphTextLen(p)}
+}
+
+// Range returns the range of the matched text.
+func (m Match) Range() *Range {
+	p := m.Paragraph
+	return &Range{d: p._adga, start: p, end: p, startOffset: p.offsetOf(m.Start), endOffset: p.offsetOf(m.End)}
+}
+
+// Start returns the paragraph and character offset of the start of the range.
+func (r *Range) Start() (Paragraph, int) { return r.start, r.startOffset }
+
+// End returns the paragraph and character offset just after the end of the
+// range.
+func (r *Range) End() (Paragraph, int) { return r.end, r.endOffset }
+
+// IsCollapsed returns true if the range is empty.
+func (r *Range) IsCollapsed() bool {
+	return r.start._cebfg == r.end._cebfg && r.startOffset == r.endOffset
+}
+
+// Paragraphs returns the paragraphs the range starts, ends or passes through.
+func (r *Range) Paragraphs() []Paragraph {
+	for _, bles := range r.d.stories() {
+		ps := r.d.storyParagraphs(*bles)
+		for i, p := range ps {
+			if p._cebfg != r.start._cebfg {
+				continue
+			}
+			for j := i; j < len(ps); j++ {
+				if ps[j]._cebfg == r.end._cebfg {
+					return ps[i : j+1]
+				}
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// bounds returns the character offsets of the range in the paragraph.
+func (r *Range) bounds(p Paragraph) (int, int) {
+	a, b := 0, paragraphTextLen(p)
+	if p._cebfg == r.start._cebfg {
+		a = r.startOffset
+	}
+	if p._cebfg == r.end._cebfg {
+		b = r.endOffset
+	}
+	return a, b
+}
+
+// Text returns the text of the range, with paragraphs separated by newlines.
+func (r *Range) Text() string {
+	parts := []string{}
+	for _, p := range r.Paragraphs() {
+		a, b := r.bounds(p)
+		parts = append(parts, string([]rune(paragraphText(p))[a:b]))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Runs returns the runs holding the text of the range, splitting the runs at
+// the ends of the range.
+func (r *Range) Runs() []Run {
+	ret := []Run{}
+	for _, p := range r.Paragraphs() {
+		a, b := r.bounds(p)
+		ret = append(ret, p.runsBetween(a, b)...)
+	}
+	return ret
+}
+
+// Delete removes the text of the range. If the range ends in another
+// paragraph, the paragraphs in between are removed and the rest of the end
+// paragraph is joined to the start paragraph. Tables crossed by the range are
+// kept with their cells cleared. The range is collapsed to its start.
+func (r *Range) Delete() {
+	ps := r.Paragraphs()
+	if len(ps) == 0 {
+		return
+	}
+	first, last := ps[0], ps[len(ps)-1]
+	for _, p := range ps {
+		a, b := r.bounds(p)
+		p.removeRuns(p.runsBetween(a, b))
+	}
+	if len(ps) > 1 {
+		fs, ok := r.d.locateParagraph(first._cebfg)
+		for _, p := range ps[1:] {
+			s, found := r.d.locateParagraph(p._cebfg)
+			if !ok || !found || !fs.sameContainer(s) {
+				continue
+			}
+			if p._cebfg == last._cebfg {
+				first._cebfg.EG_PContent = append(first._cebfg.EG_PContent, last._cebfg.EG_PContent...)
+			}
+			s.remove(p._cebfg)
+		}
+	}
+	r.end, r.endOffset = r.start, r.startOffset
+}
+
+// InsertBefore inserts text at the start of the range in a new run with the
+// properties of the run that follows it. The range is extended to include the
+// inserted text.
+func (r *Range) InsertBefore(text string) Run {
+	run := r.start.insertRunAt(r.startOffset, false, text)
+	if r.end._cebfg == r.start._cebfg {
+		r.endOffset += run.textLen()
+	}
+	return run
+}
+
+// InsertAfter inserts text at the end of the range in a new run with the
+// properties of the run that precedes it. The range is extended to include
+// the inserted text.
+func (r *Range) InsertAfter(text string) Run {
+	run := r.end.insertRunAt(r.endOffset, !r.IsCollapsed(), text)
+	r.endOffset += run.textLen()
+	return run
+}
+
+// ApplyRunProperties calls apply with the properties of each run of the
+// range, e.g. to make the text of the range bold while keeping its other
+// formatting.
+func (r *Range) ApplyRunProperties(apply func(rp RunProperties)) {
+	for _, run := range r.Runs() {
+		apply(run.Properties())
+	}
+}
+
+// ApplyStyle applies a style to the range. A paragraph style is applied to the
+// paragraphs of the range, a character style to its runs.
+func (r *Range) ApplyStyle(styleID string) error {
+	s, ok := r.d.Styles.SearchStyleById(styleID)
+	if !ok {
+		return fmt.Errorf("style %s not found", styleID)
+	}
+	switch s.Type() {
+	case wml.ST_StyleTypeParagraph:
+		for _, p := range r.Paragraphs() {
+			p.SetStyle(styleID)
+		}
+	case wml.ST_StyleTypeCharacter:
+		r.ApplyRunProperties(func(rp RunProperties) { rp.SetStyle(styleID) })
+	default:
+		return fmt.Errorf("style %s is not a paragraph or character style", styleID)
+	}
+	return nil
+}
+
+// WrapInBookmark adds a bookmark around the range.
+func (r *Range) WrapInBookmark(name string) Bookmark {
+	id := r.d.nextBookmarkID()
+	bs := wml.NewCT_Bookmark()
+	bs.IdAttr = id
+	bs.NameAttr = name
+	be := wml.NewCT_MarkupRange()
+	be.IdAttr = id
+
+	rme := wml.NewEG_RangeMarkupElements()
+	rme.RangeMarkupElementsChoice.BookmarkStart = bs
+	start := newRangeMarkup(rme)
+	rme = wml.NewEG_RangeMarkupElements()
+	rme.RangeMarkupElementsChoice.BookmarkEnd = be
+	r.mark(start, newRangeMarkup(rme))
+	return Bookmark{bs}
+}
+
+// AddComment adds a comment anchored to the range.
+func (r *Range) AddComment(author, text string) Comment {
+	// build the comment markup in a scratch paragraph, its content is then
+	// moved to the ends of the range
+	scratch := Paragraph{r.d, wml.NewCT_P()}
+	id := scratch.AddComment(author, text)
+	scratch.CloseComment(id)
+	start := []*wml.EG_ContentRunContent{}
+	end := []*wml.EG_ContentRunContent{}
+	for i, pc := range scratch._cebfg.EG_PContent {
+		if i == 0 {
+			start = append(start, pc.PContentChoice.EG_ContentRunContent...)
+		} else {
+			end = append(end, pc.PContentChoice.EG_ContentRunContent...)
+		}
+	}
+	r.mark(start, end)
+	comments := r.d.Comments()
+	return comments[len(comments)-1]
+}
+
+// WrapInContentControl wraps the range in a content control with the given
+// tag and title, either of which may be empty. A range within a paragraph is
+// wrapped in an inline content control, in which case it may not partially
+// cover a hyperlink. A range spanning paragraphs wraps the whole paragraphs in
+// a block content control, which requires the paragraphs to be in the same
+// container, e.g. not to start in the body and end in a table.
+func (r *Range) WrapInContentControl(tag, title string) error {
+	ps := r.Paragraphs()
+	if len(ps) == 0 {
+		return errors.New("invalid range")
+	}
+	if len(ps) == 1 {
+		return r.wrapInRunContentControl(tag, title)
+	}
+	fs, ok1 := r.d.locateParagraph(r.start._cebfg)
+	ls, ok2 := r.d.locateParagraph(r.end._cebfg)
+	if !ok1 || !ok2 || !fs.sameContainer(ls) {
+		return errors.New("range paragraphs are not in the same container")
+	}
+
+	sdt := wml.NewCT_SdtBlock()
+	sdt.SdtPr = newSdtPr(tag, title)
+	sdt.SdtContent = wml.NewCT_SdtContentBlock()
+	cbc := wml.NewEG_ContentBlockContent()
+	cbc.ContentBlockContentChoice.Sdt = sdt
+
+	if fs.bles != nil {
+		bles := *fs.bles
+		covered := r.d.storyParagraphs(bles[fs.ble : ls.ble+1])
+		if covered[0]._cebfg != r.start._cebfg || covered[len(covered)-1]._cebfg != r.end._cebfg {
+			return errors.New("range paragraphs share block content with other paragraphs")
+		}
+		for _, ble := range bles[fs.ble : ls.ble+1] {
+			sdt.SdtContent.EG_ContentBlockContent = append(sdt.SdtContent.EG_ContentBlockContent, ble.BlockLevelEltsChoice.EG_ContentBlockContent...)
+		}
+		ble := wml.NewEG_BlockLevelElts()
+		ble.BlockLevelEltsChoice.EG_ContentBlockContent = append(ble.BlockLevelEltsChoice.EG_ContentBlockContent, cbc)
+		rest := append([]*wml.EG_BlockLevelElts{ble}, bles[ls.ble+1:]...)
+		*fs.bles = append(bles[:fs.ble], rest...)
+		return nil
+	}
+
+	cbcs := *fs.cbcs
+	covered := r.d.blockParagraphs(cbcs[fs.cbc : ls.cbc+1])
+	if covered[0]._cebfg != r.start._cebfg || covered[len(covered)-1]._cebfg != r.end._cebfg {
+		return errors.New("range paragraphs share block content with other paragraphs")
+	}
+	sdt.SdtContent.EG_ContentBlockContent = append(sdt.SdtContent.EG_ContentBlockContent, cbcs[fs.cbc:ls.cbc+1]...)
+	rest := append([]*wml.EG_ContentBlockContent{cbc}, cbcs[ls.cbc+1:]...)
+	*fs.cbcs = append(cbcs[:fs.cbc], rest...)
+	return nil
+}
+
+func (r *Range) wrapInRunContentControl(tag, title string) error {
+	runs := r.Runs()
+	if len(runs) == 0 {
+		return errors.New("empty range")
+	}
+	p := r.start
+	c, i, _ := p.runContainer(runs[0]._bbdb)
+	lc, j, _ := p.runContainer(runs[len(runs)-1]._bbdb)
+	if c == nil || c != lc {
+		return errors.New("range partially covers a hyperlink or content control")
+	}
+
+	sdt := wml.NewCT_SdtRun()
+	sdt.SdtPr = newSdtPr(tag, title)
+	sdt.SdtContent = wml.NewCT_SdtContentRun()
+	pc := wml.NewEG_PContent()
+	pc.PContentChoice.EG_ContentRunContent = append(pc.PContentChoice.EG_ContentRunContent, (*c)[i:j+1]...)
+	sdt.SdtContent.EG_PContent = append(sdt.SdtContent.EG_PContent, pc)
+	crc := wml.NewEG_ContentRunContent()
+	crc.ContentRunContentChoice.Sdt = sdt
+	rest := append([]*wml.EG_ContentRunContent{crc}, (*c)[j+1:]...)
+	*c = append((*c)[:i], rest...)
+	return nil
+}
+
+func newSdtPr(tag, title string) *wml.CT_SdtPr {
+	pr := wml.NewCT_SdtPr()
+	if tag != "" {
+		pr.Tag = wml.NewCT_String()
+		pr.Tag.ValAttr = tag
+	}
+	if title != "" {
+		pr.Alias = wml.NewCT_String()
+		pr.Alias.ValAttr = title
+	}
+	return pr
+}
+
+// mark inserts run content at the start and end of the range.
+func (r *Range) mark(start, end []*wml.EG_ContentRunContent) {
+	if r.IsCollapsed() {
+		r.start.insertRunContent(r.startOffset, false, append(start, end...))
+		return
+	}
+	r.end.insertRunContent(r.endOffset, true, end)
+	r.start.insertRunContent(r.startOffset, false, start)
+}
+
+func newRangeMarkup(rme *wml.EG_RangeMarkupElements) []*wml.EG_ContentRunContent {
+	rle := wml.NewEG_RunLevelElts()
+	rle.RunLevelEltsChoice.EG_RangeMarkupElements = append(rle.RunLevelEltsChoice.EG_RangeMarkupElements, rme)
+	crc := wml.NewEG_ContentRunContent()
+	crc.ContentRunContentChoice.EG_RunLevelElts = append(crc.ContentRunContentChoice.EG_RunLevelElts, rle)
+	return []*wml.EG_ContentRunContent{crc}
+}
+
+func paragraphText(p Paragraph) string {
+	sb := strings.Builder{}
+	for _, r := range p.Runs() {
+		sb.WriteString(r.Text())
+	}
+	return sb.String()
+}
+
+func paragraphTextLen(p Paragraph) int {
+	n := 0
+	for _, r := range p.Runs() {
+		n += r.textLen()
+	}
+	return n
+}
+
+// offsetOf returns the character offset of a position in the paragraph text.
+func (p Paragraph) offsetOf(pos TextPosition) int {
+	off := 0
+	for _, r := range p.Runs() {
+		if r._bbdb == pos.Run._bbdb {
+			return off + pos.Offset
+		}
+		off += r.textLen()
+	}
+	return off
+}
+
+// splitAt splits the run holding the character offset so that a run starts
+// at the offset.
+func (p Paragraph) splitAt(off int) {
+	pos := 0
+	for _, r := range p.Runs() {
+		n := r.textLen()
+		if off > pos && off < pos+n {
+			p.splitRun(r, off-pos)
+			return
+		}
+		pos += n
+	}
+}
+
+// runsBetween splits the runs at the character offsets and returns the runs
+// between them, including runs without text such as drawings that lie
+// strictly inside.
+func (p Paragraph) runsBetween(a, b int) []Run {
+	if a >= b {
+		return nil
+	}
+	p.splitAt(b)
+	p.splitAt(a)
+	ret := []Run{}
+	pos := 0
+	for _, r := range p.Runs() {
+		n := r.textLen()
+		if n > 0 && pos >= a && pos+n <= b || n == 0 && pos > a && pos < b {
+			ret = append(ret, r)
+		}
+		pos += n
+	}
+	return ret
+}
+
+func (p Paragraph) removeRuns(runs []Run) {
+	for _, r := range runs {
+		if c, i, _ := p.runContainer(r._bbdb); c != nil {
+			*c = append((*c)[:i], (*c)[i+1:]...)
+		}
+	}
+}
+
+// insertionPoint splits the runs at the character offset and returns the
+// position in a run content container just after the run ending at the offset
+// if after is set, or just before the run starting at it otherwise, along with
+// that run. A nil container means the paragraph has no text.
+func (p Paragraph) insertionPoint(off int, after bool) (*[]*wml.EG_ContentRunContent, int, *wml.CT_R) {
+	p.splitAt(off)
+	var prev, next *wml.CT_R
+	pos := 0
+	for _, r := range p.Runs() {
+		n := r.textLen()
+		if n > 0 && pos+n <= off {
+			prev = r._bbdb
+		}
+		if n > 0 && pos >= off && next == nil {
+			next = r._bbdb
+		}
+		pos += n
+	}
+	ref, delta := next, 0
+	if after && prev != nil || next == nil {
+		ref, delta = prev, 1
+	}
+	if ref == nil {
+		return nil, 0, nil
+	}
+	c, i, _ := p.runContainer(ref)
+	return c, i + delta, ref
+}
+
+func (p Paragraph) insertRunContent(off int, after bool, crcs []*wml.EG_ContentRunContent) {
+	c, i, _ := p.insertionPoint(off, after)
+	if c == nil {
+		pc := wml.NewEG_PContent()
+		pc.PContentChoice.EG_ContentRunContent = crcs
+		p._cebfg.EG_PContent = append(p._cebfg.EG_PContent, pc)
+		return
+	}
+	*c = append((*c)[:i], append(crcs, (*c)[i:]...)...)
+}
+
+// insertRunAt inserts a run with the text at the character offset, taking the
+// properties of the neighbouring run.
+func (p Paragraph) insertRunAt(off int, after bool, text string) Run {
+	c, i, ref := p.insertionPoint(off, after)
+	if c == nil {
+		run := p.AddRun()
+		run.AddText(text)
+		return run
+	}
+	run := Run{p._adga, wml.NewCT_R()}
+	if ref.RPr != nil {
+		run.SetProperties(RunProperties{ref.RPr})
+	}
+	run.AddText(text)
+	crc := wml.NewEG_ContentRunContent()
+	crc.ContentRunContentChoice.R = run._bbdb
+	*c = append((*c)[:i], append([]*wml.EG_ContentRunContent{crc}, (*c)[i:]...)...)
+	return run
+}
+
+// stories returns the block level content of the parts of the document that
+// hold paragraphs, including text boxes.
+func (d *Document) stories() []*[]*wml.EG_BlockLevelElts {
+	ret := []*[]*wml.EG_BlockLevelElts{}
+	if d.X().Body != nil {
+		ret = append(ret, &d.X().Body.EG_BlockLevelElts)
+	}
+	for _, h := range d.Headers() {
+		ret = append(ret, &h.X().EG_BlockLevelElts)
+	}
+	for _, f := range d.Footers() {
+		ret = append(ret, &f.X().EG_BlockLevelElts)
+	}
+	if d._gbd != nil {
+		for _, f := range d.Footnotes() {
+			ret = append(ret, &f.X().EG_BlockLevelElts)
+		}
+	}
+	if d._bdcb != nil {
+		for _, e := range d.Endnotes() {
+			ret = append(ret, &e.X().EG_BlockLevelElts)
+		}
+	}
+	for _, c := range d.Comments() {
+		ret = append(ret, &c.X().EG_BlockLevelElts)
+	}
+	// text boxes are appended to the list and searched for nested text boxes
+	// in turn
+	for i := 0; i < len(ret); i++ {
+		for _, p := range d.storyParagraphs(*ret[i]) {
+			for _, r := range p.Runs() {
+				shapes := r.Shapes()
+				for _, g := range r.ShapeGroups() {
+					shapes = append(shapes, g.AllShapes()...)
+				}
+				for _, s := range shapes {
+					if tc := s.txbxContent(false); tc != nil {
+						ret = append(ret, &tc.EG_BlockLevelElts)
+					}
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// blockSlot is the location of a paragraph in block level content.
+type blockSlot struct {
+	// bles and ble locate the block level element holding the content, bles
+	// is nil for the content of a block content control
+	bles *[]*wml.EG_BlockLevelElts
+	ble  int
+	cbcs *[]*wml.EG_ContentBlockContent
+	cbc  int
+}
+
+// sameContainer returns true if the slots are in the same list of block level
+// content, e.g. the same table cell.
+func (s blockSlot) sameContainer(o blockSlot) bool {
+	if s.bles != nil || o.bles != nil {
+		return s.bles == o.bles
+	}
+	return s.cbcs == o.cbcs
+}
+
+// remove removes the paragraph from the slot, along with the block content
+// and block level element holding it if they become empty.
+func (s blockSlot) remove(p *wml.CT_P) {
+	cbc := (*s.cbcs)[s.cbc]
+	ps := cbc.ContentBlockContentChoice.P
+	for i, cp := range ps {
+		if cp == p {
+			cbc.ContentBlockContentChoice.P = append(ps[:i], ps[i+1:]...)
+			break
+		}
+	}
+	c := cbc.ContentBlockContentChoice
+	if len(c.P) > 0 || len(c.Tbl) > 0 || c.Sdt != nil {
+		return
+	}
+	*s.cbcs = append((*s.cbcs)[:s.cbc], (*s.cbcs)[s.cbc+1:]...)
+	if len(*s.cbcs) == 0 && s.bles != nil {
+		*s.bles = append((*s.bles)[:s.ble], (*s.bles)[s.ble+1:]...)
+	}
+}
+
+// locateParagraph returns the location of the paragraph in the document.
+func (d *Document) locateParagraph(p *wml.CT_P) (blockSlot, bool) {
+	for _, bles := range d.stories() {
+		if s, ok := locateInBlocks(bles, p); ok {
+			return s, true
+		}
+	}
+	return blockSlot{}, false
+}
+
+func locateInBlocks(bles *[]*wml.EG_BlockLevelElts, p *wml.CT_P) (blockSlot, bool) {
+	for i, ble := range *bles {
+		cbcs := &ble.BlockLevelEltsChoice.EG_ContentBlockContent
+		if s, ok := locateInContent(cbcs, p); ok {
+			if s.bles == nil && s.cbcs == cbcs {
+				s.bles, s.ble = bles, i
+			}
+			return s, true
+		}
+	}
+	return blockSlot{}, false
+}
+
+func locateInContent(cbcs *[]*wml.EG_ContentBlockContent, p *wml.CT_P) (blockSlot, bool) {
+	for i, cbc := range *cbcs {
+		for _, cp := range cbc.ContentBlockContentChoice.P {
+			if cp == p {
+				return blockSlot{cbcs: cbcs, cbc: i}, true
+			}
+		}
+		for _, tbl := range cbc.ContentBlockContentChoice.Tbl {
+			for _, crc := range tbl.EG_ContentRowContent {
+				for _, tr := range crc.ContentRowContentChoice.Tr {
+					for _, ccc := range tr.EG_ContentCellContent {
+						for _, tc := range ccc.ContentCellContentChoice.Tc {
+							if s, ok := locateInBlocks(&tc.EG_BlockLevelElts, p); ok {
+								return s, true
+							}
+						}
+					}
+				}
+			}
+		}
+		if sdt := cbc.ContentBlockContentChoice.Sdt; sdt != nil && sdt.SdtContent != nil {
+			if s, ok := locateInContent(&sdt.SdtContent.EG_ContentBlockContent, p); ok {
+				return s, true
+			}
+		}
+	}
+	return blockSlot{}, false
+}
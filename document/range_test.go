@@ -0,0 +1,320 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// bodyTexts returns the text of the body paragraphs in document order,
+// including those in tables.
+func bodyTexts(d *Document) []string {
+	ret := []string{}
+	for _, p := range d.paragraphsInOrder() {
+		ret = append(ret, paragraphText(p))
+	}
+	return ret
+}
+
+// runTexts returns the text of the runs of the paragraph.
+func runTexts(p Paragraph) []string {
+	ret := []string{}
+	for _, r := range p.Runs() {
+		ret = append(ret, r.Text())
+	}
+	return ret
+}
+
+func TestNewRange(t *testing.T) {
+	d := New()
+	addRuns(d, "Hello ", "brave")
+	addRuns(d, "new")
+	addRuns(d, "world")
+	d.AddHeader().AddParagraph().AddRun().AddText("header")
+
+	r, err := d.RangeAt(0, 6, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Text(); got != "brave\nnew\nwor" {
+		t.Errorf("range text = %q", got)
+	}
+	if n := len(r.Paragraphs()); n != 3 {
+		t.Errorf("range has %d paragraphs, want 3", n)
+	}
+	got := []string{}
+	for _, run := range r.Runs() {
+		got = append(got, run.Text())
+	}
+	if !reflect.DeepEqual(got, []string{"brave", "new", "wor"}) {
+		t.Errorf("range runs = %q", got)
+	}
+	if got := runTexts(d.Paragraphs()[2]); !reflect.DeepEqual(got, []string{"wor", "ld"}) {
+		t.Errorf("end runs = %q", got)
+	}
+	if p, off := r.End(); p.X() != d.Paragraphs()[2].X() || off != 3 {
+		t.Errorf("range end = %d", off)
+	}
+
+	whole := d.Paragraphs()[0].Range()
+	if whole.Text() != "Hello brave" || whole.IsCollapsed() {
+		t.Errorf("paragraph range = %q", whole.Text())
+	}
+	matches, _ := d.FindAll("rave", FindOptions{})
+	if got := matches[0].Range().Text(); got != "rave" {
+		t.Errorf("match range = %q", got)
+	}
+
+	header := d.Headers()[0].Paragraphs()[0]
+	errs := []struct {
+		name string
+		err  error
+	}{
+		{"end before start", func() error { _, err := d.RangeAt(2, 0, 0, 0); return err }()},
+		{"reversed offsets", func() error { _, err := d.RangeAt(1, 2, 1, 1); return err }()},
+		{"start offset", func() error { _, err := d.RangeAt(0, 12, 1, 0); return err }()},
+		{"end offset", func() error { _, err := d.RangeAt(0, 0, 1, 4); return err }()},
+		{"paragraph", func() error { _, err := d.RangeAt(0, 0, 3, 0); return err }()},
+		{"story", func() error { _, err := d.NewRange(d.Paragraphs()[0], 0, header, 1); return err }()},
+	}
+	for _, e := range errs {
+		if e.err == nil {
+			t.Errorf("no error for %s", e.name)
+		}
+	}
+	if _, err := d.NewRange(header, 1, header, 4); err != nil {
+		t.Errorf("range in header: %s", err)
+	}
+}
+
+func TestRangeDelete(t *testing.T) {
+	d := New()
+	addRuns(d, "Hello ", "brave")
+	addRuns(d, "new")
+	addRuns(d, "world")
+	r, _ := d.RangeAt(0, 6, 2, 3)
+	r.Delete()
+	if got := paragraphTexts(d); !reflect.DeepEqual(got, []string{"Hello ld"}) {
+		t.Errorf("paragraphs after delete = %q", got)
+	}
+	if !r.IsCollapsed() {
+		t.Error("range not collapsed after delete")
+	}
+
+	// tables crossed by a range are cleared
+	d = New()
+	addRuns(d, "before")
+	d.AddTable().AddRow().AddCell().AddParagraph().AddRun().AddText("cell")
+	addRuns(d, "after")
+	r, _ = d.RangeAt(0, 3, 2, 3)
+	if got := r.Text(); got != "ore\ncell\naft" {
+		t.Errorf("range text = %q", got)
+	}
+	r.Delete()
+	if got := bodyTexts(d); !reflect.DeepEqual(got, []string{"befer", ""}) {
+		t.Errorf("paragraphs after delete = %q", got)
+	}
+	if n := len(d.Tables()); n != 1 {
+		t.Errorf("document has %d tables, want 1", n)
+	}
+
+	read := roundTrip(t, d)
+	if got := bodyTexts(read); !reflect.DeepEqual(got, []string{"befer", ""}) {
+		t.Errorf("read paragraphs = %q", got)
+	}
+}
+
+func TestRangeInsert(t *testing.T) {
+	d := New()
+	p := addRuns(d, "Hello ", "world")
+	p.Runs()[1].Properties().SetBold(true)
+	r, _ := d.RangeAt(0, 6, 0, 11)
+	r.InsertBefore("big ")
+	r.InsertAfter("!")
+	if got := r.Text(); got != "big world!" {
+		t.Errorf("range text = %q", got)
+	}
+	if got := runTexts(p); !reflect.DeepEqual(got, []string{"Hello ", "big ", "world", "!"}) {
+		t.Errorf("runs = %q", got)
+	}
+	for i, run := range p.Runs() {
+		if run.Properties().IsBold() != (i > 0) {
+			t.Errorf("run %q doesn't take the properties of its neighbour", run.Text())
+		}
+	}
+
+	// a collapsed range inserts at its position
+	c, _ := d.RangeAt(0, 0, 0, 0)
+	c.InsertAfter("Oh, ")
+	if got := paragraphText(p); got != "Oh, Hello big world!" {
+		t.Errorf("paragraph = %q", got)
+	}
+	if c.Text() != "Oh, " {
+		t.Errorf("collapsed range text = %q", c.Text())
+	}
+
+	// an empty paragraph gets its first run
+	e := d.AddParagraph().Range()
+	e.InsertBefore("first")
+	if got := paragraphTexts(d); !reflect.DeepEqual(got, []string{"Oh, Hello big world!", "first"}) {
+		t.Errorf("paragraphs = %q", got)
+	}
+}
+
+func TestRangeFormatting(t *testing.T) {
+	d := New()
+	p := addRuns(d, "Hello brave new world")
+	d.Styles.AddStyle("Quote", wml.ST_StyleTypeParagraph, false)
+	d.Styles.AddStyle("Emphasis", wml.ST_StyleTypeCharacter, false)
+	d.Styles.AddStyle("Grid", wml.ST_StyleTypeTable, false)
+
+	r, _ := d.RangeAt(0, 6, 0, 11)
+	r.ApplyRunProperties(func(rp RunProperties) { rp.SetItalic(true) })
+	if err := r.ApplyStyle("Emphasis"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Range().ApplyStyle("Quote"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.ApplyStyle("Grid"); err == nil {
+		t.Error("applied a table style to a range")
+	}
+	if err := r.ApplyStyle("Missing"); err == nil {
+		t.Error("applied a missing style")
+	}
+	runs := p.Runs()
+	if got := runTexts(p); !reflect.DeepEqual(got, []string{"Hello ", "brave", " new world"}) {
+		t.Fatalf("runs = %q", got)
+	}
+	if !runs[1].Properties().IsItalic() || runs[0].Properties().IsItalic() || runs[2].Properties().IsItalic() {
+		t.Error("only the range should be italic")
+	}
+	if got := runs[1].Properties().X().RStyle; got == nil || got.ValAttr != "Emphasis" {
+		t.Error("character style not applied to the range")
+	}
+	if p.Style() != "Quote" {
+		t.Errorf("paragraph style = %q", p.Style())
+	}
+
+	read := roundTrip(t, d)
+	rp := read.Paragraphs()[0]
+	if rp.Style() != "Quote" || !rp.Runs()[1].Properties().IsItalic() {
+		t.Error("formatting lost in round trip")
+	}
+}
+
+func TestRangeMarkup(t *testing.T) {
+	d := New()
+	p := addRuns(d, "Hello brave new world")
+	r, _ := d.RangeAt(0, 6, 0, 11)
+	bm := r.WrapInBookmark("brave")
+	if bm.Name() != "brave" {
+		t.Errorf("bookmark name = %s", bm.Name())
+	}
+	r2, _ := d.RangeAt(0, 12, 0, 15)
+	c := r2.AddComment("Ann", "really?")
+	if c.X().AuthorAttr != "Ann" || len(d.Comments()) != 1 {
+		t.Errorf("comment by %q", c.X().AuthorAttr)
+	}
+
+	// describe the markup as [name text] for bookmarks and (text) for
+	// comment ranges
+	markup := func(p Paragraph) string {
+		sb := strings.Builder{}
+		for _, pc := range p.X().EG_PContent {
+			for _, crc := range pc.PContentChoice.EG_ContentRunContent {
+				if r := crc.ContentRunContentChoice.R; r != nil {
+					sb.WriteString(Run{d, r}.Text())
+				}
+				for _, rle := range crc.ContentRunContentChoice.EG_RunLevelElts {
+					for _, rme := range rle.RunLevelEltsChoice.EG_RangeMarkupElements {
+						switch c := rme.RangeMarkupElementsChoice; {
+						case c.BookmarkStart != nil:
+							sb.WriteString("[" + c.BookmarkStart.NameAttr + " ")
+						case c.BookmarkEnd != nil:
+							sb.WriteString("]")
+						case c.CommentRangeStart != nil:
+							sb.WriteString("(")
+						case c.CommentRangeEnd != nil:
+							sb.WriteString(")")
+						}
+					}
+				}
+			}
+		}
+		return sb.String()
+	}
+	if got := markup(p); got != "Hello [brave brave] (new) world" {
+		t.Errorf("markup = %q", got)
+	}
+	read := roundTrip(t, d)
+	if got := markup(read.Paragraphs()[0]); got != "Hello [brave brave] (new) world" {
+		t.Errorf("read markup = %q", got)
+	}
+	if len(read.Comments()) != 1 {
+		t.Error("comment lost in round trip")
+	}
+}
+
+func TestRangeContentControl(t *testing.T) {
+	d := New()
+	p := addRuns(d, "Hello brave world")
+	r, _ := d.RangeAt(0, 0, 0, 11)
+	if err := r.WrapInContentControl("greeting", "Greeting"); err != nil {
+		t.Fatal(err)
+	}
+	crcs := p.X().EG_PContent[0].PContentChoice.EG_ContentRunContent
+	if len(crcs) != 2 || crcs[0].ContentRunContentChoice.Sdt == nil {
+		t.Fatalf("paragraph content = %d parts", len(crcs))
+	}
+	sdt := crcs[0].ContentRunContentChoice.Sdt
+	if sdt.SdtPr.Tag.ValAttr != "greeting" || sdt.SdtPr.Alias.ValAttr != "Greeting" {
+		t.Error("content control properties not set")
+	}
+	if got := paragraphText(p); got != "Hello brave world" {
+		t.Errorf("paragraph = %q", got)
+	}
+
+	addRuns(d, "second")
+	addRuns(d, "third")
+	d.AddTable().AddRow().AddCell().AddParagraph().AddRun().AddText("cell")
+	r, _ = d.RangeAt(1, 2, 2, 1)
+	if err := r.WrapInContentControl("block", ""); err != nil {
+		t.Fatal(err)
+	}
+	bles := d.X().Body.EG_BlockLevelElts
+	if len(bles) != 3 {
+		t.Fatalf("body has %d block elements, want 3", len(bles))
+	}
+	block := bles[1].BlockLevelEltsChoice.EG_ContentBlockContent[0].ContentBlockContentChoice.Sdt
+	if block == nil || len(block.SdtContent.EG_ContentBlockContent) != 2 || block.SdtPr.Alias != nil {
+		t.Error("paragraphs not wrapped in a block content control")
+	}
+	if got := bodyTexts(d); !reflect.DeepEqual(got, []string{"Hello brave world", "second", "third", "cell"}) {
+		t.Errorf("paragraphs = %q", got)
+	}
+
+	r, _ = d.RangeAt(0, 0, 3, 2)
+	if err := r.WrapInContentControl("cross", ""); err == nil {
+		t.Error("wrapped paragraphs of different containers")
+	}
+	if err := d.Paragraphs()[0].Range().WrapInContentControl("partial", ""); err == nil {
+		t.Error("wrapped a range partially covering a content control")
+	}
+
+	read := roundTrip(t, d)
+	if got := bodyTexts(read); !reflect.DeepEqual(got, []string{"Hello brave world", "second", "third", "cell"}) {
+		t.Errorf("read paragraphs = %q", got)
+	}
+}
@@ -0,0 +1,575 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/color"
+	"github.com/unidoc/unioffice/v2/drawing"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+const (
+	wpsGraphicDataURI = "http://schemas.microsoft.com/office/word/2010/wordprocessingShape"
+	wpgGraphicDataURI = "http://schemas.microsoft.com/office/word/2010/wordprocessingGroup"
+)
+
+// Shape is a DrawingML shape (wps:wsp) placed in a document. A shape with a
+// text box holds paragraphs and tables like the document body.
+type Shape struct {
+	d *Document
+	x *wml.WdWsp
+}
+
+// X returns the inner wrapped XML type.
+func (s Shape) X() *wml.WdWsp { return s.x }
+
+// Properties returns the shape properties that control geometry, fill and
+// outline.
+func (s Shape) Properties() drawing.ShapeProperties {
+	if s.x.SpPr == nil {
+		s.x.SpPr = dml.NewCT_ShapeProperties()
+	}
+	return drawing.MakeShapeProperties(s.x.SpPr)
+}
+
+// Geometry returns the preset geometry of the shape, or ST_ShapeTypeUnset if
+// the shape uses custom geometry.
+func (s Shape) Geometry() dml.ST_ShapeType {
+	if s.x.SpPr == nil || s.x.SpPr.GeometryChoice.PrstGeom == nil {
+		return dml.ST_ShapeTypeUnset
+	}
+	return s.x.SpPr.GeometryChoice.PrstGeom.PrstAttr
+}
+
+// SetGeometry sets the preset geometry of the shape.
+func (s Shape) SetGeometry(g dml.ST_ShapeType) { s.Properties().SetGeometry(g) }
+
+// SetSolidFill sets the shape fill to a solid color.
+func (s Shape) SetSolidFill(c color.Color) { s.Properties().SetSolidFill(c) }
+
+// SetNoFill removes the fill of the shape.
+func (s Shape) SetNoFill() { s.Properties().SetNoFill() }
+
+// SetOutline sets the outline of the shape to a solid color and width.
+func (s Shape) SetOutline(c color.Color, width measurement.Distance) {
+	lp := s.Properties().LineProperties()
+	lp.SetSolidFill(c)
+	lp.SetWidth(width)
+}
+
+// SetNoOutline removes the outline of the shape.
+func (s Shape) SetNoOutline() { s.Properties().LineProperties().SetNoFill() }
+
+// SetName sets the name of the shape.
+func (s Shape) SetName(name string) {
+	s.ensureNvPr()
+	s.x.CNvPr.NameAttr = name
+}
+
+// Name returns the name of the shape.
+func (s Shape) Name() string {
+	if s.x.CNvPr == nil {
+		return ""
+	}
+	return s.x.CNvPr.NameAttr
+}
+
+// SetTextAnchor controls the vertical alignment of the shape text.
+func (s Shape) SetTextAnchor(a dml.ST_TextAnchoringType) {
+	s.ensureBodyPr()
+	s.x.BodyPr.AnchorAttr = a
+}
+
+// SetTextInsets sets the distance between the shape outline and its text.
+func (s Shape) SetTextInsets(left, top, right, bottom measurement.Distance) {
+	s.ensureBodyPr()
+	s.x.BodyPr.LInsAttr = unioffice.Int32(int32(left / measurement.EMU))
+	s.x.BodyPr.TInsAttr = unioffice.Int32(int32(top / measurement.EMU))
+	s.x.BodyPr.RInsAttr = unioffice.Int32(int32(right / measurement.EMU))
+	s.x.BodyPr.BInsAttr = unioffice.Int32(int32(bottom / measurement.EMU))
+}
+
+// SetAutoFit resizes the shape to fit its text if fit is true.
+func (s Shape) SetAutoFit(fit bool) {
+	s.ensureBodyPr()
+	s.x.BodyPr.TextAutofitChoice = dml.NewEG_TextAutofitChoice()
+	if fit {
+		s.x.BodyPr.TextAutofitChoice.SpAutoFit = dml.NewCT_TextShapeAutofit()
+	} else {
+		s.x.BodyPr.TextAutofitChoice.NoAutofit = dml.NewCT_TextNoAutofit()
+	}
+}
+
+// HasText returns true if the shape has a text box.
+func (s Shape) HasText() bool { return s.txbxContent(false) != nil }
+
+// AddParagraph adds a paragraph to the text box of the shape, creating the
+// text box if necessary.
+func (s Shape) AddParagraph() Paragraph {
+	cbc := wml.NewEG_ContentBlockContent()
+	tc := s.txbxContent(true)
+	tc.EG_BlockLevelElts = append(tc.EG_BlockLevelElts, &wml.EG_BlockLevelElts{BlockLevelEltsChoice: &wml.EG_BlockLevelEltsChoice{EG_ContentBlockContent: []*wml.EG_ContentBlockContent{cbc}}})
+	p := wml.NewCT_P()
+	cbc.ContentBlockContentChoice.P = append(cbc.ContentBlockContentChoice.P, p)
+	return Paragraph{s.d, p}
+}
+
+// AddTable adds a table to the text box of the shape, creating the text box
+// if necessary.
+func (s Shape) AddTable() Table {
+	cbc := wml.NewEG_ContentBlockContent()
+	tc := s.txbxContent(true)
+	tc.EG_BlockLevelElts = append(tc.EG_BlockLevelElts, &wml.EG_BlockLevelElts{BlockLevelEltsChoice: &wml.EG_BlockLevelEltsChoice{EG_ContentBlockContent: []*wml.EG_ContentBlockContent{cbc}}})
+	tbl := wml.NewCT_Tbl()
+	cbc.ContentBlockContentChoice.Tbl = append(cbc.ContentBlockContentChoice.Tbl, tbl)
+	return Table{s.d, tbl}
+}
+
+// Paragraphs returns the top level paragraphs of the shape text box.
+func (s Shape) Paragraphs() []Paragraph {
+	ret := []Paragraph{}
+	if tc := s.txbxContent(false); tc != nil {
+		for _, ble := range tc.EG_BlockLevelElts {
+			for _, cbc := range ble.BlockLevelEltsChoice.EG_ContentBlockContent {
+				for _, p := range cbc.ContentBlockContentChoice.P {
+					ret = append(ret, Paragraph{s.d, p})
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// Tables returns the tables of the shape text box.
+func (s Shape) Tables() []Table {
+	ret := []Table{}
+	if tc := s.txbxContent(false); tc != nil {
+		for _, ble := range tc.EG_BlockLevelElts {
+			for _, cbc := range ble.BlockLevelEltsChoice.EG_ContentBlockContent {
+				for _, tbl := range cbc.ContentBlockContentChoice.Tbl {
+					ret = append(ret, Table{s.d, tbl})
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// Text returns the text of the shape, one line per paragraph including the
+// paragraphs of tables.
+func (s Shape) Text() string {
+	tc := s.txbxContent(false)
+	if tc == nil {
+		return ""
+	}
+	lines := []string{}
+	for _, ble := range tc.EG_BlockLevelElts {
+		for _, p := range s.d.blockParagraphs(ble.BlockLevelEltsChoice.EG_ContentBlockContent) {
+			sb := strings.Builder{}
+			for _, r := range p.Runs() {
+				sb.WriteString(r.Text())
+			}
+			lines = append(lines, sb.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s Shape) ensureNvPr() {
+	if s.x.CNvPr == nil {
+		s.x.CNvPr = dml.NewCT_NonVisualDrawingProps()
+	}
+}
+
+func (s Shape) ensureBodyPr() {
+	if s.x.BodyPr == nil {
+		s.x.BodyPr = dml.NewCT_TextBodyProperties()
+	}
+}
+
+func (s Shape) txbxContent(create bool) *wml.CT_TxbxContent {
+	if c := s.x.WordprocessingShapeChoice1; c != nil && c.Txbx != nil && c.Txbx.TxbxContent != nil {
+		return c.Txbx.TxbxContent
+	}
+	if !create {
+		return nil
+	}
+	s.x.WordprocessingShapeChoice1 = wml.NewWdCT_WordprocessingShapeChoice1()
+	s.x.WordprocessingShapeChoice1.Txbx = wml.NewWdCT_TextboxInfo()
+	s.x.WordprocessingShapeChoice1.Txbx.TxbxContent = wml.NewCT_TxbxContent()
+	return s.x.WordprocessingShapeChoice1.Txbx.TxbxContent
+}
+
+// ShapeGroup is a group of shapes (wpg:wgp) that are positioned and sized
+// together.
+type ShapeGroup struct {
+	d *Document
+	x *wml.WdCT_WordprocessingGroup
+}
+
+// X returns the inner wrapped XML type.
+func (g ShapeGroup) X() *wml.WdCT_WordprocessingGroup { return g.x }
+
+// AddShape adds a shape to the group at the given offset and size relative to
+// the group.
+func (g ShapeGroup) AddShape(geom dml.ST_ShapeType, x, y, w, h measurement.Distance) Shape {
+	s := newShape(g.d, geom, w, h)
+	s.Properties().SetPosition(x, y)
+	gc := wml.NewWdCT_WordprocessingGroupChoice()
+	gc.Wsp = s.x
+	g.x.WordprocessingGroupChoice = append(g.x.WordprocessingGroupChoice, gc)
+	return s
+}
+
+// AddTextBox adds a rectangular text box to the group at the given offset and
+// size relative to the group.
+func (g ShapeGroup) AddTextBox(x, y, w, h measurement.Distance) Shape {
+	s := g.AddShape(dml.ST_ShapeTypeRect, x, y, w, h)
+	makeTextBox(s)
+	return s
+}
+
+// AddGroup adds a nested group to the group at the given offset and size.
+func (g ShapeGroup) AddGroup(x, y, w, h measurement.Distance) ShapeGroup {
+	ng := newShapeGroup(g.d, wml.NewWdCT_WordprocessingGroup(), w, h)
+	ng.x.GrpSpPr.Xfrm.Off.XAttr.ST_CoordinateUnqualified = unioffice.Int64(int64(x / measurement.EMU))
+	ng.x.GrpSpPr.Xfrm.Off.YAttr.ST_CoordinateUnqualified = unioffice.Int64(int64(y / measurement.EMU))
+	ng.x.GrpSpPr.Xfrm.ChOff.XAttr.ST_CoordinateUnqualified = unioffice.Int64(int64(x / measurement.EMU))
+	ng.x.GrpSpPr.Xfrm.ChOff.YAttr.ST_CoordinateUnqualified = unioffice.Int64(int64(y / measurement.EMU))
+	gc := wml.NewWdCT_WordprocessingGroupChoice()
+	gc.GrpSp = ng.x
+	g.x.WordprocessingGroupChoice = append(g.x.WordprocessingGroupChoice, gc)
+	return ng
+}
+
+// Shapes returns the shapes of the group, not including those of nested
+// groups.
+func (g ShapeGroup) Shapes() []Shape {
+	ret := []Shape{}
+	for _, gc := range g.x.WordprocessingGroupChoice {
+		if gc.Wsp != nil {
+			ret = append(ret, Shape{g.d, gc.Wsp})
+		}
+	}
+	return ret
+}
+
+// Groups returns the groups nested in the group.
+func (g ShapeGroup) Groups() []ShapeGroup {
+	ret := []ShapeGroup{}
+	for _, gc := range g.x.WordprocessingGroupChoice {
+		if gc.GrpSp != nil {
+			ret = append(ret, ShapeGroup{g.d, gc.GrpSp})
+		}
+	}
+	return ret
+}
+
+// AllShapes returns the shapes of the group and its nested groups.
+func (g ShapeGroup) AllShapes() []Shape {
+	ret := g.Shapes()
+	for _, ng := range g.Groups() {
+		ret = append(ret, ng.AllShapes()...)
+	}
+	return ret
+}
+
+// AddShapeAnchored adds an anchored shape with the given preset geometry and
+// size to the run. Wrapping and positioning are controlled with the returned
+// AnchoredDrawing and the shape itself with AnchoredDrawing.Shape.
+func (r Run) AddShapeAnchored(geom dml.ST_ShapeType, w, h measurement.Distance) AnchoredDrawing {
+	s := newShape(r._gdedf, geom, w, h)
+	s.x.CNvPr = nil
+	return r.addShapeAnchor(wpsGraphicDataURI, s.x, w, h)
+}
+
+// AddShapeInline adds an inline shape with the given preset geometry and size
+// to the run.
+func (r Run) AddShapeInline(geom dml.ST_ShapeType, w, h measurement.Distance) InlineDrawing {
+	s := newShape(r._gdedf, geom, w, h)
+	s.x.CNvPr = nil
+	return r.addShapeInline(wpsGraphicDataURI, s.x, w, h)
+}
+
+// AddTextBoxAnchored adds an anchored text box of the given size to the run.
+// Content is added with AnchoredDrawing.Shape().AddParagraph.
+func (r Run) AddTextBoxAnchored(w, h measurement.Distance) AnchoredDrawing {
+	ad := r.AddShapeAnchored(dml.ST_ShapeTypeRect, w, h)
+	s, _ := ad.Shape()
+	makeTextBox(s)
+	return ad
+}
+
+// AddTextBoxInline adds an inline text box of the given size to the run.
+func (r Run) AddTextBoxInline(w, h measurement.Distance) InlineDrawing {
+	id := r.AddShapeInline(dml.ST_ShapeTypeRect, w, h)
+	s, _ := id.Shape()
+	makeTextBox(s)
+	return id
+}
+
+// AddGroupAnchored adds an anchored, empty shape group of the given size to
+// the run.
+func (r Run) AddGroupAnchored(w, h measurement.Distance) AnchoredDrawing {
+	g := newShapeGroup(r._gdedf, wml.NewWdCT_WordprocessingGroup(), w, h)
+	return r.addShapeAnchor(wpgGraphicDataURI, &wml.WdWgp{WdCT_WordprocessingGroup: *g.x}, w, h)
+}
+
+// AddGroupInline adds an inline, empty shape group of the given size to the
+// run.
+func (r Run) AddGroupInline(w, h measurement.Distance) InlineDrawing {
+	g := newShapeGroup(r._gdedf, wml.NewWdCT_WordprocessingGroup(), w, h)
+	return r.addShapeInline(wpgGraphicDataURI, &wml.WdWgp{WdCT_WordprocessingGroup: *g.x}, w, h)
+}
+
+// Shapes returns the top level shapes of the drawings in the run. Shapes that
+// are part of a group are returned by ShapeGroup.Shapes.
+func (r Run) Shapes() []Shape {
+	ret := []Shape{}
+	for _, gd := range r.shapeGraphicData() {
+		for _, a := range gd.Any {
+			if wsp, ok := a.(*wml.WdWsp); ok {
+				ret = append(ret, Shape{r._gdedf, wsp})
+			}
+		}
+	}
+	return ret
+}
+
+// ShapeGroups returns the shape groups of the drawings in the run.
+func (r Run) ShapeGroups() []ShapeGroup {
+	ret := []ShapeGroup{}
+	for _, gd := range r.shapeGraphicData() {
+		for _, a := range gd.Any {
+			if wgp, ok := a.(*wml.WdWgp); ok {
+				ret = append(ret, ShapeGroup{r._gdedf, &wgp.WdCT_WordprocessingGroup})
+			}
+		}
+	}
+	return ret
+}
+
+// Shapes returns all of the shapes in the document body, including those in
+// shape groups, in document order.
+func (d *Document) Shapes() []Shape {
+	ret := []Shape{}
+	for _, p := range d.paragraphsInOrder() {
+		for _, r := range p.Runs() {
+			ret = append(ret, r.Shapes()...)
+			for _, g := range r.ShapeGroups() {
+				ret = append(ret, g.AllShapes()...)
+			}
+		}
+	}
+	return ret
+}
+
+// Shape returns the shape of an anchored drawing, if it holds one.
+func (a AnchoredDrawing) Shape() (Shape, bool) {
+	if a._ag.Graphic != nil && a._ag.Graphic.GraphicData != nil {
+		for _, el := range a._ag.Graphic.GraphicData.Any {
+			if wsp, ok := el.(*wml.WdWsp); ok {
+				return Shape{a._dc, wsp}, true
+			}
+		}
+	}
+	return Shape{}, false
+}
+
+// Group returns the shape group of an anchored drawing, if it holds one.
+func (a AnchoredDrawing) Group() (ShapeGroup, bool) {
+	if a._ag.Graphic != nil && a._ag.Graphic.GraphicData != nil {
+		for _, el := range a._ag.Graphic.GraphicData.Any {
+			if wgp, ok := el.(*wml.WdWgp); ok {
+				return ShapeGroup{a._dc, &wgp.WdCT_WordprocessingGroup}, true
+			}
+		}
+	}
+	return ShapeGroup{}, false
+}
+
+// Shape returns the shape of an inline drawing, if it holds one.
+func (i InlineDrawing) Shape() (Shape, bool) {
+	if i._edfce.Graphic != nil && i._edfce.Graphic.GraphicData != nil {
+		for _, a := range i._edfce.Graphic.GraphicData.Any {
+			if wsp, ok := a.(*wml.WdWsp); ok {
+				return Shape{i._affc, wsp}, true
+			}
+		}
+	}
+	return Shape{}, false
+}
+
+// Group returns the shape group of an inline drawing, if it holds one.
+func (i InlineDrawing) Group() (ShapeGroup, bool) {
+	if i._edfce.Graphic != nil && i._edfce.Graphic.GraphicData != nil {
+		for _, a := range i._edfce.Graphic.GraphicData.Any {
+			if wgp, ok := a.(*wml.WdWgp); ok {
+				return ShapeGroup{i._affc, &wgp.WdCT_WordprocessingGroup}, true
+			}
+		}
+	}
+	return ShapeGroup{}, false
+}
+
+func newShape(d *Document, geom dml.ST_ShapeType, w, h measurement.Distance) Shape {
+	s := Shape{d, wml.NewWdWsp()}
+	s.ensureNvPr()
+	s.x.CNvPr.IdAttr = 0x7FFFFFFF & rand.Uint32()
+	s.x.WordprocessingShapeChoice = wml.NewWdCT_WordprocessingShapeChoice()
+	s.x.WordprocessingShapeChoice.CNvSpPr = dml.NewCT_NonVisualDrawingShapeProps()
+	sp := s.Properties()
+	sp.SetPosition(0, 0)
+	sp.SetSize(w, h)
+	sp.SetGeometry(geom)
+	// match the default look of shapes inserted by Word
+	s.SetSolidFill(color.RGB(0x44, 0x72, 0xC4))
+	s.SetOutline(color.RGB(0x2F, 0x52, 0x8F), 1*measurement.Point)
+	s.ensureBodyPr()
+	s.x.BodyPr.AnchorAttr = dml.ST_TextAnchoringTypeCtr
+	return s
+}
+
+func makeTextBox(s Shape) {
+	s.SetSolidFill(color.White)
+	s.SetOutline(color.Black, 0.75*measurement.Point)
+	s.x.WordprocessingShapeChoice.CNvSpPr.TxBoxAttr = unioffice.Bool(true)
+	s.x.BodyPr.AnchorAttr = dml.ST_TextAnchoringTypeT
+	s.txbxContent(true)
+}
+
+func newShapeGroup(d *Document, x *wml.WdCT_WordprocessingGroup, w, h measurement.Distance) ShapeGroup {
+	x.CNvGrpSpPr = dml.NewCT_NonVisualGroupDrawingShapeProps()
+	x.GrpSpPr = dml.NewCT_GroupShapeProperties()
+	xfrm := dml.NewCT_GroupTransform2D()
+	xfrm.Off = dml.NewCT_Point2D()
+	xfrm.Off.XAttr.ST_CoordinateUnqualified = unioffice.Int64(0)
+	xfrm.Off.YAttr.ST_CoordinateUnqualified = unioffice.Int64(0)
+	xfrm.Ext = dml.NewCT_PositiveSize2D()
+	xfrm.Ext.CxAttr = int64(w / measurement.EMU)
+	xfrm.Ext.CyAttr = int64(h / measurement.EMU)
+	xfrm.ChOff = dml.NewCT_Point2D()
+	xfrm.ChOff.XAttr.ST_CoordinateUnqualified = unioffice.Int64(0)
+	xfrm.ChOff.YAttr.ST_CoordinateUnqualified = unioffice.Int64(0)
+	xfrm.ChExt = dml.NewCT_PositiveSize2D()
+	xfrm.ChExt.CxAttr = xfrm.Ext.CxAttr
+	xfrm.ChExt.CyAttr = xfrm.Ext.CyAttr
+	x.GrpSpPr.Xfrm = xfrm
+	return ShapeGroup{d, x}
+}
+
+// addShapeDrawing adds a drawing holding a shape or group to the run. Word
+// stores these in an alternate content block that requires the wps namespace.
+func (r Run) addShapeDrawing() *wml.CT_Drawing {
+	dr := wml.NewCT_Drawing()
+	acr := &wml.AlternateContentRun{Choice: &wml.AC_ChoiceRun{RequiresAttr: "wps", Drawing: dr}}
+	r._bbdb.Extra = append(r._bbdb.Extra, acr)
+	return dr
+}
+
+func (r Run) addShapeAnchor(uri string, shape interface{}, w, h measurement.Distance) AnchoredDrawing {
+	anchor := newGraphicAnchor(uri, shape, w, h)
+	dr := r.addShapeDrawing()
+	dr.DrawingChoice = append(dr.DrawingChoice, &wml.CT_DrawingChoice{Anchor: anchor})
+	return AnchoredDrawing{r._gdedf, anchor}
+}
+
+func (r Run) addShapeInline(uri string, shape interface{}, w, h measurement.Distance) InlineDrawing {
+	inline := newGraphicInline(uri, shape, w, h)
+	dr := r.addShapeDrawing()
+	dr.DrawingChoice = append(dr.DrawingChoice, &wml.CT_DrawingChoice{Inline: inline})
+	return InlineDrawing{r._gdedf, inline}
+}
+
+// newGraphicAnchor returns a floating drawing anchor of the given size that
+// holds a graphic object, positioned at the top left of the paragraph.
+func newGraphicAnchor(uri string, obj interface{}, w, h measurement.Distance) *wml.WdAnchor {
+	anchor := wml.NewWdAnchor()
+	anchor.SimplePosAttr = unioffice.Bool(false)
+	anchor.AllowOverlapAttr = true
+	anchor.LayoutInCellAttr = true
+	anchor.RelativeHeightAttr = 1
+	anchor.SimplePos.XAttr.ST_CoordinateUnqualified = unioffice.Int64(0)
+	anchor.SimplePos.YAttr.ST_CoordinateUnqualified = unioffice.Int64(0)
+	anchor.PositionH.RelativeFromAttr = wml.WdST_RelFromHColumn
+	anchor.PositionH.PosHChoice = &wml.WdCT_PosHChoice{}
+	anchor.PositionH.PosHChoice.PosOffset = unioffice.Int32(0)
+	anchor.PositionV.RelativeFromAttr = wml.WdST_RelFromVParagraph
+	anchor.PositionV.PosVChoice = &wml.WdCT_PosVChoice{}
+	anchor.PositionV.PosVChoice.PosOffset = unioffice.Int32(0)
+	anchor.Extent.CxAttr = int64(w / measurement.EMU)
+	anchor.Extent.CyAttr = int64(h / measurement.EMU)
+	anchor.WrapTypeChoice = &wml.WdEG_WrapTypeChoice{}
+	anchor.WrapTypeChoice.WrapSquare = wml.NewWdCT_WrapSquare()
+	anchor.WrapTypeChoice.WrapSquare.WrapTextAttr = wml.WdST_WrapTextBothSides
+	anchor.DocPr.IdAttr = 0x7FFFFFFF & rand.Uint32()
+	anchor.CNvGraphicFramePr = dml.NewCT_NonVisualGraphicFrameProperties()
+	anchor.Graphic = dml.NewGraphic()
+	anchor.Graphic.GraphicData = dml.NewCT_GraphicalObjectData()
+	anchor.Graphic.GraphicData.UriAttr = uri
+	anchor.Graphic.GraphicData.Any = append(anchor.Graphic.GraphicData.Any, obj)
+	return anchor
+}
+
+// newGraphicInline returns an inline drawing of the given size that holds a
+// graphic object.
+func newGraphicInline(uri string, obj interface{}, w, h measurement.Distance) *wml.WdInline {
+	inline := wml.NewWdInline()
+	inline.DistTAttr = unioffice.Uint32(0)
+	inline.DistLAttr = unioffice.Uint32(0)
+	inline.DistBAttr = unioffice.Uint32(0)
+	inline.DistRAttr = unioffice.Uint32(0)
+	inline.Extent.CxAttr = int64(w / measurement.EMU)
+	inline.Extent.CyAttr = int64(h / measurement.EMU)
+	inline.DocPr.IdAttr = 0x7FFFFFFF & rand.Uint32()
+	inline.CNvGraphicFramePr = dml.NewCT_NonVisualGraphicFrameProperties()
+	inline.Graphic = dml.NewGraphic()
+	inline.Graphic.GraphicData = dml.NewCT_GraphicalObjectData()
+	inline.Graphic.GraphicData.UriAttr = uri
+	inline.Graphic.GraphicData.Any = append(inline.Graphic.GraphicData.Any, obj)
+	return inline
+}
+
+// shapeGraphicData returns the graphic data of all of the drawings in the run,
+// whether stored directly or in an alternate content block.
+func (r Run) shapeGraphicData() []*dml.CT_GraphicalObjectData {
+	drawings := []*wml.CT_Drawing{}
+	for _, ric := range r._bbdb.EG_RunInnerContent {
+		if ric.RunInnerContentChoice.Drawing != nil {
+			drawings = append(drawings, ric.RunInnerContentChoice.Drawing)
+		}
+	}
+	for _, e := range r._bbdb.Extra {
+		if acr, ok := e.(*wml.AlternateContentRun); ok && acr.Choice != nil && acr.Choice.Drawing != nil {
+			drawings = append(drawings, acr.Choice.Drawing)
+		}
+	}
+	ret := []*dml.CT_GraphicalObjectData{}
+	for _, dr := range drawings {
+		for _, dc := range dr.DrawingChoice {
+			var g *dml.Graphic
+			if dc.Anchor != nil {
+				g = dc.Anchor.Graphic
+			} else if dc.Inline != nil {
+				g = dc.Inline.Graphic
+			}
+			if g != nil && g.GraphicData != nil {
+				ret = append(ret, g.GraphicData)
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,120 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/color"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+)
+
+func TestShapes(t *testing.T) {
+	d := New()
+	r := d.AddParagraph().AddRun()
+	ad := r.AddShapeAnchored(dml.ST_ShapeTypeEllipse, 2*measurement.Inch, 1*measurement.Inch)
+	s, ok := ad.Shape()
+	if !ok {
+		t.Fatal("anchored drawing has no shape")
+	}
+	s.SetName("Oval")
+	s.SetSolidFill(color.Red)
+	if s.HasText() {
+		t.Error("shape has a text box")
+	}
+	if _, ok := ad.Group(); ok {
+		t.Error("shape drawing has a group")
+	}
+
+	tb := d.AddParagraph().AddRun().AddTextBoxInline(3*measurement.Inch, 1*measurement.Inch)
+	box, ok := tb.Shape()
+	if !ok || !box.HasText() {
+		t.Fatal("text box has no text")
+	}
+	box.AddParagraph().AddRun().AddText("first")
+	box.AddParagraph().AddRun().AddText("second")
+	box.AddTable().AddRow().AddCell().AddParagraph().AddRun().AddText("cell")
+
+	g, ok := d.AddParagraph().AddRun().AddGroupAnchored(4*measurement.Inch, 2*measurement.Inch).Group()
+	if !ok {
+		t.Fatal("group drawing has no group")
+	}
+	g.AddShape(dml.ST_ShapeTypeRect, 0, 0, measurement.Inch, measurement.Inch)
+	nested := g.AddGroup(measurement.Inch, 0, 2*measurement.Inch, measurement.Inch)
+	nested.AddTextBox(measurement.Inch, 0, measurement.Inch, measurement.Inch).AddParagraph().AddRun().AddText("nested")
+	if n := len(g.Shapes()); n != 1 {
+		t.Errorf("group has %d shapes, want 1", n)
+	}
+	if n := len(g.AllShapes()); n != 2 {
+		t.Errorf("group and nested group have %d shapes, want 2", n)
+	}
+
+	check := func(d *Document) {
+		t.Helper()
+		shapes := d.Shapes()
+		if len(shapes) != 4 {
+			t.Fatalf("document has %d shapes, want 4", len(shapes))
+		}
+		if got := shapes[0].Geometry(); got != dml.ST_ShapeTypeEllipse {
+			t.Errorf("geometry = %s, want ellipse", got)
+		}
+		if got := shapes[0].Name(); got != "Oval" {
+			t.Errorf("name = %q, want Oval", got)
+		}
+		if got := shapes[1].Text(); got != "first\nsecond\ncell" {
+			t.Errorf("text box text = %q", got)
+		}
+		if n := len(shapes[1].Paragraphs()); n != 2 {
+			t.Errorf("text box has %d paragraphs, want 2", n)
+		}
+		if n := len(shapes[1].Tables()); n != 1 {
+			t.Errorf("text box has %d tables, want 1", n)
+		}
+		if got := shapes[2].Geometry(); got != dml.ST_ShapeTypeRect || shapes[2].HasText() {
+			t.Errorf("group shape = %s, text %v", got, shapes[2].HasText())
+		}
+		if got := shapes[3].Text(); got != "nested" {
+			t.Errorf("nested text box text = %q", got)
+		}
+		if n := len(d.Paragraphs()[2].Runs()[0].ShapeGroups()); n != 1 {
+			t.Errorf("run has %d shape groups, want 1", n)
+		}
+	}
+	check(d)
+	check(roundTrip(t, d))
+}
+
+func TestShapeProperties(t *testing.T) {
+	d := New()
+	s, _ := d.AddParagraph().AddRun().AddShapeInline(dml.ST_ShapeTypeRect, measurement.Inch, measurement.Inch).Shape()
+	if s.Name() != "" {
+		t.Errorf("name of a top level shape = %q", s.Name())
+	}
+	s.SetGeometry(dml.ST_ShapeTypeRoundRect)
+	if got := s.Geometry(); got != dml.ST_ShapeTypeRoundRect {
+		t.Errorf("geometry = %s", got)
+	}
+	s.SetNoFill()
+	if s.X().SpPr.FillPropertiesChoice.NoFill == nil {
+		t.Error("shape is filled")
+	}
+	s.SetTextAnchor(dml.ST_TextAnchoringTypeB)
+	s.SetTextInsets(measurement.Point, 2*measurement.Point, 3*measurement.Point, 4*measurement.Point)
+	s.SetAutoFit(true)
+	bp := s.X().BodyPr
+	if bp.AnchorAttr != dml.ST_TextAnchoringTypeB || *bp.LInsAttr != 12700 || *bp.BInsAttr != 4*12700 || bp.TextAutofitChoice.SpAutoFit == nil {
+		t.Errorf("body properties = %v %d %d", bp.AnchorAttr, *bp.LInsAttr, *bp.BInsAttr)
+	}
+	s.SetAutoFit(false)
+	if bp.TextAutofitChoice.NoAutofit == nil || bp.TextAutofitChoice.SpAutoFit != nil {
+		t.Error("autofit wasn't turned off")
+	}
+}
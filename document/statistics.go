@@ -0,0 +1,238 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// TextStatistics are counts of the text of a document or a part of it.
+type TextStatistics struct {
+	Words int
+	// Characters doesn't count white space, CharactersWithSpaces does.
+	Characters           int
+	CharactersWithSpaces int
+	// Paragraphs counts the paragraphs that contain text.
+	Paragraphs int
+	Sentences  int
+	Syllables  int
+	// ComplexWords counts the words of three or more syllables, not counting
+	// the common suffixes -es, -ed and -ing.
+	ComplexWords int
+}
+
+// Statistics are the counts of the body text of a document.
+type Statistics struct {
+	TextStatistics
+	// Pages and Lines are only counted by StatisticsWithLayout, which lays
+	// out the document. They are zero if no layout engine is registered, see
+	// RegisterLayoutCounter.
+	Pages int
+	Lines int
+	// Sections holds the counts of each section of the document in order.
+	Sections []TextStatistics
+	// Styles holds the counts of the paragraphs of each paragraph style, by
+	// style ID.
+	Styles map[string]TextStatistics
+}
+
+// layoutCounter counts the pages and lines of a document by laying it out.
+var layoutCounter func(d *Document) (pages, lines int)
+
+// RegisterLayoutCounter sets the function used by StatisticsWithLayout to lay
+// out a document and count its pages and lines. Importing the document/convert
+// package registers its layout engine.
+func RegisterLayoutCounter(f func(d *Document) (pages, lines int)) {
+	layoutCounter = f
+}
+
+// Statistics counts the words, characters and paragraphs of the body of the
+// document the way Word does, along with the counts of each section and
+// paragraph style. Pages and lines are not counted, see StatisticsWithLayout.
+func (d *Document) Statistics() Statistics {
+	return d.statistics(false)
+}
+
+// StatisticsWithLayout is like Statistics but also counts the pages and lines
+// of the document, including the lines of text in tables. Counting them lays
+// out the document, which takes about as long as converting it to PDF.
+func (d *Document) StatisticsWithLayout() Statistics {
+	return d.statistics(true)
+}
+
+func (d *Document) statistics(layout bool) Statistics {
+	s := Statistics{Styles: map[string]TextStatistics{}}
+	defaultStyle := d.defaultParagraphStyle()
+	section := TextStatistics{}
+	for _, p := range d.paragraphsInOrder() {
+		ts := TextStatistics{}
+		ts.addText(paragraphText(p))
+		s.add(ts)
+		section.add(ts)
+		style := p.Style()
+		if style == "" {
+			style = defaultStyle
+		}
+		st := s.Styles[style]
+		st.add(ts)
+		s.Styles[style] = st
+		if p.X().PPr != nil && p.X().PPr.SectPr != nil {
+			s.Sections = append(s.Sections, section)
+			section = TextStatistics{}
+		}
+	}
+	s.Sections = append(s.Sections, section)
+	if layout && layoutCounter != nil && d.X().Body != nil {
+		s.Pages, s.Lines = layoutCounter(d)
+	}
+	return s
+}
+
+// UpdateAppProperties computes the statistics of the document, including the
+// page and line counts, and writes the counts to the application properties
+// saved in docProps/app.xml.
+func (d *Document) UpdateAppProperties() Statistics {
+	s := d.StatisticsWithLayout()
+	x := d.AppProperties.X()
+	x.Words = int32Ptr(s.Words)
+	x.Characters = int32Ptr(s.Characters)
+	x.CharactersWithSpaces = int32Ptr(s.CharactersWithSpaces)
+	x.Paragraphs = int32Ptr(s.Paragraphs)
+	if layoutCounter != nil {
+		x.Pages = int32Ptr(s.Pages)
+		x.Lines = int32Ptr(s.Lines)
+	}
+	return s
+}
+
+// FleschReadingEase returns the Flesch reading ease score of the text,
+// higher scores indicating text that is easier to read.
+func (t TextStatistics) FleschReadingEase() float64 {
+	if t.Words == 0 || t.Sentences == 0 {
+		return 0
+	}
+	return 206.835 - 1.015*float64(t.Words)/float64(t.Sentences) - 84.6*float64(t.Syllables)/float64(t.Words)
+}
+
+// FleschKincaidGrade returns the Flesch-Kincaid grade level of the text.
+func (t TextStatistics) FleschKincaidGrade() float64 {
+	if t.Words == 0 || t.Sentences == 0 {
+		return 0
+	}
+	return 0.39*float64(t.Words)/float64(t.Sentences) + 11.8*float64(t.Syllables)/float64(t.Words) - 15.59
+}
+
+// GunningFog returns the Gunning fog index of the text, an estimate of the
+// years of formal education needed to understand it on first reading.
+func (t TextStatistics) GunningFog() float64 {
+	if t.Words == 0 || t.Sentences == 0 {
+		return 0
+	}
+	return 0.4 * (float64(t.Words)/float64(t.Sentences) + 100*float64(t.ComplexWords)/float64(t.Words))
+}
+
+func (t *TextStatistics) add(o TextStatistics) {
+	t.Words += o.Words
+	t.Characters += o.Characters
+	t.CharactersWithSpaces += o.CharactersWithSpaces
+	t.Paragraphs += o.Paragraphs
+	t.Sentences += o.Sentences
+	t.Syllables += o.Syllables
+	t.ComplexWords += o.ComplexWords
+}
+
+// addText counts the text of a paragraph.
+func (t *TextStatistics) addText(text string) {
+	for _, c := range text {
+		t.CharactersWithSpaces++
+		if !unicode.IsSpace(c) {
+			t.Characters++
+		}
+	}
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return
+	}
+	t.Paragraphs++
+	t.Words += len(words)
+	for i, w := range words {
+		n := syllables(w)
+		t.Syllables += n
+		if n >= 3 && syllables(trimSuffixes(w)) >= 3 {
+			t.ComplexWords++
+		}
+		// a paragraph without final punctuation, e.g. a heading, is still a
+		// sentence
+		if endsSentence(w) || i == len(words)-1 {
+			t.Sentences++
+		}
+	}
+}
+
+func endsSentence(w string) bool {
+	w = strings.TrimRight(w, "\"')]}”’»")
+	return strings.HasSuffix(w, ".") || strings.HasSuffix(w, "!") || strings.HasSuffix(w, "?")
+}
+
+func trimSuffixes(w string) string {
+	lw := strings.ToLower(w)
+	for _, s := range []string{"es", "ed", "ing"} {
+		if strings.HasSuffix(lw, s) {
+			return w[:len(w)-len(s)]
+		}
+	}
+	return w
+}
+
+// syllables estimates the number of syllables of an English word by counting
+// its groups of vowels, not counting a silent final e. Words without letters
+// such as numbers count as one syllable.
+func syllables(w string) int {
+	letters := []rune{}
+	for _, c := range strings.ToLower(w) {
+		if unicode.IsLetter(c) {
+			letters = append(letters, c)
+		}
+	}
+	n := 0
+	prevVowel := false
+	for _, c := range letters {
+		vowel := strings.ContainsRune("aeiouy", c)
+		if vowel && !prevVowel {
+			n++
+		}
+		prevVowel = vowel
+	}
+	if l := len(letters); n > 1 && l > 2 && letters[l-1] == 'e' && letters[l-2] != 'l' {
+		n--
+	}
+	if n == 0 {
+		n = 1
+	}
+	return n
+}
+
+// defaultParagraphStyle returns the ID of the default paragraph style.
+func (d *Document) defaultParagraphStyle() string {
+	for _, s := range d.Styles.Styles() {
+		if s.Type() == wml.ST_StyleTypeParagraph && isOnOff(s.X().DefaultAttr) {
+			return s.StyleID()
+		}
+	}
+	return ""
+}
+
+func int32Ptr(n int) *int32 {
+	v := int32(n)
+	return &v
+}
@@ -0,0 +1,144 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+func TestSyllables(t *testing.T) {
+	tests := []struct {
+		word    string
+		want    int
+		complex bool
+	}{
+		{"the", 1, false},
+		{"cake", 1, false},
+		{"table", 2, false},
+		{"rhythm", 1, false},
+		{"queue", 1, false},
+		{"reading", 2, false},
+		{"Beautiful,", 3, true},
+		{"education", 4, true},
+		{"interesting", 4, true},
+		{"everything", 4, true},
+		{"relaxes", 3, false},
+		{"2024", 1, false},
+	}
+	for _, tc := range tests {
+		if got := syllables(tc.word); got != tc.want {
+			t.Errorf("syllables(%q) = %d, want %d", tc.word, got, tc.want)
+		}
+		ts := TextStatistics{}
+		ts.addText(tc.word)
+		if got := ts.ComplexWords == 1; got != tc.complex {
+			t.Errorf("%q is complex = %v, want %v", tc.word, got, tc.complex)
+		}
+	}
+}
+
+func TestTextStatistics(t *testing.T) {
+	tests := []struct {
+		text string
+		want TextStatistics
+	}{
+		{"The cat sat. It was happy!", TextStatistics{Words: 6, Characters: 21, CharactersWithSpaces: 26, Paragraphs: 1, Sentences: 2, Syllables: 7}},
+		{"Introduction", TextStatistics{Words: 1, Characters: 12, CharactersWithSpaces: 12, Paragraphs: 1, Sentences: 1, Syllables: 4, ComplexWords: 1}},
+		{"He said \"stop.\" Then left", TextStatistics{Words: 5, Characters: 21, CharactersWithSpaces: 25, Paragraphs: 1, Sentences: 2, Syllables: 5}},
+		{"a\tb", TextStatistics{Words: 2, Characters: 2, CharactersWithSpaces: 3, Paragraphs: 1, Sentences: 1, Syllables: 2}},
+		{"  ", TextStatistics{CharactersWithSpaces: 2}},
+	}
+	for _, tc := range tests {
+		got := TextStatistics{}
+		got.addText(tc.text)
+		if got != tc.want {
+			t.Errorf("statistics of %q = %+v, want %+v", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestReadability(t *testing.T) {
+	ts := TextStatistics{Words: 100, Sentences: 5, Syllables: 150, ComplexWords: 10}
+	scores := []struct {
+		name      string
+		got, want float64
+	}{
+		{"FleschReadingEase", ts.FleschReadingEase(), 59.635},
+		{"FleschKincaidGrade", ts.FleschKincaidGrade(), 9.91},
+		{"GunningFog", ts.GunningFog(), 12},
+		{"empty FleschReadingEase", TextStatistics{}.FleschReadingEase(), 0},
+		{"empty FleschKincaidGrade", TextStatistics{}.FleschKincaidGrade(), 0},
+		{"empty GunningFog", TextStatistics{}.GunningFog(), 0},
+	}
+	for _, s := range scores {
+		if math.Abs(s.got-s.want) > 1e-9 {
+			t.Errorf("%s = %g, want %g", s.name, s.got, s.want)
+		}
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	d := New()
+	title := d.AddParagraph()
+	title.SetStyle("Title")
+	title.AddRun().AddText("Introduction")
+	d.AddParagraph().AddRun().AddText("The cat sat. It was happy!")
+	d.AddParagraph().Properties().AddSection(wml.ST_SectionMarkNextPage)
+	d.AddTable().AddRow().AddCell().AddParagraph().AddRun().AddText("Cell text here")
+
+	s := d.Statistics()
+	if s.Words != 10 || s.Paragraphs != 3 || s.Sentences != 4 || s.Characters != 45 {
+		t.Errorf("statistics = %+v", s.TextStatistics)
+	}
+	if s.Pages != 0 || s.Lines != 0 {
+		t.Errorf("counted %d pages and %d lines without layout", s.Pages, s.Lines)
+	}
+	if len(s.Sections) != 2 || s.Sections[0].Words != 7 || s.Sections[1].Words != 3 {
+		t.Errorf("sections = %+v", s.Sections)
+	}
+	if st := s.Styles["Title"]; st.Words != 1 || st.Paragraphs != 1 {
+		t.Errorf("Title statistics = %+v", st)
+	}
+	// paragraphs without a style count for the default paragraph style
+	if st := s.Styles["Normal"]; st.Words != 9 || st.Paragraphs != 2 {
+		t.Errorf("Normal statistics = %+v", st)
+	}
+	if lt := d.StatisticsWithLayout(); layoutCounter == nil && lt.Pages != 0 {
+		t.Errorf("counted %d pages without a layout engine", lt.Pages)
+	}
+
+	d.UpdateAppProperties()
+	read := roundTrip(t, d)
+	x := read.AppProperties.X()
+	counts := []struct {
+		name string
+		got  *int32
+		want int32
+	}{
+		{"Words", x.Words, 10},
+		{"Characters", x.Characters, 45},
+		{"CharactersWithSpaces", x.CharactersWithSpaces, 52},
+		{"Paragraphs", x.Paragraphs, 3},
+	}
+	for _, c := range counts {
+		if c.got == nil || *c.got != c.want {
+			t.Errorf("read %s = %v, want %d", c.name, c.got, c.want)
+		}
+	}
+	if x.Pages != nil {
+		t.Errorf("saved page count %d without a layout engine", *x.Pages)
+	}
+	if got := read.Statistics(); got.TextStatistics != s.TextStatistics {
+		t.Errorf("read statistics = %+v, want %+v", got.TextStatistics, s.TextStatistics)
+	}
+}
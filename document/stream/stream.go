@@ -0,0 +1,450 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+// Package stream reads the body of Word documents one block at a time, without
+// loading the whole document into memory. It is intended for text extraction
+// and indexing of documents too large to open with document.Open.
+//
+// Example:
+//
+//	r, err := stream.Open("large.docx")
+//	if err != nil {
+//		return err
+//	}
+//	defer r.Close()
+//	for {
+//		b, err := r.Next()
+//		if err == io.EOF {
+//			break
+//		} else if err != nil {
+//			return err
+//		}
+//		fmt.Println(b.StyleName, b.Text)
+//	}
+package stream
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/common/logger"
+	"github.com/unidoc/unioffice/v2/document/internal/semantic"
+	"github.com/unidoc/unioffice/v2/internal/license"
+	"github.com/unidoc/unioffice/v2/schema/soo/pkg/relationships"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+	"github.com/unidoc/unioffice/v2/zippkg"
+)
+
+const wmlNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+// BlockType is the type of a block of the document body.
+type BlockType byte
+
+// BlockType constants.
+const (
+	BlockParagraph BlockType = iota
+	BlockTable
+	// BlockContentControl is a block level content control, holding
+	// paragraphs and tables.
+	BlockContentControl
+	// BlockSection ends a section. It follows the last paragraph of each
+	// section but the last, and ends the body with the properties of the last
+	// section.
+	BlockSection
+)
+
+func (t BlockType) String() string {
+	switch t {
+	case BlockParagraph:
+		return "paragraph"
+	case BlockTable:
+		return "table"
+	case BlockContentControl:
+		return "content control"
+	case BlockSection:
+		return "section"
+	}
+	return fmt.Sprintf("BlockType(%d)", byte(t))
+}
+
+// Block is a top level block of the document body. Only the field matching
+// the block type is set.
+type Block struct {
+	Type BlockType
+	// Index is the position of the block in the body, starting at zero.
+	Index     int
+	Paragraph *wml.CT_P
+	Table     *wml.CT_Tbl
+	Sdt       *wml.CT_SdtBlock
+	Section   *wml.CT_SectPr
+
+	// Text is the text of the block, excluding deleted text. The cells of a
+	// table row are separated by tabs, and table rows and the paragraphs of
+	// content controls by newlines.
+	Text string
+	// StyleID and StyleName identify the paragraph or table style of the
+	// block, empty for the default style.
+	StyleID   string
+	StyleName string
+	// HeadingLevel is the heading level of a paragraph, from 1 to 9, or 0 if
+	// it is not a heading.
+	HeadingLevel int
+	// ListLevel is the zero based list level of a list paragraph, or -1 if it
+	// is not a list item.
+	ListLevel int
+}
+
+// Reader reads the blocks of a document body in document order.
+type Reader struct {
+	zr     *zip.Reader
+	closer io.Closer
+	part   io.ReadCloser
+	dec    *xml.Decoder
+	styles *wml.Styles
+	inBody bool
+	index  int
+	queue  []*Block
+	err    error
+}
+
+// Open opens a document file for streaming.
+func Open(filename string) (*Reader, error) {
+	if err := checkLicense(filename); err != nil {
+		return nil, err
+	}
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %s", filename, err)
+	}
+	r, err := newReader(&zr.Reader)
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	r.closer = zr
+	return r, nil
+}
+
+// Read reads a document for streaming from a reader.
+func Read(ra io.ReaderAt, size int64) (*Reader, error) {
+	name := "unknown"
+	if f, ok := ra.(*os.File); ok {
+		name = f.Name()
+	}
+	if err := checkLicense(name); err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("parsing zip: %s", err)
+	}
+	return newReader(zr)
+}
+
+// checkLicense applies the license checks of document.Read and tracks the
+// use of the document name.
+func checkLicense(name string) error {
+	if !license.GetLicenseKey().IsLicensed() {
+		fmt.Println("Unlicensed version of UniOffice")
+		fmt.Println("- Get a trial license on https://unidoc.io")
+		return errors.New("unioffice license required")
+	}
+	refID, err := license.GenRefId("dr")
+	if err != nil {
+		logger.Log.Error("ERROR: %v", err)
+		return err
+	}
+	if err := license.Track(refID, "document:stream.Read", name); err != nil {
+		logger.Log.Error("ERROR: %v", err)
+		return err
+	}
+	return nil
+}
+
+func newReader(zr *zip.Reader) (*Reader, error) {
+	r := &Reader{zr: zr, styles: wml.NewStyles()}
+	docPath := "word/document.xml"
+	if rel := r.relationship(unioffice.BaseRelsFilename, "", unioffice.OfficeDocumentType); rel != "" {
+		docPath = rel
+	}
+	if stylesPath := r.relationship(zippkg.RelationsPathFor(docPath), path.Dir(docPath), unioffice.StylesType); stylesPath != "" {
+		if f := r.file(stylesPath); f != nil {
+			if err := zippkg.Decode(f, r.styles); err != nil {
+				return nil, err
+			}
+		}
+	}
+	f := r.file(docPath)
+	if f == nil {
+		return nil, errors.New("document part not found")
+	}
+	part, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %s", docPath, err)
+	}
+	r.part = part
+	r.dec = xml.NewDecoder(part)
+	return r, nil
+}
+
+func (r *Reader) file(name string) *zip.File {
+	for _, f := range r.zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// relationship returns the package path of the first target of a
+// relationship type in a relationships part, relative targets being resolved
+// against dir.
+func (r *Reader) relationship(relsPath, dir, typ string) string {
+	f := r.file(relsPath)
+	if f == nil {
+		return ""
+	}
+	rels := relationships.NewRelationships()
+	if err := zippkg.Decode(f, rels); err != nil {
+		return ""
+	}
+	for _, rel := range rels.Relationship {
+		if rel.TypeAttr != typ {
+			continue
+		}
+		if strings.HasPrefix(rel.TargetAttr, "/") {
+			return strings.TrimPrefix(rel.TargetAttr, "/")
+		}
+		return path.Join(dir, rel.TargetAttr)
+	}
+	return ""
+}
+
+// Styles returns the styles of the document.
+func (r *Reader) Styles() *wml.Styles { return r.styles }
+
+// Next returns the next block of the body, or io.EOF after the last block.
+// Blocks are decoded one at a time and aren't retained by the reader.
+func (r *Reader) Next() (*Block, error) {
+	if len(r.queue) > 0 {
+		b := r.queue[0]
+		r.queue = r.queue[1:]
+		return b, nil
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	for {
+		tok, err := r.dec.Token()
+		if err == io.EOF {
+			r.err = io.EOF
+			return nil, r.err
+		} else if err != nil {
+			r.err = fmt.Errorf("reading document: %s", err)
+			return nil, r.err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			if ee, ok := tok.(xml.EndElement); ok && ee.Name.Space == wmlNamespace && ee.Name.Local == "body" {
+				r.err = io.EOF
+				return nil, r.err
+			}
+			continue
+		}
+		if !r.inBody {
+			r.inBody = se.Name.Space == wmlNamespace && se.Name.Local == "body"
+			continue
+		}
+		b, err := r.decodeBlock(se)
+		if err != nil {
+			r.err = err
+			return nil, err
+		}
+		if b != nil {
+			return b, nil
+		}
+	}
+}
+
+// decodeBlock decodes the body child starting with se, returning nil for
+// elements that aren't blocks.
+func (r *Reader) decodeBlock(se xml.StartElement) (*Block, error) {
+	if se.Name.Space != wmlNamespace {
+		return nil, r.dec.Skip()
+	}
+	b := &Block{Index: r.index, ListLevel: -1}
+	switch se.Name.Local {
+	case "p":
+		p := wml.NewCT_P()
+		if err := r.dec.DecodeElement(p, &se); err != nil {
+			return nil, fmt.Errorf("decoding paragraph %d: %s", r.index, err)
+		}
+		b.Type, b.Paragraph = BlockParagraph, p
+		b.Text = paragraphText(p)
+		if p.PPr != nil && p.PPr.PStyle != nil {
+			b.StyleID = p.PPr.PStyle.ValAttr
+		}
+		b.HeadingLevel = semantic.HeadingLevel(r.styles, p)
+		if lvl, ok := semantic.ListLevel(r.styles, p); ok {
+			b.ListLevel = lvl
+		}
+		if p.PPr != nil && p.PPr.SectPr != nil {
+			r.index++
+			r.queue = append(r.queue, &Block{Type: BlockSection, Index: r.index, Section: p.PPr.SectPr, ListLevel: -1})
+		}
+	case "tbl":
+		t := wml.NewCT_Tbl()
+		if err := r.dec.DecodeElement(t, &se); err != nil {
+			return nil, fmt.Errorf("decoding table %d: %s", r.index, err)
+		}
+		b.Type, b.Table = BlockTable, t
+		b.Text = tableText(t)
+		if t.TblPr != nil && t.TblPr.TblStyle != nil {
+			b.StyleID = t.TblPr.TblStyle.ValAttr
+		}
+	case "sdt":
+		sdt := wml.NewCT_SdtBlock()
+		if err := r.dec.DecodeElement(sdt, &se); err != nil {
+			return nil, fmt.Errorf("decoding content control %d: %s", r.index, err)
+		}
+		b.Type, b.Sdt = BlockContentControl, sdt
+		if sdt.SdtContent != nil {
+			b.Text = contentText(sdt.SdtContent.EG_ContentBlockContent)
+		}
+	case "sectPr":
+		sp := wml.NewCT_SectPr()
+		if err := r.dec.DecodeElement(sp, &se); err != nil {
+			return nil, fmt.Errorf("decoding section properties: %s", err)
+		}
+		b.Type, b.Section = BlockSection, sp
+	default:
+		return nil, r.dec.Skip()
+	}
+	if s := semantic.Style(r.styles, b.StyleID); s != nil && s.Name != nil {
+		b.StyleName = s.Name.ValAttr
+	}
+	r.index++
+	return b, nil
+}
+
+// Close closes the document.
+func (r *Reader) Close() error {
+	err := r.part.Close()
+	if r.closer != nil {
+		if cerr := r.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func paragraphText(p *wml.CT_P) string {
+	sb := strings.Builder{}
+	pcontentText(&sb, p.EG_PContent)
+	return sb.String()
+}
+
+func pcontentText(sb *strings.Builder, pcs []*wml.EG_PContent) {
+	for _, pc := range pcs {
+		runContentText(sb, pc.PContentChoice.EG_ContentRunContent)
+		if hl := pc.PContentChoice.Hyperlink; hl != nil {
+			runContentText(sb, hl.PContentChoice.EG_ContentRunContent)
+		}
+		for _, fs := range pc.PContentChoice.FldSimple {
+			pcontentText(sb, fs.EG_PContent)
+		}
+	}
+}
+
+func runContentText(sb *strings.Builder, crcs []*wml.EG_ContentRunContent) {
+	for _, crc := range crcs {
+		c := crc.ContentRunContentChoice
+		if c == nil {
+			continue
+		}
+		if c.R != nil {
+			runText(sb, c.R)
+		}
+		if c.Sdt != nil && c.Sdt.SdtContent != nil {
+			pcontentText(sb, c.Sdt.SdtContent.EG_PContent)
+		}
+		for _, rle := range c.EG_RunLevelElts {
+			// inserted text is part of the text, deleted text isn't
+			if ins := rle.RunLevelEltsChoice.Ins; ins != nil {
+				for _, tc := range ins.RunTrackChangeChoice {
+					if tc.ContentRunContentChoice != nil && tc.ContentRunContentChoice.R != nil {
+						runText(sb, tc.ContentRunContentChoice.R)
+					}
+				}
+			}
+		}
+	}
+}
+
+func runText(sb *strings.Builder, r *wml.CT_R) {
+	for _, ic := range r.EG_RunInnerContent {
+		c := ic.RunInnerContentChoice
+		switch {
+		case c.T != nil:
+			sb.WriteString(c.T.Content)
+		case c.Tab != nil:
+			sb.WriteByte('\t')
+		case c.Br != nil, c.Cr != nil:
+			sb.WriteByte('\n')
+		case c.NoBreakHyphen != nil:
+			sb.WriteByte('-')
+		}
+	}
+}
+
+func contentText(cbcs []*wml.EG_ContentBlockContent) string {
+	parts := []string{}
+	for _, cbc := range cbcs {
+		c := cbc.ContentBlockContentChoice
+		for _, p := range c.P {
+			parts = append(parts, paragraphText(p))
+		}
+		for _, t := range c.Tbl {
+			parts = append(parts, tableText(t))
+		}
+		if c.Sdt != nil && c.Sdt.SdtContent != nil {
+			parts = append(parts, contentText(c.Sdt.SdtContent.EG_ContentBlockContent))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func tableText(t *wml.CT_Tbl) string {
+	rows := []string{}
+	for _, crc := range t.EG_ContentRowContent {
+		for _, tr := range crc.ContentRowContentChoice.Tr {
+			cells := []string{}
+			for _, ccc := range tr.EG_ContentCellContent {
+				for _, tc := range ccc.ContentCellContentChoice.Tc {
+					cbcs := []*wml.EG_ContentBlockContent{}
+					for _, ble := range tc.EG_BlockLevelElts {
+						cbcs = append(cbcs, ble.BlockLevelEltsChoice.EG_ContentBlockContent...)
+					}
+					// paragraphs of a cell are joined by spaces to keep rows
+					// on one line
+					cells = append(cells, strings.Replace(contentText(cbcs), "\n", " ", -1))
+				}
+			}
+			rows = append(rows, strings.Join(cells, "\t"))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
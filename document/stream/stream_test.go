@@ -0,0 +1,137 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/common/license"
+	"github.com/unidoc/unioffice/v2/document"
+	"github.com/unidoc/unioffice/v2/schema/soo/wml"
+)
+
+// TestMain sets the metered license key of UNIDOC_LICENSE_API_KEY, which
+// saving and streaming documents require.
+func TestMain(m *testing.M) {
+	if key := os.Getenv("UNIDOC_LICENSE_API_KEY"); key != "" {
+		if err := license.SetMeteredKey(key); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// newDocument returns a saved document with a heading, a paragraph and a
+// table.
+func newDocument(t *testing.T) []byte {
+	t.Helper()
+	doc := document.New()
+	h := doc.Styles.AddStyle("Heading1", wml.ST_StyleTypeParagraph, false)
+	h.SetName("heading 1")
+	p := doc.AddParagraph()
+	p.SetStyle("Heading1")
+	p.AddRun().AddText("Title")
+	doc.AddParagraph().AddRun().AddText("Body text")
+	tbl := doc.AddTable()
+	row := tbl.AddRow()
+	row.AddCell().AddParagraph().AddRun().AddText("a")
+	row.AddCell().AddParagraph().AddRun().AddText("b")
+	buf := bytes.Buffer{}
+	if err := doc.Save(&buf); err != nil {
+		t.Fatalf("saving: %s", err)
+	}
+	return buf.Bytes()
+}
+
+// readBlocks returns the blocks of a reader up to io.EOF.
+func readBlocks(t *testing.T, r *Reader) []*Block {
+	t.Helper()
+	blocks := []*Block{}
+	for {
+		b, err := r.Next()
+		if err == io.EOF {
+			return blocks
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, b)
+	}
+}
+
+func checkBlocks(t *testing.T, blocks []*Block) {
+	t.Helper()
+	want := []struct {
+		typ     BlockType
+		text    string
+		style   string
+		heading int
+	}{
+		{BlockParagraph, "Title", "heading 1", 1},
+		{BlockParagraph, "Body text", "", 0},
+		{BlockTable, "a\tb", "", 0},
+		{BlockSection, "", "", 0},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("read %d blocks, want %d", len(blocks), len(want))
+	}
+	for i, w := range want {
+		b := blocks[i]
+		if b.Index != i || b.Type != w.typ || b.Text != w.text || b.StyleName != w.style || b.HeadingLevel != w.heading {
+			t.Errorf("block %d = %d %s %q %q %d, want %s %q %q %d", i, b.Index, b.Type, b.Text, b.StyleName, b.HeadingLevel,
+				w.typ, w.text, w.style, w.heading)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	data := newDocument(t)
+	r, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	checkBlocks(t, readBlocks(t, r))
+	// the reader keeps returning io.EOF
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next after the last block = %v, want io.EOF", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.docx")
+	if err := os.WriteFile(filename, newDocument(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBlocks(t, readBlocks(t, r))
+	if err := r.Close(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.docx")); err == nil {
+		t.Error("opened a missing file")
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	data := []byte("not a zip file")
+	if _, err := Read(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("read a file which isn't a zip file")
+	}
+}
@@ -0,0 +1,33 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+)
+
+// ApplyTheme replaces each theme of the document with a copy of a theme, e.g.
+// one taken from a template with common.MakeTheme. A theme part is added to
+// documents without one, such as new documents.
+func (d *Document) ApplyTheme(t common.Theme) error {
+	if len(d._bdc) == 0 {
+		d._bdc = append(d._bdc, dml.NewTheme())
+		d._ead.AddAutoRelationship(unioffice.DocTypeDocument, unioffice.OfficeDocumentType, 1, unioffice.ThemeType)
+		d.ContentTypes.AddOverride("/"+unioffice.AbsoluteFilename(unioffice.DocTypeDocument, unioffice.ThemeType, 1), unioffice.ThemeContentType)
+	}
+	for _, th := range d.Themes() {
+		if err := common.MakeTheme(th).Apply(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
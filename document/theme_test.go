@@ -0,0 +1,42 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package document
+
+import (
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+)
+
+func TestApplyTheme(t *testing.T) {
+	d := New()
+	brand := common.MakeTheme(dml.NewTheme())
+	brand.SetName("Brand")
+	brand.SetColor(dml.ST_SchemeColorValHlink, "C00000")
+	brand.SetMajorFont(common.ThemeFontLatin, "Georgia")
+	// applying a theme twice doesn't add a second theme part
+	for i := 0; i < 2; i++ {
+		if err := d.ApplyTheme(brand); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(d.Themes()); n != 1 {
+		t.Fatalf("document has %d themes, want 1", n)
+	}
+	brand.SetMajorFont(common.ThemeFontLatin, "Verdana")
+
+	for _, d := range []*Document{d, roundTrip(t, d)} {
+		th := common.MakeTheme(d.Themes()[0])
+		if th.Name() != "Brand" || th.ResolveWordColor("hyperlink", "", "") != "C00000" || th.MajorFont(common.ThemeFontLatin) != "Georgia" {
+			t.Errorf("theme = %q %q %q", th.Name(), th.ResolveWordColor("hyperlink", "", ""), th.MajorFont(common.ThemeFontLatin))
+		}
+	}
+}
@@ -0,0 +1,226 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+// Package colorutils implements the DrawingML color transforms, such as tint,
+// shade and luminance modulation, on RRGGBB hex colors.
+package colorutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+)
+
+// Percent100 is the value of 100% in DrawingML percentages.
+const Percent100 = 100000.0
+
+// AdjustColor applies the tint, shade, lumMod and lumOff transforms of a
+// DrawingML color to an RRGGBB color.
+func AdjustColor(colorStr string, transforms []*dml.EG_ColorTransform) string {
+	for _, t := range transforms {
+		c := t.ColorTransformChoice
+		if c == nil {
+			continue
+		}
+		if c.Tint != nil {
+			if v, ok := positiveFixedPercentage(c.Tint.ValAttr); ok {
+				colorStr = AdjustColorByTint(colorStr, 1.0-v)
+			}
+		}
+		if c.Shade != nil {
+			if v, ok := positiveFixedPercentage(c.Shade.ValAttr); ok {
+				colorStr = AdjustColorByShade(colorStr, v)
+			}
+		}
+		if c.LumMod != nil {
+			if v, ok := percentage(c.LumMod.ValAttr); ok {
+				colorStr = AdjustColorByLumMod(colorStr, v)
+			}
+		}
+		if c.LumOff != nil {
+			if v, ok := percentage(c.LumOff.ValAttr); ok {
+				colorStr = AdjustColorByLumOff(colorStr, v)
+			}
+		}
+	}
+	return colorStr
+}
+
+// AdjustColorByTint lightens a color towards white for positive tints and
+// darkens it towards black for negative tints, tints ranging from -1 to 1 as
+// in SpreadsheetML colors.
+func AdjustColorByTint(colorStr string, tint float64) string {
+	r, g, b, ok := parseRGB(colorStr)
+	if !ok {
+		return ""
+	}
+	tintChannel := func(c uint8) uint8 {
+		v := float64(c)
+		if tint < 0 {
+			return clamp(v * (1 + tint))
+		}
+		return clamp(v + (255-v)*tint)
+	}
+	return formatRGB(tintChannel(r), tintChannel(g), tintChannel(b))
+}
+
+// AdjustColorByShade darkens a color, a shade of 1 leaving it unchanged and a
+// shade of 0 turning it black.
+func AdjustColorByShade(colorStr string, shade float64) string {
+	r, g, b, ok := parseRGB(colorStr)
+	if !ok {
+		return ""
+	}
+	return formatRGB(clamp(float64(r)*shade), clamp(float64(g)*shade), clamp(float64(b)*shade))
+}
+
+// AdjustColorByLumMod multiplies the luminance of a color.
+func AdjustColorByLumMod(colorStr string, lum float64) string {
+	r, g, b, ok := parseRGB(colorStr)
+	if !ok {
+		return ""
+	}
+	h, s, l := RGBToHSL(r, g, b)
+	return formatRGB(HSLToRGB(h, s, l*lum))
+}
+
+// AdjustColorByLumOff adds an offset to the luminance of a color.
+func AdjustColorByLumOff(colorStr string, lumOff float64) string {
+	r, g, b, ok := parseRGB(colorStr)
+	if !ok {
+		return ""
+	}
+	h, s, l := RGBToHSL(r, g, b)
+	return formatRGB(HSLToRGB(h, s, l+lumOff))
+}
+
+// RGBToHSL converts a color to hue in degrees, saturation and luminance.
+func RGBToHSL(r, g, b uint8) (float64, float64, float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	min, max := rf, rf
+	for _, v := range []float64{gf, bf} {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	l := (min + max) / 2
+	if min == max {
+		return 0, 0, l
+	}
+	var s float64
+	if l <= 0.5 {
+		s = (max - min) / (max + min)
+	} else {
+		s = (max - min) / (2.0 - max - min)
+	}
+	var h float64
+	switch max {
+	case rf:
+		h = (gf - bf) / (max - min)
+	case gf:
+		h = 2.0 + (bf-rf)/(max-min)
+	default:
+		h = 4.0 + (rf-gf)/(max-min)
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, l
+}
+
+// HSLToRGB converts hue in degrees, saturation and luminance to a color. The
+// luminance is limited to the range from 0 to 1.
+func HSLToRGB(h, s, l float64) (uint8, uint8, uint8) {
+	if l < 0 {
+		l = 0
+	} else if l > 1 {
+		l = 1
+	}
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	h /= 360.0
+	channel := func(t float64) uint8 {
+		t -= float64(int(t))
+		if t < 0 {
+			t++
+		}
+		var v float64
+		switch {
+		case t*6 < 1:
+			v = p + (q-p)*6*t
+		case t*2 < 1:
+			v = q
+		case t*3 < 2:
+			v = p + (q-p)*(2.0/3.0-t)*6
+		default:
+			v = p
+		}
+		return clamp(255 * v)
+	}
+	return channel(h + 1.0/3.0), channel(h), channel(h - 1.0/3.0)
+}
+
+func parseRGB(colorStr string) (uint8, uint8, uint8, bool) {
+	colorStr = strings.TrimPrefix(colorStr, "#")
+	if len(colorStr) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(colorStr, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
+
+func formatRGB(r, g, b uint8) string { return fmt.Sprintf("%02x%02x%02x", r, g, b) }
+
+func clamp(v float64) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	return uint8(v)
+}
+
+func positiveFixedPercentage(v dml.ST_PositiveFixedPercentage) (float64, bool) {
+	if v.ST_PositiveFixedPercentageDecimal != nil {
+		return float64(*v.ST_PositiveFixedPercentageDecimal) / Percent100, true
+	}
+	if v.ST_PositiveFixedPercentage != nil {
+		return percentage(*v.ST_PositiveFixedPercentage)
+	}
+	return 0, false
+}
+
+func percentage(v dml.ST_Percentage) (float64, bool) {
+	if v.ST_PercentageDecimal != nil {
+		return float64(*v.ST_PercentageDecimal) / Percent100, true
+	}
+	if v.ST_Percentage != nil {
+		f, err := strconv.ParseFloat(strings.TrimSuffix(*v.ST_Percentage, "%"), 64)
+		if err != nil {
+			return 0, false
+		}
+		return f / 100, true
+	}
+	return 0, false
+}
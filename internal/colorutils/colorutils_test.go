@@ -0,0 +1,110 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package colorutils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+)
+
+func TestAdjustColorBy(t *testing.T) {
+	tests := []struct {
+		name   string
+		adjust func(string, float64) string
+		color  string
+		v      float64
+		want   string
+	}{
+		{"tint", AdjustColorByTint, "808080", 0.5, "bfbfbf"},
+		{"tint", AdjustColorByTint, "808080", -0.5, "404040"},
+		{"tint", AdjustColorByTint, "#4472C4", 0, "4472c4"},
+		{"tint", AdjustColorByTint, "4472C4", 1, "ffffff"},
+		{"tint", AdjustColorByTint, "4472C4", -1, "000000"},
+		{"shade", AdjustColorByShade, "FF8000", 0.5, "7f4000"},
+		{"shade", AdjustColorByShade, "FF8000", 0, "000000"},
+		{"lumMod", AdjustColorByLumMod, "4472C4", 0.75, "2f5496"},
+		{"lumMod", AdjustColorByLumMod, "FFFFFF", 0.85, "d8d8d8"},
+		{"lumOff", AdjustColorByLumOff, "000000", 0.5, "7f7f7f"},
+		{"lumOff", AdjustColorByLumOff, "000000", 2, "ffffff"},
+		{"tint", AdjustColorByTint, "80808", 0.5, ""},
+		{"shade", AdjustColorByShade, "GGGGGG", 0.5, ""},
+	}
+	for _, tc := range tests {
+		if got := tc.adjust(tc.color, tc.v); got != tc.want {
+			t.Errorf("%s %s by %g = %q, want %q", tc.name, tc.color, tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestHSL(t *testing.T) {
+	tests := []struct {
+		r, g, b uint8
+		h, s, l float64
+	}{
+		{0, 0, 0, 0, 0, 0},
+		{255, 255, 255, 0, 0, 1},
+		{255, 0, 0, 0, 1, 0.5},
+		{0, 255, 0, 120, 1, 0.5},
+		{0, 0, 255, 240, 1, 0.5},
+		{128, 64, 64, 0, 1.0 / 3, 96.0 / 255},
+	}
+	for _, tc := range tests {
+		h, s, l := RGBToHSL(tc.r, tc.g, tc.b)
+		if math.Abs(h-tc.h) > 1e-9 || math.Abs(s-tc.s) > 1e-9 || math.Abs(l-tc.l) > 1e-9 {
+			t.Errorf("RGBToHSL(%d, %d, %d) = %g %g %g, want %g %g %g", tc.r, tc.g, tc.b, h, s, l, tc.h, tc.s, tc.l)
+		}
+		if r, g, b := HSLToRGB(h, s, l); r != tc.r || g != tc.g || b != tc.b {
+			t.Errorf("HSLToRGB(%g, %g, %g) = %d %d %d, want %d %d %d", h, s, l, r, g, b, tc.r, tc.g, tc.b)
+		}
+	}
+}
+
+func TestAdjustColor(t *testing.T) {
+	decimal := func(v int32) *int32 { return &v }
+	percent := func(s string) *string { return &s }
+	tint := func(v int32) *dml.EG_ColorTransform {
+		ct := dml.NewEG_ColorTransform()
+		ct.ColorTransformChoice.Tint = &dml.CT_PositiveFixedPercentage{ValAttr: dml.ST_PositiveFixedPercentage{ST_PositiveFixedPercentageDecimal: decimal(v)}}
+		return ct
+	}
+	// shades are given as percentage strings, as in strict files
+	shade := func(v string) *dml.EG_ColorTransform {
+		ct := dml.NewEG_ColorTransform()
+		ct.ColorTransformChoice.Shade = &dml.CT_PositiveFixedPercentage{ValAttr: dml.ST_PositiveFixedPercentage{ST_PositiveFixedPercentage: &dml.ST_Percentage{ST_Percentage: percent(v)}}}
+		return ct
+	}
+	lum := func(mod, off int32) *dml.EG_ColorTransform {
+		ct := dml.NewEG_ColorTransform()
+		ct.ColorTransformChoice.LumMod = &dml.CT_Percentage{ValAttr: dml.ST_Percentage{ST_PercentageDecimal: decimal(mod)}}
+		ct.ColorTransformChoice.LumOff = &dml.CT_Percentage{ValAttr: dml.ST_Percentage{ST_PercentageDecimal: decimal(off)}}
+		return ct
+	}
+	tests := []struct {
+		transforms []*dml.EG_ColorTransform
+		want       string
+	}{
+		{nil, "4472C4"},
+		{[]*dml.EG_ColorTransform{dml.NewEG_ColorTransform()}, "4472C4"},
+		// a tint of 25% keeps a quarter of the color
+		{[]*dml.EG_ColorTransform{tint(25000)}, "d0dbf0"},
+		{[]*dml.EG_ColorTransform{shade("50%")}, "223962"},
+		{[]*dml.EG_ColorTransform{lum(75000, 0)}, "2f5396"},
+		// "lighter 40%" of Office color pickers
+		{[]*dml.EG_ColorTransform{lum(60000, 40000)}, "8da9db"},
+		{[]*dml.EG_ColorTransform{tint(50000), shade("50%")}, "505c70"},
+	}
+	for _, tc := range tests {
+		if got := AdjustColor("4472C4", tc.transforms); got != tc.want {
+			t.Errorf("AdjustColor with %d transforms = %q, want %q", len(tc.transforms), got, tc.want)
+		}
+	}
+}
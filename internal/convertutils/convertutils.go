@@ -9,7 +9,7 @@
 // Use of this source code is governed by the UniDoc End User License Agreement
 // terms that can be accessed at https://unidoc.io/eula/
 
-package convertutils ;import (_a "bytes";_ge "errors";_f "fmt";_aa "github.com/unidoc/unichart";_ee "github.com/unidoc/unichart/dataset";_fef "github.com/unidoc/unichart/render";_ffc "github.com/unidoc/unioffice/v2/common/logger";_cd "github.com/unidoc/unioffice/v2/document";
+package convertutils ;import (_a "bytes";_ge "errors";_f "fmt";_aa "github.com/unidoc/unichart";_ee "github.com/unidoc/unichart/dataset";_fef "github.com/unidoc/unichart/render";_ffc "github.com/unidoc/unioffice/v2/common/logger";_cu "github.com/unidoc/unioffice/v2/internal/colorutils";_cd "github.com/unidoc/unioffice/v2/document";
 _bg "github.com/unidoc/unioffice/v2/measurement";_df "github.com/unidoc/unioffice/v2/schema/soo/dml";_acf "github.com/unidoc/unioffice/v2/schema/soo/dml/chart";_cfg "github.com/unidoc/unioffice/v2/spreadsheet";_eg "github.com/unidoc/unioffice/v2/spreadsheet/format";
 _ff "github.com/unidoc/unioffice/v2/spreadsheet/formula";_cf "github.com/unidoc/unioffice/v2/spreadsheet/reference";_gb "github.com/unidoc/unipdf/v4/core";_ed "github.com/unidoc/unipdf/v4/creator";_ac "github.com/unidoc/unipdf/v4/model";_bc "github.com/unidoc/unipdf/v4/render";
 _fe "github.com/unidoc/unitype";_be "image";_d "image/color";_cg "math";_de "os";_gd "regexp";_c "sort";_ad "strconv";_eb "strings";_e "sync";_g "unicode";);func (_bfef *creatorContext )drawRectangleWithProps (_egef *_df .CT_ShapeProperties ,_daff ,_egbc ,_fbfe ,_eea float64 ,_abbc bool ){_gcaa :=_bfef ._aga .NewRectangle (_daff ,_egbc ,_fbfe ,_eea );
@@ -64,9 +64,7 @@ case _df .ST_SchemeColorValLt2 :return GetColorStringFromDmlColor (_cfe .Lt2 );c
 case _df .ST_SchemeColorValAccent3 :return GetColorStringFromDmlColor (_cfe .Accent3 );case _df .ST_SchemeColorValAccent4 :return GetColorStringFromDmlColor (_cfe .Accent4 );case _df .ST_SchemeColorValAccent5 :return GetColorStringFromDmlColor (_cfe .Accent5 );
 case _df .ST_SchemeColorValAccent6 :return GetColorStringFromDmlColor (_cfe .Accent6 );};};};return "";};func _fbbb (_effb float64 )float64 {return _effb *_bg .Millimeter };func (_dea *creatorContext )getPdfColorFromSolidFill (_dafca *_df .CT_SolidColorFillProperties )_ed .Color {if _dafca ==nil {return nil ;
 };_eccf :="";if _cfgg :=_dafca .SrgbClr ;_cfgg !=nil {_eccf =_cfgg .ValAttr ;}else if _ffbb :=_dafca .SchemeClr ;_ffbb !=nil {_eccf =_gcge (_ffbb .ValAttr ,_dea ._agbfe );_eccf =AdjustColor (_eccf ,_ffbb .EG_ColorTransform );};if _eccf ==""{return nil ;
-};return _ed .ColorRGBFromHex ("\u0023"+_eccf );};func AssignStdFontByName (style _ed .TextStyle ,fontName string )*_ac .PdfFont {_ceg :=_ac .StdFontName (fontName );return _ac .NewStandard14FontMustCompile (_ceg );};func AdjustColorByLumMod (colorStr string ,lum float64 )string {var _badg ,_gcf ,_edc uint8 ;
-_ddgf ,_ :=_f .Sscanf (colorStr ,"\u0025\u0030\u0032x\u0025\u0030\u0032\u0078\u0025\u0030\u0032\u0078",&_badg ,&_gcf ,&_edc );if _ddgf !=3{return "";};_gbgb ,_fggf ,_gcad :=_efbe (_badg ,_gcf ,_edc );_gcad =lum *_gcad ;_badg ,_gcf ,_edc =_gffa (_gbgb ,_fggf ,_gcad );
-return _f .Sprintf ("\u0025\u0030\u0032x\u0025\u0030\u0032\u0078\u0025\u0030\u0032\u0078",_badg ,_gcf ,_edc );};func FromSTCoordinate (st _df .ST_Coordinate )int64 {if _fdde :=st .ST_CoordinateUnqualified ;_fdde !=nil {return *_fdde ;};return 0;};const (FontStyle_Regular FontStyle =0;
+};return _ed .ColorRGBFromHex ("\u0023"+_eccf );};func AssignStdFontByName (style _ed .TextStyle ,fontName string )*_ac .PdfFont {_ceg :=_ac .StdFontName (fontName );return _ac .NewStandard14FontMustCompile (_ceg );};func AdjustColorByLumMod (colorStr string ,lum float64 )string {return _cu .AdjustColorByLumMod (colorStr ,lum )};func FromSTCoordinate (st _df .ST_Coordinate )int64 {if _fdde :=st .ST_CoordinateUnqualified ;_fdde !=nil {return *_fdde ;};return 0;};const (FontStyle_Regular FontStyle =0;
 FontStyle_Bold FontStyle =1;FontStyle_Italic FontStyle =2;FontStyle_BoldItalic FontStyle =3;);func IsNoSpaceLanguage (symbol string )bool {for _ ,_gda :=range symbol {if _g .Is (_g .Han ,_gda ){return true ;};};return false ;};func _eafb (_cdba string )([]byte ,error ){_dgc :=_gd .MustCompile ("\u005b\u005e\u0061\u002d\u007a\u0041\u002d\u005a\u0030\u002d\u0039\u005d\u002b");
 _cdba =_dgc .ReplaceAllString (_cdba ,"");_ecee :=[]rune (_cdba );_dgfd :=[]byte {};for _fdea :=len (_ecee )-2;_fdea >=0;_fdea -=2{_ecgc ,_cgaa :=_ad .ParseUint (string (_ecee [_fdea ])+string (_ecee [_fdea +1]),16,8);if _cgaa !=nil {return nil ,_cgaa ;
 };_dgfd =append (_dgfd ,byte (_ecgc ));};return _dgfd ,nil ;};var RtlFontFile *_ac .PdfFont ;func (_dge *creatorContext )drawLegend (_ggg *Rectangle ,_dgb []*legendItem ,_ced bool ){_cefe :=_dge ._daebe ;_fcd :=_fbbb (2.5)*_cefe ;_agf :=_ece *_cefe ;_ffef :=(_fcd -_agf )/2;
@@ -82,10 +80,7 @@ return _f .Errorf ("\u0055\u006e\u0073\u0075\u0070\u0070o\u0072\u0074\u0065\u006
 if _gac !=nil {return _gac ;};_bgf :=_egdd .GetNameRecords ();for _ ,_bdcea :=range _bgf {_bde :=_bdcea [1];if _bde ==""{continue ;};_bgdb :=make ([]byte ,0);for _dbg :=0;_dbg < len (_bde );_dbg ++{if _bde [_dbg ]==39||_bde [_dbg ]==92{continue ;};_gfgaa :=4;
 if _dbg +_gfgaa < len (_bde ){if _bde [_dbg :_dbg +_gfgaa ]=="\u0000"{_dbg =_dbg +_gfgaa +1;continue ;};};_bgdb =append (_bgdb ,_bde [_dbg ]);};_bde =_eb .Replace (string (_bgdb ),"\u0078\u0030\u0030","",-1);_dbge :=_bdcea [2];if _dbge ==""{return _f .Errorf ("N\u006f\u0020\u0073\u0074\u0079\u006ce\u0020\u0069\u006e\u0066\u006f\u0072m\u0061\u0074\u0069\u006f\u006e\u0020\u0069n\u0020\u0074\u0068\u0065\u0020\u0066\u0069\u006c\u0065\u0020%\u0073",_cedg );
 };_bgdb =make ([]byte ,0);for _dggg :=0;_dggg < len (_dbge );_dggg ++{if _dbge [_dggg ]==39||_dbge [_dggg ]==92{continue ;};_ceef :=4;if _dggg +_ceef < len (_dbge ){if _dbge [_dggg :_dggg +_ceef ]=="\u0000"{_dggg =_dggg +_ceef +1;continue ;};};_bgdb =append (_bgdb ,_dbge [_dggg ]);
-};_dbge =_eb .Replace (string (_bgdb ),"\u0078\u0030\u0030","",-1);RegisterFont (_bde ,_faag [_dbge ],_fbgfc );};return nil ;};type BorderPosition byte ;func AdjustColorByLumOff (colorStr string ,lumOff float64 )string {var _bcgc ,_dabcf ,_ebgc uint8 ;
-_ffea ,_ :=_f .Sscanf (colorStr ,"\u0025\u0030\u0032x\u0025\u0030\u0032\u0078\u0025\u0030\u0032\u0078",&_bcgc ,&_dabcf ,&_ebgc );if _ffea !=3{return "";};_abgc ,_gbec ,_cgfa :=_efbe (_bcgc ,_dabcf ,_ebgc );_cgfa =_cgfa +lumOff ;_bcgc ,_dabcf ,_ebgc =_gffa (_abgc ,_gbec ,_cgfa );
-return _f .Sprintf ("\u0025\u0030\u0032x\u0025\u0030\u0032\u0078\u0025\u0030\u0032\u0078",_bcgc ,_dabcf ,_ebgc );};func AdjustColorByShade (colorStr string ,shade float64 )string {var _dafc ,_baab ,_cag uint8 ;_abe ,_ :=_f .Sscanf (colorStr ,"\u0025\u0030\u0032x\u0025\u0030\u0032\u0078\u0025\u0030\u0032\u0078",&_dafc ,&_baab ,&_cag );
-if _abe !=3{return "";};return _adad (_dafc ,shade )+_adad (_baab ,shade )+_adad (_cag ,shade );};func (_eae *creatorContext )drawPieChart (_ebf *_acf .CT_PieChart ,_fga *Rectangle ,_dcb *_cfg .Workbook ,_eadc float64 )([]*legendItem ,error ){_fdead :=[]*legendItem {};
+};_dbge =_eb .Replace (string (_bgdb ),"\u0078\u0030\u0030","",-1);RegisterFont (_bde ,_faag [_dbge ],_fbgfc );};return nil ;};type BorderPosition byte ;func AdjustColorByLumOff (colorStr string ,lumOff float64 )string {return _cu .AdjustColorByLumOff (colorStr ,lumOff )};func AdjustColorByShade (colorStr string ,shade float64 )string {return _cu .AdjustColorByShade (colorStr ,shade )};func (_eae *creatorContext )drawPieChart (_ebf *_acf .CT_PieChart ,_fga *Rectangle ,_dcb *_cfg .Workbook ,_eadc float64 )([]*legendItem ,error ){_fdead :=[]*legendItem {};
 _acdb :=map[string ]serCategory {};_aggb :=[]string {};_dgbde :=_cg .Inf (1);_efeb :=_cg .Inf (-1);_aeca :=_ebf .Ser ;for _ ,_cgea :=range _aeca {var _bbfb string ;if _afe :=_cgea .Tx ;_afe !=nil {if _edfa :=_afe .SerTxChoice ;_edfa !=nil {if _edfa .V !=nil {_bbfb =*_edfa .V ;
 }else if _fegd :=_edfa .StrRef ;_fegd !=nil {if _bebe :=_fegd .StrCache ;_bebe !=nil {for _ ,_deb :=range _bebe .Pt {_bbfb =_deb .V ;};};};};};if _cbad :=_cgea .Cat ;_cbad !=nil {if _ggd :=_cbad .AxDataSourceChoice ;_ggd !=nil {if _eecgb :=_ggd .StrRef ;
 _eecgb !=nil {if _caee :=_eecgb .F ;_caee !=""&&_dcb !=nil {_adfd ,_acb ,_dafb ,_fgec :=ParseExcelRange (_caee );if _fgec ==nil {for _ ,_fdb :=range _dcb .Sheets (){if _fdb .Name ()==_eb .Trim (_adfd ,"\u0027"){_cccf :=_acb .String ();for _egga :=_acb .RowIdx ;
@@ -100,14 +95,10 @@ if len (_gcefb ._ecb )> 0{_gef .Values =append (_gef .Values ,_ee .Value {Value
 _aefa :=_eae ._aga ;_bdec :=_ed .NewChart (_gef );_bdec .SetPos (_fga .Left ,_fga .Top );_bdadd :=_aefa .Draw (_bdec );if _bdadd !=nil {return nil ,_bdadd ;};return _fdead ,nil ;};func (_aacf *Rectangle )scale (_eddf float64 ){_aacf .Top *=_eddf ;_aacf .Bottom *=_eddf ;
 _aacf .Left *=_eddf ;_aacf .Right *=_eddf ;};func _gaff (_dfgb *_cd .Document ,_fade ,_gcga ,_aafd string )error {_aec ,_aeea :=_dfgb .GetFontBytesByRelId (_gcga );if _aeea !=nil {return _aeea ;};_fefgc ,_aeea :=_eafb (_aafd );if _aeea !=nil {return _aeea ;
 };for _badgd :=0;_badgd < 32;_badgd ++{_dag :=_badgd %len (_fefgc );_aec [_badgd ]=_aec [_badgd ]^_fefgc [_dag ];};_dfb :=_dfgb .TmpPath +"\u002f"+_fade +"\u002e\u0074\u0074\u0066";_aeea =_de .WriteFile (_dfb ,_aec ,0644);if _aeea !=nil {return _aeea ;
-};_gfcc (_dfb );return nil ;};func AdjustColorByTint (colorStr string ,tint float64 )string {var _gce ,_fgca ,_ccfc uint8 ;_bgea ,_ :=_f .Sscanf (colorStr ,"\u0025\u0030\u0032x\u0025\u0030\u0032\u0078\u0025\u0030\u0032\u0078",&_gce ,&_fgca ,&_ccfc );if _bgea !=3{return "";
-};return _bagc (_gce ,tint )+_bagc (_fgca ,tint )+_bagc (_ccfc ,tint );};var _eec =fontsMap {_dfaa :&_e .Mutex {},_dda :map[string ]map[FontStyle ]*_ac .PdfFont {}};func FromSTPercentage (st *_df .ST_Percentage )float64 {if _cbea :=st .ST_PercentageDecimal ;
+};_gfcc (_dfb );return nil ;};func AdjustColorByTint (colorStr string ,tint float64 )string {return _cu .AdjustColorByTint (colorStr ,tint )};var _eec =fontsMap {_dfaa :&_e .Mutex {},_dda :map[string ]map[FontStyle ]*_ac .PdfFont {}};func FromSTPercentage (st *_df .ST_Percentage )float64 {if _cbea :=st .ST_PercentageDecimal ;
 _cbea !=nil {return float64 (*_cbea )/Percent100 ;};return 0;};var _aaef =_fbbb (5);func GetPageFromCreator (c *_ed .Creator )(*_ac .PdfPage ,error ){_gfccf :=_a .NewBuffer ([]byte {});_gddf :=c .Write (_gfccf );if _gddf !=nil {return nil ,_gddf ;};_ddad :=_a .NewReader (_gfccf .Bytes ());
 _cefeb ,_gddf :=_ac .NewPdfReader (_ddad );if _gddf !=nil {return nil ,_gddf ;};return _cefeb .GetPage (1);};func RegisterFont (name string ,style FontStyle ,font *_ac .PdfFont ){_eec ._dfaa .Lock ();if _eec ._dda [name ]==nil {_eec ._dda [name ]=map[FontStyle ]*_ac .PdfFont {};
-};_eec ._dda [name ][style ]=font ;_eec ._dfaa .Unlock ();};func AdjustColor (colorStr string ,EG_ColorTransform []*_df .EG_ColorTransform )string {for _ ,_bged :=range EG_ColorTransform {if _baga :=_bged .ColorTransformChoice .Tint ;_baga !=nil {if _fddf :=_baga .ValAttr .ST_PositiveFixedPercentageDecimal ;
-_fddf !=nil {colorStr =AdjustColorByTint (colorStr ,1.0-float64 (*_fddf )/Percent100 );};};if _edec :=_bged .ColorTransformChoice .Shade ;_edec !=nil {if _dac :=_edec .ValAttr .ST_PositiveFixedPercentageDecimal ;_dac !=nil {colorStr =AdjustColorByShade (colorStr ,float64 (*_dac )/Percent100 );
-};};if _bfb :=_bged .ColorTransformChoice .LumMod ;_bfb !=nil {if _fbb :=_bfb .ValAttr .ST_PercentageDecimal ;_fbb !=nil {colorStr =AdjustColorByLumMod (colorStr ,float64 (*_fbb )/Percent100 );};};if _cgeg :=_bged .ColorTransformChoice .LumOff ;_cgeg !=nil {if _bcge :=_cgeg .ValAttr .ST_PercentageDecimal ;
-_bcge !=nil {colorStr =AdjustColorByLumOff (colorStr ,float64 (*_bcge )/Percent100 );};};};return colorStr ;};func _fcce (_baeb *_acf .CT_DateAx )(uint32 ,_acf .ST_AxPos ,_acf .ST_TickMark ,_acf .ST_TickLblPos ,*_acf .CT_ChartLines ,uint32 ,*_df .CT_ShapeProperties ,error ){var _fcbc ,_gcc uint32 ;
+};_eec ._dda [name ][style ]=font ;_eec ._dfaa .Unlock ();};func AdjustColor (colorStr string ,EG_ColorTransform []*_df .EG_ColorTransform )string {return _cu .AdjustColor (colorStr ,EG_ColorTransform )};func _fcce (_baeb *_acf .CT_DateAx )(uint32 ,_acf .ST_AxPos ,_acf .ST_TickMark ,_acf .ST_TickLblPos ,*_acf .CT_ChartLines ,uint32 ,*_df .CT_ShapeProperties ,error ){var _fcbc ,_gcc uint32 ;
 var _bge _acf .ST_AxPos ;var _aega _acf .ST_TickMark ;var _abga *_acf .CT_ChartLines ;var _dadd _acf .ST_TickLblPos ;if _baeb .AxId ==nil {return _fcbc ,_bge ,_aega ,_dadd ,_abga ,_gcc ,_baeb .SpPr ,_ge .New ("\u004e\u006f\u0020x\u0020\u0061\u0078\u0069\u0073\u0020\u0049\u0044");
 }else {_fcbc =_baeb .AxId .ValAttr ;};if _baeb .AxPos ==nil {return _fcbc ,_bge ,_aega ,_dadd ,_abga ,_gcc ,_baeb .SpPr ,_ge .New ("\u004eo\u0020x\u0020\u0061\u0078\u0069\u0073 \u0070\u006fs\u0069\u0074\u0069\u006f\u006e");}else {_bge =_baeb .AxPos .ValAttr ;
 };if _baeb .MajorTickMark !=nil {_aega =_baeb .MajorTickMark .ValAttr ;};if _baeb .TickLblPos !=nil {_dadd =_baeb .TickLblPos .ValAttr ;};if _baeb .CrossAx ==nil {return _fcbc ,_bge ,_aega ,_dadd ,_abga ,_gcc ,_baeb .SpPr ,_ge .New ("\u004e\u006f \u0063\u0072\u006fs\u0073\u0020\u0061\u0078\u0069\u0073\u0020\u0049\u0044");
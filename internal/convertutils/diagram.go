@@ -0,0 +1,427 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package convertutils
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/diagram"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+	"github.com/unidoc/unipdf/v4/contentstream/draw"
+	"github.com/unidoc/unipdf/v4/creator"
+	"github.com/unidoc/unipdf/v4/model"
+)
+
+// colors of the default Office theme, used when a diagram refers to a scheme
+// color and no theme is available
+var defaultSchemeColors = map[dml.ST_SchemeColorVal]string{
+	dml.ST_SchemeColorValLt1:     "FFFFFF",
+	dml.ST_SchemeColorValDk1:     "000000",
+	dml.ST_SchemeColorValLt2:     "E7E6E6",
+	dml.ST_SchemeColorValDk2:     "44546A",
+	dml.ST_SchemeColorValAccent1: "4472C4",
+	dml.ST_SchemeColorValAccent2: "ED7D31",
+	dml.ST_SchemeColorValAccent3: "A5A5A5",
+	dml.ST_SchemeColorValAccent4: "FFC000",
+	dml.ST_SchemeColorValAccent5: "5B9BD5",
+	dml.ST_SchemeColorValAccent6: "70AD47",
+}
+
+// MakeBlockFromDiagramDrawing renders the cached drawing of a SmartArt diagram
+// into a block of the given width and height in points.
+func MakeBlockFromDiagramDrawing(dr *diagram.Drawing, width, height float64, theme *dml.Theme) (*creator.Block, error) {
+	if dr == nil || len(dr.Shapes) == 0 {
+		return nil, errors.New("diagram drawing has no shapes")
+	}
+	c := MakeTempCreator(width, height)
+	for _, sp := range dr.Shapes {
+		drawDiagramShape(c, sp, theme)
+	}
+	return MakeBlockFromCreator(c)
+}
+
+func drawDiagramShape(c *creator.Creator, sp *diagram.Shape, theme *dml.Theme) {
+	if sp.SpPr == nil || sp.SpPr.Xfrm == nil {
+		return
+	}
+	x, y, w, h := GetDataFromXfrm(sp.SpPr.Xfrm)
+	rot := 0.0
+	if r := sp.SpPr.Xfrm.RotAttr; r != nil {
+		rot = float64(*r) / 60000
+	}
+	fill, line, lineWidth := diagramShapeColors(sp, theme)
+
+	geom := dml.ST_ShapeTypeRect
+	if pg := sp.SpPr.GeometryChoice.PrstGeom; pg != nil {
+		geom = pg.PrstAttr
+	}
+	switch {
+	case sp.SpPr.GeometryChoice.CustGeom != nil:
+		drawCustomGeometry(c, sp.SpPr.GeometryChoice.CustGeom, x, y, w, h, fill, line, lineWidth)
+	case geom == dml.ST_ShapeTypeLine || geom == dml.ST_ShapeTypeStraightConnector1:
+		x0, y0, x1, y1 := x, y, x+w, y+h
+		if v := sp.SpPr.Xfrm.FlipHAttr; v != nil && *v {
+			x0, x1 = x1, x0
+		}
+		if v := sp.SpPr.Xfrm.FlipVAttr; v != nil && *v {
+			y0, y1 = y1, y0
+		}
+		DrawLine(c, x0, y0, x1, y1, lineWidth, line)
+	case geom == dml.ST_ShapeTypeEllipse && rot == 0:
+		e := c.NewEllipse(x+w/2, y+h/2, w, h)
+		setShapeColors(e, fill, line, lineWidth)
+		c.Draw(e)
+	case geom == dml.ST_ShapeTypeRect && rot == 0, geom == dml.ST_ShapeTypeRoundRect && rot == 0:
+		r := c.NewRectangle(x, y, w, h)
+		if geom == dml.ST_ShapeTypeRoundRect {
+			rad := math.Min(w, h) / 6
+			r.SetBorderRadius(rad, rad, rad, rad)
+		}
+		setShapeColors(r, fill, line, lineWidth)
+		c.Draw(r)
+	default:
+		pts := presetPolygon(geom, w, h)
+		cx, cy := w/2, h/2
+		sin, cos := math.Sincos(rot * math.Pi / 180)
+		for i, p := range pts {
+			dx, dy := p.X-cx, p.Y-cy
+			pts[i] = draw.Point{X: x + cx + dx*cos - dy*sin, Y: y + cy + dx*sin + dy*cos}
+		}
+		pg := c.NewPolygon([][]draw.Point{pts})
+		setShapeColors(pg, fill, line, lineWidth)
+		c.Draw(pg)
+	}
+
+	if sp.TxBody != nil {
+		tx, ty, tw, th := x, y, w, h
+		if sp.TxXfrm != nil {
+			tx, ty, tw, th = GetDataFromXfrm(sp.TxXfrm)
+		}
+		drawDiagramText(c, sp, tx, ty, tw, th, theme)
+	}
+}
+
+type shapeColorSetter interface {
+	SetFillColor(creator.Color)
+	SetBorderColor(creator.Color)
+	SetBorderWidth(float64)
+}
+
+func setShapeColors(s shapeColorSetter, fill, line creator.Color, lineWidth float64) {
+	if fill != nil {
+		s.SetFillColor(fill)
+	}
+	if line != nil {
+		s.SetBorderColor(line)
+		s.SetBorderWidth(lineWidth)
+	} else {
+		s.SetBorderWidth(0)
+	}
+}
+
+// diagramShapeColors returns the fill and line colors and the line width of
+// a shape, falling back to the colors referenced by the shape style.
+func diagramShapeColors(sp *diagram.Shape, theme *dml.Theme) (creator.Color, creator.Color, float64) {
+	var fill, line creator.Color
+	lineWidth := 0.75
+	fc := sp.SpPr.FillPropertiesChoice
+	switch {
+	case fc.NoFill != nil:
+	case fc.SolidFill != nil:
+		fill = diagramSolidFillColor(fc.SolidFill, theme)
+	case sp.Style != nil && sp.Style.FillRef != nil && sp.Style.FillRef.IdxAttr > 0:
+		ref := sp.Style.FillRef
+		fill = diagramColor(ref.SrgbClr, ref.SchemeClr, ref.SysClr, theme)
+	}
+	ln := sp.SpPr.Ln
+	switch {
+	case ln != nil && ln.LineFillPropertiesChoice.NoFill != nil:
+	case ln != nil && ln.LineFillPropertiesChoice.SolidFill != nil:
+		line = diagramSolidFillColor(ln.LineFillPropertiesChoice.SolidFill, theme)
+	case sp.Style != nil && sp.Style.LnRef != nil && sp.Style.LnRef.IdxAttr > 0:
+		ref := sp.Style.LnRef
+		line = diagramColor(ref.SrgbClr, ref.SchemeClr, ref.SysClr, theme)
+	}
+	if ln != nil && ln.WAttr != nil {
+		lineWidth = measurement.FromEMU(int64(*ln.WAttr))
+	}
+	return fill, line, lineWidth
+}
+
+func diagramSolidFillColor(f *dml.CT_SolidColorFillProperties, theme *dml.Theme) creator.Color {
+	return diagramColor(f.SrgbClr, f.SchemeClr, f.SysClr, theme)
+}
+
+func diagramColor(srgb *dml.CT_SRgbColor, scheme *dml.CT_SchemeColor, sys *dml.CT_SystemColor, theme *dml.Theme) creator.Color {
+	clr := ""
+	switch {
+	case srgb != nil:
+		clr = AdjustColor(srgb.ValAttr, srgb.EG_ColorTransform)
+	case scheme != nil:
+		clr = AdjustColor(diagramSchemeColor(scheme.ValAttr, theme), scheme.EG_ColorTransform)
+	case sys != nil && sys.LastClrAttr != nil:
+		clr = AdjustColor(*sys.LastClrAttr, sys.EG_ColorTransform)
+	}
+	if clr == "" {
+		return nil
+	}
+	return creator.ColorRGBFromHex("#" + clr)
+}
+
+func diagramSchemeColor(val dml.ST_SchemeColorVal, theme *dml.Theme) string {
+	switch val {
+	case dml.ST_SchemeColorValBg1:
+		val = dml.ST_SchemeColorValLt1
+	case dml.ST_SchemeColorValTx1:
+		val = dml.ST_SchemeColorValDk1
+	case dml.ST_SchemeColorValBg2:
+		val = dml.ST_SchemeColorValLt2
+	case dml.ST_SchemeColorValTx2:
+		val = dml.ST_SchemeColorValDk2
+	}
+	if theme != nil {
+		if clr := _becf(val, theme); clr != "" {
+			return clr
+		}
+	}
+	return defaultSchemeColors[val]
+}
+
+// presetPolygon returns the outline of a preset shape of the given size as a
+// polygon. Unsupported shapes are drawn as rectangles.
+func presetPolygon(geom dml.ST_ShapeType, w, h float64) []draw.Point {
+	ss := math.Min(w, h)
+	switch geom {
+	case dml.ST_ShapeTypeRightArrow:
+		hd := math.Min(ss/2, w)
+		return []draw.Point{{X: 0, Y: h / 4}, {X: w - hd, Y: h / 4}, {X: w - hd, Y: 0}, {X: w, Y: h / 2},
+			{X: w - hd, Y: h}, {X: w - hd, Y: h * 3 / 4}, {X: 0, Y: h * 3 / 4}}
+	case dml.ST_ShapeTypeLeftArrow:
+		hd := math.Min(ss/2, w)
+		return []draw.Point{{X: w, Y: h / 4}, {X: hd, Y: h / 4}, {X: hd, Y: 0}, {X: 0, Y: h / 2},
+			{X: hd, Y: h}, {X: hd, Y: h * 3 / 4}, {X: w, Y: h * 3 / 4}}
+	case dml.ST_ShapeTypeChevron:
+		d := math.Min(ss/2, w)
+		return []draw.Point{{X: 0, Y: 0}, {X: w - d, Y: 0}, {X: w, Y: h / 2}, {X: w - d, Y: h}, {X: 0, Y: h}, {X: d, Y: h / 2}}
+	case dml.ST_ShapeTypeHomePlate:
+		d := math.Min(ss/2, w)
+		return []draw.Point{{X: 0, Y: 0}, {X: w - d, Y: 0}, {X: w, Y: h / 2}, {X: w - d, Y: h}, {X: 0, Y: h}}
+	case dml.ST_ShapeTypeTriangle:
+		return []draw.Point{{X: w / 2, Y: 0}, {X: w, Y: h}, {X: 0, Y: h}}
+	case dml.ST_ShapeTypeDiamond:
+		return []draw.Point{{X: w / 2, Y: 0}, {X: w, Y: h / 2}, {X: w / 2, Y: h}, {X: 0, Y: h / 2}}
+	case dml.ST_ShapeTypeEllipse:
+		pts := []draw.Point{}
+		for i := 0; i < 36; i++ {
+			a := float64(i) * math.Pi / 18
+			pts = append(pts, draw.Point{X: w/2 + w/2*math.Cos(a), Y: h/2 + h/2*math.Sin(a)})
+		}
+		return pts
+	}
+	return []draw.Point{{X: 0, Y: 0}, {X: w, Y: 0}, {X: w, Y: h}, {X: 0, Y: h}}
+}
+
+// drawCustomGeometry draws the straight segments of the paths of a custom
+// geometry, such as the connectors of hierarchy diagrams.
+func drawCustomGeometry(c *creator.Creator, g *dml.CT_CustomGeometry2D, x, y, w, h float64, fill, line creator.Color, lineWidth float64) {
+	if g.PathLst == nil {
+		return
+	}
+	for _, p := range g.PathLst.Path {
+		sx, sy := 1.0, 1.0
+		if p.WAttr != nil && *p.WAttr > 0 {
+			sx = w / measurement.FromEMU(*p.WAttr)
+		}
+		if p.HAttr != nil && *p.HAttr > 0 {
+			sy = h / measurement.FromEMU(*p.HAttr)
+		}
+		point := func(pt *dml.CT_AdjPoint2D) (draw.Point, bool) {
+			if pt == nil || pt.XAttr.ST_Coordinate == nil || pt.YAttr.ST_Coordinate == nil {
+				return draw.Point{}, false
+			}
+			return draw.Point{
+				X: x + measurement.FromEMU(FromSTCoordinate(*pt.XAttr.ST_Coordinate))*sx,
+				Y: y + measurement.FromEMU(FromSTCoordinate(*pt.YAttr.ST_Coordinate))*sy,
+			}, true
+		}
+		pts, closed := []draw.Point{}, false
+		for _, ch := range p.Path2DChoice {
+			var pt *dml.CT_AdjPoint2D
+			switch {
+			case ch.MoveTo != nil:
+				pt = ch.MoveTo.Pt
+			case ch.LnTo != nil:
+				pt = ch.LnTo.Pt
+			case ch.CubicBezTo != nil && len(ch.CubicBezTo.Pt) > 0:
+				pt = ch.CubicBezTo.Pt[len(ch.CubicBezTo.Pt)-1]
+			case ch.QuadBezTo != nil && len(ch.QuadBezTo.Pt) > 0:
+				pt = ch.QuadBezTo.Pt[len(ch.QuadBezTo.Pt)-1]
+			case ch.Close != nil:
+				closed = true
+			}
+			if v, ok := point(pt); ok {
+				pts = append(pts, v)
+			}
+		}
+		if len(pts) < 2 {
+			continue
+		}
+		if closed && fill != nil && p.FillAttr != dml.ST_PathFillModeNone {
+			pg := c.NewPolygon([][]draw.Point{pts})
+			setShapeColors(pg, fill, line, lineWidth)
+			c.Draw(pg)
+			continue
+		}
+		if line != nil && (p.StrokeAttr == nil || *p.StrokeAttr) {
+			if closed {
+				pts = append(pts, pts[0])
+			}
+			pl := c.NewPolyline(pts)
+			pl.SetLineColor(line)
+			pl.SetLineWidth(lineWidth)
+			c.Draw(pl)
+		}
+	}
+}
+
+// drawDiagramText draws the paragraphs of the text body of a shape within the
+// given bounds, anchored as specified by the body properties.
+func drawDiagramText(c *creator.Creator, sp *diagram.Shape, x, y, w, h float64, theme *dml.Theme) {
+	tb := sp.TxBody
+	lIns, tIns, rIns, bIns := 7.2, 3.6, 7.2, 3.6
+	anchor := dml.ST_TextAnchoringTypeCtr
+	if bp := tb.BodyPr; bp != nil {
+		if bp.LInsAttr != nil {
+			lIns = measurement.FromEMU(FromSTCoordinate32(*bp.LInsAttr))
+		}
+		if bp.TInsAttr != nil {
+			tIns = measurement.FromEMU(FromSTCoordinate32(*bp.TInsAttr))
+		}
+		if bp.RInsAttr != nil {
+			rIns = measurement.FromEMU(FromSTCoordinate32(*bp.RInsAttr))
+		}
+		if bp.BInsAttr != nil {
+			bIns = measurement.FromEMU(FromSTCoordinate32(*bp.BInsAttr))
+		}
+		if bp.AnchorAttr != dml.ST_TextAnchoringTypeUnset {
+			anchor = bp.AnchorAttr
+		}
+	}
+	x, y, w, h = x+lIns, y+tIns, w-lIns-rIns, h-tIns-bIns
+	if w <= 0 {
+		return
+	}
+	var fontColor creator.Color = creator.ColorBlack
+	if sp.Style != nil && sp.Style.FontRef != nil {
+		ref := sp.Style.FontRef
+		if clr := diagramColor(ref.SrgbClr, ref.SchemeClr, ref.SysClr, theme); clr != nil {
+			fontColor = clr
+		}
+	}
+
+	paras, indents := []*creator.Paragraph{}, []float64{}
+	total := 0.0
+	for _, p := range tb.P {
+		text := strings.Builder{}
+		var rpr *dml.CT_TextCharacterProperties
+		for _, r := range p.EG_TextRun {
+			switch {
+			case r.TextRunChoice.R != nil:
+				text.WriteString(r.TextRunChoice.R.T)
+				if rpr == nil {
+					rpr = r.TextRunChoice.R.RPr
+				}
+			case r.TextRunChoice.Fld != nil && r.TextRunChoice.Fld.T != nil:
+				text.WriteString(*r.TextRunChoice.Fld.T)
+			case r.TextRunChoice.Br != nil:
+				text.WriteString("\n")
+			}
+		}
+		if rpr == nil {
+			rpr = p.EndParaRPr
+		}
+		s := text.String()
+		indent := 0.0
+		align := creator.TextAlignmentCenter
+		if ppr := p.PPr; ppr != nil {
+			switch ppr.AlgnAttr {
+			case dml.ST_TextAlignTypeL:
+				align = creator.TextAlignmentLeft
+			case dml.ST_TextAlignTypeR:
+				align = creator.TextAlignmentRight
+			case dml.ST_TextAlignTypeJust:
+				align = creator.TextAlignmentJustify
+			}
+			if ppr.MarLAttr != nil {
+				indent = measurement.FromEMU(int64(*ppr.MarLAttr))
+			}
+			if bu := ppr.TextBulletChoice.BuChar; bu != nil && s != "" {
+				s = bu.CharAttr + " " + s
+				if ppr.IndentAttr != nil {
+					indent += measurement.FromEMU(int64(*ppr.IndentAttr))
+				}
+			}
+		}
+		if indent < 0 || indent >= w {
+			indent = 0
+		}
+
+		size, bold, color := 18.0, false, fontColor
+		if rpr != nil {
+			if rpr.SzAttr != nil {
+				size = float64(*rpr.SzAttr) / 100
+			}
+			bold = rpr.BAttr != nil && *rpr.BAttr
+			if f := rpr.FillPropertiesChoice.SolidFill; f != nil {
+				if clr := diagramSolidFillColor(f, theme); clr != nil {
+					color = clr
+				}
+			}
+		}
+		para := c.NewParagraph(s)
+		para.SetFont(diagramFont(rpr, bold))
+		para.SetFontSize(size)
+		para.SetColor(color)
+		para.SetWidth(w - indent)
+		para.SetTextAlignment(align)
+		paras = append(paras, para)
+		indents = append(indents, indent)
+		total += para.Height()
+	}
+
+	top := y
+	switch anchor {
+	case dml.ST_TextAnchoringTypeCtr:
+		top = y + (h-total)/2
+	case dml.ST_TextAnchoringTypeB:
+		top = y + h - total
+	}
+	for i, para := range paras {
+		para.SetPos(x+indents[i], top)
+		c.Draw(para)
+		top += para.Height()
+	}
+}
+
+func diagramFont(rpr *dml.CT_TextCharacterProperties, bold bool) *model.PdfFont {
+	style, stdName := FontStyle_Regular, model.HelveticaName
+	if bold {
+		style, stdName = FontStyle_Bold, model.HelveticaBoldName
+	}
+	if rpr != nil && rpr.Latin != nil && rpr.Latin.TypefaceAttr != "" {
+		if f := GetRegisteredFont(rpr.Latin.TypefaceAttr, style); f != nil {
+			return f
+		}
+	}
+	return model.NewStandard14FontMustCompile(stdName)
+}
@@ -0,0 +1,141 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+// Package hyphenation finds hyphenation points in words with Liang's
+// algorithm, using TeX hyphenation patterns.
+package hyphenation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Hyphenator hyphenates words of a language.
+type Hyphenator struct {
+	patterns   map[string][]int
+	exceptions map[string][]int
+	maxLen     int
+	// LeftMin and RightMin are the minimum number of characters kept before
+	// and after a hyphen.
+	LeftMin  int
+	RightMin int
+}
+
+// Parse reads TeX hyphenation patterns, either as a TeX file with \patterns
+// and \hyphenation groups or as a plain list of patterns such as the
+// hyph-*.pat.txt files of the hyph-utf8 project. Exceptions are words with
+// hyphens at the allowed break points, e.g. "ta-ble".
+func Parse(r io.Reader) (*Hyphenator, error) {
+	h := &Hyphenator{patterns: map[string][]int{}, exceptions: map[string][]int{}, LeftMin: 2, RightMin: 3}
+	inExceptions := false
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '%'); i >= 0 {
+			line = line[:i]
+		}
+		for _, tok := range strings.Fields(line) {
+			switch {
+			case strings.HasPrefix(tok, `\patterns`):
+				inExceptions = false
+				tok = strings.TrimPrefix(strings.TrimPrefix(tok, `\patterns`), "{")
+			case strings.HasPrefix(tok, `\hyphenation`):
+				inExceptions = true
+				tok = strings.TrimPrefix(strings.TrimPrefix(tok, `\hyphenation`), "{")
+			case strings.HasPrefix(tok, `\`):
+				return nil, fmt.Errorf("unsupported TeX command %s", tok)
+			}
+			tok = strings.Trim(tok, "{}")
+			if tok == "" {
+				continue
+			}
+			if inExceptions {
+				h.addException(tok)
+			} else {
+				h.addPattern(tok)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(h.patterns) == 0 {
+		return nil, fmt.Errorf("no hyphenation patterns")
+	}
+	return h, nil
+}
+
+// addPattern adds a pattern such as ".ab1c", where digits are the priorities
+// of breaks between the letters and odd priorities allow a break.
+func (h *Hyphenator) addPattern(p string) {
+	letters := []rune{}
+	values := []int{0}
+	for _, c := range p {
+		if c >= '0' && c <= '9' {
+			values[len(values)-1] = int(c - '0')
+		} else {
+			letters = append(letters, unicode.ToLower(c))
+			values = append(values, 0)
+		}
+	}
+	h.patterns[string(letters)] = values
+	if len(letters) > h.maxLen {
+		h.maxLen = len(letters)
+	}
+}
+
+func (h *Hyphenator) addException(w string) {
+	word := []rune{}
+	breaks := []int{}
+	for _, c := range w {
+		if c == '-' {
+			breaks = append(breaks, len(word))
+		} else {
+			word = append(word, unicode.ToLower(c))
+		}
+	}
+	h.exceptions[string(word)] = breaks
+}
+
+// Hyphenate returns the character offsets in the word before which a hyphen
+// may be inserted, in increasing order.
+func (h *Hyphenator) Hyphenate(word string) []int {
+	lower := []rune(strings.ToLower(word))
+	if breaks, ok := h.exceptions[string(lower)]; ok {
+		return breaks
+	}
+	if len(lower) < h.LeftMin+h.RightMin {
+		return nil
+	}
+	w := append(append([]rune{'.'}, lower...), '.')
+	points := make([]int, len(w)+1)
+	for i := range w {
+		for j := i + 1; j <= len(w) && j-i <= h.maxLen; j++ {
+			if values, ok := h.patterns[string(w[i:j])]; ok {
+				for k, v := range values {
+					if v > points[i+k] {
+						points[i+k] = v
+					}
+				}
+			}
+		}
+	}
+	ret := []int{}
+	for i := h.LeftMin; i <= len(lower)-h.RightMin; i++ {
+		// points[i+1] is the priority of a break between w[i] and w[i+1],
+		// that is before character i of the word
+		if points[i+1]%2 == 1 {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,86 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package hyphenation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// patterns are the patterns of Liang's thesis that hyphenate "hyphenation".
+const patterns = `% hyphenation patterns
+\patterns{
+hy3ph he2n hena4 hen5at 1na n2at 1tio 2io o2n
+.ta4b 4ble
+}
+\hyphenation{
+ta-ble pro-ject
+}
+`
+
+func TestHyphenate(t *testing.T) {
+	h, err := Parse(strings.NewReader(patterns))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		word string
+		want []int
+	}{
+		{"hyphenation", []int{2, 6}},
+		{"Hyphenation", []int{2, 6}},
+		// exceptions are used as they are
+		{"table", []int{2}},
+		{"Project", []int{3}},
+		{"nation", []int{2}},
+		// too short to keep two letters before and three after a hyphen
+		{"tion", nil},
+	}
+	for _, tc := range tests {
+		if got := h.Hyphenate(tc.word); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Hyphenate(%q) = %v, want %v", tc.word, got, tc.want)
+		}
+	}
+
+	h.LeftMin, h.RightMin = 3, 4
+	if got := h.Hyphenate("hyphenation"); !reflect.DeepEqual(got, []int{6}) {
+		t.Errorf("Hyphenate(hyphenation) with longer minimums = %v", got)
+	}
+	if got := h.Hyphenate("nation"); got != nil {
+		t.Errorf("Hyphenate(nation) with longer minimums = %v", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	// plain lists of patterns as in the hyph-utf8 project
+	h, err := Parse(strings.NewReader("hy3ph\nhe2n\nhena4\nhen5at\n1na\nn2at\n1tio\n2io\no2n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := h.Hyphenate("hyphenation"); !reflect.DeepEqual(got, []int{2, 6}) {
+		t.Errorf("Hyphenate(hyphenation) = %v", got)
+	}
+	if values := h.patterns["hyph"]; !reflect.DeepEqual(values, []int{0, 0, 3, 0, 0}) {
+		t.Errorf("pattern hy3ph = %v", values)
+	}
+
+	errs := []string{
+		"",
+		"% only a comment",
+		`\hyphenation{ta-ble}`,
+		`\input hyph-en`,
+	}
+	for _, src := range errs {
+		if _, err := Parse(strings.NewReader(src)); err == nil {
+			t.Errorf("no error parsing %q", src)
+		}
+	}
+}
@@ -10,7 +10,7 @@
 // terms that can be accessed at https://unidoc.io/eula/
 
 package convert ;import (_c "bytes";_a "errors";_bb "github.com/unidoc/unioffice/v2/common";_ba "github.com/unidoc/unioffice/v2/common/logger";_ac "github.com/unidoc/unioffice/v2/common/tempstorage";_df "github.com/unidoc/unioffice/v2/internal/convertutils";
-_fa "github.com/unidoc/unioffice/v2/measurement";_fg "github.com/unidoc/unioffice/v2/presentation";_cf "github.com/unidoc/unioffice/v2/schema/soo/dml";_gd "github.com/unidoc/unioffice/v2/schema/soo/dml/chart";_ab "github.com/unidoc/unioffice/v2/schema/soo/pml";
+_fa "github.com/unidoc/unioffice/v2/measurement";_fg "github.com/unidoc/unioffice/v2/presentation";_cf "github.com/unidoc/unioffice/v2/schema/soo/dml";_gd "github.com/unidoc/unioffice/v2/schema/soo/dml/chart";_fgda "github.com/unidoc/unioffice/v2/schema/soo/dml/diagram";_ab "github.com/unidoc/unioffice/v2/schema/soo/pml";
 _ae "github.com/unidoc/unipdf/v4/contentstream/draw";_fac "github.com/unidoc/unipdf/v4/core";_ag "github.com/unidoc/unipdf/v4/creator";_ed "github.com/unidoc/unipdf/v4/model";_dc "github.com/unidoc/unipdf/v4/render";_f "image";_e "image/color";_b "image/draw";
 _fd "math";_ef "strconv";_g "strings";);func _fgefd (_dafa ,_ffecc *_cf .CT_TableStyleTextStyle )*_cf .CT_TableStyleTextStyle {_gccf :=_cf .NewCT_TableStyleTextStyle ();if _dafa !=nil {*_gccf =*_dafa ;};if _ffecc ==nil {return _gccf ;};if _gccf .BAttr ==_cf .ST_OnOffStyleTypeUnset {_gccf .BAttr =_ffecc .BAttr ;
 };if _gccf .IAttr ==_cf .ST_OnOffStyleTypeUnset {_gccf .IAttr =_ffecc .IAttr ;};if _gccf .ThemeableFontStylesChoice .Font ==nil {_gccf .ThemeableFontStylesChoice .Font =_ffecc .ThemeableFontStylesChoice .Font ;};if _gccf .ThemeableFontStylesChoice .FontRef ==nil {_gccf .ThemeableFontStylesChoice .FontRef =_ffecc .ThemeableFontStylesChoice .FontRef ;
@@ -132,7 +132,7 @@ if _ceafe !=nil {*_gefg =*_ceafe ;};if _ededa ==nil {return _gefg ;};if _gefg .R
 if _dge !=nil {_cc ._agf =_dge ;_cc ._dceg =_aa ;};}else if _cbe :=_gee .FillPropertiesChoice .BlipFill ;_cbe !=nil {_cc ._cfbe =_cbe ;};};};};_bbc ._dded =_cc ;if _bc :=_baeg .SpTree ;_bc !=nil {for _ ,_fae :=range _bc .GroupShapeChoice {if _fae !=nil {if _fae .Sp !=nil {_abd :=_bbc .getShapes (_fae .Sp ,_abf ,false );
 _bbc ._eed =append (_bbc ._eed ,_abd ...);};if _fae .GraphicFrame !=nil {var _ccf ,_cca ,_gg ,_bg float64 ;if _fec :=_fae .GraphicFrame .Xfrm ;_fec !=nil {_ccf ,_cca ,_gg ,_bg =_df .GetDataFromXfrm (_fec );};if _gg ==0&&_bg ==0{_gg =_bbc ._bebf ;_bg =_bbc ._cgcc ;
 };if _baa :=_fae .GraphicFrame .Graphic ;_baa !=nil {if _gc :=_baa .GraphicData ;_gc !=nil {for _ ,_dad :=range _gc .Any {if _bgc ,_fgg :=_dad .(*_gd .Chart );_fgg {_eb ,_gb :=_bbc .makePdfBlockFromChart (_bgc ,_gg ,_bg );if _gb !=nil {_ba .Log .Debug ("C\u0061\u006e\u006e\u006ft \u0072e\u0061\u0064\u0020\u0062\u006co\u0063\u006b\u003a\u0020\u0025\u0073",_gb );
-};if _eb !=nil {_eb .SetPos (_ccf ,_cca );_bbc ._eed =append (_bbc ._eed ,_eb );};}else if _fcf ,_agg :=_dad .(*_cf .Tbl );_agg {_ebd :=_bbc .makePdfBlockFromTable (_fcf );if _ebd !=nil {_bce :=_ag .NewBlock (_gg ,_bg );_bce .SetPos (_ccf ,_cca );_dcdb :=_bce .Draw (_ebd );
+};if _eb !=nil {_eb .SetPos (_ccf ,_cca );_bbc ._eed =append (_bbc ._eed ,_eb );};}else if relIds ,ok :=_dad .(*_fgda .RelIds );ok {diagramBlock ,err :=_bbc .makePdfBlockFromDiagram (relIds ,_gg ,_bg );if err !=nil {_ba .Log .Debug ("\u0043\u0061\u006e\u006e\u006f\u0074\u0020\u0072\u0065\u0061\u0064\u0020\u0064\u0069\u0061\u0067\u0072\u0061\u006d\u003a\u0020\u0025\u0073",err );};if diagramBlock !=nil {diagramBlock .SetPos (_ccf ,_cca );_bbc ._eed =append (_bbc ._eed ,diagramBlock );};}else if _fcf ,_agg :=_dad .(*_cf .Tbl );_agg {_ebd :=_bbc .makePdfBlockFromTable (_fcf );if _ebd !=nil {_bce :=_ag .NewBlock (_gg ,_bg );_bce .SetPos (_ccf ,_cca );_dcdb :=_bce .Draw (_ebd );
 if _dcdb !=nil {_ba .Log .Debug ("C\u0061\u006e\u006e\u006ft \u0064r\u0061\u0077\u0020\u0074\u0061b\u006c\u0065\u003a\u0020\u0025\u0073",_dcdb );if _dcdb ==_ag .ErrContentNotFit {_bce =_ag .NewBlock (_bbc ._bebf -1.5*_ccf ,_bbc ._cgcc -1.5*_cca );_bce .SetPos (_ccf ,_cca );
 _dcdb =_bce .Draw (_ebd );};};if _dcdb ==nil {_bbc ._eed =append (_bbc ._eed ,_bce );};};};};};};};if _fae .CxnSp !=nil {_fge :=_bbc .getConnectors (_fae .CxnSp );_bbc ._eed =append (_bbc ._eed ,_fge ...);};if _fae .GrpSp !=nil {_bbb :=0.0;_eec :=0.0;if _bdd :=_fae .GrpSp .GrpSpPr .Xfrm ;
 _bdd !=nil {_bbb ,_eec =_df .GetGroupOffsetFromXfrm (_bdd );};for _ ,_fda :=range _fae .GrpSp .GroupShapeChoice {if _fda .CxnSp !=nil {_gde :=_bbc .getGroupConnectors (_fda .CxnSp ,_bbb ,_eec );_bbc ._eed =append (_bbc ._eed ,_gde ...);};};};if _fae .Pic !=nil {_ebb :=false ;
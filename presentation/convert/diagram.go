@@ -0,0 +1,36 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package convert
+
+import (
+	"errors"
+
+	"github.com/unidoc/unioffice/v2/internal/convertutils"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+	dgm "github.com/unidoc/unioffice/v2/schema/soo/dml/diagram"
+	"github.com/unidoc/unipdf/v4/creator"
+)
+
+// makePdfBlockFromDiagram renders the cached drawing of a SmartArt diagram
+// graphic frame on the current slide.
+func (c *convertContext) makePdfBlockFromDiagram(ids *dgm.RelIds, w, h float64) (*creator.Block, error) {
+	dg, ok := c._efc.GetDiagramByRelIds(&ids.CT_RelIds)
+	if !ok {
+		return nil, errors.New("no diagram data")
+	}
+	if dg.Drawing() == nil {
+		return nil, errors.New("no diagram drawing")
+	}
+	var theme *dml.Theme
+	if themes := c._ggda.Themes(); len(themes) > 0 {
+		theme = themes[0]
+	}
+	return convertutils.MakeBlockFromDiagramDrawing(dg.Drawing(), w, h, theme)
+}
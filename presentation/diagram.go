@@ -0,0 +1,212 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package presentation
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/common/logger"
+	"github.com/unidoc/unioffice/v2/common/tempstorage"
+	"github.com/unidoc/unioffice/v2/diagram"
+	"github.com/unidoc/unioffice/v2/measurement"
+	dgm "github.com/unidoc/unioffice/v2/schema/soo/dml/diagram"
+)
+
+// diagramPart is a SmartArt diagram loaded from a slide, written back when
+// the presentation is saved.
+type diagramPart struct {
+	dg          *diagram.Diagram
+	rels        common.Relationships
+	dataPath    string
+	drawingPath string
+}
+
+// Diagrams returns the SmartArt diagrams on the slide. Changes made to the
+// diagrams are written when the presentation is saved.
+func (s *Slide) Diagrams() []*diagram.Diagram {
+	ret := []*diagram.Diagram{}
+	if s._gddb.CSld == nil || s._gddb.CSld.SpTree == nil {
+		return ret
+	}
+	for _, c := range s._gddb.CSld.SpTree.GroupShapeChoice {
+		if c == nil || c.GraphicFrame == nil || c.GraphicFrame.Graphic == nil || c.GraphicFrame.Graphic.GraphicData == nil {
+			continue
+		}
+		var cx, cy int64
+		if xfrm := c.GraphicFrame.Xfrm; xfrm != nil && xfrm.Ext != nil {
+			cx, cy = xfrm.Ext.CxAttr, xfrm.Ext.CyAttr
+		}
+		for _, a := range c.GraphicFrame.Graphic.GraphicData.Any {
+			if ids, ok := a.(*dgm.RelIds); ok {
+				if dg, ok := s.diagram(&ids.CT_RelIds, cx, cy); ok {
+					ret = append(ret, dg)
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// GetDiagramByRelIds returns the SmartArt diagram referenced by the
+// relationship IDs of a diagram graphic frame on the slide.
+func (s *Slide) GetDiagramByRelIds(ids *dgm.CT_RelIds) (*diagram.Diagram, bool) {
+	return s.diagram(ids, 0, 0)
+}
+
+// diagram returns the diagram with the given relationship IDs, loading it
+// from the presentation parts on first use. Non-zero sizes in EMU set the
+// size of the diagram frame.
+func (s *Slide) diagram(ids *dgm.CT_RelIds, cx, cy int64) (*diagram.Diagram, bool) {
+	rels := s.getSlideRels()
+	if (rels == common.Relationships{}) {
+		return nil, false
+	}
+	p := s._cbab
+	dataPath, storagePath := p.diagramPartPath(rels, ids.DmAttr)
+	if storagePath == "" {
+		return nil, false
+	}
+	var dg *diagram.Diagram
+	for _, dp := range p.diagrams {
+		if dp.dataPath == dataPath {
+			dg = dp.dg
+		}
+	}
+	if dg == nil {
+		f, err := tempstorage.Open(storagePath)
+		if err != nil {
+			logger.Log.Debug("unable to open diagram data: %s", err)
+			return nil, false
+		}
+		dg, err = diagram.Read(f)
+		f.Close()
+		if err != nil {
+			logger.Log.Debug("unable to read diagram data: %s", err)
+			return nil, false
+		}
+		dp := &diagramPart{dg: dg, rels: rels, dataPath: dataPath}
+		if drawingPath, drawingStorage := p.diagramPartPath(rels, dg.DrawingRelID()); drawingStorage != "" {
+			if f, err := tempstorage.Open(drawingStorage); err == nil {
+				dr, err := diagram.ReadDrawing(f)
+				f.Close()
+				if err == nil {
+					dg.SetDrawing(dr)
+					dp.drawingPath = drawingPath
+				} else {
+					logger.Log.Debug("unable to read diagram drawing: %s", err)
+				}
+			}
+		}
+		p.diagrams = append(p.diagrams, dp)
+	}
+	if cx > 0 && cy > 0 {
+		dg.SetSize(measurement.Distance(cx)*measurement.EMU, measurement.Distance(cy)*measurement.EMU)
+	}
+	return dg, true
+}
+
+// diagramPartPath returns the package path and the storage path of the
+// diagram part with the given slide relationship ID.
+func (p *Presentation) diagramPartPath(rels common.Relationships, relID string) (string, string) {
+	if relID == "" {
+		return "", ""
+	}
+	target := rels.GetTargetByRelId(relID)
+	if target == "" {
+		return "", ""
+	}
+	zipPath := strings.TrimPrefix(target, "/")
+	if !strings.HasPrefix(target, "/") {
+		zipPath = path.Join("ppt/slides", target)
+	}
+	for _, ef := range p.ExtraFiles {
+		if ef.ZipPath == zipPath {
+			return zipPath, ef.StoragePath
+		}
+	}
+	return zipPath, ""
+}
+
+// saveDiagrams writes the loaded diagrams back to their parts, adding a
+// drawing part to diagrams that have a drawing but had none when loaded.
+func (p *Presentation) saveDiagrams() error {
+	for _, dp := range p.diagrams {
+		if dp.dg.Drawing() != nil {
+			if dp.drawingPath == "" {
+				dp.drawingPath = p.freeExtraFilePath("ppt/diagrams/drawing%d.xml", 1)
+				rel := dp.rels.AddRelationship(strings.Replace(dp.drawingPath, "ppt/", "../", 1), diagram.DrawingType)
+				dp.dg.SetDrawingRelID(rel.ID())
+				p.ContentTypes.AddOverride("/"+dp.drawingPath, diagram.DrawingContentType)
+			}
+			storagePath, err := writeTempFile("diagram-drawing-", func(f tempstorage.File) error {
+				return dp.dg.Drawing().Write(f)
+			})
+			if err != nil {
+				return err
+			}
+			p.setExtraFile(dp.drawingPath, storagePath)
+		}
+		storagePath, err := writeTempFile("diagram-data-", func(f tempstorage.File) error {
+			return dp.dg.Write(f)
+		})
+		if err != nil {
+			return err
+		}
+		p.setExtraFile(dp.dataPath, storagePath)
+	}
+	return nil
+}
+
+// freeExtraFilePath returns the first path generated from the format and an
+// index starting at idx that is not used by an extra file.
+func (p *Presentation) freeExtraFilePath(format string, idx int) string {
+	for {
+		path := fmt.Sprintf(format, idx)
+		used := false
+		for _, ef := range p.ExtraFiles {
+			if ef.ZipPath == path {
+				used = true
+				break
+			}
+		}
+		if !used {
+			return path
+		}
+		idx++
+	}
+}
+
+// setExtraFile sets the storage path of the extra file with the given package
+// path, adding the extra file if it doesn't exist.
+func (p *Presentation) setExtraFile(zipPath, storagePath string) {
+	for i, ef := range p.ExtraFiles {
+		if ef.ZipPath == zipPath {
+			p.ExtraFiles[i].StoragePath = storagePath
+			return
+		}
+	}
+	p.ExtraFiles = append(p.ExtraFiles, common.ExtraFile{ZipPath: zipPath, StoragePath: storagePath})
+}
+
+// writeTempFile writes a file to temporary storage and returns its path.
+func writeTempFile(pattern string, write func(f tempstorage.File) error) (string, error) {
+	f, err := tempstorage.TempFile("", pattern)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := write(f); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -169,7 +169,7 @@ _eag .Xfrm .Off =_ee .NewCT_Point2D ();_ebd :=int64 (1);_eag .Xfrm .Off .XAttr =
 _dae .UriAttr ="\u0068\u0074\u0074\u0070\u003a\u002f\u002f\u0073\u0063\u0068\u0065\u006d\u0061\u0073\u002eo\u0070\u0065\u006e\u0078\u006d\u006c\u0066\u006f\u0072\u006d\u0061\u0074\u0073.\u006f\u0072\u0067\u002f\u0064\u0072\u0061\u0077\u0069\u006e\u0067\u006dl/\u0032\u0030\u0030\u0036\u002f\u0074\u0061\u0062\u006c\u0065";
 _aabc :=_ge .NewTableWithXfrm (_eag .Xfrm );_dae .Any =append (_dae .Any ,_aabc .X ());return _aabc ;};func (_febd *Presentation )save (_degd _ccf .Writer ,_abc bool )error {const _dfb ="\u0050\u0072\u0065\u0073en\u0074\u0061\u0074\u0069\u006f\u006e\u003a\u0070\u002e\u0053\u0061\u0076\u0065";
 if _fbae :=_febd ._dgf .Validate ();_fbae !=nil {_gg .Log .Debug ("\u0076\u0061\u006c\u0069\u0064\u0061\u0074\u0069\u006f\u006e\u0020\u0065\u0072\u0072\u006fr\u0020i\u006e\u0020\u0064\u006f\u0063\u0075\u006d\u0065\u006e\u0074\u003a\u0020\u0025\u0073",_fbae );
-};if !_e .GetLicenseKey ().IsLicensed ()&&!_geg {_ed .Println ("\u0055\u006e\u006ci\u0063\u0065\u006e\u0073e\u0064\u0020\u0076\u0065\u0072\u0073\u0069o\u006e\u0020\u006f\u0066\u0020\u0055\u006e\u0069\u004f\u0066\u0066\u0069\u0063\u0065");_ed .Println ("\u002d\u0020\u0047e\u0074\u0020\u0061\u0020\u0074\u0072\u0069\u0061\u006c\u0020\u006c\u0069\u0063\u0065\u006e\u0073\u0065\u0020\u006f\u006e\u0020\u0068\u0074\u0074\u0070\u0073\u003a\u002f\u002fu\u006e\u0069\u0064\u006f\u0063\u002e\u0069\u006f");
+};if err :=_febd .saveDiagrams ();err !=nil {return err ;};if !_e .GetLicenseKey ().IsLicensed ()&&!_geg {_ed .Println ("\u0055\u006e\u006ci\u0063\u0065\u006e\u0073e\u0064\u0020\u0076\u0065\u0072\u0073\u0069o\u006e\u0020\u006f\u0066\u0020\u0055\u006e\u0069\u004f\u0066\u0066\u0069\u0063\u0065");_ed .Println ("\u002d\u0020\u0047e\u0074\u0020\u0061\u0020\u0074\u0072\u0069\u0061\u006c\u0020\u006c\u0069\u0063\u0065\u006e\u0073\u0065\u0020\u006f\u006e\u0020\u0068\u0074\u0074\u0070\u0073\u003a\u002f\u002fu\u006e\u0069\u0064\u006f\u0063\u002e\u0069\u006f");
 return _ggc .New ("\u0075\u006e\u0069\u006f\u0066\u0066\u0069\u0063\u0065\u0020\u006ci\u0063\u0065\u006e\u0073\u0065\u0020\u0072\u0065\u0071\u0075i\u0072\u0065\u0064");};_deb :="\u0075n\u006b\u006e\u006f\u0077\u006e";if _afd ,_afed :=_degd .(*_cd .File );
 _afed {_deb =_afd .Name ();};if len (_febd ._ecf )==0{_aed ,_gde :=_e .GenRefId ("\u0070\u0077");if _gde !=nil {_gg .Log .Error ("\u0045R\u0052\u004f\u0052\u003a\u0020\u0025v",_gde );return _gde ;};_febd ._ecf =_aed ;};if _gfc :=_e .Track (_febd ._ecf ,_dfb ,_deb );
 _gfc !=nil {_gg .Log .Error ("\u0045R\u0052\u004f\u0052\u003a\u0020\u0025v",_gfc );return _gfc ;};if _abc {_febd .ContentTypes .RemoveOverride ("\u0061\u0070\u0070\u006c\u0069\u0063\u0061t\u0069\u006f\u006e\u002f\u0076\u006e\u0064\u002e\u006f\u0070\u0065\u006e\u0078\u006d\u006c\u0066\u006f\u0072m\u0061\u0074\u0073\u002d\u006ff\u0066\u0069\u0063\u0065\u0064\u006f\u0063\u0075\u006de\u006e\u0074\u002e\u0070\u0072\u0065\u0073\u0065\u006e\u0074\u0061\u0074\u0069\u006f\u006e\u006d\u006c\u002e\u0070\u0072\u0065\u0073\u0065\u006e\u0074\u0061\u0074\u0069\u006f\u006e\u002e\u006d\u0061\u0069\u006e\u002b\u0078\u006d\u006c");
@@ -433,7 +433,7 @@ func (_bdac ViewProperties )ExtLst ()*_cf .CT_ExtensionList {return _bdac ._dddf
 
 // Presentation is the a presentation base document.
 type Presentation struct{_ge .DocBase ;_dgf *_cf .Presentation ;_cbb _ge .Relationships ;_fde []*_cf .Sld ;_bafb []_ge .Relationships ;_ff []int ;_dfd []*_cf .SldMaster ;_cac []_ge .Relationships ;_gceb []int ;_cbag []*_cf .SldLayout ;_gbd []_ge .Relationships ;
-_adfc []*_ee .Theme ;_ec []_ge .Relationships ;_dfgd []int ;_ffe _ge .TableStyles ;_bcaf PresentationProperties ;_eeae ViewProperties ;_ddd []*_ee .CT_Hyperlink ;_gcd []*chart ;_dbf []*_cf .HandoutMaster ;_efab []*_cf .NotesMaster ;_bbd []int ;_gfa []*_gd .XSDAny ;
+_adfc []*_ee .Theme ;_ec []_ge .Relationships ;_dfgd []int ;_ffe _ge .TableStyles ;_bcaf PresentationProperties ;_eeae ViewProperties ;_ddd []*_ee .CT_Hyperlink ;_gcd []*chart ;diagrams []*diagramPart ;_dbf []*_cf .HandoutMaster ;_efab []*_cf .NotesMaster ;_bbd []int ;_gfa []*_gd .XSDAny ;
 _gag []int ;_dcc map[string ]string ;_ecf string ;};
 
 // Properties returns the properties of the TextBox.
@@ -0,0 +1,23 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package presentation
+
+import "github.com/unidoc/unioffice/v2/common"
+
+// ApplyTheme replaces each theme of the presentation with a copy of a theme, e.g.
+// one taken from a template with common.MakeTheme.
+func (p *Presentation) ApplyTheme(t common.Theme) error {
+	for _, th := range p.Themes() {
+		if err := common.MakeTheme(th).Apply(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
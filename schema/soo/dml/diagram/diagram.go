@@ -806,7 +806,7 @@ if _befde :=d .DecodeElement (_dbfd .CxnLst ,&_cdfag );_befde !=nil {return _bef
 if _gdfa :=d .DecodeElement (_dbfd .Bg ,&_cdfag );_gdfa !=nil {return _gdfa ;};case _a .Name {Space :"\u0068\u0074\u0074\u0070\u003a\u002f/\u0073\u0063\u0068e\u006d\u0061\u0073.\u006f\u0070\u0065\u006e\u0078\u006d\u006c\u0066\u006f\u0072m\u0061\u0074\u0073\u002e\u006frg\u002f\u0064\u0072\u0061\u0077\u0069\u006e\u0067\u006d\u006c\u002f\u0032\u0030\u0030\u0036\u002f\u0064\u0069\u0061\u0067\u0072\u0061\u006d",Local :"\u0077\u0068\u006fl\u0065"}:_dbfd .Whole =_ce .NewCT_WholeE2oFormatting ();
 if _acfbc :=d .DecodeElement (_dbfd .Whole ,&_cdfag );_acfbc !=nil {return _acfbc ;};case _a .Name {Space :"\u0068\u0074\u0074\u0070\u003a\u002f/\u0073\u0063\u0068e\u006d\u0061\u0073.\u006f\u0070\u0065\u006e\u0078\u006d\u006c\u0066\u006f\u0072m\u0061\u0074\u0073\u002e\u006frg\u002f\u0064\u0072\u0061\u0077\u0069\u006e\u0067\u006d\u006c\u002f\u0032\u0030\u0030\u0036\u002f\u0064\u0069\u0061\u0067\u0072\u0061\u006d",Local :"\u0065\u0078\u0074\u004c\u0073\u0074"}:_dbfd .ExtLst =_ce .NewCT_OfficeArtExtensionList ();
 if _ecgbf :=d .DecodeElement (_dbfd .ExtLst ,&_cdfag );_ecgbf !=nil {return _ecgbf ;};default:_d .Log .Debug ("\u0073k\u0069\u0070p\u0069\u006e\u0067\u0020u\u006e\u0073\u0075p\u0070\u006f\u0072\u0074\u0065\u0064\u0020\u0065\u006cem\u0065\u006e\u0074 \u006f\u006e \u0044\u0061\u0074\u0061\u004d\u006fd\u0065\u006c \u0025\u0076",_cdfag .Name );
-if _fagb :=d .Skip ();_fagb !=nil {return _fagb ;};};case _a .EndElement :break _cafda ;case _a .CharData :};};return nil ;};func (_gcdea ST_TextAnchorVertical )Validate ()error {return _gcdea .ValidateWithPath ("")};func ParseUnionST_ModelId (s string )(ST_ModelId ,error ){return ST_ModelId {},nil };
+if _fagb :=d .Skip ();_fagb !=nil {return _fagb ;};};case _a .EndElement :break _cafda ;case _a .CharData :};};return nil ;};func (_gcdea ST_TextAnchorVertical )Validate ()error {return _gcdea .ValidateWithPath ("")};func ParseUnionST_ModelId (s string )(ST_ModelId ,error ){if s ==""{return ST_ModelId {},nil ;};if v ,err :=_g .ParseInt (s ,10,32);err ==nil {id :=int32 (v );return ST_ModelId {Int32 :&id },nil ;};return ST_ModelId {ST_Guid :&s },nil ;};
 type CT_LayoutNodeChoice struct{
 
 // Algorithm
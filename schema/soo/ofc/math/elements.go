@@ -0,0 +1,36 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package math
+
+import "encoding/xml"
+
+// mathElements are the local names of the elements of EG_OMathMathElements.
+var mathElements = map[string]bool{
+	"acc": true, "bar": true, "box": true, "borderBox": true, "d": true,
+	"eqArr": true, "f": true, "func": true, "groupChr": true, "limLow": true,
+	"limUpp": true, "m": true, "nary": true, "phant": true, "rad": true,
+	"sPre": true, "sSub": true, "sSubSup": true, "sSup": true, "r": true,
+}
+
+// decodeOMathElement decodes the element started by start and appends it to
+// elems if it is one of the elements of EG_OMathElements. It returns false,
+// without consuming the element, otherwise.
+func decodeOMathElement(d *xml.Decoder, start xml.StartElement, elems *[]*EG_OMathElements) (bool, error) {
+	if !mathElements[start.Name.Local] || (start.Name.Space != "http://schemas.openxmlformats.org/officeDocument/2006/math" &&
+		start.Name.Space != "http://purl.oclc.org/ooxml/officeDocument/math") {
+		return false, nil
+	}
+	el := NewEG_OMathElements()
+	if err := el.OMathElementsChoice.UnmarshalXML(d, start); err != nil {
+		return true, err
+	}
+	*elems = append(*elems, el)
+	return true, nil
+}
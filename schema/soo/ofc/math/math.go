@@ -15,8 +15,8 @@ package math ;import (_a "encoding/xml";_g "fmt";_fc "github.com/unidoc/unioffic
 func (_acbb *CT_Shp )Validate ()error {return _acbb .ValidateWithPath ("\u0043\u0054\u005f\u0053\u0068\u0070");};func (_fdffc *CT_OMathArg )UnmarshalXML (d *_a .Decoder ,start _a .StartElement )error {_edgg :for {_becef ,_dbgga :=d .Token ();if _dbgga !=nil {return _dbgga ;
 };switch _eae :=_becef .(type ){case _a .StartElement :switch _eae .Name {case _a .Name {Space :"\u0068\u0074\u0074\u0070\u003a\u002f\u002f\u0073\u0063\u0068\u0065\u006d\u0061\u0073\u002e\u006f\u0070\u0065\u006e\u0078m\u006c\u0066\u006f\u0072\u006d\u0061\u0074\u0073\u002eo\u0072\u0067\u002f\u006f\u0066\u0066\u0069\u0063\u0065\u0044\u006f\u0063\u0075m\u0065\u006e\u0074\u002f\u0032\u00300\u0036\u002f\u006da\u0074\u0068",Local :"\u0061\u0072\u0067P\u0072"},_a .Name {Space :"\u0068\u0074t\u0070\u003a\u002f\u002f\u0070\u0075\u0072\u006c\u002e\u006f\u0063\u006c\u0063\u002e\u006f\u0072\u0067\u002f\u006f\u006f\u0078\u006d\u006c\u002f\u006f\u0066\u0066\u0069\u0063\u0065\u0044\u006f\u0063\u0075\u006d\u0065\u006e\u0074\u002f\u006d\u0061\u0074\u0068",Local :"\u0061\u0072\u0067P\u0072"}:_fdffc .ArgPr =NewCT_OMathArgPr ();
 if _bdgf :=d .DecodeElement (_fdffc .ArgPr ,&_eae );_bdgf !=nil {return _bdgf ;};case _a .Name {Space :"\u0068\u0074\u0074\u0070\u003a\u002f\u002f\u0073\u0063\u0068\u0065\u006d\u0061\u0073\u002e\u006f\u0070\u0065\u006e\u0078m\u006c\u0066\u006f\u0072\u006d\u0061\u0074\u0073\u002eo\u0072\u0067\u002f\u006f\u0066\u0066\u0069\u0063\u0065\u0044\u006f\u0063\u0075m\u0065\u006e\u0074\u002f\u0032\u00300\u0036\u002f\u006da\u0074\u0068",Local :"\u0063\u0074\u0072\u006c\u0050\u0072"},_a .Name {Space :"\u0068\u0074t\u0070\u003a\u002f\u002f\u0070\u0075\u0072\u006c\u002e\u006f\u0063\u006c\u0063\u002e\u006f\u0072\u0067\u002f\u006f\u006f\u0078\u006d\u006c\u002f\u006f\u0066\u0066\u0069\u0063\u0065\u0044\u006f\u0063\u0075\u006d\u0065\u006e\u0074\u002f\u006d\u0061\u0074\u0068",Local :"\u0063\u0074\u0072\u006c\u0050\u0072"}:_fdffc .CtrlPr =NewCT_CtrlPr ();
-if _bgbcf :=d .DecodeElement (_fdffc .CtrlPr ,&_eae );_bgbcf !=nil {return _bgbcf ;};default:_b .Log .Debug ("\u0073\u006bi\u0070\u0070\u0069\u006e\u0067\u0020\u0075\u006e\u0073\u0075\u0070\u0070\u006f\u0072\u0074\u0065\u0064\u0020\u0065\u006c\u0065\u006d\u0065\u006e\u0074\u0020\u006f\u006e\u0020\u0043\u0054\u005f\u004f\u004d\u0061\u0074\u0068\u0041\u0072\u0067\u0020\u0025\u0076",_eae .Name );
-if _geeba :=d .Skip ();_geeba !=nil {return _geeba ;};};case _a .EndElement :break _edgg ;case _a .CharData :};};return nil ;};func (_fdcbf *EG_OMathElements )MarshalXML (e *_a .Encoder ,start _a .StartElement )error {_fdcbf .OMathElementsChoice .MarshalXML (e ,_a .StartElement {});
+if _bgbcf :=d .DecodeElement (_fdffc .CtrlPr ,&_eae );_bgbcf !=nil {return _bgbcf ;};default:if _ok ,_err :=decodeOMathElement (d ,_eae ,&_fdffc .EG_OMathElements );_err !=nil {return _err ;}else if !_ok {_b .Log .Debug ("\u0073\u006bi\u0070\u0070\u0069\u006e\u0067\u0020\u0075\u006e\u0073\u0075\u0070\u0070\u006f\u0072\u0074\u0065\u0064\u0020\u0065\u006c\u0065\u006d\u0065\u006e\u0074\u0020\u006f\u006e\u0020\u0043\u0054\u005f\u004f\u004d\u0061\u0074\u0068\u0041\u0072\u0067\u0020\u0025\u0076",_eae .Name );
+if _geeba :=d .Skip ();_geeba !=nil {return _geeba ;};};};case _a .EndElement :break _edgg ;case _a .CharData :};};return nil ;};func (_fdcbf *EG_OMathElements )MarshalXML (e *_a .Encoder ,start _a .StartElement )error {_fdcbf .OMathElementsChoice .MarshalXML (e ,_a .StartElement {});
 return nil ;};type CT_MPr struct{
 
 // Matrix Base Justification
@@ -855,8 +855,8 @@ OMathParaPr *CT_OMathParaPr ;
 OMath []*CT_OMath ;};func NewCT_OMath ()*CT_OMath {_gceg :=&CT_OMath {};return _gceg };func (_eccf *ST_TopBot )UnmarshalXMLAttr (attr _a .Attr )error {switch attr .Value {case "":*_eccf =0;case "\u0074\u006f\u0070":*_eccf =1;case "\u0062\u006f\u0074":*_eccf =2;
 };return nil ;};type EG_OMathElements struct{OMathElementsChoice *EG_OMathElementsChoice ;};func (_edfce ST_BreakBinSub )MarshalXMLAttr (name _a .Name )(_a .Attr ,error ){_gbfg :=_a .Attr {};_gbfg .Name =name ;switch _edfce {case ST_BreakBinSubUnset :_gbfg .Value ="";
 case ST_BreakBinSub__ :_gbfg .Value ="\u002d\u002d";case ST_BreakBinSub___ :_gbfg .Value ="\u002d\u002b";case ST_BreakBinSub____ :_gbfg .Value ="\u002b\u002d";};return _gbfg ,nil ;};func (_aefe *CT_OMath )UnmarshalXML (d *_a .Decoder ,start _a .StartElement )error {_gfaa :for {_fegc ,_eccag :=d .Token ();
-if _eccag !=nil {return _eccag ;};switch _agce :=_fegc .(type ){case _a .StartElement :switch _agce .Name {default:_b .Log .Debug ("\u0073\u006b\u0069\u0070\u0070\u0069\u006eg\u0020\u0075\u006es\u0075\u0070\u0070\u006fr\u0074\u0065\u0064\u0020\u0065\u006c\u0065\u006d\u0065\u006e\u0074\u0020\u006f\u006e\u0020\u0043\u0054\u005f\u004f\u004d\u0061\u0074\u0068\u0020\u0025\u0076",_agce .Name );
-if _feffeg :=d .Skip ();_feffeg !=nil {return _feffeg ;};};case _a .EndElement :break _gfaa ;case _a .CharData :};};return nil ;};func (_ddac ST_BreakBinSub )Validate ()error {return _ddac .ValidateWithPath ("")};func NewCT_NaryPr ()*CT_NaryPr {_badb :=&CT_NaryPr {};
+if _eccag !=nil {return _eccag ;};switch _agce :=_fegc .(type ){case _a .StartElement :switch _agce .Name {default:if _ok ,_err :=decodeOMathElement (d ,_agce ,&_aefe .EG_OMathElements );_err !=nil {return _err ;}else if !_ok {_b .Log .Debug ("\u0073\u006b\u0069\u0070\u0070\u0069\u006eg\u0020\u0075\u006es\u0075\u0070\u0070\u006fr\u0074\u0065\u0064\u0020\u0065\u006c\u0065\u006d\u0065\u006e\u0074\u0020\u006f\u006e\u0020\u0043\u0054\u005f\u004f\u004d\u0061\u0074\u0068\u0020\u0025\u0076",_agce .Name );
+if _feffeg :=d .Skip ();_feffeg !=nil {return _feffeg ;};};};case _a .EndElement :break _gfaa ;case _a .CharData :};};return nil ;};func (_ddac ST_BreakBinSub )Validate ()error {return _ddac .ValidateWithPath ("")};func NewCT_NaryPr ()*CT_NaryPr {_badb :=&CT_NaryPr {};
 return _badb };
 
 // ValidateWithPath validates the CT_SPre and its children, prefixing error messages with path
@@ -1711,8 +1711,8 @@ Sub *CT_OMathArg ;
 Sup *CT_OMathArg ;
 
 // Base
-E *CT_OMathArg ;};func (_eaee *OMath )UnmarshalXML (d *_a .Decoder ,start _a .StartElement )error {_eaee .CT_OMath =*NewCT_OMath ();_gega :for {_bbafg ,_ccfee :=d .Token ();if _ccfee !=nil {return _ccfee ;};switch _dfcg :=_bbafg .(type ){case _a .StartElement :switch _dfcg .Name {default:_b .Log .Debug ("s\u006b\u0069\u0070\u0070\u0069\u006e\u0067\u0020\u0075\u006e\u0073\u0075\u0070\u0070\u006f\u0072\u0074\u0065d\u0020\u0065\u006c\u0065\u006d\u0065\u006e\u0074\u0020\u006fn \u004f\u004d\u0061t\u0068 \u0025\u0076",_dfcg .Name );
-if _aeegd :=d .Skip ();_aeegd !=nil {return _aeegd ;};};case _a .EndElement :break _gega ;case _a .CharData :};};return nil ;};func (_ggcf *CT_SSubPr )UnmarshalXML (d *_a .Decoder ,start _a .StartElement )error {_edbfb :for {_gcce ,_bcgg :=d .Token ();
+E *CT_OMathArg ;};func (_eaee *OMath )UnmarshalXML (d *_a .Decoder ,start _a .StartElement )error {_eaee .CT_OMath =*NewCT_OMath ();_gega :for {_bbafg ,_ccfee :=d .Token ();if _ccfee !=nil {return _ccfee ;};switch _dfcg :=_bbafg .(type ){case _a .StartElement :switch _dfcg .Name {default:if _ok ,_err :=decodeOMathElement (d ,_dfcg ,&_eaee .EG_OMathElements );_err !=nil {return _err ;}else if !_ok {_b .Log .Debug ("s\u006b\u0069\u0070\u0070\u0069\u006e\u0067\u0020\u0075\u006e\u0073\u0075\u0070\u0070\u006f\u0072\u0074\u0065d\u0020\u0065\u006c\u0065\u006d\u0065\u006e\u0074\u0020\u006fn \u004f\u004d\u0061t\u0068 \u0025\u0076",_dfcg .Name );
+if _aeegd :=d .Skip ();_aeegd !=nil {return _aeegd ;};};};case _a .EndElement :break _gega ;case _a .CharData :};};return nil ;};func (_ggcf *CT_SSubPr )UnmarshalXML (d *_a .Decoder ,start _a .StartElement )error {_edbfb :for {_gcce ,_bcgg :=d .Token ();
 if _bcgg !=nil {return _bcgg ;};switch _becc :=_gcce .(type ){case _a .StartElement :switch _becc .Name {case _a .Name {Space :"\u0068\u0074\u0074\u0070\u003a\u002f\u002f\u0073\u0063\u0068\u0065\u006d\u0061\u0073\u002e\u006f\u0070\u0065\u006e\u0078m\u006c\u0066\u006f\u0072\u006d\u0061\u0074\u0073\u002eo\u0072\u0067\u002f\u006f\u0066\u0066\u0069\u0063\u0065\u0044\u006f\u0063\u0075m\u0065\u006e\u0074\u002f\u0032\u00300\u0036\u002f\u006da\u0074\u0068",Local :"\u0063\u0074\u0072\u006c\u0050\u0072"},_a .Name {Space :"\u0068\u0074t\u0070\u003a\u002f\u002f\u0070\u0075\u0072\u006c\u002e\u006f\u0063\u006c\u0063\u002e\u006f\u0072\u0067\u002f\u006f\u006f\u0078\u006d\u006c\u002f\u006f\u0066\u0066\u0069\u0063\u0065\u0044\u006f\u0063\u0075\u006d\u0065\u006e\u0074\u002f\u006d\u0061\u0074\u0068",Local :"\u0063\u0074\u0072\u006c\u0050\u0072"}:_ggcf .CtrlPr =NewCT_CtrlPr ();
 if _gefed :=d .DecodeElement (_ggcf .CtrlPr ,&_becc );_gefed !=nil {return _gefed ;};default:_b .Log .Debug ("\u0073k\u0069\u0070p\u0069\u006e\u0067\u0020u\u006e\u0073\u0075p\u0070\u006f\u0072\u0074\u0065\u0064\u0020\u0065\u006cem\u0065\u006e\u0074 \u006f\u006e \u0043\u0054\u005f\u0053\u0053\u0075b\u0050\u0072 \u0025\u0076",_becc .Name );
 if _bfeg :=d .Skip ();_bfeg !=nil {return _bfeg ;};};case _a .EndElement :break _edbfb ;case _a .CharData :};};return nil ;};func (_gggga ST_BreakBin )String ()string {switch _gggga {case 0:return "";case 1:return "\u0062\u0065\u0066\u006f\u0072\u0065";
@@ -0,0 +1,1173 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/common/logger"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/spreadsheet/formula"
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+)
+
+// Default iterative calculation settings, as in Excel.
+const (
+	DefaultIterateCount = 100
+	DefaultIterateDelta = 0.001
+)
+
+// maxSpillPasses bounds the passes recalculating the formulas referring to
+// the cells dynamic array formulas spilled into.
+const maxSpillPasses = 16
+
+// Recalculate recalculates the formulas affected by the cells changed with
+// the cell setters since the last calculation, such as Cell.SetNumber or
+// Cell.SetFormulaRaw, along with the volatile formulas calling functions such
+// as NOW, RAND, OFFSET or INDIRECT. The first call recalculates every formula,
+// as do the calls following changes made to the sheets, defined names or
+// tables, or made through the XML types, which can't be tracked.
+func (wb *Workbook) Recalculate() {
+	wb.calc().recalculate(false)
+}
+
+// CircularReferences returns the cells whose formula refers to itself,
+// directly or through other formulas, such as "Sheet1!A1". Circular
+// references are calculated iteratively if enabled with
+// SetIterativeCalculation, their cached value being left empty otherwise.
+func (wb *Workbook) CircularReferences() []string {
+	e := wb.calc()
+	e.update()
+	var refs []string
+	for _, scc := range e.components(e.all()) {
+		if !scc.circular() {
+			continue
+		}
+		for _, n := range scc {
+			refs = append(refs, n.String())
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// SetCalculationWorkers sets the number of goroutines evaluating formulas
+// independent of each other concurrently, one by default.
+func (wb *Workbook) SetCalculationWorkers(n int) {
+	if n < 1 {
+		n = 1
+	}
+	wb.calc().workers = n
+}
+
+// IterativeCalculation returns whether circular references are calculated
+// iteratively, with the maximum number of iterations and the maximum change
+// between two iterations below which the calculation stops.
+func (wb *Workbook) IterativeCalculation() (enabled bool, maxIterations uint32, maxChange float64) {
+	maxIterations, maxChange = DefaultIterateCount, DefaultIterateDelta
+	pr := wb._gbadf.CalcPr
+	if pr == nil {
+		return false, maxIterations, maxChange
+	}
+	if pr.IterateCountAttr != nil {
+		maxIterations = *pr.IterateCountAttr
+	}
+	if pr.IterateDeltaAttr != nil {
+		maxChange = *pr.IterateDeltaAttr
+	}
+	return pr.IterateAttr != nil && *pr.IterateAttr, maxIterations, maxChange
+}
+
+// SetIterativeCalculation enables or disables the iterative calculation of
+// circular references, stored in the calculation properties of the workbook.
+// The calculation of circular references stops after maxIterations or when no
+// result changes by more than maxChange.
+func (wb *Workbook) SetIterativeCalculation(enabled bool, maxIterations uint32, maxChange float64) {
+	if wb._gbadf.CalcPr == nil {
+		wb._gbadf.CalcPr = sml.NewCT_CalcPr()
+	}
+	pr := wb._gbadf.CalcPr
+	if !enabled {
+		pr.IterateAttr = nil
+		pr.IterateCountAttr = nil
+		pr.IterateDeltaAttr = nil
+		return
+	}
+	pr.IterateAttr = unioffice.Bool(true)
+	pr.IterateCountAttr = unioffice.Uint32(maxIterations)
+	pr.IterateDeltaAttr = unioffice.Float64(maxChange)
+}
+
+func (wb *Workbook) calc() *calcEngine {
+	if wb._fdcbg == nil {
+		wb._fdcbg = &calcEngine{wb: wb, workers: 1}
+	}
+	return wb._fdcbg
+}
+
+// trackShift records the positions of the cells of a sheet and the formulas
+// of the workbook before rows or columns of the sheet are inserted or removed.
+// The returned function, called once the cells are moved, marks the cells
+// that moved, were removed or whose formula was updated as changed, so that
+// the next calculation updates them.
+func (s *Sheet) trackShift() func() {
+	e := s._fgeg._fdcbg
+	if e == nil || !e.built {
+		return func() {}
+	}
+	type cellState struct {
+		ws           *sml.Worksheet
+		ref, formula string
+	}
+	before := map[*sml.CT_Cell]cellState{}
+	state := func(ws *sml.Worksheet, c *sml.CT_Cell) cellState {
+		st := cellState{ws: ws, ref: *c.RAttr}
+		if c.F != nil {
+			st.formula = c.F.Content
+		}
+		return st
+	}
+	for _, ws := range s._fgeg._fbef {
+		for _, r := range ws.SheetData.Row {
+			for _, c := range r.C {
+				if c.RAttr != nil && (ws == s._bbbe || c.F != nil) {
+					before[c] = state(ws, c)
+				}
+			}
+		}
+	}
+	return func() {
+		mark := func(st cellState) {
+			if ref, err := reference.ParseCellReference(st.ref); err == nil {
+				e.changes[calcPos{st.ws, ref.ColumnIdx, ref.RowIdx}] = struct{}{}
+			}
+		}
+		for _, ws := range s._fgeg._fbef {
+			for _, r := range ws.SheetData.Row {
+				for _, c := range r.C {
+					old, ok := before[c]
+					if !ok || c.RAttr == nil {
+						continue
+					}
+					delete(before, c)
+					if st := state(ws, c); st != old {
+						mark(old)
+						mark(st)
+					}
+				}
+			}
+		}
+		// removed cells
+		for _, st := range before {
+			mark(st)
+		}
+	}
+}
+
+// changed records that the value or the formula of a cell is about to change.
+func (c Cell) changed() {
+	if c._bgg == nil || c._bgg._fdcbg == nil || c._cee == nil || c._dga.RAttr == nil {
+		return
+	}
+	e := c._bgg._fdcbg
+	if !e.built || e.evaluating {
+		return
+	}
+	ref, err := reference.ParseCellReference(*c._dga.RAttr)
+	if err != nil {
+		return
+	}
+	e.changes[calcPos{c._cee._bbbe, ref.ColumnIdx, ref.RowIdx}] = struct{}{}
+}
+
+// calculated returns the result of a formula cell computed by the calculation
+// in progress, if any.
+func (e *evalContext) calculated(c Cell) (formula.Result, bool) {
+	ce := e._daa._fgeg._fdcbg
+	if ce == nil || !ce.evaluating {
+		return formula.Result{}, false
+	}
+	return ce.result(c._dga)
+}
+
+// cell returns a cell of the sheet being evaluated. Cells missing from the
+// sheet aren't added while formulas are evaluated concurrently.
+func (e *evalContext) cell(ref string) Cell {
+	ce := e._daa._fgeg._fdcbg
+	if ce == nil || !ce.concurrent {
+		return e._daa.Cell(ref)
+	}
+	return e._daa.lookupCell(ref)
+}
+
+// lookupCell returns a cell without adding it to the sheet, returning an
+// empty cell outside of the sheet if it doesn't exist.
+func (s *Sheet) lookupCell(ref string) Cell {
+	cr, err := reference.ParseCellReference(ref)
+	if err == nil {
+		name := cr.Column + strconv.Itoa(int(cr.RowIdx))
+		for _, r := range s._bbbe.SheetData.Row {
+			if r.RAttr == nil || *r.RAttr != cr.RowIdx {
+				continue
+			}
+			for _, c := range r.C {
+				if c.RAttr != nil && *c.RAttr == name {
+					return Cell{s._fgeg, s, r, c}
+				}
+			}
+			break
+		}
+	}
+	return Cell{s._fgeg, s, nil, sml.NewCT_Cell()}
+}
+
+// calcPos is the position of a cell, with its zero based column index and its
+// row number.
+type calcPos struct {
+	ws  *sml.Worksheet
+	col uint32
+	row uint32
+}
+
+// calcRange is a rectangle of cells a formula depends on.
+type calcRange struct {
+	ws         *sml.Worksheet
+	col0, col1 uint32
+	row0, row1 uint32
+}
+
+func (r calcRange) contains(p calcPos) bool {
+	return p.ws == r.ws && p.col >= r.col0 && p.col <= r.col1 && p.row >= r.row0 && p.row <= r.row1
+}
+
+func (r calcRange) area() uint64 {
+	return uint64(r.col1-r.col0+1) * uint64(r.row1-r.row0+1)
+}
+
+// calcCell is a cell computed by a formula, with its offset from the cell of
+// the formula for the cells sharing it.
+type calcCell struct {
+	pos        calcPos
+	x          *sml.CT_Cell
+	dcol, drow uint32
+}
+
+// calcNode is a formula of the dependency graph, computing one cell or the
+// cells of a shared formula.
+type calcNode struct {
+	sheet    Sheet
+	sheetIdx int
+	pos      calcPos
+	x        *sml.CT_Cell
+	formula  string
+	cells    []calcCell
+	deps     []calcRange
+	volatile bool
+	dynamic  bool
+
+	// spill are the cells a dynamic array formula spilled into, other than
+	// its own, and watch the range it couldn't spill into
+	spill []calcPos
+	watch *calcRange
+
+	precedents []*calcNode
+	dependents []*calcNode
+
+	// Tarjan's algorithm state
+	index, low int
+	onStack    bool
+}
+
+func (n *calcNode) String() string {
+	return sheetReference(n.sheet.Name(), reference.IndexToColumn(n.pos.col)+strconv.Itoa(int(n.pos.row)))
+}
+
+func (n *calcNode) shared() bool {
+	return len(n.cells) > 1 || n.cells[0].x != n.x
+}
+
+// calcComponent is a strongly connected component of the dependency graph,
+// more than one formula or a formula referring to itself being a circular
+// reference.
+type calcComponent []*calcNode
+
+func (c calcComponent) circular() bool {
+	if len(c) > 1 {
+		return true
+	}
+	for _, p := range c[0].precedents {
+		if p == c[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// calcWatch is a range of cells watched by a formula.
+type calcWatch struct {
+	r calcRange
+	n *calcNode
+}
+
+// calcEngine recalculates formulas in dependency order. It keeps the graph of
+// the formulas of the workbook and the cells changed since the last
+// calculation, so that only the formulas depending on them are recalculated.
+type calcEngine struct {
+	wb      *Workbook
+	workers int
+
+	built     bool
+	rebuilt   bool
+	linked    bool
+	signature string
+	nodes     map[calcPos]*calcNode
+	owners    map[calcPos]*calcNode
+	columns   map[*sml.Worksheet]map[uint32][]uint32
+	changes   map[calcPos]struct{}
+	stale     []*calcNode
+
+	// spilled are the cells whose spilled values changed in the current
+	// pass, overwritten the spilled cells changed since the last calculation
+	spilled     map[calcPos]struct{}
+	overwritten map[calcPos]bool
+
+	watchCells  map[calcPos][]*calcNode
+	watchRanges map[*sml.Worksheet][]calcWatch
+
+	mu         sync.RWMutex
+	results    map[*sml.CT_Cell]formula.Result
+	evaluating bool
+	concurrent bool
+}
+
+func (e *calcEngine) result(x *sml.CT_Cell) (formula.Result, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	r, ok := e.results[x]
+	return r, ok
+}
+
+func (e *calcEngine) setResult(x *sml.CT_Cell, r formula.Result) {
+	e.mu.Lock()
+	e.results[x] = r
+	e.mu.Unlock()
+}
+
+// workbookSignature describes the sheets, defined names and tables of the
+// workbook, whose changes require rebuilding the graph.
+func (e *calcEngine) workbookSignature() string {
+	wb := e.wb
+	b := strings.Builder{}
+	for i, ws := range wb._fbef {
+		fmt.Fprintf(&b, "%p:%s\x00", ws, wb._gbadf.Sheets.Sheet[i].NameAttr)
+	}
+	for _, dn := range wb.DefinedNames() {
+		fmt.Fprintf(&b, "%s=%s\x00", dn.Name(), dn.Content())
+	}
+	for _, t := range wb.Tables() {
+		d := t.Definition()
+		fmt.Fprintf(&b, "%s:%s!%s:%s\x00", d.Name, d.Sheet, d.Reference, strings.Join(d.Columns, ","))
+	}
+	return b.String()
+}
+
+func (e *calcEngine) sheets() []Sheet {
+	wb := e.wb
+	sheets := make([]Sheet, len(wb._fbef))
+	for i, ws := range wb._fbef {
+		sheets[i] = Sheet{wb, wb._gbadf.Sheets.Sheet[i], ws}
+	}
+	return sheets
+}
+
+// build creates the graph of all the formulas of the workbook.
+func (e *calcEngine) build() {
+	e.nodes = map[calcPos]*calcNode{}
+	e.owners = map[calcPos]*calcNode{}
+	e.columns = map[*sml.Worksheet]map[uint32][]uint32{}
+	e.changes = map[calcPos]struct{}{}
+	e.overwritten = map[calcPos]bool{}
+	e.results = map[*sml.CT_Cell]formula.Result{}
+	e.stale = nil
+	for i, s := range e.sheets() {
+		for _, r := range s._bbbe.SheetData.Row {
+			for _, c := range r.C {
+				if c.F == nil || c.F.Content == "" || c.RAttr == nil {
+					continue
+				}
+				ref, err := reference.ParseCellReference(*c.RAttr)
+				if err != nil {
+					continue
+				}
+				e.add(s, i, calcPos{s._bbbe, ref.ColumnIdx, ref.RowIdx}, c)
+			}
+		}
+	}
+	e.signature = e.workbookSignature()
+	e.built = true
+	e.rebuilt = true
+	e.linked = false
+}
+
+// add adds the node of a formula cell.
+func (e *calcEngine) add(s Sheet, sheetIdx int, pos calcPos, x *sml.CT_Cell) *calcNode {
+	n := &calcNode{sheet: s, sheetIdx: sheetIdx, pos: pos, x: x, formula: x.F.Content}
+	n.cells = []calcCell{{pos: pos, x: x}}
+	if x.F.TAttr == sml.ST_CellFormulaTypeShared && x.F.RefAttr != nil && x.F.SiAttr != nil {
+		if cells := sharedCells(s, pos, *x.F.RefAttr, *x.F.SiAttr); len(cells) > 0 {
+			n.cells = cells
+		}
+	}
+	ctx := _bddb(&n.sheet)
+	ctx._gfcbd = reference.IndexToColumn(pos.col) + strconv.Itoa(int(pos.row))
+	deps := formula.FormulaDependencies(formula.ResolveStructuredReferences(n.formula, ctx))
+	n.volatile = deps.Volatile
+	n.dynamic = deps.Dynamic
+	var spanCols, spanRows uint32
+	for _, c := range n.cells {
+		if c.dcol > spanCols {
+			spanCols = c.dcol
+		}
+		if c.drow > spanRows {
+			spanRows = c.drow
+		}
+	}
+	for _, d := range deps.Cells {
+		if r, ok := e.dependencyRange(d, s, spanCols, spanRows); ok {
+			n.deps = append(n.deps, r)
+		}
+	}
+	for _, name := range deps.Names {
+		n.deps = append(n.deps, e.nameRanges(name, s, map[string]bool{})...)
+	}
+
+	e.nodes[pos] = n
+	for _, c := range n.cells {
+		e.own(c.pos, n)
+	}
+	if x.F.RefAttr != nil && e.wb.isDynamicArray(x) {
+		n.spill = spillCells(pos, *x.F.RefAttr)
+		for _, p := range n.spill {
+			if e.owners[p] == nil {
+				e.own(p, n)
+			}
+		}
+	}
+	e.linked = false
+	return n
+}
+
+// remove removes the node of a formula cell.
+func (e *calcEngine) remove(n *calcNode) {
+	delete(e.nodes, n.pos)
+	for _, c := range n.cells {
+		e.disown(c.pos, n)
+		delete(e.results, c.x)
+	}
+	for _, p := range n.spill {
+		e.disown(p, n)
+	}
+	e.linked = false
+}
+
+// own records the node computing a cell.
+func (e *calcEngine) own(p calcPos, n *calcNode) {
+	if e.owners[p] == nil {
+		cols := e.columns[p.ws]
+		if cols == nil {
+			cols = map[uint32][]uint32{}
+			e.columns[p.ws] = cols
+		}
+		cols[p.col] = append(cols[p.col], p.row)
+	}
+	e.owners[p] = n
+}
+
+// disown removes a cell computed by a node.
+func (e *calcEngine) disown(p calcPos, n *calcNode) {
+	if e.owners[p] != n {
+		return
+	}
+	delete(e.owners, p)
+	cols := e.columns[p.ws]
+	rows := cols[p.col][:0]
+	for _, row := range cols[p.col] {
+		if row != p.row {
+			rows = append(rows, row)
+		}
+	}
+	cols[p.col] = rows
+}
+
+// spillCells returns the cells of a spill range other than the cell of its
+// formula.
+func spillCells(pos calcPos, ref string) []calcPos {
+	from, to, ok := spillBounds(ref)
+	if !ok {
+		return nil
+	}
+	var cells []calcPos
+	for row := from.RowIdx; row <= to.RowIdx; row++ {
+		for col := from.ColumnIdx; col <= to.ColumnIdx; col++ {
+			if p := (calcPos{pos.ws, col, row}); p != pos {
+				cells = append(cells, p)
+			}
+		}
+	}
+	return cells
+}
+
+// sharedCells returns the cells sharing the formula of a cell, the cells of
+// its range with the same shared formula index.
+func sharedCells(s Sheet, pos calcPos, ref string, si uint32) []calcCell {
+	cells := []calcCell{}
+	from, to, err := reference.ParseRangeReference(ref)
+	if err != nil {
+		return cells
+	}
+	for _, r := range s._bbbe.SheetData.Row {
+		if r.RAttr == nil || *r.RAttr < from.RowIdx || *r.RAttr > to.RowIdx {
+			continue
+		}
+		for _, c := range r.C {
+			if c.RAttr == nil || c.F == nil || c.F.TAttr != sml.ST_CellFormulaTypeShared || c.F.SiAttr == nil || *c.F.SiAttr != si {
+				continue
+			}
+			cr, err := reference.ParseCellReference(*c.RAttr)
+			if err != nil || cr.ColumnIdx < from.ColumnIdx || cr.ColumnIdx > to.ColumnIdx || cr.ColumnIdx < pos.col || cr.RowIdx < pos.row {
+				continue
+			}
+			p := calcPos{pos.ws, cr.ColumnIdx, cr.RowIdx}
+			cells = append(cells, calcCell{pos: p, x: c, dcol: p.col - pos.col, drow: p.row - pos.row})
+		}
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].pos.row != cells[j].pos.row {
+			return cells[i].pos.row < cells[j].pos.row
+		}
+		return cells[i].pos.col < cells[j].pos.col
+	})
+	return cells
+}
+
+// dependencyRange returns the cells referred to by a formula, extended by the
+// span of the cells sharing it for relative references.
+func (e *calcEngine) dependencyRange(d formula.Dependency, s Sheet, spanCols, spanRows uint32) (calcRange, bool) {
+	ws := s._bbbe
+	if d.Sheet != "" {
+		var ok bool
+		if ws, ok = e.worksheet(d.Sheet); !ok {
+			return calcRange{}, false
+		}
+	}
+	r := calcRange{ws: ws, col0: d.From.ColumnIdx, col1: d.To.ColumnIdx, row0: d.From.RowIdx, row1: d.To.RowIdx}
+	if !d.To.AbsoluteColumn {
+		r.col1 += spanCols
+	}
+	if !d.To.AbsoluteRow {
+		r.row1 += spanRows
+	}
+	return r, true
+}
+
+// nameRanges returns the cells referred to by a defined name or a table name,
+// resolving names referring to other names. Names are looked up as in
+// formula evaluation.
+func (e *calcEngine) nameRanges(name string, s Sheet, seen map[string]bool) []calcRange {
+	if seen[name] {
+		return nil
+	}
+	seen[name] = true
+	var ranges []calcRange
+	for _, dn := range e.wb.DefinedNames() {
+		if !strings.EqualFold(dn.Name(), name) {
+			continue
+		}
+		deps := formula.FormulaDependencies(dn.Content())
+		for _, d := range deps.Cells {
+			if r, ok := e.dependencyRange(d, s, 0, 0); ok {
+				ranges = append(ranges, r)
+			}
+		}
+		for _, n := range deps.Names {
+			ranges = append(ranges, e.nameRanges(n, s, seen)...)
+		}
+		return ranges
+	}
+	for _, t := range e.wb.Tables() {
+		d := t.Definition()
+		if !strings.EqualFold(d.Name, name) {
+			continue
+		}
+		ws, ok := e.worksheet(d.Sheet)
+		from, to, err := reference.ParseRangeReference(d.Reference)
+		if ok && err == nil {
+			ranges = append(ranges, calcRange{ws: ws, col0: from.ColumnIdx, col1: to.ColumnIdx, row0: from.RowIdx, row1: to.RowIdx})
+		}
+		break
+	}
+	return ranges
+}
+
+// worksheet returns the worksheet of a sheet name, compared case
+// insensitively as in Excel.
+func (e *calcEngine) worksheet(name string) (*sml.Worksheet, bool) {
+	for i, s := range e.wb._gbadf.Sheets.Sheet {
+		if strings.EqualFold(s.NameAttr, name) && i < len(e.wb._fbef) {
+			return e.wb._fbef[i], true
+		}
+	}
+	return nil, false
+}
+
+// update brings the graph up to date with the cells changed since the last
+// calculation. A rebuilt graph is recalculated entirely by the next
+// calculation.
+func (e *calcEngine) update() {
+	if !e.built || e.workbookSignature() != e.signature {
+		e.build()
+		e.link()
+		return
+	}
+	sheets := e.sheets()
+	for pos := range e.changes {
+		if n := e.owners[pos]; n != nil {
+			if len(n.spill) > 0 && pos != n.pos {
+				e.overwritten[pos] = true
+			}
+			e.remove(n)
+			e.changes[n.pos] = struct{}{}
+			for _, c := range n.cells {
+				e.changes[c.pos] = struct{}{}
+			}
+		}
+	}
+	for pos := range e.changes {
+		if e.nodes[pos] != nil {
+			continue
+		}
+		for i, s := range sheets {
+			if s._bbbe != pos.ws {
+				continue
+			}
+			c := s.lookupCell(reference.IndexToColumn(pos.col) + strconv.Itoa(int(pos.row)))
+			if c._dga.F != nil && c._dga.F.Content != "" {
+				e.stale = append(e.stale, e.add(s, i, pos, c._dga))
+			}
+		}
+	}
+	if !e.linked {
+		e.link()
+	}
+}
+
+// link computes the edges of the graph from the ranges formulas refer to.
+func (e *calcEngine) link() {
+	e.watchCells = map[calcPos][]*calcNode{}
+	e.watchRanges = map[*sml.Worksheet][]calcWatch{}
+	for _, n := range e.nodes {
+		n.precedents = nil
+		n.dependents = nil
+	}
+	for _, cols := range e.columns {
+		for _, rows := range cols {
+			sort.Slice(rows, func(i, j int) bool { return rows[i] < rows[j] })
+		}
+	}
+	for _, n := range e.nodes {
+		seen := map[*calcNode]bool{}
+		for _, r := range n.deps {
+			if r.area() == 1 {
+				p := calcPos{r.ws, r.col0, r.row0}
+				e.watchCells[p] = append(e.watchCells[p], n)
+				if m := e.owners[p]; m != nil && !seen[m] {
+					seen[m] = true
+					n.precedents = append(n.precedents, m)
+					m.dependents = append(m.dependents, n)
+				}
+				continue
+			}
+			e.watchRanges[r.ws] = append(e.watchRanges[r.ws], calcWatch{r, n})
+			e.within(r, func(m *calcNode) {
+				if !seen[m] {
+					seen[m] = true
+					n.precedents = append(n.precedents, m)
+					m.dependents = append(m.dependents, n)
+				}
+			})
+		}
+		if n.watch != nil {
+			e.watchRanges[n.watch.ws] = append(e.watchRanges[n.watch.ws], calcWatch{*n.watch, n})
+		}
+	}
+	e.linked = true
+}
+
+// within calls fn for the nodes computing the cells of a range, looking up
+// the columns of the range or the columns of the sheet having formulas,
+// whichever are fewer.
+func (e *calcEngine) within(r calcRange, fn func(n *calcNode)) {
+	cols := e.columns[r.ws]
+	visit := func(col uint32, rows []uint32) {
+		i := sort.Search(len(rows), func(i int) bool { return rows[i] >= r.row0 })
+		for ; i < len(rows) && rows[i] <= r.row1; i++ {
+			if m := e.owners[calcPos{r.ws, col, rows[i]}]; m != nil {
+				fn(m)
+			}
+		}
+	}
+	if uint64(r.col1-r.col0+1) > uint64(len(cols)) {
+		for col, rows := range cols {
+			if col >= r.col0 && col <= r.col1 {
+				visit(col, rows)
+			}
+		}
+		return
+	}
+	for col := r.col0; col <= r.col1; col++ {
+		if rows, ok := cols[col]; ok {
+			visit(col, rows)
+		}
+	}
+}
+
+// all returns the nodes of the graph in sheet, row and column order.
+func (e *calcEngine) all() []*calcNode {
+	nodes := make([]*calcNode, 0, len(e.nodes))
+	for _, n := range e.nodes {
+		nodes = append(nodes, n)
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+func sortNodes(nodes []*calcNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.sheetIdx != b.sheetIdx {
+			return a.sheetIdx < b.sheetIdx
+		}
+		if a.pos.row != b.pos.row {
+			return a.pos.row < b.pos.row
+		}
+		return a.pos.col < b.pos.col
+	})
+}
+
+// affected returns the formulas to recalculate: the formulas referring to the
+// cells changed, along with the formulas changed and the volatile formulas if
+// requested, and the formulas depending on them.
+func (e *calcEngine) affected(changes map[calcPos]struct{}, volatile bool) []*calcNode {
+	set := map[*calcNode]bool{}
+	var queue []*calcNode
+	mark := func(n *calcNode) {
+		if !set[n] {
+			set[n] = true
+			queue = append(queue, n)
+		}
+	}
+	for pos := range changes {
+		for _, n := range e.watchCells[pos] {
+			mark(n)
+		}
+		for _, w := range e.watchRanges[pos.ws] {
+			if w.r.contains(pos) {
+				mark(w.n)
+			}
+		}
+	}
+	for _, n := range e.stale {
+		if volatile && e.nodes[n.pos] == n {
+			mark(n)
+		}
+	}
+	for _, n := range e.nodes {
+		if volatile && n.volatile {
+			mark(n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, d := range n.dependents {
+			mark(d)
+		}
+	}
+	nodes := make([]*calcNode, 0, len(set))
+	for n := range set {
+		nodes = append(nodes, n)
+	}
+	sortNodes(nodes)
+	return nodes
+}
+
+// components returns the strongly connected components of a set of nodes
+// closed under their dependents, precedents first, using Tarjan's algorithm.
+func (e *calcEngine) components(nodes []*calcNode) []calcComponent {
+	in := make(map[*calcNode]bool, len(nodes))
+	for _, n := range nodes {
+		in[n] = true
+		n.index = -1
+		n.onStack = false
+	}
+	var sccs []calcComponent
+	var stack []*calcNode
+	index := 0
+	var visit func(n *calcNode)
+	visit = func(n *calcNode) {
+		n.index, n.low = index, index
+		index++
+		stack = append(stack, n)
+		n.onStack = true
+		for _, d := range n.dependents {
+			if !in[d] {
+				continue
+			}
+			if d.index < 0 {
+				visit(d)
+				if d.low < n.low {
+					n.low = d.low
+				}
+			} else if d.onStack && d.index < n.low {
+				n.low = d.index
+			}
+		}
+		if n.low != n.index {
+			return
+		}
+		var scc calcComponent
+		for {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			m.onStack = false
+			scc = append(scc, m)
+			if m == n {
+				break
+			}
+		}
+		sortNodes(scc)
+		sccs = append(sccs, scc)
+	}
+	for _, n := range nodes {
+		if n.index < 0 {
+			visit(n)
+		}
+	}
+	for i, j := 0, len(sccs)-1; i < j; i, j = i+1, j-1 {
+		sccs[i], sccs[j] = sccs[j], sccs[i]
+	}
+	return sccs
+}
+
+// levels groups components in calculation order, the components of a level
+// depending only on the components of the previous levels.
+func levels(sccs []calcComponent) [][]calcComponent {
+	level := map[*calcNode]int{}
+	var out [][]calcComponent
+	for _, scc := range sccs {
+		l := 0
+		for _, n := range scc {
+			for _, p := range n.precedents {
+				if pl, ok := level[p]; ok && pl+1 > l {
+					l = pl + 1
+				}
+			}
+		}
+		// members of the component have no level while it is computed, so
+		// that references within it are ignored
+		for _, n := range scc {
+			level[n] = l
+		}
+		for len(out) <= l {
+			out = append(out, nil)
+		}
+		out[l] = append(out[l], scc)
+	}
+	return out
+}
+
+// recalculate recalculates the formulas affected by the changes since the
+// last calculation, or all of them. The formulas referring to the cells
+// dynamic array formulas spilled into are recalculated in further passes.
+func (e *calcEngine) recalculate(all bool) {
+	e.update()
+	all = all || e.rebuilt
+	e.rebuilt = false
+	nodes := e.all()
+	if !all {
+		nodes = e.affected(e.changes, true)
+	}
+	e.changes = map[calcPos]struct{}{}
+	e.stale = nil
+
+	e.evaluating = true
+	defer func() {
+		e.evaluating = false
+		e.spilled = nil
+		e.overwritten = map[calcPos]bool{}
+	}()
+	for pass := 0; len(nodes) > 0 && pass < maxSpillPasses; pass++ {
+		e.spilled = map[calcPos]struct{}{}
+		e.evaluate(nodes)
+		if len(e.spilled) == 0 {
+			break
+		}
+		if !e.linked {
+			e.link()
+		}
+		nodes = e.affected(e.spilled, false)
+	}
+}
+
+// evaluate calculates formulas in dependency order.
+func (e *calcEngine) evaluate(nodes []*calcNode) {
+	for _, n := range nodes {
+		for _, c := range n.cells {
+			delete(e.results, c.x)
+		}
+	}
+	for _, level := range levels(e.components(nodes)) {
+		var simple []*calcNode
+		var serial []calcComponent
+		for _, scc := range level {
+			// RAND and RANDBETWEEN share a random source that isn't safe
+			// for concurrent use
+			if scc.circular() || scc[0].dynamic || scc[0].volatile || e.workers < 2 {
+				serial = append(serial, scc)
+			} else {
+				simple = append(simple, scc[0])
+			}
+		}
+		e.evaluateConcurrently(simple)
+		w := newCalcWorker(e)
+		for _, scc := range serial {
+			if scc.circular() {
+				e.iterate(scc)
+				continue
+			}
+			w.eval(scc[0])
+			e.store(scc[0])
+		}
+	}
+}
+
+// evaluateConcurrently evaluates formulas independent of each other with the
+// workers of the engine, storing the results once all are computed.
+func (e *calcEngine) evaluateConcurrently(nodes []*calcNode) {
+	if len(nodes) == 0 {
+		return
+	}
+	workers := e.workers
+	if workers > len(nodes) {
+		workers = len(nodes)
+	}
+	jobs := make(chan *calcNode)
+	wg := sync.WaitGroup{}
+	e.concurrent = true
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := newCalcWorker(e)
+			for n := range jobs {
+				w.eval(n)
+			}
+		}()
+	}
+	for _, n := range nodes {
+		jobs <- n
+	}
+	close(jobs)
+	wg.Wait()
+	e.concurrent = false
+	for _, n := range nodes {
+		e.store(n)
+	}
+}
+
+// iterate calculates a circular reference iteratively if enabled, starting
+// from the values cached in the cells. Without iterative calculation, the
+// cached values of the cells are removed as for formulas that fail to
+// evaluate.
+func (e *calcEngine) iterate(scc calcComponent) {
+	enabled, maxIterations, maxChange := e.wb.IterativeCalculation()
+	for _, n := range scc {
+		for _, c := range n.cells {
+			if !enabled {
+				e.setResult(c.x, formula.MakeErrorResultType(formula.ErrorTypeRef, "circular reference at "+n.String()))
+				continue
+			}
+			// numbers are cached as strings by store, as by
+			// Sheet.RecalculateFormulas
+			v := 0.0
+			switch c.x.TAttr {
+			case sml.ST_CellTypeN, sml.ST_CellTypeUnset, sml.ST_CellTypeStr, sml.ST_CellTypeInlineStr:
+				if c.x.V != nil {
+					if f, err := strconv.ParseFloat(*c.x.V, 64); err == nil {
+						v = f
+					}
+				}
+			}
+			e.setResult(c.x, formula.MakeNumberResult(v))
+		}
+	}
+	for i := uint32(0); enabled && i < maxIterations; i++ {
+		w := newCalcWorker(e)
+		change := 0.0
+		for _, n := range scc {
+			prev := make([]formula.Result, len(n.cells))
+			for j, c := range n.cells {
+				prev[j], _ = e.result(c.x)
+			}
+			w.eval(n)
+			for j, c := range n.cells {
+				r, _ := e.result(c.x)
+				change = math.Max(change, resultChange(prev[j], r))
+			}
+		}
+		if change <= maxChange {
+			break
+		}
+	}
+	for _, n := range scc {
+		e.store(n)
+	}
+}
+
+// resultChange returns the change between two results, infinite for results
+// that aren't both numbers and differ.
+func resultChange(a, b formula.Result) float64 {
+	if a.Type == formula.ResultTypeNumber && b.Type == formula.ResultTypeNumber {
+		return math.Abs(a.ValueNumber - b.ValueNumber)
+	}
+	if a.Type == b.Type && a.Value() == b.Value() {
+		return 0
+	}
+	return math.Inf(1)
+}
+
+// store caches the results of a formula in its cells as
+// Sheet.RecalculateFormulas does.
+func (e *calcEngine) store(n *calcNode) {
+	if len(n.cells) == 1 && e.wb.isDynamicArray(n.x) {
+		e.storeSpill(n)
+		return
+	}
+	for _, c := range n.cells {
+		r, ok := e.result(c.x)
+		if !ok || c.x.F == nil {
+			continue
+		}
+		v := r.AsString()
+		if v.Type == formula.ResultTypeError {
+			logger.Log.Debug("error evaluating formula %s: %s", n.formula, v.ErrorMessage)
+			c.x.V = nil
+			continue
+		}
+		if v.Type == formula.ResultTypeNumber {
+			c.x.TAttr = sml.ST_CellTypeN
+		} else {
+			c.x.TAttr = sml.ST_CellTypeInlineStr
+		}
+		c.x.V = unioffice.String(v.Value())
+		if c.x.F.TAttr == sml.ST_CellFormulaTypeArray {
+			ref := reference.IndexToColumn(c.pos.col) + strconv.Itoa(int(c.pos.row))
+			if v.Type == formula.ResultTypeArray {
+				n.sheet.setArray(ref, v)
+			} else if v.Type == formula.ResultTypeList {
+				n.sheet.setList(ref, v)
+			}
+		}
+	}
+}
+
+// calcWorker evaluates formulas with an evaluator per sheet, as the range
+// results cached by evaluators are keyed without sheet name.
+type calcWorker struct {
+	e          *calcEngine
+	contexts   map[*sml.Worksheet]*evalContext
+	evaluators map[*sml.Worksheet]formula.Evaluator
+}
+
+func newCalcWorker(e *calcEngine) *calcWorker {
+	return &calcWorker{e: e, contexts: map[*sml.Worksheet]*evalContext{}, evaluators: map[*sml.Worksheet]formula.Evaluator{}}
+}
+
+func (w *calcWorker) eval(n *calcNode) {
+	if n.shared() {
+		// the evaluator caches cells by their reference before the offset
+		// is applied, each cell needs its own
+		s := n.sheet
+		ctx := _bddb(&s)
+		for _, c := range n.cells {
+			ctx.SetOffset(c.dcol, c.drow)
+			ctx._gfcbd = reference.IndexToColumn(c.pos.col) + strconv.Itoa(int(c.pos.row))
+			w.e.setResult(c.x, formula.NewEvaluator().Eval(ctx, n.formula))
+		}
+		return
+	}
+	ctx, ok := w.contexts[n.pos.ws]
+	if !ok {
+		s := n.sheet
+		ctx = _bddb(&s)
+		w.contexts[n.pos.ws] = ctx
+		w.evaluators[n.pos.ws] = formula.NewEvaluator()
+	}
+	ctx._gfcbd = reference.IndexToColumn(n.pos.col) + strconv.Itoa(int(n.pos.row))
+	w.e.setResult(n.x, w.evaluators[n.pos.ws].Eval(ctx, n.formula))
+}
+
+// storeSpill spills the result of a dynamic array formula, updating the
+// cells owned by its node.
+func (e *calcEngine) storeSpill(n *calcNode) {
+	r, ok := e.result(n.x)
+	if !ok || n.x.F == nil {
+		return
+	}
+	out := n.sheet.spill(n.x, r, func(col, row uint32) bool {
+		return e.overwritten[calcPos{n.pos.ws, col, row}]
+	})
+	e.setResult(n.x, out.value)
+	if out.value.Type == formula.ResultTypeError && out.next == out.want {
+		logger.Log.Debug("error evaluating formula %s: %s", n.formula, out.value.ErrorMessage)
+	}
+
+	var watch *calcRange
+	if out.next != out.want {
+		if from, to, ok := spillBounds(out.want); ok {
+			watch = &calcRange{n.pos.ws, from.ColumnIdx, to.ColumnIdx, from.RowIdx, to.RowIdx}
+		}
+	}
+	if (watch == nil) != (n.watch == nil) || watch != nil && *watch != *n.watch {
+		n.watch = watch
+		e.linked = false
+	}
+	if out.prev == out.next {
+		return
+	}
+	for _, p := range n.spill {
+		e.disown(p, n)
+		e.spilled[p] = struct{}{}
+	}
+	n.spill = spillCells(n.pos, out.next)
+	for _, p := range n.spill {
+		if e.owners[p] == nil {
+			e.own(p, n)
+		}
+		e.spilled[p] = struct{}{}
+	}
+	e.linked = false
+}
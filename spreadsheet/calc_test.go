@@ -0,0 +1,260 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2"
+)
+
+// cachedValues returns the cached values of cells.
+func cachedValues(s Sheet, refs ...string) []string {
+	var values []string
+	for _, ref := range refs {
+		values = append(values, s.Cell(ref).GetCachedFormulaResult())
+	}
+	return values
+}
+
+func TestRecalculate(t *testing.T) {
+	wb := New()
+	s := wb.AddSheet()
+	s.SetName("Main")
+	data := wb.AddSheet()
+	data.SetName("Rates")
+	data.Cell("B1").SetNumber(0.5)
+	wb.AddDefinedName("Rate", "Rates!$B$1")
+
+	s.Cell("A1").SetNumber(1)
+	s.Cell("B1").SetFormulaRaw("A1*2")
+	s.Cell("C1").SetFormulaRaw("1+1")
+	s.Cell("D1").SetFormulaRaw("B1*Rate")
+	s.Cell("E1").SetFormulaRaw("SUM(A:A)")
+	data.Cell("A1").SetFormulaRaw("Main!D1+1")
+	wb.Recalculate()
+	if got := cachedValues(s, "B1", "C1", "D1", "E1"); !reflect.DeepEqual(got, []string{"2", "2", "1", "1"}) {
+		t.Errorf("values = %q", got)
+	}
+	if got := data.Cell("A1").GetCachedFormulaResult(); got != "2" {
+		t.Errorf("cross sheet value = %q, want 2", got)
+	}
+
+	// only the formulas depending on changed cells are recalculated, the
+	// value of C1 changed through the XML types being kept
+	s.Cell("C1").X().V = unioffice.String("99")
+	s.Cell("A1").SetNumber(3)
+	s.Cell("A2").SetNumber(4)
+	wb.Recalculate()
+	if got := cachedValues(s, "B1", "C1", "D1", "E1"); !reflect.DeepEqual(got, []string{"6", "99", "3", "7"}) {
+		t.Errorf("values after SetNumber = %q", got)
+	}
+	if got := data.Cell("A1").GetCachedFormulaResult(); got != "4" {
+		t.Errorf("cross sheet value after SetNumber = %q, want 4", got)
+	}
+
+	// through a defined name
+	data.Cell("B1").SetNumber(2)
+	wb.Recalculate()
+	if got := cachedValues(s, "B1", "C1", "D1"); !reflect.DeepEqual(got, []string{"6", "99", "12"}) {
+		t.Errorf("values after changing a named cell = %q", got)
+	}
+
+	// a new formula and the formulas referring to its cell
+	s.Cell("F1").SetFormulaRaw("G1&\"!\"")
+	s.Cell("G1").SetFormulaRaw("\"x\"")
+	wb.Recalculate()
+	if got := cachedValues(s, "F1", "G1", "C1"); !reflect.DeepEqual(got, []string{"x!", "x", "99"}) {
+		t.Errorf("values after adding formulas = %q", got)
+	}
+	s.Cell("G1").SetString("y")
+	wb.Recalculate()
+	if got := s.Cell("F1").GetCachedFormulaResult(); got != "y!" {
+		t.Errorf("value after replacing a formula = %q, want y!", got)
+	}
+
+	// changes to the defined names can't be tracked, all the formulas
+	// being recalculated
+	wb.AddDefinedName("Other", "Main!$A$1")
+	wb.Recalculate()
+	if got := s.Cell("C1").GetCachedFormulaResult(); got != "2" {
+		t.Errorf("value after adding a name = %q, want 2", got)
+	}
+
+	read := roundTrip(t, wb)
+	rs := read.Sheets()[0]
+	if got := cachedValues(rs, "B1", "C1", "D1", "E1", "F1"); !reflect.DeepEqual(got, []string{"6", "2", "12", "7", "y!"}) {
+		t.Errorf("read values = %q", got)
+	}
+	rs.Cell("A1").SetNumber(1)
+	read.Recalculate()
+	if got := cachedValues(rs, "B1", "D1", "E1"); !reflect.DeepEqual(got, []string{"2", "4", "5"}) {
+		t.Errorf("read values after SetNumber = %q", got)
+	}
+}
+
+func TestRecalculateVolatile(t *testing.T) {
+	wb := New()
+	s := wb.AddSheet()
+	s.Cell("A1").SetNumber(1)
+	s.Cell("A2").SetNumber(2)
+	s.Cell("B1").SetFormulaRaw("INDIRECT(\"A\"&A2)")
+	s.Cell("B2").SetFormulaRaw("A1+1")
+	wb.Recalculate()
+	if got := cachedValues(s, "B1", "B2"); !reflect.DeepEqual(got, []string{"2", "2"}) {
+		t.Errorf("values = %q", got)
+	}
+
+	// volatile formulas are recalculated along with any change
+	s.Cell("B1").X().V = unioffice.String("99")
+	s.Cell("B2").X().V = unioffice.String("99")
+	s.Cell("C1").SetNumber(0)
+	wb.Recalculate()
+	if got := cachedValues(s, "B1", "B2"); !reflect.DeepEqual(got, []string{"2", "99"}) {
+		t.Errorf("values after an unrelated change = %q", got)
+	}
+}
+
+func TestCircularReferences(t *testing.T) {
+	wb := New()
+	s := wb.AddSheet()
+	s.SetName("Main")
+	s.Cell("A1").SetFormulaRaw("B1+1")
+	s.Cell("B1").SetFormulaRaw("A1+1")
+	s.Cell("C1").SetFormulaRaw("A1*2")
+	s.Cell("D1").SetFormulaRaw("D1")
+	s.Cell("E1").SetFormulaRaw("1+1")
+	if got := wb.CircularReferences(); !reflect.DeepEqual(got, []string{"Main!A1", "Main!B1", "Main!D1"}) {
+		t.Errorf("circular references = %q", got)
+	}
+
+	// circular references aren't calculated without iterative calculation,
+	// the first calculation following CircularReferences being complete
+	wb.Recalculate()
+	if got := cachedValues(s, "A1", "B1", "C1", "D1", "E1"); !reflect.DeepEqual(got, []string{"", "", "", "", "2"}) {
+		t.Errorf("values = %q", got)
+	}
+
+	// breaking the cycle
+	s.Cell("B1").SetNumber(1)
+	if got := wb.CircularReferences(); !reflect.DeepEqual(got, []string{"Main!D1"}) {
+		t.Errorf("circular references after breaking a cycle = %q", got)
+	}
+	wb.Recalculate()
+	if got := cachedValues(s, "A1", "C1"); !reflect.DeepEqual(got, []string{"2", "4"}) {
+		t.Errorf("values after breaking a cycle = %q", got)
+	}
+
+	// and closing it again through another cell
+	s.Cell("B1").SetFormulaRaw("C1")
+	if got := wb.CircularReferences(); !reflect.DeepEqual(got, []string{"Main!A1", "Main!B1", "Main!C1", "Main!D1"}) {
+		t.Errorf("circular references after closing a cycle = %q", got)
+	}
+	wb.Recalculate()
+	if got := cachedValues(s, "A1", "B1", "C1"); !reflect.DeepEqual(got, []string{"", "", ""}) {
+		t.Errorf("values after closing a cycle = %q", got)
+	}
+}
+
+func TestIterativeCalculation(t *testing.T) {
+	wb := New()
+	s := wb.AddSheet()
+	if enabled, n, delta := wb.IterativeCalculation(); enabled || n != DefaultIterateCount || delta != DefaultIterateDelta {
+		t.Errorf("default iterative calculation = %v %d %v", enabled, n, delta)
+	}
+	wb.SetIterativeCalculation(true, 100, 0.001)
+	s.Cell("A1").SetFormulaRaw("(A1+10)/2")
+	s.Cell("B1").SetFormulaRaw("C1/2+1")
+	s.Cell("C1").SetFormulaRaw("B1/2")
+	s.Cell("D1").SetFormulaRaw("A1*2")
+	wb.Recalculate()
+	for _, tc := range []struct {
+		ref  string
+		want float64
+	}{
+		{"A1", 10},
+		{"B1", 4.0 / 3},
+		{"C1", 2.0 / 3},
+		{"D1", 20},
+	} {
+		got, err := s.Cell(tc.ref).GetValueAsNumber()
+		if err != nil || math.Abs(got-tc.want) > 0.01 {
+			t.Errorf("%s = %v, want %v", tc.ref, got, tc.want)
+		}
+	}
+
+	// the calculation stops after the maximum number of iterations, and
+	// starts again from the cached values
+	wb.SetIterativeCalculation(true, 1, 0.001)
+	s.Cell("E1").SetNumber(1)
+	s.Cell("F1").SetFormulaRaw("F1+E1")
+	wb.Recalculate()
+	s.Cell("E1").SetNumber(1)
+	wb.Recalculate()
+	if got := s.Cell("F1").GetCachedFormulaResult(); got != "2" {
+		t.Errorf("value after two calculations of one iteration = %q, want 2", got)
+	}
+
+	read := roundTrip(t, wb)
+	if enabled, n, delta := read.IterativeCalculation(); !enabled || n != 1 || delta != 0.001 {
+		t.Errorf("read iterative calculation = %v %d %v", enabled, n, delta)
+	}
+	read.SetIterativeCalculation(false, 1, 0.001)
+	if enabled, n, delta := read.IterativeCalculation(); enabled || n != DefaultIterateCount || delta != DefaultIterateDelta {
+		t.Errorf("disabled iterative calculation = %v %d %v", enabled, n, delta)
+	}
+	read.Recalculate()
+	if got := read.Sheets()[0].Cell("F1").GetCachedFormulaResult(); got != "" {
+		t.Errorf("value without iterative calculation = %q", got)
+	}
+}
+
+func TestCalculationWorkers(t *testing.T) {
+	const rows = 200
+	build := func(workers int) (*Workbook, Sheet) {
+		wb := New()
+		s := wb.AddSheet()
+		for r := 1; r <= rows; r++ {
+			row := strconv.Itoa(r)
+			s.Cell("A" + row).SetNumber(float64(r))
+			s.Cell("B" + row).SetFormulaRaw("A" + row + "*2")
+			s.Cell("C" + row).SetFormulaRaw("B" + row + "+A" + row)
+		}
+		s.Cell("D1").SetFormulaRaw("SUM(C1:C" + strconv.Itoa(rows) + ")")
+		wb.SetCalculationWorkers(workers)
+		wb.Recalculate()
+		return wb, s
+	}
+	_, serial := build(1)
+	wb, s := build(8)
+	for r := 1; r <= rows; r++ {
+		ref := "C" + strconv.Itoa(r)
+		if got, want := s.Cell(ref).GetCachedFormulaResult(), serial.Cell(ref).GetCachedFormulaResult(); got != want || got != strconv.Itoa(3*r) {
+			t.Fatalf("%s = %q, want %q", ref, got, want)
+		}
+	}
+	if got := s.Cell("D1").GetCachedFormulaResult(); got != strconv.Itoa(3*rows*(rows+1)/2) {
+		t.Errorf("sum = %q", got)
+	}
+
+	s.Cell("A5").SetNumber(0)
+	wb.Recalculate()
+	if got := cachedValues(s, "B5", "C5", "D1"); !reflect.DeepEqual(got, []string{"0", "0", strconv.Itoa(3*rows*(rows+1)/2 - 15)}) {
+		t.Errorf("values after SetNumber = %q", got)
+	}
+	// fewer than one worker evaluates serially
+	wb.SetCalculationWorkers(0)
+	if wb.calc().workers != 1 {
+		t.Errorf("workers = %d, want 1", wb.calc().workers)
+	}
+}
@@ -41,7 +41,7 @@ _bcca .SetPos (_fdc ,_gcgb );var _dbde *_ac .TextChunk ;if _cca ._dbgf !=""{_dbd
 };if _ggbb :=_gdf ._bebd ;_ggbb !=nil {_eaeg =_ggbb ._afba ;_deef =_eaeg /2;_ebabe =_ggbb ._cbc ;};if _dffb :=_gdf ._gbbb ;_dffb !=nil {_bgg =_dffb ._afba ;_ffc =_bgg /2;_gbbe =_dffb ._cbc ;};var _beff float64 ;if _gead ._fafg > 1{_beff =_gbfa ._caea [_gead ._fafg -1]._gdgf ;
 };_edc :=_bee +_dba ._fdaa -0.5*(_beff -_gegb );_ffce :=_bee +_dba ._fdaa +_dba ._gebge +0.5*(_dba ._gdgf +_bedg );_eafa :=_aga +_gdf ._aacff ;_gege :=_eafa +_gdf ._cdg ;_df .DrawLine (_gbfa ._adda ,_eafa ,_edc ,_gege ,_edc ,_gegb ,_fgcbg );_df .DrawLine (_gbfa ._adda ,_eafa ,_ffce ,_gege ,_ffce ,_bedg ,_cedc );
 if !_acfg {_df .DrawLine (_gbfa ._adda ,_eafa -_deef ,_edc ,_eafa -_deef ,_ffce ,_eaeg ,_ebabe );};if !_adfc {_df .DrawLine (_gbfa ._adda ,_gege -_ffc ,_edc ,_gege -_ffc ,_ffce ,_bgg ,_gbbe );};};};for _ ,_fcbb :=range _cbb ._efbg {if _fcbb !=nil {_gbfa ._adda .Draw (_fcbb );
-};};};func (_bfbe *convertContext )makeTextStyleFromCellStyle (_bdbe *style )*_ac .TextStyle {_efcc :=_bfbe ._adda .NewTextStyle ();if _bdbe ==nil {_efcc .FontSize =_df .DefaultFontSize ;_efcc .Font =_df .AssignStdFontByName (_efcc ,_df .StdFontsMap ["\u0064e\u0066\u0061\u0075\u006c\u0074"][FontStyle_Regular ]);
+};};_gbfa .drawSparklines (_cbb );};func (_bfbe *convertContext )makeTextStyleFromCellStyle (_bdbe *style )*_ac .TextStyle {_efcc :=_bfbe ._adda .NewTextStyle ();if _bdbe ==nil {_efcc .FontSize =_df .DefaultFontSize ;_efcc .Font =_df .AssignStdFontByName (_efcc ,_df .StdFontsMap ["\u0064e\u0066\u0061\u0075\u006c\u0074"][FontStyle_Regular ]);
 return &_efcc ;};if _gbfc (_bdbe ._addd ){_efcc .Underline =true ;_efcc .UnderlineStyle =_ac .TextDecorationLineStyle {Offset :0.5,Thickness :_cdbf (1/32)};};var _eeba FontStyle ;if _gbfc (_bdbe ._geca )&&_gbfc (_bdbe ._efba ){_eeba =FontStyle_BoldItalic ;
 }else if _gbfc (_bdbe ._geca ){_eeba =FontStyle_Bold ;}else if _gbfc (_bdbe ._efba ){_eeba =FontStyle_Italic ;}else {_eeba =FontStyle_Regular ;};_edf :="\u0064e\u0066\u0061\u0075\u006c\u0074";if _bdbe ._dgcg !=nil {_edf =*_bdbe ._dgcg ;};if _gaab ,_bbfe :=_df .StdFontsMap [_edf ];
 _bbfe {_efcc .Font =_df .AssignStdFontByName (_efcc ,_gaab [_eeba ]);}else if _gaaf :=_df .GetRegisteredFont (_edf ,_eeba );_gaaf !=nil {_efcc .Font =_gaaf ;}else {_c .Log .Debug ("\u0046\u006f\u006e\u0074\u0020\u0025\u0073\u0020\u0077\u0069\u0074h\u0020\u0073\u0074\u0079\u006c\u0065\u0020\u0025s\u0020i\u0073\u0020\u006e\u006f\u0074\u0020\u0066\u006f\u0075\u006e\u0064\u002c\u0020\u0072\u0065\u0073\u0065\u0074 \u0074\u006f\u0020\u0064\u0065\u0066\u0061\u0075\u006c\u0074\u002e",_edf ,_eeba );
@@ -101,7 +101,7 @@ _cbbb +=0.5*_gg ;};default:_cbbb =_cdf ._fcgd -_bg ;if _cdf ._egca {_cbbb -=4*_f
 _adg :=[]*cell {};if _dcaa ._ddab {for _ ,_befg :=range _dcaa ._agcf {_daa :=_dee ._ddce [_bae ];_dee ._geba =_daa ._gggc [_cbg ];_dee ._geba ._ecba =true ;_aa :=_befg ._cdg ;if _afb +_aa > _daa ._fcbd {_dee .addRowToPage (_adg ,_gfa );_adg =[]*cell {_befg };
 _afb =_aa ;_bae ++;}else {_befg ._aacff =_afb ;_adg =append (_adg ,_befg );_afb +=_aa ;};};if len (_adg )> 0{_dff :=_dee ._ddce [_bae ];_dee ._geba =_dff ._gggc [_cbg ];_dee ._geba ._ecba =true ;_dee .addRowToPage (_adg ,_gfa );};};};};};func (_cfcg *convertContext )makePages (){for _ ,_fcb :=range _cfcg ._ddce {for _ ,_afcf :=range _cfcg ._addb {_fcb ._gggc =append (_fcb ._gggc ,&page {_gbbg :[]*pageRow {},_edb :_fcb ,_dbg :_afcf });
 };};};type convertContext struct{_adda *_ac .Creator ;_ecd *_e .Workbook ;_ggga *_da .Theme ;_ebbc *_e .Sheet ;_agff *_e .StyleSheet ;_abed int ;_aacf int ;_ddce []*pagespan ;_geba *page ;_bbc []*colInfo ;_caea []*rowInfo ;_addb []*rowspan ;_gda float64 ;
-_eecg float64 ;_adcb float64 ;_afda float64 ;_bcad []*mergedCell ;_aaf []*anchor ;_ddcd float64 ;_acec int ;_eaa int ;_gfafg int ;_bac int ;_ccf bool ;_befd []_e .Table ;};var _efe =3.025/_cdbf (1);type page struct{_gbbg []*pageRow ;_ecba bool ;_efbg []*_ac .Image ;
+_eecg float64 ;_adcb float64 ;_afda float64 ;_bcad []*mergedCell ;_aaf []*anchor ;_ddcd float64 ;_acec int ;_eaa int ;_gfafg int ;_bac int ;_ccf bool ;_befd []_e .Table ;_gdbe []*sparkline ;};var _efe =3.025/_cdbf (1);type page struct{_gbbg []*pageRow ;_ecba bool ;_efbg []*_ac .Image ;
 _edb *pagespan ;_dbg *rowspan ;};const _dad =0.25;func (_ddb *convertContext )makeAnchors (){_efd ,_dag :=_ddb ._ebbc .GetDrawing ();if _efd !=nil {for _ ,_de :=range _efd .EG_Anchor {_cgc :=&anchor {};if _cfc :=_de .AnchorChoice .TwoCellAnchor ;_cfc !=nil {_cgg ,_fef :=_cfc .From ,_cfc .To ;
 if _cgg ==nil ||_fef ==nil {return ;};_cgc ._bcd =int (_cgg .Row );_cgc ._baf =_df .FromSTCoordinate (_cgg .RowOff );_cgc ._abede =int (_cgg .Col );_cgc ._cde =_df .FromSTCoordinate (_cgg .ColOff );_cgc ._bfcd =int (_fef .Row );_cgc ._efdc =_df .FromSTCoordinate (_fef .RowOff );
 _cgc ._dge =int (_fef .Col );_cgc ._baaed =_df .FromSTCoordinate (_fef .ColOff );if _ceb :=_cfc .ObjectChoicesChoice ;_ceb !=nil {if _bfc :=_ceb .Pic ;_bfc !=nil {if _cda :=_bfc .BlipFill ;_cda !=nil {if _fee :=_cda .Blip ;_fee !=nil {if _dfd :=_fee .EmbedAttr ;
@@ -136,7 +136,7 @@ _efdc int64 ;_dge int ;_baaed int64 ;};const _fa =64.0;func (_abb *convertContex
 };return _cfea ;};func (_fcg *convertContext )makePagespans (){_fcg ._ddce =[]*pagespan {};_bad :=0.0;_cdbb :=0;for _dbd ,_edeb :=range _fcg ._bbc {_cae :=_edeb ._fcfe ;if _bad +_cae <=_fcg ._afda {_edeb ._dcba =_bad ;_bad +=_cae ;}else {_edeb ._dcba =0;
 _fcg ._ddce =append (_fcg ._ddce ,&pagespan {_fcbd :_bad ,_ged :_cdbb ,_dgdga :_dbd });_bad =_cae ;_cdbb =_dbd ;};};_fcg ._ddce =append (_fcg ._ddce ,&pagespan {_fcbd :_bad ,_ged :_cdbb ,_dgdga :len (_fcg ._bbc )});};const _ge =0.0;func (_ab *convertContext )determineMaxIndexes (){var _ecb ,_eec int ;
 _ecb =int (_ab ._ebbc .MaxColumnIdx ());_cga :=_ab ._ebbc .Rows ();if len (_cga )> 0{_eec =int (_cga [len (_cga )-1].RowNumber ());};for _ ,_gfb :=range _ab ._aaf {if _gfb ._bfcd >=_eec {_eec =_gfb ._bfcd +1;};if _gfb ._dge >=_ecb {_ecb =_gfb ._dge +1;
-};};_ab ._abed =_eec ;_ab ._aacf =_ecb ;};type style struct{_eaedg *string ;_faaa *string ;_dfge *float64 ;_dgcg *string ;_geca *bool ;_efba *bool ;_addd *bool ;_ecc *bool ;_egdb *bool ;_aafa *border ;_aec *border ;_fdb *border ;_fggdf *border ;_eccg bool ;
+};};_eec ,_ecb =_ab .sparklineExtents (_eec ,_ecb );_ab ._abed =_eec ;_ab ._aacf =_ecb ;};type style struct{_eaedg *string ;_faaa *string ;_dfge *float64 ;_dgcg *string ;_geca *bool ;_efba *bool ;_addd *bool ;_ecc *bool ;_egdb *bool ;_aafa *border ;_aec *border ;_fdb *border ;_fggdf *border ;_eccg bool ;
 _egg _ee .ST_VerticalAlignment ;_fgbb _ee .ST_HorizontalAlignment ;_ggdf bool ;};
 
 // ConvertToPdfWithOptions convert a sheet to PDF with given options.
@@ -148,8 +148,8 @@ if len (_fgc .Themes ())> 0{_dg =_fgc .Themes ()[0];};var _eba ,_afg ,_cc ,_dae
 if _bf ==nil &&s .Name ()==_agf {_eba =int (_bed .ColumnIdx );_afg =int (_dde .ColumnIdx );_cc =int (_bed .RowIdx );_dae =int (_dde .RowIdx );};};};_ddc :=[]_e .Table {};if _ce .TableParts !=nil &&_ce .TableParts .TablePart !=nil {_dc :=0;_db :=s .Workbook ().Tables ();
 _f .Slice (_db [:],func (_gfe ,_efb int )bool {return _db [_gfe ].X ().IdAttr < _db [_efb ].X ().IdAttr });for _ ,_ec :=range s .Workbook ().Sheets (){if _ec .Name ()==s .Name (){break ;}else {if _ec .X ().TableParts !=nil &&_ec .X ().TableParts .TablePart !=nil {_dc +=len (_ec .X ().TableParts .TablePart );
 };};};if len (_db )>=_dc +len (_ce .TableParts .TablePart ){_ddc =append (_ddc ,_db [_dc :_dc +len (_ce .TableParts .TablePart )]...);};};_gcc :=&convertContext {_adda :_fed ,_ebbc :s ,_ecd :s .Workbook (),_ggga :_dg ,_agff :&s .Workbook ().StyleSheet ,_gda :_ag ,_eecg :_gge ,_adcb :_dd [1]-_afd -_ag ,_afda :_dd [0]-_gd -_gge ,_acec :_eba ,_eaa :_afg ,_gfafg :_cc ,_bac :_dae ,_ccf :_cd ,_befd :_ddc };
-_gcc .makeAnchors ();_gcc .determineMaxIndexes ();if _gcc ._abed ==0&&_gcc ._aacf ==0{_fed .NewPage ();return _fed ;};_gcc .makeCols ();_gcc .makeRows ();_gcc .makeMergedCells ();_gcc .makeCells ();_gcc .makePagespans ();_gcc .makeRowspans ();_gcc .makePages ();
-_gcc .fillPages ();_gcc .distributeAnchors ();_gcc .drawSheet ();return _fed ;};type colInfo struct{_dcba float64 ;_fcfe float64 ;_cagg *style ;};func (_bebe *convertContext )getBorder (_gabg *_ee .CT_BorderPr )*border {_cfb :=&border {};switch _gabg .StyleAttr {case _ee .ST_BorderStyleHair :_cfb ._afba =_gc /2;
+_gcc .makeAnchors ();_gcc .makeSparklines ();_gcc .determineMaxIndexes ();if _gcc ._abed ==0&&_gcc ._aacf ==0{_fed .NewPage ();return _fed ;};_gcc .makeCols ();_gcc .makeRows ();_gcc .makeMergedCells ();_gcc .makeCells ();_gcc .makePagespans ();_gcc .makeRowspans ();_gcc .makePages ();
+_gcc .fillPages ();_gcc .distributeAnchors ();_gcc .distributeSparklines ();_gcc .drawSheet ();return _fed ;};type colInfo struct{_dcba float64 ;_fcfe float64 ;_cagg *style ;};func (_bebe *convertContext )getBorder (_gabg *_ee .CT_BorderPr )*border {_cfb :=&border {};switch _gabg .StyleAttr {case _ee .ST_BorderStyleHair :_cfb ._afba =_gc /2;
 case _ee .ST_BorderStyleThin :_cfb ._afba =_gc ;case _ee .ST_BorderStyleMedium :_cfb ._afba =_gc *2;case _ee .ST_BorderStyleThick :_cfb ._afba =_gc *4;};if _cfb ._afba ==0.0{return nil ;};if _efcg :=_gabg .Color ;_efcg !=nil {_aee :=_bebe .getColorStringFromSmlColor (_efcg );
 if _aee !=nil {_cfb ._cbc =_ac .ColorRGBFromHex (*_aee );}else {_cfb ._cbc =_ac .ColorBlack ;};};return _cfb ;};func _ebb (_cfec *symbol ){_bgc :=_ac .New ();_eadg :=_bgc .NewStyledParagraph ();_eadg .SetMargins (0,0,0,0);_bdgcg :=_eadg .Append (_cfec ._badd );
 if _cfec ._acd !=nil {_bdgcg .Style =*_cfec ._acd ;};_cfec ._gbaad =_eadg .Height ();if _cfec ._aaa ==0{_cfec ._aaa =_eadg .Width ();};};func (_ggbc *convertContext )distributeAnchors (){for _ ,_cfcc :=range _ggbc ._aaf {_acc ,_fgf :=_cfcc ._bcd ,_cfcc ._baf ;
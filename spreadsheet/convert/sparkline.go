@@ -0,0 +1,433 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package convert
+
+import (
+	"math"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/common/logger"
+	"github.com/unidoc/unioffice/v2/internal/convertutils"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/spreadsheet"
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+	"github.com/unidoc/unipdf/v4/contentstream/draw"
+	"github.com/unidoc/unipdf/v4/creator"
+)
+
+// sparkline is a sparkline to be drawn in a cell of the sheet.
+type sparkline struct {
+	group spreadsheet.SparklineGroup
+	// row and col are the position of the cell, 0-N
+	row, col int
+	// values are the values displayed, NaN for the empty cells, and dates
+	// their dates if the group has a date axis
+	values []float64
+	dates  []float64
+	// min and max are the range of the vertical axis
+	min, max float64
+	page     *page
+}
+
+// sheetValues are the numeric values of the cells of a sheet, by row (1-N)
+// and column (0-N).
+type sheetValues struct {
+	values     map[[2]uint32]float64
+	hiddenRows map[uint32]bool
+	hiddenCols map[uint32]bool
+}
+
+// makeSparklines reads the sparklines of the sheet and their values.
+func (c *convertContext) makeSparklines() {
+	cache := map[string]*sheetValues{}
+	for _, g := range c._ebbc.SparklineGroups() {
+		var group []*sparkline
+		for _, sl := range g.Sparklines() {
+			loc, err := reference.ParseCellReference(sl.Location)
+			if err != nil {
+				logger.Log.Debug("invalid sparkline location %s: %s", sl.Location, err)
+				continue
+			}
+			s := &sparkline{group: g, row: int(loc.RowIdx) - 1, col: int(loc.ColumnIdx)}
+			s.values = c.sparklineValues(cache, sl.Data, g.ShowHidden())
+			if g.EmptyCells() == spreadsheet.SparklineEmptyCellsZero {
+				for i, v := range s.values {
+					if math.IsNaN(v) {
+						s.values[i] = 0
+					}
+				}
+			}
+			if ref := g.DateAxis(); ref != "" {
+				dates := c.sparklineValues(cache, ref, g.ShowHidden())
+				if len(dates) == len(s.values) {
+					s.dates = dates
+				}
+			}
+			if g.RightToLeft() {
+				reverse(s.values)
+				reverse(s.dates)
+			}
+			group = append(group, s)
+		}
+		scaleSparklines(g, group)
+		c._gdbe = append(c._gdbe, group...)
+	}
+}
+
+func reverse(values []float64) {
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
+	}
+}
+
+// sparklineValues returns the values of a range such as "Sheet1!A1:E1", NaN
+// for empty and non-numeric cells.
+func (c *convertContext) sparklineValues(cache map[string]*sheetValues, ref string, hidden bool) []float64 {
+	name, cells := "", ref
+	if i := strings.LastIndex(ref, "!"); i >= 0 {
+		name, cells = ref[:i], ref[i+1:]
+		if strings.HasPrefix(name, "'") && strings.HasSuffix(name, "'") && len(name) > 1 {
+			name = strings.ReplaceAll(name[1:len(name)-1], "''", "'")
+		}
+	} else {
+		name = c._ebbc.Name()
+	}
+	cells = strings.ReplaceAll(cells, "$", "")
+	from, to, err := reference.ParseRangeReference(cells)
+	if err != nil {
+		if from, err = reference.ParseCellReference(cells); err != nil {
+			logger.Log.Debug("invalid sparkline range %s: %s", ref, err)
+			return nil
+		}
+		to = from
+	}
+	sv, ok := cache[name]
+	if !ok {
+		if sheet, err := c._ecd.GetSheet(name); err == nil {
+			sv = readSheetValues(sheet)
+		}
+		cache[name] = sv
+	}
+	var ret []float64
+	for row := from.RowIdx; row <= to.RowIdx; row++ {
+		for col := from.ColumnIdx; col <= to.ColumnIdx; col++ {
+			if sv == nil {
+				ret = append(ret, math.NaN())
+				continue
+			}
+			if !hidden && (sv.hiddenRows[row] || sv.hiddenCols[col]) {
+				continue
+			}
+			if v, ok := sv.values[[2]uint32{row, col}]; ok {
+				ret = append(ret, v)
+			} else {
+				ret = append(ret, math.NaN())
+			}
+		}
+	}
+	return ret
+}
+
+func readSheetValues(sheet spreadsheet.Sheet) *sheetValues {
+	sv := &sheetValues{values: map[[2]uint32]float64{}, hiddenRows: map[uint32]bool{}, hiddenCols: map[uint32]bool{}}
+	for _, r := range sheet.Rows() {
+		if r.IsHidden() {
+			sv.hiddenRows[r.RowNumber()] = true
+		}
+		for _, cell := range r.Cells() {
+			if !cell.IsNumber() {
+				continue
+			}
+			ref, err := reference.ParseCellReference(cell.Reference())
+			if err != nil {
+				continue
+			}
+			if v, err := cell.GetValueAsNumber(); err == nil {
+				sv.values[[2]uint32{ref.RowIdx, ref.ColumnIdx}] = v
+			}
+		}
+	}
+	for _, cols := range sheet.X().Cols {
+		for _, col := range cols.Col {
+			if col.HiddenAttr != nil && *col.HiddenAttr {
+				for i := col.MinAttr; i <= col.MaxAttr && i > 0; i++ {
+					sv.hiddenCols[i-1] = true
+				}
+			}
+		}
+	}
+	return sv
+}
+
+// scaleSparklines sets the range of the vertical axis of the sparklines of a
+// group.
+func scaleSparklines(g spreadsheet.SparklineGroup, group []*sparkline) {
+	groupMin, groupMax := math.Inf(1), math.Inf(-1)
+	for _, s := range group {
+		s.min, s.max = math.Inf(1), math.Inf(-1)
+		for _, v := range s.values {
+			if !math.IsNaN(v) {
+				s.min = math.Min(s.min, v)
+				s.max = math.Max(s.max, v)
+			}
+		}
+		groupMin = math.Min(groupMin, s.min)
+		groupMax = math.Max(groupMax, s.max)
+	}
+	minScale, minValue := g.MinAxis()
+	maxScale, maxValue := g.MaxAxis()
+	for _, s := range group {
+		switch minScale {
+		case spreadsheet.SparklineAxisGroup:
+			s.min = groupMin
+		case spreadsheet.SparklineAxisCustom:
+			s.min = minValue
+		default:
+			// columns are drawn from zero when all the values are positive
+			if g.Type() == spreadsheet.SparklineTypeColumn && s.min > 0 {
+				s.min = 0
+			}
+		}
+		switch maxScale {
+		case spreadsheet.SparklineAxisGroup:
+			s.max = groupMax
+		case spreadsheet.SparklineAxisCustom:
+			s.max = maxValue
+		default:
+			if g.Type() == spreadsheet.SparklineTypeColumn && s.max < 0 {
+				s.max = 0
+			}
+		}
+	}
+}
+
+// sparklineExtents returns the number of rows and columns of the sheet,
+// extended to the cells of the sparklines.
+func (c *convertContext) sparklineExtents(rows, cols int) (int, int) {
+	for _, s := range c._gdbe {
+		if s.row >= rows {
+			rows = s.row + 1
+		}
+		if s.col >= cols {
+			cols = s.col + 1
+		}
+	}
+	return rows, cols
+}
+
+// distributeSparklines sets the pages the sparklines are drawn on.
+func (c *convertContext) distributeSparklines() {
+	for _, s := range c._gdbe {
+		if s.row < c._gfafg || (s.row > c._bac && c._bac > 0) {
+			continue
+		}
+		if s.col < c._acec || (s.col > c._eaa && c._eaa > 0) {
+			continue
+		}
+		if s.row >= len(c._caea) || s.col >= len(c._bbc) {
+			continue
+		}
+		for _, ps := range c._ddce {
+			if s.col < ps._ged || s.col >= ps._dgdga {
+				continue
+			}
+			for _, p := range ps._gggc {
+				if s.row >= p._dbg._fda && s.row < p._dbg._cegb {
+					p._ecba = true
+					s.page = p
+				}
+			}
+		}
+	}
+}
+
+// drawSparklines draws the sparklines of a page.
+func (c *convertContext) drawSparklines(p *page) {
+	for _, s := range c._gdbe {
+		if s.page != p {
+			continue
+		}
+		x := c._eecg + c._bbc[s.col]._dcba
+		y := c._gda + c._caea[s.row]._fdaa
+		w, h := c._bbc[s.col]._fcfe, c._caea[s.row]._gebge
+		for _, m := range c._bcad {
+			if int(m._beeb) == s.row+1 && int(m._gca) == s.col {
+				w, h = m._bggbg, m._aca
+			}
+		}
+		c.drawSparkline(s, x, y, w, h)
+	}
+}
+
+const (
+	sparklinePaddingX = 1.5
+	sparklinePaddingY = 2.0
+)
+
+// drawSparkline draws a sparkline in a box.
+func (c *convertContext) drawSparkline(s *sparkline, x, y, w, h float64) {
+	n := len(s.values)
+	if n == 0 || w <= 2*sparklinePaddingX || h <= 2*sparklinePaddingY {
+		return
+	}
+	x, y = x+sparklinePaddingX, y+sparklinePaddingY
+	w, h = w-2*sparklinePaddingX, h-2*sparklinePaddingY
+	g := s.group
+	typ := g.Type()
+
+	// the vertical position of a value, from the top of the page
+	valueY := func(v float64) float64 {
+		if typ == spreadsheet.SparklineTypeWinLoss {
+			switch {
+			case v > 0:
+				return y
+			case v < 0:
+				return y + h
+			}
+			return y + h/2
+		}
+		if s.max <= s.min {
+			return y + h/2
+		}
+		v = math.Max(s.min, math.Min(s.max, v))
+		return y + h - (v-s.min)/(s.max-s.min)*h
+	}
+	axisY := valueY(0)
+
+	// the colors of the points that are highlighted
+	colors := c.sparklinePointColors(s)
+
+	switch typ {
+	case spreadsheet.SparklineTypeLine:
+		pointX := func(i int) float64 {
+			if s.dates != nil {
+				lo, hi := math.Inf(1), math.Inf(-1)
+				for _, d := range s.dates {
+					if !math.IsNaN(d) {
+						lo, hi = math.Min(lo, d), math.Max(hi, d)
+					}
+				}
+				if hi > lo && !math.IsNaN(s.dates[i]) {
+					return x + (s.dates[i]-lo)/(hi-lo)*w
+				}
+			}
+			if n == 1 {
+				return x + w/2
+			}
+			return x + float64(i)*w/float64(n-1)
+		}
+		if g.ShowAxis() && s.min < 0 && s.max > 0 {
+			convertutils.DrawLine(c._adda, x, axisY, x+w, axisY, 0.5, c.sparklineColor(g, spreadsheet.SparklineColorAxis))
+		}
+		weight := float64(g.LineWeight() / measurement.Point)
+		var points []draw.Point
+		flush := func() {
+			if len(points) > 1 {
+				line := c._adda.NewPolyline(points)
+				line.SetLineColor(c.sparklineColor(g, spreadsheet.SparklineColorSeries))
+				line.SetLineWidth(weight)
+				c._adda.Draw(line)
+			}
+			points = nil
+		}
+		for i, v := range s.values {
+			if math.IsNaN(v) {
+				if g.EmptyCells() == spreadsheet.SparklineEmptyCellsGap {
+					flush()
+				}
+				continue
+			}
+			points = append(points, draw.Point{X: pointX(i), Y: valueY(v)})
+		}
+		flush()
+		size := math.Max(2.5, 3*weight)
+		for i, v := range s.values {
+			if clr := colors[i]; clr != nil && !math.IsNaN(v) {
+				marker := c._adda.NewEllipse(pointX(i), valueY(v), size, size)
+				marker.SetFillColor(clr)
+				marker.SetBorderColor(clr)
+				marker.SetBorderWidth(0)
+				c._adda.Draw(marker)
+			}
+		}
+	default:
+		slot := w / float64(n)
+		bar := slot * 0.8
+		for i, v := range s.values {
+			if math.IsNaN(v) {
+				continue
+			}
+			top, bottom := valueY(v), axisY
+			if top > bottom {
+				top, bottom = bottom, top
+			}
+			if bottom-top <= 0 {
+				continue
+			}
+			clr := colors[i]
+			if clr == nil {
+				clr = c.sparklineColor(g, spreadsheet.SparklineColorSeries)
+			}
+			convertutils.FillRectangle(c._adda, x+float64(i)*slot+(slot-bar)/2, top, bar, bottom-top, clr)
+		}
+		if g.ShowAxis() && (typ == spreadsheet.SparklineTypeWinLoss || (s.min < 0 && s.max > 0)) {
+			convertutils.DrawLine(c._adda, x, axisY, x+w, axisY, 0.5, c.sparklineColor(g, spreadsheet.SparklineColorAxis))
+		}
+	}
+}
+
+// sparklinePointColors returns the colors of the points of a sparkline that
+// are highlighted, nil for the others.
+func (c *convertContext) sparklinePointColors(s *sparkline) []creator.Color {
+	g := s.group
+	colors := make([]creator.Color, len(s.values))
+	first, last := -1, -1
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, v := range s.values {
+		if math.IsNaN(v) {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	set := func(p spreadsheet.SparklinePoints, clr spreadsheet.SparklineColor, match func(i int, v float64) bool) {
+		if !g.ShowPoints(p) {
+			return
+		}
+		for i, v := range s.values {
+			if colors[i] == nil && !math.IsNaN(v) && match(i, v) {
+				colors[i] = c.sparklineColor(g, clr)
+			}
+		}
+	}
+	// the colors take precedence in the order Excel gives them
+	set(spreadsheet.SparklinePointsHigh, spreadsheet.SparklineColorHigh, func(i int, v float64) bool { return v == hi })
+	set(spreadsheet.SparklinePointsLow, spreadsheet.SparklineColorLow, func(i int, v float64) bool { return v == lo })
+	set(spreadsheet.SparklinePointsFirst, spreadsheet.SparklineColorFirst, func(i int, v float64) bool { return i == first })
+	set(spreadsheet.SparklinePointsLast, spreadsheet.SparklineColorLast, func(i int, v float64) bool { return i == last })
+	set(spreadsheet.SparklinePointsNegative, spreadsheet.SparklineColorNegative, func(i int, v float64) bool { return v < 0 })
+	if g.Type() == spreadsheet.SparklineTypeLine {
+		set(spreadsheet.SparklinePointsAll, spreadsheet.SparklineColorMarkers, func(i int, v float64) bool { return true })
+	}
+	return colors
+}
+
+// sparklineColor returns a color of a sparkline group, black if it isn't set.
+func (c *convertContext) sparklineColor(g spreadsheet.SparklineGroup, which spreadsheet.SparklineColor) creator.Color {
+	if clr := g.Color(which); clr != nil {
+		if hex := c.getColorStringFromSmlColor(clr); hex != nil {
+			return creator.ColorRGBFromHex(*hex)
+		}
+	}
+	return creator.ColorBlack
+}
@@ -0,0 +1,496 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"strconv"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/schema/soo/pkg/relationships"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/spreadsheet/formula"
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+	"github.com/unidoc/unioffice/v2/zippkg"
+)
+
+// Dynamic array formulas are array formulas whose cell metadata refers to the
+// XLDAPR metadata type, with the dynamic array properties stored in an
+// extension of its future metadata. Without them Excel shows the formulas as
+// legacy Ctrl+Shift+Enter arrays.
+const (
+	dynamicArrayNamespace    = "http://schemas.microsoft.com/office/spreadsheetml/2017/dynamicarray"
+	dynamicArrayURI          = "{bdbb8cdc-fa1e-496e-a857-3c3f30c029c3}"
+	dynamicArrayMetadataType = "XLDAPR"
+	spillError               = "#SPILL!"
+)
+
+// Sheet limits for the cells formulas spill into.
+const (
+	maxSpillRow    = 1048576
+	maxSpillColumn = 16383
+)
+
+// SetFormulaDynamicArray sets the cell to a dynamic array formula, whose
+// result spills into the cells below and to the right of it when formulas are
+// recalculated. The formula is written as in Excel 365, e.g. "SORT(A1:A10)",
+// with the spill range operator A1# and the implicit intersection operator @,
+// and is stored in the form Excel expects, see formula.StoredFormula. The
+// formulas of other cells using the spill range operator must be stored in
+// that form as well, e.g. SetFormulaRaw(formula.StoredFormula("SUM(A1#)")),
+// as Excel doesn't read the operator in files.
+func (c Cell) SetFormulaDynamicArray(s string) {
+	f := formula.StoredFormula(s)
+	if formula.ParseString(f) == nil {
+		return
+	}
+	c.clearValue()
+	c._dga.TAttr = sml.ST_CellTypeStr
+	c._dga.F = sml.NewCT_CellFormula()
+	c._dga.F.TAttr = sml.ST_CellFormulaTypeArray
+	c._dga.F.RefAttr = unioffice.String(c.Reference())
+	c._dga.F.Content = f
+	c._dga.CmAttr = unioffice.Uint32(c._bgg.dynamicArrayMetadata())
+}
+
+// IsDynamicArray returns true if the cell has a dynamic array formula.
+func (c Cell) IsDynamicArray() bool {
+	return c._bgg != nil && c._bgg.isDynamicArray(c._dga)
+}
+
+// SpillRange returns the range of cells the dynamic array formula of the cell
+// spilled into when last calculated, such as "B2:B10", or an empty string if
+// the cell has no dynamic array formula.
+func (c Cell) SpillRange() string {
+	if !c.IsDynamicArray() || c._dga.F.RefAttr == nil {
+		return ""
+	}
+	return *c._dga.F.RefAttr
+}
+
+// clearSpill clears the cells the dynamic array formula of the cell spilled
+// into and its dynamic array flag, before the cell is changed.
+func (c Cell) clearSpill() {
+	if !c.IsDynamicArray() {
+		return
+	}
+	if c._cee != nil && c._dga.RAttr != nil && c._dga.F.RefAttr != nil {
+		if from, to, ok := spillBounds(*c._dga.F.RefAttr); ok {
+			c._cee.clearSpilled(*c._dga.RAttr, from, to, nil)
+		}
+	}
+	c._dga.CmAttr = nil
+}
+
+// spilledValue returns the value of a dynamic array formula cell, the first
+// value of the result of its formula.
+func (c Cell) spilledValue(r formula.Result) formula.Result {
+	if r.Type == formula.ResultTypeError {
+		return r
+	}
+	if c._dga.TAttr == sml.ST_CellTypeE && c._dga.V != nil && *c._dga.V == spillError {
+		return formula.MakeErrorResultType(formula.ErrorTypeSpill, "")
+	}
+	return spillRows(r)[0][0]
+}
+
+// SpillRange returns the range the dynamic array formula of a cell spills into,
+// used by the spill range operator.
+func (e *evalContext) SpillRange(cell string) (string, bool) {
+	ref, err := reference.ParseCellReference(cell)
+	if err != nil {
+		return "", false
+	}
+	if e._abdg != 0 && !ref.AbsoluteColumn {
+		ref.ColumnIdx += e._abdg
+		ref.Column = reference.IndexToColumn(ref.ColumnIdx)
+	}
+	if e._bgba != 0 && !ref.AbsoluteRow {
+		ref.RowIdx += e._bgba
+	}
+	x := e.cell(ref.Column + strconv.Itoa(int(ref.RowIdx)))._dga
+	if x.F == nil || x.F.TAttr != sml.ST_CellFormulaTypeArray || x.F.RefAttr == nil {
+		return "", false
+	}
+	if x.TAttr == sml.ST_CellTypeE && x.V != nil && *x.V == spillError {
+		return "", false
+	}
+	return *x.F.RefAttr, true
+}
+
+// spillOutcome describes the cells a dynamic array formula spilled into.
+type spillOutcome struct {
+	// prev and next are the ranges spilled into before and after, want is
+	// the range of the result, differing from next if it was blocked
+	prev, next, want string
+
+	// value is the value of the formula cell
+	value formula.Result
+}
+
+// spill writes the result of the dynamic array formula of a cell to the cell
+// and the cells below and to the right of it. If the result would overwrite
+// cells with contents, other than those the formula spilled into before and
+// those not overwritten since, the formula gets a #SPILL! error instead.
+func (s *Sheet) spill(x *sml.CT_Cell, r formula.Result, overwritten func(col, row uint32) bool) spillOutcome {
+	master := *x.RAttr
+	out := spillOutcome{prev: master, next: master, want: master}
+	if x.F.RefAttr != nil {
+		out.prev = *x.F.RefAttr
+	}
+	mref, err := reference.ParseCellReference(master)
+	if err != nil {
+		return out
+	}
+	pf, pt, ok := spillBounds(out.prev)
+	if !ok {
+		pf, pt = mref, mref
+	}
+	if overwritten == nil {
+		overwritten = func(col, row uint32) bool { return false }
+	}
+	s.clearSpilled(master, pf, pt, overwritten)
+	x.F.RefAttr = unioffice.String(master)
+
+	if r.Type == formula.ResultTypeError {
+		out.value = r
+		x.V = nil
+		return out
+	}
+	rows := spillRows(r)
+	endCol := mref.ColumnIdx + uint32(len(rows[0])) - 1
+	endRow := mref.RowIdx + uint32(len(rows)) - 1
+	if endCol > mref.ColumnIdx || endRow > mref.RowIdx {
+		out.want = master + ":" + reference.IndexToColumn(endCol) + strconv.Itoa(int(endRow))
+	}
+	if endCol > maxSpillColumn || endRow > maxSpillRow || s.spillBlocked(mref, endCol, endRow) {
+		out.value = formula.MakeErrorResultType(formula.ErrorTypeSpill, "spill range "+out.want+" isn't empty")
+		setFormulaValue(x, out.value)
+		return out
+	}
+	for i, row := range rows {
+		sr := s.Row(mref.RowIdx + uint32(i))
+		for j, v := range row {
+			if i == 0 && j == 0 {
+				continue
+			}
+			setSpilledValue(sr.Cell(reference.IndexToColumn(mref.ColumnIdx+uint32(j)))._dga, v)
+		}
+	}
+	out.next = out.want
+	out.value = rows[0][0]
+	setFormulaValue(x, out.value)
+	x.F.RefAttr = unioffice.String(out.next)
+	return out
+}
+
+// spillBlocked returns true if cells of a spill range other than its formula
+// cell have contents, or are merged.
+func (s *Sheet) spillBlocked(from reference.CellReference, endCol, endRow uint32) bool {
+	for _, r := range s._bbbe.SheetData.Row {
+		if r.RAttr == nil || *r.RAttr < from.RowIdx || *r.RAttr > endRow {
+			continue
+		}
+		for _, c := range r.C {
+			if c.RAttr == nil {
+				continue
+			}
+			ref, err := reference.ParseCellReference(*c.RAttr)
+			if err != nil || ref.ColumnIdx < from.ColumnIdx || ref.ColumnIdx > endCol || (ref.ColumnIdx == from.ColumnIdx && ref.RowIdx == from.RowIdx) {
+				continue
+			}
+			if c.F != nil || c.V != nil || c.Is != nil {
+				return true
+			}
+		}
+	}
+	for _, m := range s.MergedCells() {
+		mf, mt, err := reference.ParseRangeReference(m.Reference())
+		if err == nil && mf.ColumnIdx <= endCol && mt.ColumnIdx >= from.ColumnIdx && mf.RowIdx <= endRow && mt.RowIdx >= from.RowIdx {
+			return true
+		}
+	}
+	return false
+}
+
+// clearSpilled clears the values of the cells of a spill range, except its
+// formula cell, the cells having formulas and the cells overwritten since the
+// formula spilled.
+func (s *Sheet) clearSpilled(master string, from, to reference.CellReference, overwritten func(col, row uint32) bool) {
+	for _, r := range s._bbbe.SheetData.Row {
+		if r.RAttr == nil || *r.RAttr < from.RowIdx || *r.RAttr > to.RowIdx {
+			continue
+		}
+		for _, c := range r.C {
+			if c.RAttr == nil || *c.RAttr == master || c.F != nil {
+				continue
+			}
+			ref, err := reference.ParseCellReference(*c.RAttr)
+			if err != nil || ref.ColumnIdx < from.ColumnIdx || ref.ColumnIdx > to.ColumnIdx {
+				continue
+			}
+			if overwritten != nil && overwritten(ref.ColumnIdx, ref.RowIdx) {
+				continue
+			}
+			Cell{s._fgeg, s, r, c}.changed()
+			c.V = nil
+			c.Is = nil
+			c.TAttr = sml.ST_CellTypeUnset
+		}
+	}
+}
+
+// spillBounds parses a spill range, a single cell for formulas that didn't
+// spill.
+func spillBounds(ref string) (from, to reference.CellReference, ok bool) {
+	from, to, err := reference.ParseRangeReference(ref)
+	if err == nil {
+		return from, to, true
+	}
+	from, err = reference.ParseCellReference(ref)
+	return from, from, err == nil
+}
+
+// spillRows returns the rows of values of a formula result.
+func spillRows(r formula.Result) [][]formula.Result {
+	switch r.Type {
+	case formula.ResultTypeArray:
+		if len(r.ValueArray) > 0 && len(r.ValueArray[0]) > 0 {
+			return r.ValueArray
+		}
+	case formula.ResultTypeList:
+		if len(r.ValueList) > 0 {
+			return [][]formula.Result{r.ValueList}
+		}
+	default:
+		return [][]formula.Result{{r}}
+	}
+	return [][]formula.Result{{formula.MakeEmptyResult()}}
+}
+
+// setFormulaValue caches the value of a formula cell.
+func setFormulaValue(x *sml.CT_Cell, v formula.Result) {
+	x.Is = nil
+	switch v.Type {
+	case formula.ResultTypeNumber:
+		if v.IsBoolean {
+			x.TAttr = sml.ST_CellTypeB
+		} else {
+			x.TAttr = sml.ST_CellTypeN
+		}
+	case formula.ResultTypeError:
+		x.TAttr = sml.ST_CellTypeE
+	case formula.ResultTypeEmpty:
+		x.TAttr = sml.ST_CellTypeN
+		x.V = unioffice.String("0")
+		return
+	default:
+		x.TAttr = sml.ST_CellTypeStr
+	}
+	x.V = unioffice.String(spillValue(v))
+}
+
+// setSpilledValue sets the value of a cell a formula spilled into.
+func setSpilledValue(x *sml.CT_Cell, v formula.Result) {
+	x.F = nil
+	x.Is = nil
+	x.V = nil
+	x.TAttr = sml.ST_CellTypeUnset
+	switch v.Type {
+	case formula.ResultTypeEmpty:
+		return
+	case formula.ResultTypeString:
+		x.TAttr = sml.ST_CellTypeInlineStr
+		x.Is = sml.NewCT_Rst()
+		x.Is.T = unioffice.String(v.ValueString)
+		return
+	case formula.ResultTypeNumber:
+		if v.IsBoolean {
+			x.TAttr = sml.ST_CellTypeB
+		} else {
+			x.TAttr = sml.ST_CellTypeN
+		}
+	case formula.ResultTypeError:
+		x.TAttr = sml.ST_CellTypeE
+	}
+	x.V = unioffice.String(spillValue(v))
+}
+
+// spillValue formats a value as stored in a cell.
+func spillValue(v formula.Result) string {
+	switch {
+	case v.Type == formula.ResultTypeNumber && v.IsBoolean:
+		if v.ValueNumber != 0 {
+			return "1"
+		}
+		return "0"
+	case v.Type == formula.ResultTypeNumber:
+		return strconv.FormatFloat(v.ValueNumber, 'g', -1, 64)
+	}
+	return v.ValueString
+}
+
+// isDynamicArray returns true if a cell has an array formula flagged as
+// dynamic by its cell metadata.
+func (wb *Workbook) isDynamicArray(x *sml.CT_Cell) bool {
+	md := wb._dfcga
+	if x.F == nil || x.F.TAttr != sml.ST_CellFormulaTypeArray || x.CmAttr == nil || md == nil || md.CellMetadata == nil || md.MetadataTypes == nil {
+		return false
+	}
+	cm := int(*x.CmAttr)
+	if cm < 1 || cm > len(md.CellMetadata.Bk) {
+		return false
+	}
+	types := md.MetadataTypes.MetadataType
+	for _, rc := range md.CellMetadata.Bk[cm-1].Rc {
+		if rc.TAttr >= 1 && int(rc.TAttr) <= len(types) && types[rc.TAttr-1].NameAttr == dynamicArrayMetadataType {
+			return true
+		}
+	}
+	return false
+}
+
+// dynamicArrayMetadata returns the index of the cell metadata flagging
+// dynamic array formulas, adding the metadata part if needed.
+func (wb *Workbook) dynamicArrayMetadata() uint32 {
+	md := wb._dfcga
+	if md == nil {
+		md = sml.NewMetadata()
+		wb._dfcga = md
+		wb._bcg.AddAutoRelationship(unioffice.DocTypeSpreadsheet, unioffice.OfficeDocumentType, 1, unioffice.SheetMetadataType)
+	}
+	if md.MetadataTypes == nil {
+		md.MetadataTypes = sml.NewCT_MetadataTypes()
+	}
+	typ := 0
+	for i, t := range md.MetadataTypes.MetadataType {
+		if t.NameAttr == dynamicArrayMetadataType {
+			typ = i + 1
+			break
+		}
+	}
+	if typ == 0 {
+		md.MetadataTypes.MetadataType = append(md.MetadataTypes.MetadataType, newDynamicArrayMetadataType())
+		typ = len(md.MetadataTypes.MetadataType)
+		md.MetadataTypes.CountAttr = unioffice.Uint32(uint32(typ))
+	}
+
+	var future *sml.CT_FutureMetadata
+	for _, f := range md.FutureMetadata {
+		if f.NameAttr == dynamicArrayMetadataType {
+			future = f
+			break
+		}
+	}
+	if future == nil {
+		future = sml.NewCT_FutureMetadata()
+		future.NameAttr = dynamicArrayMetadataType
+		md.FutureMetadata = append(md.FutureMetadata, future)
+	}
+	value := -1
+	for i, bk := range future.Bk {
+		if isDynamicArrayBlock(bk) {
+			value = i
+			break
+		}
+	}
+	if value < 0 {
+		future.Bk = append(future.Bk, newDynamicArrayBlock())
+		value = len(future.Bk) - 1
+		future.CountAttr = unioffice.Uint32(uint32(len(future.Bk)))
+	}
+
+	if md.CellMetadata == nil {
+		md.CellMetadata = sml.NewCT_MetadataBlocks()
+	}
+	for i, bk := range md.CellMetadata.Bk {
+		if len(bk.Rc) == 1 && bk.Rc[0].TAttr == uint32(typ) && bk.Rc[0].VAttr == uint32(value) {
+			return uint32(i + 1)
+		}
+	}
+	rc := sml.NewCT_MetadataRecord()
+	rc.TAttr = uint32(typ)
+	rc.VAttr = uint32(value)
+	bk := sml.NewCT_MetadataBlock()
+	bk.Rc = []*sml.CT_MetadataRecord{rc}
+	md.CellMetadata.Bk = append(md.CellMetadata.Bk, bk)
+	md.CellMetadata.CountAttr = unioffice.Uint32(uint32(len(md.CellMetadata.Bk)))
+	return uint32(len(md.CellMetadata.Bk))
+}
+
+// newDynamicArrayMetadataType returns the XLDAPR metadata type with the
+// options Excel writes for it.
+func newDynamicArrayMetadataType() *sml.CT_MetadataType {
+	t := sml.NewCT_MetadataType()
+	t.NameAttr = dynamicArrayMetadataType
+	t.MinSupportedVersionAttr = 120000
+	for _, attr := range []**bool{&t.CopyAttr, &t.PasteAllAttr, &t.PasteValuesAttr, &t.MergeAttr, &t.SplitFirstAttr, &t.RowColShiftAttr, &t.ClearFormatsAttr, &t.ClearCommentsAttr, &t.AssignAttr, &t.CoerceAttr, &t.CellMetaAttr} {
+		*attr = unioffice.Bool(true)
+	}
+	return t
+}
+
+// newDynamicArrayBlock returns a future metadata block with the properties of
+// a dynamic array formula that isn't collapsed to a single value.
+func newDynamicArrayBlock() *sml.CT_FutureMetadataBlock {
+	props := &unioffice.XSDAny{XMLName: xml.Name{Space: dynamicArrayNamespace, Local: "dynamicArrayProperties"}}
+	props.Attrs = []xml.Attr{
+		{Name: xml.Name{Local: "fDynamic"}, Value: "1"},
+		{Name: xml.Name{Local: "fCollapsed"}, Value: "0"},
+	}
+	ext := sml.NewCT_Extension()
+	ext.UriAttr = unioffice.String(dynamicArrayURI)
+	ext.Any = props
+	bk := sml.NewCT_FutureMetadataBlock()
+	bk.ExtLst = sml.NewCT_ExtensionList()
+	bk.ExtLst.Ext = []*sml.CT_Extension{ext}
+	return bk
+}
+
+// isDynamicArrayBlock returns true if a future metadata block has the
+// properties of a dynamic array formula that isn't collapsed.
+func isDynamicArrayBlock(bk *sml.CT_FutureMetadataBlock) bool {
+	if bk.ExtLst == nil {
+		return false
+	}
+	for _, ext := range bk.ExtLst.Ext {
+		props, ok := ext.Any.(*unioffice.XSDAny)
+		if ext.UriAttr == nil || *ext.UriAttr != dynamicArrayURI || !ok {
+			continue
+		}
+		attrs := map[string]string{}
+		for _, a := range props.Attrs {
+			attrs[a.Name.Local] = a.Value
+		}
+		return attrs["fDynamic"] == "1" && attrs["fCollapsed"] != "1"
+	}
+	return false
+}
+
+// readMetadataPart reads the cell metadata part of a workbook.
+func (wb *Workbook) readMetadataPart(dm *zippkg.DecodeMap, path, typ string, rel *relationships.Relationship, src zippkg.Target) {
+	wb._dfcga = sml.NewMetadata()
+	dm.AddTarget(path, wb._dfcga, typ, 0)
+	rel.TargetAttr = unioffice.RelativeFilename(unioffice.DocTypeSpreadsheet, src.Typ, typ, 0)
+}
+
+// saveMetadataPart writes the cell metadata part of a workbook, if any.
+func (wb *Workbook) saveMetadataPart(z *zip.Writer) error {
+	if wb._dfcga == nil {
+		return nil
+	}
+	path := unioffice.AbsoluteFilename(unioffice.DocTypeSpreadsheet, unioffice.SheetMetadataType, 0)
+	if err := zippkg.MarshalXML(z, path, wb._dfcga); err != nil {
+		return err
+	}
+	wb.ContentTypes.AddOverride("/"+path, unioffice.SheetMetadataContentType)
+	return nil
+}
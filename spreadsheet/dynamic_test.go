@@ -0,0 +1,110 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/spreadsheet/formula"
+)
+
+// columnValues returns the values of the cells of a column from row 1 to n.
+func columnValues(s Sheet, col string, n int) []string {
+	var values []string
+	for r := 1; r <= n; r++ {
+		values = append(values, s.Cell(col+strconv.Itoa(r)).GetFormattedValue())
+	}
+	return values
+}
+
+func TestDynamicArraySpill(t *testing.T) {
+	wb := New()
+	s := wb.AddSheet()
+	for i, v := range []float64{3, 1, 2} {
+		s.Cell("A" + strconv.Itoa(i+1)).SetNumber(v)
+	}
+	s.Cell("C1").SetFormulaDynamicArray("SORT(A1:A3)")
+	s.Cell("D1").SetFormulaRaw(formula.StoredFormula("SUM(C1#)"))
+	if got := s.Cell("C1").GetFormula(); got != "_xlfn._xlws.SORT(A1:A3)" {
+		t.Errorf("stored formula = %q", got)
+	}
+	if got := s.Cell("D1").GetFormula(); got != "SUM(_xlfn.ANCHORARRAY(C1))" {
+		t.Errorf("stored spill reference = %q", got)
+	}
+	wb.Recalculate()
+	if got := columnValues(s, "C", 4); !reflect.DeepEqual(got, []string{"1", "2", "3", ""}) {
+		t.Errorf("spilled values = %q", got)
+	}
+	if got := s.Cell("C1").SpillRange(); got != "C1:C3" {
+		t.Errorf("spill range = %q, want C1:C3", got)
+	}
+	if got, _ := s.Cell("D1").GetValueAsNumber(); got != 6 {
+		t.Errorf("SUM(C1#) = %v, want 6", got)
+	}
+
+	// the range grows and shrinks with the result
+	s.Cell("A4").SetNumber(0)
+	s.Cell("C1").SetFormulaDynamicArray("SORT(A1:A4)")
+	wb.Recalculate()
+	if got := columnValues(s, "C", 4); !reflect.DeepEqual(got, []string{"0", "1", "2", "3"}) {
+		t.Errorf("grown spilled values = %q", got)
+	}
+	s.Cell("C1").SetFormulaDynamicArray("SORT(A1:A2)")
+	wb.Recalculate()
+	if got := columnValues(s, "C", 4); !reflect.DeepEqual(got, []string{"1", "3", "", ""}) {
+		t.Errorf("shrunk spilled values = %q", got)
+	}
+	if got := s.Cell("C1").SpillRange(); got != "C1:C2" {
+		t.Errorf("spill range = %q, want C1:C2", got)
+	}
+
+	// the formula is read back as a dynamic array
+	read := roundTrip(t, wb)
+	rc := read.Sheets()[0].Cell("C1")
+	if !rc.IsDynamicArray() || rc.SpillRange() != "C1:C2" {
+		t.Errorf("read cell dynamic %v with spill range %q", rc.IsDynamicArray(), rc.SpillRange())
+	}
+}
+
+func TestDynamicArraySpillError(t *testing.T) {
+	wb := New()
+	s := wb.AddSheet()
+	s.Cell("A1").SetNumber(2)
+	s.Cell("A2").SetNumber(1)
+	s.Cell("C2").SetString("in the way")
+	s.Cell("C1").SetFormulaDynamicArray("SORT(A1:A2)")
+	wb.Recalculate()
+	if got := s.Cell("C1").GetFormattedValue(); got != "#SPILL!" {
+		t.Errorf("blocked formula = %q, want #SPILL!", got)
+	}
+	if got := s.Cell("C2").GetString(); got != "in the way" {
+		t.Errorf("blocking cell = %q", got)
+	}
+	if got := s.Cell("C1").SpillRange(); got != "C1" {
+		t.Errorf("blocked spill range = %q, want C1", got)
+	}
+
+	// clearing the blocking cell lets the formula spill
+	s.Cell("C2").Clear()
+	wb.Recalculate()
+	if got := columnValues(s, "C", 2); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("spilled values = %q", got)
+	}
+
+	// merged cells block the spill range as well
+	s.AddMergedCells("D2", "E2")
+	s.Cell("D1").SetFormulaDynamicArray("SORT(A1:A2)")
+	wb.Recalculate()
+	if got := s.Cell("D1").GetFormattedValue(); got != "#SPILL!" {
+		t.Errorf("formula blocked by merged cells = %q, want #SPILL!", got)
+	}
+}
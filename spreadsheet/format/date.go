@@ -0,0 +1,56 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package format
+
+import "strings"
+
+// IsDateFormat returns true if a number format displays dates or times,
+// either being a built-in date format or having date or time placeholders
+// outside of quoted text, escapes and brackets.
+func IsDateFormat(id uint32, code string) bool {
+	switch {
+	case id >= 14 && id <= 22, id >= 27 && id <= 36, id >= 45 && id <= 47, id >= 50 && id <= 58:
+		return true
+	}
+	if code == "" || strings.EqualFold(code, "General") {
+		return false
+	}
+	// only the first section applies to positive numbers
+	inQuote := false
+	for i := 0; i < len(code); i++ {
+		ch := code[i]
+		switch {
+		case inQuote:
+			inQuote = ch != '"'
+		case ch == '"':
+			inQuote = true
+		case ch == '\\', ch == '_', ch == '*':
+			i++
+		case ch == '[':
+			end := strings.IndexByte(code[i:], ']')
+			if end < 0 {
+				return false
+			}
+			// elapsed time, such as [h]:mm
+			if inner := strings.ToLower(code[i+1 : i+end]); inner != "" && strings.Trim(inner, "hms") == "" {
+				return true
+			}
+			i += end
+		case ch == ';':
+			return false
+		default:
+			switch ch | 0x20 {
+			case 'd', 'm', 'y', 'h', 's':
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+)
+
+// Sheet limits used for the references to whole rows and columns.
+const (
+	maxRow    = 1048576
+	maxColumn = 16383
+)
+
+// volatileFunctions are the functions whose result may change each time they
+// are evaluated, even if the cells their formula refers to don't.
+var volatileFunctions = map[string]bool{
+	"NOW":         true,
+	"TODAY":       true,
+	"RAND":        true,
+	"RANDBETWEEN": true,
+	"RANDARRAY":   true,
+	"OFFSET":      true,
+	"INDIRECT":    true,
+	"CELL":        true,
+	"INFO":        true,
+}
+
+// dynamicFunctions are the functions computing the references they return,
+// which can't be known before evaluation.
+var dynamicFunctions = map[string]bool{
+	"OFFSET":   true,
+	"INDIRECT": true,
+}
+
+// Dependency is a rectangle of cells referred to by a formula. References to
+// whole columns and rows span all the rows or columns of the sheet.
+type Dependency struct {
+	// Sheet is the name of the sheet of the cells, empty for the sheet of the
+	// formula.
+	Sheet string
+
+	// From and To are the top left and bottom right cells. Absolute rows and
+	// columns are flagged as written in the formula, whole columns and rows
+	// having absolute row and column bounds.
+	From, To reference.CellReference
+}
+
+// Contains returns true if the dependency covers a cell, given its zero based
+// column index and its row number.
+func (d Dependency) Contains(column, row uint32) bool {
+	return column >= d.From.ColumnIdx && column <= d.To.ColumnIdx && row >= d.From.RowIdx && row <= d.To.RowIdx
+}
+
+// Dependencies are the references of a formula, used to determine which
+// formulas must be recalculated when cells change.
+type Dependencies struct {
+	Cells []Dependency
+
+	// Names are the defined names and table names referred to, including the
+	// names of the lambdas called.
+	Names []string
+
+	// Volatile is true if the formula calls functions whose result changes on
+	// each evaluation, such as NOW, RAND or INDIRECT.
+	Volatile bool
+
+	// Dynamic is true if the formula computes references with INDIRECT or
+	// OFFSET, so that it may refer to cells other than Cells.
+	Dynamic bool
+}
+
+// FormulaDependencies returns the cells, names and volatile functions a
+// formula refers to. Structured references must have been resolved before,
+// see ResolveStructuredReferences. Formulas that can't be parsed have no
+// dependencies.
+func FormulaDependencies(f string) Dependencies {
+	deps := Dependencies{}
+	body := strings.TrimPrefix(f, "=")
+	if strings.TrimSpace(body) == "" {
+		return deps
+	}
+	expr := ParseString(body)
+	if expr == nil {
+		return deps
+	}
+	deps.collect(expr, "")
+	return deps
+}
+
+func (d *Dependencies) collect(expr Expression, sheet string) {
+	switch e := derefExpression(expr).(type) {
+	case CellRef:
+		d.addRange(sheet, e._gge, e._gge)
+	case Range:
+		from, fok := derefExpression(e._agbg).(CellRef)
+		to, tok := derefExpression(e._eaebg).(CellRef)
+		if fok && tok {
+			d.addRange(sheet, from._gge, to._gge)
+			return
+		}
+		d.collect(e._agbg, sheet)
+		d.collect(e._eaebg, sheet)
+	case VerticalRange:
+		d.addColumns(sheet, e._ddaf, e._addad)
+	case HorizontalRange:
+		d.addRows(sheet, e._gcabd, e._gfbga)
+	case PrefixExpr:
+		d.collect(e._fecb, prefixSheet(e._fdfge))
+	case PrefixRangeExpr:
+		d.collect(Range{_agbg: e._dfedb, _eaebg: e._bebbg}, prefixSheet(e._bcdd))
+	case PrefixVerticalRange:
+		d.addColumns(prefixSheet(e._decac), e._bddaf, e._cfbe)
+	case PrefixHorizontalRange:
+		d.addRows(prefixSheet(e._efaa), e._fceca, e._fegg)
+	case NamedRangeRef:
+		if !isLocalName(e._dcdga) {
+			d.Names = append(d.Names, e._dcdga)
+		}
+	case FunctionCall:
+		if isLambdaCall(e._ddea) && !isLocalName(e._ddea) {
+			d.Names = append(d.Names, lambdaName(e._ddea))
+		}
+		name := strings.ToUpper(e._ddea)
+		name = strings.TrimPrefix(name, "_XLFN.")
+		name = strings.TrimPrefix(name, "_XLWS.")
+		if volatileFunctions[name] {
+			d.Volatile = true
+		}
+		if dynamicFunctions[name] {
+			d.Dynamic = true
+		}
+		for _, arg := range e._ddaa {
+			d.collect(arg, sheet)
+		}
+	case BinaryExpr:
+		d.collect(e._da, sheet)
+		d.collect(e._db, sheet)
+	case Negate:
+		d.collect(e._ebadf, sheet)
+	}
+}
+
+func (d *Dependencies) addRange(sheet, from, to string) {
+	f, err := reference.ParseCellReference(from)
+	if err != nil {
+		return
+	}
+	t, err := reference.ParseCellReference(to)
+	if err != nil {
+		return
+	}
+	if f.ColumnIdx > t.ColumnIdx {
+		f.ColumnIdx, t.ColumnIdx = t.ColumnIdx, f.ColumnIdx
+		f.Column, t.Column = t.Column, f.Column
+		f.AbsoluteColumn, t.AbsoluteColumn = t.AbsoluteColumn, f.AbsoluteColumn
+	}
+	if f.RowIdx > t.RowIdx {
+		f.RowIdx, t.RowIdx = t.RowIdx, f.RowIdx
+		f.AbsoluteRow, t.AbsoluteRow = t.AbsoluteRow, f.AbsoluteRow
+	}
+	d.Cells = append(d.Cells, Dependency{Sheet: sheet, From: f, To: t})
+}
+
+func (d *Dependencies) addColumns(sheet, from, to string) {
+	f := columnBound(from)
+	t := columnBound(to)
+	if f.ColumnIdx > t.ColumnIdx {
+		f, t = t, f
+	}
+	f.RowIdx, f.AbsoluteRow = 1, true
+	t.RowIdx, t.AbsoluteRow = maxRow, true
+	d.Cells = append(d.Cells, Dependency{Sheet: sheet, From: f, To: t})
+}
+
+func (d *Dependencies) addRows(sheet string, from, to int) {
+	if from > to {
+		from, to = to, from
+	}
+	f := reference.CellReference{RowIdx: uint32(from), Column: "A", AbsoluteColumn: true}
+	t := reference.CellReference{RowIdx: uint32(to), ColumnIdx: maxColumn, Column: reference.IndexToColumn(maxColumn), AbsoluteColumn: true}
+	d.Cells = append(d.Cells, Dependency{Sheet: sheet, From: f, To: t})
+}
+
+// derefExpression returns the value of the expressions the parser returns as
+// pointers.
+func derefExpression(expr Expression) Expression {
+	switch e := expr.(type) {
+	case *CellRef:
+		return *e
+	case *Range:
+		return *e
+	case *VerticalRange:
+		return *e
+	case *HorizontalRange:
+		return *e
+	case *PrefixExpr:
+		return *e
+	case *PrefixRangeExpr:
+		return *e
+	case *PrefixVerticalRange:
+		return *e
+	case *PrefixHorizontalRange:
+		return *e
+	case *NamedRangeRef:
+		return *e
+	case *FunctionCall:
+		return *e
+	case *BinaryExpr:
+		return *e
+	case *Negate:
+		return *e
+	}
+	return expr
+}
+
+// columnBound parses one end of a column range such as "$B".
+func columnBound(s string) reference.CellReference {
+	abs := strings.HasPrefix(s, "$")
+	col := strings.ToUpper(strings.TrimPrefix(s, "$"))
+	return reference.CellReference{ColumnIdx: reference.ColumnToIndex(col), Column: col, AbsoluteColumn: abs}
+}
+
+// prefixSheet returns the unquoted sheet name of a sheet prefix.
+func prefixSheet(pfx Expression) string {
+	return unquoteSheetName(pfx.String())
+}
+
+// unquoteSheetName returns a sheet name as written in formulas without the
+// quotes of names with spaces or punctuation.
+func unquoteSheetName(name string) string {
+	if strings.HasPrefix(name, "'") && strings.HasSuffix(name, "'") && len(name) > 1 {
+		name = strings.ReplaceAll(name[1:len(name)-1], "''", "'")
+	}
+	return name
+}
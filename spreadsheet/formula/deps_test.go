@@ -0,0 +1,92 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// dependencyStrings formats the cells of dependencies as sheet!from:to with
+// the zero based column indexes and row numbers of their bounds.
+func dependencyStrings(deps Dependencies) []string {
+	ret := []string{}
+	for _, d := range deps.Cells {
+		ret = append(ret, fmt.Sprintf("%s!%d.%d:%d.%d", d.Sheet, d.From.ColumnIdx, d.From.RowIdx, d.To.ColumnIdx, d.To.RowIdx))
+	}
+	return ret
+}
+
+func TestFormulaDependencies(t *testing.T) {
+	tests := []struct {
+		formula           string
+		cells, names      []string
+		volatile, dynamic bool
+	}{
+		{"=A1+$B$2", []string{"!0.1:0.1", "!1.2:1.2"}, nil, false, false},
+		{"SUM(B3:A1)", []string{"!0.1:1.3"}, nil, false, false},
+		{"SUM(Sheet2!A1:B2,'My sheet'!C3)", []string{"Sheet2!0.1:1.2", "My sheet!2.3:2.3"}, nil, false, false},
+		{"'Q1 data'!A1", []string{"Q1 data!0.1:0.1"}, nil, false, false},
+		{"SUM(C:B)", []string{"!1.1:2.1048576"}, nil, false, false},
+		{"SUM(3:2)", []string{"!0.2:16383.3"}, nil, false, false},
+		{"SUM(Sheet2!A:A)", []string{"Sheet2!0.1:0.1048576"}, nil, false, false},
+		{"Rate*-Amount", []string{}, []string{"Rate", "Amount"}, false, false},
+		{"RAND()*A1", []string{"!0.1:0.1"}, nil, true, false},
+		{"_xlfn.RANDARRAY(2)", []string{}, nil, true, false},
+		{"SUM(OFFSET(A1,1,1))", []string{"!0.1:0.1"}, nil, true, true},
+		{"INDIRECT(\"B\"&A2)", []string{"!0.2:0.2"}, nil, true, true},
+		{"ABS(-A1)>=1", []string{"!0.1:0.1"}, nil, false, false},
+		{"", []string{}, nil, false, false},
+		{"=", []string{}, nil, false, false},
+	}
+	for _, tc := range tests {
+		deps := FormulaDependencies(tc.formula)
+		if got := dependencyStrings(deps); !reflect.DeepEqual(got, tc.cells) {
+			t.Errorf("FormulaDependencies(%q) cells = %q, want %q", tc.formula, got, tc.cells)
+		}
+		if !reflect.DeepEqual(deps.Names, tc.names) {
+			t.Errorf("FormulaDependencies(%q) names = %q, want %q", tc.formula, deps.Names, tc.names)
+		}
+		if deps.Volatile != tc.volatile || deps.Dynamic != tc.dynamic {
+			t.Errorf("FormulaDependencies(%q) volatile %v dynamic %v, want %v %v", tc.formula, deps.Volatile, deps.Dynamic, tc.volatile, tc.dynamic)
+		}
+	}
+}
+
+func TestDependencyContains(t *testing.T) {
+	d := FormulaDependencies("SUM(B2:C4)").Cells[0]
+	if d.From.Column != "B" || d.To.Column != "C" || d.From.AbsoluteColumn || d.From.AbsoluteRow {
+		t.Errorf("bounds = %+v %+v", d.From, d.To)
+	}
+	tests := []struct {
+		column, row uint32
+		want        bool
+	}{
+		{1, 2, true},
+		{2, 4, true},
+		{0, 2, false},
+		{3, 3, false},
+		{1, 1, false},
+		{1, 5, false},
+	}
+	for _, tc := range tests {
+		if got := d.Contains(tc.column, tc.row); got != tc.want {
+			t.Errorf("Contains(%d, %d) = %v", tc.column, tc.row, got)
+		}
+	}
+	col := FormulaDependencies("$B:B").Cells[0]
+	if !col.From.AbsoluteColumn || col.To.AbsoluteColumn || !col.From.AbsoluteRow || !col.To.AbsoluteRow {
+		t.Errorf("whole column bounds = %+v %+v", col.From, col.To)
+	}
+	if !col.Contains(1, maxRow) || col.Contains(2, 1) {
+		t.Error("whole column doesn't span the rows of the sheet")
+	}
+}
@@ -0,0 +1,883 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import "math"
+
+func init() {
+	RegisterFunction("NORM.DIST", NormDist)
+	RegisterFunction("_xlfn.NORM.DIST", NormDist)
+	RegisterFunction("NORMDIST", NormDist)
+	RegisterFunction("NORM.INV", NormInv)
+	RegisterFunction("_xlfn.NORM.INV", NormInv)
+	RegisterFunction("NORMINV", NormInv)
+	RegisterFunction("NORM.S.DIST", NormSDist)
+	RegisterFunction("_xlfn.NORM.S.DIST", NormSDist)
+	RegisterFunction("NORMSDIST", Normsdist)
+	RegisterFunction("NORM.S.INV", NormSInv)
+	RegisterFunction("_xlfn.NORM.S.INV", NormSInv)
+	RegisterFunction("NORMSINV", NormSInv)
+	RegisterFunction("T.DIST", TDist)
+	RegisterFunction("_xlfn.T.DIST", TDist)
+	RegisterFunction("T.DIST.2T", TDist2T)
+	RegisterFunction("_xlfn.T.DIST.2T", TDist2T)
+	RegisterFunction("T.DIST.RT", TDistRT)
+	RegisterFunction("_xlfn.T.DIST.RT", TDistRT)
+	RegisterFunction("TDIST", Tdist)
+	RegisterFunction("T.INV", TInv)
+	RegisterFunction("_xlfn.T.INV", TInv)
+	RegisterFunction("T.INV.2T", TInv2T)
+	RegisterFunction("_xlfn.T.INV.2T", TInv2T)
+	RegisterFunction("TINV", TInv2T)
+	RegisterFunction("CHISQ.DIST", ChisqDist)
+	RegisterFunction("_xlfn.CHISQ.DIST", ChisqDist)
+	RegisterFunction("CHISQ.DIST.RT", ChisqDistRT)
+	RegisterFunction("_xlfn.CHISQ.DIST.RT", ChisqDistRT)
+	RegisterFunction("CHIDIST", ChisqDistRT)
+	RegisterFunction("CHISQ.INV", ChisqInv)
+	RegisterFunction("_xlfn.CHISQ.INV", ChisqInv)
+	RegisterFunction("CHISQ.INV.RT", ChisqInvRT)
+	RegisterFunction("_xlfn.CHISQ.INV.RT", ChisqInvRT)
+	RegisterFunction("CHIINV", ChisqInvRT)
+	RegisterFunction("BINOM.DIST", BinomDist)
+	RegisterFunction("_xlfn.BINOM.DIST", BinomDist)
+	RegisterFunction("BINOMDIST", BinomDist)
+	RegisterFunction("BINOM.INV", BinomInv)
+	RegisterFunction("_xlfn.BINOM.INV", BinomInv)
+	RegisterFunction("CRITBINOM", BinomInv)
+	RegisterFunction("POISSON.DIST", PoissonDist)
+	RegisterFunction("_xlfn.POISSON.DIST", PoissonDist)
+	RegisterFunction("POISSON", PoissonDist)
+	RegisterFunction("GAMMA", Gamma)
+	RegisterFunction("_xlfn.GAMMA", Gamma)
+	RegisterFunction("GAMMA.DIST", GammaDist)
+	RegisterFunction("_xlfn.GAMMA.DIST", GammaDist)
+	RegisterFunction("GAMMADIST", GammaDist)
+	RegisterFunction("GAMMA.INV", GammaInv)
+	RegisterFunction("_xlfn.GAMMA.INV", GammaInv)
+	RegisterFunction("GAMMAINV", GammaInv)
+	RegisterFunction("GAMMALN", GammaLn)
+	RegisterFunction("GAMMALN.PRECISE", GammaLn)
+	RegisterFunction("_xlfn.GAMMALN.PRECISE", GammaLn)
+	RegisterFunction("BETA.DIST", BetaDist)
+	RegisterFunction("_xlfn.BETA.DIST", BetaDist)
+	RegisterFunction("BETADIST", Betadist)
+	RegisterFunction("BETA.INV", BetaInv)
+	RegisterFunction("_xlfn.BETA.INV", BetaInv)
+	RegisterFunction("BETAINV", BetaInv)
+	RegisterFunction("F.DIST", FDist)
+	RegisterFunction("_xlfn.F.DIST", FDist)
+	RegisterFunction("F.DIST.RT", FDistRT)
+	RegisterFunction("_xlfn.F.DIST.RT", FDistRT)
+	RegisterFunction("FDIST", FDistRT)
+	RegisterFunction("F.INV", FInv)
+	RegisterFunction("_xlfn.F.INV", FInv)
+	RegisterFunction("F.INV.RT", FInvRT)
+	RegisterFunction("_xlfn.F.INV.RT", FInvRT)
+	RegisterFunction("FINV", FInvRT)
+}
+
+const (
+	// distributionEpsilon is the relative precision of the series and
+	// continued fractions of the incomplete gamma and beta functions.
+	distributionEpsilon = 1e-16
+	// distributionIterations bounds the terms of the series and continued
+	// fractions, the number needed growing with the square root of the
+	// parameters.
+	distributionIterations = 100000
+	// inverseIterations bounds the bisection steps of the inverse functions,
+	// enough to reach the precision of float64 for values above 2^-200.
+	inverseIterations = 300
+	// maxDegreesOfFreedom is the largest number of degrees of freedom
+	// accepted, as in Excel.
+	maxDegreesOfFreedom = 1e10
+	lentzFloor          = 1e-300
+)
+
+// distributionArguments returns the numbers of the arguments of a distribution
+// function with n numbers followed by the cumulative flag and max arguments at
+// most, the flag being excluded from the numbers.
+func distributionArguments(args []Result, n, max int, name string) ([]float64, bool, Result) {
+	if len(args) < n+1 || len(args) > max {
+		_, err := numberArguments(args, n+1, max, name)
+		return nil, false, err
+	}
+	cumulative, err := truthValue(args[n])
+	if err.Type == ResultTypeError {
+		return nil, false, err
+	}
+	rest := append(append([]Result{}, args[:n]...), args[n+1:]...)
+	values, err := numberArguments(rest, len(rest), len(rest), name)
+	return values, cumulative, err
+}
+
+func outOfRange(name string) Result {
+	return MakeErrorResultType(ErrorTypeNum, name+" argument out of range")
+}
+
+// distributionResult returns a number, or #NUM! if it isn't finite.
+func distributionResult(v float64, name string) Result {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return MakeErrorResultType(ErrorTypeNum, name+" result out of range")
+	}
+	return MakeNumberResult(v)
+}
+
+func logBeta(a, b float64) float64 {
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	return la + lb - lab
+}
+
+// xLogY returns x*log(y), 0 if x is 0.
+func xLogY(x, y float64) float64 {
+	if x == 0 {
+		return 0
+	}
+	return x * math.Log(y)
+}
+
+// regularizedGamma returns the regularized lower and upper incomplete gamma
+// functions P(a, x) and Q(a, x), computed by series for x < a+1 and by
+// continued fraction otherwise.
+func regularizedGamma(a, x float64) (p, q float64) {
+	if x <= 0 {
+		return 0, 1
+	}
+	if math.IsInf(x, 1) {
+		return 1, 0
+	}
+	lg, _ := math.Lgamma(a)
+	front := a*math.Log(x) - x - lg
+	if x < a+1 {
+		term := 1 / a
+		sum := term
+		for n := 1.0; n < distributionIterations; n++ {
+			term *= x / (a + n)
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*distributionEpsilon {
+				break
+			}
+		}
+		p = math.Min(sum*math.Exp(front), 1)
+		return p, 1 - p
+	}
+	// modified Lentz's method
+	b := x + 1 - a
+	c := 1 / lentzFloor
+	d := 1 / b
+	h := d
+	for i := 1.0; i < distributionIterations; i++ {
+		an := -i * (i - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < lentzFloor {
+			d = lentzFloor
+		}
+		c = b + an/c
+		if math.Abs(c) < lentzFloor {
+			c = lentzFloor
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < distributionEpsilon {
+			break
+		}
+	}
+	q = math.Min(math.Exp(front)*h, 1)
+	return 1 - q, q
+}
+
+// regularizedBeta returns the regularized incomplete beta function I_x(a, b),
+// y being 1-x given separately for precision, computed by continued fraction.
+func regularizedBeta(x, y, a, b float64) float64 {
+	switch {
+	case x <= 0:
+		return 0
+	case y <= 0:
+		return 1
+	case x > (a+1)/(a+b+2):
+		return 1 - regularizedBeta(y, x, b, a)
+	}
+	front := math.Exp(a*math.Log(x)+b*math.Log(y)-logBeta(a, b)) / a
+	// modified Lentz's method
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < lentzFloor {
+		d = lentzFloor
+	}
+	d = 1 / d
+	h := d
+	for m := 1.0; m < distributionIterations; m++ {
+		num := m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		d = 1 + num*d
+		if math.Abs(d) < lentzFloor {
+			d = lentzFloor
+		}
+		c = 1 + num/c
+		if math.Abs(c) < lentzFloor {
+			c = lentzFloor
+		}
+		d = 1 / d
+		h *= d * c
+		num = -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		d = 1 + num*d
+		if math.Abs(d) < lentzFloor {
+			d = lentzFloor
+		}
+		c = 1 + num/c
+		if math.Abs(c) < lentzFloor {
+			c = lentzFloor
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < distributionEpsilon {
+			break
+		}
+	}
+	return math.Min(front*h, 1)
+}
+
+// inverse returns the x in [lo, hi] where the increasing function f reaches
+// p, found by bisection, hi being doubled while f(hi) is below p.
+func inverse(f func(float64) float64, p, lo, hi float64) float64 {
+	if p <= f(lo) {
+		return lo
+	}
+	for f(hi) < p && hi < math.MaxFloat64/2 {
+		lo, hi = hi, 2*hi
+	}
+	for i := 0; i < inverseIterations; i++ {
+		mid := lo + (hi-lo)/2
+		if mid == lo || mid == hi {
+			break
+		}
+		switch v := f(mid); {
+		case v == p:
+			return mid
+		case v < p:
+			lo = mid
+		default:
+			hi = mid
+		}
+	}
+	return lo + (hi-lo)/2
+}
+
+func normalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+func normalPDF(z float64) float64 {
+	return math.Exp(-z*z/2) / math.Sqrt(2*math.Pi)
+}
+
+func normalInverse(p float64) float64 {
+	switch {
+	case p == 0.5:
+		return 0
+	case p > 0.5:
+		return -normalInverse(1 - p)
+	}
+	return inverse(normalCDF, p, -40, 0)
+}
+
+// NormDist implements the Excel NORM.DIST and NORMDIST functions, the normal
+// distribution of a mean and a standard deviation.
+func NormDist(args []Result) Result {
+	v, cumulative, err := distributionArguments(args, 3, 4, "NORM.DIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if v[2] <= 0 {
+		return outOfRange("NORM.DIST")
+	}
+	z := (v[0] - v[1]) / v[2]
+	if cumulative {
+		return MakeNumberResult(normalCDF(z))
+	}
+	return MakeNumberResult(normalPDF(z) / v[2])
+}
+
+// NormSDist implements the Excel NORM.S.DIST function, the standard normal
+// distribution.
+func NormSDist(args []Result) Result {
+	v, cumulative, err := distributionArguments(args, 1, 2, "NORM.S.DIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if cumulative {
+		return MakeNumberResult(normalCDF(v[0]))
+	}
+	return MakeNumberResult(normalPDF(v[0]))
+}
+
+// Normsdist implements the Excel NORMSDIST function, the cumulative standard
+// normal distribution.
+func Normsdist(args []Result) Result {
+	v, err := numberArguments(args, 1, 1, "NORMSDIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	return MakeNumberResult(normalCDF(v[0]))
+}
+
+// NormInv implements the Excel NORM.INV and NORMINV functions, the inverse of
+// the cumulative normal distribution.
+func NormInv(args []Result) Result {
+	v, err := numberArguments(args, 3, 3, "NORM.INV")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if v[0] <= 0 || v[0] >= 1 || v[2] <= 0 {
+		return outOfRange("NORM.INV")
+	}
+	return MakeNumberResult(v[1] + v[2]*normalInverse(v[0]))
+}
+
+// NormSInv implements the Excel NORM.S.INV and NORMSINV functions, the inverse
+// of the cumulative standard normal distribution.
+func NormSInv(args []Result) Result {
+	v, err := numberArguments(args, 1, 1, "NORM.S.INV")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if v[0] <= 0 || v[0] >= 1 {
+		return outOfRange("NORM.S.INV")
+	}
+	return MakeNumberResult(normalInverse(v[0]))
+}
+
+// tTail returns the probability that a variable of Student's t distribution
+// exceeds |t|.
+func tTail(t, df float64) float64 {
+	t2 := t * t
+	return 0.5 * regularizedBeta(df/(df+t2), 1/(1+df/t2), df/2, 0.5)
+}
+
+func tPDF(t, df float64) float64 {
+	a, _ := math.Lgamma((df + 1) / 2)
+	b, _ := math.Lgamma(df / 2)
+	return math.Exp(a - b - 0.5*math.Log(df*math.Pi) - (df+1)/2*math.Log1p(t*t/df))
+}
+
+// tInverseTail returns the t above 0 that a variable of Student's t
+// distribution exceeds with probability q, at most 0.5.
+func tInverseTail(q, df float64) float64 {
+	return inverse(func(t float64) float64 { return -tTail(t, df) }, -q, 0, 1)
+}
+
+// degreesOfFreedom truncates degrees of freedom and validates them.
+func degreesOfFreedom(df float64) (float64, bool) {
+	df = math.Trunc(df)
+	return df, df >= 1 && df <= maxDegreesOfFreedom
+}
+
+// TDist implements the Excel T.DIST function, the left-tailed Student's t
+// distribution.
+func TDist(args []Result) Result {
+	v, cumulative, err := distributionArguments(args, 2, 3, "T.DIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	df, ok := degreesOfFreedom(v[1])
+	if !ok {
+		return outOfRange("T.DIST")
+	}
+	if !cumulative {
+		return MakeNumberResult(tPDF(v[0], df))
+	}
+	if v[0] > 0 {
+		return MakeNumberResult(1 - tTail(v[0], df))
+	}
+	return MakeNumberResult(tTail(v[0], df))
+}
+
+// TDist2T implements the Excel T.DIST.2T function, the two-tailed Student's t
+// distribution.
+func TDist2T(args []Result) Result {
+	v, err := numberArguments(args, 2, 2, "T.DIST.2T")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	df, ok := degreesOfFreedom(v[1])
+	if !ok || v[0] < 0 {
+		return outOfRange("T.DIST.2T")
+	}
+	return MakeNumberResult(2 * tTail(v[0], df))
+}
+
+// TDistRT implements the Excel T.DIST.RT function, the right-tailed Student's t
+// distribution.
+func TDistRT(args []Result) Result {
+	v, err := numberArguments(args, 2, 2, "T.DIST.RT")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	df, ok := degreesOfFreedom(v[1])
+	if !ok {
+		return outOfRange("T.DIST.RT")
+	}
+	if v[0] < 0 {
+		return MakeNumberResult(1 - tTail(v[0], df))
+	}
+	return MakeNumberResult(tTail(v[0], df))
+}
+
+// Tdist implements the Excel TDIST function, the one or two-tailed Student's t
+// distribution of a positive value.
+func Tdist(args []Result) Result {
+	v, err := numberArguments(args, 3, 3, "TDIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	df, ok := degreesOfFreedom(v[1])
+	tails := math.Trunc(v[2])
+	if !ok || v[0] < 0 || tails != 1 && tails != 2 {
+		return outOfRange("TDIST")
+	}
+	return MakeNumberResult(tails * tTail(v[0], df))
+}
+
+// TInv implements the Excel T.INV function, the inverse of the left-tailed
+// Student's t distribution.
+func TInv(args []Result) Result {
+	v, err := numberArguments(args, 2, 2, "T.INV")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	df, ok := degreesOfFreedom(v[1])
+	if !ok || v[0] <= 0 || v[0] >= 1 {
+		return outOfRange("T.INV")
+	}
+	switch {
+	case v[0] < 0.5:
+		return MakeNumberResult(-tInverseTail(v[0], df))
+	case v[0] > 0.5:
+		return MakeNumberResult(tInverseTail(1-v[0], df))
+	}
+	return MakeNumberResult(0)
+}
+
+// TInv2T implements the Excel T.INV.2T and TINV functions, the inverse of the
+// two-tailed Student's t distribution.
+func TInv2T(args []Result) Result {
+	v, err := numberArguments(args, 2, 2, "T.INV.2T")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	df, ok := degreesOfFreedom(v[1])
+	if !ok || v[0] <= 0 || v[0] > 1 {
+		return outOfRange("T.INV.2T")
+	}
+	return MakeNumberResult(tInverseTail(v[0]/2, df))
+}
+
+// gammaPDF returns the density of the gamma distribution of shape a and scale
+// b.
+func gammaPDF(x, a, b float64) float64 {
+	if x == 0 {
+		switch {
+		case a < 1:
+			return math.Inf(1)
+		case a == 1:
+			return 1 / b
+		}
+		return 0
+	}
+	lg, _ := math.Lgamma(a)
+	return math.Exp((a-1)*math.Log(x) - x/b - lg - a*math.Log(b))
+}
+
+// gammaInverse returns the x where the cumulative gamma distribution of shape
+// a and scale 1 reaches p or, if upper is set, where its complement reaches p.
+func gammaInverse(p, a float64, upper bool) float64 {
+	if upper {
+		return inverse(func(x float64) float64 {
+			_, q := regularizedGamma(a, x)
+			return -q
+		}, -p, 0, 1)
+	}
+	return inverse(func(x float64) float64 {
+		p, _ := regularizedGamma(a, x)
+		return p
+	}, p, 0, 1)
+}
+
+// ChisqDist implements the Excel CHISQ.DIST function, the left-tailed
+// chi-squared distribution.
+func ChisqDist(args []Result) Result {
+	v, cumulative, err := distributionArguments(args, 2, 3, "CHISQ.DIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	df, ok := degreesOfFreedom(v[1])
+	if !ok || v[0] < 0 {
+		return outOfRange("CHISQ.DIST")
+	}
+	if cumulative {
+		p, _ := regularizedGamma(df/2, v[0]/2)
+		return MakeNumberResult(p)
+	}
+	return distributionResult(gammaPDF(v[0], df/2, 2), "CHISQ.DIST")
+}
+
+// ChisqDistRT implements the Excel CHISQ.DIST.RT and CHIDIST functions, the
+// right-tailed chi-squared distribution.
+func ChisqDistRT(args []Result) Result {
+	v, err := numberArguments(args, 2, 2, "CHISQ.DIST.RT")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	df, ok := degreesOfFreedom(v[1])
+	if !ok || v[0] < 0 {
+		return outOfRange("CHISQ.DIST.RT")
+	}
+	_, q := regularizedGamma(df/2, v[0]/2)
+	return MakeNumberResult(q)
+}
+
+// ChisqInv implements the Excel CHISQ.INV function, the inverse of the
+// left-tailed chi-squared distribution.
+func ChisqInv(args []Result) Result {
+	v, err := numberArguments(args, 2, 2, "CHISQ.INV")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	df, ok := degreesOfFreedom(v[1])
+	if !ok || v[0] < 0 || v[0] >= 1 {
+		return outOfRange("CHISQ.INV")
+	}
+	return MakeNumberResult(2 * gammaInverse(v[0], df/2, false))
+}
+
+// ChisqInvRT implements the Excel CHISQ.INV.RT and CHIINV functions, the
+// inverse of the right-tailed chi-squared distribution.
+func ChisqInvRT(args []Result) Result {
+	v, err := numberArguments(args, 2, 2, "CHISQ.INV.RT")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	df, ok := degreesOfFreedom(v[1])
+	if !ok || v[0] <= 0 || v[0] > 1 {
+		return outOfRange("CHISQ.INV.RT")
+	}
+	return MakeNumberResult(2 * gammaInverse(v[0], df/2, true))
+}
+
+// GammaDist implements the Excel GAMMA.DIST and GAMMADIST functions, the gamma
+// distribution of shape alpha and scale beta.
+func GammaDist(args []Result) Result {
+	v, cumulative, err := distributionArguments(args, 3, 4, "GAMMA.DIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if v[0] < 0 || v[1] <= 0 || v[2] <= 0 {
+		return outOfRange("GAMMA.DIST")
+	}
+	if cumulative {
+		p, _ := regularizedGamma(v[1], v[0]/v[2])
+		return MakeNumberResult(p)
+	}
+	return distributionResult(gammaPDF(v[0], v[1], v[2]), "GAMMA.DIST")
+}
+
+// GammaInv implements the Excel GAMMA.INV and GAMMAINV functions, the inverse
+// of the cumulative gamma distribution.
+func GammaInv(args []Result) Result {
+	v, err := numberArguments(args, 3, 3, "GAMMA.INV")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if v[0] < 0 || v[0] >= 1 || v[1] <= 0 || v[2] <= 0 {
+		return outOfRange("GAMMA.INV")
+	}
+	return MakeNumberResult(v[2] * gammaInverse(v[0], v[1], false))
+}
+
+// Gamma implements the Excel GAMMA function.
+func Gamma(args []Result) Result {
+	v, err := numberArguments(args, 1, 1, "GAMMA")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if v[0] <= 0 && v[0] == math.Trunc(v[0]) {
+		return outOfRange("GAMMA")
+	}
+	return distributionResult(math.Gamma(v[0]), "GAMMA")
+}
+
+// GammaLn implements the Excel GAMMALN and GAMMALN.PRECISE functions, the
+// natural logarithm of the gamma function.
+func GammaLn(args []Result) Result {
+	v, err := numberArguments(args, 1, 1, "GAMMALN")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if v[0] <= 0 {
+		return outOfRange("GAMMALN")
+	}
+	lg, _ := math.Lgamma(v[0])
+	return MakeNumberResult(lg)
+}
+
+// betaArguments returns the value scaled to [0, 1] of a beta distribution
+// function of x, alpha, beta and the optional bounds A and B, at position i
+// of args and of the values, and the length of the interval.
+func betaArguments(args []Result, v []float64, i int, name string) (z, y, width float64, err Result) {
+	lower, upper := 0.0, 1.0
+	if len(args) > i && args[i].Type != ResultTypeEmpty {
+		lower = v[3]
+	}
+	if len(args) > i+1 && args[i+1].Type != ResultTypeEmpty {
+		upper = v[4]
+	}
+	if v[1] <= 0 || v[2] <= 0 || lower >= upper || v[0] < lower || v[0] > upper {
+		return 0, 0, 0, outOfRange(name)
+	}
+	width = upper - lower
+	return (v[0] - lower) / width, (upper - v[0]) / width, width, MakeEmptyResult()
+}
+
+// BetaDist implements the Excel BETA.DIST function, the beta distribution
+// over [0, 1] or over [A, B].
+func BetaDist(args []Result) Result {
+	v, cumulative, err := distributionArguments(args, 3, 6, "BETA.DIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	z, y, width, err := betaArguments(args, v, 4, "BETA.DIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if cumulative {
+		return MakeNumberResult(regularizedBeta(z, y, v[1], v[2]))
+	}
+	d := math.Exp(xLogY(v[1]-1, z)+xLogY(v[2]-1, y)-logBeta(v[1], v[2])) / width
+	return distributionResult(d, "BETA.DIST")
+}
+
+// Betadist implements the Excel BETADIST function, the cumulative beta
+// distribution over [0, 1] or over [A, B].
+func Betadist(args []Result) Result {
+	v, err := numberArguments(args, 3, 5, "BETADIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	z, y, _, err := betaArguments(args, v, 3, "BETADIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	return MakeNumberResult(regularizedBeta(z, y, v[1], v[2]))
+}
+
+// BetaInv implements the Excel BETA.INV and BETAINV functions, the inverse of
+// the cumulative beta distribution.
+func BetaInv(args []Result) Result {
+	v, err := numberArguments(args, 3, 5, "BETA.INV")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	p := v[0]
+	lower, upper := 0.0, 1.0
+	if len(args) > 3 && args[3].Type != ResultTypeEmpty {
+		lower = v[3]
+	}
+	if len(args) > 4 && args[4].Type != ResultTypeEmpty {
+		upper = v[4]
+	}
+	if p <= 0 || p > 1 || v[1] <= 0 || v[2] <= 0 || lower >= upper {
+		return outOfRange("BETA.INV")
+	}
+	z := inverse(func(z float64) float64 { return regularizedBeta(z, 1-z, v[1], v[2]) }, p, 0, 1)
+	return MakeNumberResult(lower + z*(upper-lower))
+}
+
+// binomialPMF returns the probability of k successes in n trials of
+// probability p.
+func binomialPMF(k, n, p float64) float64 {
+	switch {
+	case p == 0 && k == 0, p == 1 && k == n:
+		return 1
+	case p == 0 || p == 1:
+		return 0
+	}
+	a, _ := math.Lgamma(n + 1)
+	b, _ := math.Lgamma(k + 1)
+	c, _ := math.Lgamma(n - k + 1)
+	return math.Exp(a - b - c + k*math.Log(p) + (n-k)*math.Log1p(-p))
+}
+
+// BinomDist implements the Excel BINOM.DIST and BINOMDIST functions, the
+// binomial distribution of the number of successes in a number of trials.
+func BinomDist(args []Result) Result {
+	v, cumulative, err := distributionArguments(args, 3, 4, "BINOM.DIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	k, n, p := math.Trunc(v[0]), math.Trunc(v[1]), v[2]
+	if k < 0 || k > n || p < 0 || p > 1 {
+		return outOfRange("BINOM.DIST")
+	}
+	if !cumulative {
+		return MakeNumberResult(binomialPMF(k, n, p))
+	}
+	if k == n {
+		return MakeNumberResult(1)
+	}
+	return MakeNumberResult(regularizedBeta(1-p, p, n-k, k+1))
+}
+
+// BinomInv implements the Excel BINOM.INV and CRITBINOM functions, the
+// smallest number of successes whose cumulative binomial distribution reaches
+// a criterion.
+func BinomInv(args []Result) Result {
+	v, err := numberArguments(args, 3, 3, "BINOM.INV")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	n, p, alpha := math.Trunc(v[0]), v[1], v[2]
+	if n < 0 || p < 0 || p > 1 || alpha < 0 || alpha > 1 {
+		return outOfRange("BINOM.INV")
+	}
+	sum := 0.0
+	for k := 0.0; k < n; k++ {
+		sum += binomialPMF(k, n, p)
+		if sum >= alpha {
+			return MakeNumberResult(k)
+		}
+	}
+	return MakeNumberResult(n)
+}
+
+// PoissonDist implements the Excel POISSON.DIST and POISSON functions, the
+// Poisson distribution of a number of events.
+func PoissonDist(args []Result) Result {
+	v, cumulative, err := distributionArguments(args, 2, 3, "POISSON.DIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	x, m := math.Trunc(v[0]), v[1]
+	if x < 0 || m < 0 {
+		return outOfRange("POISSON.DIST")
+	}
+	if cumulative {
+		if m == 0 {
+			return MakeNumberResult(1)
+		}
+		_, q := regularizedGamma(x+1, m)
+		return MakeNumberResult(q)
+	}
+	if m == 0 {
+		if x == 0 {
+			return MakeNumberResult(1)
+		}
+		return MakeNumberResult(0)
+	}
+	lg, _ := math.Lgamma(x + 1)
+	return MakeNumberResult(math.Exp(x*math.Log(m) - m - lg))
+}
+
+// fTail returns the probability that a variable of the F distribution exceeds
+// x.
+func fTail(x, d1, d2 float64) float64 {
+	return regularizedBeta(d2/(d2+d1*x), d1*x/(d2+d1*x), d2/2, d1/2)
+}
+
+func fCDF(x, d1, d2 float64) float64 {
+	return regularizedBeta(d1*x/(d1*x+d2), d2/(d1*x+d2), d1/2, d2/2)
+}
+
+// fArguments returns the value and the degrees of freedom of an F
+// distribution function.
+func fArguments(v []float64, name string) (x, d1, d2 float64, err Result) {
+	d1, ok1 := degreesOfFreedom(v[1])
+	d2, ok2 := degreesOfFreedom(v[2])
+	if !ok1 || !ok2 || v[0] < 0 {
+		return 0, 0, 0, outOfRange(name)
+	}
+	return v[0], d1, d2, MakeEmptyResult()
+}
+
+// FDist implements the Excel F.DIST function, the left-tailed F distribution.
+func FDist(args []Result) Result {
+	v, cumulative, err := distributionArguments(args, 3, 4, "F.DIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	x, d1, d2, err := fArguments(v, "F.DIST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if cumulative {
+		return MakeNumberResult(fCDF(x, d1, d2))
+	}
+	if x == 0 {
+		return distributionResult(gammaPDF(0, d1/2, 1), "F.DIST")
+	}
+	d := math.Exp(0.5*(d1*math.Log(d1)+d2*math.Log(d2)) + (d1/2-1)*math.Log(x) -
+		(d1+d2)/2*math.Log(d2+d1*x) - logBeta(d1/2, d2/2))
+	return distributionResult(d, "F.DIST")
+}
+
+// FDistRT implements the Excel F.DIST.RT and FDIST functions, the
+// right-tailed F distribution.
+func FDistRT(args []Result) Result {
+	v, err := numberArguments(args, 3, 3, "F.DIST.RT")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	x, d1, d2, err := fArguments(v, "F.DIST.RT")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	return MakeNumberResult(fTail(x, d1, d2))
+}
+
+// FInv implements the Excel F.INV function, the inverse of the left-tailed F
+// distribution.
+func FInv(args []Result) Result {
+	v, err := numberArguments(args, 3, 3, "F.INV")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	p := v[0]
+	v[0] = 0
+	_, d1, d2, err := fArguments(v, "F.INV")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if p < 0 || p >= 1 {
+		return outOfRange("F.INV")
+	}
+	return MakeNumberResult(inverse(func(x float64) float64 { return fCDF(x, d1, d2) }, p, 0, 1))
+}
+
+// FInvRT implements the Excel F.INV.RT and FINV functions, the inverse of the
+// right-tailed F distribution.
+func FInvRT(args []Result) Result {
+	v, err := numberArguments(args, 3, 3, "F.INV.RT")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	p := v[0]
+	v[0] = 0
+	_, d1, d2, err := fArguments(v, "F.INV.RT")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	if p <= 0 || p > 1 {
+		return outOfRange("F.INV.RT")
+	}
+	return MakeNumberResult(inverse(func(x float64) float64 { return -fTail(x, d1, d2) }, -p, 0, 1))
+}
@@ -0,0 +1,110 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import "testing"
+
+// The expected values are those Excel returns, most of them from the examples
+// of the Excel documentation.
+func TestDistributions(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    float64
+	}{
+		{"NORM.DIST(42,40,1.5,TRUE)", 0.9087888},
+		{"NORM.DIST(42,40,1.5,FALSE)", 0.10934005},
+		{"NORMDIST(42,40,1.5,TRUE)", 0.9087888},
+		{"NORM.S.DIST(1.333333,TRUE)", 0.908788726},
+		{"NORM.S.DIST(1.333333,FALSE)", 0.164010148},
+		{"NORMSDIST(1.333333)", 0.908788726},
+		{"T.DIST(60,1,TRUE)", 0.99469533},
+		{"T.DIST(8,3,FALSE)", 0.00073691},
+		{"T.DIST.2T(1.959999998,60)", 0.054644930},
+		{"T.DIST.RT(1.959999998,60)", 0.027322465},
+		{"TDIST(1.959999998,60,2)", 0.054644930},
+		{"TDIST(1.959999998,60,1)", 0.027322465},
+		{"CHISQ.DIST(0.5,1,TRUE)", 0.52049988},
+		{"CHISQ.DIST(2,3,FALSE)", 0.20755375},
+		{"CHISQ.DIST.RT(18.307,10)", 0.0500006},
+		{"CHIDIST(18.307,10)", 0.0500006},
+		{"BINOM.DIST(6,10,0.5,FALSE)", 0.2050781},
+		{"BINOMDIST(6,10,0.5,TRUE)", 0.828125},
+		{"POISSON.DIST(2,5,TRUE)", 0.124652},
+		{"POISSON.DIST(2,5,FALSE)", 0.084224},
+		{"POISSON(2,5,TRUE)", 0.124652},
+		{"GAMMA(2.5)", 1.329340388},
+		{"GAMMA(-3.75)", 0.267866129},
+		{"GAMMALN(4)", 1.791759469},
+		{"GAMMALN.PRECISE(4)", 1.791759469},
+		{"GAMMA.DIST(10.00001131,9,2,FALSE)", 0.032639},
+		{"GAMMA.DIST(10.00001131,9,2,TRUE)", 0.068094},
+		{"GAMMADIST(10.00001131,9,2,TRUE)", 0.068094},
+		{"BETA.DIST(2,8,10,TRUE,1,3)", 0.6854706},
+		{"BETA.DIST(2,8,10,FALSE,1,3)", 1.4837646},
+		{"BETADIST(2,8,10,1,3)", 0.6854706},
+		{"F.DIST(15.2069,6,4,TRUE)", 0.99},
+		{"F.DIST(15.2069,6,4,FALSE)", 0.0012238},
+		{"F.DIST.RT(15.207,6,4)", 0.01},
+		{"FDIST(15.207,6,4)", 0.01},
+	}
+	for _, tc := range tests {
+		checkNumber(t, tc.formula, tc.want)
+	}
+}
+
+func TestInverseDistributions(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    float64
+	}{
+		{"NORM.INV(0.908789,40,1.5)", 42.000002},
+		{"NORM.S.INV(0.908789)", 1.3333347},
+		{"NORMSINV(0.908789)", 1.3333347},
+		{"T.INV(0.75,2)", 0.8164966},
+		{"T.INV.2T(0.546449,60)", 0.606533076},
+		{"TINV(0.546449,60)", 0.606533076},
+		{"CHISQ.INV(0.93,1)", 3.283020287},
+		{"CHISQ.INV(0.6,2)", 1.832581464},
+		{"CHISQ.INV.RT(0.050001,10)", 18.306973},
+		{"CHIINV(0.050001,10)", 18.306973},
+		{"BINOM.INV(6,0.5,0.75)", 4},
+		{"CRITBINOM(6,0.5,0.75)", 4},
+		{"GAMMA.INV(0.068094,9,2)", 10.0000112},
+		{"BETA.INV(0.685470581,8,10,1,3)", 2},
+		{"BETAINV(0.685470581,8,10,1,3)", 2},
+		{"F.INV(0.01,6,4)", 0.10930991},
+		{"F.INV.RT(0.01,6,4)", 15.20686},
+		{"FINV(0.01,6,4)", 15.20686},
+	}
+	for _, tc := range tests {
+		checkNumber(t, tc.formula, tc.want)
+	}
+}
+
+func TestDistributionErrors(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    string
+	}{
+		{"GAMMA(0)", "#NUM!"},
+		{"GAMMA(-2)", "#NUM!"},
+		{"NORM.INV(0,1,1)", "#NUM!"},
+		{"T.DIST(1,0,TRUE)", "#NUM!"},
+		{"CHISQ.DIST(-1,1,TRUE)", "#NUM!"},
+		{"BINOM.DIST(11,10,0.5,TRUE)", "#NUM!"},
+		{"NORM.DIST(\"x\",0,1,TRUE)", "#VALUE!"},
+	}
+	for _, tc := range tests {
+		r := evalFormula(tc.formula)
+		if r.Type != ResultTypeError || r.ValueString != tc.want {
+			t.Errorf("%s = %v %q, want %s", tc.formula, r.Type, r.Value(), tc.want)
+		}
+	}
+}
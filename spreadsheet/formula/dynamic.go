@@ -0,0 +1,922 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+)
+
+func init() {
+	RegisterFunctionComplex("_xlfn.ANCHORARRAY", AnchorArray)
+	RegisterFunctionComplex("_xlfn.SINGLE", Single)
+	RegisterFunction("_xlfn._xlws.FILTER", Filter)
+	RegisterFunction("_xlfn._xlws.SORT", Sort)
+	RegisterFunction("_xlfn.SORTBY", SortBy)
+	RegisterFunction("_xlfn.UNIQUE", Unique)
+	RegisterFunction("_xlfn.SEQUENCE", Sequence)
+	RegisterFunction("_xlfn.RANDARRAY", RandArray)
+}
+
+// SpillContext is implemented by evaluation contexts knowing the cells that
+// dynamic array formulas spill into, used by the spill range operator.
+type SpillContext interface {
+	// SpillRange returns the range, such as "A1:B10", that the dynamic array
+	// formula of a cell spills into, false if the cell has none.
+	SpillRange(cell string) (string, bool)
+}
+
+// StoredFormula returns a formula as Excel stores it in files: the spill range
+// operator A1# is written _xlfn.ANCHORARRAY(A1), the implicit intersection
+// operator @ is written _xlfn.SINGLE(...) and the functions added by later
+// versions of Excel, such as FILTER or SORT, get the _xlfn. or _xlfn._xlws.
+// prefix they are registered with. The names declared by LET and LAMBDA get
+// the _xlpm. prefix. The @ of structured references is kept.
+func StoredFormula(f string) string {
+	return storedFormula(f, nil)
+}
+
+// storedFormula returns a formula as stored, params being the names declared
+// by the LET and LAMBDA calls it is part of.
+func storedFormula(f string, params map[string]bool) string {
+	b := strings.Builder{}
+	for i := 0; i < len(f); {
+		switch c := f[i]; {
+		case c == '"' || c == '[' || c == '{':
+			j := skipFormulaGroup(f, i)
+			b.WriteString(f[i:j])
+			i = j
+		case c == '@':
+			j := operandEnd(f, i+1)
+			if j == i+1 {
+				b.WriteByte(c)
+				i++
+				continue
+			}
+			b.WriteString("_xlfn.SINGLE(" + storedFormula(f[i+1:j], params) + ")")
+			i = j
+		case c == '\'' || isNameChar(c):
+			j := nameEnd(f, i)
+			name := f[i:j]
+			if params[strings.ToUpper(name)] {
+				name = localParameterPrefix + name
+			}
+			switch {
+			case j < len(f) && f[j] == '(':
+				name = storedFunctionName(name)
+				end := skipFormulaGroup(f, j)
+				if !lambdaForms[name] || f[end-1] != ')' {
+					b.WriteString(name)
+					break
+				}
+				args := f[j+1 : end-1]
+				b.WriteString(name + "(" + storedFormula(args, declaredNames(name, args, params)) + ")")
+				j = end
+			case j < len(f) && f[j] == '#' && isCellName(name):
+				b.WriteString("_xlfn.ANCHORARRAY(" + name + ")")
+				j++
+			default:
+				b.WriteString(name)
+			}
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// DisplayFormula returns a formula stored in a file as Excel shows it, without
+// the _xlfn. prefixes and with the spill range and implicit intersection
+// operators. It is the reverse of StoredFormula.
+func DisplayFormula(f string) string {
+	b := strings.Builder{}
+	for i := 0; i < len(f); {
+		switch c := f[i]; {
+		case c == '"' || c == '[':
+			j := skipFormulaGroup(f, i)
+			b.WriteString(f[i:j])
+			i = j
+		case c == '\'' || isNameChar(c):
+			j := nameEnd(f, i)
+			name := f[i:j]
+			if isLocalName(name) {
+				name = name[len(localParameterPrefix):]
+			}
+			if j >= len(f) || f[j] != '(' || !strings.HasPrefix(strings.ToUpper(name), "_XLFN.") {
+				b.WriteString(name)
+				i = j
+				continue
+			}
+			name = name[len("_xlfn."):]
+			if strings.HasPrefix(strings.ToUpper(name), "_XLWS.") {
+				name = name[len("_xlws."):]
+			}
+			end := skipFormulaGroup(f, j)
+			if f[end-1] == ')' {
+				inner := f[j+1 : end-1]
+				switch strings.ToUpper(name) {
+				case "ANCHORARRAY":
+					b.WriteString(DisplayFormula(inner) + "#")
+					i = end
+					continue
+				case "SINGLE":
+					if operandEnd(inner, 0) != len(inner) {
+						inner = "(" + inner + ")"
+					}
+					b.WriteString("@" + DisplayFormula(inner))
+					i = end
+					continue
+				}
+			}
+			b.WriteString(name)
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// storedFunctionName returns the name of a function with the prefix it is
+// registered with, if any.
+func storedFunctionName(name string) string {
+	upper := strings.ToUpper(name)
+	if strings.HasPrefix(upper, "_XLFN.") || isLocalName(name) {
+		return name
+	}
+	for _, prefix := range []string{"_xlfn._xlws.", "_xlfn."} {
+		if LookupFunction(prefix+upper) != nil || LookupFunctionComplex(prefix+upper) != nil || lambdaForms[prefix+upper] {
+			return prefix + upper
+		}
+	}
+	return name
+}
+
+// declaredNames returns the names of the enclosing calls along with the names
+// declared by the arguments of a LET or LAMBDA call: the parameters of LAMBDA
+// and the names bound by LET, all the arguments but the last one or every
+// other one.
+func declaredNames(fn, args string, params map[string]bool) map[string]bool {
+	names := map[string]bool{}
+	for name := range params {
+		names[name] = true
+	}
+	list := splitArguments(args)
+	for i, a := range list[:len(list)-1] {
+		a = strings.TrimSpace(a)
+		if (fn == "_xlfn.LET" && i%2 != 0) || isLocalName(a) || !isTableName(a) {
+			continue
+		}
+		names[strings.ToUpper(a)] = true
+	}
+	return names
+}
+
+// splitArguments splits the arguments of a function call.
+func splitArguments(args string) []string {
+	var list []string
+	start := 0
+	for i := 0; i < len(args); {
+		switch args[i] {
+		case '"', '[', '(', '{':
+			i = skipFormulaGroup(args, i)
+		case '\'':
+			i = nameEnd(args, i)
+		case ',':
+			list = append(list, args[start:i])
+			i++
+			start = i
+		default:
+			i++
+		}
+	}
+	return append(list, args[start:])
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '$' || c == '\\' || c >= 0x80
+}
+
+// nameEnd returns the end of the name, number or cell reference starting at
+// i, including its sheet prefix.
+func nameEnd(f string, i int) int {
+	j := i
+	if f[j] == '\'' {
+		for j++; j < len(f); j++ {
+			if f[j] == '\'' {
+				if j+1 < len(f) && f[j+1] == '\'' {
+					j++
+					continue
+				}
+				j++
+				break
+			}
+		}
+	}
+	for j < len(f) && (isNameChar(f[j]) || f[j] == '!') {
+		j++
+	}
+	return j
+}
+
+// isCellName returns true if a name is a reference to a cell, the operand
+// of the spill range operator.
+func isCellName(name string) bool {
+	if i := strings.LastIndexByte(name, '!'); i >= 0 {
+		name = name[i+1:]
+	}
+	_, err := reference.ParseCellReference(name)
+	return err == nil
+}
+
+// operandEnd returns the end of the operand of the implicit intersection
+// operator starting at i: a reference, a name, a function call or an
+// expression within parentheses, with their ranges and structured
+// references.
+func operandEnd(f string, i int) int {
+	j := i
+	for {
+		switch {
+		case j < len(f) && f[j] == '(':
+			j = skipFormulaGroup(f, j)
+		case j < len(f) && (f[j] == '\'' || isNameChar(f[j])):
+			j = nameEnd(f, j)
+			for j < len(f) && (f[j] == '(' || f[j] == '[') {
+				j = skipFormulaGroup(f, j)
+			}
+		default:
+			return j
+		}
+		if j >= len(f) || f[j] != ':' {
+			return j
+		}
+		j++
+	}
+}
+
+// skipFormulaGroup returns the end of the string, the brackets, the
+// parentheses or the array constant starting at i.
+func skipFormulaGroup(f string, i int) int {
+	switch f[i] {
+	case '"':
+		for j := i + 1; j < len(f); j++ {
+			if f[j] == '"' {
+				if j+1 < len(f) && f[j+1] == '"' {
+					j++
+					continue
+				}
+				return j + 1
+			}
+		}
+	case '[':
+		depth := 0
+		for j := i; j < len(f); j++ {
+			switch f[j] {
+			case '\'':
+				// escapes the next character of a column name
+				j++
+			case '[':
+				depth++
+			case ']':
+				depth--
+				if depth == 0 {
+					return j + 1
+				}
+			}
+		}
+	case '{':
+		for j := i + 1; j < len(f); j++ {
+			switch f[j] {
+			case '"':
+				j = skipFormulaGroup(f, j) - 1
+			case '}':
+				return j + 1
+			}
+		}
+	case '(':
+		depth := 0
+		for j := i; j < len(f); j++ {
+			switch f[j] {
+			case '"', '[':
+				j = skipFormulaGroup(f, j) - 1
+			case '\'':
+				j = nameEnd(f, j) - 1
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return j + 1
+				}
+			}
+		}
+	}
+	return len(f)
+}
+
+// AnchorArray implements the spill range operator, written A1# and stored as
+// _xlfn.ANCHORARRAY(A1), which returns the values of the cells a dynamic array
+// formula spills into.
+func AnchorArray(ctx Context, ev Evaluator, args []Result) Result {
+	if len(args) != 1 {
+		return MakeErrorResult("ANCHORARRAY requires one argument")
+	}
+	ref := args[0].Ref
+	if ref.Type != ReferenceTypeCell {
+		return MakeErrorResultType(ErrorTypeRef, "ANCHORARRAY requires a cell reference")
+	}
+	cell := ref.Value
+	if i := strings.LastIndexByte(cell, '!'); i >= 0 {
+		ctx = ctx.Sheet(unquoteSheetName(cell[:i]))
+		cell = cell[i+1:]
+	}
+	sc, ok := ctx.(SpillContext)
+	if !ok {
+		return MakeErrorResultType(ErrorTypeRef, "spill ranges aren't supported by the context")
+	}
+	rng, ok := sc.SpillRange(cell)
+	if !ok {
+		return MakeErrorResultType(ErrorTypeRef, cell+" doesn't spill")
+	}
+	bounds := strings.Split(rng, ":")
+	if len(bounds) != 2 {
+		return ctx.Cell(rng, ev)
+	}
+	return _bbea(ctx, ev, bounds[0], bounds[1])
+}
+
+// Single implements the implicit intersection operator, written @ and stored
+// as _xlfn.SINGLE. A range returns the cell in the row or the column of the
+// formula, other arrays their first value.
+func Single(ctx Context, ev Evaluator, args []Result) Result {
+	if len(args) != 1 {
+		return MakeErrorResult("SINGLE requires one argument")
+	}
+	arg := args[0]
+	if arg.Ref.Type == ReferenceTypeRange {
+		if tc, ok := ctx.(TableContext); ok {
+			_, cell := tc.CurrentCell()
+			return implicitIntersection(ctx, ev, arg.Ref.Value, cell)
+		}
+	}
+	switch arg.Type {
+	case ResultTypeArray:
+		if len(arg.ValueArray) > 0 && len(arg.ValueArray[0]) > 0 {
+			return arg.ValueArray[0][0]
+		}
+		return MakeErrorResultType(ErrorTypeValue, "SINGLE of an empty array")
+	case ResultTypeList:
+		if len(arg.ValueList) > 0 {
+			return arg.ValueList[0]
+		}
+		return MakeErrorResultType(ErrorTypeValue, "SINGLE of an empty list")
+	}
+	return arg
+}
+
+// implicitIntersection returns the cell of a range in the row and the column
+// of a cell, for the ranges spanning more than one row or column.
+func implicitIntersection(ctx Context, ev Evaluator, rng, cell string) Result {
+	from, to, err := reference.ParseRangeReference(rng)
+	if err != nil {
+		return MakeErrorResultType(ErrorTypeValue, "invalid range "+rng)
+	}
+	current, err := reference.ParseCellReference(cell)
+	if err != nil {
+		return MakeErrorResultType(ErrorTypeValue, "invalid cell "+cell)
+	}
+	col, row := from.ColumnIdx, from.RowIdx
+	if to.RowIdx != from.RowIdx {
+		if current.RowIdx < from.RowIdx || current.RowIdx > to.RowIdx {
+			return MakeErrorResultType(ErrorTypeValue, "no intersection of "+rng+" with row "+strconv.Itoa(int(current.RowIdx)))
+		}
+		row = current.RowIdx
+	}
+	if to.ColumnIdx != from.ColumnIdx {
+		if current.ColumnIdx < from.ColumnIdx || current.ColumnIdx > to.ColumnIdx {
+			return MakeErrorResultType(ErrorTypeValue, "no intersection of "+rng+" with column "+current.Column)
+		}
+		col = current.ColumnIdx
+	}
+	if from.SheetName != "" {
+		ctx = ctx.Sheet(unquoteSheetName(from.SheetName))
+	}
+	return ctx.Cell(reference.IndexToColumn(col)+strconv.Itoa(int(row)), ev)
+}
+
+// Filter is an implementation of the Excel FILTER function, returning the rows
+// or the columns of an array for which the values of a second array are true.
+func Filter(args []Result) Result {
+	if len(args) != 2 && len(args) != 3 {
+		return MakeErrorResult("FILTER requires two or three arguments")
+	}
+	rows, errResult := arrayRows(args[0], "FILTER")
+	if errResult.Type == ResultTypeError {
+		return errResult
+	}
+	include, errResult := arrayRows(args[1], "FILTER")
+	if errResult.Type == ResultTypeError {
+		return errResult
+	}
+	height, width := len(rows), len(rows[0])
+	byCol := false
+	switch {
+	case len(include) == height && len(include[0]) == 1:
+	case len(include) == 1 && len(include[0]) == width:
+		byCol = true
+		rows = transposeRows(rows)
+	default:
+		return MakeErrorResultType(ErrorTypeValue, "FILTER requires an include array matching the rows or the columns of the array")
+	}
+	var kept [][]Result
+	for i, row := range rows {
+		var v Result
+		if byCol {
+			v = include[0][i]
+		} else {
+			v = include[i][0]
+		}
+		ok, errResult := truthValue(v)
+		if errResult.Type == ResultTypeError {
+			return errResult
+		}
+		if ok {
+			kept = append(kept, row)
+		}
+	}
+	if len(kept) == 0 {
+		if len(args) == 3 {
+			return args[2]
+		}
+		return MakeErrorResultType(ErrorTypeCalc, "FILTER found no values")
+	}
+	if byCol {
+		kept = transposeRows(kept)
+	}
+	return rowsResult(kept)
+}
+
+// Sort is an implementation of the Excel SORT function, sorting the rows or
+// the columns of an array by one of their values.
+func Sort(args []Result) Result {
+	if len(args) < 1 || len(args) > 4 {
+		return MakeErrorResult("SORT requires one to four arguments")
+	}
+	rows, errResult := arrayRows(args[0], "SORT")
+	if errResult.Type == ResultTypeError {
+		return errResult
+	}
+	byCol := false
+	if len(args) > 3 {
+		b, errResult := truthValue(args[3])
+		if errResult.Type == ResultTypeError {
+			return errResult
+		}
+		byCol = b
+	}
+	if byCol {
+		rows = transposeRows(rows)
+	}
+	indexes := []float64{1}
+	if len(args) > 1 && args[1].Type != ResultTypeEmpty {
+		var errResult Result
+		if indexes, errResult = numberValues(args[1], "SORT"); errResult.Type == ResultTypeError {
+			return errResult
+		}
+	}
+	orders := []float64{1}
+	if len(args) > 2 && args[2].Type != ResultTypeEmpty {
+		var errResult Result
+		if orders, errResult = numberValues(args[2], "SORT"); errResult.Type == ResultTypeError {
+			return errResult
+		}
+	}
+	if len(orders) != 1 && len(orders) != len(indexes) {
+		return MakeErrorResultType(ErrorTypeValue, "SORT requires a sort order per sort index")
+	}
+	keys := make([][]Result, len(indexes))
+	for k, index := range indexes {
+		if index < 1 || int(index) > len(rows[0]) {
+			return MakeErrorResultType(ErrorTypeValue, "SORT index out of range")
+		}
+		keys[k] = make([]Result, len(rows))
+		for i, row := range rows {
+			keys[k][i] = row[int(index)-1]
+		}
+	}
+	sorted, errResult := sortRows(rows, keys, orders, "SORT")
+	if errResult.Type == ResultTypeError {
+		return errResult
+	}
+	if byCol {
+		sorted = transposeRows(sorted)
+	}
+	return rowsResult(sorted)
+}
+
+// SortBy is an implementation of the Excel SORTBY function, sorting the rows
+// or the columns of an array by the values of other arrays.
+func SortBy(args []Result) Result {
+	if len(args) < 2 {
+		return MakeErrorResult("SORTBY requires at least two arguments")
+	}
+	rows, errResult := arrayRows(args[0], "SORTBY")
+	if errResult.Type == ResultTypeError {
+		return errResult
+	}
+	byCol := false
+	var keys [][]Result
+	var orders []float64
+	for i := 1; i < len(args); i += 2 {
+		by, errResult := arrayRows(args[i], "SORTBY")
+		if errResult.Type == ResultTypeError {
+			return errResult
+		}
+		var key []Result
+		switch {
+		case !byCol && len(by) == len(rows) && len(by[0]) == 1:
+			for _, row := range by {
+				key = append(key, row[0])
+			}
+		case (byCol || i == 1) && len(by) == 1 && len(by[0]) == len(rows[0]):
+			byCol = true
+			key = by[0]
+		default:
+			return MakeErrorResultType(ErrorTypeValue, "SORTBY requires arrays of one row or one column matching the array")
+		}
+		keys = append(keys, key)
+		order := 1.0
+		if i+1 < len(args) && args[i+1].Type != ResultTypeEmpty {
+			o := args[i+1].AsNumber()
+			if o.Type != ResultTypeNumber {
+				return MakeErrorResultType(ErrorTypeValue, "SORTBY requires numeric sort orders")
+			}
+			order = o.ValueNumber
+		}
+		orders = append(orders, order)
+	}
+	if byCol {
+		rows = transposeRows(rows)
+	}
+	sorted, errResult := sortRows(rows, keys, orders, "SORTBY")
+	if errResult.Type == ResultTypeError {
+		return errResult
+	}
+	if byCol {
+		sorted = transposeRows(sorted)
+	}
+	return rowsResult(sorted)
+}
+
+// Unique is an implementation of the Excel UNIQUE function, returning the
+// distinct rows or columns of an array, or those appearing exactly once.
+// Text is compared case insensitively.
+func Unique(args []Result) Result {
+	if len(args) < 1 || len(args) > 3 {
+		return MakeErrorResult("UNIQUE requires one to three arguments")
+	}
+	rows, errResult := arrayRows(args[0], "UNIQUE")
+	if errResult.Type == ResultTypeError {
+		return errResult
+	}
+	flags := [2]bool{}
+	for i := 1; i < len(args); i++ {
+		b, errResult := truthValue(args[i])
+		if errResult.Type == ResultTypeError {
+			return errResult
+		}
+		flags[i-1] = b
+	}
+	byCol, exactlyOnce := flags[0], flags[1]
+	if byCol {
+		rows = transposeRows(rows)
+	}
+	counts := map[string]int{}
+	var order []string
+	first := map[string][]Result{}
+	for _, row := range rows {
+		parts := make([]string, len(row))
+		for i, v := range row {
+			parts[i] = valueKey(v)
+		}
+		key := strings.Join(parts, "\x00")
+		if counts[key] == 0 {
+			order = append(order, key)
+			first[key] = row
+		}
+		counts[key]++
+	}
+	var kept [][]Result
+	for _, key := range order {
+		if !exactlyOnce || counts[key] == 1 {
+			kept = append(kept, first[key])
+		}
+	}
+	if len(kept) == 0 {
+		return MakeErrorResultType(ErrorTypeCalc, "UNIQUE found no values")
+	}
+	if byCol {
+		kept = transposeRows(kept)
+	}
+	return rowsResult(kept)
+}
+
+// Sequence is an implementation of the Excel SEQUENCE function, returning an
+// array of sequential numbers.
+func Sequence(args []Result) Result {
+	if len(args) < 1 || len(args) > 4 {
+		return MakeErrorResult("SEQUENCE requires one to four arguments")
+	}
+	values := []float64{0, 1, 1, 1}
+	for i, arg := range args {
+		if arg.Type == ResultTypeEmpty {
+			continue
+		}
+		n := arg.AsNumber()
+		if n.Type != ResultTypeNumber {
+			return MakeErrorResultType(ErrorTypeValue, "SEQUENCE requires numeric arguments")
+		}
+		values[i] = n.ValueNumber
+	}
+	height, width, errResult := arraySize(values[0], values[1], "SEQUENCE")
+	if errResult.Type == ResultTypeError {
+		return errResult
+	}
+	start, step := values[2], values[3]
+	rows := make([][]Result, height)
+	for i := range rows {
+		rows[i] = make([]Result, width)
+		for j := range rows[i] {
+			rows[i][j] = MakeNumberResult(start + float64(i*width+j)*step)
+		}
+	}
+	return rowsResult(rows)
+}
+
+// RandArray is an implementation of the Excel RANDARRAY function, returning an
+// array of random numbers between a minimum and a maximum.
+func RandArray(args []Result) Result {
+	if len(args) > 5 {
+		return MakeErrorResult("RANDARRAY allows at most five arguments")
+	}
+	values := []float64{1, 1, 0, 1}
+	for i, arg := range args {
+		if i == 4 || arg.Type == ResultTypeEmpty {
+			continue
+		}
+		n := arg.AsNumber()
+		if n.Type != ResultTypeNumber {
+			return MakeErrorResultType(ErrorTypeValue, "RANDARRAY requires numeric arguments")
+		}
+		values[i] = n.ValueNumber
+	}
+	whole := false
+	if len(args) == 5 {
+		b, errResult := truthValue(args[4])
+		if errResult.Type == ResultTypeError {
+			return errResult
+		}
+		whole = b
+	}
+	height, width, errResult := arraySize(values[0], values[1], "RANDARRAY")
+	if errResult.Type == ResultTypeError {
+		return errResult
+	}
+	min, max := values[2], values[3]
+	if whole {
+		min, max = math.Ceil(min), math.Floor(max)
+	}
+	if min > max {
+		return MakeErrorResultType(ErrorTypeValue, "RANDARRAY requires a minimum less than the maximum")
+	}
+	rows := make([][]Result, height)
+	for i := range rows {
+		rows[i] = make([]Result, width)
+		for j := range rows[i] {
+			v := min + rand.Float64()*(max-min)
+			if whole {
+				v = min + float64(rand.Int63n(int64(max-min)+1))
+			}
+			rows[i][j] = MakeNumberResult(v)
+		}
+	}
+	return rowsResult(rows)
+}
+
+// resultRows returns the rows of an array, a list being a single row and
+// other values an array of one value.
+func resultRows(r Result) [][]Result {
+	switch r.Type {
+	case ResultTypeArray:
+		if len(r.ValueArray) > 0 && len(r.ValueArray[0]) > 0 {
+			return r.ValueArray
+		}
+	case ResultTypeList:
+		if len(r.ValueList) > 0 {
+			return [][]Result{r.ValueList}
+		}
+	default:
+		return [][]Result{{r}}
+	}
+	return [][]Result{{MakeEmptyResult()}}
+}
+
+// arrayRows returns the rows of an array argument of the dynamic array
+// functions, with a #CALC! error for empty arrays and a #VALUE! error for rows
+// of different lengths.
+func arrayRows(r Result, name string) ([][]Result, Result) {
+	if r.Type == ResultTypeArray && (len(r.ValueArray) == 0 || len(r.ValueArray[0]) == 0) || r.Type == ResultTypeList && len(r.ValueList) == 0 {
+		return nil, MakeErrorResultType(ErrorTypeCalc, name+" requires a non empty array")
+	}
+	rows := resultRows(r)
+	for _, row := range rows {
+		if len(row) != len(rows[0]) {
+			return nil, MakeErrorResultType(ErrorTypeValue, name+" requires arrays with rows of the same length")
+		}
+	}
+	return rows, MakeEmptyResult()
+}
+
+// rowsResult returns rows as ranges evaluate: a single value, a list for a
+// single row, an array otherwise, or a #CALC! error if there are no values.
+func rowsResult(rows [][]Result) Result {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return MakeErrorResultType(ErrorTypeCalc, "empty array")
+	}
+	if len(rows) == 1 {
+		if len(rows[0]) == 1 {
+			return rows[0][0]
+		}
+		return MakeListResult(rows[0])
+	}
+	return MakeArrayResult(rows)
+}
+
+func transposeRows(rows [][]Result) [][]Result {
+	if len(rows) == 0 {
+		return nil
+	}
+	t := make([][]Result, len(rows[0]))
+	for j := range t {
+		t[j] = make([]Result, len(rows))
+		for i, row := range rows {
+			if j < len(row) {
+				t[j][i] = row[j]
+			} else {
+				t[j][i] = MakeEmptyResult()
+			}
+		}
+	}
+	return t
+}
+
+// truthValue returns the logical value of a number or a boolean, empty values
+// being false.
+func truthValue(r Result) (bool, Result) {
+	switch r.Type {
+	case ResultTypeEmpty:
+		return false, r
+	case ResultTypeNumber:
+		return r.ValueNumber != 0, r
+	case ResultTypeError:
+		return false, r
+	}
+	n := r.AsNumber()
+	if n.Type != ResultTypeNumber {
+		return false, MakeErrorResultType(ErrorTypeValue, "expected a logical value, got "+r.Value())
+	}
+	return n.ValueNumber != 0, n
+}
+
+// numberValues returns the numbers of a value or an array.
+func numberValues(r Result, name string) ([]float64, Result) {
+	var values []float64
+	for _, row := range resultRows(r) {
+		for _, v := range row {
+			n := v.AsNumber()
+			if n.Type != ResultTypeNumber {
+				return nil, MakeErrorResultType(ErrorTypeValue, name+" requires numeric arguments")
+			}
+			values = append(values, n.ValueNumber)
+		}
+	}
+	return values, MakeEmptyResult()
+}
+
+// arraySize validates the number of rows and columns of an array to create.
+func arraySize(rows, cols float64, name string) (int, int, Result) {
+	rows, cols = math.Trunc(rows), math.Trunc(cols)
+	switch {
+	case rows < 0 || cols < 0 || rows > maxRow || cols > maxColumn+1:
+		return 0, 0, MakeErrorResultType(ErrorTypeValue, name+" size out of range")
+	case rows == 0 || cols == 0:
+		return 0, 0, MakeErrorResultType(ErrorTypeCalc, name+" returns an empty array")
+	}
+	return int(rows), int(cols), MakeEmptyResult()
+}
+
+// sortRows sorts rows by keys, one value per row, in ascending order for the
+// positive orders and descending order for the negative ones. The sort is
+// stable and empty values are sorted last in both orders.
+func sortRows(rows [][]Result, keys [][]Result, orders []float64, name string) ([][]Result, Result) {
+	for _, o := range orders {
+		if o != 1 && o != -1 {
+			return nil, MakeErrorResultType(ErrorTypeValue, name+" requires sort orders of 1 or -1")
+		}
+	}
+	idx := make([]int, len(rows))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		for k, key := range keys {
+			x, y := key[idx[a]], key[idx[b]]
+			if xe, ye := x.Type == ResultTypeEmpty, y.Type == ResultTypeEmpty; xe || ye {
+				if xe != ye {
+					return ye
+				}
+				continue
+			}
+			c := compareValues(x, y)
+			if c == 0 {
+				continue
+			}
+			order := orders[0]
+			if len(orders) > k {
+				order = orders[k]
+			}
+			return (c < 0) == (order > 0)
+		}
+		return false
+	})
+	sorted := make([][]Result, len(rows))
+	for i, j := range idx {
+		sorted[i] = rows[j]
+	}
+	return sorted, MakeEmptyResult()
+}
+
+// sortRank orders the types of values as Excel sorts them: numbers, text,
+// logical values, errors then empty values.
+func sortRank(r Result) int {
+	switch r.Type {
+	case ResultTypeNumber:
+		if r.IsBoolean {
+			return 2
+		}
+		return 0
+	case ResultTypeString:
+		return 1
+	case ResultTypeError:
+		return 3
+	}
+	return 4
+}
+
+// compareValues compares values in sort order, text case insensitively.
+func compareValues(a, b Result) int {
+	ra, rb := sortRank(a), sortRank(b)
+	switch {
+	case ra != rb:
+		return ra - rb
+	case ra == 0 || ra == 2:
+		switch {
+		case a.ValueNumber < b.ValueNumber:
+			return -1
+		case a.ValueNumber > b.ValueNumber:
+			return 1
+		}
+		return 0
+	case ra == 1:
+		return strings.Compare(strings.ToLower(a.ValueString), strings.ToLower(b.ValueString))
+	}
+	return 0
+}
+
+// valueKey identifies a value for UNIQUE, text being compared case
+// insensitively.
+func valueKey(r Result) string {
+	switch rank := sortRank(r); rank {
+	case 0, 2:
+		return strconv.Itoa(rank) + strconv.FormatFloat(r.ValueNumber, 'g', -1, 64)
+	case 1:
+		return "1" + strings.ToLower(r.ValueString)
+	default:
+		return strconv.Itoa(rank) + r.ValueString
+	}
+}
@@ -0,0 +1,109 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"reflect"
+	"testing"
+)
+
+// evalFormula evaluates a formula as entered in Excel, without cell
+// references.
+func evalFormula(f string) Result {
+	return NewEvaluator().Eval(InvalidReferenceContext, StoredFormula(f))
+}
+
+// resultText returns the values of a result as rows of text.
+func resultText(r Result) [][]string {
+	ret := [][]string{}
+	for _, row := range resultRows(r) {
+		values := []string{}
+		for _, v := range row {
+			values = append(values, v.Value())
+		}
+		ret = append(ret, values)
+	}
+	return ret
+}
+
+func TestDynamicArrays(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    [][]string
+	}{
+		{"FILTER({1,2,3},{TRUE,FALSE,TRUE})", [][]string{{"1", "3"}}},
+		{"FILTER({1;2;3},{TRUE;FALSE;TRUE})", [][]string{{"1"}, {"3"}}},
+		{"FILTER({1,2;3,4},{FALSE;TRUE})", [][]string{{"3", "4"}}},
+		{"FILTER({1,2,3},{FALSE,FALSE,FALSE},\"none\")", [][]string{{"none"}}},
+		{"SORT({3,1,2},1,1,TRUE)", [][]string{{"1", "2", "3"}}},
+		{"SORTBY({1;2;3},{3;1;2})", [][]string{{"2"}, {"3"}, {"1"}}},
+		{"SORTBY({1,2,3},{3,1,2})", [][]string{{"2", "3", "1"}}},
+		{"UNIQUE({1;1;2})", [][]string{{"1"}, {"2"}}},
+	}
+	for _, tc := range tests {
+		r := evalFormula(tc.formula)
+		if r.Type == ResultTypeError {
+			t.Errorf("%s = %s %s", tc.formula, r.Value(), r.ErrorMessage)
+			continue
+		}
+		if got := resultText(r); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s = %v, want %v", tc.formula, got, tc.want)
+		}
+	}
+}
+
+func TestDynamicArrayErrors(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    string
+	}{
+		{"FILTER({1,2,3},{TRUE,FALSE})", "#VALUE!"},
+		{"FILTER({1,2;3,4},{TRUE,FALSE,TRUE})", "#VALUE!"},
+		{"FILTER({1,2,3},{FALSE,FALSE,FALSE})", "#CALC!"},
+		{"SORTBY({1;2;3},{1;2})", "#VALUE!"},
+	}
+	for _, tc := range tests {
+		r := evalFormula(tc.formula)
+		if r.Type != ResultTypeError || r.ValueString != tc.want {
+			t.Errorf("%s = %v %q, want %s", tc.formula, r.Type, r.Value(), tc.want)
+		}
+	}
+	// arrays without values, which formulas can't write
+	empty := []Result{MakeArrayResult(nil), MakeListResult(nil)}
+	for _, r := range empty {
+		for name, fn := range map[string]Function{"FILTER": Filter, "SORT": Sort, "UNIQUE": Unique} {
+			args := []Result{r, MakeBoolResult(true)}
+			if name != "FILTER" {
+				args = args[:1]
+			}
+			if got := fn(args); got.Type != ResultTypeError || got.ValueString != "#CALC!" {
+				t.Errorf("%s of an empty array = %v %q, want #CALC!", name, got.Type, got.Value())
+			}
+		}
+	}
+}
+
+func TestStoredFormula(t *testing.T) {
+	tests := []struct {
+		formula, want string
+	}{
+		{"FILTER(A1:A3,B1:B3)", "_xlfn._xlws.FILTER(A1:A3,B1:B3)"},
+		{"A1#", "_xlfn.ANCHORARRAY(A1)"},
+		{"LET(x,2,x*y)", "_xlfn.LET(_xlpm.x,2,_xlpm.x*y)"},
+		// array constants aren't split into arguments
+		{"SUM(LET(a,{1,2,3},a))", "SUM(_xlfn.LET(_xlpm.a,{1,2,3},_xlpm.a))"},
+		{"LET(a,{1,\"b,c\";3,4},b,2,SUM(a)+b)", "_xlfn.LET(_xlpm.a,{1,\"b,c\";3,4},_xlpm.b,2,SUM(_xlpm.a)+_xlpm.b)"},
+	}
+	for _, tc := range tests {
+		if got := StoredFormula(tc.formula); got != tc.want {
+			t.Errorf("StoredFormula(%q) = %q, want %q", tc.formula, got, tc.want)
+		}
+	}
+}
@@ -206,7 +206,7 @@ func Today (args []Result )Result {if len (args )> 0{return MakeErrorResult ("\u
 };_fff :=_a .Now ();_ ,_edd :=_fff .Zone ();_ecca :=_efag (_gbc ,_fff .Unix ()+int64 (_edd ))+1;return MakeNumberResult (_ecca );};type rangeIndex struct{_cfbf int ;_gegd int ;};
 
 // Update updates the horizontal range references after removing a row/column.
-func (_abaeb HorizontalRange )Update (q *_cc .UpdateQuery )Expression {return _abaeb };
+func (_abaeb HorizontalRange )Update (q *_cc .UpdateQuery )Expression {return updateHorizontalRange (_abaeb ,q );};
 
 // Match implements the MATCH function.
 func Match (args []Result )Result {_edge :=len (args );if _edge !=2&&_edge !=3{return MakeErrorResult ("\u004d\u0041T\u0043\u0048\u0020\u0072e\u0071\u0075i\u0072\u0065\u0073\u0020\u0074\u0077\u006f\u0020o\u0072\u0020\u0074\u0068\u0072\u0065\u0065\u0020\u0061\u0072\u0067\u0075m\u0065\u006e\u0074\u0073");
@@ -571,7 +571,7 @@ func Xirr (args []Result )Result {_ccac :=len (args );if _ccac !=2&&_ccac !=3{re
 };};return _afbdd (_geg ,_fdcf ,_fdagg );};
 
 // Eval evaluates and returns the result of the NamedRangeRef reference.
-func (_beba NamedRangeRef )Eval (ctx Context ,ev Evaluator )Result {_bgbfb :=ctx .NamedRange (_beba ._dcdga );_ebbag :=_bgbfb .Value ;if _afbbd ,_dccee :=ev .GetFromCache (_ebbag );_dccee {return _afbbd ;};_fcaba :=_ecg .Split (_ebbag ,"\u0021");if len (_fcaba )!=2{return MakeErrorResult (_g .Sprintf ("\u0075\u006e\u0073\u0075\u0070\u0070\u006f\u0072\u0074\u0065\u0064\u0020\u006e\u0061\u006de\u0064 \u0072\u0061\u006e\u0067\u0065\u0020\u0076\u0061\u006c\u0075\u0065\u0020\u0025\u0073",_ebbag ));
+func (_beba NamedRangeRef )Eval (ctx Context ,ev Evaluator )Result {if _gcae ,_dbfg :=localValue (ctx ,_beba ._dcdga );_dbfg {return _gcae ;};_bgbfb :=ctx .NamedRange (_beba ._dcdga );_ebbag :=_bgbfb .Value ;if _afbbd ,_dccee :=ev .GetFromCache (_ebbag );_dccee {return _afbbd ;};_fcaba :=_ecg .Split (_ebbag ,"\u0021");if len (_fcaba )!=2{return MakeErrorResult (_g .Sprintf ("\u0075\u006e\u0073\u0075\u0070\u0070\u006f\u0072\u0074\u0065\u0064\u0020\u006e\u0061\u006de\u0064 \u0072\u0061\u006e\u0067\u0065\u0020\u0076\u0061\u006c\u0075\u0065\u0020\u0025\u0073",_ebbag ));
 };_aedc :=ctx .Sheet (_fcaba [0]);_cfgdg :=_ecg .Split (_fcaba [1],"\u003a");switch len (_cfgdg ){case 1:_cbde :=ev .Eval (_aedc ,_cfgdg [0]);ev .SetCache (_ebbag ,_cbde );return _cbde ;case 2:_cfcbb :=_bbea (_aedc ,ev ,_cfgdg [0],_cfgdg [1]);ev .SetCache (_ebbag ,_cfcbb );
 return _cfcbb ;};return MakeErrorResult (_g .Sprintf ("\u0075\u006es\u0075\u0070\u0070\u006f\u0072\u0074\u0065\u0064\u0020\u0072\u0065\u0066\u0065\u0072\u0065\u006e\u0063\u0065\u0020\u0074\u0079\u0070e \u0025\u0073",_bgbfb .Type ));};
 
@@ -633,7 +633,7 @@ func (_afcdg FunctionCall )Update (q *_cc .UpdateQuery )Expression {_aedgg :=[]E
 
 
 // String returns a string representation of a horizontal range.
-func (_deba HorizontalRange )String ()string {return _deba .horizontalRangeReference ()};type countMode byte ;func (_aceab node )String ()string {return _g .Sprintf ("\u007b%\u0073\u0020\u0025\u0073\u007d",_aceab ._cgbf ,_afbc (string (_aceab ._adabg )));
+func (_deba HorizontalRange )String ()string {return formatRowBound (_deba ._gcabd ,_deba ._cgfeb )+"\u003a"+formatRowBound (_deba ._gfbga ,_deba ._aadbf );};type countMode byte ;func (_aceab node )String ()string {return _g .Sprintf ("\u007b%\u0073\u0020\u0025\u0073\u007d",_aceab ._cgbf ,_afbc (string (_aceab ._adabg )));
 };
 
 // Cell is an implementation of the Excel CELL function that returns information
@@ -726,8 +726,7 @@ ev .SetCache (_eebbe ,_cage );return _cage ;default:return MakeErrorResult (_g .
 };};type noCache struct{};const _dagef =1;
 
 // Update updates references in the PrefixVerticalRange after removing a row/column.
-func (_beaae PrefixVerticalRange )Update (q *_cc .UpdateQuery )Expression {if q .UpdateType ==_cc .UpdateActionRemoveColumn {_bgcce :=_beaae ;_fgac :=_beaae ._decac .String ();if _fgac ==q .SheetToUpdate {_afed :=q .ColumnIdx ;_bgcce ._bddaf =_afe (_beaae ._bddaf ,_afed );
-_bgcce ._cfbe =_afe (_beaae ._cfbe ,_afed );};return _bgcce ;};return _beaae ;};func _cgg (_ggfc ,_dgbbb _a .Time ,_dcbd int )_a .Time {_fag :=_a .Date (_ggfc .Year (),_dgbbb .Month (),_dgbbb .Day (),0,0,0,0,_a .UTC );if _fag .After (_ggfc ){_fag =_fag .AddDate (-1,0,0);
+func (_beaae PrefixVerticalRange )Update (q *_cc .UpdateQuery )Expression {return updatePrefixVerticalRange (_beaae ,q );};func _cgg (_ggfc ,_dgbbb _a .Time ,_dcbd int )_a .Time {_fag :=_a .Date (_ggfc .Year (),_dgbbb .Month (),_dgbbb .Day (),0,0,0,0,_a .UTC );if _fag .After (_ggfc ){_fag =_fag .AddDate (-1,0,0);
 };for !_fag .After (_ggfc ){_fag =_fag .AddDate (0,12/_dcbd ,0);};return _fag ;};func _cfcf (_effg ,_daeg float64 )float64 {_effg =_fg .Trunc (_effg );_daeg =_fg .Trunc (_daeg );if _effg ==0&&_daeg ==0{return 0;};return _effg *_daeg /_bfadg (_effg ,_daeg );
 };
 
@@ -835,7 +834,7 @@ func NewNumber (v string )Expression {_edbe ,_gaaba :=_bb .ParseFloat (v ,64);if
 
 
 // String returns a string representation of FunctionCall expression.
-func (_gafca FunctionCall )String ()string {_febf :=_b .Buffer {};_febf .WriteString (_gafca ._ddea );_febf .WriteString ("\u0028");_cafc :=len (_gafca ._ddaa )-1;for _ecgf ,_bbagb :=range _gafca ._ddaa {_febf .WriteString (_bbagb .String ());if _ecgf !=_cafc {_febf .WriteString ("\u002c");
+func (_gafca FunctionCall )String ()string {if _gafca ._ddea ==lambdaInvocation &&len (_gafca ._ddaa )> 0{return invocationString (_gafca ._ddaa );};_febf :=_b .Buffer {};_febf .WriteString (_gafca ._ddea );_febf .WriteString ("\u0028");_cafc :=len (_gafca ._ddaa )-1;for _ecgf ,_bbagb :=range _gafca ._ddaa {_febf .WriteString (_bbagb .String ());if _ecgf !=_cafc {_febf .WriteString ("\u002c");
 };};_febf .WriteString ("\u0029");return _febf .String ();};func init (){_fggf ();RegisterFunction ("\u004e\u0041",NA );RegisterFunction ("\u0049S\u0042\u004c\u0041\u004e\u004b",IsBlank );RegisterFunction ("\u0049\u0053\u0045R\u0052",IsErr );RegisterFunction ("\u0049S\u0045\u0052\u0052\u004f\u0052",IsError );
 RegisterFunction ("\u0049\u0053\u0045\u0056\u0045\u004e",IsEven );RegisterFunctionComplex ("\u005fx\u006cf\u006e\u002e\u0049\u0053\u0046\u004f\u0052\u004d\u0055\u004c\u0041",IsFormula );RegisterFunctionComplex ("\u004fR\u0047\u002e\u004f\u0050E\u004e\u004f\u0046\u0046\u0049C\u0045.\u0049S\u004c\u0045\u0041\u0050\u0059\u0045\u0041R",IsLeapYear );
 RegisterFunctionComplex ("\u0049S\u004c\u004f\u0047\u0049\u0043\u0041L",IsLogical );RegisterFunction ("\u0049\u0053\u004e\u0041",IsNA );RegisterFunction ("\u0049S\u004e\u004f\u004e\u0054\u0045\u0058T",IsNonText );RegisterFunction ("\u0049\u0053\u004e\u0055\u004d\u0042\u0045\u0052",IsNumber );
@@ -855,8 +854,7 @@ _bead :=_feebe +uint32 (_ccae .ValueNumber );if _gbfe .ValueNumber > 0{_ffac --;
 _efgg :=_g .Sprintf ("\u0025\u0073\u0025\u0064",_cgf .IndexToColumn (_bead ),_ffac );if _fbce ==""{return _bbea (ctx ,ev ,_eggf ,_efgg );}else {return _bbea (ctx .Sheet (_fbce ),ev ,_eggf ,_efgg );};};
 
 // Update updates references in the VerticalRange after removing a row/column.
-func (_gdcec VerticalRange )Update (q *_cc .UpdateQuery )Expression {if q .UpdateType ==_cc .UpdateActionRemoveColumn {_deefef :=_gdcec ;if q .UpdateCurrentSheet {_bcaag :=q .ColumnIdx ;_deefef ._ddaf =_afe (_gdcec ._ddaf ,_bcaag );_deefef ._addad =_afe (_gdcec ._addad ,_bcaag );
-};return _deefef ;};return _gdcec ;};func _efbfb (_acacg Result ,_aebgd ,_ccgee string )(float64 ,Result ){switch _acacg .Type {case ResultTypeEmpty :return 0,_eege ;case ResultTypeNumber :return _acacg .ValueNumber ,_eege ;case ResultTypeString :_bgefe ,_gebd :=_bb .ParseFloat (_acacg .ValueString ,64);
+func (_gdcec VerticalRange )Update (q *_cc .UpdateQuery )Expression {return updateVerticalRange (_gdcec ,q );};func _efbfb (_acacg Result ,_aebgd ,_ccgee string )(float64 ,Result ){switch _acacg .Type {case ResultTypeEmpty :return 0,_eege ;case ResultTypeNumber :return _acacg .ValueNumber ,_eege ;case ResultTypeString :_bgefe ,_gebd :=_bb .ParseFloat (_acacg .ValueString ,64);
 if _gebd !=nil {return 0,MakeErrorResult (_ccgee +"\u0020s\u0068\u006f\u0075\u006c\u0064\u0020\u0062\u0065\u0020\u0061\u0020n\u0075\u006d\u0062\u0065\u0072\u0020\u0066\u006f\u0072\u0020"+_aebgd );};return _bgefe ,_eege ;default:return 0,MakeErrorResult (_aebgd +"\u0020\u0072\u0065\u0071\u0075\u0069\u0072\u0065\u0073\u0020"+_ccgee +"\u0020t\u006f\u0020\u0062\u0065\u0020\u0061\u0020\u006e\u0075\u006d\u0062e\u0072\u0020\u006f\u0072\u0020\u0065\u006d\u0070\u0074\u0079");
 };};
 
@@ -870,14 +868,13 @@ if _gfbed .Type !=ResultTypeArray &&_gfbed .Type !=ResultTypeList {return MakeEr
 func (_ddfgc PrefixRangeExpr )String ()string {return _g .Sprintf ("\u0025\u0073\u0021\u0025\u0073\u003a\u0025\u0073",_ddfgc ._bcdd .String (),_ddfgc ._dfedb .String (),_ddfgc ._bebbg .String ());};
 
 // NewPrefixHorizontalRange constructs a new full rows range with prefix.
-func NewPrefixHorizontalRange (pfx Expression ,v string )Expression {_cfbg :=_ecg .Split (v ,"\u003a");if len (_cfbg )!=2{return nil ;};_acbedg ,_ :=_bb .Atoi (_cfbg [0]);_gbfbe ,_ :=_bb .Atoi (_cfbg [1]);if _acbedg > _gbfbe {_acbedg ,_gbfbe =_gbfbe ,_acbedg ;
-};return PrefixHorizontalRange {_efaa :pfx ,_fceca :_acbedg ,_fegg :_gbfbe };};func _afc (_ace ,_ddfb ,_cegf int )float64 {return float64 (_cbe (_ace ,_a .Month (_ddfb ),_cegf )/86400)+_eeg ;};
+func NewPrefixHorizontalRange (pfx Expression ,v string )Expression {_cfbg :=_ecg .Split (v ,"\u003a");if len (_cfbg )!=2{return nil ;};_acbedg ,_fcbeg :=parseRowBound (_cfbg [0]);_gbfbe ,_ebgce :=parseRowBound (_cfbg [1]);if _acbedg > _gbfbe {_acbedg ,_gbfbe =_gbfbe ,_acbedg ;_fcbeg ,_ebgce =_ebgce ,_fcbeg ;};return PrefixHorizontalRange {_efaa :pfx ,_fceca :_acbedg ,_fegg :_gbfbe ,_fcbeg :_fcbeg ,_ebgce :_ebgce };};func _afc (_ace ,_ddfb ,_cegf int )float64 {return float64 (_cbe (_ace ,_a .Month (_ddfb ),_cegf )/86400)+_eeg ;};
 
 // Large implements the Excel LARGE function.
 func Large (args []Result )Result {return _aeee (args ,true )};func _aeaefa (_fdd yyLexer )int {return _dcbe ().Parse (_fdd )};
 
 // NewNamedRangeRef constructs a new named range reference.
-func NewNamedRangeRef (v string )Expression {return NamedRangeRef {_dcdga :v }};const _gcfcgf int =30;func (_gedbc *Lexer )emit (_ffcd tokenType ,_deecc []byte ){if _fbgg {_g .Println ("\u0065\u006d\u0069\u0074",_ffcd ,_afbc (string (_deecc )));};_gedbc ._gdfdb <-&node {_ffcd ,string (_deecc )};
+func NewNamedRangeRef (v string )Expression {return NamedRangeRef {_dcdga :v }};const _gcfcgf int =30;func (_gedbc *Lexer )emit (_ffcd tokenType ,_deecc []byte ){if _fbgg {_g .Println ("\u0065\u006d\u0069\u0074",_ffcd ,_afbc (string (_deecc )));};_gedbc ._gdfdb <-&node {_ffcd ,lexedToken (_deecc )};
 };
 
 // Multinomial implements the excel MULTINOMIAL function.
@@ -885,14 +882,14 @@ func Multinomial (args []Result )Result {if len (args )< 1{return MakeErrorResul
 };_dbcdg ,_babd ,_fabg :=_bdee (args );if _fabg .Type ==ResultTypeError {return _fabg ;};return MakeNumberResult (_gfce (_dbcdg )/_babd );};
 
 // PrefixHorizontalRange is a range expression that when evaluated returns a list of Results from references like Sheet1!1:4 (all cells from rows 1 to 4 of sheet 'Sheet1').
-type PrefixHorizontalRange struct{_efaa Expression ;_fceca ,_fegg int ;};
+type PrefixHorizontalRange struct{_efaa Expression ;_fceca ,_fegg int ;_fcbeg ,_ebgce bool ;};
 
 // MinA is an implementation of the Excel MINA() function.
 func MinA (args []Result )Result {return _ebfac (args ,true )};
 
 // Update updates references in the Negate after removing a row/column.
 func (_aeebg Negate )Update (q *_cc .UpdateQuery )Expression {return Negate {_ebadf :_aeebg ._ebadf .Update (q )};};func _dbbd (_ceeb ,_abbgf ,_dadaa Reference )string {return _g .Sprintf ("\u0025\u0073\u0021\u0025\u0073\u003a\u0025\u0073",_ceeb .Value ,_abbgf .Value ,_dadaa .Value );
-};type amorArgs struct{_cad float64 ;_aff float64 ;_gcgfa float64 ;_ddfcd float64 ;_abc int ;_fegad float64 ;_cafd int ;};const (ErrorTypeValue ErrorType =iota ;ErrorTypeNull ;ErrorTypeRef ;ErrorTypeName ;ErrorTypeNum ;ErrorTypeSpill ;ErrorTypeNA ;ErrorTypeDivideByZero ;
+};type amorArgs struct{_cad float64 ;_aff float64 ;_gcgfa float64 ;_ddfcd float64 ;_abc int ;_fegad float64 ;_cafd int ;};const (ErrorTypeValue ErrorType =iota ;ErrorTypeNull ;ErrorTypeRef ;ErrorTypeName ;ErrorTypeNum ;ErrorTypeSpill ;ErrorTypeNA ;ErrorTypeDivideByZero ;ErrorTypeCalc ;
 );func _babed (_ddfe [][]Result )float64 {if len (_ddfe )==2{_bagd :=_ddfe [0][0].AsNumber ();_dbda :=_ddfe [0][1].AsNumber ();_cbga :=_ddfe [1][0].AsNumber ();_decd :=_ddfe [1][1].AsNumber ();if _bagd .Type !=ResultTypeNumber ||_dbda .Type !=ResultTypeNumber ||_cbga .Type !=ResultTypeNumber ||_decd .Type !=ResultTypeNumber {return _fg .NaN ();
 };return _bagd .ValueNumber *_decd .ValueNumber -_cbga .ValueNumber *_dbda .ValueNumber ;};_fegd :=float64 (0);_fdae :=float64 (1);for _cbcfb :=range _ddfe {_fegd +=_fdae *_ddfe [0][_cbcfb ].ValueNumber *_babed (_cbgf (_ddfe ,_cbcfb ));_fdae *=-1;};return _fegd ;
 };
@@ -1049,14 +1046,14 @@ func Intrate (args []Result )Result {_eeagg :=len (args );if _eeagg !=4&&_eeagg
 };};_baaf ,_fgaa :=_gfac (_ebed ,_cdeg ,_dgcf );if _fgaa .Type ==ResultTypeError {return _fgaa ;};return MakeNumberResult ((_bedcc -_bedc )/_bedc /_baaf );};
 
 // String returns an empty string for Error.
-func (_cfe Error )String ()string {return ""};func _bfcb (_fbaa []Result ,_eeag string )(float64 ,float64 ,Result ){if len (_fbaa )!=2{return 0,0,MakeErrorResult (_eeag +"\u0020\u0072\u0065qu\u0069\u0072\u0065\u0073\u0020\u0074\u0077\u006f\u0020\u0061\u0072\u0067\u0075\u006d\u0065\u006e\u0074\u0073");
+func (_cfe Error )String ()string {return _cfe ._fge };func _bfcb (_fbaa []Result ,_eeag string )(float64 ,float64 ,Result ){if len (_fbaa )!=2{return 0,0,MakeErrorResult (_eeag +"\u0020\u0072\u0065qu\u0069\u0072\u0065\u0073\u0020\u0074\u0077\u006f\u0020\u0061\u0072\u0067\u0075\u006d\u0065\u006e\u0074\u0073");
 };if _fbaa [0].Type !=ResultTypeNumber {return 0,0,MakeErrorResult (_eeag +"\u0020\u0072\u0065\u0071\u0075\u0069r\u0065\u0073\u0020\u0066\u0072\u0061\u0063\u0074\u0069\u006f\u006e\u0061\u006c\u0020\u0064\u006f\u006c\u006c\u0061\u0072 \u0074\u006f\u0020\u0062\u0065\u0020\u006e\u0075\u006d\u0062\u0065\u0072\u0020\u0061r\u0067u\u006d\u0065\u006e\u0074");
 };_gbe :=_fbaa [0].ValueNumber ;if _fbaa [1].Type !=ResultTypeNumber {return 0,0,MakeErrorResult (_eeag +" \u0072\u0065\u0071\u0075\u0069\u0072\u0065\u0073\u0020\u0066\u0072\u0061\u0063\u0074\u0069\u006f\u006e\u0020t\u006f\u0020\u0062\u0065\u0020\u006e\u0075\u006d\u0062\u0065r \u0061\u0072\u0067u\u006de\u006e\u0074");
 };_beede :=float64 (int (_fbaa [1].ValueNumber ));if _beede < 0{return 0,0,MakeErrorResultType (ErrorTypeNum ,_eeag +"\u0020r\u0065\u0071u\u0069\u0072\u0065\u0073 \u0066\u0072\u0061c\u0074\u0069\u006f\u006e\u0020\u0074\u006f\u0020\u0062e \u006e\u006f\u006e \u006e\u0065g\u0061\u0074\u0069\u0076\u0065\u0020n\u0075\u006db\u0065\u0072");
 };return _gbe ,_beede ,_eege ;};
 
 // Reference returns a string reference value to a named range.
-func (_ddcc NamedRangeRef )Reference (ctx Context ,ev Evaluator )Reference {return Reference {Type :ReferenceTypeNamedRange ,Value :_ddcc ._dcdga };};
+func (_ddcc NamedRangeRef )Reference (ctx Context ,ev Evaluator )Reference {if _gcae ,_dbfg :=localValue (ctx ,_ddcc ._dcdga );_dbfg {return _gcae .Ref ;};return Reference {Type :ReferenceTypeNamedRange ,Value :_ddcc ._dcdga };};
 
 // MDeterm is an implementation of the Excel MDETERM which finds the determinant
 // of a matrix.
@@ -1097,7 +1094,7 @@ _egef :=_ecec .AddDate (0,int (_bfbd +1),0);_gbgb ,_ggg ,_ :=_egef .Date ();_ege
 type String struct{_defad string };
 
 // Parse parses a string to get an Expression.
-func ParseString (s string )Expression {if s ==""{return NewEmptyExpr ();};return Parse (_ecg .NewReader (s ));};
+func ParseString (s string )Expression {if s ==""{return NewEmptyExpr ();};return Parse (_ecg .NewReader (lexableFormula (s )));};
 
 // Update returns the same object as updating sheet references does not affect String.
 func (_aecgg String )Update (q *_cc .UpdateQuery )Expression {return _aecgg };
@@ -1142,7 +1139,7 @@ func Nper (args []Result )Result {_gbaab :=len (args );if _gbaab < 3||_gbaab > 5
 };_ffb =args [4].ValueNumber ;if _ffb !=0{_ffb =1;};};_ddbc :=_efcg *(1+_ecga *_ffb )-_cgbbg *_ecga ;_gbdfc :=(_eefb *_ecga +_efcg *(1+_ecga *_ffb ));return MakeNumberResult (_fg .Log (_ddbc /_gbdfc )/_fg .Log (1+_ecga ));};const _ceab =57363;
 
 // Eval evaluates and returns the result of a function call.
-func (_aeeac FunctionCall )Eval (ctx Context ,ev Evaluator )Result {_cdgd :=LookupFunction (_aeeac ._ddea );if _cdgd !=nil {_accc :=make ([]Result ,len (_aeeac ._ddaa ));for _eedf ,_fdcd :=range _aeeac ._ddaa {_accc [_eedf ]=_fdcd .Eval (ctx ,ev );_accc [_eedf ].Ref =_fdcd .Reference (ctx ,ev );
+func (_aeeac FunctionCall )Eval (ctx Context ,ev Evaluator )Result {if _fgbe ,_cbfa :=evalLambdaCall (ctx ,ev ,_aeeac ._ddea ,_aeeac ._ddaa );_cbfa {return _fgbe ;};_cdgd :=LookupFunction (_aeeac ._ddea );if _cdgd !=nil {_accc :=make ([]Result ,len (_aeeac ._ddaa ));for _eedf ,_fdcd :=range _aeeac ._ddaa {_accc [_eedf ]=_fdcd .Eval (ctx ,ev );_accc [_eedf ].Ref =_fdcd .Reference (ctx ,ev );
 };if _ ,_eadb :=_agdeb [_aeeac ._ddea ];!_eadb {if _gfbgg ,_agfe :=_cgcge (_accc );_gfbgg {return _agfe ;};};return _cdgd (_accc );};_dcdbd :=LookupFunctionComplex (_aeeac ._ddea );if _dcdbd !=nil {_eddba :=make ([]Result ,len (_aeeac ._ddaa ));for _egac ,_dbfe :=range _aeeac ._ddaa {_eddba [_egac ]=_dbfe .Eval (ctx ,ev );
 _eddba [_egac ].Ref =_dbfe .Reference (ctx ,ev );};if _ ,_fcef :=_agdeb [_aeeac ._ddea ];!_fcef {if _bdabb ,_ffbg :=_cgcge (_eddba );_bdabb {return _ffbg ;};};return _dcdbd (ctx ,ev ,_eddba );};return MakeErrorResult ("\u0075\u006e\u006b\u006e\u006f\u0077\u006e\u0020\u0066\u0075\u006e\u0063t\u0069\u006f\u006e\u0020"+_aeeac ._ddea );
 };func _gfac (_gcb ,_gd float64 ,_babcb int )(float64 ,Result ){_edec ,_fdb :=_dcd (_gcb ),_dcd (_gd );_gaac :=_edec .Unix ();_dcdg :=_fdb .Unix ();if _gaac ==_dcdg {return 0,_eege ;};_fega ,_bbd ,_ddca :=_edec .Date ();_bdc ,_eag ,_fab :=_fdb .Date ();
@@ -1256,7 +1253,7 @@ RegisterFunction ("\u004d\u0049\u004e\u0041",MinA );RegisterFunction ("\u004d\u0
 // debug message
 func MakeErrorResultType (t ErrorType ,msg string )Result {switch t {case ErrorTypeNull :return Result {Type :ResultTypeError ,ValueString :"\u0023\u004e\u0055\u004c\u004c\u0021",ErrorMessage :msg };case ErrorTypeValue :return Result {Type :ResultTypeError ,ValueString :"\u0023V\u0041\u004c\u0055\u0045\u0021",ErrorMessage :msg };
 case ErrorTypeRef :return Result {Type :ResultTypeError ,ValueString :"\u0023\u0052\u0045F\u0021",ErrorMessage :msg };case ErrorTypeName :return Result {Type :ResultTypeError ,ValueString :"\u0023\u004e\u0041\u004d\u0045\u003f",ErrorMessage :msg };case ErrorTypeNum :return Result {Type :ResultTypeError ,ValueString :"\u0023\u004e\u0055M\u0021",ErrorMessage :msg };
-case ErrorTypeSpill :return Result {Type :ResultTypeError ,ValueString :"\u0023S\u0050\u0049\u004c\u004c\u0021",ErrorMessage :msg };case ErrorTypeNA :return Result {Type :ResultTypeError ,ValueString :"\u0023\u004e\u002f\u0041",ErrorMessage :msg };case ErrorTypeDivideByZero :return Result {Type :ResultTypeError ,ValueString :"\u0023D\u0049\u0056\u002f\u0030\u0021",ErrorMessage :msg };
+case ErrorTypeSpill :return Result {Type :ResultTypeError ,ValueString :"\u0023S\u0050\u0049\u004c\u004c\u0021",ErrorMessage :msg };case ErrorTypeNA :return Result {Type :ResultTypeError ,ValueString :"\u0023\u004e\u002f\u0041",ErrorMessage :msg };case ErrorTypeDivideByZero :return Result {Type :ResultTypeError ,ValueString :"\u0023D\u0049\u0056\u002f\u0030\u0021",ErrorMessage :msg };case ErrorTypeCalc :return Result {Type :ResultTypeError ,ValueString :"\u0023\u0043A\u004c\u0043\u0021",ErrorMessage :msg };
 default:return Result {Type :ResultTypeError ,ValueString :"\u0023V\u0041\u004c\u0055\u0045\u0021",ErrorMessage :msg };};};
 
 // String returns a string representation of a named range.
@@ -1274,7 +1271,7 @@ func SumIf (args []Result )Result {if len (args )< 3{return MakeErrorResult ("\u
 _gafgg :=args [0];if _gafgg .Type !=ResultTypeArray &&_gafgg .Type !=ResultTypeList {return MakeErrorResult ("\u0053\u0055\u004d\u0049\u0046\u0020\u0072e\u0071\u0075\u0069r\u0065\u0073\u0020\u0066i\u0072\u0073\u0074\u0020\u0061\u0072\u0067\u0075\u006d\u0065\u006e\u0074\u0020\u006f\u0066\u0020\u0074\u0079\u0070\u0065\u0020\u0061\u0072\u0072\u0061\u0079");
 };_fdaaa :=_dgdcg (_gafgg );_fdfd :=args [2];if _fdfd .Type !=ResultTypeArray &&_fdfd .Type !=ResultTypeList {return MakeErrorResult ("\u0053\u0055\u004dI\u0046\u0020\u0072\u0065\u0071\u0075\u0069\u0072\u0065\u0073\u0020\u006c\u0061\u0073\u0074\u0020\u0061\u0072\u0067\u0075\u006d\u0065\u006e\u0074\u0020\u006f\u0066\u0020\u0074y\u0070\u0065\u0020\u0061\u0072\u0072\u0061\u0079");
 };_effe :=_dgdcg (_fdfd );_dddfe :=_ffbb (args [1]);_cfcba :=0.0;for _gdbbf ,_fcaddc :=range _fdaaa {for _gdbfa ,_afcde :=range _fcaddc {if _eebdg (_afcde ,_dddfe ){_cfcba +=_effe [_gdbbf ][_gdbfa ].ValueNumber ;};};};return MakeNumberResult (_cfcba );
-};var (_adcad =0;_edbdd =false ;);
+};var (_adcad =0;_edbdd =true ;);
 
 // Degrees is an implementation of the Excel function DEGREES() that converts
 // radians to degrees.
@@ -1328,7 +1325,7 @@ if _dgcd .Type !=ResultTypeNumber {return _dgcd ;};_deaff .ValueNumber +=_dgcd .
 };};return _deaff ;};var _eda =[]*_cg .Regexp {};
 
 // Eval evaluates and returns the result of the cell reference.
-func (_aca CellRef )Eval (ctx Context ,ev Evaluator )Result {return ctx .Cell (_aca ._gge ,ev )};func _cgcge (_adcf []Result )(bool ,Result ){for _ ,_ccfdbe :=range _adcf {if _ccfdbe .Type ==ResultTypeError {return true ,_ccfdbe ;};};return false ,MakeEmptyResult ();
+func (_aca CellRef )Eval (ctx Context ,ev Evaluator )Result {return ctx .Cell (_aca ._gge ,ev )};func _cgcge (_adcf []Result )(bool ,Result ){for _ ,_ccfdbe :=range _adcf {if _ccfdbe .Type ==ResultTypeError &&_ccfdbe .Lambda ==nil {return true ,_ccfdbe ;};};return false ,MakeEmptyResult ();
 };
 
 // NewEmptyExpr constructs a new empty expression.
@@ -1446,7 +1443,7 @@ if _cebc !=nil {return 0,0,0,false ,false ,MakeErrorResultType (ErrorTypeValue ,
 };return _ccg ,_efc ,_cbb ,_defa ,_dcc ,_eege ;};
 
 // Eval evaluates and returns the result of a formula.
-func (_efg *defEval )Eval (ctx Context ,formula string )Result {_ggf :=ParseString (formula );_ddc :=make (chan Result );go func (){if _ggf ==nil {_ddc <-MakeErrorResult (_g .Sprintf ("\u0075\u006e\u0061\u0062\u006c\u0065\u0020\u0074\u006f\u0020\u0070a\u0072\u0073\u0065\u0020\u0066\u006f\u0072\u006d\u0075\u006ca\u0020\u0025\u0073",formula ));
+func (_efg *defEval )Eval (ctx Context ,formula string )Result {_ggf :=ParseString (expandStructuredReferences (ctx ,formula ));_ddc :=make (chan Result );go func (){if _ggf ==nil {_ddc <-MakeErrorResult (_g .Sprintf ("\u0075\u006e\u0061\u0062\u006c\u0065\u0020\u0074\u006f\u0020\u0070a\u0072\u0073\u0065\u0020\u0066\u006f\u0072\u006d\u0075\u006ca\u0020\u0025\u0073",formula ));
 }else {_efg .checkLastEvalIsRef (ctx ,_ggf );_ddc <-_ggf .Eval (ctx ,_efg );};}();select{case _egg :=<-_ddc :return _egg ;case <-_a .After (_fb ):_eg .Log .Debug ("\u0055\u006e\u0069\u004ff\u0066\u0069\u0063\u0065\u0020\u0065\u0076\u0061\u006c\u0075a\u0074i\u006f\u006e\u0020\u0074\u0069\u006d\u0065o\u0075\u0074");
 return MakeNumberResult (0);};};
 
@@ -1743,7 +1740,7 @@ func LookupFunctionComplex (name string )FunctionComplex {_aaded .Lock ();defer
 func (_dddf EmptyExpr )Reference (ctx Context ,ev Evaluator )Reference {return ReferenceInvalid };
 
 // String returns a string representation of a horizontal range with prefix.
-func (_febdb PrefixHorizontalRange )String ()string {return _g .Sprintf ("\u0025\u0073\u0021\u0025\u0064\u003a\u0025\u0064",_febdb ._efaa .String (),_febdb ._fceca ,_febdb ._fegg );};
+func (_febdb PrefixHorizontalRange )String ()string {return _g .Sprintf ("\u0025\u0073\u0021\u0025\u0073\u003a\u0025\u0073",_febdb ._efaa .String (),formatRowBound (_febdb ._fceca ,_febdb ._fcbeg ),formatRowBound (_febdb ._fegg ,_febdb ._ebgce ));};
 
 // Amordegrc implements the Excel AMORDEGRC function.
 func Amordegrc (args []Result )Result {_eaea ,_aefb :=_gdd (args ,"\u0041M\u004f\u0052\u0044\u0045\u0047\u0052C");if _aefb .Type ==ResultTypeError {return _aefb ;};_bafe :=_eaea ._cad ;_cgga :=_eaea ._aff ;_cfc :=_eaea ._gcgfa ;_cdcg :=_eaea ._ddfcd ;_cbcc :=_eaea ._abc ;
@@ -1833,7 +1830,7 @@ NamedRange (_bc string )Reference ;
 SetOffset (_gag ,_ggd uint32 );};
 
 // Update updates references in the Range after removing a row/column.
-func (_acafc Range )Update (q *_cc .UpdateQuery )Expression {_bfdbe :=_acafc ;if q .UpdateCurrentSheet {_bfdbe ._agbg =_acafc ._agbg .Update (q );_bfdbe ._eaebg =_acafc ._eaebg .Update (q );};return _bfdbe ;};const _dag ="\u0028\u0028\u005b\u0030\u002d\u0039]\u0029\u002b\u0029:\u0028\u0028\u005b0\u002d\u0039\u005d\u0029\u002b\u0029\u003a\u0028\u0028\u005b0\u002d\u0039\u005d\u0029\u002b(\\\u002e\u0028\u005b\u0030\u002d\u0039\u005d\u0029\u002b\u0029\u003f\u0029\u0028\u0020\u0028\u0061\u006d\u007c\u0070\u006d\u0029\u0029\u003f";
+func (_acafc Range )Update (q *_cc .UpdateQuery )Expression {return updateRange (_acafc ,q );};const _dag ="\u0028\u0028\u005b\u0030\u002d\u0039]\u0029\u002b\u0029:\u0028\u0028\u005b0\u002d\u0039\u005d\u0029\u002b\u0029\u003a\u0028\u0028\u005b0\u002d\u0039\u005d\u0029\u002b(\\\u002e\u0028\u005b\u0030\u002d\u0039\u005d\u0029\u002b\u0029\u003f\u0029\u0028\u0020\u0028\u0061\u006d\u007c\u0070\u006d\u0029\u0029\u003f";
 const _bafa =57355;
 
 // NewPrefixExpr constructs an expression with prefix.
@@ -1860,10 +1857,10 @@ _gfbae :=args [1].AsNumber ();if _dgga .Type !=ResultTypeNumber ||_gfbae .Type !
 };return _cebd [_ba [_af ]:_ba [_af +1]];};var _ba =[...]uint8 {0,16,29,43,56,68,80,91,102,113,125,137,148,163};
 
 // Update makes a reference to point to one of the neighboring cells after removing a row/column with respect to the update type.
-func (_cec CellRef )Update (q *_cc .UpdateQuery )Expression {if q .UpdateCurrentSheet {_cec ._gge =_bgf (_cec ._gge ,q );};return _cec ;};
+func (_cec CellRef )Update (q *_cc .UpdateQuery )Expression {return updateCellRef (_cec ,q );};
 
 // Update updates references in the PrefixHorizontalRange after removing a row/column.
-func (_bbgdb PrefixHorizontalRange )Update (q *_cc .UpdateQuery )Expression {return _bbgdb };
+func (_bbgdb PrefixHorizontalRange )Update (q *_cc .UpdateQuery )Expression {return updatePrefixHorizontalRange (_bbgdb ,q );};
 
 // Day is an implementation of the Excel DAY() function.
 func Day (args []Result )Result {if len (args )!=1{return MakeErrorResult ("\u0044A\u0059\u0020\u0072\u0065q\u0075\u0069\u0072\u0065\u0073 \u006fn\u0065 \u0061\u0072\u0067\u0075\u006d\u0065\u006et");};_ede :=args [0];switch _ede .Type {case ResultTypeEmpty :return MakeNumberResult (0);
@@ -1918,8 +1915,7 @@ func Code (args []Result )Result {if len (args )!=1{return MakeErrorResult ("\u0
 };if len (_gbeg .ValueString )==0{return MakeNumberResult (0);};return MakeNumberResult (float64 (_gbeg .ValueString [0]));};
 
 // Update updates references in the PrefixRangeExpr after removing a row/column.
-func (_daggd PrefixRangeExpr )Update (q *_cc .UpdateQuery )Expression {_abfd :=_daggd ;_cccad :=_daggd ._bcdd .String ();if _cccad ==q .SheetToUpdate {_fbdeg :=*q ;_fbdeg .UpdateCurrentSheet =true ;_abfd ._dfedb =_daggd ._dfedb .Update (&_fbdeg );_abfd ._bebbg =_daggd ._bebbg .Update (&_fbdeg );
-};return _abfd ;};
+func (_daggd PrefixRangeExpr )Update (q *_cc .UpdateQuery )Expression {return updatePrefixRangeExpr (_daggd ,q );};
 
 // RoundDown is an implementation of the Excel ROUNDDOWN function that rounds a number
 // down to a specified number of digits.
@@ -2071,7 +2067,7 @@ case BinOpTypeGT :_gab ="\u003e";case BinOpTypeEQ :_gab ="\u003d";case BinOpType
 };const _edeg ="\u0049\u006e\u0063\u006f\u0072\u0072\u0065\u0063\u0074\u0020\u0061\u0072\u0067\u0075\u006de\u006et\u0020\u0066\u006f\u0072\u0020\u0054\u0049\u004d\u0045\u0056\u0041\u004c\u0055\u0045";
 
 // NewHorizontalRange constructs a new full rows range.
-func NewHorizontalRange (v string )Expression {_cdbga :=_ecg .Split (v ,"\u003a");if len (_cdbga )!=2{return nil ;};_adedg ,_ :=_bb .Atoi (_cdbga [0]);_bdeba ,_ :=_bb .Atoi (_cdbga [1]);if _adedg > _bdeba {_adedg ,_bdeba =_bdeba ,_adedg ;};return HorizontalRange {_gcabd :_adedg ,_gfbga :_bdeba };
+func NewHorizontalRange (v string )Expression {_cdbga :=_ecg .Split (v ,"\u003a");if len (_cdbga )!=2{return nil ;};_adedg ,_cgfeb :=parseRowBound (_cdbga [0]);_bdeba ,_aadbf :=parseRowBound (_cdbga [1]);if _adedg > _bdeba {_adedg ,_bdeba =_bdeba ,_adedg ;_cgfeb ,_aadbf =_aadbf ,_cgfeb ;};return HorizontalRange {_gcabd :_adedg ,_gfbga :_bdeba ,_cgfeb :_cgfeb ,_aadbf :_aadbf };
 };func _agae (_bdeea string )*criteriaRegex {_faabe :=&criteriaRegex {};if _bdeea ==""{return _faabe ;};if _bfeg :=_cdgg .FindStringSubmatch (_bdeea );len (_bfeg )> 1{_faabe ._agdf =_fagd ;_faabe ._abee =_bfeg [1];}else if _aggfa :=_ecfc .FindStringSubmatch (_bdeea );
 len (_aggfa )> 1{_faabe ._agdf =_fagd ;_faabe ._abee =_aggfa [1];}else if _bbgcb :=_dfeeb .FindStringSubmatch (_bdeea );len (_bbgcb )> 1{_faabe ._agdf =_bfdde ;_faabe ._abee =_bbgcb [1];}else if _fdbfc :=_cfdd .FindStringSubmatch (_bdeea );len (_fdbfc )> 1{_faabe ._agdf =_abfe ;
 _faabe ._abee =_fdbfc [1];}else if _gedf :=_aagc .FindStringSubmatch (_bdeea );len (_gedf )> 1{_faabe ._agdf =_bacge ;_faabe ._abee =_gedf [1];}else if _fabfc :=_debgd .FindStringSubmatch (_bdeea );len (_fabfc )> 1{_faabe ._agdf =_aeded ;_faabe ._abee =_fabfc [1];
@@ -2201,7 +2197,7 @@ func Pricedisc (args []Result )Result {_dbgd :=len (args );if _dbgd !=4&&_dbgd !
 
 
 // HorizontalRange is a range expression that when evaluated returns a list of Results from references like 1:4 (all cells from rows 1 to 4).
-type HorizontalRange struct{_gcabd ,_gfbga int };
+type HorizontalRange struct{_gcabd ,_gfbga int ;_cgfeb ,_aadbf bool ;};
 
 // String returns a string representation of a vertical range with prefix.
 func (_ecdb PrefixVerticalRange )String ()string {return _g .Sprintf ("\u0025\u0073\u0021\u0025\u0073\u003a\u0025\u0073",_ecdb ._decac .String (),_ecdb ._bddaf ,_ecdb ._cfbe );};
@@ -2340,8 +2336,7 @@ func NewPrefixRangeExpr (pfx ,from ,to Expression )Expression {_cddab ,_gebb ,_f
 };if _efbc > _fgda {_acge =true ;break ;};};if _acge ||_fg .IsNaN (_dgaa )||_fg .IsInf (_dgaa ,0){return MakeErrorResultType (ErrorTypeNum ,"");};return MakeNumberResult (_dgaa );};
 
 // Update updates references in the PrefixExpr after removing a row/column.
-func (_gccac PrefixExpr )Update (q *_cc .UpdateQuery )Expression {_ffgbg :=_gccac ;_egacd :=_gccac ._fdfge .String ();if _egacd ==q .SheetToUpdate {_fcaee :=*q ;_fcaee .UpdateCurrentSheet =true ;_ffgbg ._fecb =_gccac ._fecb .Update (&_fcaee );};return _ffgbg ;
-};
+func (_gccac PrefixExpr )Update (q *_cc .UpdateQuery )Expression {return updatePrefixExpr (_gccac ,q );};
 
 // Eval evaluates and returns the result of an error expression.
 func (_fad Error )Eval (ctx Context ,ev Evaluator )Result {return MakeErrorResult (_fad ._fge )};const _bcadf =57344;func _dcce (_ddba float64 ,_cecg *criteriaRegex )bool {_dddg ,_bdefg :=_bb .ParseFloat (_cecg ._abee ,64);if _bdefg !=nil {return false ;
@@ -2431,7 +2426,7 @@ func MakeArrayResult (arr [][]Result )Result {return Result {Type :ResultTypeArr
 _gbaag ++{_gcfc :=365;if _aedd (_gbaag ){_gcfc =366;};_abf +=_gcfc ;};return _abf ;};
 
 // Result is the result of a formula or cell evaluation .
-type Result struct{ValueNumber float64 ;ValueString string ;ValueList []Result ;ValueArray [][]Result ;IsBoolean bool ;ErrorMessage string ;Type ResultType ;Ref Reference ;};func _aa (_ea BinOpType ,_de ,_aaf [][]Result )Result {_gc :=[][]Result {};for _ebf :=range _de {_bf :=_ca (_ea ,_de [_ebf ],_aaf [_ebf ]);
+type Result struct{ValueNumber float64 ;ValueString string ;ValueList []Result ;ValueArray [][]Result ;IsBoolean bool ;ErrorMessage string ;Type ResultType ;Ref Reference ;Lambda *Lambda ;};func _aa (_ea BinOpType ,_de ,_aaf [][]Result )Result {_gc :=[][]Result {};for _ebf :=range _de {_bf :=_ca (_ea ,_de [_ebf ],_aaf [_ebf ]);
 if _bf .Type ==ResultTypeError {return _bf ;};_gc =append (_gc ,_bf .ValueList );};return MakeArrayResult (_gc );};
 
 // NewVerticalRange constructs a new full columns range.
@@ -2469,7 +2464,7 @@ func IsNumber (args []Result )Result {if len (args )!=1{return MakeErrorResult (
 // Concat is an implementation of the Excel CONCAT() and deprecated CONCATENATE() function.
 func Concat (args []Result )Result {_acgf :=_b .Buffer {};for _ ,_adab :=range args {switch _adab .Type {case ResultTypeString :_acgf .WriteString (_adab .ValueString );case ResultTypeNumber :var _geea string ;if _adab .IsBoolean {if _adab .ValueNumber ==0{_geea ="\u0046\u0041\u004cS\u0045";
 }else {_geea ="\u0054\u0052\u0055\u0045";};}else {_geea =_adab .AsString ().ValueString ;};_acgf .WriteString (_geea );default:return MakeErrorResult ("\u0043\u004f\u004e\u0043\u0041T\u0028\u0029\u0020\u0072\u0065\u0071\u0075\u0069\u0072\u0065\u0073\u0020\u0061r\u0067\u0075\u006d\u0065\u006e\u0074\u0073\u0020\u0074\u006f\u0020\u0062\u0065\u0020\u0073\u0074\u0072\u0069\u006e\u0067\u0073");
-};};return MakeStringResult (_acgf .String ());};func (_gebge *plex )Lex (lval *yySymType )int {_edbdd =true ;_cagg :=<-_gebge ._gffa ;if _cagg !=nil {lval ._dfdf =_cagg ;return int (lval ._dfdf ._cgbf );};return 0;};
+};};return MakeStringResult (_acgf .String ());};func (_gebge *plex )Lex (lval *yySymType )int {_cagg :=<-_gebge ._gffa ;if _cagg !=nil {lval ._dfdf =_cagg ;return int (lval ._dfdf ._cgbf );};return 0;};
 
 // Eval evaluates and returns the result of a Negate expression.
 func (_fegbb Negate )Eval (ctx Context ,ev Evaluator )Result {_gebc :=_fegbb ._ebadf .Eval (ctx ,ev );if _gebc .Type ==ResultTypeNumber {return MakeNumberResult (-_gebc .ValueNumber );};return MakeErrorResult ("\u004e\u0045\u0047A\u0054\u0045\u0020\u0065x\u0070\u0065\u0063\u0074\u0065\u0064\u0020n\u0075\u006d\u0062\u0065\u0072\u0020\u0061\u0072\u0067\u0075\u006d\u0065\u006e\u0074");
@@ -0,0 +1,500 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	RegisterFunction("_xlfn.MAP", Map)
+	RegisterFunction("_xlfn.REDUCE", Reduce)
+	RegisterFunction("_xlfn.SCAN", Scan)
+	RegisterFunction("_xlfn.BYROW", ByRow)
+	RegisterFunction("_xlfn.BYCOL", ByCol)
+	RegisterFunction("_xlfn.MAKEARRAY", MakeArray)
+}
+
+// lambdaForms are the functions whose arguments aren't evaluated before the
+// call, as they declare names.
+var lambdaForms = map[string]bool{
+	"_xlfn.LET":    true,
+	"_xlfn.LAMBDA": true,
+}
+
+// maxLambdaDepth bounds the nesting of lambda calls, reached by recursive
+// lambdas that don't end.
+const maxLambdaDepth = 1024
+
+// localParameterPrefix is the prefix of the names declared by LET and LAMBDA
+// in stored formulas.
+const localParameterPrefix = "_xlpm."
+
+// Lambda is a function defined by LAMBDA, the value of LAMBDA formulas and of
+// the names bound to them by LET or defined in the workbook. Functions such as
+// MAP receive lambdas as arguments, the results holding them being #CALC!
+// errors for the other functions.
+type Lambda struct {
+	params []string
+	body   Expression
+	ctx    Context
+	ev     Evaluator
+	depth  int
+}
+
+// Parameters returns the number of arguments the lambda requires.
+func (l *Lambda) Parameters() int {
+	return len(l.params)
+}
+
+// Call evaluates the lambda with one argument per parameter, in the context it
+// was defined in.
+func (l *Lambda) Call(args ...Result) Result {
+	if len(args) != len(l.params) {
+		return MakeErrorResultType(ErrorTypeValue, fmt.Sprintf("LAMBDA requires %d arguments, got %d", len(l.params), len(args)))
+	}
+	if l.depth >= maxLambdaDepth {
+		return MakeErrorResultType(ErrorTypeNum, "LAMBDA calls nested too deeply")
+	}
+	scope := &lambdaScope{Context: l.ctx, names: make(map[string]Result, len(args)), depth: l.depth + 1}
+	for i, p := range l.params {
+		scope.names[p] = args[i]
+	}
+	return l.body.Eval(scope, l.ev)
+}
+
+func lambdaResult(l *Lambda) Result {
+	r := MakeErrorResultType(ErrorTypeCalc, "LAMBDA must be called")
+	r.Lambda = l
+	return r
+}
+
+// lambdaScope is the context in which LET and the body of lambdas are
+// evaluated, binding the names they declare.
+type lambdaScope struct {
+	Context
+	names map[string]Result
+	depth int
+}
+
+func (s *lambdaScope) lookup(name string) (Result, bool) {
+	key := localName(name)
+	for sc := s; sc != nil; sc, _ = sc.Context.(*lambdaScope) {
+		if v, ok := sc.names[key]; ok {
+			return v, true
+		}
+	}
+	return Result{}, false
+}
+
+// Tables implements TableContext for the contexts that do.
+func (s *lambdaScope) Tables() []TableDefinition {
+	if tc, ok := s.Context.(TableContext); ok {
+		return tc.Tables()
+	}
+	return nil
+}
+
+// CurrentCell implements TableContext for the contexts that do.
+func (s *lambdaScope) CurrentCell() (sheet, cell string) {
+	if tc, ok := s.Context.(TableContext); ok {
+		return tc.CurrentCell()
+	}
+	return "", ""
+}
+
+// SpillRange implements SpillContext for the contexts that do.
+func (s *lambdaScope) SpillRange(cell string) (string, bool) {
+	if sc, ok := s.Context.(SpillContext); ok {
+		return sc.SpillRange(cell)
+	}
+	return "", false
+}
+
+// localName returns the key of a name declared by LET or LAMBDA, names being
+// case insensitive.
+func localName(name string) string {
+	upper := strings.ToUpper(name)
+	return strings.TrimPrefix(upper, strings.ToUpper(localParameterPrefix))
+}
+
+func isLocalName(name string) bool {
+	return strings.HasPrefix(strings.ToUpper(name), strings.ToUpper(localParameterPrefix))
+}
+
+// localValue returns the value bound to a name by LET or by a lambda call.
+func localValue(ctx Context, name string) (Result, bool) {
+	if s, ok := ctx.(*lambdaScope); ok {
+		return s.lookup(name)
+	}
+	return Result{}, false
+}
+
+func scopeDepth(ctx Context) int {
+	if s, ok := ctx.(*lambdaScope); ok {
+		return s.depth
+	}
+	return 0
+}
+
+// rootContext returns the context of the formula a scope belongs to.
+func rootContext(ctx Context) Context {
+	for {
+		s, ok := ctx.(*lambdaScope)
+		if !ok {
+			return ctx
+		}
+		ctx = s.Context
+	}
+}
+
+// lambdaName returns the name of a lambda defined in the workbook, as called
+// in a formula.
+func lambdaName(name string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(name, "_xludf."), "_xlfn.")
+}
+
+// isLambdaCall returns true if a function call isn't a call of a function of
+// the package, but of a lambda bound by LET or defined in the workbook.
+func isLambdaCall(name string) bool {
+	return !lambdaForms[name] && !lambdaForms["_xlfn."+name] && name != lambdaInvocation && LookupFunction(name) == nil && LookupFunctionComplex(name) == nil
+}
+
+// evalLambdaCall evaluates LET, LAMBDA and the calls of lambdas, returning
+// false for the calls of the other functions. Within lambdas, IF only
+// evaluates the argument it returns so that recursive lambdas end.
+func evalLambdaCall(ctx Context, ev Evaluator, name string, args []Expression) (Result, bool) {
+	form := name
+	if !strings.HasPrefix(form, "_xlfn.") {
+		form = "_xlfn." + form
+	}
+	switch {
+	case form == "_xlfn.LET":
+		return evalLet(ctx, ev, args), true
+	case form == "_xlfn.LAMBDA":
+		return makeLambda(ctx, ev, args), true
+	case name == lambdaInvocation:
+		if len(args) == 0 {
+			return MakeErrorResult("missing lambda to call"), true
+		}
+		return callLambda(args[0].Eval(ctx, ev), ctx, ev, args[1:]), true
+	case name == "IF":
+		if _, ok := ctx.(*lambdaScope); ok {
+			return lazyIf(ctx, ev, args)
+		}
+		return Result{}, false
+	case !isLambdaCall(name):
+		return Result{}, false
+	}
+	if v, ok := localValue(ctx, name); ok {
+		return callLambda(v, ctx, ev, args), true
+	}
+	if l := namedLambda(ctx, ev, name); l != nil {
+		return callLambda(lambdaResult(l), ctx, ev, args), true
+	}
+	return Result{}, false
+}
+
+// callLambda calls a lambda with the values of arguments.
+func callLambda(fn Result, ctx Context, ev Evaluator, args []Expression) Result {
+	if fn.Lambda == nil {
+		if fn.Type == ResultTypeError {
+			return fn
+		}
+		return MakeErrorResultType(ErrorTypeValue, "only lambdas can be called")
+	}
+	values := make([]Result, len(args))
+	for i, a := range args {
+		values[i] = a.Eval(ctx, ev)
+		values[i].Ref = a.Reference(ctx, ev)
+	}
+	return fn.Lambda.Call(values...)
+}
+
+// namedLambda returns the lambda defined by a name of the workbook, if any.
+func namedLambda(ctx Context, ev Evaluator, name string) *Lambda {
+	ref := ctx.NamedRange(lambdaName(name))
+	if ref.Type == ReferenceTypeInvalid {
+		return nil
+	}
+	call, ok := derefExpression(ParseString(strings.TrimPrefix(ref.Value, "="))).(FunctionCall)
+	if !ok || strings.TrimPrefix(call._ddea, "_xlfn.") != "LAMBDA" {
+		return nil
+	}
+	// the body doesn't see the names of the caller, but its calls count
+	// towards the nesting of recursive lambdas
+	r := makeLambda(rootContext(ctx), ev, call._ddaa)
+	if r.Lambda != nil {
+		r.Lambda.depth = scopeDepth(ctx)
+	}
+	return r.Lambda
+}
+
+// parameterName returns the name declared by an argument of LET or LAMBDA.
+func parameterName(expr Expression) (string, bool) {
+	ref, ok := derefExpression(expr).(NamedRangeRef)
+	if !ok {
+		return "", false
+	}
+	return localName(ref._dcdga), true
+}
+
+// evalLet implements LET, which binds names to values in turn, each value
+// seeing the names bound before, and evaluates its last argument with them.
+func evalLet(ctx Context, ev Evaluator, args []Expression) Result {
+	if len(args) < 3 || len(args)%2 == 0 {
+		return MakeErrorResult("LET requires pairs of names and values followed by a calculation")
+	}
+	scope := &lambdaScope{Context: ctx, names: map[string]Result{}, depth: scopeDepth(ctx)}
+	for i := 0; i+1 < len(args); i += 2 {
+		name, ok := parameterName(args[i])
+		if !ok {
+			return MakeErrorResultType(ErrorTypeName, "LET requires names, got "+args[i].String())
+		}
+		v := args[i+1].Eval(scope, ev)
+		v.Ref = args[i+1].Reference(scope, ev)
+		scope.names[name] = v
+	}
+	return args[len(args)-1].Eval(scope, ev)
+}
+
+// makeLambda implements LAMBDA, whose last argument is the body of the
+// function and the others its parameters.
+func makeLambda(ctx Context, ev Evaluator, args []Expression) Result {
+	if len(args) == 0 {
+		return MakeErrorResult("LAMBDA requires a calculation")
+	}
+	params := make([]string, 0, len(args)-1)
+	seen := map[string]bool{}
+	for _, a := range args[:len(args)-1] {
+		name, ok := parameterName(a)
+		if !ok || seen[name] {
+			return MakeErrorResultType(ErrorTypeValue, "LAMBDA requires distinct parameter names, got "+a.String())
+		}
+		seen[name] = true
+		params = append(params, name)
+	}
+	return lambdaResult(&Lambda{params: params, body: args[len(args)-1], ctx: ctx, ev: ev, depth: scopeDepth(ctx)})
+}
+
+// lazyIf evaluates IF with a single condition, returning false for arrays of
+// conditions.
+func lazyIf(ctx Context, ev Evaluator, args []Expression) (Result, bool) {
+	if len(args) < 2 || len(args) > 3 {
+		return Result{}, false
+	}
+	cond := args[0].Eval(ctx, ev)
+	switch cond.Type {
+	case ResultTypeError:
+		return cond, true
+	case ResultTypeNumber:
+	default:
+		return Result{}, false
+	}
+	switch {
+	case cond.ValueNumber != 0:
+		return args[1].Eval(ctx, ev), true
+	case len(args) == 3:
+		return args[2].Eval(ctx, ev), true
+	}
+	return MakeBoolResult(false), true
+}
+
+// invocationString returns a call of a lambda as written in formulas.
+func invocationString(args []Expression) string {
+	b := strings.Builder{}
+	b.WriteString(args[0].String())
+	b.WriteByte('(')
+	for i, a := range args[1:] {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(a.String())
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// lambdaArguments checks the arguments of the functions calling a lambda,
+// the last one, returning the first error of the others. Variadic functions
+// require at least count arguments.
+func lambdaArguments(args []Result, name string, count int, variadic bool) (*Lambda, Result) {
+	switch {
+	case variadic && len(args) < count:
+		return nil, MakeErrorResult(fmt.Sprintf("%s requires at least %d arguments", name, count))
+	case !variadic && len(args) != count:
+		return nil, MakeErrorResult(fmt.Sprintf("%s requires %d arguments", name, count))
+	}
+	for _, a := range args[:len(args)-1] {
+		if a.Type == ResultTypeError {
+			return nil, a
+		}
+	}
+	fn := args[len(args)-1].Lambda
+	if fn == nil {
+		return nil, MakeErrorResultType(ErrorTypeValue, name+" requires a LAMBDA as last argument")
+	}
+	return fn, MakeEmptyResult()
+}
+
+// singleValue returns the result of a lambda whose results make an array,
+// which can't be an array itself.
+func singleValue(r Result, name string) Result {
+	switch r.Type {
+	case ResultTypeList, ResultTypeArray:
+		rows := resultRows(r)
+		if len(rows) == 1 && len(rows[0]) == 1 {
+			return rows[0][0]
+		}
+		return MakeErrorResultType(ErrorTypeCalc, name+" doesn't support nested arrays")
+	}
+	if r.Lambda != nil {
+		return MakeErrorResultType(ErrorTypeCalc, name+" doesn't support lambdas as values")
+	}
+	return r
+}
+
+// Map implements the Excel MAP function, which calls a lambda with the values
+// at the same position in each array and returns the array of its results.
+// Single values are used at every position, and arrays too small provide #N/A.
+func Map(args []Result) Result {
+	fn, res := lambdaArguments(args, "MAP", 2, true)
+	if fn == nil {
+		return res
+	}
+	arrays := make([][][]Result, len(args)-1)
+	height, width := 0, 0
+	for i, a := range args[:len(args)-1] {
+		arrays[i] = resultRows(a)
+		if len(arrays[i]) > height {
+			height = len(arrays[i])
+		}
+		if len(arrays[i][0]) > width {
+			width = len(arrays[i][0])
+		}
+	}
+	out := make([][]Result, height)
+	values := make([]Result, len(arrays))
+	for r := range out {
+		out[r] = make([]Result, width)
+		for c := range out[r] {
+			for i, rows := range arrays {
+				switch {
+				case len(rows) == 1 && len(rows[0]) == 1:
+					values[i] = rows[0][0]
+				case r < len(rows) && c < len(rows[r]):
+					values[i] = rows[r][c]
+				default:
+					values[i] = MakeErrorResultType(ErrorTypeNA, "")
+				}
+			}
+			out[r][c] = singleValue(fn.Call(values...), "MAP")
+		}
+	}
+	return rowsResult(out)
+}
+
+// Reduce implements the Excel REDUCE function, which accumulates the values
+// of an array row by row by calling a lambda with the accumulated value and
+// the next value.
+func Reduce(args []Result) Result {
+	fn, res := lambdaArguments(args, "REDUCE", 3, false)
+	if fn == nil {
+		return res
+	}
+	acc := args[0]
+	for _, row := range resultRows(args[1]) {
+		for _, v := range row {
+			acc = fn.Call(acc, v)
+		}
+	}
+	return acc
+}
+
+// Scan implements the Excel SCAN function, which accumulates the values of an
+// array as REDUCE does and returns the array of the accumulated values.
+func Scan(args []Result) Result {
+	fn, res := lambdaArguments(args, "SCAN", 3, false)
+	if fn == nil {
+		return res
+	}
+	acc := args[0]
+	rows := resultRows(args[1])
+	out := make([][]Result, len(rows))
+	for r, row := range rows {
+		out[r] = make([]Result, len(row))
+		for c, v := range row {
+			acc = singleValue(fn.Call(acc, v), "SCAN")
+			out[r][c] = acc
+		}
+	}
+	return rowsResult(out)
+}
+
+// ByRow implements the Excel BYROW function, which calls a lambda with each
+// row of an array and returns the column of its results.
+func ByRow(args []Result) Result {
+	fn, res := lambdaArguments(args, "BYROW", 2, false)
+	if fn == nil {
+		return res
+	}
+	rows := resultRows(args[0])
+	out := make([][]Result, len(rows))
+	for r, row := range rows {
+		out[r] = []Result{singleValue(fn.Call(rowsResult([][]Result{row})), "BYROW")}
+	}
+	return rowsResult(out)
+}
+
+// ByCol implements the Excel BYCOL function, which calls a lambda with each
+// column of an array and returns the row of its results.
+func ByCol(args []Result) Result {
+	fn, res := lambdaArguments(args, "BYCOL", 2, false)
+	if fn == nil {
+		return res
+	}
+	cols := transposeRows(resultRows(args[0]))
+	out := make([]Result, len(cols))
+	for c, col := range cols {
+		column := make([][]Result, len(col))
+		for r, v := range col {
+			column[r] = []Result{v}
+		}
+		out[c] = singleValue(fn.Call(rowsResult(column)), "BYCOL")
+	}
+	return rowsResult([][]Result{out})
+}
+
+// MakeArray implements the Excel MAKEARRAY function, which returns an array
+// of the given size whose values are the results of a lambda called with
+// their row and column numbers.
+func MakeArray(args []Result) Result {
+	fn, res := lambdaArguments(args, "MAKEARRAY", 3, false)
+	if fn == nil {
+		return res
+	}
+	rows, cols := args[0].AsNumber(), args[1].AsNumber()
+	if rows.Type != ResultTypeNumber || cols.Type != ResultTypeNumber {
+		return MakeErrorResultType(ErrorTypeValue, "MAKEARRAY requires numeric sizes")
+	}
+	height, width, res := arraySize(rows.ValueNumber, cols.ValueNumber, "MAKEARRAY")
+	if height == 0 {
+		return res
+	}
+	out := make([][]Result, height)
+	for r := range out {
+		out[r] = make([]Result, width)
+		for c := range out[r] {
+			out[r][c] = singleValue(fn.Call(MakeNumberResult(float64(r+1)), MakeNumberResult(float64(c+1))), "MAKEARRAY")
+		}
+	}
+	return rowsResult(out)
+}
@@ -0,0 +1,119 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLetLambda(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    float64
+	}{
+		{"LET(x,2,x)", 2},
+		{"LET(xy,2,xy*3)", 6},
+		{"LET(x,2,y,3,x*y)", 6},
+		{"LET(x,2,y,x+1,x*y)", 6},
+		{"LET(x, 2, y, 3, x * y)", 6},
+		{"LAMBDA(x, y, x*y/2)(3, 4)", 6},
+		{"LAMBDA(x,x+1)(2)", 3},
+		{"LET(f,LAMBDA(a,b,a*b),f(3,4))", 12},
+		{"SUM(LET(a,{1,2,3},a))", 6},
+		{"LET(a,{1,\"b,c\";3,4},SUM(a))", 8},
+		{"SUM(MAP({1,2},LAMBDA(v,v*2)))", 6},
+		{"REDUCE(0,{1,2,3},LAMBDA(a,v,a+v))", 6},
+	}
+	for _, tc := range tests {
+		checkNumber(t, tc.formula, tc.want)
+	}
+}
+
+// LET and LAMBDA are evaluated without the prefixes of stored formulas as
+// well.
+func TestLetLambdaAsEntered(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    float64
+	}{
+		{"LET(x,2,x)", 2},
+		{"LET(x,2,y,3,x*y)", 6},
+		{"LAMBDA(x,x+1)(2)", 3},
+		{"SUM(LET(a,{1,2,3},a))", 6},
+	}
+	for _, tc := range tests {
+		r := NewEvaluator().Eval(InvalidReferenceContext, tc.formula)
+		if r.Type != ResultTypeNumber || r.ValueNumber != tc.want {
+			t.Errorf("%s = %v %q %s, want %v", tc.formula, r.Type, r.Value(), r.ErrorMessage, tc.want)
+		}
+	}
+}
+
+func TestLambdaHelperErrors(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    string
+	}{
+		// wrong number of arguments
+		{"MAP(LAMBDA(v,v))", "#VALUE!"},
+		{"REDUCE({1,2},LAMBDA(a,v,a+v))", "#VALUE!"},
+		{"SCAN(0,{1,2},{3},LAMBDA(a,v,a+v))", "#VALUE!"},
+		{"BYROW({1,2},{3},LAMBDA(r,SUM(r)))", "#VALUE!"},
+		{"BYCOL(LAMBDA(c,SUM(c)))", "#VALUE!"},
+		{"MAKEARRAY(2,LAMBDA(r,c,r*c))", "#VALUE!"},
+		// arguments which aren't lambdas
+		{"MAP({1,2},3)", "#VALUE!"},
+		{"REDUCE(0,{1,2},SUM)", "#VALUE!"},
+		{"SCAN(0,{1,2},\"x\")", "#VALUE!"},
+		{"BYROW({1,2;3,4},{1})", "#VALUE!"},
+		{"BYCOL({1,2;3,4},1)", "#VALUE!"},
+		{"MAKEARRAY(2,2,2)", "#VALUE!"},
+		// lambdas called with the wrong number of parameters
+		{"REDUCE(0,{1,2},LAMBDA(a,a))", "#VALUE!"},
+		{"INDEX(MAP({1,2},LAMBDA(a,b,a+b)),1,1)", "#VALUE!"},
+		{"INDEX(MAKEARRAY(1,1,LAMBDA(r,r)),1,1)", "#VALUE!"},
+		// results which aren't single values
+		{"INDEX(BYROW({1,2;3,4},LAMBDA(r,r)),1,1)", "#CALC!"},
+		{"INDEX(SCAN(0,{1,2},LAMBDA(a,v,{1,2})),1,1)", "#CALC!"},
+		// other errors
+		{"MAKEARRAY(\"a\",2,LAMBDA(r,c,r*c))", "#VALUE!"},
+		{"MAKEARRAY(0,2,LAMBDA(r,c,r*c))", "#CALC!"},
+		{"MAP(1/0,LAMBDA(v,v))", "#DIV/0!"},
+		{"LAMBDA(x,x+1)", "#CALC!"},
+		{"LAMBDA(x,x+1)(1,2)", "#VALUE!"},
+		{"LET(1,2,3)", "#NAME?"},
+	}
+	for _, tc := range tests {
+		r := evalFormula(tc.formula)
+		if r.Type != ResultTypeError || r.ValueString != tc.want {
+			t.Errorf("%s = %v %q, want %s", tc.formula, r.Type, r.Value(), tc.want)
+		}
+	}
+}
+
+// checkNumber checks that a formula returns a number within the precision of
+// the expected value, given with up to 7 significant digits.
+func checkNumber(t *testing.T, f string, want float64) {
+	t.Helper()
+	checkResult(t, f, evalFormula(f), want)
+}
+
+// checkResult checks the result of a formula like checkNumber.
+func checkResult(t *testing.T, f string, r Result, want float64) {
+	t.Helper()
+	if r.Type != ResultTypeNumber {
+		t.Errorf("%s = %v %q %s, want %v", f, r.Type, r.Value(), r.ErrorMessage, want)
+		return
+	}
+	if math.Abs(r.ValueNumber-want) > 5e-7*math.Max(1, math.Abs(want)) {
+		t.Errorf("%s = %v, want %v", f, r.ValueNumber, want)
+	}
+}
@@ -0,0 +1,190 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// The lexer only accepts function names made of upper case letters, digits
+// and dots, with an optional _xlfn. prefix. Other names, such as
+// _xlfn._xlws.SORT, the names of the lambdas bound by LET written
+// _xlpm.name or the names of lambdas defined in the workbook, are hex
+// encoded after a prefix the lexer accepts and decoded in the tokens.
+//
+// Structured references such as Table1[Column] or [@Column] are encoded in
+// the same way, the parser reading them as names. They are resolved before
+// parsing when formulas are evaluated.
+//
+// The lexer doesn't accept whitespace either, which is dropped unless it is
+// between two operands, where it is the intersection operator.
+//
+// The parser doesn't accept calls of the lambdas returned by functions, such
+// as LAMBDA(x,x+1)(2), which are rewritten as calls of lambdaInvocation with
+// the lambda as first argument.
+const (
+	encodedNamePrefix = "_xlfn._XLHEX."
+	lambdaInvocation  = "_xlfn._XLINVOKE"
+)
+
+// lexableFormula returns a formula with the function names and the calls of
+// lambdas the lexer and the parser accept.
+func lexableFormula(f string) string {
+	b := strings.Builder{}
+	for i := 0; i < len(f); {
+		switch c := f[i]; {
+		case c == '"':
+			j := skipFormulaGroup(f, i)
+			b.WriteString(f[i:j])
+			i = j
+		case isSpace(c):
+			j := i
+			for j < len(f) && isSpace(f[j]) {
+				j++
+			}
+			if i > 0 && j < len(f) && isOperandEnd(f[i-1]) && isOperandStart(f[j]) {
+				b.WriteString(f[i:j])
+			}
+			i = j
+		case c == '[':
+			j := skipFormulaGroup(f, i)
+			if isStructuredReference(f, i, j) {
+				b.WriteString(encodedName(f[i:j]))
+			} else {
+				b.WriteString(f[i:j])
+			}
+			i = j
+		case c == '\'' || isNameChar(c):
+			j := nameEnd(f, i)
+			if j < len(f) && f[j] == '[' {
+				if k := skipFormulaGroup(f, j); isStructuredReference(f, j, k) {
+					b.WriteString(encodedName(f[i:k]))
+					i = k
+					continue
+				}
+			}
+			if j >= len(f) || f[j] != '(' {
+				b.WriteString(lexableOperand(f, i, j))
+				i = j
+				continue
+			}
+			call := lexableName(f[i:j])
+			for first := true; j < len(f) && f[j] == '('; first = false {
+				end := skipFormulaGroup(f, j)
+				if f[end-1] != ')' {
+					// unbalanced, rejected by the parser
+					b.WriteString(call + f[j:])
+					return b.String()
+				}
+				args := lexableFormula(f[j+1 : end-1])
+				if first {
+					call += "(" + args + ")"
+				} else if args == "" {
+					call = lambdaInvocation + "(" + call + ")"
+				} else {
+					call = lambdaInvocation + "(" + call + "," + args + ")"
+				}
+				j = end
+			}
+			b.WriteString(call)
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// lexableOperand returns the name, number or reference f[i:j] as the lexer
+// accepts it. The lexer only accepts single letters as the ends of column
+// ranges such as A:C, single letter names, such as those declared by
+// LET(x,1,x), are encoded.
+func lexableOperand(f string, i, j int) string {
+	name := f[i:j]
+	if len(name) != 1 || !(name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z') {
+		return name
+	}
+	if i > 0 && f[i-1] == ':' || j < len(f) && f[j] == ':' {
+		return name
+	}
+	return encodedName(name)
+}
+
+// isStructuredReference returns true if the brackets f[i:j] are those of a
+// structured reference, rather than the workbook of an external reference
+// such as [1]Sheet1!A1.
+func isStructuredReference(f string, i, j int) bool {
+	return f[j-1] == ']' && !isExternalReference(f, j)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isOperandEnd(c byte) bool {
+	return isNameChar(c) || c == ')' || c == ']' || c == '}' || c == '\'' || c == '"' || c == '#'
+}
+
+func isOperandStart(c byte) bool {
+	return isNameChar(c) || c == '(' || c == '[' || c == '{' || c == '\'' || c == '"'
+}
+
+func encodedName(name string) string {
+	return encodedNamePrefix + strings.ToUpper(hex.EncodeToString([]byte(name)))
+}
+
+// lexableName returns the name of a function as the lexer accepts it, upper
+// cased for the functions of the package or encoded.
+func lexableName(name string) string {
+	if isLexableName(name) || strings.ContainsAny(name, "'!") {
+		return name
+	}
+	if upper := strings.ToUpper(name); isLexableName(upper) && (LookupFunction(upper) != nil || LookupFunctionComplex(upper) != nil) {
+		return upper
+	}
+	return encodedName(name)
+}
+
+func isLexableName(name string) bool {
+	name = strings.TrimPrefix(name, "_xlfn.")
+	if name == "" || name[0] < 'A' || name[0] > 'Z' {
+		if !strings.HasPrefix(name, "_") || len(name) < 2 || name[1] < 'A' || name[1] > 'Z' {
+			return false
+		}
+		name = name[1:]
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// lexedToken returns the text of a token with the name it starts with
+// decoded.
+func lexedToken(b []byte) string {
+	s := string(b)
+	if !strings.HasPrefix(s, encodedNamePrefix) {
+		return s
+	}
+	rest := s[len(encodedNamePrefix):]
+	n := 0
+	for n < len(rest) && (rest[n] >= '0' && rest[n] <= '9' || rest[n] >= 'A' && rest[n] <= 'F') {
+		n++
+	}
+	name, err := hex.DecodeString(rest[:n])
+	if err != nil {
+		return s
+	}
+	return string(name) + rest[n:]
+}
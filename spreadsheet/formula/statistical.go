@@ -0,0 +1,1087 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"math"
+	"sort"
+)
+
+func init() {
+	RegisterFunction("STDEV", Stdev)
+	RegisterFunction("STDEV.S", Stdev)
+	RegisterFunction("_xlfn.STDEV.S", Stdev)
+	RegisterFunction("STDEVP", StdevP)
+	RegisterFunction("STDEV.P", StdevP)
+	RegisterFunction("_xlfn.STDEV.P", StdevP)
+	RegisterFunction("STDEVA", StdevA)
+	RegisterFunction("STDEVPA", StdevPA)
+	RegisterFunction("VAR", Var)
+	RegisterFunction("VAR.S", Var)
+	RegisterFunction("_xlfn.VAR.S", Var)
+	RegisterFunction("VARP", VarP)
+	RegisterFunction("VAR.P", VarP)
+	RegisterFunction("_xlfn.VAR.P", VarP)
+	RegisterFunction("VARA", VarA)
+	RegisterFunction("VARPA", VarPA)
+	RegisterFunction("AVERAGEIF", AverageIf)
+	RegisterFunction("AVERAGEIFS", AverageIfs)
+	RegisterFunction("MODE", Mode)
+	RegisterFunction("MODE.SNGL", Mode)
+	RegisterFunction("_xlfn.MODE.SNGL", Mode)
+	RegisterFunction("MODE.MULT", ModeMult)
+	RegisterFunction("_xlfn.MODE.MULT", ModeMult)
+	RegisterFunction("PERCENTILE", Percentile)
+	RegisterFunction("PERCENTILE.INC", Percentile)
+	RegisterFunction("_xlfn.PERCENTILE.INC", Percentile)
+	RegisterFunction("PERCENTILE.EXC", PercentileExc)
+	RegisterFunction("_xlfn.PERCENTILE.EXC", PercentileExc)
+	RegisterFunction("QUARTILE", Quartile)
+	RegisterFunction("QUARTILE.INC", Quartile)
+	RegisterFunction("_xlfn.QUARTILE.INC", Quartile)
+	RegisterFunction("QUARTILE.EXC", QuartileExc)
+	RegisterFunction("_xlfn.QUARTILE.EXC", QuartileExc)
+	RegisterFunction("RANK", Rank)
+	RegisterFunction("RANK.EQ", Rank)
+	RegisterFunction("_xlfn.RANK.EQ", Rank)
+	RegisterFunction("RANK.AVG", RankAvg)
+	RegisterFunction("_xlfn.RANK.AVG", RankAvg)
+	RegisterFunction("CORREL", Correl)
+	RegisterFunction("PEARSON", Correl)
+	RegisterFunction("COVAR", Covar)
+	RegisterFunction("COVARIANCE.P", Covar)
+	RegisterFunction("_xlfn.COVARIANCE.P", Covar)
+	RegisterFunction("COVARIANCE.S", CovarianceS)
+	RegisterFunction("_xlfn.COVARIANCE.S", CovarianceS)
+	RegisterFunction("SLOPE", Slope)
+	RegisterFunction("INTERCEPT", Intercept)
+	RegisterFunction("RSQ", Rsq)
+	RegisterFunction("STEYX", Steyx)
+	RegisterFunction("FORECAST", Forecast)
+	RegisterFunction("FORECAST.LINEAR", Forecast)
+	RegisterFunction("_xlfn.FORECAST.LINEAR", Forecast)
+	RegisterFunction("LINEST", Linest)
+	RegisterFunction("TREND", Trend)
+	RegisterFunction("GROWTH", Growth)
+}
+
+// sampleValues returns the numbers of the arguments of a statistical function
+// as Excel counts them: the numbers of the references and arrays, and the
+// numbers, logical values and numeric text of the other arguments. With all
+// set, the text and logical values of references and arrays count as well,
+// text and FALSE as 0 and TRUE as 1.
+func sampleValues(args []Result, all bool, name string) ([]float64, Result) {
+	values := []float64{}
+	for _, arg := range args {
+		if arg.Type == ResultTypeError {
+			return nil, arg
+		}
+		if arg.Ref.Type == ReferenceTypeInvalid && arg.Type != ResultTypeArray && arg.Type != ResultTypeList {
+			if arg.Type == ResultTypeEmpty {
+				continue
+			}
+			n := arg.AsNumber()
+			if n.Type != ResultTypeNumber {
+				return nil, MakeErrorResultType(ErrorTypeValue, name+" requires numeric arguments")
+			}
+			values = append(values, n.ValueNumber)
+			continue
+		}
+		for _, row := range resultRows(arg) {
+			for _, v := range row {
+				switch v.Type {
+				case ResultTypeError:
+					return nil, v
+				case ResultTypeNumber:
+					if all || !v.IsBoolean {
+						values = append(values, v.ValueNumber)
+					}
+				case ResultTypeString:
+					if all {
+						values = append(values, 0)
+					}
+				}
+			}
+		}
+	}
+	return values, MakeEmptyResult()
+}
+
+// numberArguments validates the number of arguments of a function taking
+// numbers and returns them, the arguments omitted being 0.
+func numberArguments(args []Result, min, max int, name string) ([]float64, Result) {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return nil, MakeErrorResult(name + " requires " + argumentCount(min))
+		}
+		return nil, MakeErrorResult(name + " requires " + argumentCount(min) + " to " + argumentCount(max))
+	}
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		if arg.Type == ResultTypeError {
+			return nil, arg
+		}
+		n := arg.AsNumber()
+		if n.Type != ResultTypeNumber {
+			return nil, MakeErrorResultType(ErrorTypeValue, name+" requires numeric arguments")
+		}
+		values[i] = n.ValueNumber
+	}
+	return values, MakeEmptyResult()
+}
+
+func argumentCount(n int) string {
+	names := []string{"no arguments", "one argument", "two arguments", "three arguments",
+		"four arguments", "five arguments", "six arguments"}
+	if n < len(names) {
+		return names[n]
+	}
+	return "more arguments"
+}
+
+// mean returns the arithmetic mean of values.
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// variance returns the variance of a sample, or of a population, using the
+// corrected two pass algorithm for accuracy.
+func variance(values []float64, sample bool) (float64, Result) {
+	n := float64(len(values))
+	if n == 0 || sample && n == 1 {
+		return 0, MakeErrorResultType(ErrorTypeDivideByZero, "variance requires more values")
+	}
+	m := mean(values)
+	squares, sum := 0.0, 0.0
+	for _, v := range values {
+		squares += (v - m) * (v - m)
+		sum += v - m
+	}
+	squares -= sum * sum / n
+	if sample {
+		return squares / (n - 1), MakeEmptyResult()
+	}
+	return squares / n, MakeEmptyResult()
+}
+
+// varianceFunction returns an implementation of one of the VAR and STDEV
+// functions.
+func varianceFunction(name string, sample, all, root bool) Function {
+	return func(args []Result) Result {
+		if len(args) == 0 {
+			return MakeErrorResult(name + " requires at least one argument")
+		}
+		values, err := sampleValues(args, all, name)
+		if err.Type != ResultTypeEmpty {
+			return err
+		}
+		v, err := variance(values, sample)
+		if err.Type != ResultTypeEmpty {
+			return err
+		}
+		if root {
+			return MakeNumberResult(math.Sqrt(v))
+		}
+		return MakeNumberResult(v)
+	}
+}
+
+// Stdev implements the Excel STDEV and STDEV.S functions.
+func Stdev(args []Result) Result { return varianceFunction("STDEV", true, false, true)(args) }
+
+// StdevP implements the Excel STDEVP and STDEV.P functions.
+func StdevP(args []Result) Result { return varianceFunction("STDEVP", false, false, true)(args) }
+
+// StdevA implements the Excel STDEVA function, which counts text and logical
+// values.
+func StdevA(args []Result) Result { return varianceFunction("STDEVA", true, true, true)(args) }
+
+// StdevPA implements the Excel STDEVPA function, which counts text and logical
+// values.
+func StdevPA(args []Result) Result { return varianceFunction("STDEVPA", false, true, true)(args) }
+
+// Var implements the Excel VAR and VAR.S functions.
+func Var(args []Result) Result { return varianceFunction("VAR", true, false, false)(args) }
+
+// VarP implements the Excel VARP and VAR.P functions.
+func VarP(args []Result) Result { return varianceFunction("VARP", false, false, false)(args) }
+
+// VarA implements the Excel VARA function, which counts text and logical
+// values.
+func VarA(args []Result) Result { return varianceFunction("VARA", true, true, false)(args) }
+
+// VarPA implements the Excel VARPA function, which counts text and logical
+// values.
+func VarPA(args []Result) Result { return varianceFunction("VARPA", false, true, false)(args) }
+
+// AverageIf implements the Excel AVERAGEIF function.
+func AverageIf(args []Result) Result {
+	if len(args) != 2 && len(args) != 3 {
+		return MakeErrorResult("AVERAGEIF requires two or three arguments")
+	}
+	averaged := args[0]
+	if len(args) == 3 && args[2].Type != ResultTypeEmpty {
+		averaged = args[2]
+	}
+	criteria := _ffbb(args[1])
+	values := resultRows(averaged)
+	sum, count := 0.0, 0.0
+	for i, row := range resultRows(args[0]) {
+		for j, v := range row {
+			if i >= len(values) || j >= len(values[i]) || !_eebdg(v, criteria) {
+				continue
+			}
+			switch a := values[i][j]; a.Type {
+			case ResultTypeError:
+				return a
+			case ResultTypeNumber:
+				if !a.IsBoolean {
+					sum += a.ValueNumber
+					count++
+				}
+			}
+		}
+	}
+	if count == 0 {
+		return MakeErrorResultType(ErrorTypeDivideByZero, "AVERAGEIF found no values to average")
+	}
+	return MakeNumberResult(sum / count)
+}
+
+// AverageIfs implements the Excel AVERAGEIFS function.
+func AverageIfs(args []Result) Result {
+	if err := _bgbf(args, true, "AVERAGEIFS"); err.Type != ResultTypeEmpty {
+		return err
+	}
+	values := _dgdcg(args[0])
+	sum, count := 0.0, 0.0
+	for _, index := range _dfea(args[1:]) {
+		switch a := values[index._cfbf][index._gegd]; a.Type {
+		case ResultTypeError:
+			return a
+		case ResultTypeNumber:
+			if !a.IsBoolean {
+				sum += a.ValueNumber
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return MakeErrorResultType(ErrorTypeDivideByZero, "AVERAGEIFS found no values to average")
+	}
+	return MakeNumberResult(sum / count)
+}
+
+// modes returns the values occurring most often, at least twice, in the order
+// of their first occurrence.
+func modes(values []float64) []float64 {
+	counts := map[float64]int{}
+	most := 1
+	for _, v := range values {
+		counts[v]++
+		if counts[v] > most {
+			most = counts[v]
+		}
+	}
+	result := []float64{}
+	for _, v := range values {
+		if counts[v] == most {
+			result = append(result, v)
+			delete(counts, v)
+		}
+	}
+	if most == 1 {
+		return nil
+	}
+	return result
+}
+
+// Mode implements the Excel MODE and MODE.SNGL functions.
+func Mode(args []Result) Result {
+	if len(args) == 0 {
+		return MakeErrorResult("MODE requires at least one argument")
+	}
+	values, err := sampleValues(args, false, "MODE")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	m := modes(values)
+	if len(m) == 0 {
+		return MakeErrorResultType(ErrorTypeNA, "MODE found no repeated value")
+	}
+	return MakeNumberResult(m[0])
+}
+
+// ModeMult implements the Excel MODE.MULT function, which returns a vertical
+// array of the most frequent values.
+func ModeMult(args []Result) Result {
+	if len(args) == 0 {
+		return MakeErrorResult("MODE.MULT requires at least one argument")
+	}
+	values, err := sampleValues(args, false, "MODE.MULT")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	m := modes(values)
+	if len(m) == 0 {
+		return MakeErrorResultType(ErrorTypeNA, "MODE.MULT found no repeated value")
+	}
+	rows := make([][]Result, len(m))
+	for i, v := range m {
+		rows[i] = []Result{MakeNumberResult(v)}
+	}
+	return rowsResult(rows)
+}
+
+// percentile returns the k-th percentile of values, interpolated between the
+// ranks 0 to n-1 or, if exclusive, 1 to n of n+1.
+func percentile(values []float64, k float64, exclusive bool, name string) Result {
+	n := float64(len(values))
+	if n == 0 {
+		return MakeErrorResultType(ErrorTypeNum, name+" requires values")
+	}
+	var rank float64
+	if exclusive {
+		rank = k*(n+1) - 1
+		if k <= 0 || k >= 1 || rank < 0 || rank > n-1 {
+			return MakeErrorResultType(ErrorTypeNum, name+" percentile out of range")
+		}
+	} else {
+		if k < 0 || k > 1 {
+			return MakeErrorResultType(ErrorTypeNum, name+" percentile out of range")
+		}
+		rank = k * (n - 1)
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	i := int(rank)
+	if i >= len(sorted)-1 {
+		return MakeNumberResult(sorted[len(sorted)-1])
+	}
+	return MakeNumberResult(sorted[i] + (rank-float64(i))*(sorted[i+1]-sorted[i]))
+}
+
+func percentileFunction(name string, exclusive, quartile bool) Function {
+	return func(args []Result) Result {
+		if len(args) != 2 {
+			return MakeErrorResult(name + " requires two arguments")
+		}
+		values, err := sampleValues(args[:1], false, name)
+		if err.Type != ResultTypeEmpty {
+			return err
+		}
+		k, err := numberArguments(args[1:], 1, 1, name)
+		if err.Type != ResultTypeEmpty {
+			return err
+		}
+		if !quartile {
+			return percentile(values, k[0], exclusive, name)
+		}
+		q := math.Trunc(k[0])
+		if exclusive && (q < 1 || q > 3) || q < 0 || q > 4 {
+			return MakeErrorResultType(ErrorTypeNum, name+" quartile out of range")
+		}
+		return percentile(values, q/4, exclusive, name)
+	}
+}
+
+// Percentile implements the Excel PERCENTILE and PERCENTILE.INC functions.
+func Percentile(args []Result) Result {
+	return percentileFunction("PERCENTILE", false, false)(args)
+}
+
+// PercentileExc implements the Excel PERCENTILE.EXC function.
+func PercentileExc(args []Result) Result {
+	return percentileFunction("PERCENTILE.EXC", true, false)(args)
+}
+
+// Quartile implements the Excel QUARTILE and QUARTILE.INC functions.
+func Quartile(args []Result) Result {
+	return percentileFunction("QUARTILE", false, true)(args)
+}
+
+// QuartileExc implements the Excel QUARTILE.EXC function.
+func QuartileExc(args []Result) Result {
+	return percentileFunction("QUARTILE.EXC", true, true)(args)
+}
+
+func rankFunction(name string, average bool) Function {
+	return func(args []Result) Result {
+		if len(args) != 2 && len(args) != 3 {
+			return MakeErrorResult(name + " requires two or three arguments")
+		}
+		number, err := numberArguments(args[:1], 1, 1, name)
+		if err.Type != ResultTypeEmpty {
+			return err
+		}
+		values, err := sampleValues([]Result{referenceValues(args[1])}, false, name)
+		if err.Type != ResultTypeEmpty {
+			return err
+		}
+		ascending := false
+		if len(args) == 3 {
+			if ascending, err = truthValue(args[2]); err.Type == ResultTypeError {
+				return err
+			}
+		}
+		before, ties := 0, 0
+		for _, v := range values {
+			switch {
+			case v == number[0]:
+				ties++
+			case ascending && v < number[0] || !ascending && v > number[0]:
+				before++
+			}
+		}
+		if ties == 0 {
+			return MakeErrorResultType(ErrorTypeNA, name+" number not found")
+		}
+		if average {
+			return MakeNumberResult(float64(before) + float64(ties+1)/2)
+		}
+		return MakeNumberResult(float64(before + 1))
+	}
+}
+
+// referenceValues returns a value as the values of a reference, a single
+// value being a reference to a cell.
+func referenceValues(r Result) Result {
+	if r.Ref.Type == ReferenceTypeInvalid && r.Type != ResultTypeArray && r.Type != ResultTypeList {
+		r.Ref = Reference{Type: ReferenceTypeCell}
+	}
+	return r
+}
+
+// Rank implements the Excel RANK and RANK.EQ functions, which return the
+// position of a number in the numbers of a reference sorted in descending
+// order or, if the third argument is true, in ascending order.
+func Rank(args []Result) Result { return rankFunction("RANK", false)(args) }
+
+// RankAvg implements the Excel RANK.AVG function, which returns the average
+// position of the numbers equal to a number.
+func RankAvg(args []Result) Result { return rankFunction("RANK.AVG", true)(args) }
+
+// pairedValues returns the numbers at the same positions of two arrays of the
+// same size, skipping the positions where either value isn't a number.
+func pairedValues(ys, xs Result, name string) ([]float64, []float64, Result) {
+	if ys.Type == ResultTypeError {
+		return nil, nil, ys
+	}
+	if xs.Type == ResultTypeError {
+		return nil, nil, xs
+	}
+	yl, xl := resultValues(ys), resultValues(xs)
+	if len(yl) != len(xl) {
+		return nil, nil, MakeErrorResultType(ErrorTypeNA, name+" requires arrays of the same size")
+	}
+	var y, x []float64
+	for i := range yl {
+		for _, v := range []Result{yl[i], xl[i]} {
+			if v.Type == ResultTypeError {
+				return nil, nil, v
+			}
+		}
+		if yl[i].Type == ResultTypeNumber && !yl[i].IsBoolean && xl[i].Type == ResultTypeNumber && !xl[i].IsBoolean {
+			y = append(y, yl[i].ValueNumber)
+			x = append(x, xl[i].ValueNumber)
+		}
+	}
+	return y, x, MakeEmptyResult()
+}
+
+// resultValues returns the values of an array row by row.
+func resultValues(r Result) []Result {
+	var values []Result
+	for _, row := range resultRows(r) {
+		values = append(values, row...)
+	}
+	return values
+}
+
+// deviationSums returns the sums of the squared deviations from the means of
+// paired values and of their products.
+func deviationSums(y, x []float64) (syy, sxx, sxy float64) {
+	my, mx := mean(y), mean(x)
+	for i := range y {
+		dy, dx := y[i]-my, x[i]-mx
+		syy += dy * dy
+		sxx += dx * dx
+		sxy += dx * dy
+	}
+	return syy, sxx, sxy
+}
+
+// pairedFunction returns an implementation of a function of paired values,
+// requiring at least min pairs.
+func pairedFunction(name string, min int, fn func(y, x []float64) Result) Function {
+	return func(args []Result) Result {
+		if len(args) != 2 {
+			return MakeErrorResult(name + " requires two arguments")
+		}
+		y, x, err := pairedValues(args[0], args[1], name)
+		if err.Type != ResultTypeEmpty {
+			return err
+		}
+		if len(y) < min {
+			return MakeErrorResultType(ErrorTypeDivideByZero, name+" requires more values")
+		}
+		return fn(y, x)
+	}
+}
+
+// Correl implements the Excel CORREL and PEARSON functions.
+func Correl(args []Result) Result {
+	return pairedFunction("CORREL", 1, func(y, x []float64) Result {
+		syy, sxx, sxy := deviationSums(y, x)
+		if syy == 0 || sxx == 0 {
+			return MakeErrorResultType(ErrorTypeDivideByZero, "CORREL of constant values")
+		}
+		return MakeNumberResult(sxy / math.Sqrt(syy*sxx))
+	})(args)
+}
+
+// Rsq implements the Excel RSQ function, the square of the correlation.
+func Rsq(args []Result) Result {
+	return pairedFunction("RSQ", 1, func(y, x []float64) Result {
+		syy, sxx, sxy := deviationSums(y, x)
+		if syy == 0 || sxx == 0 {
+			return MakeErrorResultType(ErrorTypeDivideByZero, "RSQ of constant values")
+		}
+		return MakeNumberResult(sxy / sxx * sxy / syy)
+	})(args)
+}
+
+// Covar implements the Excel COVAR and COVARIANCE.P functions.
+func Covar(args []Result) Result {
+	return pairedFunction("COVAR", 1, func(y, x []float64) Result {
+		_, _, sxy := deviationSums(y, x)
+		return MakeNumberResult(sxy / float64(len(y)))
+	})(args)
+}
+
+// CovarianceS implements the Excel COVARIANCE.S function.
+func CovarianceS(args []Result) Result {
+	return pairedFunction("COVARIANCE.S", 2, func(y, x []float64) Result {
+		_, _, sxy := deviationSums(y, x)
+		return MakeNumberResult(sxy / float64(len(y)-1))
+	})(args)
+}
+
+// Slope implements the Excel SLOPE function, the slope of the linear
+// regression of known y values on known x values.
+func Slope(args []Result) Result {
+	return pairedFunction("SLOPE", 1, func(y, x []float64) Result {
+		_, sxx, sxy := deviationSums(y, x)
+		if sxx == 0 {
+			return MakeErrorResultType(ErrorTypeDivideByZero, "SLOPE of constant x values")
+		}
+		return MakeNumberResult(sxy / sxx)
+	})(args)
+}
+
+// Intercept implements the Excel INTERCEPT function, the intercept of the
+// linear regression of known y values on known x values.
+func Intercept(args []Result) Result {
+	return pairedFunction("INTERCEPT", 1, func(y, x []float64) Result {
+		_, sxx, sxy := deviationSums(y, x)
+		if sxx == 0 {
+			return MakeErrorResultType(ErrorTypeDivideByZero, "INTERCEPT of constant x values")
+		}
+		return MakeNumberResult(mean(y) - sxy/sxx*mean(x))
+	})(args)
+}
+
+// Steyx implements the Excel STEYX function, the standard error of the y
+// values predicted by the linear regression.
+func Steyx(args []Result) Result {
+	return pairedFunction("STEYX", 3, func(y, x []float64) Result {
+		syy, sxx, sxy := deviationSums(y, x)
+		if sxx == 0 {
+			return MakeErrorResultType(ErrorTypeDivideByZero, "STEYX of constant x values")
+		}
+		return MakeNumberResult(math.Sqrt(math.Max(syy-sxy*sxy/sxx, 0) / float64(len(y)-2)))
+	})(args)
+}
+
+// Forecast implements the Excel FORECAST and FORECAST.LINEAR functions, which
+// predict the y value of an x value by linear regression.
+func Forecast(args []Result) Result {
+	if len(args) != 3 {
+		return MakeErrorResult("FORECAST requires three arguments")
+	}
+	at, err := numberArguments(args[:1], 1, 1, "FORECAST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	return pairedFunction("FORECAST", 1, func(y, x []float64) Result {
+		_, sxx, sxy := deviationSums(y, x)
+		if sxx == 0 {
+			return MakeErrorResultType(ErrorTypeDivideByZero, "FORECAST of constant x values")
+		}
+		b := sxy / sxx
+		return MakeNumberResult(mean(y) + b*(at[0]-mean(x)))
+	})(args[1:])
+}
+
+// regressionData holds the observations of a linear regression, the values of
+// one or more x variables and of y, and how they are laid out.
+type regressionData struct {
+	y []float64
+	// x holds the values of the variables by observation.
+	x [][]float64
+	// rows is set if the observations are laid out in rows, a variable by
+	// column, and unset if they are laid out in columns.
+	rows bool
+}
+
+// regressionValues returns the observations of the known y and x arguments
+// of LINEST, TREND and GROWTH, the x values being 1 to n if omitted.
+func regressionValues(ys Result, xs Result, hasX bool, name string) (*regressionData, Result) {
+	yRows := resultRows(ys)
+	d := &regressionData{rows: len(yRows[0]) == 1}
+	for _, row := range yRows {
+		for _, v := range row {
+			if v.Type == ResultTypeError {
+				return nil, v
+			}
+			if v.Type != ResultTypeNumber {
+				return nil, MakeErrorResultType(ErrorTypeValue, name+" requires numeric y values")
+			}
+			d.y = append(d.y, v.ValueNumber)
+		}
+	}
+	n := len(d.y)
+	if !hasX {
+		for i := 1; i <= n; i++ {
+			d.x = append(d.x, []float64{float64(i)})
+		}
+		return d, MakeEmptyResult()
+	}
+	xRows := resultRows(xs)
+	at := func(i, j int) Result { return xRows[i][j] }
+	k := 0
+	switch {
+	case len(yRows) > 1 && len(yRows[0]) > 1 || len(xRows)*len(xRows[0]) == n:
+		// one variable, the x values laid out as the y values
+		k = 1
+		values := resultValues(xs)
+		at = func(i, _ int) Result { return values[i] }
+	case d.rows && len(xRows) == n:
+		k = len(xRows[0])
+	case !d.rows && len(xRows[0]) == n:
+		k = len(xRows)
+		at = func(i, j int) Result { return xRows[j][i] }
+	default:
+		return nil, MakeErrorResultType(ErrorTypeRef, name+" requires x values matching the y values")
+	}
+	d.x = make([][]float64, n)
+	for i := range d.x {
+		d.x[i] = make([]float64, k)
+		for j := range d.x[i] {
+			v := at(i, j)
+			if v.Type == ResultTypeError {
+				return nil, v
+			}
+			if v.Type != ResultTypeNumber {
+				return nil, MakeErrorResultType(ErrorTypeValue, name+" requires numeric x values")
+			}
+			d.x[i][j] = v.ValueNumber
+		}
+	}
+	return d, MakeEmptyResult()
+}
+
+// linearFit is the least squares fit of y = b + m1*x1 + ... + mk*xk.
+type linearFit struct {
+	constant  bool
+	intercept float64
+	// coefficients are the slopes m1 to mk, 0 for the variables dropped as
+	// collinear with the others.
+	coefficients []float64
+	// dropped are the variables dropped as collinear with the others.
+	dropped []bool
+	// covariance is the unscaled covariance matrix of the coefficients, the
+	// inverse of X'X of the centered values if the fit has a constant.
+	covariance [][]float64
+	means      []float64
+	n          float64
+	df         float64
+	ssreg      float64
+	ssresid    float64
+}
+
+// collinearity is the relative norm under which a variable is dropped as a
+// linear combination of the previous ones.
+const collinearity = 1e-13
+
+// fitLinear fits y on the variables of x by least squares, solving the
+// problem with a QR decomposition of the values, centered if constant is set,
+// computed by Gram-Schmidt orthogonalization with reorthogonalization.
+func fitLinear(y []float64, x [][]float64, constant bool) *linearFit {
+	n, k := len(y), len(x[0])
+	f := &linearFit{
+		constant:     constant,
+		n:            float64(n),
+		coefficients: make([]float64, k),
+		dropped:      make([]bool, k),
+		means:        make([]float64, k),
+	}
+	yc := append([]float64{}, y...)
+	my := 0.0
+	if constant {
+		my = mean(y)
+		for i := range yc {
+			yc[i] -= my
+		}
+		for j := range f.means {
+			for i := range x {
+				f.means[j] += x[i][j]
+			}
+			f.means[j] /= float64(n)
+		}
+	}
+	// q holds the orthonormal columns, r the upper triangular factor of the
+	// variables kept, in order.
+	var q [][]float64
+	var r [][]float64
+	var kept []int
+	for j := 0; j < k; j++ {
+		col := make([]float64, n)
+		for i := range col {
+			col[i] = x[i][j] - f.means[j]
+		}
+		norm := vectorNorm(col)
+		rj := make([]float64, len(q)+1)
+		for pass := 0; pass < 2; pass++ {
+			for l, ql := range q {
+				d := dotProduct(ql, col)
+				rj[l] += d
+				for i := range col {
+					col[i] -= d * ql[i]
+				}
+			}
+		}
+		rest := vectorNorm(col)
+		if norm == 0 || rest <= collinearity*norm || len(q) == n {
+			f.dropped[j] = true
+			continue
+		}
+		for i := range col {
+			col[i] /= rest
+		}
+		rj[len(q)] = rest
+		q = append(q, col)
+		r = append(r, rj)
+		kept = append(kept, j)
+	}
+	p := len(kept)
+	// solve R b = Q'y, r[l] being the column l of R
+	qy := make([]float64, p)
+	for l := range q {
+		qy[l] = dotProduct(q[l], yc)
+	}
+	b := make([]float64, p)
+	for l := p - 1; l >= 0; l-- {
+		s := qy[l]
+		for m := l + 1; m < p; m++ {
+			s -= r[m][l] * b[m]
+		}
+		b[l] = s / r[l][l]
+	}
+	// the inverse of R, column by column, gives the covariance R^-1 R^-T
+	inv := make([][]float64, p)
+	for l := range inv {
+		inv[l] = make([]float64, p)
+	}
+	for c := 0; c < p; c++ {
+		for l := c; l >= 0; l-- {
+			s := 0.0
+			if l == c {
+				s = 1
+			}
+			for m := l + 1; m <= c; m++ {
+				s -= r[m][l] * inv[m][c]
+			}
+			inv[l][c] = s / r[l][l]
+		}
+	}
+	f.covariance = make([][]float64, k)
+	for j := range f.covariance {
+		f.covariance[j] = make([]float64, k)
+	}
+	for l, jl := range kept {
+		f.coefficients[jl] = b[l]
+		for m, jm := range kept {
+			s := 0.0
+			for c := 0; c < p; c++ {
+				s += inv[l][c] * inv[m][c]
+			}
+			f.covariance[jl][jm] = s
+		}
+	}
+	if constant {
+		f.intercept = my
+		for j, m := range f.coefficients {
+			f.intercept -= m * f.means[j]
+		}
+	}
+	for i := range y {
+		e := y[i] - f.predict(x[i])
+		f.ssresid += e * e
+		if constant {
+			f.ssreg += (f.predict(x[i]) - my) * (f.predict(x[i]) - my)
+		} else {
+			f.ssreg += f.predict(x[i]) * f.predict(x[i])
+		}
+	}
+	f.df = float64(n - p)
+	if constant {
+		f.df--
+	}
+	return f
+}
+
+func (f *linearFit) predict(x []float64) float64 {
+	v := f.intercept
+	for j, m := range f.coefficients {
+		v += m * x[j]
+	}
+	return v
+}
+
+// statistics returns the rows of the additional regression statistics of
+// LINEST: the standard errors of the coefficients, r squared and the
+// standard error of y, the F statistic and the degrees of freedom, and the
+// regression and residual sums of squares.
+func (f *linearFit) statistics() [][]Result {
+	k := len(f.coefficients)
+	na := MakeErrorResultType(ErrorTypeNA, "")
+	rows := make([][]Result, 4)
+	for i := range rows {
+		rows[i] = make([]Result, k+1)
+		for j := range rows[i] {
+			rows[i][j] = na
+		}
+	}
+	number := func(v float64) Result {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return MakeErrorResultType(ErrorTypeNum, "")
+		}
+		return MakeNumberResult(v)
+	}
+	sey := math.Sqrt(f.ssresid / f.df)
+	for j := range f.coefficients {
+		se := 0.0
+		if !f.dropped[j] {
+			se = sey * math.Sqrt(f.covariance[j][j])
+		}
+		rows[0][k-1-j] = number(se)
+	}
+	if f.constant {
+		v := 1 / f.n
+		for j := range f.means {
+			for l := range f.means {
+				v += f.means[j] * f.covariance[j][l] * f.means[l]
+			}
+		}
+		rows[0][k] = number(sey * math.Sqrt(v))
+	}
+	rows[1][0] = number(f.ssreg / (f.ssreg + f.ssresid))
+	rows[1][1] = number(sey)
+	kept := 0.0
+	for _, d := range f.dropped {
+		if !d {
+			kept++
+		}
+	}
+	rows[2][0] = number(f.ssreg / kept / (f.ssresid / f.df))
+	rows[2][1] = MakeNumberResult(f.df)
+	rows[3][0] = MakeNumberResult(f.ssreg)
+	rows[3][1] = MakeNumberResult(f.ssresid)
+	return rows
+}
+
+// regressionOption returns the logical value of the optional argument i, def
+// if omitted.
+func regressionOption(args []Result, i int, def bool) (bool, Result) {
+	if i >= len(args) || args[i].Type == ResultTypeEmpty {
+		return def, MakeEmptyResult()
+	}
+	return truthValue(args[i])
+}
+
+// Linest implements the Excel LINEST function, which returns the slopes and
+// the intercept of the linear regression of known y values on one or more
+// variables, and the regression statistics if requested.
+func Linest(args []Result) Result {
+	if len(args) < 1 || len(args) > 4 {
+		return MakeErrorResult("LINEST requires one to four arguments")
+	}
+	hasX := len(args) > 1 && args[1].Type != ResultTypeEmpty
+	var xs Result
+	if hasX {
+		xs = args[1]
+	}
+	d, err := regressionValues(args[0], xs, hasX, "LINEST")
+	if err.Type != ResultTypeEmpty {
+		return err
+	}
+	constant, err := regressionOption(args, 2, true)
+	if err.Type == ResultTypeError {
+		return err
+	}
+	stats, err := regressionOption(args, 3, false)
+	if err.Type == ResultTypeError {
+		return err
+	}
+	f := fitLinear(d.y, d.x, constant)
+	k := len(f.coefficients)
+	row := make([]Result, k+1)
+	for j, m := range f.coefficients {
+		row[k-1-j] = MakeNumberResult(m)
+	}
+	row[k] = MakeNumberResult(f.intercept)
+	rows := [][]Result{row}
+	if stats {
+		rows = append(rows, f.statistics()...)
+	}
+	return rowsResult(rows)
+}
+
+// trendFunction returns an implementation of TREND or, if exponential is set,
+// of GROWTH.
+func trendFunction(name string, exponential bool) Function {
+	return func(args []Result) Result {
+		if len(args) < 1 || len(args) > 4 {
+			return MakeErrorResult(name + " requires one to four arguments")
+		}
+		hasX := len(args) > 1 && args[1].Type != ResultTypeEmpty
+		var xs Result
+		if hasX {
+			xs = args[1]
+		}
+		d, err := regressionValues(args[0], xs, hasX, name)
+		if err.Type != ResultTypeEmpty {
+			return err
+		}
+		constant, err := regressionOption(args, 3, true)
+		if err.Type == ResultTypeError {
+			return err
+		}
+		if exponential {
+			for i, v := range d.y {
+				if v <= 0 {
+					return MakeErrorResultType(ErrorTypeNum, name+" requires positive y values")
+				}
+				d.y[i] = math.Log(v)
+			}
+		}
+		f := fitLinear(d.y, d.x, constant)
+		value := func(x []float64) Result {
+			v := f.predict(x)
+			if exponential {
+				v = math.Exp(v)
+			}
+			return MakeNumberResult(v)
+		}
+		// the new x values default to the known ones, 1 to n laid out as the
+		// y values if omitted
+		var newRows [][]Result
+		switch {
+		case len(args) > 2 && args[2].Type != ResultTypeEmpty:
+			newRows = resultRows(args[2])
+		case hasX:
+			newRows = resultRows(xs)
+		default:
+			i := 0
+			for _, row := range resultRows(args[0]) {
+				values := make([]Result, len(row))
+				for j := range values {
+					i++
+					values[j] = MakeNumberResult(float64(i))
+				}
+				newRows = append(newRows, values)
+			}
+		}
+		k := len(f.coefficients)
+		if k == 1 {
+			return predictRows(newRows, value, name)
+		}
+		// one observation per row or column of the new x values, as for
+		// the known ones
+		if !d.rows {
+			newRows = transposeRows(newRows)
+		}
+		if len(newRows[0]) != k {
+			return MakeErrorResultType(ErrorTypeRef, name+" requires new x values matching the known x values")
+		}
+		out := make([][]Result, len(newRows))
+		for i, row := range newRows {
+			x := make([]float64, k)
+			for j, v := range row {
+				if v.Type == ResultTypeError {
+					return v
+				}
+				if v.Type != ResultTypeNumber {
+					return MakeErrorResultType(ErrorTypeValue, name+" requires numeric x values")
+				}
+				x[j] = v.ValueNumber
+			}
+			out[i] = []Result{value(x)}
+		}
+		if !d.rows {
+			out = transposeRows(out)
+		}
+		return rowsResult(out)
+	}
+}
+
+// predictRows returns the predictions of a regression on one variable for
+// each value of an array.
+func predictRows(rows [][]Result, value func(x []float64) Result, name string) Result {
+	out := make([][]Result, len(rows))
+	for i, row := range rows {
+		out[i] = make([]Result, len(row))
+		for j, v := range row {
+			if v.Type == ResultTypeError {
+				return v
+			}
+			if v.Type != ResultTypeNumber {
+				return MakeErrorResultType(ErrorTypeValue, name+" requires numeric x values")
+			}
+			out[i][j] = value([]float64{v.ValueNumber})
+		}
+	}
+	return rowsResult(out)
+}
+
+// Trend implements the Excel TREND function, which returns the y values of
+// new x values predicted by the linear regression of known y values.
+func Trend(args []Result) Result { return trendFunction("TREND", false)(args) }
+
+// Growth implements the Excel GROWTH function, which returns the y values of
+// new x values predicted by the exponential regression of known y values.
+func Growth(args []Result) Result { return trendFunction("GROWTH", true)(args) }
+
+func dotProduct(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+func vectorNorm(a []float64) float64 {
+	return math.Sqrt(dotProduct(a, a))
+}
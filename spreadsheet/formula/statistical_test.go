@@ -0,0 +1,157 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"fmt"
+	"testing"
+)
+
+// cellContext is an evaluation context with the values of a few cells.
+type cellContext struct {
+	*ivr
+	cells map[string]Result
+}
+
+func (c *cellContext) Cell(ref string, ev Evaluator) Result {
+	if r, ok := c.cells[ref]; ok {
+		return r
+	}
+	return MakeEmptyResult()
+}
+
+func (c *cellContext) Sheet(name string) Context { return c }
+
+// set sets the values of a column starting from row 1.
+func (c *cellContext) set(col string, values ...interface{}) {
+	for i, v := range values {
+		ref := fmt.Sprintf("%s%d", col, i+1)
+		switch v := v.(type) {
+		case int:
+			c.cells[ref] = MakeNumberResult(float64(v))
+		case float64:
+			c.cells[ref] = MakeNumberResult(v)
+		case string:
+			c.cells[ref] = MakeStringResult(v)
+		case bool:
+			c.cells[ref] = MakeBoolResult(v)
+		}
+	}
+}
+
+func statisticsContext() *cellContext {
+	c := &cellContext{cells: map[string]Result{}}
+	c.set("A", 1345, 1301, 1368, 1322, 1310, 1370, 1318, 1350, 1303, 1299)
+	c.set("B", 100000, 200000, 300000, 400000)
+	c.set("C", 7000, 14000, 21000, 28000)
+	c.set("D", 7, 3.5, 3.5, 1, 2)
+	c.set("E", 89, 88, 92, 101, 94, 97, 95)
+	c.set("F", 11, 12, 13, 14, 15, 16)
+	c.set("G", 33100, 47300, 69000, 102000, 150000, 220000)
+	c.set("H", 1, 2, "x", true, 4)
+	c.set("I", 1, 9, 5, 7)
+	c.set("J", 0, 4, 2, 3)
+	c.set("K", 17, 18)
+	return c
+}
+
+// The expected values are those Excel returns, most of them from the examples
+// of the Excel documentation.
+func TestStatistical(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    float64
+	}{
+		{"STDEV.S(A1:A10)", 27.46391572},
+		{"STDEV(A1:A10)", 27.46391572},
+		{"STDEV.P(A1:A10)", 26.05456814},
+		{"STDEVP(A1:A10)", 26.05456814},
+		{"VAR.S(A1:A10)", 754.2666667},
+		{"VAR.P(A1:A10)", 678.84},
+		{"VAR(1,2,3,4)", 1.666666667},
+		{"STDEV(H1:H5)", 1.527525232},
+		{"STDEVA(H1:H5)", 1.516575089},
+		{"VARPA(H1:H5)", 1.84},
+		{"STDEV(1,TRUE,\"3\")", 1.154700538},
+		{"AVERAGEIF(C1:C4,\"<23000\")", 14000},
+		{"AVERAGEIF(B1:B4,\"<250000\")", 150000},
+		{"AVERAGEIF(B1:B4,\">250000\",C1:C4)", 24500},
+		{"AVERAGEIFS(C1:C4,B1:B4,\">100000\",B1:B4,\"<400000\")", 17500},
+		{"MODE.SNGL({5.6,4,4,3,2,4})", 4},
+		{"MODE(1,2,2,3,3)", 2},
+		{"SUM(MODE.MULT({1,2,3,4,3,2,1,2,3,5,6,1}))", 6},
+		{"ROWS(MODE.MULT({1,2,3,4,3,2,1,2,3,5,6,1}))", 3},
+		{"PERCENTILE.INC({1,3,2,4},0.3)", 1.9},
+		{"PERCENTILE({1,3,2,4},0.3)", 1.9},
+		{"PERCENTILE.EXC({1,2,3,6,6,6,7,8,9},0.25)", 2.5},
+		{"QUARTILE.INC({1,2,4,7,8,9,10,12},1)", 3.5},
+		{"QUARTILE.EXC({6,7,15,36,39,40,41,42,43,47,49},1)", 15},
+		{"QUARTILE.EXC({6,7,15,36,39,40,41,42,43,47,49},3)", 43},
+		{"RANK.EQ(D1,D1:D5,1)", 5},
+		{"RANK.EQ(D5,D1:D5)", 4},
+		{"RANK.EQ(D2,D1:D5,1)", 3},
+		{"RANK(D2,D1:D5)", 2},
+		{"RANK.AVG(D2,D1:D5)", 2.5},
+		{"RANK.AVG(94,E1:E7)", 4},
+		{"CORREL({3,2,4,5,6},{9,7,12,15,17})", 0.997054486},
+		{"PEARSON({9,7,5,3,1},{10,6,1,5,3})", 0.699379},
+		{"COVARIANCE.P({3,2,4,5,6},{9,7,12,15,17})", 5.2},
+		{"COVAR({3,2,4,5,6},{9,7,12,15,17})", 5.2},
+		{"COVARIANCE.S({2,4,8},{5,11,12})", 9.666666667},
+		{"SLOPE({2,3,9,1,8,7,5},{6,5,11,7,5,4,4})", 0.305556},
+		{"INTERCEPT({2,3,9,1,8},{6,5,11,7,5})", 0.0483871},
+		{"RSQ({2,3,9,1,8,7,5},{6,5,11,7,5,4,4})", 0.05795},
+		{"STEYX({2,3,9,1,8,7,5},{6,5,11,7,5,4,4})", 3.305719},
+		{"FORECAST(30,{6,7,9,15,21},{20,28,31,38,40})", 10.607253},
+		{"FORECAST.LINEAR(30,{6,7,9,15,21},{20,28,31,38,40})", 10.607253},
+		{"INDEX(LINEST(I1:I4,J1:J4),1,1)", 2},
+		{"INDEX(LINEST(I1:I4,J1:J4),1,2)", 1},
+		{"INDEX(GROWTH(G1:G6,F1:F6),1,1)", 32618.20377},
+		{"INDEX(GROWTH(G1:G6,F1:F6,K1:K2),1,1)", 320196.7184},
+		{"INDEX(GROWTH(G1:G6,F1:F6,K1:K2),2,1)", 468536.0539},
+		{"SUM(TREND({1,2,3},{1,2,3},{4}))", 4},
+		{"INDEX(TREND(I1:I4,J1:J4),3,1)", 5},
+	}
+	ctx := statisticsContext()
+	for _, tc := range tests {
+		checkResult(t, tc.formula, NewEvaluator().Eval(ctx, StoredFormula(tc.formula)), tc.want)
+	}
+}
+
+func TestStatisticalErrors(t *testing.T) {
+	tests := []struct {
+		formula string
+		want    string
+	}{
+		{"STDEV(1)", "#DIV/0!"},
+		{"VAR.S(H3)", "#DIV/0!"},
+		{"STDEV(A1:A10,1/0)", "#DIV/0!"},
+		{"AVERAGEIF(B1:B4,\"<95000\")", "#DIV/0!"},
+		{"AVERAGEIFS(C1:C4,B1:B3,\">0\")", "#VALUE!"},
+		{"MODE(1,2,3)", "#N/A"},
+		{"PERCENTILE.EXC({1,2,3,6,6,6,7,8,9},0)", "#NUM!"},
+		{"PERCENTILE.EXC({1,2,3,6,6,6,7,8,9},0.01)", "#NUM!"},
+		{"PERCENTILE.INC({1,2,3},1.5)", "#NUM!"},
+		{"QUARTILE.EXC({1,2,3},4)", "#NUM!"},
+		{"RANK(99,D1:D5)", "#N/A"},
+		{"CORREL({1,2},{1,2,3})", "#N/A"},
+		{"SLOPE({1,2},{1,2,3})", "#N/A"},
+		{"CORREL({1,1,1},{1,2,3})", "#DIV/0!"},
+		{"SLOPE({1,2,3},{2,2,2})", "#DIV/0!"},
+		{"GROWTH({1,-1},{1,2})", "#NUM!"},
+	}
+	ctx := statisticsContext()
+	for _, tc := range tests {
+		r := NewEvaluator().Eval(ctx, StoredFormula(tc.formula))
+		if r.Type != ResultTypeError || r.ValueString != tc.want {
+			t.Errorf("%s = %v %q, want %s", tc.formula, r.Type, r.Value(), tc.want)
+		}
+	}
+}
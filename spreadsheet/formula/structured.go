@@ -0,0 +1,522 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+)
+
+// Special items of structured references, selecting rows of a table.
+const (
+	TableItemAll     = "#All"
+	TableItemData    = "#Data"
+	TableItemHeaders = "#Headers"
+	TableItemTotals  = "#Totals"
+	TableItemThisRow = "#This Row"
+)
+
+// TableDefinition describes an Excel table for the resolution of structured
+// references such as Table1[Column], Table1[#Totals] or [@Column].
+type TableDefinition struct {
+	Name  string
+	Sheet string
+
+	// Reference is the range of the whole table, e.g. "A1:C10", including its
+	// header and totals rows.
+	Reference string
+	Columns   []string
+	HeaderRow bool
+	TotalsRow bool
+}
+
+// TableContext is implemented by evaluation contexts able to resolve
+// structured references. The default evaluator replaces the structured
+// references of formulas evaluated in such a context with the ranges they
+// refer to.
+type TableContext interface {
+	// Tables returns the tables of the workbook.
+	Tables() []TableDefinition
+
+	// CurrentCell returns the sheet and the reference of the cell being
+	// evaluated, used by references to the current row and by references
+	// without table name written within a table.
+	CurrentCell() (sheet, cell string)
+}
+
+// StructuredReference is a reference to the cells of a table, written as
+// Table1[Column], Table1[[#Headers],[Column1]:[Column2]] or [@Column].
+type StructuredReference struct {
+	// Table is the table name. It is empty for references within a table.
+	Table string
+
+	// Items are the special items selecting the rows of the table. Rows of
+	// data are selected if there are none.
+	Items []string
+
+	// FirstColumn and LastColumn are the columns selected, all of them if
+	// empty. LastColumn is empty for a single column.
+	FirstColumn, LastColumn string
+}
+
+// ParseStructuredReference parses a structured reference. Both the "@" and
+// the "[#This Row]" notations of references to the current row are accepted.
+func ParseStructuredReference(s string) (StructuredReference, error) {
+	open := strings.IndexByte(s, '[')
+	if open < 0 || !strings.HasSuffix(s, "]") {
+		return StructuredReference{}, fmt.Errorf("invalid structured reference %q", s)
+	}
+	ref := StructuredReference{Table: s[:open]}
+	if ref.Table != "" && !isTableName(ref.Table) {
+		return StructuredReference{}, fmt.Errorf("invalid table name in %q", s)
+	}
+	if err := ref.parseSpecifier(s[open+1 : len(s)-1]); err != nil {
+		return StructuredReference{}, fmt.Errorf("invalid structured reference %q: %s", s, err)
+	}
+	return ref, nil
+}
+
+func (r *StructuredReference) parseSpecifier(inner string) error {
+	inner = strings.TrimSpace(inner)
+	switch {
+	case inner == "":
+		return nil
+	case inner[0] == '@':
+		r.Items = []string{TableItemThisRow}
+		rest := strings.TrimSpace(inner[1:])
+		if rest == "" {
+			return nil
+		}
+		if rest[0] != '[' {
+			r.FirstColumn = unescapeColumn(rest)
+			return nil
+		}
+		return r.parseList(rest)
+	case inner[0] == '#':
+		item, ok := tableItem(inner)
+		if !ok {
+			return fmt.Errorf("unknown item %s", inner)
+		}
+		r.Items = []string{item}
+		return nil
+	case inner[0] == '[':
+		return r.parseList(inner)
+	}
+	r.FirstColumn = unescapeColumn(inner)
+	return nil
+}
+
+// parseList parses a list of bracketed items and columns, such as
+// "[#Headers],[Column1]:[Column2]".
+func (r *StructuredReference) parseList(s string) error {
+	var columns []string
+	rangeNext := false
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		switch s[0] {
+		case ',':
+			s = s[1:]
+			continue
+		case ':':
+			rangeNext = true
+			s = s[1:]
+			continue
+		case '[':
+		default:
+			return fmt.Errorf("unexpected %q", s)
+		}
+		end := closingBracket(s, 0)
+		if end < 0 {
+			return errors.New("unbalanced brackets")
+		}
+		part := s[1:end]
+		s = s[end+1:]
+		if strings.HasPrefix(part, "#") {
+			item, ok := tableItem(part)
+			if !ok {
+				return fmt.Errorf("unknown item %s", part)
+			}
+			r.Items = append(r.Items, item)
+			continue
+		}
+		if len(columns) > 0 && !rangeNext {
+			return errors.New("columns must form a range")
+		}
+		columns = append(columns, unescapeColumn(part))
+		rangeNext = false
+	}
+	switch len(columns) {
+	case 0:
+	case 1:
+		r.FirstColumn = columns[0]
+	case 2:
+		r.FirstColumn, r.LastColumn = columns[0], columns[1]
+	default:
+		return errors.New("too many columns")
+	}
+	return nil
+}
+
+// String returns the reference as written in files, with [#This Row] for
+// references to the current row.
+func (r StructuredReference) String() string {
+	var parts []string
+	for _, item := range r.Items {
+		parts = append(parts, "["+item+"]")
+	}
+	if r.FirstColumn != "" {
+		col := "[" + escapeColumn(r.FirstColumn) + "]"
+		if r.LastColumn != "" {
+			col += ":[" + escapeColumn(r.LastColumn) + "]"
+		}
+		parts = append(parts, col)
+	}
+	switch {
+	case len(parts) == 0:
+		return r.Table + "[]"
+	case len(parts) == 1 && r.LastColumn == "":
+		return r.Table + parts[0]
+	}
+	return r.Table + "[" + strings.Join(parts, ",") + "]"
+}
+
+// Resolve returns the range of cells of a table the reference refers to,
+// prefixed with the sheet name. The row of the cell being evaluated, 1-N, is
+// used by references to the current row.
+func (r StructuredReference) Resolve(t TableDefinition, row uint32) (string, error) {
+	from, to, err := reference.ParseRangeReference(t.Reference)
+	if err != nil {
+		return "", err
+	}
+	first, last := from.ColumnIdx, to.ColumnIdx
+	if r.FirstColumn != "" {
+		c1, ok1 := tableColumn(t, r.FirstColumn)
+		c2, ok2 := c1, true
+		if r.LastColumn != "" {
+			c2, ok2 = tableColumn(t, r.LastColumn)
+		}
+		if !ok1 || !ok2 {
+			return "", fmt.Errorf("no such column in table %s", t.Name)
+		}
+		if c1 > c2 {
+			c1, c2 = c2, c1
+		}
+		first, last = from.ColumnIdx+c1, from.ColumnIdx+c2
+	}
+
+	dataFrom, dataTo := from.RowIdx, to.RowIdx
+	if t.HeaderRow {
+		dataFrom++
+	}
+	if t.TotalsRow {
+		dataTo--
+	}
+	items := r.Items
+	if len(items) == 0 {
+		items = []string{TableItemData}
+	}
+	var top, bottom uint32
+	for i, item := range items {
+		lo, hi := dataFrom, dataTo
+		switch item {
+		case TableItemAll:
+			lo, hi = from.RowIdx, to.RowIdx
+		case TableItemHeaders:
+			if !t.HeaderRow {
+				return "", fmt.Errorf("table %s has no header row", t.Name)
+			}
+			lo, hi = from.RowIdx, from.RowIdx
+		case TableItemTotals:
+			if !t.TotalsRow {
+				return "", fmt.Errorf("table %s has no totals row", t.Name)
+			}
+			lo, hi = to.RowIdx, to.RowIdx
+		case TableItemThisRow:
+			if row < dataFrom || row > dataTo {
+				return "", errors.New("current row is outside of the table data")
+			}
+			lo, hi = row, row
+		}
+		if i == 0 || lo < top {
+			top = lo
+		}
+		if i == 0 || hi > bottom {
+			bottom = hi
+		}
+	}
+	if top > bottom {
+		return "", fmt.Errorf("table %s has no data rows", t.Name)
+	}
+
+	ref := reference.IndexToColumn(first) + strconv.Itoa(int(top))
+	if first != last || top != bottom {
+		ref += ":" + reference.IndexToColumn(last) + strconv.Itoa(int(bottom))
+	}
+	if t.Sheet == "" {
+		return ref, nil
+	}
+	return quoteSheetName(t.Sheet) + "!" + ref, nil
+}
+
+// MapStructuredReferences calls fn for each structured reference of a formula
+// and replaces the reference with the one returned. References that fn leaves
+// unchanged keep their original spelling.
+func MapStructuredReferences(f string, fn func(StructuredReference) StructuredReference) string {
+	return ReplaceStructuredReferences(f, func(ref StructuredReference) (string, bool) {
+		mapped := fn(ref)
+		return mapped.String(), mapped.String() != ref.String()
+	})
+}
+
+// ReplaceStructuredReferences calls fn for each structured reference of a
+// formula and replaces the reference with the text returned if fn returns
+// true, e.g. with the range it refers to.
+func ReplaceStructuredReferences(f string, fn func(StructuredReference) (string, bool)) string {
+	return rewriteStructuredReferences(f, func(s string) (string, bool) {
+		ref, err := ParseStructuredReference(s)
+		if err != nil {
+			return "", false
+		}
+		return fn(ref)
+	})
+}
+
+// CanonicalStructuredReferences returns a formula with its structured
+// references written as Excel stores them in files: with the name of their
+// table, which defaults to table, and with [#This Row] instead of "@".
+func CanonicalStructuredReferences(f string, table string) string {
+	return rewriteStructuredReferences(f, func(s string) (string, bool) {
+		ref, err := ParseStructuredReference(s)
+		if err != nil {
+			return "", false
+		}
+		if ref.Table == "" {
+			ref.Table = table
+		}
+		return ref.String(), ref.String() != s
+	})
+}
+
+// ResolveStructuredReferences replaces the structured references of a formula
+// with the ranges they refer to, or with #REF! errors if they can't be
+// resolved. Formulas without structured references are returned unchanged.
+func ResolveStructuredReferences(f string, ctx TableContext) string {
+	if !strings.Contains(f, "[") {
+		return f
+	}
+	tables := ctx.Tables()
+	sheet, cell := ctx.CurrentCell()
+	current, _ := reference.ParseCellReference(cell)
+	return rewriteStructuredReferences(f, func(s string) (string, bool) {
+		ref, err := ParseStructuredReference(s)
+		if err != nil {
+			return "", false
+		}
+		t, ok := findTable(tables, ref.Table, sheet, current)
+		if !ok {
+			return refError, true
+		}
+		resolved, err := ref.Resolve(t, current.RowIdx)
+		if err != nil {
+			return refError, true
+		}
+		return resolved, true
+	})
+}
+
+// expandStructuredReferences resolves the structured references of a formula
+// if the context supports it.
+func expandStructuredReferences(ctx Context, f string) string {
+	if tc, ok := ctx.(TableContext); ok {
+		return ResolveStructuredReferences(f, tc)
+	}
+	return f
+}
+
+// findTable returns the table with the given name, or the table containing the
+// current cell if name is empty.
+func findTable(tables []TableDefinition, name, sheet string, current reference.CellReference) (TableDefinition, bool) {
+	for _, t := range tables {
+		if name != "" {
+			if strings.EqualFold(t.Name, name) {
+				return t, true
+			}
+			continue
+		}
+		if !strings.EqualFold(t.Sheet, sheet) {
+			continue
+		}
+		from, to, err := reference.ParseRangeReference(t.Reference)
+		if err == nil && current.RowIdx >= from.RowIdx && current.RowIdx <= to.RowIdx &&
+			current.ColumnIdx >= from.ColumnIdx && current.ColumnIdx <= to.ColumnIdx {
+			return t, true
+		}
+	}
+	return TableDefinition{}, false
+}
+
+func tableColumn(t TableDefinition, name string) (uint32, bool) {
+	for i, c := range t.Columns {
+		if strings.EqualFold(c, name) {
+			return uint32(i), true
+		}
+	}
+	return 0, false
+}
+
+// rewriteStructuredReferences calls fn with each structured reference of a
+// formula, including its table name, and replaces the reference when fn
+// returns true. Strings, quoted sheet names and external workbook references
+// are left alone.
+func rewriteStructuredReferences(f string, fn func(string) (string, bool)) string {
+	if !strings.Contains(f, "[") {
+		return f
+	}
+	var out strings.Builder
+	nameStart := -1
+	for i := 0; i < len(f); i++ {
+		ch := f[i]
+		switch {
+		case ch == '"' || ch == '\'':
+			end := closingQuote(f, i)
+			out.WriteString(f[i:end])
+			i = end - 1
+			nameStart = -1
+			continue
+		case ch == '[':
+			end := closingBracket(f, i)
+			if end < 0 {
+				out.WriteString(f[i:])
+				return out.String()
+			}
+			start := i
+			if nameStart >= 0 {
+				start = nameStart
+			}
+			// external workbook references such as [1]Sheet1!A1
+			if isExternalReference(f, end+1) {
+				out.WriteString(f[i : end+1])
+			} else if repl, ok := fn(f[start : end+1]); ok {
+				s := out.String()
+				out.Reset()
+				out.WriteString(s[:len(s)-(i-start)])
+				out.WriteString(repl)
+			} else {
+				out.WriteString(f[i : end+1])
+			}
+			i = end
+			nameStart = -1
+			continue
+		case isNameByte(ch):
+			if nameStart < 0 {
+				nameStart = i
+			}
+		default:
+			nameStart = -1
+		}
+		out.WriteByte(ch)
+	}
+	return out.String()
+}
+
+// closingQuote returns the index following the string or quoted name
+// starting at i, doubled quotes being escapes.
+func closingQuote(s string, i int) int {
+	q := s[i]
+	for j := i + 1; j < len(s); j++ {
+		if s[j] != q {
+			continue
+		}
+		if j+1 < len(s) && s[j+1] == q {
+			j++
+			continue
+		}
+		return j + 1
+	}
+	return len(s)
+}
+
+// closingBracket returns the index of the bracket closing the one at i, or -1.
+// Within brackets, a quote escapes the following character.
+func closingBracket(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\'':
+			j++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+func isExternalReference(f string, i int) bool {
+	j := i
+	for j < len(f) && (isNameByte(f[j]) || f[j] == ' ') {
+		j++
+	}
+	return j > i && j < len(f) && f[j] == '!'
+}
+
+func isNameByte(ch byte) bool {
+	return ch == '_' || ch == '.' || ch == '\\' || ch >= 0x80 ||
+		('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ('0' <= ch && ch <= '9')
+}
+
+func isTableName(name string) bool {
+	for i, r := range name {
+		if !(r == '_' || r == '\\' || unicode.IsLetter(r) || (i > 0 && (r == '.' || unicode.IsDigit(r)))) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+func tableItem(s string) (string, bool) {
+	for _, item := range []string{TableItemAll, TableItemData, TableItemHeaders, TableItemTotals, TableItemThisRow} {
+		if strings.EqualFold(strings.TrimSpace(s), item) {
+			return item, true
+		}
+	}
+	return "", false
+}
+
+func unescapeColumn(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\'' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func escapeColumn(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[', ']', '#', '\'':
+			b.WriteByte('\'')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
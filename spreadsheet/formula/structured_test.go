@@ -0,0 +1,35 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import "testing"
+
+func TestParseStructuredReferences(t *testing.T) {
+	for _, f := range []string{
+		"Sales[Qty]",
+		"SUM(Sales[Qty])",
+		"MAX(Sales[Qty])",
+		"SUM(1,Sales[Qty])",
+		"SUM(Sales[[#Totals],[Qty]])",
+		"SUM(Sales[[Qty]:[Price]])",
+		"[@Qty]*2",
+		"Sales[[#This Row],[Qty]]*[@Price]",
+		"SUM(Sales['#Items])",
+	} {
+		expr := ParseString(f)
+		if expr == nil {
+			t.Errorf("ParseString(%q) = nil", f)
+			continue
+		}
+		if got := expr.String(); got != f {
+			t.Errorf("ParseString(%q) = %q", f, got)
+		}
+	}
+}
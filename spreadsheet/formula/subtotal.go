@@ -0,0 +1,52 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+func init() {
+	RegisterFunction("SUBTOTAL", Subtotal)
+}
+
+// subtotalFunctions are the functions applied by SUBTOTAL, by function number
+// modulo 100. The numbers above 100, which ignore hidden rows in Excel, are
+// evaluated as the numbers below as rows visibility isn't known here.
+var subtotalFunctions = map[int]string{
+	1:  "AVERAGE",
+	2:  "COUNT",
+	3:  "COUNTA",
+	4:  "MAX",
+	5:  "MIN",
+	6:  "PRODUCT",
+	7:  "STDEV",
+	8:  "STDEVP",
+	9:  "SUM",
+	10: "VAR",
+	11: "VARP",
+}
+
+// Subtotal is an implementation of the Excel SUBTOTAL function, used by the
+// totals rows of tables.
+func Subtotal(args []Result) Result {
+	if len(args) < 2 {
+		return MakeErrorResult("SUBTOTAL requires at least two arguments")
+	}
+	num := args[0].AsNumber()
+	if num.Type != ResultTypeNumber {
+		return MakeErrorResultType(ErrorTypeValue, "SUBTOTAL requires a numeric function number")
+	}
+	name, ok := subtotalFunctions[int(num.ValueNumber)%100]
+	if !ok || num.ValueNumber < 1 || num.ValueNumber >= 112 {
+		return MakeErrorResultType(ErrorTypeValue, "SUBTOTAL function number out of range")
+	}
+	fn := LookupFunction(name)
+	if fn == nil {
+		return MakeErrorResultType(ErrorTypeValue, "SUBTOTAL function "+name+" isn't supported")
+	}
+	return fn(args[1:])
+}
@@ -0,0 +1,334 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+	"github.com/unidoc/unioffice/v2/spreadsheet/update"
+)
+
+// refError is the reference to cells that were removed.
+const refError = "#REF!"
+
+func updateCellRef(c CellRef, q *update.UpdateQuery) Expression {
+	if !q.UpdateCurrentSheet {
+		return c
+	}
+	ref, ok := reference.UpdateRangeReference(c._gge, q)
+	if !ok {
+		ref = refError
+	}
+	return CellRef{_gge: ref}
+}
+
+// updateRangeEnds updates the ends of a range as a whole, so that the range
+// shrinks when some of its cells are removed instead of turning into an
+// error. Ends that aren't cell references are updated on their own.
+func updateRangeEnds(from, to Expression, q *update.UpdateQuery) (Expression, Expression, bool) {
+	fc, fok := from.(CellRef)
+	tc, tok := to.(CellRef)
+	if !fok || !tok {
+		return from.Update(q), to.Update(q), true
+	}
+	ref, ok := reference.UpdateRangeReference(fc._gge+":"+tc._gge, q)
+	if !ok {
+		return nil, nil, false
+	}
+	ends := strings.SplitN(ref, ":", 2)
+	return CellRef{_gge: ends[0]}, CellRef{_gge: ends[1]}, true
+}
+
+func updateRange(r Range, q *update.UpdateQuery) Expression {
+	if !q.UpdateCurrentSheet {
+		return r
+	}
+	from, to, ok := updateRangeEnds(r._agbg, r._eaebg, q)
+	if !ok {
+		return CellRef{_gge: refError}
+	}
+	return Range{_agbg: from, _eaebg: to}
+}
+
+func updateVerticalRange(r VerticalRange, q *update.UpdateQuery) Expression {
+	if !q.UpdateCurrentSheet || !q.UpdatesColumns() {
+		return r
+	}
+	from, to, ok := updateColumns(r._ddaf, r._addad, q)
+	if !ok {
+		return CellRef{_gge: refError}
+	}
+	return VerticalRange{_ddaf: from, _addad: to}
+}
+
+func updateHorizontalRange(r HorizontalRange, q *update.UpdateQuery) Expression {
+	if !q.UpdateCurrentSheet || !q.UpdatesRows() {
+		return r
+	}
+	from, to, ok := q.UpdateSpan(uint32(r._gcabd), uint32(r._gfbga))
+	if !ok {
+		return CellRef{_gge: refError}
+	}
+	return HorizontalRange{_gcabd: int(from), _gfbga: int(to), _cgfeb: r._cgfeb, _aadbf: r._aadbf}
+}
+
+func updatePrefixExpr(p PrefixExpr, q *update.UpdateQuery) Expression {
+	p._fdfge = quotePrefix(p._fdfge)
+	if !sheetMatches(p._fdfge, q) {
+		return p
+	}
+	return PrefixExpr{_fdfge: p._fdfge, _fecb: p._fecb.Update(onSheet(q))}
+}
+
+func updatePrefixRangeExpr(p PrefixRangeExpr, q *update.UpdateQuery) Expression {
+	p._bcdd = quotePrefix(p._bcdd)
+	if !sheetMatches(p._bcdd, q) {
+		return p
+	}
+	from, to, ok := updateRangeEnds(p._dfedb, p._bebbg, onSheet(q))
+	if !ok {
+		return PrefixExpr{_fdfge: p._bcdd, _fecb: CellRef{_gge: refError}}
+	}
+	return PrefixRangeExpr{_bcdd: p._bcdd, _dfedb: from, _bebbg: to}
+}
+
+func updatePrefixVerticalRange(p PrefixVerticalRange, q *update.UpdateQuery) Expression {
+	p._decac = quotePrefix(p._decac)
+	if !q.UpdatesColumns() || !sheetMatches(p._decac, q) {
+		return p
+	}
+	from, to, ok := updateColumns(p._bddaf, p._cfbe, q)
+	if !ok {
+		return PrefixExpr{_fdfge: p._decac, _fecb: CellRef{_gge: refError}}
+	}
+	return PrefixVerticalRange{_decac: p._decac, _bddaf: from, _cfbe: to}
+}
+
+func updatePrefixHorizontalRange(p PrefixHorizontalRange, q *update.UpdateQuery) Expression {
+	p._efaa = quotePrefix(p._efaa)
+	if !q.UpdatesRows() || !sheetMatches(p._efaa, q) {
+		return p
+	}
+	from, to, ok := q.UpdateSpan(uint32(p._fceca), uint32(p._fegg))
+	if !ok {
+		return PrefixExpr{_fdfge: p._efaa, _fecb: CellRef{_gge: refError}}
+	}
+	return PrefixHorizontalRange{_efaa: p._efaa, _fceca: int(from), _fegg: int(to), _fcbeg: p._fcbeg, _ebgce: p._ebgce}
+}
+
+func updateColumns(from, to string, q *update.UpdateQuery) (string, string, bool) {
+	ref, ok := reference.UpdateRangeReference(from+":"+to, q)
+	if !ok {
+		return "", "", false
+	}
+	ends := strings.SplitN(ref, ":", 2)
+	return ends[0], ends[1], true
+}
+
+// parseRowBound parses one end of a row range such as "$3", returning the row
+// number and whether it is absolute.
+func parseRowBound(s string) (int, bool) {
+	abs := strings.HasPrefix(s, "$")
+	row, _ := strconv.Atoi(strings.TrimPrefix(s, "$"))
+	return row, abs
+}
+
+func formatRowBound(row int, abs bool) string {
+	if abs {
+		return "$" + strconv.Itoa(row)
+	}
+	return strconv.Itoa(row)
+}
+
+// onSheet returns a copy of the query applying to references without sheet
+// prefix, used for the references following a matching prefix.
+func onSheet(q *update.UpdateQuery) *update.UpdateQuery {
+	c := *q
+	c.UpdateCurrentSheet = true
+	return &c
+}
+
+// quotedPrefix is a sheet prefix written with quotes when the sheet name
+// needs them. The parser drops the quotes of sheet names, which would make
+// updated formulas referring to such sheets unparseable.
+type quotedPrefix struct{ Expression }
+
+func (p quotedPrefix) String() string {
+	name := p.Expression.String()
+	if strings.HasPrefix(name, "'") {
+		return name
+	}
+	return quoteSheetName(name)
+}
+
+// quoteSheetName returns a sheet name as written in formulas, quoted if it
+// contains characters other than letters, digits, dots and underscores.
+func quoteSheetName(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '.' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+		}
+	}
+	return name
+}
+
+func (p quotedPrefix) Update(q *update.UpdateQuery) Expression { return p }
+
+func quotePrefix(pfx Expression) Expression {
+	if _, ok := pfx.(quotedPrefix); ok {
+		return pfx
+	}
+	return quotedPrefix{pfx}
+}
+
+// sheetMatches returns true if a sheet prefix names the sheet being updated.
+// Sheet names are compared unquoted and case insensitively, as in Excel.
+func sheetMatches(pfx Expression, q *update.UpdateQuery) bool {
+	return strings.EqualFold(prefixSheet(pfx), q.SheetToUpdate)
+}
+
+// UpdateFormula updates the references of a formula with the query and
+// returns the updated formula. Only the references are rewritten, each one
+// parsed and updated on its own, so that the rest of the formula keeps its
+// original spelling, including parentheses, operators and whitespace. This
+// also updates lists of references separated by commas, as found in defined
+// names such as print titles. References to other workbooks and to several
+// sheets are left unchanged.
+func UpdateFormula(f string, q *update.UpdateQuery) string {
+	b := strings.Builder{}
+	for i := 0; i < len(f); {
+		c := f[i]
+		switch {
+		case c == '"':
+			j := skipFormulaGroup(f, i)
+			b.WriteString(f[i:j])
+			i = j
+		case c == '[':
+			// structured references, and external references such as
+			// [1]Sheet1!A1 which are copied as a whole
+			j := skipFormulaGroup(f, i)
+			if isExternalReference(f, j) {
+				j = referenceEnd(f, j)
+			}
+			b.WriteString(f[i:j])
+			i = j
+		case c == '#':
+			// error values such as #REF! and #DIV/0!
+			j := i + 1
+			for j < len(f) && (isNameChar(f[j]) || f[j] == '/') {
+				j++
+			}
+			if j < len(f) && (f[j] == '!' || f[j] == '?') {
+				j++
+			}
+			b.WriteString(f[i:j])
+			i = j
+		case c == '\'' || isNameChar(c):
+			j := referenceEnd(f, i)
+			ref := f[i:j]
+			switch {
+			case j < len(f) && f[j] == '(':
+				// function names
+			case c >= '0' && c <= '9' || c == '.':
+				if !strings.Contains(ref, ":") {
+					// numbers, with the sign of their exponent
+					for j < len(f) && (isNameChar(f[j]) || (f[j] == '+' || f[j] == '-') && (f[j-1] == 'E' || f[j-1] == 'e')) {
+						j++
+					}
+					ref = f[i:j]
+					break
+				}
+				ref = updateReference(ref, q)
+			case strings.HasPrefix(ref, "'["):
+				// quoted external references
+			default:
+				ref = updateReference(ref, q)
+				// the spill range of a removed cell is a reference error
+				if j < len(f) && f[j] == '#' && strings.HasSuffix(ref, refError) {
+					j++
+				}
+			}
+			b.WriteString(ref)
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// referenceEnd returns the end of the name, number or reference starting at
+// i, including its sheet prefix and the end of a range. The ends of 3D
+// references such as Sheet1:Sheet3!A1 are included as well.
+func referenceEnd(f string, i int) int {
+	j := nameEnd(f, i)
+	if j+1 < len(f) && f[j] == ':' && (isNameChar(f[j+1]) || f[j+1] == '\'') {
+		if k := nameEnd(f, j+1); k >= len(f) || f[k] != '(' {
+			return k
+		}
+	}
+	return j
+}
+
+// sheetPrefixEnd returns the end of the sheet prefix of a reference, after
+// its exclamation mark, or 0 if it has none.
+func sheetPrefixEnd(ref string) int {
+	j := 0
+	if strings.HasPrefix(ref, "'") {
+		j = closingQuote(ref, 0)
+	}
+	if k := strings.IndexByte(ref[j:], '!'); k >= 0 {
+		return j + k + 1
+	}
+	return 0
+}
+
+// updateReference updates a reference with the query. The sheet prefix keeps
+// its original spelling, and may be repeated at the end of a range as in
+// Sheet1!A1:Sheet1!B5. References that aren't affected by the query, and
+// names or references that can't be parsed, such as 3D references, are
+// returned unchanged.
+func updateReference(ref string, q *update.UpdateQuery) string {
+	n := sheetPrefixEnd(ref)
+	prefix, rest := ref[:n], ref[n:]
+	sheet := unquoteSheetName(strings.TrimSuffix(prefix, "!"))
+	if prefix != "" {
+		if !strings.EqualFold(sheet, q.SheetToUpdate) {
+			return ref
+		}
+		q = onSheet(q)
+	}
+	endPrefix := ""
+	if i := strings.IndexByte(rest, ':'); i >= 0 && prefix != "" {
+		if m := sheetPrefixEnd(rest[i+1:]); m > 0 {
+			endPrefix = rest[i+1 : i+1+m]
+			if !strings.EqualFold(unquoteSheetName(endPrefix[:m-1]), sheet) {
+				return ref
+			}
+			rest = rest[:i+1] + rest[i+1+m:]
+		}
+	}
+	expr := ParseString(rest)
+	if expr == nil {
+		return ref
+	}
+	updated := expr.Update(q).String()
+	if updated == expr.Update(&update.UpdateQuery{UpdateType: q.UpdateType}).String() {
+		return ref
+	}
+	if i := strings.IndexByte(updated, ':'); i >= 0 {
+		updated = updated[:i+1] + endPrefix + updated[i+1:]
+	}
+	return prefix + updated
+}
@@ -0,0 +1,58 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package formula
+
+import (
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/spreadsheet/update"
+)
+
+func TestUpdateFormula(t *testing.T) {
+	insertRow := &update.UpdateQuery{UpdateType: update.UpdateActionInsertRow, RowIdx: 1, Count: 1, SheetToUpdate: "Sheet1", UpdateCurrentSheet: true}
+	otherSheet := &update.UpdateQuery{UpdateType: update.UpdateActionInsertRow, RowIdx: 1, Count: 1, SheetToUpdate: "My sheet"}
+	removeRow := &update.UpdateQuery{UpdateType: update.UpdateActionRemoveRow, RowIdx: 2, Count: 1, SheetToUpdate: "Sheet1", UpdateCurrentSheet: true}
+	removeColumn := &update.UpdateQuery{UpdateType: update.UpdateActionRemoveColumn, ColumnIdx: 1, Count: 1, SheetToUpdate: "Sheet1", UpdateCurrentSheet: true}
+	tests := []struct {
+		formula string
+		query   *update.UpdateQuery
+		want    string
+	}{
+		// the formula keeps its spelling, only the references change
+		{"=(A1+A2)*A3", insertRow, "=(A2+A3)*A4"},
+		{"=(A1+A2)*A3", removeRow, "=(A1+#REF!)*A2"},
+		{"SUM(Sheet1!A1:B2, 'My sheet'!$C$3)", insertRow, "SUM(Sheet1!A2:B3, 'My sheet'!$C$3)"},
+		{"SUM(Sheet1!A1:B2, 'My sheet'!$C$3)", otherSheet, "SUM(Sheet1!A1:B2, 'My sheet'!$C$4)"},
+		{"\"a\"\"b A1\"&A1%", insertRow, "\"a\"\"b A1\"&A2%"},
+		{"2^3+A:A+1:1+{1,2;3,4}+1.5E+3*$A$1", insertRow, "2^3+A:A+2:2+{1,2;3,4}+1.5E+3*$A$2"},
+		{"IF(A1>=1,TRUE,#N/A)+#REF!", insertRow, "IF(A2>=1,TRUE,#N/A)+#REF!"},
+		{"LOG10(A2)+foo", removeRow, "LOG10(#REF!)+foo"},
+		{"$A$1:$B2,$C$1:$C$3", insertRow, "$A$2:$B3,$C$2:$C$4"},
+		{"A2:A5", removeRow, "A2:A4"},
+		// sheet prefixes keep their spelling
+		{"'Sheet1'!B2+sheet1!B2", insertRow, "'Sheet1'!B3+sheet1!B3"},
+		{"SUM(Sheet1!A1:Sheet1!B5)", insertRow, "SUM(Sheet1!A2:Sheet1!B6)"},
+		{"SUM(Sheet1!A1:Sheet2!B5)", insertRow, "SUM(Sheet1!A1:Sheet2!B5)"},
+		{"SUM('My sheet'!A1:'My sheet'!B5)", otherSheet, "SUM('My sheet'!A2:'My sheet'!B6)"},
+		// spill ranges of removed cells
+		{"SUM(A2#)+A3#", removeRow, "SUM(#REF!)+A2#"},
+		{"Sheet1!A2#", removeRow, "Sheet1!#REF!"},
+		{"B1#+C1#", removeColumn, "#REF!+B1#"},
+		// references to other workbooks and to several sheets
+		{"Table1[[#This Row],[Col]]+[1]Sheet1!A1", insertRow, "Table1[[#This Row],[Col]]+[1]Sheet1!A1"},
+		{"Sheet1:Sheet3!A1", insertRow, "Sheet1:Sheet3!A1"},
+		{"'[1]x'!A1+A1#", insertRow, "'[1]x'!A1+A2#"},
+	}
+	for _, tc := range tests {
+		if got := UpdateFormula(tc.formula, tc.query); got != tc.want {
+			t.Errorf("UpdateFormula(%q) = %q, want %q", tc.formula, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,691 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/dml/chart"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/schema/urn/schemas_microsoft_com/office/excel"
+	"github.com/unidoc/unioffice/v2/schema/urn/schemas_microsoft_com/vml"
+	"github.com/unidoc/unioffice/v2/spreadsheet/formula"
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+	"github.com/unidoc/unioffice/v2/spreadsheet/update"
+	"github.com/unidoc/unioffice/v2/vmldrawing"
+)
+
+// ErrOffSheet is returned when inserting rows or columns would push cells off
+// the sheet.
+var ErrOffSheet = errors.New("insertion would push cells off the sheet")
+
+// InsertRows inserts n empty rows before the row rowNum (1-N), moving the rows
+// below it down. References to the moved cells are updated on all sheets of
+// the workbook, as in Excel, in formulas, defined names such as print areas,
+// merged cells, conditional formatting, data validations, hyperlinks, tables,
+// auto filters, chart series, sparklines and comments. The cached results of
+// formulas aren't recalculated, the moved cells and the updated formulas are
+// recalculated by the next call of Workbook.Recalculate.
+func (s *Sheet) InsertRows(rowNum, n uint32) error {
+	if rowNum == 0 {
+		return errors.New("row numbers start at 1")
+	}
+	return s.shift(&update.UpdateQuery{UpdateType: update.UpdateActionInsertRow, RowIdx: rowNum, Count: n})
+}
+
+// DeleteRows removes n rows starting with the row rowNum (1-N), moving the rows
+// below it up. References to the moved cells are updated as with InsertRows
+// and references to the removed cells become #REF! errors. Ranges partially
+// removed shrink, ranges entirely removed are dropped.
+func (s *Sheet) DeleteRows(rowNum, n uint32) error {
+	if rowNum == 0 {
+		return errors.New("row numbers start at 1")
+	}
+	return s.shift(&update.UpdateQuery{UpdateType: update.UpdateActionRemoveRow, RowIdx: rowNum, Count: n})
+}
+
+// InsertColumns inserts n empty columns before the column named column (e.g.
+// "C"), moving the columns to its right. References are updated as with
+// InsertRows and tables spanning the column get new columns.
+func (s *Sheet) InsertColumns(column string, n uint32) error {
+	idx, err := parseColumn(column)
+	if err != nil {
+		return err
+	}
+	return s.shift(&update.UpdateQuery{UpdateType: update.UpdateActionInsertColumn, ColumnIdx: idx, Count: n})
+}
+
+// DeleteColumns removes n columns starting with the column named column (e.g.
+// "C"), moving the columns to its right. References are updated as with
+// DeleteRows and tables spanning the column lose their columns.
+func (s *Sheet) DeleteColumns(column string, n uint32) error {
+	idx, err := parseColumn(column)
+	if err != nil {
+		return err
+	}
+	return s.shift(&update.UpdateQuery{UpdateType: update.UpdateActionRemoveColumn, ColumnIdx: idx, Count: n})
+}
+
+func parseColumn(column string) (uint32, error) {
+	column = strings.ToUpper(strings.TrimPrefix(column, "$"))
+	if column == "" || strings.Trim(column, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" || len(column) > 3 {
+		return 0, fmt.Errorf("invalid column %q", column)
+	}
+	return reference.ColumnToIndex(column), nil
+}
+
+// shift validates the query against the sheet and applies it to the sheet and
+// the references to it.
+func (s *Sheet) shift(q *update.UpdateQuery) error {
+	if q.Count == 0 {
+		return nil
+	}
+	idx := s.index()
+	if idx < 0 {
+		return ErrorNotFound
+	}
+	q.SheetToUpdate = s.Name()
+	if err := s.checkShift(q); err != nil {
+		return err
+	}
+	shifted := s.trackShift()
+
+	s.shiftCells(q)
+	s.shiftColumns(q)
+	s.shiftMergedCells(q)
+	s.shiftConditionalFormatting(q)
+	s.shiftDataValidations(q)
+	s.shiftHyperlinks(q)
+	s.shiftDimension(q)
+	if !updateAutoFilter(s._bbbe.AutoFilter, q) {
+		s._bbbe.AutoFilter = nil
+	}
+	if !updateSortState(s._bbbe.SortState, q) {
+		s._bbbe.SortState = nil
+	}
+	s.shiftTables(q)
+	s.shiftComments(idx, q)
+
+	wb := s._fgeg
+	for i, ws := range wb._fbef {
+		sq := *q
+		sq.UpdateCurrentSheet = i == idx
+		updateSheetFormulas(ws, &sq)
+		updateHyperlinkLocations(ws, &sq)
+		updateSparklines(ws, &sq)
+	}
+	other := *q
+	other.UpdateCurrentSheet = false
+	if wb._gbadf.DefinedNames != nil {
+		for _, dn := range wb._gbadf.DefinedNames.DefinedName {
+			dn.Content = formula.UpdateFormula(dn.Content, &other)
+		}
+	}
+	for _, cs := range wb._faebe {
+		updateChartReferences(reflect.ValueOf(cs), &other)
+	}
+
+	shifted()
+	return nil
+}
+
+// index returns the position of the sheet in the workbook, including hidden
+// sheets, or -1 if it isn't found.
+func (s *Sheet) index() int {
+	for i, ws := range s._fgeg._fbef {
+		if ws == s._bbbe {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkShift returns an error if the query would push cells off the sheet, or
+// change part of an array formula or of a table in a way Excel doesn't allow.
+func (s *Sheet) checkShift(q *update.UpdateQuery) error {
+	first, last := q.RowIdx, q.RowIdx+q.Count-1
+	if q.UpdatesColumns() {
+		first, last = q.ColumnIdx, q.ColumnIdx+q.Count-1
+	}
+	// position returns the row number or column index of a cell reference
+	// along the direction of the query
+	position := func(c reference.CellReference) uint32 {
+		if q.UpdatesRows() {
+			return c.RowIdx
+		}
+		return c.ColumnIdx
+	}
+	for _, r := range s._bbbe.SheetData.Row {
+		for _, c := range r.C {
+			if c.RAttr == nil {
+				continue
+			}
+			if !q.Removes() {
+				if _, ok := reference.UpdateRangeReference(*c.RAttr, q); !ok {
+					return ErrOffSheet
+				}
+			}
+			if c.F == nil || c.F.TAttr != sml.ST_CellFormulaTypeArray || c.F.RefAttr == nil {
+				continue
+			}
+			from, to, err := reference.ParseRangeReference(*c.F.RefAttr)
+			if err != nil {
+				continue
+			}
+			lo, hi := position(from), position(to)
+			partial := lo < first && hi >= first || lo <= last && hi > last
+			if !q.Removes() {
+				partial = lo < first && hi >= first
+			}
+			if partial {
+				return fmt.Errorf("cannot change part of the array formula in %s", *c.F.RefAttr)
+			}
+		}
+	}
+	if !q.Removes() {
+		return nil
+	}
+	for _, t := range s.tables() {
+		from, to, err := reference.ParseRangeReference(t.RefAttr)
+		if err != nil {
+			continue
+		}
+		lo, hi := position(from), position(to)
+		if lo >= first && hi <= last {
+			return fmt.Errorf("cannot delete all the cells of table %s", t.DisplayNameAttr)
+		}
+		if q.UpdatesRows() && lo >= first && lo <= last && (t.HeaderRowCountAttr == nil || *t.HeaderRowCountAttr > 0) {
+			return fmt.Errorf("cannot delete the header row of table %s", t.DisplayNameAttr)
+		}
+	}
+	return nil
+}
+
+// shiftCells moves the cells of the sheet and drops the removed ones.
+func (s *Sheet) shiftCells(q *update.UpdateQuery) {
+	sd := s._bbbe.SheetData
+	rows := sd.Row[:0]
+	for _, r := range sd.Row {
+		if q.UpdatesRows() && r.RAttr != nil {
+			num, ok := q.UpdateIndex(*r.RAttr)
+			if !ok {
+				continue
+			}
+			r.RAttr = &num
+		}
+		cells := r.C[:0]
+		for _, c := range r.C {
+			if c.RAttr != nil {
+				ref, ok := reference.UpdateRangeReference(*c.RAttr, q)
+				if !ok {
+					continue
+				}
+				c.RAttr = &ref
+				if c.F != nil && c.F.RefAttr != nil {
+					if ref, ok := reference.UpdateRangeReference(*c.F.RefAttr, q); ok {
+						c.F.RefAttr = &ref
+					}
+				}
+			}
+			cells = append(cells, c)
+		}
+		r.C = cells
+		// spans are an optimization hint that may no longer hold
+		r.SpansAttr = nil
+		rows = append(rows, r)
+	}
+	sd.Row = rows
+}
+
+// shiftColumns moves the column widths and styles.
+func (s *Sheet) shiftColumns(q *update.UpdateQuery) {
+	if !q.UpdatesColumns() {
+		return
+	}
+	cols := s._bbbe.Cols[:0]
+	for _, cs := range s._bbbe.Cols {
+		kept := cs.Col[:0]
+		for _, c := range cs.Col {
+			from, to, ok := q.UpdateSpan(c.MinAttr-1, c.MaxAttr-1)
+			if !ok {
+				continue
+			}
+			c.MinAttr, c.MaxAttr = from+1, to+1
+			kept = append(kept, c)
+		}
+		cs.Col = kept
+		if len(kept) > 0 {
+			cols = append(cols, cs)
+		}
+	}
+	s._bbbe.Cols = cols
+}
+
+// shiftMergedCells updates the merged cells, dropping the ones that were
+// removed or reduced to a single cell.
+func (s *Sheet) shiftMergedCells(q *update.UpdateQuery) {
+	mcs := s._bbbe.MergeCells
+	if mcs == nil {
+		return
+	}
+	kept := mcs.MergeCell[:0]
+	for _, mc := range mcs.MergeCell {
+		ref, ok := reference.UpdateRangeReference(mc.RefAttr, q)
+		if !ok {
+			continue
+		}
+		if ends := strings.Split(ref, ":"); len(ends) == 2 && ends[0] == ends[1] {
+			continue
+		}
+		mc.RefAttr = ref
+		kept = append(kept, mc)
+	}
+	mcs.MergeCell = kept
+	if len(kept) == 0 {
+		s._bbbe.MergeCells = nil
+	} else if mcs.CountAttr != nil {
+		*mcs.CountAttr = uint32(len(kept))
+	}
+}
+
+func (s *Sheet) shiftConditionalFormatting(q *update.UpdateQuery) {
+	current := *q
+	current.UpdateCurrentSheet = true
+	kept := s._bbbe.ConditionalFormatting[:0]
+	for _, cf := range s._bbbe.ConditionalFormatting {
+		if cf.SqrefAttr != nil {
+			sqref := sml.ST_Sqref(reference.UpdateSqref(*cf.SqrefAttr, q))
+			if len(sqref) == 0 {
+				continue
+			}
+			cf.SqrefAttr = &sqref
+		}
+		for _, rule := range cf.CfRule {
+			for i, f := range rule.Formula {
+				rule.Formula[i] = formula.UpdateFormula(f, &current)
+			}
+		}
+		kept = append(kept, cf)
+	}
+	s._bbbe.ConditionalFormatting = kept
+}
+
+func (s *Sheet) shiftDataValidations(q *update.UpdateQuery) {
+	dvs := s._bbbe.DataValidations
+	if dvs == nil {
+		return
+	}
+	current := *q
+	current.UpdateCurrentSheet = true
+	kept := dvs.DataValidation[:0]
+	for _, dv := range dvs.DataValidation {
+		dv.SqrefAttr = reference.UpdateSqref(dv.SqrefAttr, q)
+		if len(dv.SqrefAttr) == 0 {
+			continue
+		}
+		for _, f := range []*string{dv.Formula1, dv.Formula2} {
+			if f != nil {
+				*f = formula.UpdateFormula(*f, &current)
+			}
+		}
+		kept = append(kept, dv)
+	}
+	dvs.DataValidation = kept
+	if len(kept) == 0 {
+		s._bbbe.DataValidations = nil
+	} else if dvs.CountAttr != nil {
+		*dvs.CountAttr = uint32(len(kept))
+	}
+}
+
+func (s *Sheet) shiftHyperlinks(q *update.UpdateQuery) {
+	hls := s._bbbe.Hyperlinks
+	if hls == nil {
+		return
+	}
+	kept := hls.Hyperlink[:0]
+	for _, hl := range hls.Hyperlink {
+		ref, ok := reference.UpdateRangeReference(hl.RefAttr, q)
+		if !ok {
+			continue
+		}
+		hl.RefAttr = ref
+		kept = append(kept, hl)
+	}
+	hls.Hyperlink = kept
+	if len(kept) == 0 {
+		s._bbbe.Hyperlinks = nil
+	}
+}
+
+// updateHyperlinkLocations updates the cells targeted by the hyperlinks of a
+// sheet, which refer to the sheet itself unless they have a sheet prefix.
+func updateHyperlinkLocations(ws *sml.Worksheet, q *update.UpdateQuery) {
+	if ws.Hyperlinks == nil {
+		return
+	}
+	for _, hl := range ws.Hyperlinks.Hyperlink {
+		if hl.LocationAttr != nil {
+			*hl.LocationAttr = formula.UpdateFormula(*hl.LocationAttr, q)
+		}
+	}
+}
+
+// shiftDimension updates the range of the used cells of the sheet.
+func (s *Sheet) shiftDimension(q *update.UpdateQuery) {
+	dim := s._bbbe.Dimension
+	if dim == nil {
+		return
+	}
+	ref, ok := reference.UpdateRangeReference(dim.RefAttr, q)
+	if !ok {
+		ref = "A1"
+	}
+	dim.RefAttr = ref
+}
+
+// updateAutoFilter updates the range of an auto filter and the columns it
+// filters. It returns false if the range was removed.
+func updateAutoFilter(af *sml.CT_AutoFilter, q *update.UpdateQuery) bool {
+	if af == nil || af.RefAttr == nil {
+		return true
+	}
+	old := *af.RefAttr
+	ref, ok := reference.UpdateRangeReference(old, q)
+	if !ok {
+		return false
+	}
+	af.RefAttr = &ref
+	if !updateSortState(af.SortState, q) {
+		af.SortState = nil
+	}
+	if !q.UpdatesColumns() {
+		return true
+	}
+	from, _, err := reference.ParseRangeReference(old)
+	if err != nil {
+		return true
+	}
+	to, _, err := reference.ParseRangeReference(ref)
+	if err != nil {
+		return true
+	}
+	kept := af.FilterColumn[:0]
+	for _, fc := range af.FilterColumn {
+		col, ok := q.UpdateIndex(from.ColumnIdx + fc.ColIdAttr)
+		if !ok {
+			continue
+		}
+		fc.ColIdAttr = col - to.ColumnIdx
+		kept = append(kept, fc)
+	}
+	af.FilterColumn = kept
+	return true
+}
+
+// updateSortState updates the ranges of a sort state. It returns false if the
+// range sorted was removed.
+func updateSortState(ss *sml.CT_SortState, q *update.UpdateQuery) bool {
+	if ss == nil {
+		return true
+	}
+	ref, ok := reference.UpdateRangeReference(ss.RefAttr, q)
+	if !ok {
+		return false
+	}
+	ss.RefAttr = ref
+	kept := ss.SortCondition[:0]
+	for _, sc := range ss.SortCondition {
+		if ref, ok := reference.UpdateRangeReference(sc.RefAttr, q); ok {
+			sc.RefAttr = ref
+			kept = append(kept, sc)
+		}
+	}
+	ss.SortCondition = kept
+	return true
+}
+
+// tables returns the tables of the sheet. The tables of the workbook are
+// stored in the order of the table parts of the sheets.
+func (s *Sheet) tables() []*sml.Table {
+	count := func(ws *sml.Worksheet) int {
+		if ws.TableParts == nil {
+			return 0
+		}
+		return len(ws.TableParts.TablePart)
+	}
+	offset := 0
+	for _, ws := range s._fgeg._fbef {
+		if ws == s._bbbe {
+			break
+		}
+		offset += count(ws)
+	}
+	n := count(s._bbbe)
+	if n == 0 || offset+n > len(s._fgeg._eeegg) {
+		return nil
+	}
+	return s._fgeg._eeegg[offset : offset+n]
+}
+
+// shiftTables moves the tables of the sheet. Tables spanning inserted or
+// removed columns gain or lose table columns.
+func (s *Sheet) shiftTables(q *update.UpdateQuery) {
+	current := *q
+	current.UpdateCurrentSheet = true
+	for _, t := range s.tables() {
+		from, to, err := reference.ParseRangeReference(t.RefAttr)
+		ref, ok := reference.UpdateRangeReference(t.RefAttr, q)
+		if !ok {
+			continue
+		}
+		t.RefAttr = ref
+		if !updateAutoFilter(t.AutoFilter, q) {
+			t.AutoFilter = nil
+		}
+		if !updateSortState(t.SortState, q) {
+			t.SortState = nil
+		}
+		if err == nil && q.UpdatesColumns() && t.TableColumns != nil {
+			s.shiftTableColumns(t, from, to, q)
+		}
+		if t.TableColumns == nil {
+			continue
+		}
+		for _, tc := range t.TableColumns.TableColumn {
+			for _, tf := range []*sml.CT_TableFormula{tc.CalculatedColumnFormula, tc.TotalsRowFormula} {
+				if tf != nil {
+					tf.Content = formula.UpdateFormula(tf.Content, &current)
+				}
+			}
+		}
+	}
+}
+
+// shiftTableColumns inserts or removes the table columns of a table that spans
+// from and to before the update. Inserted columns are named as in Excel and
+// their names are written in the header row.
+func (s *Sheet) shiftTableColumns(t *sml.Table, from, to reference.CellReference, q *update.UpdateQuery) {
+	first, n := q.ColumnIdx, q.Count
+	tcs := t.TableColumns
+	if q.Removes() {
+		kept := tcs.TableColumn[:0]
+		for i, tc := range tcs.TableColumn {
+			col := from.ColumnIdx + uint32(i)
+			if col < first || col >= first+n {
+				kept = append(kept, tc)
+			}
+		}
+		tcs.TableColumn = kept
+	} else if first > from.ColumnIdx && first <= to.ColumnIdx {
+		names := map[string]bool{}
+		id := uint32(0)
+		for _, tc := range tcs.TableColumn {
+			names[strings.ToLower(tc.NameAttr)] = true
+			if tc.IdAttr > id {
+				id = tc.IdAttr
+			}
+		}
+		pos := int(first - from.ColumnIdx)
+		if pos > len(tcs.TableColumn) {
+			pos = len(tcs.TableColumn)
+		}
+		added := make([]*sml.CT_TableColumn, 0, n)
+		for k := 1; uint32(len(added)) < n; k++ {
+			name := "Column" + strconv.Itoa(k)
+			if names[strings.ToLower(name)] {
+				continue
+			}
+			id++
+			tc := sml.NewCT_TableColumn()
+			tc.IdAttr = id
+			tc.NameAttr = name
+			added = append(added, tc)
+			if t.HeaderRowCountAttr == nil || *t.HeaderRowCountAttr > 0 {
+				col := reference.IndexToColumn(first + uint32(len(added)-1))
+				s.Cell(col + strconv.Itoa(int(from.RowIdx))).SetString(name)
+			}
+		}
+		tcs.TableColumn = append(tcs.TableColumn[:pos], append(added, tcs.TableColumn[pos:]...)...)
+	}
+	count := uint32(len(tcs.TableColumn))
+	tcs.CountAttr = &count
+}
+
+// shiftComments moves the comments of the sheet and their note shapes,
+// dropping the comments of removed cells.
+func (s *Sheet) shiftComments(idx int, q *update.UpdateQuery) {
+	wb := s._fgeg
+	if idx >= len(wb._edca) || wb._edca[idx] == nil || wb._edca[idx].CommentList == nil {
+		return
+	}
+	cl := wb._edca[idx].CommentList
+	kept := cl.Comment[:0]
+	for _, c := range cl.Comment {
+		ref, ok := reference.UpdateRangeReference(c.RefAttr, q)
+		if !ok {
+			continue
+		}
+		c.RefAttr = ref
+		kept = append(kept, c)
+	}
+	cl.Comment = kept
+
+	drawing := s.commentDrawing(idx)
+	if drawing == nil {
+		return
+	}
+	shapes := drawing.Shape[:0]
+	for _, sh := range drawing.Shape {
+		if updateNoteShape(sh, q) {
+			shapes = append(shapes, sh)
+		}
+	}
+	drawing.Shape = shapes
+}
+
+// commentDrawing returns the VML drawing holding the note shapes of the
+// sheet comments. Drawings are stored in the order of their part names.
+func (s *Sheet) commentDrawing(idx int) *vmldrawing.Container {
+	wb := s._fgeg
+	if s._bbbe.LegacyDrawing != nil && idx < len(wb._aedf) {
+		target := wb._aedf[idx].GetTargetByRelId(s._bbbe.LegacyDrawing.IdAttr)
+		digits := strings.TrimFunc(path.Base(target), func(r rune) bool { return r < '0' || r > '9' })
+		if n, err := strconv.Atoi(digits); err == nil && n >= 1 && n <= len(wb._adbg) {
+			return wb._adbg[n-1]
+		}
+	}
+	if len(wb._adbg) == 1 {
+		return wb._adbg[0]
+	}
+	return nil
+}
+
+// updateNoteShape moves the anchor of a comment note. Its row and column are
+// 0-N. It returns false if the cell of the note was removed.
+func updateNoteShape(sh *vml.Shape, q *update.UpdateQuery) bool {
+	for _, ch := range sh.ShapeChoice {
+		if ch.ShapeElementsChoice == nil || ch.ShapeElementsChoice.ClientData == nil {
+			continue
+		}
+		cd := ch.ShapeElementsChoice.ClientData
+		if cd.ObjectTypeAttr != excel.ST_ObjectTypeNote {
+			continue
+		}
+		for _, c := range cd.ClientDataChoice {
+			switch {
+			case q.UpdatesRows() && c.Row != nil:
+				row, ok := q.UpdateIndex(uint32(*c.Row) + 1)
+				if !ok {
+					return false
+				}
+				*c.Row = int64(row) - 1
+			case q.UpdatesColumns() && c.Column != nil:
+				col, ok := q.UpdateIndex(uint32(*c.Column))
+				if !ok {
+					return false
+				}
+				*c.Column = int64(col)
+			}
+		}
+	}
+	return true
+}
+
+// updateSheetFormulas updates the cell formulas of a worksheet.
+func updateSheetFormulas(ws *sml.Worksheet, q *update.UpdateQuery) {
+	if ws.SheetData == nil {
+		return
+	}
+	for _, r := range ws.SheetData.Row {
+		for _, c := range r.C {
+			if c.F != nil {
+				c.F.Content = formula.UpdateFormula(c.F.Content, q)
+			}
+		}
+	}
+}
+
+var chartReferenceTypes = map[reflect.Type]bool{
+	reflect.TypeOf(chart.CT_NumRef{}):         true,
+	reflect.TypeOf(chart.CT_StrRef{}):         true,
+	reflect.TypeOf(chart.CT_MultiLvlStrRef{}): true,
+}
+
+// updateChartReferences updates the formulas of the data references found in
+// a chart, such as the values and categories of its series.
+func updateChartReferences(v reflect.Value, q *update.UpdateQuery) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			updateChartReferences(v.Elem(), q)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			updateChartReferences(v.Index(i), q)
+		}
+	case reflect.Struct:
+		if chartReferenceTypes[v.Type()] {
+			f := v.FieldByName("F")
+			f.SetString(formula.UpdateFormula(f.String(), q))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				updateChartReferences(v.Field(i), q)
+			}
+		}
+	}
+}
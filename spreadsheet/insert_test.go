@@ -0,0 +1,316 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml/chart"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+)
+
+// shiftedWorkbook holds a workbook with something of each kind of reference
+// updated when inserting or removing rows and columns of Sheet1.
+type shiftedWorkbook struct {
+	wb     *Workbook
+	s, s2  Sheet
+	series *chart.CT_LineSer
+}
+
+func newShiftedWorkbook(t *testing.T) *shiftedWorkbook {
+	wb := New()
+	s := wb.AddSheet()
+	s.SetName("Sheet1")
+	s2 := wb.AddSheet()
+	s2.SetName("Sheet2")
+	for r := 1; r <= 5; r++ {
+		s.Cell("A" + strconv.Itoa(r)).SetNumber(float64(r))
+		s.Cell("B" + strconv.Itoa(r)).SetNumber(float64(r * 10))
+	}
+	s.Cell("D1").SetFormulaRaw("SUM(A1:A5)")
+	s.Cell("D2").SetFormulaRaw("A3*2")
+	s2.Cell("A1").SetFormulaRaw("Sheet1!A4+'Sheet1'!B4")
+
+	s.AddMergedCells("A7", "B7")
+	s.AddMergedCells("A3", "B3")
+
+	rule := s.AddConditionalFormatting([]string{"A2:A5"}).AddRule()
+	rule.SetType(sml.ST_CfTypeExpression)
+	rule.SetConditionValue("A2>1")
+
+	dv := s.AddDataValidation()
+	dv.SetRange("C3:C4")
+	dv.SetList().SetRange("$B$1:$B$5")
+
+	s.Cell("B10").SetString("Name")
+	s.Cell("C10").SetString("Qty")
+	if _, err := s.AddTable("B10:C12", "Sales", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	s.Comments().AddComment("A4", "author").AddRun().SetText("four")
+	wb.AddDefinedName("Data", "Sheet1!$A$1:$A$5")
+
+	s.X().Hyperlinks = sml.NewCT_Hyperlinks()
+	s.X().Hyperlinks.Hyperlink = []*sml.CT_Hyperlink{{RefAttr: "E4", LocationAttr: unioffice.String("A4")}}
+	s2.X().Hyperlinks = sml.NewCT_Hyperlinks()
+	s2.X().Hyperlinks.Hyperlink = []*sml.CT_Hyperlink{{RefAttr: "B1", LocationAttr: unioffice.String("Sheet1!A4")}}
+	s.X().Dimension = &sml.CT_SheetDimension{RefAttr: "A1:E12"}
+
+	dr := wb.AddDrawing()
+	s.SetDrawing(dr)
+	c, _ := dr.AddChart(AnchorTypeTwoCell)
+	ser := c.AddLineChart().AddSeries()
+	ser.Values().SetReference("Sheet1!$A$1:$A$5")
+	ser.CategoryAxis().SetNumberReference("'Sheet1'!$B$1:$B$5")
+	return &shiftedWorkbook{wb: wb, s: s, s2: s2, series: ser.X()}
+}
+
+// shiftedReferences are the references of a shiftedWorkbook after a change.
+type shiftedReferences struct {
+	formulas     map[string]string
+	merged       []string
+	cfRange      string
+	cfFormula    string
+	dvRange      string
+	dvFormula    string
+	table        string
+	tableColumns []string
+	comments     []string
+	definedName  string
+	hyperlink    string
+	locations    []string
+	dimension    string
+	values       string
+	categories   string
+}
+
+func (w *shiftedWorkbook) references() shiftedReferences {
+	s := w.s
+	r := shiftedReferences{formulas: map[string]string{}}
+	for _, sheet := range []Sheet{w.s, w.s2} {
+		for _, row := range sheet.Rows() {
+			for _, c := range row.Cells() {
+				if c.HasFormula() {
+					r.formulas[sheet.Name()+"!"+c.Reference()] = c.GetFormula()
+				}
+			}
+		}
+	}
+	for _, mc := range s.MergedCells() {
+		r.merged = append(r.merged, mc.Reference())
+	}
+	if cfs := s.X().ConditionalFormatting; len(cfs) > 0 {
+		if cfs[0].SqrefAttr != nil {
+			r.cfRange = strings.Join(*cfs[0].SqrefAttr, " ")
+		}
+		r.cfFormula = cfs[0].CfRule[0].Formula[0]
+	}
+	if dvs := s.X().DataValidations; dvs != nil && len(dvs.DataValidation) > 0 {
+		r.dvRange = strings.Join(dvs.DataValidation[0].SqrefAttr, " ")
+		r.dvFormula = *dvs.DataValidation[0].Formula1
+	}
+	if tables := s.Tables(); len(tables) > 0 {
+		r.table = tables[0].Reference()
+		for _, tc := range tables[0].Columns() {
+			r.tableColumns = append(r.tableColumns, tc.Name())
+		}
+	}
+	for _, c := range s.Comments().Comments() {
+		r.comments = append(r.comments, c.CellReference())
+	}
+	r.definedName = w.wb.DefinedNames()[0].Content()
+	if hls := s.X().Hyperlinks; hls != nil {
+		r.hyperlink = hls.Hyperlink[0].RefAttr
+		r.locations = append(r.locations, *hls.Hyperlink[0].LocationAttr)
+	}
+	r.locations = append(r.locations, *w.s2.X().Hyperlinks.Hyperlink[0].LocationAttr)
+	r.dimension = s.X().Dimension.RefAttr
+	r.values = w.series.Val.NumDataSourceChoice.NumRef.F
+	r.categories = w.series.Cat.AxDataSourceChoice.NumRef.F
+	return r
+}
+
+func TestShiftReferences(t *testing.T) {
+	tests := []struct {
+		name  string
+		shift func(s Sheet) error
+		want  shiftedReferences
+	}{
+		{
+			"InsertRows",
+			func(s Sheet) error { return s.InsertRows(3, 2) },
+			shiftedReferences{
+				formulas:     map[string]string{"Sheet1!D1": "SUM(A1:A7)", "Sheet1!D2": "A5*2", "Sheet2!A1": "Sheet1!A6+'Sheet1'!B6"},
+				merged:       []string{"A9:B9", "A5:B5"},
+				cfRange:      "A2:A7",
+				cfFormula:    "A2>1",
+				dvRange:      "C5:C6",
+				dvFormula:    "$B$1:$B$7",
+				table:        "B12:C14",
+				tableColumns: []string{"Name", "Qty"},
+				comments:     []string{"A6"},
+				definedName:  "Sheet1!$A$1:$A$7",
+				hyperlink:    "E6",
+				locations:    []string{"A6", "Sheet1!A6"},
+				dimension:    "A1:E14",
+				values:       "Sheet1!$A$1:$A$7",
+				categories:   "'Sheet1'!$B$1:$B$7",
+			},
+		},
+		{
+			"DeleteRows",
+			func(s Sheet) error { return s.DeleteRows(3, 1) },
+			shiftedReferences{
+				formulas:     map[string]string{"Sheet1!D1": "SUM(A1:A4)", "Sheet1!D2": "#REF!*2", "Sheet2!A1": "Sheet1!A3+'Sheet1'!B3"},
+				merged:       []string{"A6:B6"},
+				cfRange:      "A2:A4",
+				cfFormula:    "A2>1",
+				dvRange:      "C3",
+				dvFormula:    "$B$1:$B$4",
+				table:        "B9:C11",
+				tableColumns: []string{"Name", "Qty"},
+				comments:     []string{"A3"},
+				definedName:  "Sheet1!$A$1:$A$4",
+				hyperlink:    "E3",
+				locations:    []string{"A3", "Sheet1!A3"},
+				dimension:    "A1:E11",
+				values:       "Sheet1!$A$1:$A$4",
+				categories:   "'Sheet1'!$B$1:$B$4",
+			},
+		},
+		{
+			"InsertColumns",
+			func(s Sheet) error { return s.InsertColumns("B", 1) },
+			shiftedReferences{
+				formulas:     map[string]string{"Sheet1!E1": "SUM(A1:A5)", "Sheet1!E2": "A3*2", "Sheet2!A1": "Sheet1!A4+'Sheet1'!C4"},
+				merged:       []string{"A7:C7", "A3:C3"},
+				cfRange:      "A2:A5",
+				cfFormula:    "A2>1",
+				dvRange:      "D3:D4",
+				dvFormula:    "$C$1:$C$5",
+				table:        "C10:D12",
+				tableColumns: []string{"Name", "Qty"},
+				comments:     []string{"A4"},
+				definedName:  "Sheet1!$A$1:$A$5",
+				hyperlink:    "F4",
+				locations:    []string{"A4", "Sheet1!A4"},
+				dimension:    "A1:F12",
+				values:       "Sheet1!$A$1:$A$5",
+				categories:   "'Sheet1'!$C$1:$C$5",
+			},
+		},
+		{
+			"InsertColumns in a table",
+			func(s Sheet) error { return s.InsertColumns("C", 1) },
+			shiftedReferences{
+				formulas:     map[string]string{"Sheet1!E1": "SUM(A1:A5)", "Sheet1!E2": "A3*2", "Sheet2!A1": "Sheet1!A4+'Sheet1'!B4"},
+				merged:       []string{"A7:B7", "A3:B3"},
+				cfRange:      "A2:A5",
+				cfFormula:    "A2>1",
+				dvRange:      "D3:D4",
+				dvFormula:    "$B$1:$B$5",
+				table:        "B10:D12",
+				tableColumns: []string{"Name", "Column1", "Qty"},
+				comments:     []string{"A4"},
+				definedName:  "Sheet1!$A$1:$A$5",
+				hyperlink:    "F4",
+				locations:    []string{"A4", "Sheet1!A4"},
+				dimension:    "A1:F12",
+				values:       "Sheet1!$A$1:$A$5",
+				categories:   "'Sheet1'!$B$1:$B$5",
+			},
+		},
+		{
+			"DeleteColumns",
+			func(s Sheet) error { return s.DeleteColumns("B", 1) },
+			shiftedReferences{
+				formulas:     map[string]string{"Sheet1!C1": "SUM(A1:A5)", "Sheet1!C2": "A3*2", "Sheet2!A1": "Sheet1!A4+'Sheet1'!#REF!"},
+				cfRange:      "A2:A5",
+				cfFormula:    "A2>1",
+				dvRange:      "B3:B4",
+				dvFormula:    "#REF!",
+				table:        "B10:B12",
+				tableColumns: []string{"Qty"},
+				comments:     []string{"A4"},
+				definedName:  "Sheet1!$A$1:$A$5",
+				hyperlink:    "D4",
+				locations:    []string{"A4", "Sheet1!A4"},
+				dimension:    "A1:D12",
+				values:       "Sheet1!$A$1:$A$5",
+				categories:   "'Sheet1'!#REF!",
+			},
+		},
+	}
+	for _, tc := range tests {
+		w := newShiftedWorkbook(t)
+		if err := tc.shift(w.s); err != nil {
+			t.Errorf("%s: %s", tc.name, err)
+			continue
+		}
+		if got := w.references(); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestShiftedCells(t *testing.T) {
+	w := newShiftedWorkbook(t)
+	if err := w.s.InsertRows(2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.s.DeleteColumns("A", 1); err != nil {
+		t.Fatal(err)
+	}
+	if !w.s.Cell("A2").IsEmpty() {
+		t.Errorf("inserted row has value %q", w.s.Cell("A2").GetString())
+	}
+	for r, want := range map[string]string{"A1": "10", "A3": "20", "A6": "50"} {
+		if got := w.s.Cell(r).GetString(); got != want {
+			t.Errorf("%s = %q, want %q", r, got, want)
+		}
+	}
+	// the updated references are saved
+	wb := roundTrip(t, w.wb)
+	s, err := wb.GetSheet("Sheet1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Cell("C1").GetFormula(); got != "SUM(#REF!)" {
+		t.Errorf("formula = %q, want SUM(#REF!)", got)
+	}
+	if got := s.Tables()[0].Reference(); got != "A11:B13" {
+		t.Errorf("table = %s, want A11:B13", got)
+	}
+	if got := wb.DefinedNames()[0].Content(); got != "Sheet1!#REF!" {
+		t.Errorf("defined name = %s, want Sheet1!#REF!", got)
+	}
+	if mcs := s.MergedCells(); len(mcs) != 0 {
+		t.Errorf("merged cells of a removed column = %d, want none", len(mcs))
+	}
+}
+
+func TestShiftErrors(t *testing.T) {
+	w := newShiftedWorkbook(t)
+	if err := w.s.DeleteRows(10, 1); err == nil {
+		t.Error("deleting the header row of a table succeeded")
+	}
+	if err := w.s.DeleteColumns("B", 2); err == nil {
+		t.Error("deleting all the columns of a table succeeded")
+	}
+	w.s.Cell("A1048576").SetNumber(1)
+	if err := w.s.InsertRows(1, 1); err != ErrOffSheet {
+		t.Errorf("pushing cells off the sheet = %v, want ErrOffSheet", err)
+	}
+}
@@ -0,0 +1,34 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/spreadsheet/formula"
+)
+
+// AddLambda adds a defined name for a lambda, such as
+// "LAMBDA(x, y, x*y/2)", which formulas call by name like functions. The
+// lambda is written as in Excel and stored in the form Excel expects, see
+// formula.StoredFormula.
+func (wb *Workbook) AddLambda(name, lambda string) (DefinedName, error) {
+	f := formula.StoredFormula(strings.TrimPrefix(lambda, "="))
+	if !strings.HasPrefix(strings.ToUpper(f), "_XLFN.LAMBDA(") || formula.ParseString(f) == nil {
+		return DefinedName{}, errors.New("invalid lambda " + lambda)
+	}
+	for _, dn := range wb.DefinedNames() {
+		if strings.EqualFold(dn.Name(), name) {
+			return DefinedName{}, errors.New("name " + name + " is already defined")
+		}
+	}
+	return wb.AddDefinedName(name, f), nil
+}
@@ -0,0 +1,72 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import "testing"
+
+func TestAddLambda(t *testing.T) {
+	wb := New()
+	s := wb.AddSheet()
+	dn, err := wb.AddLambda("HalfProduct", "LAMBDA(x, y, x*y/2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := dn.Content(); got != "_xlfn.LAMBDA(_xlpm.x, _xlpm.y, _xlpm.x*_xlpm.y/2)" {
+		t.Errorf("stored lambda = %q", got)
+	}
+	if _, err := wb.AddLambda("Twice", "=LAMBDA(v,HalfProduct(v,4))"); err != nil {
+		t.Fatal(err)
+	}
+	s.Cell("A1").SetNumber(3)
+	s.Cell("B1").SetFormulaRaw("HalfProduct(A1,4)")
+	s.Cell("B2").SetFormulaRaw("halfproduct(2,5)+Twice(1)")
+	s.Cell("B3").SetFormulaRaw("HalfProduct(1)")
+	check := func(s Sheet) {
+		t.Helper()
+		// errors aren't cached, as with RecalculateFormulas
+		for ref, want := range map[string]string{"B1": "6", "B2": "7", "B3": ""} {
+			if got := s.Cell(ref).GetFormattedValue(); got != want {
+				t.Errorf("%s = %q, want %q", s.Cell(ref).GetFormula(), got, want)
+			}
+		}
+	}
+	wb.Recalculate()
+	check(s)
+
+	// the lambdas are saved as defined names and evaluated after reading
+	read := roundTrip(t, wb)
+	rs := read.Sheets()[0]
+	rs.Cell("A1").SetNumber(3)
+	for _, ref := range []string{"B1", "B2", "B3"} {
+		rs.Cell(ref).SetCachedFormulaResult("")
+	}
+	read.Recalculate()
+	check(rs)
+	if names := read.DefinedNames(); len(names) != 2 || names[0].Name() != "HalfProduct" {
+		t.Errorf("read defined names = %v", names)
+	}
+}
+
+func TestAddLambdaErrors(t *testing.T) {
+	wb := New()
+	wb.AddSheet()
+	if _, err := wb.AddLambda("Double", "LAMBDA(x,x*2)"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct{ name, lambda string }{
+		{"double", "LAMBDA(x,x*3)"},
+		{"Triple", "x*3"},
+		{"Triple", "LAMBDA(x,x*3"},
+	} {
+		if _, err := wb.AddLambda(tc.name, tc.lambda); err == nil {
+			t.Errorf("AddLambda(%q, %q) succeeded", tc.name, tc.lambda)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/schema/soo/pkg/relationships"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/zippkg"
+)
+
+// PivotCache is a pivot cache of a workbook. It keeps a copy of the source
+// data of one or more pivot tables, which Excel lays the pivot tables out from
+// when they are refreshed.
+type PivotCache struct {
+	x  *pivotCachePart
+	wb *Workbook
+}
+
+// PivotTable is a pivot table of a sheet.
+type PivotTable struct {
+	x  *pivotTablePart
+	wb *Workbook
+}
+
+type pivotCachePart struct {
+	def     *sml.PivotCacheDefinition
+	rels    common.Relationships
+	records *sml.PivotCacheRecords
+	// path is the part the cache was read from, as a cache is referred to
+	// by the workbook and by each of its pivot tables
+	path string
+}
+
+type pivotTablePart struct {
+	def  *sml.PivotTableDefinition
+	rels common.Relationships
+}
+
+// X returns the inner wrapped XML type.
+func (c PivotCache) X() *sml.PivotCacheDefinition { return c.x.def }
+
+// Records returns the records of the cache, or nil if the cache doesn't keep
+// its records.
+func (c PivotCache) Records() *sml.PivotCacheRecords { return c.x.records }
+
+// ID returns the ID pivot tables refer to the cache by.
+func (c PivotCache) ID() uint32 {
+	wb := c.wb
+	n := c.index()
+	if n < 0 || wb._gbadf.PivotCaches == nil {
+		return 0
+	}
+	target := unioffice.RelativeFilename(unioffice.DocTypeSpreadsheet, unioffice.OfficeDocumentType, unioffice.PivotCacheDefinitionType, n+1)
+	for _, r := range wb._bcg.Relationships() {
+		if r.Type() != unioffice.PivotCacheDefinitionType || r.Target() != target {
+			continue
+		}
+		for _, pc := range wb._gbadf.PivotCaches.PivotCache {
+			if pc.IdAttr == r.ID() {
+				return pc.CacheIdAttr
+			}
+		}
+	}
+	return 0
+}
+
+func (c PivotCache) index() int {
+	for i, x := range c.wb.pivotCaches {
+		if x == c.x {
+			return i
+		}
+	}
+	return -1
+}
+
+// X returns the inner wrapped XML type.
+func (t PivotTable) X() *sml.PivotTableDefinition { return t.x.def }
+
+// Name returns the name of the pivot table.
+func (t PivotTable) Name() string { return t.x.def.NameAttr }
+
+// Cache returns the pivot cache the pivot table is laid out from.
+func (t PivotTable) Cache() (PivotCache, error) {
+	for _, c := range t.wb.PivotCaches() {
+		if c.ID() == t.x.def.CacheIdAttr {
+			return c, nil
+		}
+	}
+	return PivotCache{}, ErrorNotFound
+}
+
+// PivotCaches returns the pivot caches of the workbook.
+func (wb *Workbook) PivotCaches() []PivotCache {
+	ret := []PivotCache{}
+	for _, x := range wb.pivotCaches {
+		ret = append(ret, PivotCache{x, wb})
+	}
+	return ret
+}
+
+// AddPivotCache adds a pivot cache to the workbook. The records may be nil for
+// a cache that doesn't keep them, in which case Excel reads the source again
+// when the pivot tables are refreshed.
+func (wb *Workbook) AddPivotCache(def *sml.PivotCacheDefinition, records *sml.PivotCacheRecords) PivotCache {
+	dt := unioffice.DocTypeSpreadsheet
+	x := &pivotCachePart{def: def, rels: common.NewRelationships(), records: records}
+	wb.pivotCaches = append(wb.pivotCaches, x)
+	n := len(wb.pivotCaches)
+
+	id := uint32(0)
+	if wb._gbadf.PivotCaches == nil {
+		wb._gbadf.PivotCaches = sml.NewCT_PivotCaches()
+	}
+	for _, pc := range wb._gbadf.PivotCaches.PivotCache {
+		if pc.CacheIdAttr >= id {
+			id = pc.CacheIdAttr + 1
+		}
+	}
+	rel := wb._bcg.AddAutoRelationship(dt, unioffice.OfficeDocumentType, n, unioffice.PivotCacheDefinitionType)
+	pc := sml.NewCT_PivotCache()
+	pc.CacheIdAttr = id
+	pc.IdAttr = rel.ID()
+	wb._gbadf.PivotCaches.PivotCache = append(wb._gbadf.PivotCaches.PivotCache, pc)
+
+	def.IdAttr = nil
+	if records != nil {
+		rid := x.rels.AddAutoRelationship(dt, unioffice.PivotCacheDefinitionType, n, unioffice.PivotCacheRecordsType).ID()
+		def.IdAttr = &rid
+	}
+	return PivotCache{x, wb}
+}
+
+// PivotTables returns the pivot tables of the sheet.
+func (s *Sheet) PivotTables() []PivotTable {
+	wb := s._fgeg
+	ret := []PivotTable{}
+	idx := s.index()
+	if idx < 0 || idx >= len(wb._aedf) {
+		return ret
+	}
+	for _, r := range wb._aedf[idx].Relationships() {
+		if r.Type() != unioffice.PivotTableType {
+			continue
+		}
+		for i, x := range wb.pivotTables {
+			if r.Target() == unioffice.RelativeFilename(unioffice.DocTypeSpreadsheet, unioffice.WorksheetType, unioffice.PivotTableType, i+1) {
+				ret = append(ret, PivotTable{x, wb})
+			}
+		}
+	}
+	return ret
+}
+
+// AddPivotTable adds a pivot table laid out from a pivot cache of the workbook
+// to the sheet. The cache ID of the definition is set to the ID of the cache.
+// The values of the pivot table are displayed by the cells of the sheet, which
+// the caller is responsible for setting.
+func (s *Sheet) AddPivotTable(def *sml.PivotTableDefinition, cache PivotCache) (PivotTable, error) {
+	wb := s._fgeg
+	idx := s.index()
+	if idx < 0 {
+		return PivotTable{}, ErrorNotFound
+	}
+	if cache.wb != wb || cache.index() < 0 {
+		return PivotTable{}, errors.New("pivot cache belongs to another workbook")
+	}
+	for _, t := range s.PivotTables() {
+		if strings.EqualFold(t.Name(), def.NameAttr) {
+			return PivotTable{}, fmt.Errorf("sheet already has a pivot table named %s", def.NameAttr)
+		}
+	}
+	dt := unioffice.DocTypeSpreadsheet
+	def.CacheIdAttr = cache.ID()
+	x := &pivotTablePart{def: def, rels: common.NewRelationships()}
+	wb.pivotTables = append(wb.pivotTables, x)
+	wb._aedf[idx].AddAutoRelationship(dt, unioffice.WorksheetType, len(wb.pivotTables), unioffice.PivotTableType)
+	x.rels.AddAutoRelationship(dt, unioffice.PivotTableType, cache.index()+1, unioffice.PivotCacheDefinitionType)
+	return PivotTable{x, wb}, nil
+}
+
+// readPivotPart registers a pivot part found while reading a workbook to be
+// decoded, and points the relationship to it at the name the part is saved
+// under.
+func (wb *Workbook) readPivotPart(dm *zippkg.DecodeMap, target, typ string, rel *relationships.Relationship, src zippkg.Target) {
+	dt := unioffice.DocTypeSpreadsheet
+	// a cache is referred to by the workbook and by its pivot tables,
+	// relative to different directories
+	name := path.Clean(target)
+	switch typ {
+	case unioffice.PivotCacheDefinitionType:
+		for i, x := range wb.pivotCaches {
+			if x.path == name {
+				rel.TargetAttr = unioffice.RelativeFilename(dt, src.Typ, typ, i+1)
+				return
+			}
+		}
+		x := &pivotCachePart{def: sml.NewPivotCacheDefinition(), rels: common.NewRelationships(), path: name}
+		wb.pivotCaches = append(wb.pivotCaches, x)
+		n := len(wb.pivotCaches)
+		dm.AddTarget(name, x.def, typ, uint32(n-1))
+		dm.AddTarget(zippkg.RelationsPathFor(name), x.rels.X(), typ, uint32(n-1))
+		rel.TargetAttr = unioffice.RelativeFilename(dt, src.Typ, typ, n)
+	case unioffice.PivotCacheRecordsType:
+		// records are only referred to by the relationships of their cache
+		if src.Typ != unioffice.PivotCacheDefinitionType || int(src.Index) >= len(wb.pivotCaches) {
+			return
+		}
+		x := wb.pivotCaches[src.Index]
+		x.records = sml.NewPivotCacheRecords()
+		dm.AddTarget(name, x.records, typ, src.Index)
+		rel.TargetAttr = unioffice.RelativeFilename(dt, src.Typ, typ, int(src.Index)+1)
+	case unioffice.PivotTableType:
+		x := &pivotTablePart{def: sml.NewPivotTableDefinition(), rels: common.NewRelationships()}
+		wb.pivotTables = append(wb.pivotTables, x)
+		n := len(wb.pivotTables)
+		dm.AddTarget(name, x.def, typ, uint32(n-1))
+		dm.AddTarget(zippkg.RelationsPathFor(name), x.rels.X(), typ, uint32(n-1))
+		rel.TargetAttr = unioffice.RelativeFilename(dt, src.Typ, typ, n)
+	}
+}
+
+// savePivotParts writes the pivot caches and pivot tables, and sets the
+// content types of their parts.
+func (wb *Workbook) savePivotParts(z *zip.Writer) error {
+	dt := unioffice.DocTypeSpreadsheet
+	pivotTypes := map[string]bool{
+		unioffice.PivotTableContentType:           true,
+		unioffice.PivotCacheDefinitionContentType: true,
+		unioffice.PivotCacheRecordsContentType:    true,
+	}
+	types := wb.ContentTypes.X()
+	kept := types.TypesChoice[:0]
+	for _, c := range types.TypesChoice {
+		if c.Override == nil || !pivotTypes[c.Override.ContentTypeAttr] {
+			kept = append(kept, c)
+		}
+	}
+	types.TypesChoice = kept
+
+	for i, x := range wb.pivotCaches {
+		path := unioffice.AbsoluteFilename(dt, unioffice.PivotCacheDefinitionType, i+1)
+		if err := zippkg.MarshalXML(z, path, x.def); err != nil {
+			return err
+		}
+		wb.ContentTypes.AddOverride("/"+path, unioffice.PivotCacheDefinitionContentType)
+		if !x.rels.IsEmpty() {
+			if err := zippkg.MarshalXML(z, zippkg.RelationsPathFor(path), x.rels.X()); err != nil {
+				return err
+			}
+		}
+		if x.records == nil {
+			continue
+		}
+		path = unioffice.AbsoluteFilename(dt, unioffice.PivotCacheRecordsType, i+1)
+		if err := zippkg.MarshalXML(z, path, x.records); err != nil {
+			return err
+		}
+		wb.ContentTypes.AddOverride("/"+path, unioffice.PivotCacheRecordsContentType)
+	}
+	for i, x := range wb.pivotTables {
+		path := unioffice.AbsoluteFilename(dt, unioffice.PivotTableType, i+1)
+		if err := zippkg.MarshalXML(z, path, x.def); err != nil {
+			return err
+		}
+		if err := zippkg.MarshalXML(z, zippkg.RelationsPathFor(path), x.rels.X()); err != nil {
+			return err
+		}
+		wb.ContentTypes.AddOverride("/"+path, unioffice.PivotTableContentType)
+	}
+	return nil
+}
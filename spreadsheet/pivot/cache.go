@@ -0,0 +1,410 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package pivot
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+)
+
+type valueKind byte
+
+// value kinds, in the order Excel sorts pivot items
+const (
+	kindNumber valueKind = iota
+	kindDate
+	kindText
+	kindBool
+	kindError
+	kindBlank
+)
+
+// value is a value of the source data.
+type value struct {
+	kind valueKind
+	num  float64
+	text string
+	date time.Time
+	b    bool
+}
+
+// key identifies equal values, text being compared case insensitively as
+// Excel does.
+func (v value) key() string {
+	switch v.kind {
+	case kindNumber:
+		return "n" + strconv.FormatFloat(v.num, 'g', -1, 64)
+	case kindDate:
+		return "d" + v.date.Format(time.RFC3339Nano)
+	case kindText:
+		return "s" + strings.ToLower(v.text)
+	case kindBool:
+		return "b" + strconv.FormatBool(v.b)
+	case kindError:
+		return "e" + v.text
+	}
+	return ""
+}
+
+// label is the text an item is selected by in filters.
+func (v value) label() string {
+	switch v.kind {
+	case kindNumber:
+		return strconv.FormatFloat(v.num, 'f', -1, 64)
+	case kindDate:
+		if v.date.Equal(v.date.Truncate(24 * time.Hour)) {
+			return v.date.Format("2006-01-02")
+		}
+		return v.date.Format("2006-01-02T15:04:05")
+	case kindText, kindError:
+		return v.text
+	case kindBool:
+		if v.b {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return blankLabel
+}
+
+// blankLabel is the label of the item of blank values.
+const blankLabel = "(blank)"
+
+// numeric returns the value as a number for the summary functions, dates
+// counting as their serial numbers.
+func (v value) numeric() (float64, bool) {
+	switch v.kind {
+	case kindNumber:
+		return v.num, true
+	case kindDate:
+		return dateSerial(v.date), true
+	}
+	return 0, false
+}
+
+func dateSerial(t time.Time) float64 {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return t.Sub(epoch).Hours() / 24
+}
+
+func lessValue(a, b value) bool {
+	if a.kind != b.kind {
+		return a.kind < b.kind
+	}
+	switch a.kind {
+	case kindNumber:
+		return a.num < b.num
+	case kindDate:
+		return a.date.Before(b.date)
+	case kindText, kindError:
+		return strings.ToLower(a.text) < strings.ToLower(b.text)
+	case kindBool:
+		return !a.b && b.b
+	}
+	return false
+}
+
+// field is a field of the source data, one of its columns.
+type field struct {
+	name   string
+	values []value
+	// items are the distinct values, in the order they first appear, and
+	// index is the item of each value
+	items []value
+	index []int
+	// shared is true if the items are listed by the cache, the records
+	// referring to them by index
+	shared bool
+	group  *dateGroup
+}
+
+func newField(name string, values []value) *field {
+	f := &field{name: name, values: values, index: make([]int, len(values))}
+	seen := map[string]int{}
+	for i, v := range values {
+		k := v.key()
+		idx, ok := seen[k]
+		if !ok {
+			idx = len(f.items)
+			seen[k] = idx
+			f.items = append(f.items, v)
+		}
+		f.index[i] = idx
+	}
+	return f
+}
+
+// kinds returns whether the field has values of each kind.
+func (f *field) kinds() map[valueKind]bool {
+	ret := map[valueKind]bool{}
+	for _, it := range f.items {
+		ret[it.kind] = true
+	}
+	return ret
+}
+
+// axis returns the items a field is laid out with when placed on an axis:
+// the labels of the items in the order they are displayed, the item of the
+// cache each refers to, and the position of the item of each record.
+func (f *field) axis() (labels []value, cacheItems []int, positions []int) {
+	positions = make([]int, len(f.values))
+	if f.group != nil {
+		for i, name := range f.group.items {
+			labels = append(labels, value{kind: kindText, text: name})
+			cacheItems = append(cacheItems, i)
+		}
+		copy(positions, f.group.index)
+		return labels, cacheItems, positions
+	}
+	order := make([]int, len(f.items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return lessValue(f.items[order[i]], f.items[order[j]]) })
+	pos := make([]int, len(f.items))
+	for p, idx := range order {
+		pos[idx] = p
+		labels = append(labels, f.items[idx])
+	}
+	for i, idx := range f.index {
+		positions[i] = pos[idx]
+	}
+	return labels, order, positions
+}
+
+// dateGroup groups the dates of a field into years, quarters, months or days.
+type dateGroup struct {
+	by         DateGrouping
+	start, end time.Time
+	// items are the names of the groups, the first and the last being the
+	// groups of the dates before the start and after the end
+	items []string
+	index []int
+}
+
+var monthNames = []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"}
+
+func newDateGroup(f *field, by DateGrouping) (*dateGroup, error) {
+	g := &dateGroup{by: by, index: make([]int, len(f.values))}
+	first := true
+	for _, v := range f.values {
+		if v.kind != kindDate {
+			return nil, fmt.Errorf("can't group field %s by dates, it has values other than dates", f.name)
+		}
+		if first || v.date.Before(g.start) {
+			g.start = v.date
+		}
+		if first || v.date.After(g.end) {
+			g.end = v.date
+		}
+		first = false
+	}
+	g.start = time.Date(g.start.Year(), g.start.Month(), g.start.Day(), 0, 0, 0, 0, time.UTC)
+	g.end = time.Date(g.end.Year(), g.end.Month(), g.end.Day()+1, 0, 0, 0, 0, time.UTC)
+
+	g.items = append(g.items, "<"+g.start.Format("1/2/2006"))
+	switch by {
+	case Years:
+		for y := g.start.Year(); y <= g.end.AddDate(0, 0, -1).Year(); y++ {
+			g.items = append(g.items, strconv.Itoa(y))
+		}
+	case Quarters:
+		for q := 1; q <= 4; q++ {
+			g.items = append(g.items, "Qtr"+strconv.Itoa(q))
+		}
+	case Months:
+		g.items = append(g.items, monthNames...)
+	case Days:
+		// days are grouped by day of a leap year
+		for d := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC); d.Year() == 2000; d = d.AddDate(0, 0, 1) {
+			g.items = append(g.items, strconv.Itoa(d.Day())+"-"+monthNames[d.Month()-1])
+		}
+	default:
+		return nil, fmt.Errorf("unsupported date grouping %d", by)
+	}
+	g.items = append(g.items, ">"+g.end.Format("1/2/2006"))
+
+	for i, v := range f.values {
+		switch by {
+		case Years:
+			g.index[i] = v.date.Year() - g.start.Year() + 1
+		case Quarters:
+			g.index[i] = (int(v.date.Month())-1)/3 + 1
+		case Months:
+			g.index[i] = int(v.date.Month())
+		case Days:
+			g.index[i] = time.Date(2000, v.date.Month(), v.date.Day(), 0, 0, 0, 0, time.UTC).YearDay()
+		}
+	}
+	return g, nil
+}
+
+func (by DateGrouping) groupBy() sml.ST_GroupBy {
+	switch by {
+	case Years:
+		return sml.ST_GroupByYears
+	case Quarters:
+		return sml.ST_GroupByQuarters
+	case Months:
+		return sml.ST_GroupByMonths
+	case Days:
+		return sml.ST_GroupByDays
+	}
+	return sml.ST_GroupByUnset
+}
+
+// cacheDefinition returns the cache definition and records of the fields.
+func cacheDefinition(source *sml.CT_WorksheetSource, fields []*field, records int) (*sml.PivotCacheDefinition, *sml.PivotCacheRecords) {
+	def := sml.NewPivotCacheDefinition()
+	def.CreatedVersionAttr = unioffice.Uint8(3)
+	def.RefreshedVersionAttr = unioffice.Uint8(3)
+	def.MinRefreshableVersionAttr = unioffice.Uint8(3)
+	def.RecordCountAttr = unioffice.Uint32(uint32(records))
+	def.CacheSource = sml.NewCT_CacheSource()
+	def.CacheSource.TypeAttr = sml.ST_SourceTypeWorksheet
+	def.CacheSource.CacheSourceChoice = sml.NewCT_CacheSourceChoice()
+	def.CacheSource.CacheSourceChoice.WorksheetSource = source
+	def.CacheFields = sml.NewCT_CacheFields()
+	for i, f := range fields {
+		def.CacheFields.CacheField = append(def.CacheFields.CacheField, f.cacheField(i))
+	}
+	def.CacheFields.CountAttr = unioffice.Uint32(uint32(len(fields)))
+
+	recs := sml.NewPivotCacheRecords()
+	for r := 0; r < records; r++ {
+		rec := sml.NewCT_Record()
+		for _, f := range fields {
+			if f.shared {
+				rec.RecordChoice = append(rec.RecordChoice, &sml.CT_RecordChoice{X: &sml.CT_Index{VAttr: uint32(f.index[r])}})
+			} else {
+				rec.RecordChoice = append(rec.RecordChoice, recordChoice(f.values[r]))
+			}
+		}
+		recs.R = append(recs.R, rec)
+	}
+	recs.CountAttr = unioffice.Uint32(uint32(records))
+	return def, recs
+}
+
+func (f *field) cacheField(idx int) *sml.CT_CacheField {
+	cf := sml.NewCT_CacheField()
+	cf.NameAttr = f.name
+	cf.NumFmtIdAttr = unioffice.Uint32(0)
+	kinds := f.kinds()
+	si := sml.NewCT_SharedItems()
+	types := 0
+	for k := range kinds {
+		if k != kindBlank {
+			types++
+		}
+	}
+	if !kinds[kindText] && !kinds[kindBlank] {
+		si.ContainsSemiMixedTypesAttr = unioffice.Bool(false)
+	}
+	if !kinds[kindText] {
+		si.ContainsStringAttr = unioffice.Bool(false)
+	}
+	if kinds[kindBlank] {
+		si.ContainsBlankAttr = unioffice.Bool(true)
+	}
+	if types > 1 {
+		si.ContainsMixedTypesAttr = unioffice.Bool(true)
+	}
+	if kinds[kindNumber] {
+		si.ContainsNumberAttr = unioffice.Bool(true)
+		integer := true
+		minValue, maxValue := math.Inf(1), math.Inf(-1)
+		for _, it := range f.items {
+			if it.kind == kindNumber {
+				integer = integer && it.num == math.Trunc(it.num)
+				minValue = math.Min(minValue, it.num)
+				maxValue = math.Max(maxValue, it.num)
+			}
+		}
+		if integer {
+			si.ContainsIntegerAttr = unioffice.Bool(true)
+		}
+		si.MinValueAttr = unioffice.Float64(minValue)
+		si.MaxValueAttr = unioffice.Float64(maxValue)
+	}
+	if kinds[kindDate] {
+		cf.NumFmtIdAttr = unioffice.Uint32(14)
+		si.ContainsDateAttr = unioffice.Bool(true)
+		if types == 1 {
+			si.ContainsNonDateAttr = unioffice.Bool(false)
+		}
+		var minDate, maxDate time.Time
+		for _, it := range f.items {
+			if it.kind != kindDate {
+				continue
+			}
+			if minDate.IsZero() || it.date.Before(minDate) {
+				minDate = it.date
+			}
+			if maxDate.IsZero() || it.date.After(maxDate) {
+				maxDate = it.date
+			}
+		}
+		si.MinDateAttr = &minDate
+		si.MaxDateAttr = &maxDate
+	}
+	if f.shared {
+		for _, it := range f.items {
+			si.SharedItemsChoice = append(si.SharedItemsChoice, sharedItem(it))
+		}
+		si.CountAttr = unioffice.Uint32(uint32(len(f.items)))
+	}
+	cf.SharedItems = si
+
+	if g := f.group; g != nil {
+		fg := sml.NewCT_FieldGroup()
+		fg.BaseAttr = unioffice.Uint32(uint32(idx))
+		fg.RangePr = sml.NewCT_RangePr()
+		fg.RangePr.GroupByAttr = g.by.groupBy()
+		fg.RangePr.StartDateAttr = &g.start
+		fg.RangePr.EndDateAttr = &g.end
+		fg.GroupItems = sml.NewCT_GroupItems()
+		for _, name := range g.items {
+			fg.GroupItems.GroupItemsChoice = append(fg.GroupItems.GroupItemsChoice, &sml.CT_GroupItemsChoice{S: &sml.CT_String{VAttr: name}})
+		}
+		fg.GroupItems.CountAttr = unioffice.Uint32(uint32(len(g.items)))
+		cf.FieldGroup = fg
+	}
+	return cf
+}
+
+func sharedItem(v value) *sml.CT_SharedItemsChoice {
+	c := recordChoice(v)
+	return &sml.CT_SharedItemsChoice{M: c.M, N: c.N, B: c.B, E: c.E, S: c.S, D: c.D}
+}
+
+func recordChoice(v value) *sml.CT_RecordChoice {
+	switch v.kind {
+	case kindNumber:
+		return &sml.CT_RecordChoice{N: &sml.CT_Number{VAttr: v.num}}
+	case kindDate:
+		return &sml.CT_RecordChoice{D: &sml.CT_DateTime{VAttr: v.date}}
+	case kindText:
+		return &sml.CT_RecordChoice{S: &sml.CT_String{VAttr: v.text}}
+	case kindBool:
+		return &sml.CT_RecordChoice{B: &sml.CT_Boolean{VAttr: v.b}}
+	case kindError:
+		return &sml.CT_RecordChoice{E: &sml.CT_Error{VAttr: v.text}}
+	}
+	return &sml.CT_RecordChoice{M: sml.NewCT_Missing()}
+}
@@ -0,0 +1,578 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package pivot
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+)
+
+// valuesField is the field index of the values, when several data fields are
+// laid out as columns.
+const valuesField = -2
+
+// dataField is a data field of a layout.
+type dataField struct {
+	field int
+	fn    Function
+	name  string
+}
+
+// pageFilter is a filter of a layout, with the positions of the items shown
+// or nil if all of them are.
+type pageFilter struct {
+	field int
+	shown map[int]bool
+}
+
+// layout lays a pivot table out from the fields of its cache.
+type layout struct {
+	fields  []*field
+	rows    []int
+	cols    []int
+	data    []dataField
+	filters []pageFilter
+
+	// labels, cacheItems and positions are the items of the fields placed
+	// on an axis, as returned by field.axis
+	labels     map[int][]value
+	cacheItems map[int][]int
+	positions  map[int][]int
+	// records are the records that pass the filters
+	records []int
+}
+
+func newLayout(fields []*field) *layout {
+	return &layout{
+		fields:     fields,
+		labels:     map[int][]value{},
+		cacheItems: map[int][]int{},
+		positions:  map[int][]int{},
+	}
+}
+
+// place prepares a field to be placed on an axis.
+func (l *layout) place(f int) {
+	if _, ok := l.labels[f]; !ok {
+		l.labels[f], l.cacheItems[f], l.positions[f] = l.fields[f].axis()
+	}
+}
+
+// filter sets the records that pass the filters.
+func (l *layout) filter(records int) {
+	l.records = nil
+	for r := 0; r < records; r++ {
+		ok := true
+		for _, pf := range l.filters {
+			if pf.shown != nil && !pf.shown[l.positions[pf.field][r]] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			l.records = append(l.records, r)
+		}
+	}
+}
+
+// line is a line of the rows or columns of a pivot table.
+type line struct {
+	t sml.ST_ItemType
+	// members are the positions of the items of the fields of the axis,
+	// including the data field when the values are on the axis
+	members []int
+	data    int
+	// prefix are the positions of the items the line is restricted to, and
+	// records the records of row lines
+	prefix  []int
+	records []int
+}
+
+// groups splits records by the position of their item of a field, in the
+// order of the positions.
+func (l *layout) groups(f int, records []int) ([]int, [][]int) {
+	byPos := map[int][]int{}
+	for _, r := range records {
+		p := l.positions[f][r]
+		byPos[p] = append(byPos[p], r)
+	}
+	positions := []int{}
+	for p := range byPos {
+		positions = append(positions, p)
+	}
+	sort.Ints(positions)
+	groups := make([][]int, len(positions))
+	for i, p := range positions {
+		groups[i] = byPos[p]
+	}
+	return positions, groups
+}
+
+func extend(prefix []int, p int) []int {
+	return append(append([]int{}, prefix...), p)
+}
+
+// rowLines returns the lines of the rows in compact form, where the line of
+// an item also shows the subtotal of the items nested within it.
+func (l *layout) rowLines() []line {
+	if len(l.rows) == 0 {
+		return []line{{records: l.records}}
+	}
+	var lines []line
+	var visit func(depth int, prefix, records []int)
+	visit = func(depth int, prefix, records []int) {
+		positions, groups := l.groups(l.rows[depth], records)
+		for i, p := range positions {
+			pre := extend(prefix, p)
+			lines = append(lines, line{members: pre, prefix: pre, records: groups[i]})
+			if depth+1 < len(l.rows) {
+				visit(depth+1, pre, groups[i])
+			}
+		}
+	}
+	visit(0, nil, l.records)
+	return append(lines, line{t: sml.ST_ItemTypeGrand, members: []int{0}, records: l.records})
+}
+
+// colLines returns the lines of the columns, where the subtotal of an item
+// follows the items nested within it.
+func (l *layout) colLines() []line {
+	multi := len(l.data) > 1
+	if len(l.cols) == 0 {
+		if !multi {
+			return []line{{}}
+		}
+		var lines []line
+		for k := range l.data {
+			lines = append(lines, line{members: []int{k}, data: k})
+		}
+		return lines
+	}
+	var lines []line
+	var visit func(depth int, prefix, records []int)
+	visit = func(depth int, prefix, records []int) {
+		positions, groups := l.groups(l.cols[depth], records)
+		for i, p := range positions {
+			pre := extend(prefix, p)
+			if depth+1 == len(l.cols) {
+				for k := range l.data {
+					members := pre
+					if multi {
+						members = extend(pre, k)
+					}
+					lines = append(lines, line{members: members, data: k, prefix: pre})
+				}
+				continue
+			}
+			visit(depth+1, pre, groups[i])
+			for k := range l.data {
+				lines = append(lines, line{t: sml.ST_ItemTypeDefault, members: pre, data: k, prefix: pre})
+			}
+		}
+	}
+	visit(0, nil, l.records)
+	for k := range l.data {
+		lines = append(lines, line{t: sml.ST_ItemTypeGrand, members: []int{0}, data: k})
+	}
+	return lines
+}
+
+// items returns the row or column items of lines, each repeating the
+// members it shares with the line before.
+func items(lines []line) []*sml.CT_I {
+	var ret []*sml.CT_I
+	var prev []int
+	for _, ln := range lines {
+		it := sml.NewCT_I()
+		it.TAttr = ln.t
+		shared := 0
+		if ln.t != sml.ST_ItemTypeGrand {
+			for shared < len(prev) && shared < len(ln.members) && prev[shared] == ln.members[shared] {
+				shared++
+			}
+		}
+		if shared > 0 && shared >= len(ln.members) {
+			shared = len(ln.members) - 1
+		}
+		if shared > 0 {
+			it.RAttr = unioffice.Uint32(uint32(shared))
+		}
+		if ln.data > 0 {
+			it.IAttr = unioffice.Uint32(uint32(ln.data))
+		}
+		for _, m := range ln.members[shared:] {
+			x := sml.NewCT_X()
+			if m != 0 {
+				x.VAttr = unioffice.Int32(int32(m))
+			}
+			it.X = append(it.X, x)
+		}
+		ret = append(ret, it)
+		prev = ln.members
+	}
+	return ret
+}
+
+// aggregate summarizes the values of a data field for records, returning
+// false if there are no records.
+func (l *layout) aggregate(d dataField, records []int) (value, bool) {
+	if len(records) == 0 {
+		return value{}, false
+	}
+	f := l.fields[d.field]
+	count, nums := 0, 0
+	sum, minValue, maxValue := 0.0, math.Inf(1), math.Inf(-1)
+	for _, r := range records {
+		v := f.values[r]
+		if v.kind != kindBlank {
+			count++
+		}
+		if n, ok := v.numeric(); ok {
+			nums++
+			sum += n
+			minValue = math.Min(minValue, n)
+			maxValue = math.Max(maxValue, n)
+		}
+	}
+	result := 0.0
+	switch d.fn {
+	case Count:
+		result = float64(count)
+	case Average:
+		if nums == 0 {
+			return value{kind: kindError, text: "#DIV/0!"}, true
+		}
+		result = sum / float64(nums)
+	case Max:
+		if nums > 0 {
+			result = maxValue
+		}
+	case Min:
+		if nums > 0 {
+			result = minValue
+		}
+	default:
+		result = sum
+	}
+	return value{kind: kindNumber, num: result}, true
+}
+
+// matching returns the records whose items of the columns are those of a
+// prefix.
+func (l *layout) matching(records, prefix []int) []int {
+	if len(prefix) == 0 {
+		return records
+	}
+	var ret []int
+	for _, r := range records {
+		ok := true
+		for d, p := range prefix {
+			if l.positions[l.cols[d]][r] != p {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			ret = append(ret, r)
+		}
+	}
+	return ret
+}
+
+// gridCell is a cell of a laid out pivot table, relative to its top left
+// cell.
+type gridCell struct {
+	row, col int
+	v        value
+}
+
+func text(s string) value { return value{kind: kindText, text: s} }
+
+// render returns the pivot table definition of the layout placed at a cell,
+// and the cells displaying the pivot table.
+func (l *layout) render(name string, col, row uint32) (*sml.PivotTableDefinition, []gridCell) {
+	var cells []gridCell
+	put := func(r, c int, v value) { cells = append(cells, gridCell{r, c, v}) }
+
+	top := 0
+	if len(l.filters) > 0 {
+		top = len(l.filters) + 1
+	}
+	for i, pf := range l.filters {
+		put(i, 0, text(l.fields[pf.field].name))
+		switch {
+		case pf.shown == nil:
+			put(i, 1, text("(All)"))
+		case len(pf.shown) == 1:
+			for p := range pf.shown {
+				put(i, 1, l.labelValue(pf.field, p))
+			}
+		default:
+			put(i, 1, text("(Multiple Items)"))
+		}
+	}
+
+	rows, cols := l.rowLines(), l.colLines()
+	multi := len(l.data) > 1
+	labelCols := 0
+	if len(l.rows) > 0 || len(l.cols) > 0 {
+		labelCols = 1
+	}
+	headerRows := 1
+	if len(l.cols) > 0 {
+		headerRows = 2 + len(l.cols)
+		if !multi {
+			headerRows--
+		}
+	}
+
+	// headers
+	if len(l.cols) == 0 {
+		if len(l.rows) > 0 {
+			put(top, 0, text("Row Labels"))
+		}
+		for j, ln := range cols {
+			put(top, labelCols+j, text(l.data[ln.data].name))
+		}
+	} else {
+		if !multi && len(l.rows) > 0 {
+			put(top, 0, text(l.data[0].name))
+		}
+		put(top, labelCols, text("Column Labels"))
+		if len(l.rows) > 0 {
+			put(top+headerRows-1, 0, text("Row Labels"))
+		}
+		var prev []int
+		for j, ln := range cols {
+			c := labelCols + j
+			switch ln.t {
+			case sml.ST_ItemTypeGrand:
+				if multi {
+					put(top+1, c, text("Total "+l.data[ln.data].name))
+				} else {
+					put(top+1, c, text("Grand Total"))
+				}
+			case sml.ST_ItemTypeDefault:
+				d := len(ln.prefix) - 1
+				label := l.labelValue(l.cols[d], ln.prefix[d]).label()
+				if multi {
+					put(top+1+d, c, text(label+" "+l.data[ln.data].name))
+				} else {
+					put(top+1+d, c, text(label+" Total"))
+				}
+			default:
+				for d, p := range ln.prefix {
+					if prev == nil || len(prev) <= d || !equalPrefix(prev, ln.prefix, d+1) {
+						put(top+1+d, c, l.labelValue(l.cols[d], p))
+					}
+				}
+				if multi {
+					put(top+1+len(l.cols), c, text(l.data[ln.data].name))
+				}
+				prev = ln.prefix
+			}
+		}
+	}
+
+	// rows and values
+	for i, rl := range rows {
+		r := top + headerRows + i
+		switch {
+		case rl.t == sml.ST_ItemTypeGrand:
+			put(r, 0, text("Grand Total"))
+		case len(rl.prefix) > 0:
+			d := len(rl.prefix) - 1
+			put(r, 0, l.labelValue(l.rows[d], rl.prefix[d]))
+		case labelCols > 0 && !multi:
+			put(r, 0, text(l.data[0].name))
+		}
+		for j, cl := range cols {
+			if v, ok := l.aggregate(l.data[cl.data], l.matching(rl.records, cl.prefix)); ok {
+				put(r, labelCols+j, v)
+			}
+		}
+	}
+
+	def := sml.NewPivotTableDefinition()
+	def.NameAttr = name
+	def.DataCaptionAttr = "Values"
+	def.ApplyNumberFormatsAttr = unioffice.Bool(false)
+	def.ApplyBorderFormatsAttr = unioffice.Bool(false)
+	def.ApplyFontFormatsAttr = unioffice.Bool(false)
+	def.ApplyPatternFormatsAttr = unioffice.Bool(false)
+	def.ApplyAlignmentFormatsAttr = unioffice.Bool(false)
+	def.ApplyWidthHeightFormatsAttr = unioffice.Bool(true)
+	def.UpdatedVersionAttr = unioffice.Uint8(3)
+	def.MinRefreshableVersionAttr = unioffice.Uint8(3)
+	def.CreatedVersionAttr = unioffice.Uint8(3)
+	def.UseAutoFormattingAttr = unioffice.Bool(true)
+	def.ItemPrintTitlesAttr = unioffice.Bool(true)
+	def.IndentAttr = unioffice.Uint32(0)
+	def.OutlineAttr = unioffice.Bool(true)
+	def.OutlineDataAttr = unioffice.Bool(true)
+	def.MultipleFieldFiltersAttr = unioffice.Bool(false)
+
+	width := labelCols + len(cols)
+	height := headerRows + len(rows)
+	from := reference.IndexToColumn(col) + strconv.Itoa(int(row)+top)
+	to := reference.IndexToColumn(col+uint32(width-1)) + strconv.Itoa(int(row)+top+height-1)
+	def.Location = sml.NewCT_Location()
+	def.Location.RefAttr = from + ":" + to
+	if len(l.cols) > 0 || !multi {
+		def.Location.FirstHeaderRowAttr = 1
+	}
+	def.Location.FirstDataRowAttr = uint32(headerRows)
+	def.Location.FirstDataColAttr = uint32(labelCols)
+	if len(l.filters) > 0 {
+		def.Location.RowPageCountAttr = unioffice.Uint32(uint32(len(l.filters)))
+		def.Location.ColPageCountAttr = unioffice.Uint32(1)
+	}
+
+	def.PivotFields = sml.NewCT_PivotFields()
+	for i := range l.fields {
+		def.PivotFields.PivotField = append(def.PivotFields.PivotField, l.pivotField(i))
+	}
+	def.PivotFields.CountAttr = unioffice.Uint32(uint32(len(l.fields)))
+
+	if len(l.rows) > 0 {
+		def.RowFields = sml.NewCT_RowFields()
+		for _, f := range l.rows {
+			def.RowFields.Field = append(def.RowFields.Field, &sml.CT_Field{XAttr: int32(f)})
+		}
+		def.RowFields.CountAttr = unioffice.Uint32(uint32(len(l.rows)))
+	}
+	def.RowItems = sml.NewCT_rowItems()
+	def.RowItems.I = items(rows)
+	def.RowItems.CountAttr = unioffice.Uint32(uint32(len(rows)))
+
+	colFields := append([]int{}, l.cols...)
+	if multi {
+		colFields = append(colFields, valuesField)
+	}
+	if len(colFields) > 0 {
+		def.ColFields = sml.NewCT_ColFields()
+		for _, f := range colFields {
+			def.ColFields.Field = append(def.ColFields.Field, &sml.CT_Field{XAttr: int32(f)})
+		}
+		def.ColFields.CountAttr = unioffice.Uint32(uint32(len(colFields)))
+	}
+	def.ColItems = sml.NewCT_colItems()
+	def.ColItems.I = items(cols)
+	def.ColItems.CountAttr = unioffice.Uint32(uint32(len(cols)))
+
+	if len(l.filters) > 0 {
+		def.PageFields = sml.NewCT_PageFields()
+		for _, pf := range l.filters {
+			page := sml.NewCT_PageField()
+			page.FldAttr = int32(pf.field)
+			page.HierAttr = unioffice.Int32(-1)
+			if len(pf.shown) == 1 {
+				for p := range pf.shown {
+					page.ItemAttr = unioffice.Uint32(uint32(p))
+				}
+			}
+			def.PageFields.PageField = append(def.PageFields.PageField, page)
+		}
+		def.PageFields.CountAttr = unioffice.Uint32(uint32(len(l.filters)))
+	}
+
+	def.DataFields = sml.NewCT_DataFields()
+	for _, d := range l.data {
+		df := sml.NewCT_DataField()
+		df.NameAttr = unioffice.String(d.name)
+		df.FldAttr = uint32(d.field)
+		df.SubtotalAttr = d.fn.consolidate()
+		df.BaseFieldAttr = unioffice.Int32(0)
+		df.BaseItemAttr = unioffice.Uint32(0)
+		def.DataFields.DataField = append(def.DataFields.DataField, df)
+	}
+	def.DataFields.CountAttr = unioffice.Uint32(uint32(len(l.data)))
+	return def, cells
+}
+
+func equalPrefix(a, b []int, n int) bool {
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// labelValue returns the value displayed as the label of an item.
+func (l *layout) labelValue(f, pos int) value {
+	v := l.labels[f][pos]
+	if v.kind == kindBlank {
+		return text(blankLabel)
+	}
+	return v
+}
+
+func (l *layout) pivotField(f int) *sml.CT_PivotField {
+	pf := sml.NewCT_PivotField()
+	pf.ShowAllAttr = unioffice.Bool(false)
+	for _, d := range l.data {
+		if d.field == f {
+			pf.DataFieldAttr = unioffice.Bool(true)
+		}
+	}
+	var shown map[int]bool
+	switch {
+	case contains(l.rows, f):
+		pf.AxisAttr = sml.ST_AxisAxisRow
+	case contains(l.cols, f):
+		pf.AxisAttr = sml.ST_AxisAxisCol
+	default:
+		page := false
+		for _, p := range l.filters {
+			if p.field == f {
+				page = true
+				shown = p.shown
+			}
+		}
+		if !page {
+			return pf
+		}
+		pf.AxisAttr = sml.ST_AxisAxisPage
+		if len(shown) > 1 {
+			pf.MultipleItemSelectionAllowedAttr = unioffice.Bool(true)
+		} else {
+			shown = nil
+		}
+	}
+	pf.Items = sml.NewCT_Items()
+	for p, x := range l.cacheItems[f] {
+		it := sml.NewCT_Item()
+		it.XAttr = unioffice.Uint32(uint32(x))
+		if shown != nil && !shown[p] {
+			it.HAttr = unioffice.Bool(true)
+		}
+		pf.Items.Item = append(pf.Items.Item, it)
+	}
+	def := sml.NewCT_Item()
+	def.TAttr = sml.ST_ItemTypeDefault
+	pf.Items.Item = append(pf.Items.Item, def)
+	pf.Items.CountAttr = unioffice.Uint32(uint32(len(pf.Items.Item)))
+	return pf
+}
+
+func contains(fields []int, f int) bool {
+	for _, x := range fields {
+		if x == f {
+			return true
+		}
+	}
+	return false
+}
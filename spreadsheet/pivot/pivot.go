@@ -0,0 +1,678 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+// Package pivot creates pivot tables summarizing a range or a table of a
+// workbook. The pivot cache, holding a copy of the source data, and the
+// layout of the pivot table are computed when the pivot table is added, and
+// the values are written to the cells of the sheet, so that the workbook
+// displays the pivot table without being refreshed by Excel.
+//
+// Example:
+//
+//	pt, err := pivot.Add(&report, "A3", pivot.Definition{
+//		Name:    "SalesByRegion",
+//		Source:  "Sales!A1:D500",
+//		Rows:    []string{"Region"},
+//		Columns: []string{"Date"},
+//		Values:  []pivot.Value{{Field: "Amount", Function: pivot.Sum}},
+//		Filters: []pivot.Filter{{Field: "Product", Items: []string{"Widgets"}}},
+//		Groups:  []pivot.Group{{Field: "Date", By: pivot.Quarters}},
+//	})
+//
+// Pivot tables are laid out in compact form, with the subtotals of the rows
+// shown on the rows of their items and the subtotals of the columns following
+// their items. Existing pivot tables, including those read from a file, are
+// described by Read.
+package pivot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/spreadsheet"
+	"github.com/unidoc/unioffice/v2/spreadsheet/format"
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+)
+
+// DefaultStyle is the style of pivot tables without a style.
+const DefaultStyle = "PivotStyleLight16"
+
+// Function is the function summarizing the values of a data field.
+type Function byte
+
+// Function constants.
+const (
+	Sum Function = iota
+	Count
+	Average
+	Max
+	Min
+)
+
+func (fn Function) String() string {
+	switch fn {
+	case Count:
+		return "Count"
+	case Average:
+		return "Average"
+	case Max:
+		return "Max"
+	case Min:
+		return "Min"
+	}
+	return "Sum"
+}
+
+func (fn Function) consolidate() sml.ST_DataConsolidateFunction {
+	switch fn {
+	case Count:
+		return sml.ST_DataConsolidateFunctionCount
+	case Average:
+		return sml.ST_DataConsolidateFunctionAverage
+	case Max:
+		return sml.ST_DataConsolidateFunctionMax
+	case Min:
+		return sml.ST_DataConsolidateFunctionMin
+	}
+	return sml.ST_DataConsolidateFunctionSum
+}
+
+// DateGrouping is the period the dates of a field are grouped by.
+type DateGrouping byte
+
+// DateGrouping constants.
+const (
+	Years DateGrouping = iota + 1
+	Quarters
+	Months
+	Days
+)
+
+// Value is a data field of a pivot table, summarizing the values of a field
+// of the source.
+type Value struct {
+	Field    string
+	Function Function
+	// Name is the caption of the data field, "Sum of Field" and the like if
+	// empty.
+	Name string
+}
+
+// Filter is a report filter of a pivot table, restricting the records
+// summarized to those with some items of a field.
+type Filter struct {
+	Field string
+	// Items are the labels of the items shown, all of them if empty. Numbers
+	// are labeled as written by strconv.FormatFloat with the 'f' format,
+	// dates as 2006-01-02 and blank values as (blank).
+	Items []string
+}
+
+// Group groups the dates of a field, which then has an item per year,
+// quarter, month or day of the year instead of an item per date.
+type Group struct {
+	Field string
+	By    DateGrouping
+}
+
+// Definition defines a pivot table.
+type Definition struct {
+	Name string
+	// Source is the data summarized, either a range such as Sales!A1:D500
+	// whose first row has the names of the fields, or the name of a table.
+	Source  string
+	Rows    []string
+	Columns []string
+	Values  []Value
+	Filters []Filter
+	Groups  []Group
+	// Style is the pivot table style, DefaultStyle if empty.
+	Style string
+	// RefreshOnLoad makes Excel refresh the pivot table when opening the
+	// workbook.
+	RefreshOnLoad bool
+}
+
+// Add adds a pivot table to a sheet, with its top left cell at a cell, and
+// writes its values to the cells of the sheet. Report filters take the
+// first rows, followed by a blank row and the pivot table.
+func Add(sheet *spreadsheet.Sheet, cell string, def Definition) (spreadsheet.PivotTable, error) {
+	wb := sheet.Workbook()
+	if def.Name == "" {
+		return spreadsheet.PivotTable{}, errors.New("pivot table has no name")
+	}
+	if len(def.Values) == 0 {
+		return spreadsheet.PivotTable{}, errors.New("pivot table has no values")
+	}
+	origin, err := reference.ParseCellReference(cell)
+	if err != nil {
+		return spreadsheet.PivotTable{}, err
+	}
+	src, names, values, err := readSource(wb, def.Source)
+	if err != nil {
+		return spreadsheet.PivotTable{}, err
+	}
+	records := 0
+	if len(values) > 0 {
+		records = len(values[0])
+	}
+	fields := make([]*field, len(names))
+	for i, name := range names {
+		fields[i] = newField(name, values[i])
+	}
+	lookup := func(name string) (int, error) {
+		for i, f := range fields {
+			if strings.EqualFold(f.name, name) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("source of pivot table %s has no field %s", def.Name, name)
+	}
+
+	for _, g := range def.Groups {
+		i, err := lookup(g.Field)
+		if err != nil {
+			return spreadsheet.PivotTable{}, err
+		}
+		if fields[i].group, err = newDateGroup(fields[i], g.By); err != nil {
+			return spreadsheet.PivotTable{}, err
+		}
+	}
+
+	l := newLayout(fields)
+	used := map[int]bool{}
+	place := func(names []string) ([]int, error) {
+		var ret []int
+		for _, name := range names {
+			i, err := lookup(name)
+			if err != nil {
+				return nil, err
+			}
+			if used[i] {
+				return nil, fmt.Errorf("field %s is placed twice in pivot table %s", name, def.Name)
+			}
+			used[i] = true
+			l.place(i)
+			ret = append(ret, i)
+		}
+		return ret, nil
+	}
+	if l.rows, err = place(def.Rows); err != nil {
+		return spreadsheet.PivotTable{}, err
+	}
+	if l.cols, err = place(def.Columns); err != nil {
+		return spreadsheet.PivotTable{}, err
+	}
+	for _, flt := range def.Filters {
+		placed, err := place([]string{flt.Field})
+		if err != nil {
+			return spreadsheet.PivotTable{}, err
+		}
+		pf := pageFilter{field: placed[0]}
+		for _, item := range flt.Items {
+			found := false
+			for p := range l.labels[pf.field] {
+				if strings.EqualFold(l.labelValue(pf.field, p).label(), item) {
+					if pf.shown == nil {
+						pf.shown = map[int]bool{}
+					}
+					pf.shown[p] = true
+					found = true
+				}
+			}
+			if !found {
+				return spreadsheet.PivotTable{}, fmt.Errorf("field %s has no item %s", flt.Field, item)
+			}
+		}
+		l.filters = append(l.filters, pf)
+	}
+	for _, v := range def.Values {
+		i, err := lookup(v.Field)
+		if err != nil {
+			return spreadsheet.PivotTable{}, err
+		}
+		name, err := l.dataName(v, fields[i].name)
+		if err != nil {
+			return spreadsheet.PivotTable{}, err
+		}
+		l.data = append(l.data, dataField{field: i, fn: v.Function, name: name})
+	}
+	for i, f := range fields {
+		kinds := f.kinds()
+		numeric := !kinds[kindText] && !kinds[kindBool] && !kinds[kindError]
+		f.shared = f.group == nil && (used[i] || !numeric)
+	}
+	l.filter(records)
+
+	cacheDef, cacheRecords := cacheDefinition(src, fields, records)
+	if def.RefreshOnLoad {
+		cacheDef.RefreshOnLoadAttr = unioffice.Bool(true)
+	}
+	tableDef, cells := l.render(def.Name, origin.ColumnIdx, origin.RowIdx)
+	style := def.Style
+	if style == "" {
+		style = DefaultStyle
+	}
+	tableDef.PivotTableStyleInfo = sml.NewCT_PivotTableStyle()
+	tableDef.PivotTableStyleInfo.NameAttr = unioffice.String(style)
+	tableDef.PivotTableStyleInfo.ShowRowHeadersAttr = unioffice.Bool(true)
+	tableDef.PivotTableStyleInfo.ShowColHeadersAttr = unioffice.Bool(true)
+	tableDef.PivotTableStyleInfo.ShowRowStripesAttr = unioffice.Bool(false)
+	tableDef.PivotTableStyleInfo.ShowColStripesAttr = unioffice.Bool(false)
+	tableDef.PivotTableStyleInfo.ShowLastColumnAttr = unioffice.Bool(true)
+
+	refs := make([]string, len(cells))
+	for i, c := range cells {
+		refs[i] = reference.IndexToColumn(origin.ColumnIdx+uint32(c.col)) + strconv.Itoa(int(origin.RowIdx)+c.row)
+	}
+	if ref, ok := occupied(sheet, refs); ok {
+		return spreadsheet.PivotTable{}, fmt.Errorf("pivot table %s would overwrite cell %s", def.Name, ref)
+	}
+
+	cache := wb.AddPivotCache(cacheDef, cacheRecords)
+	pt, err := sheet.AddPivotTable(tableDef, cache)
+	if err != nil {
+		return spreadsheet.PivotTable{}, err
+	}
+	for i, c := range cells {
+		setCell(sheet.Cell(refs[i]), c.v)
+	}
+	return pt, nil
+}
+
+// dataName returns the name of a data field, which has to differ from the
+// names of the fields and of the other data fields.
+func (l *layout) dataName(v Value, field string) (string, error) {
+	taken := func(name string) bool {
+		for _, f := range l.fields {
+			if strings.EqualFold(f.name, name) {
+				return true
+			}
+		}
+		for _, d := range l.data {
+			if strings.EqualFold(d.name, name) {
+				return true
+			}
+		}
+		return false
+	}
+	if v.Name != "" {
+		if taken(v.Name) {
+			return "", fmt.Errorf("data field name %s is already used", v.Name)
+		}
+		return v.Name, nil
+	}
+	name := v.Function.String() + " of " + field
+	for n := 2; taken(name); n++ {
+		name = v.Function.String() + " of " + field + strconv.Itoa(n)
+	}
+	return name, nil
+}
+
+// readSource reads the fields of the source of a pivot table, returning the
+// worksheet source of the cache, the names of the fields and their values.
+func readSource(wb *spreadsheet.Workbook, source string) (*sml.CT_WorksheetSource, []string, [][]value, error) {
+	ws := sml.NewCT_WorksheetSource()
+	var sheet spreadsheet.Sheet
+	var from, to reference.CellReference
+	var err error
+	if i := strings.LastIndex(source, "!"); i >= 0 {
+		name := source[:i]
+		if len(name) > 1 && strings.HasPrefix(name, "'") && strings.HasSuffix(name, "'") {
+			name = strings.ReplaceAll(name[1:len(name)-1], "''", "'")
+		}
+		if sheet, err = wb.GetSheet(name); err != nil {
+			return nil, nil, nil, fmt.Errorf("pivot table source %s: %w", source, err)
+		}
+		ref := strings.ReplaceAll(source[i+1:], "$", "")
+		if from, to, err = reference.ParseRangeReference(ref); err != nil {
+			return nil, nil, nil, err
+		}
+		if from.RowIdx > to.RowIdx {
+			from.RowIdx, to.RowIdx = to.RowIdx, from.RowIdx
+		}
+		if from.ColumnIdx > to.ColumnIdx {
+			from.ColumnIdx, to.ColumnIdx = to.ColumnIdx, from.ColumnIdx
+		}
+		ws.RefAttr = unioffice.String(from.String() + ":" + to.String())
+		ws.SheetAttr = unioffice.String(sheet.Name())
+	} else {
+		t, err := wb.GetTable(source)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("pivot table source %s: %w", source, err)
+		}
+		td := t.Definition()
+		if !td.HeaderRow {
+			return nil, nil, nil, fmt.Errorf("table %s has no header row", td.Name)
+		}
+		if sheet, err = wb.GetSheet(td.Sheet); err != nil {
+			return nil, nil, nil, err
+		}
+		if from, to, err = reference.ParseRangeReference(td.Reference); err != nil {
+			return nil, nil, nil, err
+		}
+		if td.TotalsRow {
+			to.RowIdx--
+		}
+		ws.NameAttr = unioffice.String(td.Name)
+	}
+
+	cells := map[string]spreadsheet.Cell{}
+	for _, r := range sheet.Rows() {
+		if n := r.RowNumber(); n < from.RowIdx || n > to.RowIdx {
+			continue
+		}
+		for _, c := range r.Cells() {
+			cells[c.Reference()] = c
+		}
+	}
+	var names []string
+	var values [][]value
+	seen := map[string]bool{}
+	for col := from.ColumnIdx; col <= to.ColumnIdx; col++ {
+		column := reference.IndexToColumn(col)
+		header := ""
+		if c, ok := cells[column+strconv.Itoa(int(from.RowIdx))]; ok {
+			header = strings.TrimSpace(c.GetFormattedValue())
+		}
+		if header == "" {
+			return nil, nil, nil, fmt.Errorf("pivot table source %s has no field name in column %s", source, column)
+		}
+		name := header
+		for n := 2; seen[strings.ToLower(name)]; n++ {
+			name = header + strconv.Itoa(n)
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+
+		var vals []value
+		for row := from.RowIdx + 1; row <= to.RowIdx; row++ {
+			c, ok := cells[column+strconv.Itoa(int(row))]
+			if !ok {
+				vals = append(vals, value{kind: kindBlank})
+				continue
+			}
+			vals = append(vals, cellValue(wb, c))
+		}
+		values = append(values, vals)
+	}
+	return ws, names, values, nil
+}
+
+// cellValue returns the value of a cell of the source.
+func cellValue(wb *spreadsheet.Workbook, c spreadsheet.Cell) value {
+	switch {
+	case c.IsEmpty():
+		return value{kind: kindBlank}
+	case c.IsBool():
+		b, _ := c.GetValueAsBool()
+		return value{kind: kindBool, b: b}
+	case c.IsError():
+		raw, _ := c.GetRawValue()
+		return value{kind: kindError, text: raw}
+	case c.IsNumber():
+		n, err := c.GetValueAsNumber()
+		if err != nil {
+			break
+		}
+		if isDate(wb, c) {
+			t := wb.Epoch().Add(time.Duration(n * 24 * float64(time.Hour))).Round(time.Second)
+			return value{kind: kindDate, date: time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)}
+		}
+		return value{kind: kindNumber, num: n}
+	}
+	s := c.GetString()
+	if s == "" {
+		return value{kind: kindBlank}
+	}
+	return value{kind: kindText, text: s}
+}
+
+func isDate(wb *spreadsheet.Workbook, c spreadsheet.Cell) bool {
+	if c.X().SAttr == nil {
+		return false
+	}
+	id := wb.StyleSheet.GetCellStyle(*c.X().SAttr).NumberFormat()
+	code := ""
+	if nf := wb.StyleSheet.GetNumberFormat(id); nf.X() != nil {
+		code = nf.GetFormat()
+	}
+	return format.IsDateFormat(id, code)
+}
+
+// occupied returns the first of the cells that isn't empty.
+func occupied(sheet *spreadsheet.Sheet, refs []string) (string, bool) {
+	want := map[string]bool{}
+	for _, ref := range refs {
+		want[ref] = true
+	}
+	for _, r := range sheet.Rows() {
+		for _, c := range r.Cells() {
+			if want[c.Reference()] && !c.IsEmpty() {
+				return c.Reference(), true
+			}
+		}
+	}
+	return "", false
+}
+
+func setCell(c spreadsheet.Cell, v value) {
+	switch v.kind {
+	case kindNumber:
+		c.SetNumber(v.num)
+	case kindDate:
+		c.SetDateWithStyle(v.date)
+	case kindBool:
+		c.SetBool(v.b)
+	case kindError:
+		c.SetError(v.text)
+	default:
+		c.SetString(v.text)
+	}
+}
+
+// Read returns the definition of a pivot table. Filters only list the items
+// shown if some items are hidden.
+func Read(t spreadsheet.PivotTable) (Definition, error) {
+	x := t.X()
+	def := Definition{Name: x.NameAttr}
+	cache, err := t.Cache()
+	if err != nil {
+		return def, err
+	}
+	cx := cache.X()
+	if cx.CacheFields == nil {
+		return def, fmt.Errorf("pivot cache of %s has no fields", x.NameAttr)
+	}
+	fields := cx.CacheFields.CacheField
+	fieldName := func(i int32) (string, error) {
+		if i < 0 || int(i) >= len(fields) {
+			return "", fmt.Errorf("pivot table %s refers to field %d of %d", x.NameAttr, i, len(fields))
+		}
+		return fields[i].NameAttr, nil
+	}
+
+	if cs := cx.CacheSource; cs != nil && cs.CacheSourceChoice != nil && cs.CacheSourceChoice.WorksheetSource != nil {
+		ws := cs.CacheSourceChoice.WorksheetSource
+		switch {
+		case ws.NameAttr != nil:
+			def.Source = *ws.NameAttr
+		case ws.RefAttr != nil && ws.SheetAttr != nil:
+			def.Source = sheetPrefix(*ws.SheetAttr) + "!" + *ws.RefAttr
+		}
+	}
+	def.RefreshOnLoad = cx.RefreshOnLoadAttr != nil && *cx.RefreshOnLoadAttr
+	if x.PivotTableStyleInfo != nil && x.PivotTableStyleInfo.NameAttr != nil {
+		def.Style = *x.PivotTableStyleInfo.NameAttr
+	}
+
+	if x.RowFields != nil {
+		for _, f := range x.RowFields.Field {
+			if f.XAttr == valuesField {
+				continue
+			}
+			name, err := fieldName(f.XAttr)
+			if err != nil {
+				return def, err
+			}
+			def.Rows = append(def.Rows, name)
+		}
+	}
+	if x.ColFields != nil {
+		for _, f := range x.ColFields.Field {
+			if f.XAttr == valuesField {
+				continue
+			}
+			name, err := fieldName(f.XAttr)
+			if err != nil {
+				return def, err
+			}
+			def.Columns = append(def.Columns, name)
+		}
+	}
+	if x.DataFields != nil {
+		for _, d := range x.DataFields.DataField {
+			name, err := fieldName(int32(d.FldAttr))
+			if err != nil {
+				return def, err
+			}
+			v := Value{Field: name}
+			switch d.SubtotalAttr {
+			case sml.ST_DataConsolidateFunctionUnset, sml.ST_DataConsolidateFunctionSum:
+				v.Function = Sum
+			case sml.ST_DataConsolidateFunctionCount:
+				v.Function = Count
+			case sml.ST_DataConsolidateFunctionAverage:
+				v.Function = Average
+			case sml.ST_DataConsolidateFunctionMax:
+				v.Function = Max
+			case sml.ST_DataConsolidateFunctionMin:
+				v.Function = Min
+			default:
+				return def, fmt.Errorf("data field of %s summarizes by unsupported function %s", name, d.SubtotalAttr)
+			}
+			if d.NameAttr != nil {
+				v.Name = *d.NameAttr
+			}
+			def.Values = append(def.Values, v)
+		}
+	}
+	if x.PageFields != nil {
+		for _, pf := range x.PageFields.PageField {
+			name, err := fieldName(pf.FldAttr)
+			if err != nil {
+				return def, err
+			}
+			flt := Filter{Field: name}
+			var items []*sml.CT_Item
+			if x.PivotFields != nil && int(pf.FldAttr) < len(x.PivotFields.PivotField) && x.PivotFields.PivotField[pf.FldAttr].Items != nil {
+				items = x.PivotFields.PivotField[pf.FldAttr].Items.Item
+			}
+			if pf.ItemAttr != nil {
+				if int(*pf.ItemAttr) < len(items) {
+					flt.Items = append(flt.Items, itemLabel(fields[pf.FldAttr], items[*pf.ItemAttr]))
+				}
+			} else {
+				hidden := false
+				var shown []string
+				for _, it := range items {
+					if it.TAttr != sml.ST_ItemTypeUnset && it.TAttr != sml.ST_ItemTypeData {
+						continue
+					}
+					if it.HAttr != nil && *it.HAttr {
+						hidden = true
+						continue
+					}
+					shown = append(shown, itemLabel(fields[pf.FldAttr], it))
+				}
+				if hidden {
+					flt.Items = shown
+				}
+			}
+			def.Filters = append(def.Filters, flt)
+		}
+	}
+	for i, f := range fields {
+		fg := f.FieldGroup
+		if fg == nil || fg.RangePr == nil || (fg.BaseAttr != nil && int(*fg.BaseAttr) != i) {
+			continue
+		}
+		g := Group{Field: f.NameAttr}
+		switch fg.RangePr.GroupByAttr {
+		case sml.ST_GroupByYears:
+			g.By = Years
+		case sml.ST_GroupByQuarters:
+			g.By = Quarters
+		case sml.ST_GroupByMonths:
+			g.By = Months
+		case sml.ST_GroupByDays:
+			g.By = Days
+		default:
+			continue
+		}
+		def.Groups = append(def.Groups, g)
+	}
+	return def, nil
+}
+
+// itemLabel returns the label of an item of a pivot field, by which filters
+// select it.
+func itemLabel(f *sml.CT_CacheField, it *sml.CT_Item) string {
+	if it.NAttr != nil {
+		return *it.NAttr
+	}
+	if it.XAttr == nil {
+		return ""
+	}
+	x := int(*it.XAttr)
+	if fg := f.FieldGroup; fg != nil && fg.GroupItems != nil {
+		if x < len(fg.GroupItems.GroupItemsChoice) {
+			c := fg.GroupItems.GroupItemsChoice[x]
+			return choiceValue(c.M, c.N, c.B, c.E, c.S, c.D).label()
+		}
+		return ""
+	}
+	if f.SharedItems != nil && x < len(f.SharedItems.SharedItemsChoice) {
+		c := f.SharedItems.SharedItemsChoice[x]
+		return choiceValue(c.M, c.N, c.B, c.E, c.S, c.D).label()
+	}
+	return ""
+}
+
+func choiceValue(m *sml.CT_Missing, n *sml.CT_Number, b *sml.CT_Boolean, e *sml.CT_Error, s *sml.CT_String, d *sml.CT_DateTime) value {
+	switch {
+	case n != nil:
+		return value{kind: kindNumber, num: n.VAttr}
+	case b != nil:
+		return value{kind: kindBool, b: b.VAttr}
+	case e != nil:
+		return value{kind: kindError, text: e.VAttr}
+	case s != nil:
+		return value{kind: kindText, text: s.VAttr}
+	case d != nil:
+		return value{kind: kindDate, date: d.VAttr}
+	}
+	return value{kind: kindBlank}
+}
+
+// sheetPrefix returns a sheet name as written in references, quoted if it
+// contains characters other than letters, digits, dots and underscores.
+func sheetPrefix(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+		}
+	}
+	return name
+}
@@ -0,0 +1,366 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package pivot
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/unidoc/unioffice/v2/common/license"
+	"github.com/unidoc/unioffice/v2/spreadsheet"
+)
+
+// TestMain sets the metered license key of UNIDOC_LICENSE_API_KEY, which
+// saving and reading workbooks require.
+func TestMain(m *testing.M) {
+	if key := os.Getenv("UNIDOC_LICENSE_API_KEY"); key != "" {
+		if err := license.SetMeteredKey(key); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// newSales returns a workbook with the sheet Data, holding sales over
+// Data!A1:D6, and the empty sheet Report.
+func newSales() (*spreadsheet.Workbook, spreadsheet.Sheet) {
+	wb := spreadsheet.New()
+	data := wb.AddSheet()
+	data.SetName("Data")
+	sales := []struct {
+		region, product string
+		date            time.Time
+		amount          float64
+	}{
+		{"East", "Widgets", time.Date(2021, 1, 15, 0, 0, 0, 0, time.UTC), 100},
+		{"West", "Widgets", time.Date(2021, 2, 10, 0, 0, 0, 0, time.UTC), 200},
+		{"East", "Gadgets", time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC), 50},
+		{"West", "Gadgets", time.Date(2021, 8, 20, 0, 0, 0, 0, time.UTC), 25},
+		{"East", "Widgets", time.Date(2021, 11, 30, 0, 0, 0, 0, time.UTC), 10},
+	}
+	for i, name := range []string{"Region", "Product", "Date", "Amount"} {
+		data.Cell(string(rune('A'+i)) + "1").SetString(name)
+	}
+	for i, s := range sales {
+		row := strconv.Itoa(i + 2)
+		data.Cell("A" + row).SetString(s.region)
+		data.Cell("B" + row).SetString(s.product)
+		data.Cell("C" + row).SetDateWithStyle(s.date)
+		data.Cell("D" + row).SetNumber(s.amount)
+	}
+	report := wb.AddSheet()
+	report.SetName("Report")
+	return wb, report
+}
+
+// checkCells compares the formatted values of the cells of a sheet, empty
+// strings standing for empty cells.
+func checkCells(t *testing.T, s spreadsheet.Sheet, want map[string]string) {
+	t.Helper()
+	for ref, v := range want {
+		if got := s.Cell(ref).GetFormattedValue(); got != v {
+			t.Errorf("%s = %q, want %q", ref, got, v)
+		}
+	}
+}
+
+func TestAddLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		def      Definition
+		location string
+		cells    map[string]string
+	}{
+		{
+			"rows",
+			Definition{Rows: []string{"Region"}, Values: []Value{{Field: "Amount"}}},
+			"A3:B6",
+			map[string]string{
+				"A3": "Row Labels", "B3": "Sum of Amount",
+				"A4": "East", "B4": "160",
+				"A5": "West", "B5": "225",
+				"A6": "Grand Total", "B6": "385",
+			},
+		},
+		{
+			"nested rows",
+			Definition{Rows: []string{"Region", "Product"}, Values: []Value{{Field: "Amount", Function: Count}}},
+			"A3:B10",
+			map[string]string{
+				"A3": "Row Labels", "B3": "Count of Amount",
+				"A4": "East", "B4": "3",
+				"A5": "Gadgets", "B5": "1",
+				"A6": "Widgets", "B6": "2",
+				"A7": "West", "B7": "2",
+				"A10": "Grand Total", "B10": "5",
+			},
+		},
+		{
+			"rows and columns",
+			Definition{Rows: []string{"Region"}, Columns: []string{"Product"}, Values: []Value{{Field: "Amount", Function: Max}}},
+			"A3:D7",
+			map[string]string{
+				"A3": "Max of Amount", "B3": "Column Labels", "C3": "", "D3": "",
+				"A4": "Row Labels", "B4": "Gadgets", "C4": "Widgets", "D4": "Grand Total",
+				"A5": "East", "B5": "50", "C5": "100", "D5": "100",
+				"A6": "West", "B6": "25", "C6": "200", "D6": "200",
+				"A7": "Grand Total", "B7": "50", "C7": "200", "D7": "200",
+			},
+		},
+		{
+			"several values",
+			Definition{Rows: []string{"Product"}, Values: []Value{{Field: "Amount"}, {Field: "Amount", Function: Average, Name: "Mean"}}},
+			"A3:C6",
+			map[string]string{
+				"A3": "Row Labels", "B3": "Sum of Amount", "C3": "Mean",
+				"A4": "Gadgets", "B4": "75", "C4": "37.5",
+				"A5": "Widgets", "B5": "310",
+				"A6": "Grand Total", "B6": "385", "C6": "77",
+			},
+		},
+		{
+			"filter",
+			Definition{Rows: []string{"Region"}, Values: []Value{{Field: "Amount", Function: Min}},
+				Filters: []Filter{{Field: "Product", Items: []string{"Widgets"}}}},
+			"A5:B8",
+			map[string]string{
+				"A3": "Product", "B3": "Widgets", "A4": "",
+				"A5": "Row Labels", "B5": "Min of Amount",
+				"A6": "East", "B6": "10",
+				"A7": "West", "B7": "200",
+				"A8": "Grand Total", "B8": "10",
+			},
+		},
+	}
+	for _, tc := range tests {
+		wb, report := newSales()
+		tc.def.Name = "Pivot"
+		tc.def.Source = "Data!A1:D6"
+		pt, err := Add(&report, "A3", tc.def)
+		if err != nil {
+			t.Errorf("%s: %s", tc.name, err)
+			continue
+		}
+		if got := pt.X().Location.RefAttr; got != tc.location {
+			t.Errorf("%s: location = %s, want %s", tc.name, got, tc.location)
+		}
+		checkCells(t, report, tc.cells)
+		if n := len(wb.PivotCaches()); n != 1 {
+			t.Errorf("%s: %d pivot caches, want 1", tc.name, n)
+		}
+	}
+}
+
+func TestCacheRecords(t *testing.T) {
+	_, report := newSales()
+	pt, err := Add(&report, "A3", Definition{Name: "Pivot", Source: "Data!A1:D6", Rows: []string{"Region"},
+		Values: []Value{{Field: "Amount"}}, RefreshOnLoad: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := pt.Cache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := cache.X()
+	if x.RecordCountAttr == nil || *x.RecordCountAttr != 5 || x.RefreshOnLoadAttr == nil || !*x.RefreshOnLoadAttr {
+		t.Errorf("cache has %v records, refresh on load %v", x.RecordCountAttr, x.RefreshOnLoadAttr)
+	}
+	ws := x.CacheSource.CacheSourceChoice.WorksheetSource
+	if *ws.SheetAttr != "Data" || *ws.RefAttr != "A1:D6" {
+		t.Errorf("cache source = %s!%s, want Data!A1:D6", *ws.SheetAttr, *ws.RefAttr)
+	}
+	names := []string{}
+	for _, f := range x.CacheFields.CacheField {
+		names = append(names, f.NameAttr)
+	}
+	if !reflect.DeepEqual(names, []string{"Region", "Product", "Date", "Amount"}) {
+		t.Errorf("cache fields = %v", names)
+	}
+
+	// the text fields share their items, the records referring to them
+	region := x.CacheFields.CacheField[0].SharedItems
+	items := []string{}
+	for _, c := range region.SharedItemsChoice {
+		items = append(items, c.S.VAttr)
+	}
+	if !reflect.DeepEqual(items, []string{"East", "West"}) {
+		t.Errorf("region items = %v, want [East West]", items)
+	}
+	records := cache.Records()
+	if records == nil || len(records.R) != 5 {
+		t.Fatalf("cache records = %v, want 5", records)
+	}
+	regions, amounts := []uint32{}, []float64{}
+	for _, r := range records.R {
+		regions = append(regions, r.RecordChoice[0].X.VAttr)
+		amounts = append(amounts, r.RecordChoice[3].N.VAttr)
+	}
+	if !reflect.DeepEqual(regions, []uint32{0, 1, 0, 1, 0}) {
+		t.Errorf("region indices = %v", regions)
+	}
+	if !reflect.DeepEqual(amounts, []float64{100, 200, 50, 25, 10}) {
+		t.Errorf("amounts = %v", amounts)
+	}
+	if date := records.R[0].RecordChoice[2].D; date == nil || !date.VAttr.Equal(time.Date(2021, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("first date = %v", date)
+	}
+}
+
+func TestDateGrouping(t *testing.T) {
+	tests := []struct {
+		by    DateGrouping
+		cells map[string]string
+	}{
+		{Quarters, map[string]string{
+			"B3": "Column Labels",
+			"B4": "Qtr1", "C4": "Qtr2", "D4": "Qtr3", "E4": "Qtr4", "F4": "Grand Total",
+			"A5": "Sum of Amount", "B5": "300", "C5": "50", "D5": "25", "E5": "10", "F5": "385",
+		}},
+		{Months, map[string]string{
+			"B4": "Jan", "C4": "Feb", "D4": "May", "E4": "Aug", "F4": "Nov", "G4": "Grand Total",
+			"B5": "100", "C5": "200", "D5": "50", "E5": "25", "F5": "10", "G5": "385",
+		}},
+		{Years, map[string]string{
+			"B4": "2021", "C4": "Grand Total",
+			"B5": "385", "C5": "385",
+		}},
+	}
+	for _, tc := range tests {
+		_, report := newSales()
+		pt, err := Add(&report, "A3", Definition{Name: "Pivot", Source: "Data!A1:D6", Columns: []string{"Date"},
+			Values: []Value{{Field: "Amount"}}, Groups: []Group{{Field: "Date", By: tc.by}}})
+		if err != nil {
+			t.Errorf("grouping by %d: %s", tc.by, err)
+			continue
+		}
+		checkCells(t, report, tc.cells)
+		cache, err := pt.Cache()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fg := cache.X().CacheFields.CacheField[2].FieldGroup
+		if fg == nil || fg.RangePr == nil || fg.RangePr.GroupByAttr != tc.by.groupBy() {
+			t.Errorf("grouping by %d: field group = %v", tc.by, fg)
+		}
+	}
+
+	_, report := newSales()
+	if _, err := Add(&report, "A3", Definition{Name: "Pivot", Source: "Data!A1:D6", Rows: []string{"Region"},
+		Values: []Value{{Field: "Amount"}}, Groups: []Group{{Field: "Region", By: Years}}}); err == nil {
+		t.Error("grouped a text field by dates")
+	}
+}
+
+func TestRead(t *testing.T) {
+	wb, report := newSales()
+	def := Definition{
+		Name:    "Pivot",
+		Source:  "Data!A1:D6",
+		Rows:    []string{"Region"},
+		Columns: []string{"Date"},
+		Values:  []Value{{Field: "Amount", Function: Average, Name: "Mean"}},
+		Filters: []Filter{{Field: "Product", Items: []string{"Widgets"}}},
+		Groups:  []Group{{Field: "Date", By: Quarters}},
+		Style:   "PivotStyleMedium2",
+	}
+	if _, err := Add(&report, "A3", def); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.Buffer{}
+	if err := wb.Save(&buf); err != nil {
+		t.Fatalf("saving: %s", err)
+	}
+	read, err := spreadsheet.Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("reading: %s", err)
+	}
+	rs, err := read.GetSheet("Report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := rs.PivotTables()
+	if len(tables) != 1 {
+		t.Fatalf("read %d pivot tables, want 1", len(tables))
+	}
+	got, err := Read(tables[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, def) {
+		t.Errorf("read definition\n%+v, want\n%+v", got, def)
+	}
+	if records := len(read.PivotCaches()); records != 1 || read.PivotCaches()[0].Records() == nil {
+		t.Errorf("read %d pivot caches", records)
+	}
+
+	// the read pivot table is saved again
+	buf.Reset()
+	if err := read.Save(&buf); err != nil {
+		t.Fatalf("saving again: %s", err)
+	}
+	again, err := spreadsheet.Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("reading again: %s", err)
+	}
+	as, _ := again.GetSheet("Report")
+	if len(as.PivotTables()) != 1 || len(again.PivotCaches()) != 1 {
+		t.Errorf("read %d pivot tables and %d caches after saving again", len(as.PivotTables()), len(again.PivotCaches()))
+	}
+}
+
+func TestAddErrors(t *testing.T) {
+	valid := Definition{Name: "Pivot", Source: "Data!A1:D6", Rows: []string{"Region"}, Values: []Value{{Field: "Amount"}}}
+	tests := []struct {
+		name   string
+		change func(d *Definition)
+	}{
+		{"no name", func(d *Definition) { d.Name = "" }},
+		{"no values", func(d *Definition) { d.Values = nil }},
+		{"missing sheet", func(d *Definition) { d.Source = "Missing!A1:D6" }},
+		{"missing table", func(d *Definition) { d.Source = "Sales" }},
+		{"missing field", func(d *Definition) { d.Rows = []string{"Country"} }},
+		{"field placed twice", func(d *Definition) { d.Columns = []string{"region"} }},
+		{"missing filter item", func(d *Definition) { d.Filters = []Filter{{Field: "Product", Items: []string{"Gizmos"}}} }},
+		{"data field name of a field", func(d *Definition) { d.Values[0].Name = "Product" }},
+		{"source without field name", func(d *Definition) { d.Source = "Data!A1:E6" }},
+	}
+	for _, tc := range tests {
+		_, report := newSales()
+		def := valid
+		def.Values = append([]Value{}, valid.Values...)
+		tc.change(&def)
+		if _, err := Add(&report, "A3", def); err == nil {
+			t.Errorf("%s: added pivot table", tc.name)
+		}
+	}
+
+	_, report := newSales()
+	report.Cell("B5").SetString("taken")
+	if _, err := Add(&report, "A3", valid); err == nil {
+		t.Error("pivot table overwrote a cell")
+	}
+	if got := report.Cell("B5").GetString(); got != "taken" {
+		t.Errorf("overwritten cell = %q", got)
+	}
+	if _, err := Add(&report, "F3", valid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Add(&report, "J3", valid); err == nil {
+		t.Error("added a second pivot table with the same name")
+	}
+}
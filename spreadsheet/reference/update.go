@@ -0,0 +1,131 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package reference
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2/spreadsheet/update"
+)
+
+// UpdateRangeReference updates a reference without sheet prefix, such as
+// "B2", "A1:C4", "$A:$C" or "2:5", after inserting or removing the rows or
+// columns described by the query. Ranges grow and shrink as they do in Excel
+// and absolute markers are kept. It returns false if the cells referenced
+// were all removed, and the reference unchanged if it can't be parsed.
+func UpdateRangeReference(ref string, q *update.UpdateQuery) (string, bool) {
+	parts := strings.Split(ref, ":")
+	switch len(parts) {
+	case 1:
+		c, err := ParseCellReference(ref)
+		if err != nil {
+			return ref, true
+		}
+		if q.UpdatesRows() {
+			row, ok := q.UpdateIndex(c.RowIdx)
+			if !ok {
+				return "", false
+			}
+			c.RowIdx = row
+		} else {
+			col, ok := q.UpdateIndex(c.ColumnIdx)
+			if !ok {
+				return "", false
+			}
+			c.setColumn(col)
+		}
+		return c.String(), true
+	case 2:
+	default:
+		return ref, true
+	}
+	if from, err := ParseCellReference(parts[0]); err == nil {
+		to, err := ParseCellReference(parts[1])
+		if err != nil {
+			return ref, true
+		}
+		var ok bool
+		if q.UpdatesRows() {
+			from.RowIdx, to.RowIdx, ok = q.UpdateSpan(from.RowIdx, to.RowIdx)
+		} else {
+			var fc, tc uint32
+			fc, tc, ok = q.UpdateSpan(from.ColumnIdx, to.ColumnIdx)
+			from.setColumn(fc)
+			to.setColumn(tc)
+		}
+		if !ok {
+			return "", false
+		}
+		return from.String() + ":" + to.String(), true
+	}
+	if from, to, err := ParseColumnRangeReference(ref); err == nil {
+		if q.UpdatesRows() {
+			return ref, true
+		}
+		fc, tc, ok := q.UpdateSpan(from.ColumnIdx, to.ColumnIdx)
+		if !ok {
+			return "", false
+		}
+		from.ColumnIdx, from.Column = fc, IndexToColumn(fc)
+		to.ColumnIdx, to.Column = tc, IndexToColumn(tc)
+		return from.String() + ":" + to.String(), true
+	}
+	from, fromAbs, err1 := parseRowReference(parts[0])
+	to, toAbs, err2 := parseRowReference(parts[1])
+	if err1 != nil || err2 != nil {
+		return ref, true
+	}
+	if q.UpdatesColumns() {
+		return ref, true
+	}
+	from, to, ok := q.UpdateSpan(from, to)
+	if !ok {
+		return "", false
+	}
+	return formatRowReference(from, fromAbs) + ":" + formatRowReference(to, toAbs), true
+}
+
+// UpdateSqref updates the space separated references of a sqref attribute,
+// dropping the references that were removed.
+func UpdateSqref(refs []string, q *update.UpdateQuery) []string {
+	var res []string
+	for _, item := range refs {
+		for _, ref := range strings.Fields(item) {
+			if ref, ok := UpdateRangeReference(ref, q); ok {
+				// ranges reduced to a single cell are written as the cell
+				if ends := strings.Split(ref, ":"); len(ends) == 2 && ends[0] == ends[1] {
+					ref = ends[0]
+				}
+				res = append(res, ref)
+			}
+		}
+	}
+	return res
+}
+
+func (c *CellReference) setColumn(idx uint32) {
+	c.ColumnIdx = idx
+	c.Column = IndexToColumn(idx)
+}
+
+func parseRowReference(s string) (uint32, bool, error) {
+	abs := strings.HasPrefix(s, "$")
+	row, err := strconv.ParseUint(strings.TrimPrefix(s, "$"), 10, 32)
+	return uint32(row), abs, err
+}
+
+func formatRowReference(row uint32, abs bool) string {
+	s := strconv.FormatUint(uint64(row), 10)
+	if abs {
+		return "$" + s
+	}
+	return s
+}
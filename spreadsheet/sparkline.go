@@ -0,0 +1,701 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/color"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/spreadsheet/formula"
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+	"github.com/unidoc/unioffice/v2/spreadsheet/update"
+)
+
+// Sparklines are stored in an extension of the worksheet, which the schema
+// doesn't describe, and are kept as the generic XML read.
+const (
+	x14Namespace      = "http://schemas.microsoft.com/office/spreadsheetml/2009/9/main"
+	xmNamespace       = "http://schemas.microsoft.com/office/excel/2006/main"
+	sparklineGroupURI = "{05C60535-1F16-4fd2-B633-F4F36F0B64E0}"
+)
+
+// SparklineType is the type of the sparklines of a group.
+type SparklineType byte
+
+// SparklineType constants.
+const (
+	SparklineTypeLine SparklineType = iota
+	SparklineTypeColumn
+	// SparklineTypeWinLoss displays positive values as bars above the axis
+	// and negative values as bars below it, all of the same height.
+	SparklineTypeWinLoss
+)
+
+var sparklineTypes = []string{"line", "column", "stacked"}
+
+// SparklineEmptyCells controls how the empty cells of the data of sparklines
+// are displayed.
+type SparklineEmptyCells byte
+
+// SparklineEmptyCells constants.
+const (
+	SparklineEmptyCellsGap SparklineEmptyCells = iota
+	SparklineEmptyCellsZero
+	// SparklineEmptyCellsSpan connects the points around empty cells, for
+	// line sparklines.
+	SparklineEmptyCellsSpan
+)
+
+var sparklineEmptyCells = []string{"gap", "zero", "span"}
+
+// SparklineAxisScale controls how the minimum or maximum of the vertical axis
+// of sparklines is chosen.
+type SparklineAxisScale byte
+
+// SparklineAxisScale constants.
+const (
+	// SparklineAxisIndividual scales each sparkline to its own values.
+	SparklineAxisIndividual SparklineAxisScale = iota
+	// SparklineAxisGroup scales all the sparklines of the group to the values
+	// of the group.
+	SparklineAxisGroup
+	// SparklineAxisCustom uses a value given for all the sparklines.
+	SparklineAxisCustom
+)
+
+var sparklineAxisScales = []string{"individual", "group", "custom"}
+
+// SparklineColor identifies a color of a sparkline group.
+type SparklineColor byte
+
+// SparklineColor constants.
+const (
+	SparklineColorSeries SparklineColor = iota
+	SparklineColorNegative
+	SparklineColorAxis
+	SparklineColorMarkers
+	SparklineColorFirst
+	SparklineColorLast
+	SparklineColorHigh
+	SparklineColorLow
+)
+
+var sparklineColors = []string{"colorSeries", "colorNegative", "colorAxis", "colorMarkers", "colorFirst", "colorLast", "colorHigh", "colorLow"}
+
+// defaultSparklineColors are the colors of Excel's default sparkline style.
+var defaultSparklineColors = []string{"FF376092", "FFD00000", "FF000000", "FFD00000", "FFD00000", "FFD00000", "FFD00000", "FFD00000"}
+
+// sparklineGroupChildren are the children of a sparkline group, in the order
+// of the schema.
+var sparklineGroupChildren = append(append([]string{}, sparklineColors...), "f", "sparklines")
+
+// SparklinePoints identifies the points of sparklines that are highlighted.
+type SparklinePoints byte
+
+// SparklinePoints constants.
+const (
+	// SparklinePointsAll shows markers at all the points of line sparklines.
+	SparklinePointsAll SparklinePoints = iota
+	SparklinePointsHigh
+	SparklinePointsLow
+	SparklinePointsFirst
+	SparklinePointsLast
+	SparklinePointsNegative
+)
+
+var sparklinePoints = []string{"markers", "high", "low", "first", "last", "negative"}
+
+// Sparkline is a sparkline of a group.
+type Sparkline struct {
+	// Data is the range of the values displayed, such as "Sheet1!A2:E2".
+	Data string
+	// Location is the cell the sparkline is displayed in, such as "F2".
+	Location string
+}
+
+// SparklineGroup is a group of sparklines of a sheet, which share a type and
+// display options.
+type SparklineGroup struct {
+	x *unioffice.XSDAny
+	s *Sheet
+}
+
+// AddSparklineGroup adds a group of sparklines to the sheet, the sparkline
+// displaying the range data[i], such as "A2:E2" or "Sheet2!A2:E2", in the
+// cell locations[i]. Ranges without a sheet refer to the sheet. The sparklines
+// are given the colors of Excel's default style, and empty cells are displayed
+// as gaps.
+func (s *Sheet) AddSparklineGroup(typ SparklineType, data, locations []string) (SparklineGroup, error) {
+	if len(data) == 0 || len(data) != len(locations) {
+		return SparklineGroup{}, errors.New("sparkline group needs as many data ranges as locations")
+	}
+	g := SparklineGroup{x: newSparklineNode(x14Namespace, "sparklineGroup"), s: s}
+	for i := range data {
+		if err := g.AddSparkline(data[i], locations[i]); err != nil {
+			return SparklineGroup{}, err
+		}
+	}
+	g.SetType(typ)
+	g.SetEmptyCells(SparklineEmptyCellsGap)
+	for c, rgb := range defaultSparklineColors {
+		setSparklineAttr(g.child(sparklineColors[c], true), "rgb", rgb)
+	}
+	list := sparklineGroupList(s._bbbe, true)
+	list.Nodes = append(list.Nodes, g.x)
+	return g, nil
+}
+
+// SparklineGroups returns the sparkline groups of the sheet.
+func (s *Sheet) SparklineGroups() []SparklineGroup {
+	ret := []SparklineGroup{}
+	if list := sparklineGroupList(s._bbbe, false); list != nil {
+		for _, n := range list.Nodes {
+			if n.XMLName.Local == "sparklineGroup" {
+				ret = append(ret, SparklineGroup{n, s})
+			}
+		}
+	}
+	return ret
+}
+
+// RemoveSparklineGroup removes a sparkline group from the sheet.
+func (s *Sheet) RemoveSparklineGroup(g SparklineGroup) error {
+	list := sparklineGroupList(s._bbbe, false)
+	if list == nil {
+		return ErrorNotFound
+	}
+	for i, n := range list.Nodes {
+		if n == g.x {
+			list.Nodes = append(list.Nodes[:i], list.Nodes[i+1:]...)
+			removeEmptySparklineGroupList(s._bbbe)
+			return nil
+		}
+	}
+	return ErrorNotFound
+}
+
+// sparklineGroupList returns the element holding the sparkline groups of a
+// worksheet, creating it if create is true.
+func sparklineGroupList(ws *sml.Worksheet, create bool) *unioffice.XSDAny {
+	if ws.ExtLst != nil {
+		for _, ext := range ws.ExtLst.Ext {
+			if ext.UriAttr == nil || *ext.UriAttr != sparklineGroupURI {
+				continue
+			}
+			if list, ok := ext.Any.(*unioffice.XSDAny); ok {
+				return list
+			}
+		}
+	}
+	if !create {
+		return nil
+	}
+	if ws.ExtLst == nil {
+		ws.ExtLst = sml.NewCT_ExtensionList()
+	}
+	list := newSparklineNode(x14Namespace, "sparklineGroups")
+	ext := sml.NewCT_Extension()
+	ext.UriAttr = unioffice.String(sparklineGroupURI)
+	ext.Any = list
+	ws.ExtLst.Ext = append(ws.ExtLst.Ext, ext)
+	return list
+}
+
+// removeEmptySparklineGroupList removes the extension holding the sparkline
+// groups of a worksheet if it has none.
+func removeEmptySparklineGroupList(ws *sml.Worksheet) {
+	list := sparklineGroupList(ws, false)
+	if list == nil {
+		return
+	}
+	for _, n := range list.Nodes {
+		if n.XMLName.Local == "sparklineGroup" {
+			return
+		}
+	}
+	kept := ws.ExtLst.Ext[:0]
+	for _, ext := range ws.ExtLst.Ext {
+		if ext.Any != list {
+			kept = append(kept, ext)
+		}
+	}
+	ws.ExtLst.Ext = kept
+	if len(kept) == 0 {
+		ws.ExtLst = nil
+	}
+}
+
+// X returns the inner wrapped XML, the x14:sparklineGroup element.
+func (g SparklineGroup) X() *unioffice.XSDAny { return g.x }
+
+// Type returns the type of the sparklines.
+func (g SparklineGroup) Type() SparklineType {
+	v, _ := sparklineAttr(g.x, "type")
+	for i, t := range sparklineTypes {
+		if v == t {
+			return SparklineType(i)
+		}
+	}
+	return SparklineTypeLine
+}
+
+// SetType sets the type of the sparklines.
+func (g SparklineGroup) SetType(t SparklineType) {
+	if t == SparklineTypeLine || int(t) >= len(sparklineTypes) {
+		removeSparklineAttr(g.x, "type")
+		return
+	}
+	setSparklineAttr(g.x, "type", sparklineTypes[t])
+}
+
+// Sparklines returns the sparklines of the group.
+func (g SparklineGroup) Sparklines() []Sparkline {
+	ret := []Sparkline{}
+	list := g.child("sparklines", false)
+	if list == nil {
+		return ret
+	}
+	for _, n := range list.Nodes {
+		if n.XMLName.Local != "sparkline" {
+			continue
+		}
+		sl := Sparkline{}
+		if f := sparklineChild(n, "f"); f != nil {
+			sl.Data = strings.TrimSpace(string(f.Data))
+		}
+		if sqref := sparklineChild(n, "sqref"); sqref != nil {
+			sl.Location = strings.TrimSpace(string(sqref.Data))
+		}
+		ret = append(ret, sl)
+	}
+	return ret
+}
+
+// AddSparkline adds a sparkline displaying the range data, such as "A2:E2" or
+// "Sheet2!A2:E2", in the cell location of the sheet. A range without a sheet
+// refers to the sheet of the group. A cell can't display several sparklines.
+func (g SparklineGroup) AddSparkline(data, location string) error {
+	data, err := g.s.sparklineRange(data)
+	if err != nil {
+		return err
+	}
+	cref, err := reference.ParseCellReference(strings.ReplaceAll(location, "$", ""))
+	if err != nil {
+		return fmt.Errorf("invalid sparkline location %s: %s", location, err)
+	}
+	location = cref.String()
+	groups := g.s.SparklineGroups()
+	if !containsSparklineGroup(groups, g) {
+		groups = append(groups, g)
+	}
+	for _, other := range groups {
+		for _, sl := range other.Sparklines() {
+			if strings.EqualFold(sl.Location, location) {
+				return fmt.Errorf("cell %s already has a sparkline", location)
+			}
+		}
+	}
+	sl := newSparklineNode(x14Namespace, "sparkline")
+	f := newSparklineNode(xmNamespace, "f")
+	f.Data = []byte(data)
+	sqref := newSparklineNode(xmNamespace, "sqref")
+	sqref.Data = []byte(location)
+	sl.Nodes = []*unioffice.XSDAny{f, sqref}
+	list := g.child("sparklines", true)
+	list.Nodes = append(list.Nodes, sl)
+	return nil
+}
+
+func containsSparklineGroup(groups []SparklineGroup, g SparklineGroup) bool {
+	for _, other := range groups {
+		if other.x == g.x {
+			return true
+		}
+	}
+	return false
+}
+
+// sparklineRange validates a range of sparkline data, and prefixes it with
+// the name of the sheet if it has no sheet, as sparkline ranges always do.
+func (s *Sheet) sparklineRange(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	cells := ref
+	if i := strings.LastIndex(ref, "!"); i >= 0 {
+		cells = ref[i+1:]
+	} else {
+		ref = sheetReference(s.Name(), ref)
+	}
+	cells = strings.ReplaceAll(cells, "$", "")
+	if _, err := reference.ParseCellReference(cells); err == nil {
+		return ref, nil
+	}
+	if _, _, err := reference.ParseRangeReference(cells); err != nil {
+		return "", fmt.Errorf("invalid sparkline range %s: %s", ref, err)
+	}
+	return ref, nil
+}
+
+// sheetReference returns a reference to cells of a sheet, quoting the name
+// of the sheet if needed.
+func sheetReference(sheet, cells string) string {
+	for i, r := range sheet {
+		if !(r == '_' || r == '.' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return "'" + strings.ReplaceAll(sheet, "'", "''") + "'!" + cells
+		}
+	}
+	return sheet + "!" + cells
+}
+
+// Color returns a color of the group, or nil if it isn't set.
+func (g SparklineGroup) Color(c SparklineColor) *sml.CT_Color {
+	if int(c) >= len(sparklineColors) {
+		return nil
+	}
+	n := g.child(sparklineColors[c], false)
+	if n == nil {
+		return nil
+	}
+	clr := sml.NewCT_Color()
+	for _, a := range n.Attrs {
+		switch a.Name.Local {
+		case "auto":
+			clr.AutoAttr = unioffice.Bool(a.Value == "1" || a.Value == "true")
+		case "rgb":
+			clr.RgbAttr = unioffice.String(a.Value)
+		case "indexed":
+			if v, err := strconv.ParseUint(a.Value, 10, 32); err == nil {
+				clr.IndexedAttr = unioffice.Uint32(uint32(v))
+			}
+		case "theme":
+			if v, err := strconv.ParseUint(a.Value, 10, 32); err == nil {
+				clr.ThemeAttr = unioffice.Uint32(uint32(v))
+			}
+		case "tint":
+			if v, err := strconv.ParseFloat(a.Value, 64); err == nil {
+				clr.TintAttr = unioffice.Float64(v)
+			}
+		}
+	}
+	return clr
+}
+
+// SetColor sets a color of the group. The colors of the points are only used
+// if the points are shown, see SetShowPoints.
+func (g SparklineGroup) SetColor(c SparklineColor, clr color.Color) {
+	if int(c) >= len(sparklineColors) {
+		return
+	}
+	n := g.child(sparklineColors[c], true)
+	n.Attrs = nil
+	setSparklineAttr(n, "rgb", strings.ToUpper(*clr.AsRGBAString()))
+}
+
+// ShowPoints returns true if points of the sparklines are highlighted.
+func (g SparklineGroup) ShowPoints(p SparklinePoints) bool {
+	if int(p) >= len(sparklinePoints) {
+		return false
+	}
+	return g.boolAttr(sparklinePoints[p])
+}
+
+// SetShowPoints controls whether points of the sparklines are highlighted, by
+// markers on line sparklines and by the color of the bars on column and
+// win/loss sparklines.
+func (g SparklineGroup) SetShowPoints(p SparklinePoints, show bool) {
+	if int(p) < len(sparklinePoints) {
+		g.setBoolAttr(sparklinePoints[p], show)
+	}
+}
+
+// EmptyCells returns how empty cells are displayed.
+func (g SparklineGroup) EmptyCells() SparklineEmptyCells {
+	v, _ := sparklineAttr(g.x, "displayEmptyCellsAs")
+	for i, e := range sparklineEmptyCells {
+		if v == e {
+			return SparklineEmptyCells(i)
+		}
+	}
+	return SparklineEmptyCellsZero
+}
+
+// SetEmptyCells controls how empty cells are displayed.
+func (g SparklineGroup) SetEmptyCells(e SparklineEmptyCells) {
+	if int(e) < len(sparklineEmptyCells) {
+		setSparklineAttr(g.x, "displayEmptyCellsAs", sparklineEmptyCells[e])
+	}
+}
+
+// ShowAxis returns true if the horizontal axis is displayed.
+func (g SparklineGroup) ShowAxis() bool { return g.boolAttr("displayXAxis") }
+
+// SetShowAxis controls whether the horizontal axis is displayed, when the
+// sparklines have values on both sides of it.
+func (g SparklineGroup) SetShowAxis(show bool) { g.setBoolAttr("displayXAxis", show) }
+
+// ShowHidden returns true if the values of hidden rows and columns are
+// displayed.
+func (g SparklineGroup) ShowHidden() bool { return g.boolAttr("displayHidden") }
+
+// SetShowHidden controls whether the values of hidden rows and columns are
+// displayed.
+func (g SparklineGroup) SetShowHidden(show bool) { g.setBoolAttr("displayHidden", show) }
+
+// RightToLeft returns true if the values are displayed from right to left.
+func (g SparklineGroup) RightToLeft() bool { return g.boolAttr("rightToLeft") }
+
+// SetRightToLeft controls whether the values are displayed from right to left.
+func (g SparklineGroup) SetRightToLeft(rtl bool) { g.setBoolAttr("rightToLeft", rtl) }
+
+// LineWeight returns the weight of the lines of line sparklines.
+func (g SparklineGroup) LineWeight() measurement.Distance {
+	if v, ok := sparklineAttr(g.x, "lineWeight"); ok {
+		if w, err := strconv.ParseFloat(v, 64); err == nil {
+			return measurement.Distance(w) * measurement.Point
+		}
+	}
+	return 0.75 * measurement.Point
+}
+
+// SetLineWeight sets the weight of the lines of line sparklines.
+func (g SparklineGroup) SetLineWeight(w measurement.Distance) {
+	setSparklineAttr(g.x, "lineWeight", strconv.FormatFloat(float64(w/measurement.Point), 'f', -1, 64))
+}
+
+// MinAxis returns how the minimum of the vertical axis is chosen, and the
+// minimum if it is custom.
+func (g SparklineGroup) MinAxis() (SparklineAxisScale, float64) {
+	return g.axis("minAxisType", "manualMin")
+}
+
+// SetMinAxis controls how the minimum of the vertical axis is chosen. The
+// value is the minimum for SparklineAxisCustom and is otherwise ignored.
+func (g SparklineGroup) SetMinAxis(scale SparklineAxisScale, value float64) {
+	g.setAxis("minAxisType", "manualMin", scale, value)
+}
+
+// MaxAxis returns how the maximum of the vertical axis is chosen, and the
+// maximum if it is custom.
+func (g SparklineGroup) MaxAxis() (SparklineAxisScale, float64) {
+	return g.axis("maxAxisType", "manualMax")
+}
+
+// SetMaxAxis controls how the maximum of the vertical axis is chosen. The
+// value is the maximum for SparklineAxisCustom and is otherwise ignored.
+func (g SparklineGroup) SetMaxAxis(scale SparklineAxisScale, value float64) {
+	g.setAxis("maxAxisType", "manualMax", scale, value)
+}
+
+func (g SparklineGroup) axis(typeAttr, valueAttr string) (SparklineAxisScale, float64) {
+	scale := SparklineAxisIndividual
+	v, _ := sparklineAttr(g.x, typeAttr)
+	for i, s := range sparklineAxisScales {
+		if v == s {
+			scale = SparklineAxisScale(i)
+		}
+	}
+	value := 0.0
+	if v, ok := sparklineAttr(g.x, valueAttr); ok {
+		value, _ = strconv.ParseFloat(v, 64)
+	}
+	return scale, value
+}
+
+func (g SparklineGroup) setAxis(typeAttr, valueAttr string, scale SparklineAxisScale, value float64) {
+	removeSparklineAttr(g.x, valueAttr)
+	if scale == SparklineAxisIndividual || int(scale) >= len(sparklineAxisScales) {
+		removeSparklineAttr(g.x, typeAttr)
+		return
+	}
+	setSparklineAttr(g.x, typeAttr, sparklineAxisScales[scale])
+	if scale == SparklineAxisCustom {
+		setSparklineAttr(g.x, valueAttr, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+}
+
+// DateAxis returns the range of the dates of the values, or an empty string
+// if the values are evenly spaced.
+func (g SparklineGroup) DateAxis() string {
+	if !g.boolAttr("dateAxis") {
+		return ""
+	}
+	if f := g.child("f", false); f != nil {
+		return strings.TrimSpace(string(f.Data))
+	}
+	return ""
+}
+
+// SetDateAxis sets the range of the dates of the values, such as "A1:E1",
+// which space the values by date. An empty range spaces them evenly.
+func (g SparklineGroup) SetDateAxis(ref string) error {
+	if ref == "" {
+		removeSparklineAttr(g.x, "dateAxis")
+		g.removeChild("f")
+		return nil
+	}
+	ref, err := g.s.sparklineRange(ref)
+	if err != nil {
+		return err
+	}
+	g.setBoolAttr("dateAxis", true)
+	g.child("f", true).Data = []byte(ref)
+	return nil
+}
+
+func (g SparklineGroup) boolAttr(name string) bool {
+	v, _ := sparklineAttr(g.x, name)
+	return v == "1" || v == "true"
+}
+
+func (g SparklineGroup) setBoolAttr(name string, b bool) {
+	if b {
+		setSparklineAttr(g.x, name, "1")
+	} else {
+		removeSparklineAttr(g.x, name)
+	}
+}
+
+// child returns the child of the group with a local name, adding it where
+// the schema orders it if create is true.
+func (g SparklineGroup) child(local string, create bool) *unioffice.XSDAny {
+	if n := sparklineChild(g.x, local); n != nil || !create {
+		return n
+	}
+	order := func(local string) int {
+		for i, c := range sparklineGroupChildren {
+			if c == local {
+				return i
+			}
+		}
+		return len(sparklineGroupChildren)
+	}
+	space := x14Namespace
+	if local == "f" {
+		space = xmNamespace
+	}
+	n := newSparklineNode(space, local)
+	pos := len(g.x.Nodes)
+	for i, c := range g.x.Nodes {
+		if order(c.XMLName.Local) > order(local) {
+			pos = i
+			break
+		}
+	}
+	g.x.Nodes = append(g.x.Nodes[:pos], append([]*unioffice.XSDAny{n}, g.x.Nodes[pos:]...)...)
+	return n
+}
+
+func (g SparklineGroup) removeChild(local string) {
+	kept := g.x.Nodes[:0]
+	for _, n := range g.x.Nodes {
+		if n.XMLName.Local != local {
+			kept = append(kept, n)
+		}
+	}
+	g.x.Nodes = kept
+}
+
+func newSparklineNode(space, local string) *unioffice.XSDAny {
+	return &unioffice.XSDAny{XMLName: xml.Name{Space: space, Local: local}}
+}
+
+func sparklineChild(n *unioffice.XSDAny, local string) *unioffice.XSDAny {
+	for _, c := range n.Nodes {
+		if c.XMLName.Local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+func sparklineAttr(n *unioffice.XSDAny, name string) (string, bool) {
+	for _, a := range n.Attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+func setSparklineAttr(n *unioffice.XSDAny, name, value string) {
+	for i, a := range n.Attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			n.Attrs[i].Value = value
+			return
+		}
+	}
+	n.Attrs = append(n.Attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+}
+
+func removeSparklineAttr(n *unioffice.XSDAny, name string) {
+	kept := n.Attrs[:0]
+	for _, a := range n.Attrs {
+		if a.Name.Space != "" || a.Name.Local != name {
+			kept = append(kept, a)
+		}
+	}
+	n.Attrs = kept
+}
+
+// updateSparklines updates the ranges of the sparklines of a worksheet, and
+// their locations if the worksheet is the one updated. Sparklines whose data
+// or location was removed are dropped, as are groups left without sparklines.
+func updateSparklines(ws *sml.Worksheet, q *update.UpdateQuery) {
+	list := sparklineGroupList(ws, false)
+	if list == nil {
+		return
+	}
+	updateRange := func(n *unioffice.XSDAny) bool {
+		ref := formula.UpdateFormula(strings.TrimSpace(string(n.Data)), q)
+		n.Data = []byte(ref)
+		return !strings.Contains(ref, "#REF!")
+	}
+	groups := list.Nodes[:0]
+	for _, g := range list.Nodes {
+		if g.XMLName.Local != "sparklineGroup" {
+			groups = append(groups, g)
+			continue
+		}
+		if f := sparklineChild(g, "f"); f != nil && !updateRange(f) {
+			SparklineGroup{x: g}.SetDateAxis("")
+		}
+		sls := sparklineChild(g, "sparklines")
+		if sls == nil {
+			continue
+		}
+		kept := sls.Nodes[:0]
+		for _, sl := range sls.Nodes {
+			if f := sparklineChild(sl, "f"); f != nil && !updateRange(f) {
+				continue
+			}
+			if sqref := sparklineChild(sl, "sqref"); sqref != nil && q.UpdateCurrentSheet {
+				refs := reference.UpdateSqref([]string{string(sqref.Data)}, q)
+				if len(refs) == 0 {
+					continue
+				}
+				sqref.Data = []byte(strings.Join(refs, " "))
+			}
+			kept = append(kept, sl)
+		}
+		sls.Nodes = kept
+		if len(kept) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	list.Nodes = groups
+	removeEmptySparklineGroupList(ws)
+}
@@ -0,0 +1,264 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/color"
+	"github.com/unidoc/unioffice/v2/measurement"
+)
+
+// sparklines returns the sparklines of the groups of a sheet, a slice per
+// group.
+func sparklines(s *Sheet) [][]Sparkline {
+	ret := [][]Sparkline{}
+	for _, g := range s.SparklineGroups() {
+		ret = append(ret, g.Sparklines())
+	}
+	return ret
+}
+
+func TestAddSparklineGroup(t *testing.T) {
+	wb := New()
+	s := wb.AddSheet()
+	s.SetName("Sales")
+	other := wb.AddSheet()
+	other.SetName("Q1 Data")
+
+	if _, err := s.AddSparklineGroup(SparklineTypeLine, nil, nil); err == nil {
+		t.Error("added a group without sparklines")
+	}
+	if _, err := s.AddSparklineGroup(SparklineTypeLine, []string{"A1:E1", "A2:E2"}, []string{"F1"}); err == nil {
+		t.Error("added a group with fewer locations than ranges")
+	}
+	if _, err := s.AddSparklineGroup(SparklineTypeLine, []string{"A1:"}, []string{"F1"}); err == nil {
+		t.Error("added a sparkline of an invalid range")
+	}
+	if _, err := s.AddSparklineGroup(SparklineTypeLine, []string{"A1:E1"}, []string{"F"}); err == nil {
+		t.Error("added a sparkline at an invalid location")
+	}
+	if len(s.SparklineGroups()) != 0 || s.X().ExtLst != nil {
+		t.Error("failed groups were added")
+	}
+
+	line, err := s.AddSparklineGroup(SparklineTypeLine, []string{"A1:E1", "$A$2:$E$2"}, []string{"F1", "$F$2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := line.AddSparkline("'Q1 Data'!A1:E1", "F3"); err != nil {
+		t.Fatal(err)
+	}
+	cols, err := s.AddSparklineGroup(SparklineTypeColumn, []string{"A4:E4"}, []string{"F4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cols.AddSparkline("A5:E5", "f1"); err == nil {
+		t.Error("added a second sparkline to F1")
+	}
+	if _, err := other.AddSparklineGroup(SparklineTypeWinLoss, []string{"Sales!A1:A4"}, []string{"B1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// defaults of new groups
+	if line.Type() != SparklineTypeLine || line.EmptyCells() != SparklineEmptyCellsGap || line.ShowAxis() || line.DateAxis() != "" {
+		t.Errorf("new group = %v %v %v %q", line.Type(), line.EmptyCells(), line.ShowAxis(), line.DateAxis())
+	}
+	if c := line.Color(SparklineColorSeries); c == nil || *c.RgbAttr != "FF376092" {
+		t.Errorf("series color = %v", c)
+	}
+	if line.LineWeight() != 0.75*measurement.Point {
+		t.Errorf("line weight = %v", line.LineWeight())
+	}
+	if scale, _ := line.MinAxis(); scale != SparklineAxisIndividual {
+		t.Errorf("min axis = %v", scale)
+	}
+
+	cols.SetColor(SparklineColorNegative, color.RGB(0x12, 0x34, 0x56))
+	cols.SetShowPoints(SparklinePointsNegative, true)
+	cols.SetShowPoints(SparklinePointsHigh, true)
+	cols.SetShowPoints(SparklinePointsHigh, false)
+	cols.SetEmptyCells(SparklineEmptyCellsZero)
+	cols.SetShowAxis(true)
+	cols.SetShowHidden(true)
+	cols.SetRightToLeft(true)
+	cols.SetMinAxis(SparklineAxisCustom, -2.5)
+	cols.SetMaxAxis(SparklineAxisGroup, 7)
+	line.SetType(SparklineTypeColumn)
+	line.SetType(SparklineTypeLine)
+	line.SetLineWeight(1.5 * measurement.Point)
+	line.SetEmptyCells(SparklineEmptyCellsSpan)
+	line.SetShowPoints(SparklinePointsAll, true)
+	if err := line.SetDateAxis("A10:E10"); err != nil {
+		t.Fatal(err)
+	}
+	if err := line.SetDateAxis("A10:"); err == nil {
+		t.Error("set an invalid date axis")
+	}
+
+	check := func(prefix string, wb *Workbook) {
+		t.Helper()
+		s, other := wb.Sheets()[0], wb.Sheets()[1]
+		want := [][]Sparkline{
+			{{"Sales!A1:E1", "F1"}, {"Sales!$A$2:$E$2", "F2"}, {"'Q1 Data'!A1:E1", "F3"}},
+			{{"Sales!A4:E4", "F4"}},
+		}
+		if got := sparklines(&s); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%ssparklines = %v, want %v", prefix, got, want)
+		}
+		if got := sparklines(&other); !reflect.DeepEqual(got, [][]Sparkline{{{"Sales!A1:A4", "B1"}}}) {
+			t.Errorf("%ssparklines of the other sheet = %v", prefix, got)
+		}
+		if g := other.SparklineGroups()[0]; g.Type() != SparklineTypeWinLoss {
+			t.Errorf("%sgroup type = %v, want win/loss", prefix, g.Type())
+		}
+
+		line, cols := s.SparklineGroups()[0], s.SparklineGroups()[1]
+		if line.Type() != SparklineTypeLine || line.LineWeight() != 1.5*measurement.Point || line.EmptyCells() != SparklineEmptyCellsSpan {
+			t.Errorf("%sline group = %v %v %v", prefix, line.Type(), line.LineWeight(), line.EmptyCells())
+		}
+		if !line.ShowPoints(SparklinePointsAll) || line.DateAxis() != "Sales!A10:E10" {
+			t.Errorf("%sline group markers %v, date axis %q", prefix, line.ShowPoints(SparklinePointsAll), line.DateAxis())
+		}
+		if cols.Type() != SparklineTypeColumn || cols.EmptyCells() != SparklineEmptyCellsZero {
+			t.Errorf("%scolumn group = %v %v", prefix, cols.Type(), cols.EmptyCells())
+		}
+		if c := cols.Color(SparklineColorNegative); c == nil || *c.RgbAttr != "FF123456" {
+			t.Errorf("%snegative color = %v", prefix, c)
+		}
+		if !cols.ShowPoints(SparklinePointsNegative) || cols.ShowPoints(SparklinePointsHigh) || cols.ShowPoints(SparklinePointsLow) {
+			t.Errorf("%spoints shown = %v %v %v", prefix, cols.ShowPoints(SparklinePointsNegative), cols.ShowPoints(SparklinePointsHigh), cols.ShowPoints(SparklinePointsLow))
+		}
+		if !cols.ShowAxis() || !cols.ShowHidden() || !cols.RightToLeft() {
+			t.Errorf("%saxis %v, hidden %v, right to left %v", prefix, cols.ShowAxis(), cols.ShowHidden(), cols.RightToLeft())
+		}
+		if scale, v := cols.MinAxis(); scale != SparklineAxisCustom || v != -2.5 {
+			t.Errorf("%smin axis = %v %v", prefix, scale, v)
+		}
+		if scale, v := cols.MaxAxis(); scale != SparklineAxisGroup || v != 0 {
+			t.Errorf("%smax axis = %v %v", prefix, scale, v)
+		}
+	}
+	check("", wb)
+	read := roundTrip(t, wb)
+	check("read ", read)
+
+	// the groups are removed with the extension holding them
+	s = read.Sheets()[0]
+	groups := s.SparklineGroups()
+	for _, g := range groups {
+		if err := s.RemoveSparklineGroup(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.RemoveSparklineGroup(groups[0]); err != ErrorNotFound {
+		t.Errorf("removing a removed group = %v, want ErrorNotFound", err)
+	}
+	if s.X().ExtLst != nil {
+		t.Error("the sparkline extension wasn't removed")
+	}
+	read = roundTrip(t, read)
+	if len(read.Sheets()[0].SparklineGroups()) != 0 || len(read.Sheets()[1].SparklineGroups()) != 1 {
+		t.Errorf("groups after removal = %d %d", len(read.Sheets()[0].SparklineGroups()), len(read.Sheets()[1].SparklineGroups()))
+	}
+}
+
+func TestSparklineShift(t *testing.T) {
+	wb := New()
+	s := wb.AddSheet()
+	s.SetName("Data")
+	other := wb.AddSheet()
+	other.SetName("Summary")
+	line, err := s.AddSparklineGroup(SparklineTypeLine, []string{"A2:E2", "A3:E3", "A4:E4"}, []string{"F2", "F3", "F4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := line.SetDateAxis("A1:E1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddSparklineGroup(SparklineTypeColumn, []string{"A6:E6"}, []string{"G6"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.AddSparklineGroup(SparklineTypeLine, []string{"Data!A3:E3"}, []string{"A3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name  string
+		shift func() error
+		data  [][]Sparkline
+		other []Sparkline
+		date  string
+	}{
+		{
+			"insert rows above the data",
+			func() error { return s.InsertRows(1, 2) },
+			[][]Sparkline{{{"Data!A4:E4", "F4"}, {"Data!A5:E5", "F5"}, {"Data!A6:E6", "F6"}}, {{"Data!A8:E8", "G8"}}},
+			[]Sparkline{{"Data!A5:E5", "A3"}},
+			"Data!A3:E3",
+		},
+		{
+			"insert a column inside the data ranges",
+			func() error { return s.InsertColumns("C", 1) },
+			[][]Sparkline{{{"Data!A4:F4", "G4"}, {"Data!A5:F5", "G5"}, {"Data!A6:F6", "G6"}}, {{"Data!A8:F8", "H8"}}},
+			[]Sparkline{{"Data!A5:F5", "A3"}},
+			"Data!A3:F3",
+		},
+		{
+			"delete the row of a sparkline",
+			func() error { return s.DeleteRows(5, 1) },
+			[][]Sparkline{{{"Data!A4:F4", "G4"}, {"Data!A5:F5", "G5"}}, {{"Data!A7:F7", "H7"}}},
+			nil,
+			"Data!A3:F3",
+		},
+		{
+			"delete the row of the only sparkline of a group",
+			func() error { return s.DeleteRows(7, 1) },
+			[][]Sparkline{{{"Data!A4:F4", "G4"}, {"Data!A5:F5", "G5"}}},
+			nil,
+			"Data!A3:F3",
+		},
+		{
+			"delete the dates",
+			func() error { return s.DeleteRows(3, 1) },
+			[][]Sparkline{{{"Data!A3:F3", "G3"}, {"Data!A4:F4", "G4"}}},
+			nil,
+			"",
+		},
+	}
+	for _, st := range steps {
+		if err := st.shift(); err != nil {
+			t.Fatalf("%s: %s", st.name, err)
+		}
+		if got := sparklines(&s); !reflect.DeepEqual(got, st.data) {
+			t.Errorf("%s: sparklines = %v, want %v", st.name, got, st.data)
+		}
+		var got []Sparkline
+		if groups := other.SparklineGroups(); len(groups) > 0 {
+			got = groups[0].Sparklines()
+		}
+		if !reflect.DeepEqual(got, st.other) {
+			t.Errorf("%s: sparklines of the other sheet = %v, want %v", st.name, got, st.other)
+		}
+		if got := s.SparklineGroups()[0].DateAxis(); got != st.date {
+			t.Errorf("%s: date axis = %q, want %q", st.name, got, st.date)
+		}
+	}
+	if other.X().ExtLst != nil {
+		t.Error("the sparkline extension of the other sheet wasn't removed")
+	}
+
+	read := roundTrip(t, wb)
+	rs := read.Sheets()[0]
+	if got, want := sparklines(&rs), steps[len(steps)-1].data; !reflect.DeepEqual(got, want) {
+		t.Errorf("read sparklines = %v, want %v", got, want)
+	}
+}
@@ -18,7 +18,7 @@
 package spreadsheet ;import (_cc "archive/zip";_gf "bytes";_gb "errors";_ag "fmt";_d "github.com/unidoc/unioffice/v2";_bc "github.com/unidoc/unioffice/v2/chart";_de "github.com/unidoc/unioffice/v2/color";_bfe "github.com/unidoc/unioffice/v2/common";_ef "github.com/unidoc/unioffice/v2/common/logger";
 _gaf "github.com/unidoc/unioffice/v2/common/tempstorage";_fbe "github.com/unidoc/unioffice/v2/internal/formatutils";_bg "github.com/unidoc/unioffice/v2/internal/license";_ab "github.com/unidoc/unioffice/v2/measurement";_da "github.com/unidoc/unioffice/v2/schema/soo/dml";
 _ge "github.com/unidoc/unioffice/v2/schema/soo/dml/chart";_cdg "github.com/unidoc/unioffice/v2/schema/soo/dml/spreadsheetDrawing";_gcc "github.com/unidoc/unioffice/v2/schema/soo/pkg/relationships";_ca "github.com/unidoc/unioffice/v2/schema/soo/sml";_gd "github.com/unidoc/unioffice/v2/spreadsheet/format";
-_bcc "github.com/unidoc/unioffice/v2/spreadsheet/formula";_ed "github.com/unidoc/unioffice/v2/spreadsheet/reference";_ee "github.com/unidoc/unioffice/v2/spreadsheet/update";_ce "github.com/unidoc/unioffice/v2/vmldrawing";_fg "github.com/unidoc/unioffice/v2/zippkg";
+_bcc "github.com/unidoc/unioffice/v2/spreadsheet/formula";_ed "github.com/unidoc/unioffice/v2/spreadsheet/reference";_ce "github.com/unidoc/unioffice/v2/vmldrawing";_fg "github.com/unidoc/unioffice/v2/zippkg";
 _gc "image";_f "image/jpeg";_bf "io";_gfb "math";_e "math/big";_c "os";_b "path";_ba "path/filepath";_ga "reflect";_gfg "regexp";_a "sort";_fb "strconv";_dd "strings";_cd "time";);
 
 // ClearBorder clears any border configuration from the cell style.
@@ -121,7 +121,7 @@ func (_dadc Row )AddCell ()Cell {_cddg :=uint32 (len (_dadc ._dgaf .C ));var _bd
 func (_ad Cell )Column ()(string ,error ){_fcc ,_bge :=_ed .ParseCellReference (_ad .Reference ());if _bge !=nil {return "",_bge ;};return _fcc .Column ,nil ;};
 
 // IsBool returns true if the cell boolean value.
-func (_edge *evalContext )IsBool (cellRef string )bool {return _edge ._daa .Cell (cellRef ).IsBool ()};
+func (_edge *evalContext )IsBool (cellRef string )bool {return _edge .cell (cellRef ).IsBool ()};
 
 // Sort sorts all of the rows within a sheet by the contents of a column. As the
 // file format doesn't suppot indicating that a column should be sorted by the
@@ -139,8 +139,7 @@ if _fcfd .RowNumber ()!=_bagg {_fcfd .renumberAs (_bagg );};};};
 func (_agbbc WorkbookProtection )LockWindow (b bool ){if !b {_agbbc ._fbbb .LockWindowsAttr =nil ;}else {_agbbc ._fbbb .LockWindowsAttr =_d .Bool (true );};};
 
 // SetOperator sets the operator for the rule.
-func (_cfaf ConditionalFormattingRule )SetOperator (t _ca .ST_ConditionalFormattingOperator ){_cfaf ._fef .OperatorAttr =t ;};func (_eceg *Sheet )getAllCellsInFormulaArraysForColumn ()(map[string ]bool ,error ){return _eceg .getAllCellsInFormulaArrays (false );
-};
+func (_cfaf ConditionalFormattingRule )SetOperator (t _ca .ST_ConditionalFormattingOperator ){_cfaf ._fef .OperatorAttr =t ;};
 
 // BottomRight returns the CellMaker for the bottom right corner of the anchor.
 func (_adcee TwoCellAnchor )BottomRight ()CellMarker {return CellMarker {_adcee ._gegg .To }};
@@ -154,7 +153,7 @@ func (_bdcc CellMarker )Row ()int32 {return _bdcc ._cdag .Row };
 
 // SetBool sets the cell type to boolean and the value to the given boolean
 // value.
-func (_fe Cell )SetBool (v bool ){_fe .clearValue ();_fe ._dga .V =_d .String (_fb .Itoa (_edb (v )));_fe ._dga .TAttr =_ca .ST_CellTypeB ;};func (_gba Cell )clearValue (){_gba ._dga .F =nil ;_gba ._dga .Is =nil ;_gba ._dga .V =nil ;_gba ._dga .TAttr =_ca .ST_CellTypeUnset ;
+func (_fe Cell )SetBool (v bool ){_fe .clearValue ();_fe ._dga .V =_d .String (_fb .Itoa (_edb (v )));_fe ._dga .TAttr =_ca .ST_CellTypeB ;};func (_gba Cell )clearValue (){_gba .changed ();_gba .clearSpill ();_gba ._dga .F =nil ;_gba ._dga .Is =nil ;_gba ._dga .V =nil ;_gba ._dga .TAttr =_ca .ST_CellTypeUnset ;
 };
 
 // SetText sets the text to be displayed.
@@ -225,9 +224,7 @@ return _adgg ;};
 func (_eaca SheetView )SetXSplit (v float64 ){_eaca .ensurePane ();_eaca ._agec .Pane .XSplitAttr =_d .Float64 (v );};
 
 // X returns the inner wrapped XML type.
-func (_dafc NumberFormat )X ()*_ca .CT_NumFmt {return _dafc ._gef };func (_fccd *evalContext )SetOffset (col ,row uint32 ){_fccd ._abdg =col ;_fccd ._bgba =row };func (_ffgda *Sheet )updateAfterRemove (_baad uint32 ,_ddecg _ee .UpdateAction )error {_dfgab :=_ffgda .Name ();
-_abfb :=&_ee .UpdateQuery {UpdateType :_ddecg ,ColumnIdx :_baad ,SheetToUpdate :_dfgab };for _ ,_efee :=range _ffgda ._fgeg .Sheets (){_abfb .UpdateCurrentSheet =_dfgab ==_efee .Name ();for _ ,_gceg :=range _efee .Rows (){for _ ,_fde :=range _gceg .Cells (){if _fde .X ().F !=nil {_fbca :=_fde .X ().F .Content ;
-_cddfb :=_bcc .ParseString (_fbca );if _cddfb ==nil {_fde .SetError ("\u0023\u0052\u0045F\u0021");}else {_gceb :=_cddfb .Update (_abfb );_fde .X ().F .Content =_ag .Sprintf ("\u003d\u0025\u0073",_gceb .String ());};};};};};return nil ;};
+func (_dafc NumberFormat )X ()*_ca .CT_NumFmt {return _dafc ._gef };func (_fccd *evalContext )SetOffset (col ,row uint32 ){_fccd ._abdg =col ;_fccd ._bgba =row };
 
 // ColOffset returns the offset from the row cell.
 func (_ebb CellMarker )ColOffset ()_ab .Distance {if _ebb ._cdag .RowOff .ST_CoordinateUnqualified ==nil {return 0;};return _ab .Distance (float64 (*_ebb ._cdag .ColOff .ST_CoordinateUnqualified )*_ab .EMU );};
@@ -345,8 +342,8 @@ func (_cabb Row )SetHeight (d _ab .Distance ){_cabb ._dgaf .HtAttr =_d .Float64
 func (_cegc Fills )AddFill ()Fill {_agbb :=_ca .NewCT_Fill ();return Fill {_agbb ,_cegc ._bdgf }};
 
 // NewSharedStrings constructs a new Shared Strings table.
-func NewSharedStrings ()SharedStrings {return SharedStrings {_bbee :_ca .NewSst (),_bcd :make (map[string ]int )};};func (_aga *evalContext )NamedRange (ref string )_bcc .Reference {for _ ,_bgd :=range _aga ._daa ._fgeg .DefinedNames (){if _bgd .Name ()==ref {return _bcc .MakeRangeReference (_bgd .Content ());
-};};for _ ,_bfab :=range _aga ._daa ._fgeg .Tables (){if _bfab .Name ()==ref {return _bcc .MakeRangeReference (_ag .Sprintf ("\u0025\u0073\u0021%\u0073",_aga ._daa .Name (),_bfab .Reference ()));};};return _bcc .ReferenceInvalid ;};var ErrorNotFound =_gb .New ("\u006eo\u0074\u0020\u0066\u006f\u0075\u006ed");
+func NewSharedStrings ()SharedStrings {return SharedStrings {_bbee :_ca .NewSst (),_bcd :make (map[string ]int )};};func (_aga *evalContext )NamedRange (ref string )_bcc .Reference {for _ ,_bgd :=range _aga ._daa ._fgeg .DefinedNames (){if _dd .EqualFold (_bgd .Name (),ref ){return _bcc .MakeRangeReference (_bgd .Content ());
+};};for _ ,_bfab :=range _aga ._daa ._fgeg .Tables (){if _dd .EqualFold (_bfab .Name (),ref ){return _bcc .MakeRangeReference (_ag .Sprintf ("\u0025\u0073\u0021%\u0073",_bfab .sheetName (),_bfab .Reference ()));};};return _bcc .ReferenceInvalid ;};var ErrorNotFound =_gb .New ("\u006eo\u0074\u0020\u0066\u006f\u0075\u006ed");
 
 
 // SetReference sets the regin of cells that the merged cell applies to.
@@ -483,7 +480,7 @@ _gacg =_cgeb .ID ();break ;};};_addd ._bbbe .Drawing =_ca .NewCT_Drawing ();_add
 func (_cae ColorScale )AddGradientStop (color _de .Color ){_egcd :=_ca .NewCT_Color ();_egcd .RgbAttr =color .AsRGBAString ();_cae ._cab .Color =append (_cae ._cab .Color ,_egcd );};
 
 // GetFormat returns a cell data format.
-func (_geca *evalContext )GetFormat (cellRef string )string {return _geca ._daa .Cell (cellRef ).getFormat ();};func (_eedb *Sheet )setArray (_ccfd string ,_gfeb _bcc .Result )error {_fgbc ,_cgbg :=_ed .ParseCellReference (_ccfd );if _cgbg !=nil {return _cgbg ;
+func (_geca *evalContext )GetFormat (cellRef string )string {return _geca .cell (cellRef ).getFormat ();};func (_eedb *Sheet )setArray (_ccfd string ,_gfeb _bcc .Result )error {_fgbc ,_cgbg :=_ed .ParseCellReference (_ccfd );if _cgbg !=nil {return _cgbg ;
 };for _bacd ,_fcca :=range _gfeb .ValueArray {_bdafdd :=_eedb .Row (_fgbc .RowIdx +uint32 (_bacd ));for _dage ,_ffgdd :=range _fcca {_abfg :=_bdafdd .Cell (_ed .IndexToColumn (_fgbc .ColumnIdx +uint32 (_dage )));if _ffgdd .Type !=_bcc .ResultTypeEmpty {if _ffgdd .IsBoolean {_abfg .SetBool (_ffgdd .ValueNumber !=0);
 }else {_abfg .SetCachedFormulaResult (_ffgdd .String ());};};};};return nil ;};
 
@@ -578,7 +575,7 @@ if _gadb > 31{return _ag .Errorf ("\u0073\u0068\u0065\u0065\u0074 \u006e\u0061\u
 func (_edd SheetView )SetShowRuler (b bool ){if !b {_edd ._agec .ShowRulerAttr =_d .Bool (false );}else {_edd ._agec .ShowRulerAttr =nil ;};};
 
 // GetLocked returns true if the cell is locked.
-func (_bfba *evalContext )GetLocked (cellRef string )bool {return _bfba ._daa .Cell (cellRef ).getLocked ()};
+func (_bfba *evalContext )GetLocked (cellRef string )bool {return _bfba .cell (cellRef ).getLocked ()};
 
 // X returns the inner XML entity for a stylesheet.
 func (_dcacb StyleSheet )X ()*_ca .StyleSheet {return _dcacb ._gccd };var _gfcca =false ;
@@ -624,9 +621,7 @@ _aced :=_ffbf .Cell (_ecge );if _gcdf .Type ==_bcc .ResultTypeNumber {_aced .X (
 // SetFgColor sets the *fill* foreground color.  As an example, the solid pattern foreground color becomes the
 // background color of the cell when applied.
 func (_fabc PatternFill )SetFgColor (c _de .Color ){_fabc ._eaecg .FgColor =_ca .NewCT_Color ();_fabc ._eaecg .FgColor .RgbAttr =c .AsRGBAString ();};const (DVCompareTypeWholeNumber =DVCompareType (_ca .ST_DataValidationTypeWhole );DVCompareTypeDecimal =DVCompareType (_ca .ST_DataValidationTypeDecimal );
-DVCompareTypeDate =DVCompareType (_ca .ST_DataValidationTypeDate );DVCompareTypeTime =DVCompareType (_ca .ST_DataValidationTypeTime );DVompareTypeTextLength =DVCompareType (_ca .ST_DataValidationTypeTextLength ););func (_fbcb *Sheet )removeColumnFromMergedCells (_cdab uint32 )error {if _fbcb ._bbbe .MergeCells ==nil ||_fbcb ._bbbe .MergeCells .MergeCell ==nil {return nil ;
-};_dbgb :=[]*_ca .CT_MergeCell {};for _ ,_gddaa :=range _fbcb .MergedCells (){_egdf :=_ccce (_gddaa .Reference (),_cdab ,true );if _egdf !=""{_gddaa .SetReference (_egdf );_dbgb =append (_dbgb ,_gddaa .X ());};};_fbcb ._bbbe .MergeCells .MergeCell =_dbgb ;
-return nil ;};
+DVCompareTypeDate =DVCompareType (_ca .ST_DataValidationTypeDate );DVCompareTypeTime =DVCompareType (_ca .ST_DataValidationTypeTime );DVompareTypeTextLength =DVCompareType (_ca .ST_DataValidationTypeTextLength ););
 
 // Extents returns the sheet extents in the form "A1:B15". This requires
 // scanning the entire sheet.
@@ -700,8 +695,10 @@ func (_aefa WorkbookProtection )SetPasswordHash (pwHash string ){_aefa ._fbbb .W
 // in the sheet. As unioffice formula support is still new and not all functins are
 // supported, if formula execution fails either due to a parse error or missing
 // function, or erorr in the result (even if expected) the cached value will be
-// left empty allowing Excel to recompute it on load.
-func (_dadfb *Workbook )RecalculateFormulas (){for _ ,_daag :=range _dadfb .Sheets (){_daag .RecalculateFormulas ();};};
+// left empty allowing Excel to recompute it on load. Formulas are evaluated in
+// dependency order across sheets, see Recalculate to only recalculate the
+// formulas affected by changes.
+func (_dadfb *Workbook )RecalculateFormulas (){_dadfb .calc ().recalculate (true );};
 
 // RemoveFont removes a font from the style sheet.  It *does not* update styles that refer
 // to this font.
@@ -713,8 +710,8 @@ func (_ababf StyleSheet )RemoveFont (f Font )error {for _bgfb ,_fcec :=range _ab
 // supported,  if formula execution fails either due to a parse error or missing
 // function, or erorr in the result (even if expected) the cached value will be
 // left empty allowing Excel to recompute it on load.
-func (_geddd *Sheet )RecalculateFormulas (){_bcbag :=_bcc .NewEvaluator ();_ccca :=_geddd .FormulaContext ();for _ ,_abgc :=range _geddd .Rows (){for _ ,_eegd :=range _abgc .Cells (){if _eegd .X ().F !=nil {_ffgd :=_eegd .X ().F .Content ;if _eegd .X ().F .TAttr ==_ca .ST_CellFormulaTypeShared &&len (_ffgd )==0{continue ;
-};_dcgf :=_bcbag .Eval (_ccca ,_ffgd ).AsString ();if _dcgf .Type ==_bcc .ResultTypeError {_ef .Log .Debug ("\u0065\u0072\u0072o\u0072\u0020\u0065\u0076a\u0075\u006c\u0061\u0074\u0069\u006e\u0067 \u0066\u006f\u0072\u006d\u0075\u006c\u0061\u0020\u0025\u0073\u003a\u0020\u0025\u0073",_ffgd ,_dcgf .ErrorMessage );
+func (_geddd *Sheet )RecalculateFormulas (){_bcbag :=_bcc .NewEvaluator ();_ccca :=_bddb (_geddd );for _ ,_abgc :=range _geddd .Rows (){for _ ,_eegd :=range _abgc .Cells (){if _eegd .X ().F !=nil {_ffgd :=_eegd .X ().F .Content ;if _eegd .X ().F .TAttr ==_ca .ST_CellFormulaTypeShared &&len (_ffgd )==0{continue ;
+};_ccca ._gfcbd =_eegd .Reference ();if _geddd ._fgeg .isDynamicArray (_eegd .X ()){_geddd .spill (_eegd .X (),_bcbag .Eval (_ccca ,_ffgd ),nil );continue ;};_dcgf :=_bcbag .Eval (_ccca ,_ffgd ).AsString ();if _dcgf .Type ==_bcc .ResultTypeError {_ef .Log .Debug ("\u0065\u0072\u0072o\u0072\u0020\u0065\u0076a\u0075\u006c\u0061\u0074\u0069\u006e\u0067 \u0066\u006f\u0072\u006d\u0075\u006c\u0061\u0020\u0025\u0073\u003a\u0020\u0025\u0073",_ffgd ,_dcgf .ErrorMessage );
 _eegd .X ().V =nil ;}else {if _dcgf .Type ==_bcc .ResultTypeNumber {_eegd .X ().TAttr =_ca .ST_CellTypeN ;}else {_eegd .X ().TAttr =_ca .ST_CellTypeInlineStr ;};_eegd .X ().V =_d .String (_dcgf .Value ());if _eegd .X ().F .TAttr ==_ca .ST_CellFormulaTypeArray {if _dcgf .Type ==_bcc .ResultTypeArray {_geddd .setArray (_eegd .Reference (),_dcgf );
 }else if _dcgf .Type ==_bcc .ResultTypeList {_geddd .setList (_eegd .Reference (),_dcgf );};}else if _eegd .X ().F .TAttr ==_ca .ST_CellFormulaTypeShared &&_eegd .X ().F .RefAttr !=nil {_dddag ,_cfee ,_cadg :=_ed .ParseRangeReference (*_eegd .X ().F .RefAttr );
 if _cadg !=nil {_ef .Log .Debug ("\u0065\u0072r\u006f\u0072\u0020\u0069n\u0020\u0073h\u0061\u0072\u0065\u0064\u0020\u0066\u006f\u0072m\u0075\u006c\u0061\u0020\u0072\u0065\u0066\u0065\u0072\u0065\u006e\u0063e\u003a\u0020\u0025\u0073",_cadg );continue ;};
@@ -806,8 +803,7 @@ _acgbb ._gccd .NumFmts .CountAttr =_d .Uint32 (uint32 (len (_acgbb ._gccd .NumFm
 // display as a number. SetDateWithStyle should normally be used instead.
 func (_aaa Cell )SetDate (d _cd .Time ){_aaa .clearValue ();d =_fba (d );_aad :=_aaa ._bgg .Epoch ();if d .Before (_aad ){_ef .Log .Debug ("d\u0061\u0074\u0065\u0073\u0020\u0062e\u0066\u006f\u0072\u0065\u0020\u00319\u0030\u0030\u0020\u0061\u0072\u0065\u0020n\u006f\u0074\u0020\u0073\u0075\u0070\u0070\u006f\u0072\u0074e\u0064");
 return ;};_gda :=d .Sub (_aad );_cebb :=new (_e .Float );_adb :=new (_e .Float );_adb .SetPrec (128);_adb .SetUint64 (uint64 (_gda ));_efd :=new (_e .Float );_efd .SetUint64 (24*60*60*1e9);_cebb .Quo (_adb ,_efd );_gde ,_ :=_cebb .Uint64 ();_aaa ._dga .V =_d .Stringf ("\u0025\u0064",_gde );
-};type DifferentialStyle struct{_gace *_ca .CT_Dxf ;_bea *Workbook ;_gbc *_ca .CT_Dxfs ;};func (_feecd *Sheet )slideCellsLeft (_cdae []*_ca .CT_Cell )[]*_ca .CT_Cell {for _ ,_baef :=range _cdae {_cdbb ,_dbdd :=_ed .ParseCellReference (*_baef .RAttr );if _dbdd !=nil {return _cdae ;
-};_eacc :=_cdbb .ColumnIdx -1;_agcbe :=_ed .IndexToColumn (_eacc )+_ag .Sprintf ("\u0025\u0064",_cdbb .RowIdx );_baef .RAttr =&_agcbe ;};return _cdae ;};
+};type DifferentialStyle struct{_gace *_ca .CT_Dxf ;_bea *Workbook ;_gbc *_ca .CT_Dxfs ;};
 
 // Validate validates the sheet, returning an error if it is found to be invalid.
 func (_egg Sheet )Validate ()error {_cddd :=[]func ()error {_egg .validateRowCellNumbers ,_egg .validateMergedCells ,_egg .validateSheetNames };for _ ,_gcfeb :=range _cddd {if _ecad :=_gcfeb ();_ecad !=nil {return _ecad ;};};if _dbda :=_egg ._bbbe .Validate ();
@@ -1055,7 +1051,7 @@ _aada ._gbadf .DefinedNames .DefinedName =_aada ._gbadf .DefinedNames .DefinedNa
 
 // Workbook is the top level container item for a set of spreadsheets.
 type Workbook struct{_bfe .DocBase ;_gbadf *_ca .Workbook ;StyleSheet StyleSheet ;SharedStrings SharedStrings ;_edca []*_ca .Comments ;_fbef []*_ca .Worksheet ;_aedf []_bfe .Relationships ;_bcg _bfe .Relationships ;_bgbc []*_da .Theme ;_ecgc []*_cdg .WsDr ;
-_fcdfa []_bfe .Relationships ;_adbg []*_ce .Container ;_faebe []*_ge .ChartSpace ;_eeegg []*_ca .Table ;_dgc string ;_eagg map[string ]string ;_ffaff map[string ]*_ge .ChartSpace ;_agde string ;};
+_fcdfa []_bfe .Relationships ;_adbg []*_ce .Container ;_faebe []*_ge .ChartSpace ;_eeegg []*_ca .Table ;pivotCaches []*pivotCachePart ;pivotTables []*pivotTablePart ;_fdcbg *calcEngine ;_dfcga *_ca .Metadata ;_dgc string ;_eagg map[string ]string ;_ffaff map[string ]*_ge .ChartSpace ;_agde string ;};
 
 // AddDataValidation adds a data validation rule to a sheet.
 func (_eecd *Sheet )AddDataValidation ()DataValidation {if _eecd ._bbbe .DataValidations ==nil {_eecd ._bbbe .DataValidations =_ca .NewCT_DataValidations ();};_ggce :=_ca .NewCT_DataValidation ();_ggce .ShowErrorMessageAttr =_d .Bool (true );_eecd ._bbbe .DataValidations .DataValidation =append (_eecd ._bbbe .DataValidations .DataValidation ,_ggce );
@@ -1072,12 +1068,7 @@ type Comparer struct{Order SortOrder ;};
 func (_bbgcb Row )SetHeightAuto (){_bbgcb ._dgaf .HtAttr =nil ;_bbgcb ._dgaf .CustomHeightAttr =nil };
 
 // Name returns the name of the defined name.
-func (_gdg DefinedName )Name ()string {return _gdg ._agac .NameAttr };func (_gcfdf *Sheet )getAllCellsInFormulaArrays (_bgfa bool )(map[string ]bool ,error ){_bddg :=_bcc .NewEvaluator ();_gdbe :=_gcfdf .FormulaContext ();_cafe :=map[string ]bool {};for _ ,_gdfdc :=range _gcfdf .Rows (){for _ ,_efgfb :=range _gdfdc .Cells (){if _efgfb .X ().F !=nil {_aaff :=_efgfb .X ().F .Content ;
-if _efgfb .X ().F .TAttr ==_ca .ST_CellFormulaTypeArray {_dafg :=_bddg .Eval (_gdbe ,_aaff ).AsString ();if _dafg .Type ==_bcc .ResultTypeError {_ef .Log .Debug ("\u0065\u0072\u0072o\u0072\u0020\u0065\u0076a\u0075\u006c\u0061\u0074\u0069\u006e\u0067 \u0066\u006f\u0072\u006d\u0075\u006c\u0061\u0020\u0025\u0073\u003a\u0020\u0025\u0073",_aaff ,_dafg .ErrorMessage );
-_efgfb .X ().V =nil ;};if _dafg .Type ==_bcc .ResultTypeArray {_efcg ,_dffb :=_ed .ParseCellReference (_efgfb .Reference ());if _dffb !=nil {return map[string ]bool {},_dffb ;};if (_bgfa &&len (_dafg .ValueArray )==1)||(!_bgfa &&len (_dafg .ValueArray [0])==1){continue ;
-};for _fddd ,_efba :=range _dafg .ValueArray {_abga :=_efcg .RowIdx +uint32 (_fddd );for _aeeg :=range _efba {_bdgc :=_ed .IndexToColumn (_efcg .ColumnIdx +uint32 (_aeeg ));_cafe [_ag .Sprintf ("\u0025\u0073\u0025\u0064",_bdgc ,_abga )]=true ;};};}else if _dafg .Type ==_bcc .ResultTypeList {_ceagd ,_cfedf :=_ed .ParseCellReference (_efgfb .Reference ());
-if _cfedf !=nil {return map[string ]bool {},_cfedf ;};if _bgfa ||len (_dafg .ValueList )==1{continue ;};_acgg :=_ceagd .RowIdx ;for _eedac :=range _dafg .ValueList {_bebg :=_ed .IndexToColumn (_ceagd .ColumnIdx +uint32 (_eedac ));_cafe [_ag .Sprintf ("\u0025\u0073\u0025\u0064",_bebg ,_acgg )]=true ;
-};};};};};};return _cafe ,nil ;};func (_ceff Sheet )validateMergedCells ()error {_gdgb :=map[uint64 ]struct{}{};for _ ,_aage :=range _ceff .MergedCells (){_eggb ,_gagc ,_acff :=_ed .ParseRangeReference (_aage .Reference ());if _acff !=nil {return _ag .Errorf ("\u0073\u0068e\u0065\u0074\u0020\u006e\u0061m\u0065\u0020\u0027\u0025\u0073'\u0020\u0068\u0061\u0073\u0020\u0069\u006e\u0076\u0061\u006c\u0069\u0064\u0020\u006d\u0065\u0072\u0067\u0065\u0064\u0020\u0063\u0065\u006c\u006c\u0020\u0072\u0065\u0066\u0065\u0072\u0065\u006e\u0063\u0065\u0020\u0025\u0073",_ceff .Name (),_aage .Reference ());
+func (_gdg DefinedName )Name ()string {return _gdg ._agac .NameAttr };func (_ceff Sheet )validateMergedCells ()error {_gdgb :=map[uint64 ]struct{}{};for _ ,_aage :=range _ceff .MergedCells (){_eggb ,_gagc ,_acff :=_ed .ParseRangeReference (_aage .Reference ());if _acff !=nil {return _ag .Errorf ("\u0073\u0068e\u0065\u0074\u0020\u006e\u0061m\u0065\u0020\u0027\u0025\u0073'\u0020\u0068\u0061\u0073\u0020\u0069\u006e\u0076\u0061\u006c\u0069\u0064\u0020\u006d\u0065\u0072\u0067\u0065\u0064\u0020\u0063\u0065\u006c\u006c\u0020\u0072\u0065\u0066\u0065\u0072\u0065\u006e\u0063\u0065\u0020\u0025\u0073",_ceff .Name (),_aage .Reference ());
 };for _cacde :=_eggb .RowIdx ;_cacde <=_gagc .RowIdx ;_cacde ++{for _gaba :=_eggb .ColumnIdx ;_gaba <=_gagc .ColumnIdx ;_gaba ++{_ffgc :=uint64 (_cacde )<<32|uint64 (_gaba );if _ ,_gdacd :=_gdgb [_ffgc ];_gdacd {return _ag .Errorf ("\u0073\u0068\u0065\u0065\u0074\u0020n\u0061\u006d\u0065\u0020\u0027\u0025\u0073\u0027\u0020\u0068\u0061\u0073\u0020\u006f\u0076\u0065\u0072\u006c\u0061\u0070p\u0069\u006e\u0067\u0020\u006d\u0065\u0072\u0067\u0065\u0064\u0020\u0063\u0065\u006cl\u0020r\u0061\u006e\u0067\u0065",_ceff .Name ());
 };_gdgb [_ffgc ]=struct{}{};};};};return nil ;};
 
@@ -1123,10 +1114,7 @@ func (_bgdff *Workbook )AddDefinedName (name ,ref string )DefinedName {if _bgdff
 return DefinedName {_eefa };};
 
 // RemoveColumn removes column from the sheet and moves all columns to the right of the removed column one step left.
-func (_afae *Sheet )RemoveColumn (column string )error {_aggef ,_bdda :=_afae .getAllCellsInFormulaArraysForColumn ();if _bdda !=nil {return _bdda ;};_dedef :=_ed .ColumnToIndex (column );for _ ,_cdcg :=range _afae .Rows (){_aabc :=_ag .Sprintf ("\u0025\u0073\u0025\u0064",column ,*_cdcg .X ().RAttr );
-if _ ,_dgged :=_aggef [_aabc ];_dgged {return nil ;};};for _ ,_bdge :=range _afae .Rows (){_bagge :=_bdge ._dgaf .C ;for _feca ,_gefgd :=range _bagge {_bafa ,_fccdd :=_ed .ParseCellReference (*_gefgd .RAttr );if _fccdd !=nil {return _fccdd ;};if _bafa .ColumnIdx ==_dedef {_bdge ._dgaf .C =append (_bagge [:_feca ],_afae .slideCellsLeft (_bagge [_feca +1:])...);
-break ;}else if _bafa .ColumnIdx > _dedef {_bdge ._dgaf .C =append (_bagge [:_feca ],_afae .slideCellsLeft (_bagge [_feca :])...);break ;};};};_bdda =_afae .updateAfterRemove (_dedef ,_ee .UpdateActionRemoveColumn );if _bdda !=nil {return _bdda ;};_bdda =_afae .removeColumnFromNamedRanges (_dedef );
-if _bdda !=nil {return _bdda ;};_bdda =_afae .removeColumnFromMergedCells (_dedef );if _bdda !=nil {return _bdda ;};for _ ,_bbdgg :=range _afae ._fgeg .Sheets (){_bbdgg .RecalculateFormulas ();};return nil ;};func (_ffgb *Workbook )onNewRelationship (_fgfg *_fg .DecodeMap ,_ccfe ,_cdffb string ,_fdbd []*_cc .File ,_gebg *_gcc .Relationship ,_fgbag _fg .Target )error {_dbgbc :=_d .DocTypeSpreadsheet ;
+func (_afae *Sheet )RemoveColumn (column string )error {return _afae .DeleteColumns (column ,1);};func (_ffgb *Workbook )onNewRelationship (_fgfg *_fg .DecodeMap ,_ccfe ,_cdffb string ,_fdbd []*_cc .File ,_gebg *_gcc .Relationship ,_fgbag _fg .Target )error {_dbgbc :=_d .DocTypeSpreadsheet ;
 switch _cdffb {case _d .OfficeDocumentType :_ffgb ._gbadf =_ca .NewWorkbook ();_fgfg .AddTarget (_ccfe ,_ffgb ._gbadf ,_cdffb ,0);_ffgb ._bcg =_bfe .NewRelationships ();_fgfg .AddTarget (_fg .RelationsPathFor (_ccfe ),_ffgb ._bcg .X (),_cdffb ,0);_gebg .TargetAttr =_d .RelativeFilename (_dbgbc ,_fgbag .Typ ,_cdffb ,0);
 case _d .CorePropertiesType :_fgfg .AddTarget (_ccfe ,_ffgb .CoreProperties .X (),_cdffb ,0);_gebg .TargetAttr =_d .RelativeFilename (_dbgbc ,_fgbag .Typ ,_cdffb ,0);case _d .CustomPropertiesType :_fgfg .AddTarget (_ccfe ,_ffgb .CustomProperties .X (),_cdffb ,0);
 _gebg .TargetAttr =_d .RelativeFilename (_dbgbc ,_fgbag .Typ ,_cdffb ,0);case _d .ExtendedPropertiesType :_fgfg .AddTarget (_ccfe ,_ffgb .AppProperties .X (),_cdffb ,0);_gebg .TargetAttr =_d .RelativeFilename (_dbgbc ,_fgbag .Typ ,_cdffb ,0);case _d .WorksheetType :_beac :=_ca .NewWorksheet ();
@@ -1143,7 +1131,7 @@ _fdbd [_ecega ]=nil ;};};_gebg .TargetAttr =_eefed ;case _d .DrawingType :_eedag
 _fgfg .AddTarget (_fg .RelationsPathFor (_ccfe ),_dced .X (),_cdffb ,_becdc );_ffgb ._fcdfa =append (_ffgb ._fcdfa ,_dced );_gebg .TargetAttr =_d .RelativeFilename (_dbgbc ,_fgbag .Typ ,_cdffb ,len (_ffgb ._ecgc ));case _d .VMLDrawingType :_cgebd :=_ce .NewContainer ();
 _eaggc :=uint32 (len (_ffgb ._adbg ));_fgfg .AddTarget (_ccfe ,_cgebd ,_cdffb ,_eaggc );_ffgb ._adbg =append (_ffgb ._adbg ,_cgebd );case _d .CommentsType :_ffgb ._edca [_fgbag .Index ]=_ca .NewComments ();_fgfg .AddTarget (_ccfe ,_ffgb ._edca [_fgbag .Index ],_cdffb ,_fgbag .Index );
 _gebg .TargetAttr =_d .RelativeFilename (_dbgbc ,_fgbag .Typ ,_cdffb ,len (_ffgb ._edca ));case _d .ChartType :_ebdca :=_ge .NewChartSpace ();_fdaf :=uint32 (len (_ffgb ._faebe ));_fgfg .AddTarget (_ccfe ,_ebdca ,_cdffb ,_fdaf );_ffgb ._faebe =append (_ffgb ._faebe ,_ebdca );
-_gebg .TargetAttr =_d .RelativeFilename (_dbgbc ,_fgbag .Typ ,_cdffb ,len (_ffgb ._faebe ));if _ffgb ._ffaff ==nil {_ffgb ._ffaff =make (map[string ]*_ge .ChartSpace );};_ffgb ._ffaff [_gebg .TargetAttr ]=_ebdca ;case _d .TableType :_efeg :=_ca .NewTable ();
+_gebg .TargetAttr =_d .RelativeFilename (_dbgbc ,_fgbag .Typ ,_cdffb ,len (_ffgb ._faebe ));if _ffgb ._ffaff ==nil {_ffgb ._ffaff =make (map[string ]*_ge .ChartSpace );};_ffgb ._ffaff [_gebg .TargetAttr ]=_ebdca ;case _d .PivotCacheDefinitionType ,_d .PivotCacheRecordsType ,_d .PivotTableType :_ffgb .readPivotPart (_fgfg ,_ccfe ,_cdffb ,_gebg ,_fgbag );case _d .SheetMetadataType :_ffgb .readMetadataPart (_fgfg ,_ccfe ,_cdffb ,_gebg ,_fgbag );case _d .TableType :_efeg :=_ca .NewTable ();
 _dgea :=uint32 (len (_ffgb ._eeegg ));_fgfg .AddTarget (_ccfe ,_efeg ,_cdffb ,_dgea );_ffgb ._eeegg =append (_ffgb ._eeegg ,_efeg );_gebg .TargetAttr =_d .RelativeFilename (_dbgbc ,_fgbag .Typ ,_cdffb ,len (_ffgb ._eeegg ));default:_ef .Log .Debug ("\u0075\u006e\u0073\u0075\u0070\u0070\u006f\u0072\u0074\u0065d\u0020\u0072\u0065\u006c\u0061\u0074\u0069o\u006e\u0073\u0068\u0069\u0070\u0020\u0025\u0073\u0020\u0025\u0073",_ccfe ,_cdffb );
 };return nil ;};
 
@@ -1208,11 +1196,7 @@ SetRowOffset (_gcf _ab .Distance );
 Type ()AnchorType ;};
 
 // AddFormatValue adds a format value to be used in determining which icons to display.
-func (_ebfb IconScale )AddFormatValue (t _ca .ST_CfvoType ,val string ){_aefg :=_ca .NewCT_Cfvo ();_aefg .TypeAttr =t ;_aefg .ValAttr =_d .String (val );_ebfb ._gadf .Cfvo =append (_ebfb ._gadf .Cfvo ,_aefg );};func _ccce (_eadd string ,_fagd uint32 ,_bcefb bool )string {_fdc ,_bacg ,_gebcaa :=_ed .ParseRangeReference (_eadd );
-if _gebcaa ==nil {_fadc ,_bffc :=_fdc .ColumnIdx ,_bacg .ColumnIdx ;if _fagd >=_fadc &&_fagd <=_bffc {if _fadc ==_bffc {if _bcefb {return "";}else {return _eadd ;};}else {_fecdg :=_bacg .Update (_ee .UpdateActionRemoveColumn );return _ag .Sprintf ("\u0025\u0073\u003a%\u0073",_fdc .String (),_fecdg .String ());
-};}else if _fagd < _fadc {_badg :=_fdc .Update (_ee .UpdateActionRemoveColumn );_agga :=_bacg .Update (_ee .UpdateActionRemoveColumn );return _ag .Sprintf ("\u0025\u0073\u003a%\u0073",_badg .String (),_agga .String ());};}else {_cggc ,_caf ,_cffb :=_ed .ParseColumnRangeReference (_eadd );
-if _cffb !=nil {return "";};_dead ,_deaf :=_cggc .ColumnIdx ,_caf .ColumnIdx ;if _fagd >=_dead &&_fagd <=_deaf {if _dead ==_deaf {if _bcefb {return "";}else {return _eadd ;};}else {_edbc :=_caf .Update (_ee .UpdateActionRemoveColumn );return _ag .Sprintf ("\u0025\u0073\u003a%\u0073",_cggc .String (),_edbc .String ());
-};}else if _fagd < _dead {_eeeg :=_cggc .Update (_ee .UpdateActionRemoveColumn );_cfga :=_caf .Update (_ee .UpdateActionRemoveColumn );return _ag .Sprintf ("\u0025\u0073\u003a%\u0073",_eeeg .String (),_cfga .String ());};};return "";};
+func (_ebfb IconScale )AddFormatValue (t _ca .ST_CfvoType ,val string ){_aefg :=_ca .NewCT_Cfvo ();_aefg .TypeAttr =t ;_aefg .ValAttr =_d .String (val );_ebfb ._gadf .Cfvo =append (_ebfb ._gadf .Cfvo ,_aefg );};
 
 // SetState sets the sheet view state (frozen/split/frozen-split)
 func (_faa SheetView )SetState (st _ca .ST_PaneState ){_faa .ensurePane ();_faa ._agec .Pane .StateAttr =st ;};
@@ -1229,17 +1213,13 @@ return _bfcc ;};
 func (_cdd CellStyle )Wrapped ()bool {if _cdd ._faf .Alignment ==nil {return false ;};if _cdd ._faf .Alignment .WrapTextAttr ==nil {return false ;};return *_cdd ._faf .Alignment .WrapTextAttr ;};
 
 // TopLeft returns the CellMaker for the top left corner of the anchor.
-func (_daegc TwoCellAnchor )TopLeft ()CellMarker {return CellMarker {_daegc ._gegg .From }};func (_abgf *Sheet )removeColumnFromNamedRanges (_ebga uint32 )error {for _ ,_daab :=range _abgf ._fgeg .DefinedNames (){_cfdc :=_daab .Name ();_fabd :=_daab .Content ();
-_ggdf :=_dd .Split (_fabd ,"\u0021");if len (_ggdf )!=2{return _gb .New ("\u0049\u006e\u0063\u006frr\u0065\u0063\u0074\u0020\u006e\u0061\u006d\u0065\u0064\u0020\u0072\u0061\u006e\u0067e\u003a"+_fabd );};_bfcb :=_ggdf [0];if _abgf .Name ()==_bfcb {_gcbg :=_abgf ._fgeg .RemoveDefinedName (_daab );
-if _gcbg !=nil {return _gcbg ;};_eagaf :=_ccce (_ggdf [1],_ebga ,true );if _eagaf !=""{_eafed :=_bfcb +"\u0021"+_eagaf ;_abgf ._fgeg .AddDefinedName (_cfdc ,_eafed );};};};_cdcb :=0;if _abgf ._bbbe .TableParts !=nil &&_abgf ._bbbe .TableParts .TablePart !=nil {_cdcb =len (_abgf ._bbbe .TableParts .TablePart );
-};if _cdcb !=0{_dbdb :=0;for _ ,_dbbd :=range _abgf ._fgeg .Sheets (){if _dbbd .Name ()==_abgf .Name (){break ;}else {if _dbbd ._bbbe .TableParts !=nil &&_dbbd ._bbbe .TableParts .TablePart !=nil {_dbdb +=len (_dbbd ._bbbe .TableParts .TablePart );};};
-};_gdebg :=_abgf ._fgeg ._eeegg [_dbdb :_dbdb +_cdcb ];for _gcbb ,_faga :=range _gdebg {_gacc :=_faga ;_gacc .RefAttr =_ccce (_gacc .RefAttr ,_ebga ,false );_abgf ._fgeg ._eeegg [_dbdb +_gcbb ]=_gacc ;};};return nil ;};
+func (_daegc TwoCellAnchor )TopLeft ()CellMarker {return CellMarker {_daegc ._gegg .From }};
 
 // SetWidthCells is a no-op.
 func (_cgdb OneCellAnchor )SetWidthCells (int32 ){};
 
 // GetLabelPrefix returns label prefix which depends on the cell's horizontal alignment.
-func (_adac *evalContext )GetLabelPrefix (cellRef string )string {return _adac ._daa .Cell (cellRef ).getLabelPrefix ();};
+func (_adac *evalContext )GetLabelPrefix (cellRef string )string {return _adac .cell (cellRef ).getLabelPrefix ();};
 
 // Comments returns the comments for a sheet.
 func (_gfdc *Sheet )Comments ()Comments {for _gefg ,_daca :=range _gfdc ._fgeg ._fbef {if _daca ==_gfdc ._bbbe {if _gfdc ._fgeg ._edca [_gefg ]==nil {_gfdc ._fgeg ._edca [_gefg ]=_ca .NewComments ();_gfdc ._fgeg ._aedf [_gefg ].AddAutoRelationship (_d .DocTypeSpreadsheet ,_d .WorksheetType ,_gefg +1,_d .CommentsType );
@@ -1356,9 +1336,9 @@ func (_dab Column )SetStyle (cs CellStyle ){_dab ._ceba .StyleAttr =_d .Uint32 (
 // SetColOffset sets the column offset of the top-left of the image in fixed units.
 func (_db AbsoluteAnchor )SetColOffset (m _ab .Distance ){_db ._be .Pos .XAttr .ST_CoordinateUnqualified =_d .Int64 (int64 (m /_ab .EMU ));};func (_cfcd *evalContext )Cell (ref string ,ev _bcc .Evaluator )_bcc .Result {if !_dcb (ref ){return _bcc .MakeErrorResultType (_bcc .ErrorTypeName ,"");
 };_afdg :=_cfcd ._daa .Name ()+"\u0021"+ref ;if _bec ,_ffb :=ev .GetFromCache (_afdg );_ffb {return _bec ;};_ebbd ,_dfeb :=_ed .ParseCellReference (ref );if _dfeb !=nil {return _bcc .MakeErrorResult (_ag .Sprintf ("e\u0072r\u006f\u0072\u0020\u0070\u0061\u0072\u0073\u0069n\u0067\u0020\u0025\u0073: \u0025\u0073",ref ,_dfeb ));
-};if _cfcd ._abdg !=0&&!_ebbd .AbsoluteColumn {_ebbd .ColumnIdx +=_cfcd ._abdg ;_ebbd .Column =_ed .IndexToColumn (_ebbd .ColumnIdx );};if _cfcd ._bgba !=0&&!_ebbd .AbsoluteRow {_ebbd .RowIdx +=_cfcd ._bgba ;};_dba :=_cfcd ._daa .Cell (_ebbd .String ());
+};if _cfcd ._abdg !=0&&!_ebbd .AbsoluteColumn {_ebbd .ColumnIdx +=_cfcd ._abdg ;_ebbd .Column =_ed .IndexToColumn (_ebbd .ColumnIdx );};if _cfcd ._bgba !=0&&!_ebbd .AbsoluteRow {_ebbd .RowIdx +=_cfcd ._bgba ;};_dba :=_cfcd .cell (_ebbd .String ());if _fbcga ,_dgbfe :=_cfcd .calculated (_dba );_dgbfe {return _fbcga ;};
 if _dba .HasFormula (){if _ ,_gdbf :=_cfcd ._fea [ref ];_gdbf {return _bcc .MakeErrorResult ("r\u0065\u0063\u0075\u0072\u0073\u0069\u006f\u006e\u0020\u0064\u0065\u0074\u0065\u0063\u0074\u0065\u0064\u0020d\u0075\u0072\u0069\u006e\u0067\u0020\u0065\u0076\u0061\u006cua\u0074\u0069\u006fn\u0020o\u0066\u0020"+ref );
-};_cfcd ._fea [ref ]=struct{}{};_caab :=ev .Eval (_cfcd ,_dba .GetFormula ());delete (_cfcd ._fea ,ref );ev .SetCache (_afdg ,_caab );return _caab ;};if _dba .IsEmpty (){_eef :=_bcc .MakeEmptyResult ();ev .SetCache (_afdg ,_eef );return _eef ;}else if _dba .IsNumber (){_bfg ,_ :=_dba .GetValueAsNumber ();
+};_cfcd ._fea [ref ]=struct{}{};_fegbd :=_cfcd ._gfcbd ;_cfcd ._gfcbd =_ebbd .String ();_caab :=ev .Eval (_cfcd ,_dba .GetFormula ());if _dba .IsDynamicArray (){_caab =_dba .spilledValue (_caab );};_cfcd ._gfcbd =_fegbd ;delete (_cfcd ._fea ,ref );ev .SetCache (_afdg ,_caab );return _caab ;};if _dba .IsEmpty (){_eef :=_bcc .MakeEmptyResult ();ev .SetCache (_afdg ,_eef );return _eef ;}else if _dba .IsNumber (){_bfg ,_ :=_dba .GetValueAsNumber ();
 _feed :=_bcc .MakeNumberResult (_bfg );ev .SetCache (_afdg ,_feed );return _feed ;}else if _dba .IsBool (){_dbag ,_ :=_dba .GetValueAsBool ();_cddf :=_bcc .MakeBoolResult (_dbag );ev .SetCache (_afdg ,_cddf );return _cddf ;};_acge ,_ :=_dba .GetRawValue ();
 if _dba .IsError (){_dad :=_bcc .MakeErrorResult ("");_dad .ValueString =_acge ;ev .SetCache (_afdg ,_dad );return _dad ;};_cedc :=_bcc .MakeStringResult (_acge );ev .SetCache (_afdg ,_cedc );return _cedc ;};
 
@@ -1383,7 +1363,7 @@ func (_egbf *Workbook )RemoveSheetByName (name string )error {_adaea :=-1;for _b
 func (_bfb Cell )AddHyperlink (url string ){for _ggg ,_dcd :=range _bfb ._bgg ._fbef {if _dcd ==_bfb ._cee ._bbbe {_bfb .SetHyperlink (_bfb ._bgg ._aedf [_ggg ].AddHyperlink (url ));return ;};};};
 
 // GetChartByTargetId returns the array of workbook crt.ChartSpace.
-func (_fcbd *Workbook )GetChartByTargetId (targetAttr string )*_ge .ChartSpace {return _fcbd ._ffaff [targetAttr ];};type Table struct{_cbgb *_ca .Table };
+func (_fcbd *Workbook )GetChartByTargetId (targetAttr string )*_ge .ChartSpace {return _fcbd ._ffaff [targetAttr ];};type Table struct{_cbgb *_ca .Table ;_dbfd *Workbook ;};
 
 // IsStructureLocked returns whether the workbook structure is locked.
 func (_accb WorkbookProtection )IsStructureLocked ()bool {return _accb ._fbbb .LockStructureAttr !=nil &&*_accb ._fbbb .LockStructureAttr ;};
@@ -1427,13 +1407,13 @@ func (_efbf CellStyle )NumberFormat ()uint32 {if _efbf ._faf .NumFmtIdAttr ==nil
 };};if _baa ._dga .V ==nil {return "",nil ;};return *_baa ._dga .V ,nil ;};
 
 // Tables returns a slice of all defined tables in the workbook.
-func (_aggag *Workbook )Tables ()[]Table {if _aggag ._eeegg ==nil {return nil ;};_gega :=[]Table {};for _ ,_bbcee :=range _aggag ._eeegg {_gega =append (_gega ,Table {_bbcee });};return _gega ;};
+func (_aggag *Workbook )Tables ()[]Table {if _aggag ._eeegg ==nil {return nil ;};_gega :=[]Table {};for _ ,_bbcee :=range _aggag ._eeegg {_gega =append (_gega ,Table {_bbcee ,_aggag });};return _gega ;};
 
 // X returns the inner wrapped XML type.
 func (_afg Font )X ()*_ca .CT_Font {return _afg ._fceef };
 
 // HasFormula returns true if the cell contains formula.
-func (_add *evalContext )HasFormula (cellRef string )bool {return _add ._daa .Cell (cellRef ).HasFormula ()};
+func (_add *evalContext )HasFormula (cellRef string )bool {return _add .cell (cellRef ).HasFormula ()};
 
 // TwoCellAnchor is an anchor that is attached to a top-left cell with a fixed
 // width/height in cells.
@@ -1451,7 +1431,7 @@ func (_ebba Workbook )SheetCount ()int {return len (_ebba ._fbef )};
 // Row will return a row with a given row number, creating a new row if
 // necessary.
 func (_aacg *Sheet )Row (rowNum uint32 )Row {for _ ,_bgec :=range _aacg ._bbbe .SheetData .Row {if _bgec .RAttr !=nil &&*_bgec .RAttr ==rowNum {return Row {_aacg ._fgeg ,_aacg ,_bgec };};};return _aacg .AddNumberedRow (rowNum );};type evalContext struct{_daa *Sheet ;
-_abdg ,_bgba uint32 ;_fea map[string ]struct{};};
+_abdg ,_bgba uint32 ;_fea map[string ]struct{};_gfcbd string ;};
 
 // Cells returns a slice of cells.  The cells can be manipulated, but appending
 // to the slice will have no effect.
@@ -1641,7 +1621,7 @@ _cccbc !=nil {return _cccbc ;};};for _ebef ,_cadbe :=range _dafeb ._fbef {_cadbe
 };if _fdcd :=_fg .MarshalXMLByType (_bbdgc ,_beec ,_d .SharedStringsType ,_dafeb .SharedStrings .X ());_fdcd !=nil {return _fdcd ;};if _dafeb .CustomProperties .X ()!=nil {if _dfc :=_fg .MarshalXMLByType (_bbdgc ,_beec ,_d .CustomPropertiesType ,_dafeb .CustomProperties .X ());
 _dfc !=nil {return _dfc ;};};if _dafeb .Thumbnail !=nil {_eeecba :=_d .AbsoluteFilename (_beec ,_d .ThumbnailType ,0);_fdeg ,_cfceb :=_bbdgc .Create (_eeecba );if _cfceb !=nil {return _cfceb ;};if _dbed :=_f .Encode (_fdeg ,_dafeb .Thumbnail ,nil );_dbed !=nil {return _dbed ;
 };};for _gfae ,_fbdf :=range _dafeb ._faebe {_fdcg :=_d .AbsoluteFilename (_beec ,_d .ChartType ,_gfae +1);_fg .MarshalXML (_bbdgc ,_fdcg ,_fbdf );};for _ffee ,_beg :=range _dafeb ._eeegg {_eecc :=_d .AbsoluteFilename (_beec ,_d .TableType ,_ffee +1);_fg .MarshalXML (_bbdgc ,_eecc ,_beg );
-};for _gbag ,_aceb :=range _dafeb ._ecgc {_bddab :=_d .AbsoluteFilename (_beec ,_d .DrawingType ,_gbag +1);_fg .MarshalXML (_bbdgc ,_bddab ,_aceb );if !_dafeb ._fcdfa [_gbag ].IsEmpty (){_fg .MarshalXML (_bbdgc ,_fg .RelationsPathFor (_bddab ),_dafeb ._fcdfa [_gbag ].X ());
+};if _cadgf :=_dafeb .savePivotParts (_bbdgc );_cadgf !=nil {return _cadgf ;};if _cadgf :=_dafeb .saveMetadataPart (_bbdgc );_cadgf !=nil {return _cadgf ;};for _gbag ,_aceb :=range _dafeb ._ecgc {_bddab :=_d .AbsoluteFilename (_beec ,_d .DrawingType ,_gbag +1);_fg .MarshalXML (_bbdgc ,_bddab ,_aceb );if !_dafeb ._fcdfa [_gbag ].IsEmpty (){_fg .MarshalXML (_bbdgc ,_fg .RelationsPathFor (_bddab ),_dafeb ._fcdfa [_gbag ].X ());
 };};for _bacf ,_gefe :=range _dafeb ._adbg {_fg .MarshalXML (_bbdgc ,_d .AbsoluteFilename (_beec ,_d .VMLDrawingType ,_bacf +1),_gefe );};for _dcdc ,_ccgc :=range _dafeb .Images {if _cdbbc :=_bfe .AddImageToZip (_bbdgc ,_ccgc ,_dcdc +1,_d .DocTypeSpreadsheet );
 _cdbbc !=nil {return _cdbbc ;};};if _cccbf :=_fg .MarshalXML (_bbdgc ,_d .ContentTypesFilename ,_dafeb .ContentTypes .X ());_cccbf !=nil {return _cccbf ;};for _fage ,_dafd :=range _dafeb ._edca {if _dafd ==nil {continue ;};_fg .MarshalXML (_bbdgc ,_d .AbsoluteFilename (_beec ,_d .CommentsType ,_fage +1),_dafd );
 };if _dgfe :=_dafeb .WriteExtraFiles (_bbdgc );_dgfe !=nil {return _dgfe ;};return _bbdgc .Close ();};
@@ -0,0 +1,45 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/common/license"
+)
+
+// TestMain sets the metered license key of UNIDOC_LICENSE_API_KEY, which
+// saving and reading workbooks require.
+func TestMain(m *testing.M) {
+	if key := os.Getenv("UNIDOC_LICENSE_API_KEY"); key != "" {
+		if err := license.SetMeteredKey(key); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// roundTrip saves a workbook and reads it back.
+func roundTrip(t *testing.T, wb *Workbook) *Workbook {
+	t.Helper()
+	buf := bytes.Buffer{}
+	if err := wb.Save(&buf); err != nil {
+		t.Fatalf("saving: %s", err)
+	}
+	read, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("reading: %s", err)
+	}
+	return read
+}
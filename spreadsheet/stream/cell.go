@@ -0,0 +1,108 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package stream
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/unidoc/unioffice/v2/spreadsheet"
+)
+
+type cellKind byte
+
+const (
+	cellEmpty cellKind = iota
+	cellString
+	cellNumber
+	cellBool
+	cellFormula
+	cellTime
+)
+
+// Cell is a value written to a streamed row. Cells are created with String,
+// Number, Bool, Time, Formula or Empty and are written to consecutive columns
+// unless a column is set with At.
+type Cell struct {
+	kind   cellKind
+	str    string
+	num    float64
+	time   time.Time
+	col    uint32
+	hasCol bool
+	style  uint32
+	styled bool
+}
+
+// String returns a text cell. It is stored in the shared string table or
+// inline, depending on the writer's SetInlineStrings setting.
+func String(s string) Cell { return Cell{kind: cellString, str: s} }
+
+// Number returns a numeric cell. NaN and infinite values are written as the
+// #NUM! error, as with spreadsheet.Cell.SetNumber.
+func Number(v float64) Cell { return Cell{kind: cellNumber, num: v} }
+
+// Int returns a numeric cell holding an integer.
+func Int(v int64) Cell { return Cell{kind: cellNumber, num: float64(v)} }
+
+// Bool returns a boolean cell.
+func Bool(v bool) Cell {
+	c := Cell{kind: cellBool}
+	if v {
+		c.num = 1
+	}
+	return c
+}
+
+// Time returns a date and time cell, stored as a serial number relative to
+// the workbook epoch. The cell needs a style with a date number format to be
+// displayed as a date.
+func Time(t time.Time) Cell { return Cell{kind: cellTime, time: t} }
+
+// Formula returns a formula cell. The formula is written without a leading
+// '=' and without a cached result, so it is calculated when the workbook is
+// opened.
+func Formula(f string) Cell { return Cell{kind: cellFormula, str: f} }
+
+// Empty returns a cell without a value, useful to apply a style to a cell or
+// to skip a column.
+func Empty() Cell { return Cell{kind: cellEmpty} }
+
+// At returns a copy of the cell placed at the column with the given index
+// (1-N). Cells of a row must be placed in ascending column order.
+func (c Cell) At(col uint32) Cell {
+	c.col = col
+	c.hasCol = true
+	return c
+}
+
+// WithStyle returns a copy of the cell with a style from the writer's
+// StyleSheet applied.
+func (c Cell) WithStyle(s spreadsheet.CellStyle) Cell {
+	c.style = s.Index()
+	c.styled = true
+	return c
+}
+
+// serial returns the serial number of a date relative to epoch, keeping the
+// wall clock time of t as spreadsheet.Cell.SetTime does.
+func serial(t, epoch time.Time) float64 {
+	t = t.Local()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return float64(t.Sub(epoch)) / float64(24*time.Hour)
+}
+
+func formatNumber(v float64) (string, bool) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "#NUM!", false
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64), true
+}
@@ -0,0 +1,516 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package stream
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/common/logger"
+	"github.com/unidoc/unioffice/v2/internal/license"
+	"github.com/unidoc/unioffice/v2/schema/soo/pkg/relationships"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/spreadsheet/format"
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+	"github.com/unidoc/unioffice/v2/zippkg"
+)
+
+// ValueType is the type of a cell value read from a sheet.
+type ValueType byte
+
+// ValueType constants.
+const (
+	ValueEmpty ValueType = iota
+	ValueString
+	ValueNumber
+	ValueBool
+	// ValueDate is a number whose number format displays a date or time.
+	ValueDate
+	ValueError
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case ValueEmpty:
+		return "empty"
+	case ValueString:
+		return "string"
+	case ValueNumber:
+		return "number"
+	case ValueBool:
+		return "bool"
+	case ValueDate:
+		return "date"
+	case ValueError:
+		return "error"
+	}
+	return fmt.Sprintf("ValueType(%d)", byte(t))
+}
+
+// Value is a cell read from a sheet.
+type Value struct {
+	// Reference is the cell reference, such as "B7".
+	Reference string
+	// Column is the column index (1-N).
+	Column uint32
+	Type   ValueType
+	// Text is the text of string cells, the error code of error cells and the
+	// stored value of other cells.
+	Text string
+	// Number is the value of number and date cells, and 1 or 0 for boolean
+	// cells.
+	Number float64
+	Bool   bool
+	// Time is the value of date cells.
+	Time time.Time
+	// Formula is the formula of the cell, without a leading '='. It is empty
+	// for cells sharing the formula of another cell.
+	Formula string
+	// StyleIndex is the index of the cell style and NumberFormat the code of
+	// its number format.
+	StyleIndex   uint32
+	NumberFormat string
+}
+
+// Formatted returns the value formatted with its number format, as displayed
+// by spreadsheet.Cell.GetFormattedValue.
+func (v Value) Formatted() string {
+	switch v.Type {
+	case ValueBool:
+		if v.Bool {
+			return "TRUE"
+		}
+		return "FALSE"
+	case ValueNumber, ValueDate:
+		return format.Number(v.Number, v.NumberFormat)
+	case ValueString:
+		return format.String(v.Text, v.NumberFormat)
+	}
+	return v.Text
+}
+
+// Row is a row read from a sheet. Only cells present in the sheet are
+// included, so columns may be skipped.
+type Row struct {
+	// Number is the row number (1-N).
+	Number uint32
+	// Height is the custom row height in points, or 0 for the default height.
+	Height float64
+	Hidden bool
+	Values []Value
+}
+
+// Workbook is a workbook opened for streaming its sheets. Only the list of
+// sheets is read when opening it. Shared strings and styles are loaded on
+// first use and the rows of a sheet are decoded one at a time.
+type Workbook struct {
+	zr          *zip.Reader
+	closer      io.Closer
+	sheets      []sheetPart
+	date1904    bool
+	stylesPath  string
+	stringsPath string
+	diskStrings bool
+	styles      *styleTable
+	strings     sharedStrings
+}
+
+type sheetPart struct {
+	name string
+	path string
+}
+
+// Open opens a workbook file for streaming.
+func Open(filename string) (*Workbook, error) {
+	if err := checkLicense(filename); err != nil {
+		return nil, err
+	}
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %s", filename, err)
+	}
+	wb, err := newWorkbook(&zr.Reader)
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	wb.closer = zr
+	return wb, nil
+}
+
+// Read reads a workbook for streaming from a reader.
+func Read(ra io.ReaderAt, size int64) (*Workbook, error) {
+	name := "unknown"
+	if f, ok := ra.(*os.File); ok {
+		name = f.Name()
+	}
+	if err := checkLicense(name); err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("parsing zip: %s", err)
+	}
+	return newWorkbook(zr)
+}
+
+// checkLicense applies the license checks of spreadsheet.Read and tracks the
+// use of the workbook name.
+func checkLicense(name string) error {
+	if !license.GetLicenseKey().IsLicensed() {
+		fmt.Println("Unlicensed version of UniOffice")
+		fmt.Println("- Get a trial license on https://unidoc.io")
+		return errors.New("unioffice license required")
+	}
+	refID, err := license.GenRefId("sr")
+	if err != nil {
+		logger.Log.Error("ERROR: %v", err)
+		return err
+	}
+	if err := license.Track(refID, "spreadsheet:stream.Read", name); err != nil {
+		logger.Log.Error("ERROR: %v", err)
+		return err
+	}
+	return nil
+}
+
+func newWorkbook(zr *zip.Reader) (*Workbook, error) {
+	wb := &Workbook{zr: zr}
+	wbPath := "xl/workbook.xml"
+	if rel := wb.relationships(unioffice.BaseRelsFilename, ""); rel != nil {
+		for _, r := range rel {
+			if r.typ == unioffice.OfficeDocumentType {
+				wbPath = r.target
+				break
+			}
+		}
+	}
+	f := wb.file(wbPath)
+	if f == nil {
+		return nil, errors.New("workbook part not found")
+	}
+	x := sml.NewWorkbook()
+	if err := zippkg.Decode(f, x); err != nil {
+		return nil, err
+	}
+	if x.WorkbookPr != nil && x.WorkbookPr.Date1904Attr != nil {
+		wb.date1904 = *x.WorkbookPr.Date1904Attr
+	}
+	targets := map[string]string{}
+	for _, r := range wb.relationships(zippkg.RelationsPathFor(wbPath), path.Dir(wbPath)) {
+		switch r.typ {
+		case unioffice.WorksheetType:
+			targets[r.id] = r.target
+		case unioffice.StylesType:
+			wb.stylesPath = r.target
+		case unioffice.SharedStringsType:
+			wb.stringsPath = r.target
+		}
+	}
+	for _, s := range x.Sheets.Sheet {
+		// chart sheets and dialog sheets have other relationship types
+		if target, ok := targets[s.IdAttr]; ok {
+			wb.sheets = append(wb.sheets, sheetPart{s.NameAttr, target})
+		}
+	}
+	return wb, nil
+}
+
+func (wb *Workbook) file(name string) *zip.File {
+	for _, f := range wb.zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+type relationship struct {
+	id, typ, target string
+}
+
+// relationships returns the relationships of a relationships part, relative
+// targets being resolved against dir.
+func (wb *Workbook) relationships(relsPath, dir string) []relationship {
+	f := wb.file(relsPath)
+	if f == nil {
+		return nil
+	}
+	rels := relationships.NewRelationships()
+	if err := zippkg.Decode(f, rels); err != nil {
+		return nil
+	}
+	var res []relationship
+	for _, rel := range rels.Relationship {
+		target := path.Join(dir, rel.TargetAttr)
+		if strings.HasPrefix(rel.TargetAttr, "/") {
+			target = strings.TrimPrefix(rel.TargetAttr, "/")
+		}
+		res = append(res, relationship{rel.IdAttr, rel.TypeAttr, target})
+	}
+	return res
+}
+
+// SheetNames returns the names of the worksheets in workbook order.
+func (wb *Workbook) SheetNames() []string {
+	names := make([]string, 0, len(wb.sheets))
+	for _, s := range wb.sheets {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// Uses1904Dates returns true if the workbook dates are relative to 1904.
+func (wb *Workbook) Uses1904Dates() bool { return wb.date1904 }
+
+// Epoch returns the date serial numbers are relative to.
+func (wb *Workbook) Epoch() time.Time {
+	if wb.date1904 {
+		return time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+}
+
+// SetDiskSharedStrings controls whether the shared string table is kept in
+// temporary storage instead of memory, for workbooks whose shared strings
+// don't fit in memory. It must be set before the first sheet is streamed.
+func (wb *Workbook) SetDiskSharedStrings(b bool) { wb.diskStrings = b }
+
+// StreamSheet returns an iterator over the rows of the named sheet.
+func (wb *Workbook) StreamSheet(name string) (*RowIterator, error) {
+	for _, s := range wb.sheets {
+		if s.name != name {
+			continue
+		}
+		f := wb.file(s.path)
+		if f == nil {
+			return nil, fmt.Errorf("sheet part %s not found", s.path)
+		}
+		part, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %s", s.path, err)
+		}
+		return &RowIterator{wb: wb, part: part, dec: xml.NewDecoder(part)}, nil
+	}
+	return nil, fmt.Errorf("sheet %q not found", name)
+}
+
+// Close closes the workbook and releases the shared strings.
+func (wb *Workbook) Close() error {
+	var err error
+	if wb.strings != nil {
+		err = wb.strings.Close()
+	}
+	if wb.closer != nil {
+		if cerr := wb.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (wb *Workbook) sharedString(idx int) (string, error) {
+	if wb.strings == nil {
+		ss, err := wb.loadSharedStrings()
+		if err != nil {
+			return "", err
+		}
+		wb.strings = ss
+	}
+	return wb.strings.String(idx)
+}
+
+func (wb *Workbook) cellStyles() (*styleTable, error) {
+	if wb.styles == nil {
+		st, err := wb.loadStyles()
+		if err != nil {
+			return nil, err
+		}
+		wb.styles = st
+	}
+	return wb.styles, nil
+}
+
+// RowIterator iterates over the rows of a sheet.
+type RowIterator struct {
+	wb     *Workbook
+	part   io.ReadCloser
+	dec    *xml.Decoder
+	inData bool
+	err    error
+}
+
+// Next returns the next row of the sheet, or io.EOF after the last row. Empty
+// rows without cells or formatting are not stored in sheets and aren't
+// returned.
+func (it *RowIterator) Next() (*Row, error) {
+	if it.err != nil {
+		return nil, it.err
+	}
+	for {
+		tok, err := it.dec.Token()
+		if err == io.EOF {
+			it.err = io.EOF
+			return nil, it.err
+		} else if err != nil {
+			it.err = fmt.Errorf("reading sheet: %s", err)
+			return nil, it.err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if !it.inData {
+				it.inData = t.Name.Local == "sheetData"
+				continue
+			}
+			if t.Name.Local != "row" {
+				if err := it.dec.Skip(); err != nil {
+					it.err = err
+					return nil, err
+				}
+				continue
+			}
+			x := sml.NewCT_Row()
+			if err := it.dec.DecodeElement(x, &t); err != nil {
+				it.err = fmt.Errorf("decoding row: %s", err)
+				return nil, it.err
+			}
+			row, err := it.row(x)
+			if err != nil {
+				it.err = err
+				return nil, err
+			}
+			return row, nil
+		case xml.EndElement:
+			if it.inData && t.Name.Local == "sheetData" {
+				it.err = io.EOF
+				return nil, it.err
+			}
+		}
+	}
+}
+
+func (it *RowIterator) row(x *sml.CT_Row) (*Row, error) {
+	row := &Row{Values: make([]Value, 0, len(x.C))}
+	if x.RAttr != nil {
+		row.Number = *x.RAttr
+	}
+	if x.HtAttr != nil && x.CustomHeightAttr != nil && *x.CustomHeightAttr {
+		row.Height = *x.HtAttr
+	}
+	if x.HiddenAttr != nil {
+		row.Hidden = *x.HiddenAttr
+	}
+	col := uint32(0)
+	for _, c := range x.C {
+		if c.RAttr != nil {
+			ref, err := reference.ParseCellReference(*c.RAttr)
+			if err != nil {
+				return nil, fmt.Errorf("parsing cell reference %s: %s", *c.RAttr, err)
+			}
+			col = ref.ColumnIdx + 1
+			if row.Number == 0 {
+				row.Number = ref.RowIdx
+			}
+		} else {
+			col++
+		}
+		v, err := it.value(c)
+		if err != nil {
+			return nil, err
+		}
+		v.Column = col
+		row.Values = append(row.Values, v)
+	}
+	for i := range row.Values {
+		row.Values[i].Reference = reference.IndexToColumn(row.Values[i].Column-1) + strconv.FormatUint(uint64(row.Number), 10)
+	}
+	return row, nil
+}
+
+func (it *RowIterator) value(c *sml.CT_Cell) (Value, error) {
+	v := Value{NumberFormat: "General"}
+	if c.F != nil {
+		v.Formula = c.F.Content
+	}
+	if c.SAttr != nil {
+		v.StyleIndex = *c.SAttr
+		st, err := it.wb.cellStyles()
+		if err != nil {
+			return v, err
+		}
+		v.NumberFormat = st.numberFormat(v.StyleIndex)
+	}
+	if c.V != nil {
+		v.Text = *c.V
+	}
+	switch c.TAttr {
+	case sml.ST_CellTypeS:
+		idx, err := strconv.Atoi(v.Text)
+		if err != nil {
+			return v, fmt.Errorf("invalid shared string index %q", v.Text)
+		}
+		if v.Text, err = it.wb.sharedString(idx); err != nil {
+			return v, err
+		}
+		v.Type = ValueString
+	case sml.ST_CellTypeInlineStr:
+		if c.Is != nil {
+			v.Text = richText(c.Is)
+		}
+		v.Type = ValueString
+	case sml.ST_CellTypeStr:
+		v.Type = ValueString
+	case sml.ST_CellTypeB:
+		v.Type = ValueBool
+		v.Bool = v.Text == "1" || strings.EqualFold(v.Text, "true")
+		if v.Bool {
+			v.Number = 1
+		}
+	case sml.ST_CellTypeE:
+		v.Type = ValueError
+	default:
+		if c.V == nil {
+			break
+		}
+		n, err := strconv.ParseFloat(v.Text, 64)
+		if err != nil {
+			return v, fmt.Errorf("invalid number %q", v.Text)
+		}
+		v.Type, v.Number = ValueNumber, n
+		if c.SAttr != nil && it.wb.styles.isDate(v.StyleIndex) {
+			v.Type = ValueDate
+			v.Time = it.wb.Epoch().Add(time.Duration(n * float64(24*time.Hour))).Round(time.Millisecond)
+		}
+	}
+	return v, nil
+}
+
+// Close closes the sheet.
+func (it *RowIterator) Close() error { return it.part.Close() }
+
+func richText(rst *sml.CT_Rst) string {
+	if rst.T != nil {
+		return *rst.T
+	}
+	sb := strings.Builder{}
+	for _, r := range rst.R {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
@@ -0,0 +1,234 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/unidoc/unioffice/v2/common/license"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/spreadsheet"
+)
+
+// TestMain sets the metered license key of UNIDOC_LICENSE_API_KEY, which
+// saving and streaming workbooks require.
+func TestMain(m *testing.M) {
+	if key := os.Getenv("UNIDOC_LICENSE_API_KEY"); key != "" {
+		if err := license.SetMeteredKey(key); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+var testDate = time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC)
+
+// savedWorkbook returns a saved workbook with the sheets Data and Empty, Data
+// holding a value of each type.
+func savedWorkbook(t *testing.T, date1904 bool) []byte {
+	t.Helper()
+	wb := spreadsheet.New()
+	if date1904 {
+		wb.X().WorkbookPr = sml.NewCT_WorkbookPr()
+		wb.X().WorkbookPr.Date1904Attr = &date1904
+	}
+	s := wb.AddSheet()
+	s.SetName("Data")
+	s.Cell("A1").SetString("name")
+	s.Cell("B1").SetString("value")
+	s.Cell("A2").SetString("name")
+	s.Cell("B2").SetNumber(1.5)
+	s.Cell("D2").SetBool(true)
+	s.Cell("A4").SetInlineString("inline")
+	s.Cell("B4").SetDateWithStyle(testDate)
+	s.Cell("C4").SetError("#N/A")
+	s.Cell("D4").SetFormulaRaw("B2*2")
+	s.Cell("D4").SetCachedFormulaResult("3")
+	s.Row(4).SetHeight(30 * measurement.Point)
+	s.Row(5).SetHidden(true)
+	s.Row(5).Cell("A").SetNumber(0)
+	if date1904 {
+		// SetDateWithStyle doesn't use the 1904 epoch
+		s.Cell("B4").SetNumber(44269 - 1462)
+	}
+	empty := wb.AddSheet()
+	empty.SetName("Empty")
+	buf := bytes.Buffer{}
+	if err := wb.Save(&buf); err != nil {
+		t.Fatalf("saving: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func readWorkbook(t *testing.T, data []byte) *Workbook {
+	t.Helper()
+	wb, err := Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wb
+}
+
+// readRows returns the rows of a sheet up to io.EOF.
+func readRows(t *testing.T, wb *Workbook, sheet string) []*Row {
+	t.Helper()
+	it, err := wb.StreamSheet(sheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	rows := []*Row{}
+	for {
+		row, err := it.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	// the iterator keeps returning io.EOF
+	if _, err := it.Next(); err != io.EOF {
+		t.Errorf("Next after the last row = %v, want io.EOF", err)
+	}
+	return rows
+}
+
+func TestStreamSheet(t *testing.T) {
+	wb := readWorkbook(t, savedWorkbook(t, false))
+	defer wb.Close()
+	if got := wb.SheetNames(); !reflect.DeepEqual(got, []string{"Data", "Empty"}) {
+		t.Errorf("sheet names = %v", got)
+	}
+	rows := readRows(t, wb, "Data")
+	if len(rows) != 4 {
+		t.Fatalf("read %d rows, want 4", len(rows))
+	}
+	for i, want := range []uint32{1, 2, 4, 5} {
+		if rows[i].Number != want {
+			t.Errorf("row %d number = %d, want %d", i, rows[i].Number, want)
+		}
+	}
+	if rows[2].Height != 30 || rows[3].Hidden != true || rows[0].Height != 0 || rows[0].Hidden {
+		t.Errorf("row heights %v %v, hidden %v %v", rows[0].Height, rows[2].Height, rows[0].Hidden, rows[3].Hidden)
+	}
+
+	tests := []struct {
+		row, col int
+		ref      string
+		typ      ValueType
+		text     string
+		number   float64
+	}{
+		{0, 0, "A1", ValueString, "name", 0},
+		{0, 1, "B1", ValueString, "value", 0},
+		{1, 0, "A2", ValueString, "name", 0},
+		{1, 1, "B2", ValueNumber, "1.5", 1.5},
+		// saving adds the cells missing before D2
+		{1, 2, "C2", ValueEmpty, "", 0},
+		{1, 3, "D2", ValueBool, "1", 1},
+		{2, 0, "A4", ValueString, "inline", 0},
+		{2, 1, "B4", ValueDate, "44269", 44269},
+		{2, 2, "C4", ValueError, "#N/A", 0},
+		// cached formula results are stored as text
+		{2, 3, "D4", ValueString, "3", 0},
+	}
+	for _, tc := range tests {
+		if tc.col >= len(rows[tc.row].Values) {
+			t.Errorf("%s not read", tc.ref)
+			continue
+		}
+		v := rows[tc.row].Values[tc.col]
+		if v.Reference != tc.ref || v.Type != tc.typ || v.Text != tc.text || v.Number != tc.number {
+			t.Errorf("%s = %s %s %q %v, want %s %q %v", tc.ref, v.Reference, v.Type, v.Text, v.Number, tc.typ, tc.text, tc.number)
+		}
+	}
+	if v := rows[1].Values[3]; !v.Bool || v.Formatted() != "TRUE" || v.Column != 4 {
+		t.Errorf("D2 = %v %q column %d, want TRUE in column 4", v.Bool, v.Formatted(), v.Column)
+	}
+	if v := rows[2].Values[3]; v.Formula != "B2*2" {
+		t.Errorf("D4 formula = %q, want B2*2", v.Formula)
+	}
+	if v := rows[2].Values[1]; !v.Time.Equal(testDate) || v.Formatted() != "3/14/21" {
+		t.Errorf("B4 = %s %q, want %s", v.Time, v.Formatted(), testDate)
+	}
+
+	if rows := readRows(t, wb, "Empty"); len(rows) != 0 {
+		t.Errorf("read %d rows of an empty sheet", len(rows))
+	}
+	if _, err := wb.StreamSheet("Missing"); err == nil {
+		t.Error("streamed a missing sheet")
+	}
+}
+
+func TestStreamSheetDates(t *testing.T) {
+	wb := readWorkbook(t, savedWorkbook(t, true))
+	defer wb.Close()
+	if !wb.Uses1904Dates() || !wb.Epoch().Equal(time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("1904 dates %v, epoch %s", wb.Uses1904Dates(), wb.Epoch())
+	}
+	rows := readRows(t, wb, "Data")
+	if v := rows[2].Values[1]; v.Type != ValueDate || !v.Time.Equal(testDate) {
+		t.Errorf("B4 = %s %s, want %s", v.Type, v.Time, testDate)
+	}
+}
+
+func TestDiskSharedStrings(t *testing.T) {
+	data := savedWorkbook(t, false)
+	for _, disk := range []bool{false, true} {
+		wb := readWorkbook(t, data)
+		wb.SetDiskSharedStrings(disk)
+		rows := readRows(t, wb, "Data")
+		got := []string{rows[0].Values[0].Text, rows[0].Values[1].Text, rows[1].Values[0].Text}
+		if !reflect.DeepEqual(got, []string{"name", "value", "name"}) {
+			t.Errorf("shared strings with disk storage %v = %v", disk, got)
+		}
+		if _, err := wb.sharedString(3); err == nil {
+			t.Errorf("read a shared string out of range with disk storage %v", disk)
+		}
+		if err := wb.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.xlsx")
+	if err := os.WriteFile(filename, savedWorkbook(t, false), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wb, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows := readRows(t, wb, "Data"); len(rows) != 4 {
+		t.Errorf("read %d rows, want 4", len(rows))
+	}
+	if err := wb.Close(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.xlsx")); err == nil {
+		t.Error("opened a missing file")
+	}
+	data := []byte("not a zip file")
+	if _, err := Read(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("read a file which isn't a zip file")
+	}
+}
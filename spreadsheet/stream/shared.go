@@ -0,0 +1,195 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package stream
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/unidoc/unioffice/v2/common/tempstorage"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/spreadsheet"
+	"github.com/unidoc/unioffice/v2/spreadsheet/format"
+	"github.com/unidoc/unioffice/v2/zippkg"
+)
+
+// sharedStrings is the shared string table of a workbook being read.
+type sharedStrings interface {
+	String(idx int) (string, error)
+	Close() error
+}
+
+type memoryStrings []string
+
+func (m memoryStrings) String(idx int) (string, error) {
+	if idx < 0 || idx >= len(m) {
+		return "", fmt.Errorf("shared string index %d out of range", idx)
+	}
+	return m[idx], nil
+}
+
+func (m memoryStrings) Close() error { return nil }
+
+// diskStrings keeps the strings in temporary storage and only their offsets
+// in memory.
+type diskStrings struct {
+	dir     string
+	f       tempstorage.File
+	offsets []int64
+}
+
+func (d *diskStrings) String(idx int) (string, error) {
+	if idx < 0 || idx+1 >= len(d.offsets) {
+		return "", fmt.Errorf("shared string index %d out of range", idx)
+	}
+	buf := make([]byte, d.offsets[idx+1]-d.offsets[idx])
+	if _, err := d.f.ReadAt(buf, d.offsets[idx]); err != nil && err != io.EOF {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func (d *diskStrings) Close() error {
+	err := d.f.Close()
+	if rerr := tempstorage.RemoveAll(d.dir); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// loadSharedStrings decodes the shared string table one item at a time.
+func (wb *Workbook) loadSharedStrings() (sharedStrings, error) {
+	if wb.stringsPath == "" {
+		return memoryStrings(nil), nil
+	}
+	f := wb.file(wb.stringsPath)
+	if f == nil {
+		return memoryStrings(nil), nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %s", wb.stringsPath, err)
+	}
+	defer rc.Close()
+
+	var mem memoryStrings
+	var disk *diskStrings
+	var w *bufio.Writer
+	if wb.diskStrings {
+		dir, err := tempstorage.TempDir("unioffice-sst")
+		if err != nil {
+			return nil, err
+		}
+		tf, err := tempstorage.TempFile(dir, "sst")
+		if err != nil {
+			tempstorage.RemoveAll(dir)
+			return nil, err
+		}
+		disk = &diskStrings{dir: dir, f: tf, offsets: []int64{0}}
+		w = bufio.NewWriter(tf)
+	}
+	fail := func(err error) (sharedStrings, error) {
+		if disk != nil {
+			disk.Close()
+		}
+		return nil, err
+	}
+
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return fail(fmt.Errorf("reading shared strings: %s", err))
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "si" {
+			continue
+		}
+		rst := sml.NewCT_Rst()
+		if err := dec.DecodeElement(rst, &se); err != nil {
+			return fail(fmt.Errorf("decoding shared string: %s", err))
+		}
+		s := richText(rst)
+		if disk == nil {
+			mem = append(mem, s)
+			continue
+		}
+		n, err := w.WriteString(s)
+		if err != nil {
+			return fail(err)
+		}
+		disk.offsets = append(disk.offsets, disk.offsets[len(disk.offsets)-1]+int64(n))
+	}
+	if disk == nil {
+		return mem, nil
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	return disk, nil
+}
+
+// styleTable holds the number formats of the cell styles.
+type styleTable struct {
+	formats []string
+	dates   []bool
+}
+
+func (st *styleTable) numberFormat(idx uint32) string {
+	if st == nil || int(idx) >= len(st.formats) {
+		return "General"
+	}
+	return st.formats[idx]
+}
+
+func (st *styleTable) isDate(idx uint32) bool {
+	return st != nil && int(idx) < len(st.dates) && st.dates[idx]
+}
+
+func (wb *Workbook) loadStyles() (*styleTable, error) {
+	st := &styleTable{}
+	if wb.stylesPath == "" {
+		return st, nil
+	}
+	f := wb.file(wb.stylesPath)
+	if f == nil {
+		return st, nil
+	}
+	x := sml.NewStyleSheet()
+	if err := zippkg.Decode(f, x); err != nil {
+		return nil, err
+	}
+	custom := map[uint32]string{}
+	if x.NumFmts != nil {
+		for _, nf := range x.NumFmts.NumFmt {
+			custom[nf.NumFmtIdAttr] = nf.FormatCodeAttr
+		}
+	}
+	if x.CellXfs == nil {
+		return st, nil
+	}
+	for _, xf := range x.CellXfs.Xf {
+		id := uint32(0)
+		if xf.NumFmtIdAttr != nil {
+			id = *xf.NumFmtIdAttr
+		}
+		code, ok := custom[id]
+		if !ok {
+			code = spreadsheet.CreateDefaultNumberFormat(spreadsheet.StandardFormat(id)).GetFormat()
+		}
+		st.formats = append(st.formats, code)
+		st.dates = append(st.dates, format.IsDateFormat(id, code))
+	}
+	return st, nil
+}
@@ -0,0 +1,477 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+// Package stream writes workbooks one row at a time, without keeping the
+// rows in memory. Rows are spilled to temporary storage as sheet XML while
+// they are written and copied into the package when the writer is closed, so
+// memory use does not grow with the number of rows. Everything but the rows,
+// such as styles, column widths, panes and merged cells, is kept in an
+// ordinary spreadsheet.Workbook.
+//
+// Example:
+//
+//	w, err := stream.Create("audit.xlsx")
+//	if err != nil {
+//		return err
+//	}
+//	font := w.StyleSheet().AddFont()
+//	font.SetBold(true)
+//	bold := w.StyleSheet().AddCellStyle()
+//	bold.SetFont(font)
+//	s, err := w.AddSheet("Audit")
+//	if err != nil {
+//		return err
+//	}
+//	s.SetFrozen(1, 0)
+//	s.WriteRow(stream.String("Time").WithStyle(bold), stream.String("Event").WithStyle(bold))
+//	for _, e := range events {
+//		if err := s.WriteRow(stream.Time(e.Time), stream.String(e.Text)); err != nil {
+//			return err
+//		}
+//	}
+//	return w.Close()
+//
+// Workbooks are read the same way with Open, which only reads the list of
+// sheets, and StreamSheet, which decodes the rows of a sheet one at a time.
+// StreamSheet is a method of the Workbook of this package rather than of
+// spreadsheet.Workbook, which has decoded every sheet by the time it is read:
+//
+//	wb, err := stream.Open("vendor.xlsx")
+//	if err != nil {
+//		return err
+//	}
+//	defer wb.Close()
+//	rows, err := wb.StreamSheet("Sheet1")
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//	for {
+//		row, err := rows.Next()
+//		if err == io.EOF {
+//			break
+//		} else if err != nil {
+//			return err
+//		}
+//		for _, v := range row.Values {
+//			fmt.Println(v.Reference, v.Formatted())
+//		}
+//	}
+package stream
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/common/tempstorage"
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/spreadsheet"
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+)
+
+// Limits of a worksheet.
+const (
+	MaxRows    = 1048576
+	MaxColumns = 16384
+)
+
+var (
+	// ErrRowOrder is returned when a row is written at or before a row that
+	// was already written.
+	ErrRowOrder = errors.New("stream: rows must be written in ascending order")
+	// ErrColumnOrder is returned when the cells of a row are not in
+	// ascending column order.
+	ErrColumnOrder = errors.New("stream: cells must be in ascending column order")
+	// ErrClosed is returned when writing to a closed writer.
+	ErrClosed = errors.New("stream: writer is closed")
+)
+
+// Writer writes a workbook whose sheets are streamed row by row.
+type Writer struct {
+	wb            *spreadsheet.Workbook
+	out           io.Writer
+	file          *os.File
+	tempDir       string
+	sheets        []*Sheet
+	inlineStrings bool
+	closed        bool
+}
+
+// New returns a writer that writes the workbook to out when closed.
+func New(out io.Writer) (*Writer, error) {
+	dir, err := tempstorage.TempDir("unioffice-stream")
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{wb: spreadsheet.New(), out: out, tempDir: dir}, nil
+}
+
+// Create creates the named file and returns a writer for it.
+func Create(filename string) (*Writer, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	w, err := New(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.file = f
+	return w, nil
+}
+
+// Workbook returns the workbook holding everything but the streamed rows, to
+// set document properties, defined names and other workbook level settings.
+// Its sheets must not be given rows, as they are replaced by the streamed
+// ones.
+func (w *Writer) Workbook() *spreadsheet.Workbook { return w.wb }
+
+// StyleSheet returns the style sheet of the workbook, used to create the cell
+// styles applied with Cell.WithStyle.
+func (w *Writer) StyleSheet() spreadsheet.StyleSheet { return w.wb.StyleSheet }
+
+// SetInlineStrings controls whether text is written inline in the cells
+// instead of to the shared string table. The shared string table stores
+// repeated text once but is held in memory until the writer is closed, so
+// inline strings are preferable for mostly unique text.
+func (w *Writer) SetInlineStrings(b bool) { w.inlineStrings = b }
+
+// Sheets returns the sheets added to the writer.
+func (w *Writer) Sheets() []*Sheet { return w.sheets }
+
+// AddSheet adds a sheet with the given name.
+func (w *Writer) AddSheet(name string) (*Sheet, error) {
+	if w.closed {
+		return nil, ErrClosed
+	}
+	for _, s := range w.sheets {
+		if s.sheet.Name() == name {
+			return nil, fmt.Errorf("stream: duplicate sheet name %q", name)
+		}
+	}
+	f, err := tempstorage.TempFile(w.tempDir, "sheet")
+	if err != nil {
+		return nil, err
+	}
+	sheet := w.wb.AddSheet()
+	sheet.SetName(name)
+	s := &Sheet{w: w, sheet: sheet, index: len(w.sheets), data: f, buf: bufio.NewWriter(f)}
+	w.sheets = append(w.sheets, s)
+	return s, nil
+}
+
+// Close writes the workbook and releases the temporary storage. The output
+// file is closed if the writer was created with Create.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	w.closed = true
+	defer tempstorage.RemoveAll(w.tempDir)
+	defer w.wb.Close()
+	err := w.write()
+	for _, s := range w.sheets {
+		s.data.Close()
+	}
+	if w.file != nil {
+		if cerr := w.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (w *Writer) write() error {
+	for _, s := range w.sheets {
+		if err := s.buf.Flush(); err != nil {
+			return err
+		}
+	}
+	// The workbook without rows is saved first, which also applies the
+	// license checks, and its parts are then copied with the rows spliced
+	// into the worksheets.
+	skeleton, err := tempstorage.TempFile(w.tempDir, "workbook")
+	if err != nil {
+		return err
+	}
+	defer skeleton.Close()
+	cw := &countingWriter{w: skeleton}
+	if err := w.wb.Save(cw); err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(skeleton, cw.n)
+	if err != nil {
+		return err
+	}
+	sheetParts := map[string]*Sheet{}
+	for _, s := range w.sheets {
+		sheetParts[unioffice.AbsoluteFilename(unioffice.DocTypeSpreadsheet, unioffice.WorksheetType, s.index+1)] = s
+	}
+	zw := zip.NewWriter(w.out)
+	for _, f := range zr.File {
+		s, ok := sheetParts[f.Name]
+		if !ok {
+			if err := zw.Copy(f); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.writePart(zw, f); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Sheet is a worksheet whose rows are streamed to temporary storage.
+type Sheet struct {
+	w        *Writer
+	sheet    spreadsheet.Sheet
+	index    int
+	data     tempstorage.File
+	buf      *bufio.Writer
+	size     int64
+	firstRow uint32
+	lastRow  uint32
+	minCol   uint32
+	maxCol   uint32
+	scratch  []byte
+}
+
+// Name returns the sheet name.
+func (s *Sheet) Name() string { return s.sheet.Name() }
+
+// X returns the worksheet holding everything but the rows, such as the
+// columns, panes and merged cells.
+func (s *Sheet) X() *sml.Worksheet { return s.sheet.X() }
+
+// SetColumnWidth sets the width of the column with the given index (1-N).
+// Column settings are written when the writer is closed, so they can be
+// changed at any time.
+func (s *Sheet) SetColumnWidth(col uint32, width measurement.Distance) {
+	s.sheet.Column(col).SetWidth(width)
+}
+
+// SetFrozen freezes the given number of top rows and left columns, so they
+// stay in view while scrolling. Zero rows and columns remove the panes.
+func (s *Sheet) SetFrozen(rows, cols uint32) {
+	ws := s.sheet.X()
+	ws.SheetViews = nil
+	if rows == 0 && cols == 0 {
+		return
+	}
+	v := s.sheet.AddView()
+	v.SetState(sml.ST_PaneStateFrozen)
+	if rows > 0 {
+		v.SetYSplit(float64(rows))
+	}
+	if cols > 0 {
+		v.SetXSplit(float64(cols))
+	}
+	v.SetTopLeft(reference.IndexToColumn(cols) + strconv.FormatUint(uint64(rows)+1, 10))
+	pane := ws.SheetViews.SheetView[0].Pane
+	switch {
+	case rows > 0 && cols > 0:
+		pane.ActivePaneAttr = sml.ST_PaneBottomRight
+	case cols > 0:
+		pane.ActivePaneAttr = sml.ST_PaneTopRight
+	}
+}
+
+// MergeCells merges the cells of a range, such as MergeCells("A1", "D1").
+func (s *Sheet) MergeCells(from, to string) {
+	s.sheet.AddMergedCells(from, to)
+}
+
+// LastRow returns the number of the last row written, or zero if no rows
+// were written.
+func (s *Sheet) LastRow() uint32 { return s.lastRow }
+
+// WriteRow writes the cells to the row following the last row written.
+func (s *Sheet) WriteRow(cells ...Cell) error {
+	return s.WriteRowAt(s.lastRow+1, cells...)
+}
+
+// WriteRowAt writes the cells to the row with the given number (1-N), which
+// must be greater than the number of the last row written. Skipped rows are
+// left empty.
+func (s *Sheet) WriteRowAt(row uint32, cells ...Cell) error {
+	if s.w.closed {
+		return ErrClosed
+	}
+	if row <= s.lastRow {
+		return ErrRowOrder
+	}
+	if row > MaxRows {
+		return fmt.Errorf("stream: row %d exceeds the maximum of %d rows", row, MaxRows)
+	}
+	b := s.scratch[:0]
+	b = append(b, `<row r="`...)
+	b = strconv.AppendUint(b, uint64(row), 10)
+	b = append(b, `">`...)
+	col := uint32(0)
+	for _, c := range cells {
+		if c.hasCol {
+			if c.col <= col {
+				return ErrColumnOrder
+			}
+			col = c.col
+		} else {
+			col++
+		}
+		if col == 0 || col > MaxColumns {
+			return fmt.Errorf("stream: column %d out of range", col)
+		}
+		var err error
+		if b, err = s.appendCell(b, row, col, c); err != nil {
+			return err
+		}
+		if s.minCol == 0 || col < s.minCol {
+			s.minCol = col
+		}
+		if col > s.maxCol {
+			s.maxCol = col
+		}
+	}
+	b = append(b, `</row>`...)
+	s.scratch = b
+	n, err := s.buf.Write(b)
+	s.size += int64(n)
+	if err != nil {
+		return err
+	}
+	if s.firstRow == 0 {
+		s.firstRow = row
+	}
+	s.lastRow = row
+	return nil
+}
+
+func (s *Sheet) appendCell(b []byte, row, col uint32, c Cell) ([]byte, error) {
+	b = append(b, `<c r="`...)
+	b = append(b, reference.IndexToColumn(col-1)...)
+	b = strconv.AppendUint(b, uint64(row), 10)
+	b = append(b, '"')
+	if c.styled {
+		b = append(b, ` s="`...)
+		b = strconv.AppendUint(b, uint64(c.style), 10)
+		b = append(b, '"')
+	}
+	switch c.kind {
+	case cellEmpty:
+		return append(b, `/>`...), nil
+	case cellString:
+		if s.w.inlineStrings {
+			b = append(b, ` t="inlineStr"><is><t xml:space="preserve">`...)
+			b = appendEscaped(b, c.str)
+			return append(b, `</t></is></c>`...), nil
+		}
+		b = append(b, ` t="s"><v>`...)
+		b = strconv.AppendInt(b, int64(s.w.wb.SharedStrings.AddString(c.str)), 10)
+	case cellNumber, cellTime:
+		v := c.num
+		if c.kind == cellTime {
+			epoch := s.w.wb.Epoch()
+			if v = serial(c.time, epoch); v < 0 {
+				return b, fmt.Errorf("stream: dates before %s are not supported", epoch.Format("2006-01-02"))
+			}
+		}
+		str, ok := formatNumber(v)
+		if ok {
+			b = append(b, `><v>`...)
+		} else {
+			b = append(b, ` t="e"><v>`...)
+		}
+		b = append(b, str...)
+	case cellBool:
+		b = append(b, ` t="b"><v>`...)
+		b = strconv.AppendInt(b, int64(c.num), 10)
+	case cellFormula:
+		b = append(b, `><f>`...)
+		b = appendEscaped(b, c.str)
+		return append(b, `</f></c>`...), nil
+	}
+	return append(b, `</v></c>`...), nil
+}
+
+var dimensionRe = regexp.MustCompile(`(<(?:\w+:)?dimension ref=")[^"]*(")`)
+
+// writePart writes the worksheet part, replacing the empty sheet data saved
+// with the workbook by the streamed rows.
+func (s *Sheet) writePart(zw *zip.Writer, f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	part, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+	end := bytes.Index(part, []byte("sheetData/>"))
+	start := bytes.LastIndexByte(part[:max(end, 0)], '<')
+	if end < 0 || start < 0 {
+		return fmt.Errorf("stream: no sheet data in %s", f.Name)
+	}
+	if s.lastRow > 0 {
+		minCol, maxCol := s.minCol, s.maxCol
+		if minCol == 0 {
+			minCol, maxCol = 1, 1
+		}
+		ref := reference.IndexToColumn(minCol-1) + strconv.FormatUint(uint64(s.firstRow), 10) + ":" +
+			reference.IndexToColumn(maxCol-1) + strconv.FormatUint(uint64(s.lastRow), 10)
+		part = dimensionRe.ReplaceAll(part, []byte("${1}"+ref+"${2}"))
+		end = bytes.Index(part, []byte("sheetData/>"))
+		start = bytes.LastIndexByte(part[:end], '<')
+	}
+	name := part[start+1 : end+len("sheetData")]
+	out, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(part[:start]); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "<%s>", name)
+	if _, err := io.Copy(out, io.NewSectionReader(s.data, 0, s.size)); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "</%s>", name)
+	_, err = out.Write(part[end+len("sheetData/>"):])
+	return err
+}
+
+func appendEscaped(b []byte, s string) []byte {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return append(b, buf.Bytes()...)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,259 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package stream
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/unidoc/unioffice/v2/measurement"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/spreadsheet"
+)
+
+// readWritten reads a workbook written by a Writer.
+func readWritten(t *testing.T, data []byte) *spreadsheet.Workbook {
+	t.Helper()
+	wb, err := spreadsheet.Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("reading: %s", err)
+	}
+	return wb
+}
+
+func TestWriter(t *testing.T) {
+	buf := bytes.Buffer{}
+	w, err := New(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := w.StyleSheet().AddCellStyle()
+	date.SetNumberFormatStandard(spreadsheet.StandardFormatDate)
+	s, err := w.AddSheet("Data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.AddSheet("Data"); err == nil {
+		t.Error("added a second sheet named Data")
+	}
+	s.SetColumnWidth(1, 20*measurement.Point)
+	s.SetFrozen(1, 1)
+	s.MergeCells("A6", "C6")
+
+	rows := [][]Cell{
+		{String("name"), String("value"), String("<&>")},
+		{String("name"), Number(1.5), Int(-3), Bool(true), Bool(false)},
+		{Time(testDate).WithStyle(date), Number(math.NaN()), Formula("B2*2"), Empty().WithStyle(date), Number(7).At(6)},
+	}
+	for _, r := range rows {
+		if err := s.WriteRow(r...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// rows 4 and 5 are skipped
+	if err := s.WriteRowAt(6, String("merged").At(1)); err != nil {
+		t.Fatal(err)
+	}
+	if s.LastRow() != 6 {
+		t.Errorf("last row = %d, want 6", s.LastRow())
+	}
+	if _, err := w.AddSheet("Empty"); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Sheets()) != 2 || w.Sheets()[0].Name() != "Data" {
+		t.Errorf("sheets = %v", w.Sheets())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wb := readWritten(t, buf.Bytes())
+	if len(wb.Sheets()) != 2 || wb.Sheets()[1].Name() != "Empty" {
+		t.Fatalf("read %d sheets", len(wb.Sheets()))
+	}
+	sheet := wb.Sheets()[0]
+	strs := map[string]string{"A1": "name", "B1": "value", "C1": "<&>", "A2": "name", "A6": "merged"}
+	for ref, want := range strs {
+		if got := sheet.Cell(ref).GetString(); got != want {
+			t.Errorf("%s = %q, want %q", ref, got, want)
+		}
+	}
+	nums := map[string]float64{"B2": 1.5, "C2": -3, "A3": 44269, "F3": 7}
+	for ref, want := range nums {
+		if got, err := sheet.Cell(ref).GetValueAsNumber(); err != nil || got != want {
+			t.Errorf("%s = %v %v, want %v", ref, got, err, want)
+		}
+	}
+	if b, err := sheet.Cell("D2").GetValueAsBool(); err != nil || !b {
+		t.Errorf("D2 = %v %v, want true", b, err)
+	}
+	if b, err := sheet.Cell("E2").GetValueAsBool(); err != nil || b {
+		t.Errorf("E2 = %v %v, want false", b, err)
+	}
+	if c := sheet.Cell("B3").X(); c.TAttr != sml.ST_CellTypeE || *c.V != "#NUM!" {
+		t.Errorf("B3 = %s %s, want the #NUM! error", c.TAttr, *c.V)
+	}
+	if got := sheet.Cell("C3").GetFormula(); got != "B2*2" {
+		t.Errorf("C3 formula = %q", got)
+	}
+	for _, ref := range []string{"A3", "D3"} {
+		if c := sheet.Cell(ref).X(); c.SAttr == nil || *c.SAttr != date.Index() {
+			t.Errorf("%s isn't styled as a date", ref)
+		}
+	}
+	if !sheet.Cell("D3").IsEmpty() || !sheet.Cell("E3").IsEmpty() {
+		t.Error("D3 and E3 aren't empty")
+	}
+	if got := sheet.Extents(); got != "A1:F6" {
+		t.Errorf("extents = %s, want A1:F6", got)
+	}
+	if got := sheet.X().Dimension.RefAttr; got != "A1:F6" {
+		t.Errorf("dimension = %s, want A1:F6", got)
+	}
+	if mc := sheet.MergedCells(); len(mc) != 1 || mc[0].Reference() != "A6:C6" {
+		t.Errorf("merged cells = %v", mc)
+	}
+	if pane := sheet.X().SheetViews.SheetView[0].Pane; pane.TopLeftCellAttr == nil || *pane.TopLeftCellAttr != "B2" || pane.ActivePaneAttr != sml.ST_PaneBottomRight {
+		t.Errorf("pane = %v", pane)
+	}
+	if cols := sheet.X().Cols; len(cols) != 1 || len(cols[0].Col) != 1 || cols[0].Col[0].MinAttr != 1 {
+		t.Errorf("columns = %v", cols)
+	}
+	if rows := wb.Sheets()[1].Rows(); len(rows) != 0 {
+		t.Errorf("empty sheet has %d rows", len(rows))
+	}
+
+	// the stream reader reads the written rows
+	swb := readWorkbook(t, buf.Bytes())
+	defer swb.Close()
+	var streamed []uint32
+	for _, r := range readRows(t, swb, "Data") {
+		streamed = append(streamed, r.Number)
+	}
+	if !reflect.DeepEqual(streamed, []uint32{1, 2, 3, 6}) {
+		t.Errorf("streamed rows = %v", streamed)
+	}
+}
+
+func TestWriterInlineStrings(t *testing.T) {
+	for _, inline := range []bool{false, true} {
+		buf := bytes.Buffer{}
+		w, err := New(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.SetInlineStrings(inline)
+		s, _ := w.AddSheet("Sheet1")
+		s.WriteRow(String("a"), String(" padded "))
+		s.WriteRow(String("a"))
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		sheet := readWritten(t, buf.Bytes()).Sheets()[0]
+		want := sml.ST_CellTypeS
+		if inline {
+			want = sml.ST_CellTypeInlineStr
+		}
+		for _, ref := range []string{"A1", "B1", "A2"} {
+			if typ := sheet.Cell(ref).X().TAttr; typ != want {
+				t.Errorf("%s with inline strings %v has type %s, want %s", ref, inline, typ, want)
+			}
+		}
+		if got := sheet.Cell("B1").GetString(); got != " padded " {
+			t.Errorf("B1 with inline strings %v = %q", inline, got)
+		}
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	w, err := New(&bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := w.AddSheet("Sheet1")
+	if err := s.WriteRowAt(2, String("a")); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		row   uint32
+		cells []Cell
+		want  error
+	}{
+		{"same row", 2, nil, ErrRowOrder},
+		{"earlier row", 1, nil, ErrRowOrder},
+		{"same column", 3, []Cell{String("a").At(2), String("b").At(2)}, ErrColumnOrder},
+		{"earlier column", 3, []Cell{String("a"), String("b"), String("c").At(1)}, ErrColumnOrder},
+		{"column zero", 3, []Cell{String("a").At(0)}, ErrColumnOrder},
+		{"column out of range", 3, []Cell{String("a").At(MaxColumns + 1)}, nil},
+		{"row out of range", MaxRows + 1, nil, nil},
+		{"date before the epoch", 3, []Cell{Time(time.Date(1899, 1, 1, 0, 0, 0, 0, time.UTC))}, nil},
+	}
+	for _, tc := range tests {
+		err := s.WriteRowAt(tc.row, tc.cells...)
+		if err == nil || tc.want != nil && err != tc.want {
+			t.Errorf("%s: error = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	// failed rows aren't written
+	if err := s.WriteRow(String("b")); err != nil || s.LastRow() != 3 {
+		t.Errorf("row after errors = %v, last row %d", err, s.LastRow())
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteRow(String("c")); err != ErrClosed {
+		t.Errorf("WriteRow after Close = %v, want ErrClosed", err)
+	}
+	if _, err := w.AddSheet("Sheet2"); err != ErrClosed {
+		t.Errorf("AddSheet after Close = %v, want ErrClosed", err)
+	}
+	if err := w.Close(); err != ErrClosed {
+		t.Errorf("second Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "stream.xlsx")
+	w, err := Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := w.AddSheet("Rows")
+	for i := 0; i < 1000; i++ {
+		if err := s.WriteRow(Int(int64(i)), String("row")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sheet := readWritten(t, data).Sheets()[0]
+	if n := len(sheet.Rows()); n != 1000 {
+		t.Errorf("read %d rows, want 1000", n)
+	}
+	if got, _ := sheet.Cell("A1000").GetValueAsNumber(); got != 999 {
+		t.Errorf("A1000 = %v, want 999", got)
+	}
+
+	if _, err := Create(filepath.Join(t.TempDir(), "missing", "stream.xlsx")); err == nil {
+		t.Error("created a file in a missing directory")
+	}
+}
@@ -0,0 +1,763 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+	"github.com/unidoc/unioffice/v2/spreadsheet/formula"
+	"github.com/unidoc/unioffice/v2/spreadsheet/reference"
+)
+
+// DefaultTableStyle is the style of new tables when none is given.
+const DefaultTableStyle = "TableStyleMedium2"
+
+// AddTable adds a table with a header row over a range of cells, such as
+// "A1:D10". The header names are taken from the first row of the range, cells
+// without a name being named Column1, Column2... as in Excel. The name must be
+// unique within the workbook and the style is a table style name such as
+// "TableStyleLight9", DefaultTableStyle being used if empty.
+func (s *Sheet) AddTable(ref, name, style string) (Table, error) {
+	wb := s._fgeg
+	idx := s.index()
+	if idx < 0 {
+		return Table{}, ErrorNotFound
+	}
+	if err := wb.checkTableName(name, nil); err != nil {
+		return Table{}, err
+	}
+	from, to, err := reference.ParseRangeReference(ref)
+	if err != nil {
+		return Table{}, err
+	}
+	if from.RowIdx > to.RowIdx {
+		from.RowIdx, to.RowIdx = to.RowIdx, from.RowIdx
+	}
+	if from.ColumnIdx > to.ColumnIdx {
+		from.ColumnIdx, to.ColumnIdx = to.ColumnIdx, from.ColumnIdx
+	}
+	if to.RowIdx == from.RowIdx {
+		// a table has at least one row of data
+		to.RowIdx++
+	}
+	ref = rangeReference(from, to)
+	for _, t := range s.tables() {
+		if rangesOverlap(ref, t.RefAttr) {
+			return Table{}, fmt.Errorf("range %s overlaps table %s", ref, t.DisplayNameAttr)
+		}
+	}
+
+	if style == "" {
+		style = DefaultTableStyle
+	}
+	t := sml.NewTable()
+	t.IdAttr = wb.nextTableID()
+	t.NameAttr = unioffice.String(name)
+	t.DisplayNameAttr = name
+	t.RefAttr = ref
+	t.AutoFilter = sml.NewCT_AutoFilter()
+	t.AutoFilter.RefAttr = unioffice.String(ref)
+	t.TableColumns = sml.NewCT_TableColumns()
+	t.TableStyleInfo = sml.NewCT_TableStyleInfo()
+	t.TableStyleInfo.NameAttr = unioffice.String(style)
+	t.TableStyleInfo.ShowFirstColumnAttr = unioffice.Bool(false)
+	t.TableStyleInfo.ShowLastColumnAttr = unioffice.Bool(false)
+	t.TableStyleInfo.ShowRowStripesAttr = unioffice.Bool(true)
+	t.TableStyleInfo.ShowColumnStripesAttr = unioffice.Bool(false)
+	namer := newColumnNamer(nil)
+	for col := from.ColumnIdx; col <= to.ColumnIdx; col++ {
+		header := s.Cell(reference.IndexToColumn(col) + strconv.Itoa(int(from.RowIdx)))
+		t.TableColumns.TableColumn = append(t.TableColumns.TableColumn, namer.column(&header, int(col-from.ColumnIdx)))
+	}
+	t.TableColumns.CountAttr = unioffice.Uint32(uint32(len(t.TableColumns.TableColumn)))
+
+	// tables are stored in the order of the sheets' table parts
+	offset := 0
+	for _, ws := range wb._fbef[:idx] {
+		if ws.TableParts != nil {
+			offset += len(ws.TableParts.TablePart)
+		}
+	}
+	offset += len(s.tables())
+	wb._eeegg = append(wb._eeegg[:offset], append([]*sml.Table{t}, wb._eeegg[offset:]...)...)
+
+	rel := wb._aedf[idx].AddAutoRelationship(unioffice.DocTypeSpreadsheet, unioffice.WorksheetType, offset+1, unioffice.TableType)
+	if s._bbbe.TableParts == nil {
+		s._bbbe.TableParts = sml.NewCT_TableParts()
+	}
+	tp := sml.NewCT_TablePart()
+	tp.IdAttr = rel.ID()
+	s._bbbe.TableParts.TablePart = append(s._bbbe.TableParts.TablePart, tp)
+	s._bbbe.TableParts.CountAttr = unioffice.Uint32(uint32(len(s._bbbe.TableParts.TablePart)))
+	wb.relinkTables()
+	return Table{t, wb}, nil
+}
+
+// Tables returns the tables of the sheet.
+func (s *Sheet) Tables() []Table {
+	var tables []Table
+	for _, t := range s.tables() {
+		tables = append(tables, Table{t, s._fgeg})
+	}
+	return tables
+}
+
+// RemoveTable removes a table of the sheet, keeping its cells as a regular
+// range like Excel's "Convert to Range".
+func (s *Sheet) RemoveTable(t Table) error {
+	idx := s.index()
+	tables := s.tables()
+	pos := -1
+	for i, st := range tables {
+		if st == t._cbgb {
+			pos = i
+		}
+	}
+	if idx < 0 || pos < 0 || s._bbbe.TableParts == nil || pos >= len(s._bbbe.TableParts.TablePart) {
+		return ErrorNotFound
+	}
+	wb := s._fgeg
+	for i, wt := range wb._eeegg {
+		if wt == t._cbgb {
+			wb._eeegg = append(wb._eeegg[:i], wb._eeegg[i+1:]...)
+			break
+		}
+	}
+	tps := s._bbbe.TableParts
+	rels := wb._aedf[idx]
+	if rel := rels.GetByRelId(tps.TablePart[pos].IdAttr); rel.X() != nil {
+		rels.Remove(rel)
+	}
+	tps.TablePart = append(tps.TablePart[:pos], tps.TablePart[pos+1:]...)
+	if len(tps.TablePart) == 0 {
+		s._bbbe.TableParts = nil
+	} else {
+		tps.CountAttr = unioffice.Uint32(uint32(len(tps.TablePart)))
+	}
+	wb.relinkTables()
+
+	// structured references to the table would no longer resolve
+	def := t.Definition()
+	wb.mapFormulas(func(f string) string {
+		return formula.ReplaceStructuredReferences(f, func(r formula.StructuredReference) (string, bool) {
+			if !strings.EqualFold(r.Table, def.Name) {
+				return "", false
+			}
+			resolved, err := r.Resolve(def, 0)
+			return resolved, err == nil
+		})
+	})
+	return nil
+}
+
+// GetTable returns the table with the given name, compared case
+// insensitively as in Excel.
+func (wb *Workbook) GetTable(name string) (Table, error) {
+	for _, t := range wb._eeegg {
+		if strings.EqualFold(t.DisplayNameAttr, name) {
+			return Table{t, wb}, nil
+		}
+	}
+	return Table{}, ErrorNotFound
+}
+
+// relinkTables renames the table parts after their position in the workbook,
+// which is how they are written on save.
+func (wb *Workbook) relinkTables() {
+	n := 0
+	for i, ws := range wb._fbef {
+		if ws.TableParts == nil || i >= len(wb._aedf) {
+			continue
+		}
+		for _, tp := range ws.TableParts.TablePart {
+			n++
+			if rel := wb._aedf[i].GetByRelId(tp.IdAttr); rel.X() != nil {
+				rel.SetTarget(unioffice.RelativeFilename(unioffice.DocTypeSpreadsheet, unioffice.WorksheetType, unioffice.TableType, n))
+			}
+		}
+	}
+	for i := 1; i <= n; i++ {
+		wb.ContentTypes.EnsureOverride("/"+unioffice.AbsoluteFilename(unioffice.DocTypeSpreadsheet, unioffice.TableType, i), unioffice.TableContentType)
+	}
+	wb.ContentTypes.RemoveOverride(unioffice.AbsoluteFilename(unioffice.DocTypeSpreadsheet, unioffice.TableType, n+1))
+}
+
+func (wb *Workbook) nextTableID() uint32 {
+	id := uint32(0)
+	for _, t := range wb._eeegg {
+		if t.IdAttr > id {
+			id = t.IdAttr
+		}
+	}
+	return id + 1
+}
+
+// checkTableName returns an error if a name can't be used for a table, the
+// table being renamed excluded.
+func (wb *Workbook) checkTableName(name string, self *sml.Table) error {
+	valid := name != "" && len(name) <= 255
+	for i, r := range name {
+		if !(r == '_' || r == '\\' || r >= 0x80 || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') ||
+			(i > 0 && (r == '.' || ('0' <= r && r <= '9')))) {
+			valid = false
+		}
+	}
+	// names looking like cell references are ambiguous
+	if _, err := reference.ParseCellReference(name); err == nil {
+		valid = false
+	}
+	if !valid || strings.EqualFold(name, "R") || strings.EqualFold(name, "C") {
+		return fmt.Errorf("invalid table name %q", name)
+	}
+	for _, t := range wb._eeegg {
+		if t != self && strings.EqualFold(t.DisplayNameAttr, name) {
+			return fmt.Errorf("table %s already exists", name)
+		}
+	}
+	for _, dn := range wb.DefinedNames() {
+		if strings.EqualFold(dn.Name(), name) {
+			return fmt.Errorf("a defined name %s already exists", name)
+		}
+	}
+	return nil
+}
+
+// mapFormulas replaces the formulas of the cells, tables and defined names of
+// the workbook with the result of fn.
+func (wb *Workbook) mapFormulas(fn func(string) string) {
+	for _, ws := range wb._fbef {
+		if ws.SheetData == nil {
+			continue
+		}
+		for _, r := range ws.SheetData.Row {
+			for _, c := range r.C {
+				if c.F != nil && c.F.Content != "" {
+					c.F.Content = fn(c.F.Content)
+				}
+			}
+		}
+	}
+	for _, t := range wb._eeegg {
+		if t.TableColumns == nil {
+			continue
+		}
+		for _, tc := range t.TableColumns.TableColumn {
+			for _, tf := range []*sml.CT_TableFormula{tc.CalculatedColumnFormula, tc.TotalsRowFormula} {
+				if tf != nil {
+					tf.Content = fn(tf.Content)
+				}
+			}
+		}
+	}
+	if wb._gbadf.DefinedNames != nil {
+		for _, dn := range wb._gbadf.DefinedNames.DefinedName {
+			dn.Content = fn(dn.Content)
+		}
+	}
+}
+
+// columnNamer creates the columns of a table, with unique names and IDs.
+type columnNamer struct {
+	names map[string]bool
+	id    uint32
+}
+
+func newColumnNamer(cols []*sml.CT_TableColumn) *columnNamer {
+	n := &columnNamer{names: map[string]bool{}}
+	for _, tc := range cols {
+		n.names[strings.ToLower(tc.NameAttr)] = true
+		if tc.IdAttr > n.id {
+			n.id = tc.IdAttr
+		}
+	}
+	return n
+}
+
+// column returns a new table column at a position (0-N) of a table, named
+// after its header cell. Columns without header are named after their
+// position, and duplicate names get a number, as in Excel. The header cell is
+// updated with the name chosen.
+func (n *columnNamer) column(header *Cell, pos int) *sml.CT_TableColumn {
+	name := ""
+	if header != nil {
+		name = strings.TrimSpace(header.GetString())
+	}
+	if name == "" {
+		name = "Column" + strconv.Itoa(pos+1)
+	}
+	base := name
+	for k := 2; n.names[strings.ToLower(name)]; k++ {
+		name = base + strconv.Itoa(k)
+	}
+	n.names[strings.ToLower(name)] = true
+	n.id++
+	tc := sml.NewCT_TableColumn()
+	tc.IdAttr = n.id
+	tc.NameAttr = name
+	if header != nil && header.GetString() != name {
+		header.SetString(name)
+	}
+	return tc
+}
+
+// Definition returns the description of the table used to resolve
+// structured references.
+func (t Table) Definition() formula.TableDefinition {
+	def := formula.TableDefinition{
+		Name:      t._cbgb.DisplayNameAttr,
+		Sheet:     t.sheetName(),
+		Reference: t._cbgb.RefAttr,
+		HeaderRow: t.HasHeaderRow(),
+		TotalsRow: t.HasTotalsRow(),
+	}
+	if t._cbgb.TableColumns != nil {
+		for _, tc := range t._cbgb.TableColumns.TableColumn {
+			def.Columns = append(def.Columns, tc.NameAttr)
+		}
+	}
+	return def
+}
+
+// sheet returns the sheet holding the table.
+func (t Table) sheet() (Sheet, bool) {
+	if t._dbfd == nil {
+		return Sheet{}, false
+	}
+	wb := t._dbfd
+	for i, ws := range wb._fbef {
+		s := Sheet{wb, wb._gbadf.Sheets.Sheet[i], ws}
+		for _, st := range s.tables() {
+			if st == t._cbgb {
+				return s, true
+			}
+		}
+	}
+	return Sheet{}, false
+}
+
+func (t Table) sheetName() string {
+	if s, ok := t.sheet(); ok {
+		return s.Name()
+	}
+	return ""
+}
+
+// HasHeaderRow returns true if the table shows a header row.
+func (t Table) HasHeaderRow() bool {
+	return t._cbgb.HeaderRowCountAttr == nil || *t._cbgb.HeaderRowCountAttr > 0
+}
+
+// HasTotalsRow returns true if the table shows a totals row.
+func (t Table) HasTotalsRow() bool {
+	return t._cbgb.TotalsRowCountAttr != nil && *t._cbgb.TotalsRowCountAttr > 0
+}
+
+// SetName renames the table, updating the structured references to it.
+func (t Table) SetName(name string) error {
+	if t._dbfd == nil {
+		return ErrorNotFound
+	}
+	if err := t._dbfd.checkTableName(name, t._cbgb); err != nil {
+		return err
+	}
+	old := t._cbgb.DisplayNameAttr
+	t._cbgb.NameAttr = unioffice.String(name)
+	t._cbgb.DisplayNameAttr = name
+	t._dbfd.mapFormulas(func(f string) string {
+		return formula.MapStructuredReferences(f, func(r formula.StructuredReference) formula.StructuredReference {
+			if strings.EqualFold(r.Table, old) {
+				r.Table = name
+			}
+			return r
+		})
+	})
+	return nil
+}
+
+// SetStyle sets the table style, e.g. "TableStyleLight9".
+func (t Table) SetStyle(name string) {
+	t.styleInfo().NameAttr = unioffice.String(name)
+}
+
+// SetBandedRows controls whether alternate rows are shaded.
+func (t Table) SetBandedRows(b bool) { t.styleInfo().ShowRowStripesAttr = unioffice.Bool(b) }
+
+// SetBandedColumns controls whether alternate columns are shaded.
+func (t Table) SetBandedColumns(b bool) { t.styleInfo().ShowColumnStripesAttr = unioffice.Bool(b) }
+
+// SetHighlightFirstColumn controls whether the first column is highlighted.
+func (t Table) SetHighlightFirstColumn(b bool) { t.styleInfo().ShowFirstColumnAttr = unioffice.Bool(b) }
+
+// SetHighlightLastColumn controls whether the last column is highlighted.
+func (t Table) SetHighlightLastColumn(b bool) { t.styleInfo().ShowLastColumnAttr = unioffice.Bool(b) }
+
+func (t Table) styleInfo() *sml.CT_TableStyleInfo {
+	if t._cbgb.TableStyleInfo == nil {
+		t._cbgb.TableStyleInfo = sml.NewCT_TableStyleInfo()
+	}
+	return t._cbgb.TableStyleInfo
+}
+
+// Columns returns the columns of the table.
+func (t Table) Columns() []TableColumn {
+	if t._cbgb.TableColumns == nil {
+		return nil
+	}
+	var cols []TableColumn
+	for i, tc := range t._cbgb.TableColumns.TableColumn {
+		cols = append(cols, TableColumn{tc, t, i})
+	}
+	return cols
+}
+
+// Column returns the column of the table with the given name, compared case
+// insensitively.
+func (t Table) Column(name string) (TableColumn, error) {
+	for _, c := range t.Columns() {
+		if strings.EqualFold(c.Name(), name) {
+			return c, nil
+		}
+	}
+	return TableColumn{}, ErrorNotFound
+}
+
+// SetShowTotalsRow adds or removes the totals row below the data rows. The
+// totals row shows the label or the function result set for each column. It
+// is added in the row below the table, which must be empty: rows can be
+// inserted with Sheet.InsertRows to make room for it.
+func (t Table) SetShowTotalsRow(b bool) error {
+	if b == t.HasTotalsRow() {
+		return nil
+	}
+	s, ok := t.sheet()
+	if !ok {
+		return ErrorNotFound
+	}
+	from, to, err := reference.ParseRangeReference(t._cbgb.RefAttr)
+	if err != nil {
+		return err
+	}
+	if b {
+		to.RowIdx++
+		ref := rangeReference(from, to)
+		for _, other := range s.tables() {
+			if other != t._cbgb && rangesOverlap(ref, other.RefAttr) {
+				return fmt.Errorf("the totals row would overlap table %s", other.DisplayNameAttr)
+			}
+		}
+		for col := from.ColumnIdx; col <= to.ColumnIdx; col++ {
+			cell := reference.IndexToColumn(col) + strconv.Itoa(int(to.RowIdx))
+			if !s.lookupCell(cell).IsEmpty() {
+				return fmt.Errorf("the totals row would overwrite cell %s", cell)
+			}
+		}
+		t._cbgb.RefAttr = ref
+		t._cbgb.TotalsRowCountAttr = unioffice.Uint32(1)
+		t._cbgb.TotalsRowShownAttr = nil
+		for _, c := range t.Columns() {
+			c.writeTotal()
+		}
+		return nil
+	}
+	for col := from.ColumnIdx; col <= to.ColumnIdx; col++ {
+		s.Cell(reference.IndexToColumn(col) + strconv.Itoa(int(to.RowIdx))).Clear()
+	}
+	to.RowIdx--
+	t._cbgb.RefAttr = rangeReference(from, to)
+	t._cbgb.TotalsRowCountAttr = nil
+	t._cbgb.TotalsRowShownAttr = unioffice.Bool(false)
+	return nil
+}
+
+// Resize changes the range of the table. The header row must stay in place.
+// Columns are added, named after their header cells, or removed to match the
+// new range.
+func (t Table) Resize(ref string) error {
+	s, ok := t.sheet()
+	if !ok {
+		return ErrorNotFound
+	}
+	from, to, err := reference.ParseRangeReference(t._cbgb.RefAttr)
+	if err != nil {
+		return err
+	}
+	nfrom, nto, err := reference.ParseRangeReference(ref)
+	if err != nil {
+		return err
+	}
+	if nfrom.RowIdx != from.RowIdx {
+		return fmt.Errorf("the header row of table %s must stay in row %d", t._cbgb.DisplayNameAttr, from.RowIdx)
+	}
+	if nto.RowIdx <= nfrom.RowIdx || nto.ColumnIdx < nfrom.ColumnIdx {
+		return fmt.Errorf("invalid table range %s", ref)
+	}
+	ref = rangeReference(nfrom, nto)
+	for _, other := range s.tables() {
+		if other != t._cbgb && rangesOverlap(ref, other.RefAttr) {
+			return fmt.Errorf("range %s overlaps table %s", ref, other.DisplayNameAttr)
+		}
+	}
+
+	// keep the columns still in range, in order, and add the new ones
+	tcs := t._cbgb.TableColumns
+	if tcs == nil {
+		tcs = sml.NewCT_TableColumns()
+		t._cbgb.TableColumns = tcs
+	}
+	old := tcs.TableColumn
+	namer := newColumnNamer(old)
+	tcs.TableColumn = nil
+	for col := nfrom.ColumnIdx; col <= nto.ColumnIdx; col++ {
+		if col >= from.ColumnIdx && col <= to.ColumnIdx && int(col-from.ColumnIdx) < len(old) {
+			tcs.TableColumn = append(tcs.TableColumn, old[col-from.ColumnIdx])
+			continue
+		}
+		var header *Cell
+		if t.HasHeaderRow() {
+			c := s.Cell(reference.IndexToColumn(col) + strconv.Itoa(int(nfrom.RowIdx)))
+			header = &c
+		}
+		tcs.TableColumn = append(tcs.TableColumn, namer.column(header, len(tcs.TableColumn)))
+	}
+	tcs.CountAttr = unioffice.Uint32(uint32(len(tcs.TableColumn)))
+
+	t._cbgb.RefAttr = ref
+	if t._cbgb.AutoFilter != nil {
+		afTo := nto
+		if t.HasTotalsRow() {
+			afTo.RowIdx--
+		}
+		t._cbgb.AutoFilter.RefAttr = unioffice.String(rangeReference(nfrom, afTo))
+	}
+	if t.HasTotalsRow() {
+		for _, c := range t.Columns() {
+			c.writeTotal()
+		}
+	}
+	return nil
+}
+
+// TableColumn is a column of a table.
+type TableColumn struct {
+	x     *sml.CT_TableColumn
+	table Table
+	index int
+}
+
+// X returns the inner wrapped XML type.
+func (c TableColumn) X() *sml.CT_TableColumn { return c.x }
+
+// Name returns the name of the column.
+func (c TableColumn) Name() string { return c.x.NameAttr }
+
+// SetName renames the column and its header cell, updating the structured
+// references to it.
+func (c TableColumn) SetName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("empty column name")
+	}
+	for _, other := range c.table.Columns() {
+		if other.x != c.x && strings.EqualFold(other.Name(), name) {
+			return fmt.Errorf("column %s already exists in table %s", name, c.table._cbgb.DisplayNameAttr)
+		}
+	}
+	old := c.x.NameAttr
+	c.x.NameAttr = name
+	if c.table.HasHeaderRow() {
+		if cell, ok := c.cell(true); ok {
+			cell.SetString(name)
+		}
+	}
+	table := c.table._cbgb.DisplayNameAttr
+	rename := func(col string) string {
+		if strings.EqualFold(col, old) {
+			return name
+		}
+		return col
+	}
+	if c.table._dbfd != nil {
+		c.table._dbfd.mapFormulas(func(f string) string {
+			return formula.MapStructuredReferences(f, func(r formula.StructuredReference) formula.StructuredReference {
+				if strings.EqualFold(r.Table, table) {
+					r.FirstColumn, r.LastColumn = rename(r.FirstColumn), rename(r.LastColumn)
+				}
+				return r
+			})
+		})
+	}
+	return nil
+}
+
+// TotalsRowFunction returns the function shown in the totals row.
+func (c TableColumn) TotalsRowFunction() sml.ST_TotalsRowFunction {
+	return c.x.TotalsRowFunctionAttr
+}
+
+// SetTotalsRowFunction sets the function shown in the totals row, such as
+// sml.ST_TotalsRowFunctionSum or sml.ST_TotalsRowFunctionAverage, replacing
+// any label.
+func (c TableColumn) SetTotalsRowFunction(fn sml.ST_TotalsRowFunction) {
+	c.x.TotalsRowFunctionAttr = fn
+	c.x.TotalsRowLabelAttr = nil
+	if fn != sml.ST_TotalsRowFunctionCustom {
+		c.x.TotalsRowFormula = nil
+	}
+	c.writeTotal()
+}
+
+// SetTotalsRowFormula sets a custom formula shown in the totals row.
+func (c TableColumn) SetTotalsRowFormula(f string) {
+	c.x.TotalsRowFunctionAttr = sml.ST_TotalsRowFunctionCustom
+	c.x.TotalsRowLabelAttr = nil
+	c.x.TotalsRowFormula = sml.NewCT_TableFormula()
+	c.x.TotalsRowFormula.Content = formula.CanonicalStructuredReferences(strings.TrimPrefix(f, "="), c.table._cbgb.DisplayNameAttr)
+	c.writeTotal()
+}
+
+// SetTotalsRowLabel sets a label shown in the totals row, such as "Total",
+// replacing any function.
+func (c TableColumn) SetTotalsRowLabel(label string) {
+	c.x.TotalsRowFunctionAttr = sml.ST_TotalsRowFunctionUnset
+	c.x.TotalsRowFormula = nil
+	c.x.TotalsRowLabelAttr = unioffice.String(label)
+	c.writeTotal()
+}
+
+// SetCalculatedFormula makes the column a calculated column, setting the
+// formula of each of its data cells. The formula can use structured references
+// such as [@Price]*[@Quantity].
+func (c TableColumn) SetCalculatedFormula(f string) {
+	f = formula.CanonicalStructuredReferences(strings.TrimPrefix(f, "="), c.table._cbgb.DisplayNameAttr)
+	c.x.CalculatedColumnFormula = sml.NewCT_TableFormula()
+	c.x.CalculatedColumnFormula.Content = f
+	s, ok := c.table.sheet()
+	if !ok {
+		return
+	}
+	from, to, err := reference.ParseRangeReference(c.table._cbgb.RefAttr)
+	if err != nil {
+		return
+	}
+	first, last := from.RowIdx, to.RowIdx
+	if c.table.HasHeaderRow() {
+		first++
+	}
+	if c.table.HasTotalsRow() {
+		last--
+	}
+	col := reference.IndexToColumn(from.ColumnIdx + uint32(c.index))
+	for row := first; row <= last; row++ {
+		setFormula(s.Cell(col+strconv.Itoa(int(row))), f)
+	}
+}
+
+// ClearCalculatedFormula stops the column from being a calculated column. The
+// formulas of its cells are kept.
+func (c TableColumn) ClearCalculatedFormula() { c.x.CalculatedColumnFormula = nil }
+
+// subtotalFunctions maps the totals row functions to the function numbers of
+// SUBTOTAL written by Excel, which ignore filtered rows.
+var subtotalFunctions = map[sml.ST_TotalsRowFunction]int{
+	sml.ST_TotalsRowFunctionAverage:   101,
+	sml.ST_TotalsRowFunctionCountNums: 102,
+	sml.ST_TotalsRowFunctionCount:     103,
+	sml.ST_TotalsRowFunctionMax:       104,
+	sml.ST_TotalsRowFunctionMin:       105,
+	sml.ST_TotalsRowFunctionStdDev:    107,
+	sml.ST_TotalsRowFunctionSum:       109,
+	sml.ST_TotalsRowFunctionVar:       110,
+}
+
+// writeTotal writes the totals row cell of the column, if the table shows a
+// totals row.
+func (c TableColumn) writeTotal() {
+	if !c.table.HasTotalsRow() {
+		return
+	}
+	cell, ok := c.cell(false)
+	if !ok {
+		return
+	}
+	switch fn := c.x.TotalsRowFunctionAttr; {
+	case fn == sml.ST_TotalsRowFunctionCustom && c.x.TotalsRowFormula != nil:
+		setFormula(cell, c.x.TotalsRowFormula.Content)
+	case subtotalFunctions[fn] != 0:
+		ref := formula.StructuredReference{Table: c.table._cbgb.DisplayNameAttr, FirstColumn: c.x.NameAttr}
+		setFormula(cell, fmt.Sprintf("SUBTOTAL(%d,%s)", subtotalFunctions[fn], ref))
+	case c.x.TotalsRowLabelAttr != nil:
+		cell.SetString(*c.x.TotalsRowLabelAttr)
+	default:
+		cell.Clear()
+	}
+}
+
+// cell returns the header or totals row cell of the column.
+func (c TableColumn) cell(header bool) (Cell, bool) {
+	s, ok := c.table.sheet()
+	if !ok {
+		return Cell{}, false
+	}
+	from, to, err := reference.ParseRangeReference(c.table._cbgb.RefAttr)
+	if err != nil {
+		return Cell{}, false
+	}
+	row := to.RowIdx
+	if header {
+		row = from.RowIdx
+	}
+	return s.Cell(reference.IndexToColumn(from.ColumnIdx+uint32(c.index)) + strconv.Itoa(int(row))), true
+}
+
+// setFormula sets the formula of a cell without validating it, the formulas
+// of tables being written as Excel stores them.
+func setFormula(c Cell, f string) {
+	c.clearValue()
+	c._dga.TAttr = sml.ST_CellTypeStr
+	c._dga.F = sml.NewCT_CellFormula()
+	c._dga.F.Content = f
+}
+
+func rangeReference(from, to reference.CellReference) string {
+	return fmt.Sprintf("%s%d:%s%d", reference.IndexToColumn(from.ColumnIdx), from.RowIdx, reference.IndexToColumn(to.ColumnIdx), to.RowIdx)
+}
+
+// rangesOverlap returns true if two cell ranges have cells in common.
+func rangesOverlap(a, b string) bool {
+	af, at, err := reference.ParseRangeReference(a)
+	if err != nil {
+		return false
+	}
+	bf, bt, err := reference.ParseRangeReference(b)
+	if err != nil {
+		return false
+	}
+	return af.RowIdx <= bt.RowIdx && bf.RowIdx <= at.RowIdx && af.ColumnIdx <= bt.ColumnIdx && bf.ColumnIdx <= at.ColumnIdx
+}
+
+// Tables returns the tables of the workbook for the resolution of structured
+// references.
+func (e *evalContext) Tables() []formula.TableDefinition {
+	var defs []formula.TableDefinition
+	for _, t := range e._daa._fgeg.Tables() {
+		defs = append(defs, t.Definition())
+	}
+	return defs
+}
+
+// CurrentCell returns the sheet and the reference of the cell being
+// evaluated.
+func (e *evalContext) CurrentCell() (string, string) {
+	return e._daa.Name(), e._gfcbd
+}
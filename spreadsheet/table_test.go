@@ -0,0 +1,121 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/schema/soo/sml"
+)
+
+// newSalesTable returns a sheet with the table Sales over A1:B4, with the
+// quantities 1, 2 and 3.
+func newSalesTable(t *testing.T) (*Workbook, Sheet, Table) {
+	wb := New()
+	s := wb.AddSheet()
+	s.Cell("A1").SetString("Item")
+	s.Cell("B1").SetString("Qty")
+	for r := 2; r <= 4; r++ {
+		s.Cell("A" + strconv.Itoa(r)).SetString("item" + strconv.Itoa(r-1))
+		s.Cell("B" + strconv.Itoa(r)).SetNumber(float64(r - 1))
+	}
+	tbl, err := s.AddTable("A1:B4", "Sales", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wb, s, tbl
+}
+
+func TestStructuredReferenceFormulas(t *testing.T) {
+	wb, s, tbl := newSalesTable(t)
+	if err := tbl.SetShowTotalsRow(true); err != nil {
+		t.Fatal(err)
+	}
+	qty, err := tbl.Column("Qty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qty.SetTotalsRowFunction(sml.ST_TotalsRowFunctionSum)
+
+	tests := []struct {
+		cell, formula string
+		want          float64
+	}{
+		{"D1", "SUM(Sales[Qty])", 6},
+		{"D2", "MAX(Sales[Qty])", 3},
+		{"D3", "SUM(1,Sales[Qty])", 7},
+		{"D4", "SUM(Sales[[#Totals],[Qty]])", 6},
+		{"D5", "SUM(Sales[[#All],[Qty]])", 12},
+		{"C3", "Sales[[#This Row],[Qty]]*2", 4},
+		{"C4", "Sales[@Qty]*2", 6},
+	}
+	for _, tc := range tests {
+		s.Cell(tc.cell).SetFormulaRaw(tc.formula)
+		if got := s.Cell(tc.cell).GetFormula(); got != tc.formula {
+			t.Errorf("SetFormulaRaw(%q) set %q", tc.formula, got)
+		}
+	}
+	wb.Recalculate()
+	check := func(s Sheet) {
+		t.Helper()
+		for _, tc := range tests {
+			got, err := s.Cell(tc.cell).GetValueAsNumber()
+			if err != nil || got != tc.want {
+				t.Errorf("%s = %v %v, want %v", tc.formula, got, err, tc.want)
+			}
+		}
+	}
+	check(s)
+
+	// the formulas are saved and evaluated again after reading
+	read := roundTrip(t, wb)
+	rs := read.Sheets()[0]
+	for _, tc := range tests {
+		rs.Cell(tc.cell).SetCachedFormulaResult("")
+	}
+	read.Recalculate()
+	check(rs)
+}
+
+func TestShowTotalsRow(t *testing.T) {
+	_, s, tbl := newSalesTable(t)
+	s.Cell("B5").SetNumber(42)
+	if err := tbl.SetShowTotalsRow(true); err == nil {
+		t.Error("the totals row overwrote a cell below the table")
+	}
+	if got, _ := s.Cell("B5").GetValueAsNumber(); got != 42 {
+		t.Errorf("cell below the table = %v, want 42", got)
+	}
+	if tbl.Reference() != "A1:B4" || tbl.HasTotalsRow() {
+		t.Errorf("table = %s with totals row %v, want A1:B4 without", tbl.Reference(), tbl.HasTotalsRow())
+	}
+
+	// inserting a row makes room for the totals row
+	if err := s.InsertRows(5, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := tbl.SetShowTotalsRow(true); err != nil {
+		t.Fatal(err)
+	}
+	if tbl.Reference() != "A1:B5" || !tbl.HasTotalsRow() {
+		t.Errorf("table = %s with totals row %v, want A1:B5 with", tbl.Reference(), tbl.HasTotalsRow())
+	}
+	if got, _ := s.Cell("B6").GetValueAsNumber(); got != 42 {
+		t.Errorf("moved cell = %v, want 42", got)
+	}
+
+	if err := tbl.SetShowTotalsRow(false); err != nil {
+		t.Fatal(err)
+	}
+	if tbl.Reference() != "A1:B4" || tbl.HasTotalsRow() {
+		t.Errorf("table = %s with totals row %v, want A1:B4 without", tbl.Reference(), tbl.HasTotalsRow())
+	}
+}
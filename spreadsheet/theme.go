@@ -0,0 +1,33 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"github.com/unidoc/unioffice/v2"
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+)
+
+// ApplyTheme replaces each theme of the workbook with a copy of a theme, e.g.
+// one taken from a template with common.MakeTheme. A theme part is added to
+// workbooks without one, such as new workbooks.
+func (wb *Workbook) ApplyTheme(t common.Theme) error {
+	if len(wb._bgbc) == 0 {
+		wb._bgbc = append(wb._bgbc, dml.NewTheme())
+		wb._bcg.AddAutoRelationship(unioffice.DocTypeSpreadsheet, unioffice.OfficeDocumentType, 1, unioffice.ThemeType)
+		wb.ContentTypes.AddOverride("/"+unioffice.AbsoluteFilename(unioffice.DocTypeSpreadsheet, unioffice.ThemeType, 1), unioffice.ThemeContentType)
+	}
+	for _, th := range wb.Themes() {
+		if err := common.MakeTheme(th).Apply(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package spreadsheet
+
+import (
+	"testing"
+
+	"github.com/unidoc/unioffice/v2/common"
+	"github.com/unidoc/unioffice/v2/schema/soo/dml"
+)
+
+func TestApplyTheme(t *testing.T) {
+	// a new workbook has no theme until one is applied
+	wb := New()
+	brand := common.MakeTheme(dml.NewTheme())
+	brand.SetName("Brand")
+	brand.SetColor(dml.ST_SchemeColorValAccent1, "C00000")
+	brand.SetMinorFont(common.ThemeFontLatin, "Georgia")
+	if err := wb.ApplyTheme(brand); err != nil {
+		t.Fatal(err)
+	}
+	if err := wb.ApplyTheme(brand); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(wb.Themes()); n != 1 {
+		t.Fatalf("workbook has %d themes, want 1", n)
+	}
+	brand.SetColor(dml.ST_SchemeColorValAccent1, "00B050")
+
+	for _, wb := range []*Workbook{wb, roundTrip(t, wb)} {
+		th := common.MakeTheme(wb.Themes()[0])
+		if th.Name() != "Brand" || th.ResolveIndex(4, 0) != "C00000" || th.MinorFont(common.ThemeFontLatin) != "Georgia" {
+			t.Errorf("theme = %q %q %q", th.Name(), th.ResolveIndex(4, 0), th.MinorFont(common.ThemeFontLatin))
+		}
+	}
+}
@@ -0,0 +1,110 @@
+//
+// Copyright 2020 FoxyUtils ehf. All rights reserved.
+//
+// This is a commercial product and requires a license to operate.
+// A trial license can be obtained at https://unidoc.io
+//
+// Use of this source code is governed by the UniDoc End User License Agreement
+// terms that can be accessed at https://unidoc.io/eula/
+
+package update
+
+// Sheet limits used to detect references pushed off the sheet by insertions.
+const (
+	maxRow         = 1048576
+	maxColumnIndex = 16383
+)
+
+// UpdatesRows returns true if the query inserts or removes rows.
+func (q *UpdateQuery) UpdatesRows() bool {
+	return q.UpdateType == UpdateActionInsertRow || q.UpdateType == UpdateActionRemoveRow
+}
+
+// UpdatesColumns returns true if the query inserts or removes columns.
+func (q *UpdateQuery) UpdatesColumns() bool {
+	return q.UpdateType == UpdateActionInsertColumn || q.UpdateType == UpdateActionRemoveColumn
+}
+
+// Removes returns true if the query removes rows or columns.
+func (q *UpdateQuery) Removes() bool {
+	return q.UpdateType == UpdateActionRemoveColumn || q.UpdateType == UpdateActionRemoveRow
+}
+
+func (q *UpdateQuery) count() uint32 {
+	if q.Count == 0 {
+		return 1
+	}
+	return q.Count
+}
+
+// bounds returns the first index affected by the query and the largest valid
+// index, row numbers being 1-N and column indices 0-N.
+func (q *UpdateQuery) bounds() (uint32, uint32) {
+	if q.UpdatesRows() {
+		return q.RowIdx, maxRow
+	}
+	return q.ColumnIdx, maxColumnIndex
+}
+
+// UpdateIndex returns the new row number or column index of a single row or
+// column, depending on the query type, and false if it was removed or pushed
+// off the sheet.
+func (q *UpdateQuery) UpdateIndex(idx uint32) (uint32, bool) {
+	first, max := q.bounds()
+	n := q.count()
+	switch {
+	case idx < first:
+		return idx, true
+	case !q.Removes():
+		if idx+n > max {
+			return 0, false
+		}
+		return idx + n, true
+	case idx < first+n:
+		return 0, false
+	}
+	return idx - n, true
+}
+
+// UpdateSpan returns the new bounds of a span of rows or columns. Spans grow
+// when rows or columns are inserted inside them and shrink when some of their
+// rows or columns are removed. It returns false if all of them were removed.
+func (q *UpdateQuery) UpdateSpan(from, to uint32) (uint32, uint32, bool) {
+	if from > to {
+		from, to = to, from
+	}
+	first, max := q.bounds()
+	n := q.count()
+	if !q.Removes() {
+		if from >= first {
+			from += n
+		}
+		if to >= first {
+			to += n
+		}
+		if from > max {
+			return 0, 0, false
+		}
+		if to > max {
+			to = max
+		}
+		return from, to, true
+	}
+	last := first + n - 1
+	if from >= first && to <= last {
+		return 0, 0, false
+	}
+	switch {
+	case from > last:
+		from -= n
+	case from >= first:
+		from = first
+	}
+	switch {
+	case to > last:
+		to -= n
+	case to >= first:
+		to = first - 1
+	}
+	return from, to, true
+}
@@ -9,23 +9,29 @@
 // Use of this source code is governed by the UniDoc End User License Agreement
 // terms that can be accessed at https://unidoc.io/eula/
 
-// Package update contains definitions needed for updating references after removing rows/columns.
+// Package update contains definitions needed for updating references after inserting or removing rows/columns.
 package update ;
 
-// UpdateQuery contains terms of how to update references after removing row/column.
+// UpdateQuery contains terms of how to update references after inserting or removing rows/columns.
 type UpdateQuery struct{
 
 // UpdateType is one of the update types like UpdateActionRemoveColumn.
 UpdateType UpdateAction ;
 
-// ColumnIdx is the index of the column removed.
+// ColumnIdx is the index of the first column inserted or removed.
 ColumnIdx uint32 ;
 
+// RowIdx is the number (1-N) of the first row inserted or removed.
+RowIdx uint32 ;
+
+// Count is the number of rows or columns inserted or removed, one if zero.
+Count uint32 ;
+
 // SheetToUpdate contains the name of the sheet on which removing happened.
 SheetToUpdate string ;
 
 // UpdateCurrentSheet is true if references without sheet prefix should be updated as well.
-UpdateCurrentSheet bool ;};const (UpdateActionRemoveColumn UpdateAction =iota ;);
+UpdateCurrentSheet bool ;};const (UpdateActionRemoveColumn UpdateAction =iota ;UpdateActionInsertColumn ;UpdateActionInsertRow ;UpdateActionRemoveRow ;);
 
 // UpdateAction is the type for update types constants.
 type UpdateAction byte ;
\ No newline at end of file